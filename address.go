@@ -8,6 +8,10 @@ const (
 	ADDR_TYPE_OTS      = 0
 	ADDR_TYPE_LTREE    = 1
 	ADDR_TYPE_HASHTREE = 2
+
+	// Not used by the RFC: derives the seed of a subtree with the
+	// PrfTreeKeyStructure.
+	ADDR_TYPE_SEED = 3
 )
 
 // Address used in XMSS[MT] to diversify the hashes.  See eg prfAddrInto().
@@ -0,0 +1,77 @@
+// Package agent implements a tiny protocol to sign with a long-lived
+// private key held by an agent process over a unix domain socket, such that
+// shell pipelines and programs in other languages don't have to open the
+// private key container for every signature.  See "xmssmt agent".
+//
+// Every request and response is a frame:
+//
+//   op (1 byte) ‖ len(payload) (4 bytes, big endian) ‖ payload.
+//
+// The requests are
+//
+//   OpSign        payload is the message; the response is the signature
+//                 as encoded by Signature.MarshalBinary()
+//   OpPublicKey   empty payload; the response is the public key as
+//                 encoded by PublicKey.MarshalBinary()
+//
+// The op of a response is OpOK, or OpError, in which case the payload is
+// the error message.  A connection can be used for many requests, one after
+// the other.
+//
+// There is no authentication: anyone who can connect to the socket can
+// sign.  Serve() creates the socket only accessible to its own user.
+package agent
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Op of a frame.
+type Op uint8
+
+const (
+	OpSign      Op = 1
+	OpPublicKey Op = 2
+
+	OpOK    Op = 0x80
+	OpError Op = 0x81
+)
+
+// Maximum length of the payload of a frame.
+const MaxPayloadSize = 1 << 26
+
+// Writes a frame.
+func writeFrame(w io.Writer, op Op, payload []byte) error {
+	if len(payload) > MaxPayloadSize {
+		return errors.New("agent: payload is too large")
+	}
+	var hdr [5]byte
+	hdr[0] = byte(op)
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(payload)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// Reads a frame.
+func readFrame(r io.Reader) (Op, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(hdr[1:])
+	if size > MaxPayloadSize {
+		return 0, nil, fmt.Errorf("agent: payload of %d bytes is too large",
+			size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return Op(hdr[0]), payload, nil
+}
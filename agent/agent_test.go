@@ -0,0 +1,101 @@
+// +build !windows,!js,!wasip1
+
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+func TestAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := xmssmt.NewContext(xmssmt.Params{
+		Func:       xmssmt.SHAKE,
+		N:          16,
+		FullHeight: 8,
+		D:          2,
+		WotsW:      16,
+		Prf:        xmssmt.RFC,
+	})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	l, err2 := Listen(dir + "/agent.sock")
+	if err2 != nil {
+		t.Fatalf("Listen(): %v", err2)
+	}
+	st, err3 := os.Stat(dir + "/agent.sock")
+	if err3 != nil {
+		t.Fatalf("Stat(): %v", err3)
+	}
+	if st.Mode().Perm() != 0600 {
+		t.Fatalf("Socket is accessible to others: %v", st.Mode())
+	}
+	done := make(chan struct{})
+	go func() {
+		Serve(l, sk)
+		close(done)
+	}()
+
+	c, err2 := Dial(dir + "/agent.sock")
+	if err2 != nil {
+		t.Fatalf("Dial(): %v", err2)
+	}
+	pk2, err2 := c.PublicKey()
+	if err2 != nil {
+		t.Fatalf("PublicKey(): %v", err2)
+	}
+	if !pk.Equal(pk2) {
+		t.Fatalf("PublicKey() returned wrong public key")
+	}
+
+	msg := []byte("test message")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sig, err := c.Sign(msg)
+			if err != nil {
+				t.Errorf("Sign(): %v", err)
+				return
+			}
+			if ok, err := pk.Verify(sig, msg); !ok {
+				t.Errorf("Verify(): %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if sk.SeqNo() != 4 {
+		t.Fatalf("Agent used %d signatures", sk.SeqNo())
+	}
+
+	if _, err2 = c.do(Op(42), nil); err2 == nil {
+		t.Fatalf("Agent accepted unknown op")
+	}
+	if _, err2 = c.Sign(msg); err2 != nil {
+		t.Fatalf("Sign() after error: %v", err2)
+	}
+	c.Close()
+
+	l.Close()
+	<-done
+	if _, err := os.Stat(dir + "/agent.sock"); !os.IsNotExist(err) {
+		t.Fatalf("Socket was not removed")
+	}
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Client of an agent.  Safe for concurrent use: the requests are sent one
+// after the other over the same connection.
+type Client struct {
+	mux  sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Connects to the agent listening on the unix domain socket at path.
+//
+// NOTE Do not forget to Close() the Client.
+func Dial(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+// Closes the connection to the agent.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Sends a request and returns the payload of the response.
+func (c *Client) do(op Op, payload []byte) ([]byte, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if err := writeFrame(c.conn, op, payload); err != nil {
+		return nil, err
+	}
+	respOp, resp, err := readFrame(c.r)
+	if err != nil {
+		return nil, err
+	}
+	switch respOp {
+	case OpOK:
+		return resp, nil
+	case OpError:
+		return nil, errors.New("agent: " + string(resp))
+	default:
+		return nil, fmt.Errorf("agent: unexpected op %d in response", respOp)
+	}
+}
+
+// Signs the message with the private key of the agent.
+func (c *Client) Sign(msg []byte) (*xmssmt.Signature, error) {
+	buf, err := c.do(OpSign, msg)
+	if err != nil {
+		return nil, err
+	}
+	var sig xmssmt.Signature
+	if err := sig.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return &sig, nil
+}
+
+// Returns the public key of the agent.
+func (c *Client) PublicKey() (*xmssmt.PublicKey, error) {
+	buf, err := c.do(OpPublicKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	var pk xmssmt.PublicKey
+	if err := pk.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return &pk, nil
+}
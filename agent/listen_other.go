@@ -0,0 +1,22 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package agent
+
+import (
+	"net"
+	"os"
+)
+
+// Listens on the unix domain socket at path and restricts it to mode 0600.
+// Without a umask, the socket is briefly accessible to others.
+func listenPrivate(path string) (net.Listener, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
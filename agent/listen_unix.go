@@ -0,0 +1,25 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package agent
+
+import (
+	"net"
+	"sync"
+	"syscall"
+)
+
+// Protects the umask, which is shared by the whole process.
+var umaskMux sync.Mutex
+
+// Listens on the unix domain socket at path, which is created with mode
+// 0600, such that there is no moment at which others can connect.
+//
+// NOTE The umask is changed while the socket is created, so files that
+//      other goroutines create in the meantime might get a stricter mode.
+func listenPrivate(path string) (net.Listener, error) {
+	umaskMux.Lock()
+	defer umaskMux.Unlock()
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sync"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Listens on the unix domain socket at the given path, which is only
+// accessible to the current user, and serves requests to sign with sk
+// until the listener is closed.  See Serve().
+func ListenAndServe(path string, sk *xmssmt.PrivateKey) error {
+	l, err := Listen(path)
+	if err != nil {
+		return err
+	}
+	return Serve(l, sk)
+}
+
+// Listens on the unix domain socket at the given path, which is only
+// accessible to the current user.  Closing the listener removes the socket.
+func Listen(path string) (net.Listener, error) {
+	return listenPrivate(path)
+}
+
+// Serves requests to sign with sk on the connections accepted by l.
+// Returns when l is closed, after the connections have been closed and
+// the requests in flight have been answered, such that sk can be closed.
+func Serve(l net.Listener, sk *xmssmt.PrivateKey) error {
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	conns := make(map[net.Conn]struct{})
+	defer func() {
+		mux.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mux.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return err
+		}
+		mux.Lock()
+		conns[conn] = struct{}{}
+		mux.Unlock()
+		wg.Add(1)
+		go func() {
+			serveConn(conn, sk)
+			mux.Lock()
+			delete(conns, conn)
+			mux.Unlock()
+			wg.Done()
+		}()
+	}
+}
+
+func serveConn(conn net.Conn, sk *xmssmt.PrivateKey) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		op, payload, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		resp, err := handle(sk, op, payload)
+		if err != nil {
+			err = writeFrame(w, OpError, []byte(err.Error()))
+		} else {
+			err = writeFrame(w, OpOK, resp)
+		}
+		if err != nil || w.Flush() != nil {
+			return
+		}
+	}
+}
+
+// Handles a single request.
+func handle(sk *xmssmt.PrivateKey, op Op, payload []byte) ([]byte, error) {
+	switch op {
+	case OpSign:
+		sig, err := sk.Sign(payload)
+		if err != nil {
+			return nil, err
+		}
+		return sig.MarshalBinary()
+	case OpPublicKey:
+		return sk.PublicKey().MarshalBinary()
+	default:
+		return nil, fmt.Errorf("unknown op %d", op)
+	}
+}
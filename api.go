@@ -7,21 +7,33 @@ package xmssmt
 import (
 	"bytes"
 	"container/heap"
+	"crypto"
 	"crypto/rand"
 	"crypto/subtle"
 	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/bwesterb/go-xmssmt/internal/f1600x2"
 	"github.com/bwesterb/go-xmssmt/internal/f1600x4"
+	"github.com/bwesterb/go-xmssmt/internal/sha256x8"
+	"golang.org/x/crypto/sha3"
 )
 
 // XMSS[MT] instance.
 // Create one using NewContextFromName[2], NewContextFromOid or NewContext.
 type Context struct {
 	// Number of worker goroutines ("threads") to use for expensive operations.
-	// Will guess an appropriate number if set to 0.
+	// Uses GOMAXPROCS if set to 0.  The workers are shared by all operations
+	// on this Context and are started when first needed.  Changing Threads
+	// has no effect until the Context is closed.  The number of workers
+	// that run at the same time over all Contexts is limited separately;
+	// see SetMaxWorkers().
 	Threads int
 
 	p            Params // parameters.
@@ -37,11 +49,31 @@ type Context struct {
 	skBytes      uint32 // size of secret key
 	prefixLen    uint32 // length of PRF prefix
 
-	x4Available bool // whether fourway hashes are available
+	x2Available bool   // whether twoway hashes are available
+	x4Available bool   // whether fourway hashes are available
+	x8Available bool   // whether eightway hashes are available
+	lanes       uint32 // number of hashes computed in parallel by fXNInto
 
 	mt   bool    // true for XMSSMT; false for XMSS
 	oid  uint32  // OID of this configuration, if it has any
 	name *string // name of algorithm
+
+	poolMux sync.Mutex  // protects pool
+	pool    *workerPool // worker goroutines; nil if not started
+	padPool sync.Pool   // scratchpads; see getScratchPad()
+
+	strict        bool                     // see RequireStandard
+	leafGenerator LeafGenerator            // see SetLeafGenerator
+	progress      func(done, total uint64) // see SetProgressFunc
+	metrics       Metrics                  // see SetMetrics
+	constantTime  bool                     // see SetConstantTime
+	msgPolicy     MessagePolicy            // see SetMessagePolicy
+	drvEntropy    io.Reader                // see SetRandomizerEntropy
+	batchHeight   uint32                   // see SetLeafBatchHeight
+	keyStructure  KeyStructure             // see SetKeyStructure
+
+	checkpointInterval uint32 // see SetSubTreeCheckpointInterval
+	lowestCachedHeight uint32 // see SetLowestCachedHeight
 }
 
 // Sequence number of signatures.
@@ -58,6 +90,19 @@ type PrivateKey struct {
 
 	ctx *Context          // context, which contains algorithm parameters.
 	ph  precomputedHashes // precomputed hashes
+
+	// How the WOTS+ secret keys are derived from skSeed.
+	keyStructure KeyStructure
+
+	// The lowest height of the nodes of the cached subtrees, see
+	// CachedHeightStore.
+	lowestCachedHeight uint32
+
+	// Precomputed hashes without skSeed shared by the PublicKeys returned
+	// by PublicKey().
+	pkOnce sync.Once
+	pkPh   precomputedHashes
+
 	// container that stores the secret key, signature sequence number
 	// and caches the subtrees
 	ctr PrivateKeyContainer
@@ -74,6 +119,11 @@ type PrivateKey struct {
 	// See PrivateKey.retireSeqNo().
 	retiredSeqNos *uint32Heap
 
+	// Set while MoveTo() waits for the signatures in flight, during which
+	// no new signatures are started.  cond is signalled when a signature
+	// sequence number is retired.
+	relocating bool
+
 	mux  sync.Mutex
 	cond *sync.Cond     // signalled when a subtree is generated
 	wg   sync.WaitGroup // used to join all background workers when Close()ing
@@ -85,11 +135,87 @@ type PrivateKey struct {
 	// from the private key container.
 	subTreeChecked map[SubTreeAddress]bool
 
-	// If true, will precompute a subtree in advance
-	precomputeNextSubTree bool
+	// The parts of the signature for the layers above the lowest for
+	// signatures with the subtree tailSta on the lowest layer.
+	// See signatureTail().
+	tailSta SubTreeAddress
+	tail    []subTreeSig
+
+	// Number of subtrees on the lowest layer to precompute in advance.
+	// See SetPrecomputeAhead().
+	precomputeAhead uint64
+
+	// Number of sequence numbers to borrow when none are left; zero if
+	// disabled.  See SetAutoBorrow().
+	autoBorrow uint32
+
+	// Set when the secret seeds have been wiped.  See Zeroize().
+	zeroized bool
+
+	// Set by Close() to stop the background goroutines after the subtree
+	// they are generating.
+	closing bool
+
+	// Set if ctx was created for this private key, in which case Close()
+	// stops its workers.
+	ownsCtx bool
+
+	// Maximum number of cached subtrees; zero if unlimited.
+	// See SetCacheLimit().
+	maxCachedSubTrees int
+
+	// The signature sequence numbers this private key is allowed to use
+	// are those in the range [seqNoStart, seqNoEnd).  See SetSeqNoRange().
+	seqNoStart SignatureSeqNo
+	seqNoEnd   SignatureSeqNo
+
+	// Set if the container leases ranges of sequence numbers, in which
+	// case only those below leaseEnd, the end of the current lease, can be
+	// used.  See OpenFSLeasedPrivateKeyContainer() and SetLeaseSize().
+	leaser    SeqNoLeaser
+	leaseSize uint32
+	leaseEnd  SignatureSeqNo
+
+	// Set if the container is cross-checked against a monotonic counter.
+	// monotonicValue is the last known value of the counter and
+	// monotonicErr is set when a regression has been detected.
+	// See SetMonotonicSource().
+	monotonic      MonotonicSource
+	monotonicValue uint64
+	monotonicErr   Error
+
+	// Set if signatures are recorded in the audit log of the container.
+	// See EnableAuditLog().
+	auditLog AuditLogger
+
+	// Leases of borrowed signature sequence numbers that were not returned
+	// and have not expired when loaded.  See BorrowWithLease().
+	pendingLeases []BorrowLease
+
+	// What was found when the private key was loaded and the cached
+	// subtrees that turned out to be corrupted since.  See LoadReport().
+	loadReport       LoadReport
+	repairedSubTrees []SubTreeAddress
+
+	// Key of the checksum of cached subtrees; nil if the unkeyed checksum
+	// is used.  See SetKeyedCacheChecksum().
+	checksumKey []byte
+
+	// Set if DangerousSetSeqNo() may go below the high-water mark.
+	// See DangerousAllowSeqNoRegression().
+	allowSeqNoRegression bool
 }
 
 // XMSS[MT] public key
+//
+// A PublicKey is not modified by verification, so after it has been created
+// or unmarshaled, its methods can be called concurrently from many
+// goroutines.  The hashes of the public seed are precomputed when the
+// PublicKey is created: to verify many signatures, parse the public key once
+// and reuse it instead of calling the Verify() helper with its serialized
+// form.  The scratch buffers used while verifying are pooled per Context.
+// UnmarshalBinary() and UnmarshalText() must not be called concurrently
+// with other methods.
 type PublicKey struct {
 	ctx     *Context // context which contains algorithm parameters
 	pubSeed []byte
@@ -117,12 +243,49 @@ type subTreeSig struct {
 	authPath []byte
 }
 
+// Prefix of the messages signed by PrivateKey.SignHash()
+var prehashPrefix = []byte("XMSSMT-PREHASH")
+
+// Errors returned by this package.  Use errors.Is() to check whether an
+// error is of one of the kinds below, such as ErrLocked, and errors.As()
+// to get at the Error.  Errors also implement Unwrap() to get at the error
+// they wrap, which is the same as Inner().
 type Error interface {
 	error
 	Locked() bool // Is this error because something (like a file) was locked?
 	Inner() error // Returns the wrapped error, if any
 }
 
+// Kinds of Error to check for with errors.Is().
+var (
+	// Something, such as the private key file, is locked by another
+	// process.  Same as Error.Locked().
+	ErrLocked = errors.New("locked")
+
+	// All signatures of the private key, or of the range it may use,
+	// have been used.
+	ErrExhausted = errors.New("signatures exhausted")
+
+	// The private key container or its cache has not been initialized.
+	ErrNotInitialized = errors.New("not initialized")
+
+	// The cache of the private key container can't be read.  It can be
+	// recreated from the private key with RepairFSPrivateKey().
+	// LoadPrivateKey() recreates it by itself.
+	ErrCorruptCache = errors.New("corrupt cache")
+
+	// A file or encoding does not start with the expected magic, for
+	// instance because it's not a private key file.
+	ErrWrongMagic = errors.New("wrong magic")
+
+	// A signature or public key is not encoded correctly or is for a
+	// different instance.  See Signature.Validate().
+	ErrMalformed = errors.New("malformed")
+
+	// A well-formed signature does not verify.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
 // Generate a new keypair for the given XMSS[MT] instance alg.
 //
 // Stores the private key at privKeyPath. This will create two
@@ -143,7 +306,13 @@ func GenerateKeyPair(alg, privKeyPath string) (*PrivateKey, *PublicKey, Error) {
 	if err != nil {
 		return nil, nil, wrapErrorf(err, "%s is not a valid algorithm name", alg)
 	}
-	return ctx.GenerateKeyPair(privKeyPath)
+	sk, pk, err := ctx.GenerateKeyPair(privKeyPath)
+	if err != nil {
+		ctx.Close()
+		return nil, nil, err
+	}
+	sk.ownsCtx = true
+	return sk, pk, nil
 }
 
 // Create a signature on msg using the private key stored at privKeyPath.
@@ -174,86 +343,222 @@ func Sign(privKeyPath string, msg []byte) (sig []byte, err Error) {
 }
 
 // Checks whether sig is a valid signature of pk on msg.
+//
+// The public key is encoded by PublicKey.MarshalBinary() and carries the
+// parameters.  The signature may be encoded by Signature.MarshalBinary(),
+// in which case its parameters have to match, or as described in RFC 8391,
+// without prefix.  See PublicKey.VerifyStandard().
 func Verify(pk, sig, msg []byte) (bool, Error) {
-	var theSig Signature
 	var thePk PublicKey
-	err := theSig.UnmarshalBinary(sig)
-	if err != nil {
-		return false, wrapErrorf(err, "Failed to unmarshal signature")
-	}
-	err = thePk.UnmarshalBinary(pk)
+	err := thePk.UnmarshalBinary(pk)
 	if err != nil {
 		return false, wrapErrorf(err, "Failed to unmarshal public key")
 	}
-	return thePk.Verify(&theSig, msg)
+	return thePk.VerifyStandard(sig, msg)
 }
 
 // Check whether the sig is a valid signature of this public key
 // for the given message.
 func (pk *PublicKey) Verify(sig *Signature, msg []byte) (bool, Error) {
-	return pk.VerifyFrom(sig, bytes.NewReader(msg))
+	pad := pk.ctx.getScratchPad()
+	defer pk.ctx.putScratchPad(pad)
+	return pk.verifyBytes(*pad, sig, msg, nil)
+}
+
+// Checks whether the encoded signature sig is a valid signature of this
+// public key for the given message.
+//
+// The parameters are those of the public key: the signature may be encoded
+// as described in RFC 8391, see Signature.MarshalStandard(), which does not
+// carry them.  A signature encoded by Signature.MarshalBinary() is accepted
+// as well if its parameters match and the Context is not in strict mode.
+// If they don't match, the error says which parameters differ.
+func (pk *PublicKey) VerifyStandard(sig, msg []byte) (bool, Error) {
+	theSig, err := pk.ctx.UnmarshalSignature(sig)
+	if err != nil {
+		return false, wrapErrorf(err, "Failed to unmarshal signature")
+	}
+	return pk.Verify(theSig, msg)
+}
+
+// Like Verify(), but also rejects the signature if its sequence number
+// is not in the range [minSeqNo, maxSeqNo].  Useful for protocols that
+// bind signature sequence numbers to epochs, for instance when a range of
+// sequence numbers is assigned to each month.
+func (pk *PublicKey) VerifyWithin(sig *Signature, msg []byte,
+	minSeqNo, maxSeqNo SignatureSeqNo) (bool, Error) {
+	if sig.seqNo < minSeqNo || sig.seqNo > maxSeqNo {
+		return false, errorf("Signature sequence number %d is outside "+
+			"of the range [%d, %d]", sig.seqNo, minSeqNo, maxSeqNo)
+	}
+	return pk.Verify(sig, msg)
+}
+
+// Scratch buffers to verify signatures without allocating any memory.
+// See PublicKey.VerifyWithBuffer().
+//
+// A VerifyBuffer must not be used concurrently: use one per goroutine.
+type VerifyBuffer struct {
+	ctx *Context
+	pad scratchPad
+}
+
+// Allocates buffers to verify signatures of this instance with
+// PublicKey.VerifyWithBuffer().
+func (ctx *Context) NewVerifyBuffer() *VerifyBuffer {
+	return &VerifyBuffer{ctx: ctx, pad: ctx.newScratchPad()}
+}
+
+// Like Verify(), but uses the given buffers instead of those of the pool
+// of the Context, so that a valid signature is checked without allocating
+// any memory.  buf must have been created by NewVerifyBuffer() on a Context
+// with the same parameters.
+func (pk *PublicKey) VerifyWithBuffer(buf *VerifyBuffer, sig *Signature,
+	msg []byte) (bool, Error) {
+	if err := checkCompatibleParams("Buffer", buf.ctx.p, pk.ctx.p); err != nil {
+		return false, err
+	}
+	ok, err := pk.verifyBytes(buf.pad, sig, msg, nil)
+	buf.pad.zeroizeIfEnabled()
+	return ok, err
+}
+
+// Checks whether sig is a valid signature of this public key for the
+// digest and domain, as created by PrivateKey.SignHash().
+func (pk *PublicKey) VerifyHash(sig *Signature, digest, domain []byte) (
+	bool, Error) {
+	msg, err := pk.ctx.prehashedMessage(digest, domain)
+	if err != nil {
+		return false, err
+	}
+	return pk.Verify(sig, msg)
+}
+
+// Verifies whether sig is a valid signature for the message of the given
+// size read from the io.ReaderAt, such as an os.File.  See SignReaderAt().
+func (pk *PublicKey) VerifyReaderAt(sig *Signature, msg io.ReaderAt,
+	size int64) (bool, Error) {
+	return pk.VerifyFrom(sig, io.NewSectionReader(msg, 0, size))
+}
+
+// Computes the root of the subtree sta from a signature by its given leaf
+// on msg.  out may alias msg.  If info is not nil, the intermediate values
+// are stored in it.
+func (pk *PublicKey) layerRootInto(pad scratchPad, rxSig subTreeSig,
+	sta SubTreeAddress, leaf uint32, msg, out []byte,
+	info *SignatureLayerInfo) {
+	var lTreeAddr, otsAddr, nodeAddr address
+	rxAddr := sta.address()
+	otsAddr.setSubTreeFrom(rxAddr)
+	otsAddr.setType(ADDR_TYPE_OTS)
+	lTreeAddr.setSubTreeFrom(rxAddr)
+	lTreeAddr.setType(ADDR_TYPE_LTREE)
+	nodeAddr.setSubTreeFrom(rxAddr)
+	nodeAddr.setType(ADDR_TYPE_HASHTREE)
+
+	var offset uint32 = leaf
+	otsAddr.setOTS(offset)
+	lTreeAddr.setLTree(offset)
+	wotsPk := pad.wotsBuf()
+	pk.ctx.wotsPkFromSigInto(pad, rxSig.wotsSig, msg, pk.ph, otsAddr, wotsPk)
+	if info != nil {
+		info.Message = append([]byte{}, msg...)
+		info.WotsPublicKey = append([]byte{}, wotsPk...)
+	}
+	pk.ctx.lTreeInto(pad, wotsPk, pk.ph, lTreeAddr, out)
+	if info != nil {
+		info.LeafNode = append([]byte{}, out...)
+	}
+
+	// use the authentication path to hash up the merkle tree
+	var height uint32
+	for height = 1; height <= pk.ctx.treeHeight; height++ {
+		var left, right []byte
+		nodeAddr.setTreeHeight(height - 1)
+		nodeAddr.setTreeIndex(offset >> 1)
+		sibling := rxSig.authPath[(height-1)*pk.ctx.p.N : height*pk.ctx.p.N]
+
+		if offset&1 == 0 {
+			// we're on the left, so the sibling hash from the
+			// auth path is on the right
+			left = out
+			right = sibling
+		} else {
+			left = sibling
+			right = out
+		}
+
+		pk.ctx.hInto(pad, left, right, pk.ph, nodeAddr, out)
+		offset >>= 1
+	}
+
+	if info != nil {
+		info.Root = append([]byte{}, out...)
+	}
 }
 
 // Reads a message from the io.Reader and verifies whether the provided
-// signature is valid for this public key and message.
+// signature is valid for this public key and message.  Like SignFrom(),
+// the message is read once in chunks of MessageChunkSize bytes.
 func (pk *PublicKey) VerifyFrom(sig *Signature, msg io.Reader) (bool, Error) {
-	pad := pk.ctx.newScratchPad()
-	curHash := make([]byte, sig.ctx.p.N)
+	return pk.verifyFrom(sig, msg, nil)
+}
 
-	rxMsg, err := pk.ctx.hashMessage(pad, msg, sig.drv,
-		pk.root, uint64(sig.seqNo))
+// Verifies the signature on the message prepended by prefix, if not nil.
+// See Context.writeMessage().
+func (pk *PublicKey) verifyFrom(sig *Signature, msg io.Reader,
+	prefix []byte) (bool, Error) {
+	if err := sig.Validate(pk.ctx); err != nil {
+		return false, err
+	}
+
+	pad := pk.ctx.getScratchPad()
+	defer pk.ctx.putScratchPad(pad)
+
+	err := pk.ctx.hashMessageInto(*pad, prefix, msg, sig.drv,
+		pk.root, uint64(sig.seqNo), pad.nodeBuf)
+	if err != nil {
+		return false, wrapErrorf(err, "Failed to hash message")
+	}
+	return pk.verifyHashed(*pad, sig)
+}
+
+// Like verifyFrom(), but for a message in memory, using the given
+// scratchpad.
+func (pk *PublicKey) verifyBytes(pad scratchPad, sig *Signature,
+	msg, prefix []byte) (bool, Error) {
+	if err := sig.Validate(pk.ctx); err != nil {
+		return false, err
+	}
+
+	err := pk.ctx.hashMessageBytesInto(pad, prefix, msg, sig.drv,
+		pk.root, uint64(sig.seqNo), pad.nodeBuf)
 	if err != nil {
 		return false, wrapErrorf(err, "Failed to hash message")
 	}
+	return pk.verifyHashed(pad, sig)
+}
 
-	staPath, leafs := pk.ctx.subTreePathForSeqNo(sig.seqNo)
+// Checks the signature on the message hash in pad.nodeBuf.
+func (pk *PublicKey) verifyHashed(pad scratchPad, sig *Signature) (
+	bool, Error) {
+	th := pk.ctx.treeHeight
+	node := pad.nodeBuf
+	seqNo := uint64(sig.seqNo)
 
+	// See subTreePathForSeqNo().
 	var layer uint32
 	for layer = 0; layer < pk.ctx.p.D; layer++ {
-		var lTreeAddr, otsAddr, nodeAddr address
-		rxAddr := staPath[layer].address()
-		otsAddr.setSubTreeFrom(rxAddr)
-		otsAddr.setType(ADDR_TYPE_OTS)
-		lTreeAddr.setSubTreeFrom(rxAddr)
-		lTreeAddr.setType(ADDR_TYPE_LTREE)
-		nodeAddr.setSubTreeFrom(rxAddr)
-		nodeAddr.setType(ADDR_TYPE_HASHTREE)
-
-		rxSig := sig.sigs[layer]
-		var offset uint32 = leafs[layer]
-		otsAddr.setOTS(offset)
-		lTreeAddr.setLTree(offset)
-		wotsPk := pad.wotsBuf()
-		pk.ctx.wotsPkFromSigInto(pad, rxSig.wotsSig, rxMsg, pk.ph, otsAddr, wotsPk)
-		pk.ctx.lTreeInto(pad, wotsPk, pk.ph, lTreeAddr, curHash)
-
-		// use the authentication path to hash up the merkle tree
-		var height uint32
-		for height = 1; height <= pk.ctx.treeHeight; height++ {
-			var left, right []byte
-			nodeAddr.setTreeHeight(height - 1)
-			nodeAddr.setTreeIndex(offset >> 1)
-			sibling := rxSig.authPath[(height-1)*pk.ctx.p.N : height*pk.ctx.p.N]
-
-			if offset&1 == 0 {
-				// we're on the left, so the sibling hash from the
-				// auth path is on the right
-				left = curHash
-				right = sibling
-			} else {
-				left = sibling
-				right = curHash
-			}
-
-			pk.ctx.hInto(pad, left, right, pk.ph, nodeAddr, curHash)
-			offset >>= 1
+		sta := SubTreeAddress{
+			Layer: layer,
+			Tree:  seqNo >> ((layer + 1) * th),
 		}
-
-		rxMsg = curHash
+		leaf := uint32((seqNo >> (layer * th)) & ((1 << th) - 1))
+		pk.layerRootInto(pad, sig.sigs[layer], sta, leaf, node, node, nil)
 	}
 
-	if subtle.ConstantTimeCompare(rxMsg, pk.root) != 1 {
-		return false, errorf("Invalid signature")
+	if subtle.ConstantTimeCompare(node, pk.root) != 1 {
+		return false, kindErrorf(ErrInvalidSignature, "Invalid signature")
 	}
 
 	return true, nil
@@ -271,29 +576,60 @@ func (sig *Signature) MarshalBinary() ([]byte, error) {
 }
 
 // Initializes the Signature as stored by MarshalBinary.
+//
+// If the Signature already has a Context, for instance because it was
+// unmarshaled before, the parameters in buf have to match it.
 func (sig *Signature) UnmarshalBinary(buf []byte) error {
 	var params Params
+	if len(buf) < 4 {
+		return kindErrorf(ErrMalformed, "Signature is too short")
+	}
 	err := params.UnmarshalBinary(buf[:4])
 	if err != nil {
-		return err
+		err2 := wrapErrorf(err, "Failed to parse parameters")
+		err2.kind = ErrMalformed
+		return err2
+	}
+	if sig.ctx != nil && sig.ctx.p != params {
+		return kindErrorf(ErrMalformed, "Parameters %s do not match %s",
+			params, sig.ctx.p)
 	}
-	sig.ctx, err = NewContext(params)
+	ctx, err := NewContext(params)
 	if err != nil {
 		return err
 	}
-	sig.seqNo = SignatureSeqNo(decodeUint64(buf[4 : 4+sig.ctx.indexBytes]))
+	if uint32(len(buf)) != 4+ctx.sigBytes {
+		return kindErrorf(ErrMalformed,
+			"Signature has wrong length: %d", len(buf))
+	}
+	return sig.decodeFrom(ctx, buf[4:])
+}
+
+// Initializes the Signature from buf, which contains the signature without
+// parameter prefix as described in the RFC.  buf must have the right
+// length.  Rejects signature sequence numbers that are out of range, for
+// which the unused high bits of the index would allow different encodings.
+func (sig *Signature) decodeFrom(ctx *Context, buf []byte) Error {
+	params := ctx.p
+	seqNo := decodeUint64(buf[:ctx.indexBytes])
+	if seqNo > params.MaxSignatureSeqNo() {
+		return kindErrorf(ErrMalformed,
+			"Signature sequence number %d is out of range", seqNo)
+	}
+	sig.ctx = ctx
+	sig.seqNo = SignatureSeqNo(seqNo)
 	sig.drv = make([]byte, params.N)
 	sig.sigs = make([]subTreeSig, params.D)
-	copy(sig.drv, buf[4+sig.ctx.indexBytes:4+sig.ctx.indexBytes+params.N])
-	stOff := 4 + sig.ctx.indexBytes + params.N
-	stLen := sig.ctx.wotsSigBytes + params.N*sig.ctx.treeHeight
+	copy(sig.drv, buf[ctx.indexBytes:ctx.indexBytes+params.N])
+	stOff := ctx.indexBytes + params.N
+	stLen := ctx.wotsSigBytes + params.N*ctx.treeHeight
 	var i uint32
 	for i = 0; i < params.D; i++ {
 		stSig := &sig.sigs[i]
-		stSig.wotsSig = make([]byte, sig.ctx.wotsSigBytes)
-		stSig.authPath = make([]byte, params.N*sig.ctx.treeHeight)
-		copy(stSig.wotsSig, buf[stOff+i*stLen:stOff+i*stLen+sig.ctx.wotsSigBytes])
-		copy(stSig.authPath, buf[stOff+i*stLen+sig.ctx.wotsSigBytes:stOff+(i+1)*stLen])
+		stSig.wotsSig = make([]byte, ctx.wotsSigBytes)
+		stSig.authPath = make([]byte, params.N*ctx.treeHeight)
+		copy(stSig.wotsSig, buf[stOff+i*stLen:stOff+i*stLen+ctx.wotsSigBytes])
+		copy(stSig.authPath, buf[stOff+i*stLen+ctx.wotsSigBytes:stOff+(i+1)*stLen])
 	}
 	return nil
 }
@@ -301,19 +637,28 @@ func (sig *Signature) UnmarshalBinary(buf []byte) error {
 // Writes signature to buf in the same way as returned
 // by Signature.MarshalBinary().
 func (sig *Signature) WriteInto(buf []byte) error {
+	if sig.ctx.strict {
+		return errStrictCompressed
+	}
 	err := sig.ctx.p.WriteInto(buf)
 	if err != nil {
 		return err
 	}
-	encodeUint64Into(uint64(sig.seqNo), buf[4:4+sig.ctx.indexBytes])
-	copy(buf[4+sig.ctx.indexBytes:], sig.drv)
-	stOff := 4 + sig.ctx.indexBytes + sig.ctx.p.N
+	sig.encodeInto(buf[4:])
+	return nil
+}
+
+// Writes the signature without parameter prefix, as described in the RFC,
+// into buf.
+func (sig *Signature) encodeInto(buf []byte) {
+	encodeUint64Into(uint64(sig.seqNo), buf[:sig.ctx.indexBytes])
+	copy(buf[sig.ctx.indexBytes:], sig.drv)
+	stOff := sig.ctx.indexBytes + sig.ctx.p.N
 	stLen := sig.ctx.wotsSigBytes + sig.ctx.p.N*sig.ctx.treeHeight
 	for i, stSig := range sig.sigs {
 		copy(buf[stOff+uint32(i)*stLen:], stSig.wotsSig)
 		copy(buf[stOff+uint32(i)*stLen+sig.ctx.wotsSigBytes:], stSig.authPath)
 	}
-	return nil
 }
 
 // Returns the sequence number of this signature.
@@ -321,6 +666,35 @@ func (sig *Signature) SeqNo() SignatureSeqNo {
 	return sig.seqNo
 }
 
+// Checks that the signature is well-formed for the instance of the given
+// Context: that it has the same parameters, that its signature sequence
+// number is in range and that its parts have the right sizes.  It does not
+// check whether the signature is valid, which is done by Verify().  Errors
+// are of the kind ErrMalformed, while Verify() returns an error of the kind
+// ErrInvalidSignature for a well-formed signature that does not verify.
+func (sig *Signature) Validate(ctx *Context) Error {
+	if sig.ctx == nil {
+		return kindErrorf(ErrMalformed, "Signature is not initialized")
+	}
+	if err := checkCompatibleParams("Signature", sig.ctx.p, ctx.p); err != nil {
+		return err
+	}
+	if uint64(sig.seqNo) > ctx.p.MaxSignatureSeqNo() {
+		return kindErrorf(ErrMalformed,
+			"Signature sequence number %d is out of range", sig.seqNo)
+	}
+	if uint32(len(sig.drv)) != ctx.p.N || uint32(len(sig.sigs)) != ctx.p.D {
+		return kindErrorf(ErrMalformed, "Signature has wrong size")
+	}
+	for _, stSig := range sig.sigs {
+		if uint32(len(stSig.wotsSig)) != ctx.wotsSigBytes ||
+			uint32(len(stSig.authPath)) != ctx.p.N*ctx.treeHeight {
+			return kindErrorf(ErrMalformed, "Signature has wrong size")
+		}
+	}
+	return nil
+}
+
 func (sig Signature) String() string {
 	return fmt.Sprintf("%s seqno=%d/%d",
 		sig.ctx.p, sig.seqNo, sig.ctx.p.MaxSignatureSeqNo())
@@ -347,6 +721,9 @@ func (pk *PublicKey) MarshalText() ([]byte, error) {
 // Writes the public key into buf in the same way as returned
 // by PublicKey.MarshalBinary()
 func (pk *PublicKey) WriteInto(buf []byte) error {
+	if pk.ctx.strict {
+		return errStrictCompressed
+	}
 	err := pk.ctx.p.WriteInto(buf)
 	if err != nil {
 		return err
@@ -370,6 +747,9 @@ func (pk *PublicKey) MarshalBinary() ([]byte, error) {
 // Initializes the PublicKey as was stored by MarshalBinary.
 func (pk *PublicKey) UnmarshalBinary(buf []byte) error {
 	var params Params
+	if len(buf) < 4 {
+		return kindErrorf(ErrMalformed, "Public key is too short")
+	}
 	err := params.UnmarshalBinary(buf[:4])
 	if err != nil {
 		return err
@@ -378,6 +758,10 @@ func (pk *PublicKey) UnmarshalBinary(buf []byte) error {
 	if err != nil {
 		return err
 	}
+	if uint32(len(buf)) != 4+pk.ctx.pkBytes {
+		return kindErrorf(ErrMalformed,
+			"Public key has wrong length: %d", len(buf))
+	}
 	pk.root = make([]byte, params.N)
 	pk.pubSeed = make([]byte, params.N)
 	copy(pk.root, buf[4:4+params.N])
@@ -392,34 +776,57 @@ func (pk *PublicKey) UnmarshalBinary(buf []byte) error {
 // NOTE Do not forget to Close() the returned PrivateKey
 func (ctx *Context) GenerateKeyPair(path string) (
 	*PrivateKey, *PublicKey, Error) {
-	pubSeed := make([]byte, ctx.p.N)
-	skSeed := make([]byte, ctx.p.N)
-	skPrf := make([]byte, ctx.p.N)
-	_, err := rand.Read(pubSeed)
+	pubSeed, skSeed, skPrf, err := ctx.randomSeeds()
 	if err != nil {
-		return nil, nil, wrapErrorf(err, "crypto.rand.Read()")
+		return nil, nil, err
 	}
-	_, err = rand.Read(skSeed)
+	defer zeroize(skSeed)
+	defer zeroize(skPrf)
+	return ctx.Derive(path, pubSeed, skSeed, skPrf)
+}
+
+// Generates an XMSS[MT] public/private keypair and stores it in the
+// container, such as the one returned by NewMemoryPrivateKeyContainer().
+//
+// NOTE Do not forget to Close() the returned PrivateKey
+func (ctx *Context) GenerateKeyPairInto(ctr PrivateKeyContainer) (
+	*PrivateKey, *PublicKey, Error) {
+	pubSeed, skSeed, skPrf, err := ctx.randomSeeds()
 	if err != nil {
-		return nil, nil, wrapErrorf(err, "crypto.rand.Read()")
+		return nil, nil, err
 	}
-	_, err = rand.Read(skPrf)
-	if err != nil {
-		return nil, nil, wrapErrorf(err, "crypto.rand.Read()")
+	defer zeroize(skSeed)
+	defer zeroize(skPrf)
+	return ctx.DeriveInto(ctr, pubSeed, skSeed, skPrf)
+}
+
+// Returns fresh random seeds for a keypair.
+func (ctx *Context) randomSeeds() (pubSeed, skSeed, skPrf []byte, err Error) {
+	pubSeed = make([]byte, ctx.p.N)
+	skSeed = make([]byte, ctx.p.N)
+	skPrf = make([]byte, ctx.p.N)
+	for _, seed := range [][]byte{pubSeed, skSeed, skPrf} {
+		if _, err2 := rand.Read(seed); err2 != nil {
+			zeroize(skSeed)
+			zeroize(skPrf)
+			return nil, nil, nil, wrapErrorf(err2, "crypto.rand.Read()")
+		}
 	}
-	return ctx.Derive(path, pubSeed, skSeed, skPrf)
+	return
 }
 
 // Derives an XMSS[MT] public/private keypair from the given seeds
 // and stores it at the given path on the filesystem.
+//
+// The key is created in a temporary directory next to path and only moved
+// into place when complete, so no partially initialized key is left at path
+// if this fails or the process dies.  See RepairFSPrivateKey() to clean up
+// after the latter.
+//
 // NOTE Do not forget to Close() the returned PrivateKey
 func (ctx *Context) Derive(path string, pubSeed, skSeed, skPrf []byte) (
 	*PrivateKey, *PublicKey, Error) {
-	ctr, err := OpenFSPrivateKeyContainer(path)
-	if err != nil {
-		return nil, nil, err
-	}
-	return ctx.DeriveInto(ctr, pubSeed, skSeed, skPrf)
+	return ctx.deriveFS(path, pubSeed, skSeed, skPrf)
 }
 
 // Derives an XMSS[MT] public/private keypair from the given seeds
@@ -440,12 +847,29 @@ func (ctx *Context) DeriveInto(ctr PrivateKeyContainer,
 	if err != nil {
 		return nil, nil, err
 	}
+	err = setContainerKeyStructure(ctr, ctx.keyStructure)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = setContainerLowestCachedHeight(ctx, ctr, ctx.lowestCachedHeight)
+	if err != nil {
+		return nil, nil, err
+	}
 
+	// The PrivateKey uses its own copy of the seeds, which it wipes
+	// on Close().
 	pad := ctx.newScratchPad()
-	sk, err := ctx.newPrivateKey(pad, pubSeed, skSeed, skPrf, 0, ctr)
+	defer pad.zeroizeIfEnabled()
+	n := ctx.p.N
+	sk, err := ctx.newPrivateKey(pad, concatSk[2*n:], concatSk[:n],
+		concatSk[n:2*n], 0, ctx.keyStructure, nil, ctr)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err = setContainerRoot(ctr, sk.root); err != nil {
+		sk.zeroize()
+		return nil, nil, err
+	}
 
 	// Precompute subtrees for the first few signatures.
 	sk.getSubTree(pad, SubTreeAddress{Layer: 0, Tree: 0})
@@ -453,6 +877,62 @@ func (ctx *Context) DeriveInto(ctr PrivateKeyContainer,
 	return sk, sk.PublicKey(), nil
 }
 
+// Deterministically derives the seeds for an XMSS[MT] keypair with the
+// given parameters from a master secret and a key identifier.  Pass the
+// seeds to Context.Derive() or Context.DeriveInto().
+//
+// Different keyIDs (or params) yield independent keys, which allows one to
+// derive many keys from a single master secret.  The master secret should
+// be random and at least params.N bytes long.
+func DeriveSeeds(masterSecret, keyID []byte, params Params) (
+	pubSeed, skSeed, skPrf []byte) {
+	// We compute SHAKE256 of
+	//
+	//   "go-xmssmt derive seeds" ‖ params ‖ len(masterSecret) ‖
+	//      masterSecret ‖ keyID
+	//
+	// where the length is encoded as a big endian uint64 and params
+	// as by Params.fixedEncoding().
+	var buf [8]byte
+	h := sha3.NewShake256()
+	h.Write([]byte("go-xmssmt derive seeds"))
+	h.Write(params.fixedEncoding())
+	binary.BigEndian.PutUint64(buf[:], uint64(len(masterSecret)))
+	h.Write(buf[:])
+	h.Write(masterSecret)
+	h.Write(keyID)
+
+	seeds := make([]byte, 3*params.N)
+	h.Read(seeds)
+	return seeds[:params.N], seeds[params.N : 2*params.N], seeds[2*params.N:]
+}
+
+// Makes Sign() borrow batch signature sequence numbers whenever none are
+// borrowed, see BorrowExactly().  Zero, the default, disables automatic
+// borrowing.
+//
+// This removes the write and fsync() of the signature sequence number from
+// all but one in batch calls to Sign(), at the cost of losing up to batch
+// signatures with a crash or missing Close().
+func (sk *PrivateKey) SetAutoBorrow(batch uint32) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	sk.autoBorrow = batch
+}
+
+// Sets the number of signature sequence numbers to lease at once from
+// a container shared with other processes.  Defaults to DefaultLeaseSize.
+// Has no effect on other containers.  See OpenFSLeasedPrivateKeyContainer().
+//
+// Sequence numbers left in the lease are lost when the process exits, so
+// a smaller lease wastes fewer signatures at the cost of locking and
+// syncing the key file more often.
+func (sk *PrivateKey) SetLeaseSize(amount uint32) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	sk.leaseSize = amount
+}
+
 // Atomically runs BorrowExactly(amount) if BorrowedSeqNos()  <= treshHold.
 func (sk *PrivateKey) BorrowExactlyIfBelow(amount, treshHold uint32) Error {
 	sk.mux.Lock()
@@ -472,11 +952,14 @@ func (sk *PrivateKey) BorrowExactlyIfBelow(amount, treshHold uint32) Error {
 // To speed things up, we can reserve signatures; write the fact that we did
 // this to disk and correct the signature sequence number on Close().
 // The drawback is that with a crash or a missing Close(), we will loose the
-// signatures that were reserved.
+// signatures that were reserved.  See also BorrowWithLease().
 func (sk *PrivateKey) BorrowExactly(amount uint32) Error {
 	sk.mux.Lock()
 	defer sk.mux.Unlock()
-	return sk.borrowExactly(amount)
+	if err := sk.borrowExactly(amount); err != nil {
+		return err
+	}
+	return sk.clearBorrowLease()
 }
 
 // Implementation of BorrowExactly.  Requires sk.mux lock.
@@ -486,6 +969,10 @@ func (sk *PrivateKey) borrowExactly(amount uint32) Error {
 	}
 
 	if sk.borrowed > amount {
+		if sk.monotonic != nil {
+			// The container can't go back below the monotonic counter.
+			return nil
+		}
 		err := sk.ctr.SetSeqNo(sk.seqNo + SignatureSeqNo(amount))
 		if err != nil {
 			return err
@@ -495,28 +982,223 @@ func (sk *PrivateKey) borrowExactly(amount uint32) Error {
 	}
 
 	// sk.borrowed < amount
+	if sk.monotonicErr != nil {
+		return sk.monotonicErr
+	}
 	_, err := sk.ctr.BorrowSeqNos(amount - sk.borrowed)
 	if err != nil {
 		return err
 	}
 	sk.borrowed = amount
+	return sk.advanceMonotonic(uint64(sk.seqNo) + uint64(amount))
+}
+
+// Restricts the signature sequence numbers used by this private key to
+// the range [start, end).
+//
+// This allows several machines to sign using a copy of the same private key
+// without coordinating every signature: each is assigned a disjoint range
+// of signature sequence numbers.  See also Shard().  After the range has
+// been exhausted, Sign() will refuse to create signatures.
+//
+// If the current signature sequence number is below start, it is moved
+// forward to start.  Any borrowed signature sequence numbers are released.
+//
+// NOTE The range is not stored in the container: it has to be set again
+// each time the private key is loaded.
+func (sk *PrivateKey) SetSeqNoRange(start, end SignatureSeqNo) Error {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+
+	if start >= end {
+		return errorf("Empty signature sequence number range [%d, %d)",
+			start, end)
+	}
+	if uint64(end) > sk.ctx.p.MaxSignatureSeqNo() {
+		return errorf("End of range is too large: %d > %d",
+			end, sk.ctx.p.MaxSignatureSeqNo())
+	}
+	if sk.seqNo >= end {
+		return errorf(
+			"Signature sequence number %d is already beyond the range [%d, %d)",
+			sk.seqNo, start, end)
+	}
+
+	if sk.seqNo < start || sk.borrowed > 0 {
+		newSeqNo := sk.seqNo
+		if newSeqNo < start {
+			newSeqNo = start
+		}
+		if sk.monotonic != nil &&
+			uint64(newSeqNo) < sk.monotonicValue {
+			// Borrowed sequence numbers can't be returned.
+			newSeqNo = SignatureSeqNo(sk.monotonicValue)
+			if newSeqNo >= end {
+				return errorf(
+					"Signature sequence number %d is already beyond the range [%d, %d)",
+					newSeqNo, start, end)
+			}
+		}
+		err := sk.ctr.SetSeqNo(newSeqNo)
+		if err != nil {
+			return err
+		}
+		sk.borrowed = 0
+		if newSeqNo != sk.seqNo {
+			sk.seqNo = newSeqNo
+
+			// See DangerousSetSeqNo().
+			emptyHeap := uint32Heap([]uint32{})
+			sk.retiredSeqNos = &emptyHeap
+			heap.Init(sk.retiredSeqNos)
+			sk.leastSeqNoInUse = newSeqNo
+		}
+	}
+
+	sk.seqNoStart = start
+	sk.seqNoEnd = end
 	return nil
 }
 
+// Restricts the signature sequence numbers used by this private key to
+// the i-th of k (almost) equally sized disjoint ranges.  The ranges are
+// numbered 0, 1, ..., k-1.  See SetSeqNoRange().
+func (sk *PrivateKey) Shard(i, k uint64) Error {
+	start, end, err := sk.ctx.ShardRange(i, k)
+	if err != nil {
+		return err
+	}
+	return sk.SetSeqNoRange(start, end)
+}
+
+// Returns the range [start, end) of signature sequence numbers used for
+// the i-th of k shards.  See PrivateKey.Shard().
+//
+// If possible, the ranges are aligned to the lowest layer subtrees,
+// so that different shards do not have to generate the same subtrees.
+func (ctx *Context) ShardRange(i, k uint64) (start, end SignatureSeqNo,
+	err Error) {
+	max := ctx.p.MaxSignatureSeqNo()
+	if k == 0 || i >= k {
+		return 0, 0, errorf("Invalid shard %d of %d", i, k)
+	}
+	if k > max {
+		return 0, 0, errorf("Can't split %d signatures into %d shards",
+			max, k)
+	}
+	size := (max + k - 1) / k
+	if (max >> ctx.treeHeight) >= k {
+		// Round up to a multiple of the number of leafs of a subtree.
+		size = ((size + (1 << ctx.treeHeight) - 1) >> ctx.treeHeight) <<
+			ctx.treeHeight
+	}
+	if i*size >= max {
+		return 0, 0, errorf("Shard %d of %d would be empty", i, k)
+	}
+	start = SignatureSeqNo(i * size)
+	end = SignatureSeqNo(max)
+	if (i+1)*size < max {
+		end = SignatureSeqNo((i + 1) * size)
+	}
+	return start, end, nil
+}
+
+// Returns the range [start, end) of signature sequence numbers this private
+// key is allowed to use.  See SetSeqNoRange().
+func (sk *PrivateKey) SeqNoRange() (start, end SignatureSeqNo) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	return sk.seqNoStart, sk.seqNoEnd
+}
+
 // Returns the number of signature sequence numbers borrowed from the container.
 // See BorrowExactly() or PrivateKeyContainer.BorrowSeqNos()
 func (sk *PrivateKey) BorrowedSeqNos() uint32 {
 	return sk.borrowed
 }
 
-// Signs the given message.
+// Signs the given message.  A nil message is the same as the empty
+// message.  See also Context.SetMessagePolicy().
 func (sk *PrivateKey) Sign(msg []byte) (*Signature, Error) {
+	if err := sk.ctx.checkMessageBytes(nil, msg); err != nil {
+		return nil, err
+	}
 	return sk.SignFrom(bytes.NewReader(msg))
 }
 
+// Signs a digest of a message that has been computed elsewhere.
+//
+// The digest must be exactly N bytes, see Params.N, and should be computed
+// with a collision resistant hash function, as a collision in the digest
+// allows forgeries.  domain (at most 255 bytes) identifies the protocol
+// or hash function with which the digest was computed.  The signature is
+// on the message
+//
+//   "XMSSMT-PREHASH" ‖ len(domain) ‖ domain ‖ digest,
+//
+// and can be checked with PublicKey.VerifyHash().
+func (sk *PrivateKey) SignHash(digest, domain []byte) (*Signature, Error) {
+	msg, err := sk.ctx.prehashedMessage(digest, domain)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Sign(msg)
+}
+
+// Returns the message that is signed by SignHash() for the given
+// digest and domain.
+func (ctx *Context) prehashedMessage(digest, domain []byte) ([]byte, Error) {
+	if len(digest) != int(ctx.p.N) {
+		return nil, errorf("Digest should have length %d", ctx.p.N)
+	}
+	if len(domain) > 255 {
+		return nil, errorf("Domain can be at most 255 bytes")
+	}
+	msg := make([]byte, 0, len(prehashPrefix)+1+len(domain)+len(digest))
+	msg = append(msg, prehashPrefix...)
+	msg = append(msg, byte(len(domain)))
+	msg = append(msg, domain...)
+	msg = append(msg, digest...)
+	return msg, nil
+}
+
+// Signs the message of the given size read from the io.ReaderAt, such
+// as an os.File.  See SignFrom().
+//
+// The message is read through an io.SectionReader, which keeps its own
+// offset, so the same io.ReaderAt can be read concurrently, for instance
+// to sign it with several keys or to write it out.
+func (sk *PrivateKey) SignReaderAt(msg io.ReaderAt, size int64) (
+	*Signature, Error) {
+	if err := sk.ctx.checkMessageLength(nil, size); err != nil {
+		return nil, err
+	}
+	return sk.SignFrom(io.NewSectionReader(msg, 0, size))
+}
+
 // Reads a message from the io.Reader and signs it.
+//
+// The message is read once, sequentially, in chunks of MessageChunkSize
+// bytes and hashed as it's read, so large messages such as firmware images
+// can be signed without holding them in memory.
+//
+// NOTE As the length of the message is not known beforehand, a message
+//      that is rejected by the MessagePolicy while it is read still uses
+//      up a signature sequence number.
 func (sk *PrivateKey) SignFrom(msg io.Reader) (*Signature, Error) {
-	pad := sk.ctx.newScratchPad()
+	return sk.signFrom(msg, nil)
+}
+
+// Signs the message prepended by prefix, if not nil.
+// See Context.writeMessage().
+func (sk *PrivateKey) signFrom(msg io.Reader, prefix []byte) (
+	*Signature, Error) {
+	if err := sk.ctx.checkMessageContext(prefix); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	pad := sk.ctx.getScratchPad()
+	defer sk.ctx.putScratchPad(pad)
 	seqNo, err := sk.getSeqNo()
 	if err != nil {
 		return nil, err
@@ -526,75 +1208,302 @@ func (sk *PrivateKey) SignFrom(msg io.Reader) (*Signature, Error) {
 	// Compute the path of subtrees
 	staPath, leafs := sk.ctx.subTreePathForSeqNo(seqNo)
 
-	// Fetch (or generate) the subtrees
-	mts := make([]*merkleTree, len(staPath))
-	wotsSigs := make([][]byte, len(staPath))
-	for i := len(staPath) - 1; i >= 0; i-- {
-		var wotsSig []byte
-		mts[i], wotsSig, err = sk.getSubTree(pad, staPath[i])
+	// The tail of the signature is probably cached, but if it isn't,
+	// loading (or generating) it takes long.  So retrieve it while we
+	// create the part of the signature unique to this message.  This is
+	// not done on the workers, as generating a subtree requires the
+	// workers itself.
+	var mt *merkleTree
+	var tail []subTreeSig
+	var tailErr Error
+	tailDone := make(chan struct{})
+	go func() {
+		tailPad := sk.ctx.getScratchPad()
+		mt, tail, tailErr = sk.signatureTail(*tailPad, staPath, leafs)
+		sk.ctx.putScratchPad(tailPad)
+		close(tailDone)
+	}()
+
+	sig := sk.allocSignature(seqNo)
+	err = sk.signMessageInto(*pad, sig, prefix, msg, staPath[0], leafs[0])
+	<-tailDone
+	if err != nil {
+		return nil, err
+	}
+	if tailErr != nil {
+		return nil, tailErr
+	}
+	sk.setSignatureTail(sig, mt, leafs[0], tail)
+
+	if sk.ctx.metrics != nil {
+		sk.ctx.metrics.SignDuration(time.Since(start))
+	}
+	return sig, nil
+}
+
+// Signs the messages with consecutive signature sequence numbers.
+//
+// The block of signature sequence numbers is reserved from the container
+// at once and the WOTS+ signatures are created in parallel on the workers
+// of the Context.  Signatures that fall in the same subtree on the lowest
+// layer share the same cached subtrees and upper parts of the signature.
+// This is much faster than calling Sign() for each message when signing
+// bursts of messages.
+//
+// If the private key doesn't have len(msgs) unused signature sequence
+// numbers left in its range, no signatures are created.  At most 2^32-1
+// messages can be signed at once.
+func (sk *PrivateKey) SignMany(msgs [][]byte) ([]*Signature, Error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	if uint64(len(msgs)) > uint64(^uint32(0)) {
+		return nil, errorf("Too many messages: %d > %d",
+			uint64(len(msgs)), ^uint32(0))
+	}
+	for _, msg := range msgs {
+		if err := sk.ctx.checkMessageBytes(nil, msg); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	pad := sk.ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	first, err := sk.getSeqNos(uint32(len(msgs)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for i := range msgs {
+			sk.retireSeqNo(first + SignatureSeqNo(i))
+		}
+	}()
+
+	sigs := make([]*Signature, len(msgs))
+	errs := make([]Error, len(msgs))
+	wg := &sync.WaitGroup{}
+	pool := sk.ctx.workerPool()
+	var sta SubTreeAddress
+	var mt *merkleTree
+	var tail []subTreeSig
+	for i := range msgs {
+		seqNo := first + SignatureSeqNo(i)
+		staPath, leafs := sk.ctx.subTreePathForSeqNo(seqNo)
+
+		// Fetch the subtrees once for every subtree on the lowest layer.
+		// This is done here and not on the workers, as generating
+		// a subtree requires the workers itself.
+		if mt == nil || staPath[0] != sta {
+			sta = staPath[0]
+			mt, tail, err = sk.signatureTail(pad, staPath, leafs)
+			if err != nil {
+				wg.Wait()
+				return nil, err
+			}
+		}
+
+		sigs[i] = sk.newSignature(seqNo, mt, leafs[0], tail)
+		wg.Add(1)
+		idx, leaf, sta := i, leafs[0], sta
+		pool.jobs <- func(pad scratchPad) {
+			errs[idx] = sk.signMessageInto(pad, sigs[idx], nil,
+				bytes.NewReader(msgs[idx]), sta, leaf)
+			wg.Done()
+		}
+	}
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
 			return nil, err
 		}
-		wotsSigs[i] = make([]byte, len(wotsSig))
-		copy(wotsSigs[i], wotsSig)
 	}
 
-	// Assemble the signature.
-	sig := Signature{
-		ctx:   sk.ctx,
-		seqNo: seqNo,
-		sigs:  make([]subTreeSig, len(staPath)),
-		drv:   sk.ctx.prfUint64(pad, uint64(seqNo), sk.skPrf),
+	if sk.ctx.metrics != nil {
+		perSig := time.Since(start) / time.Duration(len(msgs))
+		for range msgs {
+			sk.ctx.metrics.SignDuration(perSig)
+		}
+	}
+	return sigs, nil
+}
+
+// Fetches (or generates) the subtrees on the path to the given signature
+// sequence number.  Returns the subtree on the lowest layer and the parts
+// of the signature for the other layers.
+//
+// The parts for the other layers only depend on the subtree on the lowest
+// layer, so they are kept for the next signature.  The returned tail is
+// shared and must not be changed.
+func (sk *PrivateKey) signatureTail(pad scratchPad, staPath []SubTreeAddress,
+	leafs []uint32) (*merkleTree, []subTreeSig, Error) {
+	sk.mux.Lock()
+	tail := sk.tail
+	if tail == nil || sk.tailSta != staPath[0] {
+		tail = nil
+	}
+	sk.mux.Unlock()
+
+	if tail != nil {
+		mt, _, err := sk.getSubTree(pad, staPath[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return mt, tail, nil
 	}
 
-	// The tail of the signature is probably cached, retrieve (or create) it
+	n := sk.ctx.p.N
+	wotsSigBytes := sk.ctx.wotsSigBytes
+	authPathBytes := sk.ctx.treeHeight * n
+	tail = make([]subTreeSig, len(staPath)-1)
+	buf := make([]byte, uint32(len(tail))*(wotsSigBytes+authPathBytes))
+
+	// Retrieve the subtrees on all layers at the same time, as each
+	// of them might have to be loaded from disk or checked.
+	mts := make([]*merkleTree, len(staPath))
+	wotsSigs := make([][]byte, len(staPath))
+	errs := make([]Error, len(staPath))
+	var wg sync.WaitGroup
 	for i := 1; i < len(staPath); i++ {
-		sig.sigs[i] = subTreeSig{
-			wotsSig:  wotsSigs[i-1],
-			authPath: mts[i].AuthPath(leafs[i]),
+		wg.Add(1)
+		go func(i int) {
+			layerPad := sk.ctx.getScratchPad()
+			mts[i], wotsSigs[i], errs[i] = sk.getSubTree(*layerPad, staPath[i])
+			sk.ctx.putScratchPad(layerPad)
+			wg.Done()
+		}(i)
+	}
+	mts[0], wotsSigs[0], errs[0] = sk.getSubTree(pad, staPath[0])
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for i := len(staPath) - 1; i >= 0; i-- {
+		if i > 0 {
+			tail[i-1].authPath, buf = buf[:authPathBytes:authPathBytes],
+				buf[authPathBytes:]
+			mts[i].AuthPathInto(leafs[i], tail[i-1].authPath)
+		}
+		if i < len(tail) {
+			tail[i].wotsSig, buf = buf[:wotsSigBytes:wotsSigBytes],
+				buf[wotsSigBytes:]
+			copy(tail[i].wotsSig, wotsSigs[i])
 		}
 	}
+	mt := mts[0]
 
-	// Create the part of the signature unique to this message
+	sk.mux.Lock()
+	sk.tailSta, sk.tail = staPath[0], tail
+	sk.mux.Unlock()
+	return mt, tail, nil
+}
+
+// Assembles a signature without the WOTS+ signature on the message,
+// see signMessageInto().
+func (sk *PrivateKey) newSignature(seqNo SignatureSeqNo, mt *merkleTree,
+	leaf uint32, tail []subTreeSig) *Signature {
+	sig := sk.allocSignature(seqNo)
+	sk.setSignatureTail(sig, mt, leaf, tail)
+	return sig
+}
+
+// Allocates an empty signature with the given sequence number.
+func (sk *PrivateKey) allocSignature(seqNo SignatureSeqNo) *Signature {
+	n := sk.ctx.p.N
+	authPathBytes := sk.ctx.treeHeight * n
+	buf := make([]byte, n+authPathBytes+sk.ctx.wotsSigBytes)
+	sig := Signature{
+		ctx:   sk.ctx,
+		seqNo: seqNo,
+		drv:   buf[:n:n],
+		sigs:  make([]subTreeSig, sk.ctx.p.D),
+	}
 	sig.sigs[0] = subTreeSig{
-		authPath: mts[0].AuthPath(leafs[0]),
-		wotsSig:  make([]byte, sk.ctx.wotsSigBytes),
+		authPath: buf[n : n+authPathBytes : n+authPathBytes],
+		wotsSig:  buf[n+authPathBytes:],
 	}
+	return &sig
+}
 
-	mhash, err2 := sk.ctx.hashMessage(pad, msg, sig.drv, sk.root, uint64(seqNo))
-	if err2 != nil {
-		return nil, wrapErrorf(err2, "Failed to hash message")
+// Sets the authentication path on the lowest layer and the parts of the
+// signature for the other layers, see signatureTail().
+func (sk *PrivateKey) setSignatureTail(sig *Signature, mt *merkleTree,
+	leaf uint32, tail []subTreeSig) {
+	mt.AuthPathInto(leaf, sig.sigs[0].authPath)
+	copy(sig.sigs[1:], tail)
+}
+
+// Computes the randomizer of the signature and the WOTS+ signature on
+// the message, prepended by prefix if not nil, with the given leaf of
+// the subtree sta on the lowest layer.
+func (sk *PrivateKey) signMessageInto(pad scratchPad, sig *Signature,
+	prefix []byte, msg io.Reader, sta SubTreeAddress, leaf uint32) Error {
+	sk.ctx.prfUint64Into(pad, uint64(sig.seqNo), sk.skPrf, sig.drv)
+	if err := sk.ctx.mixRandomizerEntropy(sig.drv); err != nil {
+		return err
+	}
+	mhash := pad.nodeBuf
+	err := sk.ctx.hashMessageInto(pad, prefix, msg, sig.drv, sk.root,
+		uint64(sig.seqNo), mhash)
+	if err != nil {
+		return wrapErrorf(err, "Failed to hash message")
 	}
-	otsAddr := staPath[0].address()
-	otsAddr.setOTS(leafs[0])
+	if err := sk.recordAuditEntry(sig.seqNo, mhash); err != nil {
+		return err
+	}
+	otsAddr := sta.address()
+	otsAddr.setOTS(leaf)
 
+	_, ph, release := sk.subTreeHashes(pad, sta)
+	defer release()
 	sk.ctx.wotsSignInto(
 		pad,
 		mhash,
-		sk.ph,
+		ph,
 		otsAddr,
 		sig.sigs[0].wotsSig)
-
-	return &sig, nil
+	return nil
 }
 
-// Close the underlying container
+// Close the underlying container.  If the Context of the private key was
+// created for it, by LoadPrivateKey() or GenerateKeyPair(), its workers are
+// stopped as well.
 func (sk *PrivateKey) Close() Error {
+	// There might be a background goroutine generating a subtree
+	// when EnableSubTreePrecomputation() was called or the cache is
+	// rebuilt.  So stop them after their current subtree and wait for
+	// that.  They need sk.mux, so we can't hold it.
+	sk.mux.Lock()
+	sk.closing = true
+	sk.cond.Broadcast()
+	sk.mux.Unlock()
+	sk.wg.Wait()
+
+	// The Context stays usable by the PublicKey: its workers are started
+	// again when needed.
+	if sk.ownsCtx {
+		defer sk.ctx.Close()
+	}
+
 	sk.mux.Lock()
 	defer sk.mux.Unlock()
-	if sk.borrowed > 0 {
+	if sk.borrowed > 0 && sk.monotonic == nil {
 		sk.borrowed = 0
 		err := sk.ctr.SetSeqNo(sk.seqNo)
 		if err != nil {
 			return err
 		}
+		if err = sk.clearBorrowLease(); err != nil {
+			return err
+		}
 	}
 	err := sk.ctr.Close()
 	sk.cond.Broadcast()
 
-	// There might be a background goroutine generating a subtree
-	// when EnableSubTreePrecomputation() was called.  So wait for that.
-	sk.wg.Wait()
+	sk.zeroize()
 
 	return err
 }
@@ -657,6 +1566,10 @@ func NewContext(params Params) (ctx *Context, err Error) {
 		return nil, errorf("Only N=16,24,32,64 are supported")
 	}
 
+	if ctx.p.Func == SHA512_256 && ctx.p.N > 32 {
+		return nil, errorf("SHA512_256 only supports N=16,24,32")
+	}
+
 	if params.D == 0 {
 		return nil, errorf("D can't be zero")
 	}
@@ -694,11 +1607,44 @@ func NewContext(params Params) (ctx *Context, err Error) {
 
 	if ctx.p.Func == SHAKE && (ctx.p.N == 32 || ctx.p.N == 16) {
 		ctx.x4Available = f1600x4.Available
+		ctx.x2Available = !ctx.x4Available && f1600x2.Available
+		if ctx.x4Available {
+			ctx.lanes = 4
+		} else if ctx.x2Available {
+			ctx.lanes = 2
+		}
+	}
+
+	if ctx.p.Func == SHA2 && ctx.p.N <= 32 {
+		ctx.x8Available = sha256x8.Available
+		if ctx.x8Available {
+			ctx.lanes = 8
+		}
 	}
 
 	return
 }
 
+// Sets a function that is called to report progress on the generation
+// of the leafs of a subtree, which is the bulk of the work of key generation.
+// done is the number of leafs generated so far and total is the number
+// of leafs of the subtree.  For XMSSMT, key generation computes a subtree
+// for every layer and so the count starts over for each.
+//
+// The function may be called from different goroutines, but never
+// concurrently for the same subtree.  Pass nil to disable progress reporting.
+// Should not be called concurrently with other operations on this Context.
+func (ctx *Context) SetProgressFunc(f func(done, total uint64)) {
+	ctx.progress = f
+}
+
+// Sets the Metrics that receives measurements on signing and subtree
+// generation by the private keys of this Context.  Pass nil to disable.
+// Should not be called concurrently with other operations on this Context.
+func (ctx *Context) SetMetrics(m Metrics) {
+	ctx.metrics = m
+}
+
 func (sk *PrivateKey) Context() *Context {
 	return sk.ctx
 }
@@ -711,15 +1657,58 @@ func (sig *Signature) Context() *Context {
 	return sig.ctx
 }
 
+// Returns a copy of the root node of the top subtree, which together with
+// the public seed forms the public key.
+func (pk *PublicKey) Root() []byte {
+	return append([]byte{}, pk.root...)
+}
+
+// Returns a copy of the public seed, which is used to randomize the hashes.
+func (pk *PublicKey) PubSeed() []byte {
+	return append([]byte{}, pk.pubSeed...)
+}
+
+// Returns a copy of the root node of the top subtree of the public key.
+// See PublicKey.Root().
+func (sk *PrivateKey) Root() []byte {
+	return append([]byte{}, sk.root...)
+}
+
+// Returns whether x is a *PublicKey with the same parameters, root and
+// public seed.  Follows the convention of the public keys of the standard
+// library, see crypto.PublicKey.
+func (pk *PublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PublicKey)
+	if !ok {
+		return false
+	}
+	return pk.ctx.p == other.ctx.p &&
+		subtle.ConstantTimeCompare(pk.root, other.root) == 1 &&
+		subtle.ConstantTimeCompare(pk.pubSeed, other.pubSeed) == 1
+}
+
+// Returns whether pk is the public key of this private key, for instance
+// to check on startup that the private key that is loaded belongs to the
+// public key in the configuration.
+func (sk *PrivateKey) PublicKeyEqual(pk *PublicKey) bool {
+	return pk != nil && sk.ctx.p == pk.ctx.p &&
+		subtle.ConstantTimeCompare(sk.root, pk.root) == 1 &&
+		subtle.ConstantTimeCompare(sk.pubSeed, pk.pubSeed) == 1
+}
+
 // Loads the private key from the given filesystem container.
 //
 // If the container wasn't properly closed, there might have been signatures
-// lost.  The amount of returned in lostSigs.
+// lost.  The amount of returned in lostSigs.  See PrivateKey.LoadReport()
+// for more details.
+//
+// If the cache is missing or corrupt, it is recreated.  See
+// LoadPrivateKeyFrom() for how the subtrees are rebuilt.
 //
 // NOTE Takes ownership of ctr.  Do not forget to Close() the  PrivateKey.
 func LoadPrivateKey(path string) (
 	sk *PrivateKey, pk *PublicKey, lostSigs uint32, err Error) {
-	ctr, err := OpenFSPrivateKeyContainer(path)
+	ctr, err := openFSPrivateKeyContainerToLoad(path)
 	if err != nil {
 		return nil, nil, 0, err
 	}
@@ -729,15 +1718,33 @@ func LoadPrivateKey(path string) (
 // Loads the private key from the given private key container.
 //
 // If the container wasn't properly closed, there might have been signatures
-// lost.  The amount of returned in lostSigs.
+// lost.  The amount of returned in lostSigs.  Signatures borrowed with
+// a lease that has not expired yet are not counted, see BorrowWithLease().
+//
+// If the container stores the root, see RootStore, the public key is
+// returned without reading or generating the root subtree.  If the root
+// subtree is not cached, which would take minutes to generate for large
+// subtrees, the subtrees for the next signature are rebuilt in the
+// background instead, starting with the root subtree.  The root subtree is
+// checked against the stored root when it is generated or first used:
+// Sign() waits for the subtrees it needs and fails if the roots don't
+// match.  If the container does not store the root yet, it is stored.
 //
 // NOTE Takes ownership of ctr.  Do not forget to Close() the  PrivateKey.
 func LoadPrivateKeyFrom(ctr PrivateKeyContainer) (
+	sk *PrivateKey, pk *PublicKey, lostSigs uint32, err Error) {
+	return loadPrivateKeyFrom(nil, ctr)
+}
+
+// Implementation of LoadPrivateKeyFrom.  If ctx is not nil, it is used
+// for the private key instead of a new Context.
+func loadPrivateKeyFrom(ctx *Context, ctr PrivateKeyContainer) (
 	sk *PrivateKey, pk *PublicKey, lostSigs uint32, err Error) {
 	// First check if the container is sane.
 	params := ctr.Initialized()
 	if params == nil {
-		return nil, nil, 0, errorf("Container is not initialized")
+		return nil, nil, 0, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
 	}
 	if !ctr.CacheInitialized() {
 		log.Logf("Cache is not initialized --- initializing...")
@@ -746,9 +1753,20 @@ func LoadPrivateKeyFrom(ctr PrivateKeyContainer) (
 			return nil, nil, 0, wrapErrorf(err, "Failed to initialize cache")
 		}
 	}
-	ctx, err := NewContext(*params)
-	if err != nil {
-		return nil, nil, 0, err
+	ownsCtx := ctx == nil
+	if ownsCtx {
+		ctx, err = NewContext(*params)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		defer func() {
+			if err != nil {
+				ctx.Close()
+			}
+		}()
+	} else if ctx.p != *params {
+		return nil, nil, 0, errorf("Container has parameters %s instead of %s",
+			params, ctx.p)
 	}
 
 	// Extract the private key and signature seqno
@@ -763,28 +1781,73 @@ func LoadPrivateKeyFrom(ctr PrivateKeyContainer) (
 		return nil, nil, 0, err
 	}
 
+	keyStructure, err := containerKeyStructure(ctr)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// If the root is stored, the root subtree is not needed to load the
+	// key.  It is checked against the stored root when it is first used.
+	root, err := containerRoot(ctx, ctr)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	rebuild := root != nil &&
+		!ctr.HasSubTree(SubTreeAddress{Layer: params.D - 1})
+
+	var pendingLeases []BorrowLease
+	if store, ok := ctr.(BorrowLeaseStore); ok {
+		lostSigs, pendingLeases, err = settleBorrowLeases(
+			store, seqNo, lostSigs)
+		if err != nil {
+			return nil, nil, 0, wrapErrorf(err, "Failed to settle leases")
+		}
+	}
+
 	// Create the private and public key structures
 	pad := ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
 	sk, err = ctx.newPrivateKey(
 		pad,
 		skBuf[params.N*2:params.N*3],
 		skBuf[:params.N],
 		skBuf[params.N:params.N*2],
 		seqNo,
+		keyStructure,
+		root,
 		ctr)
 	if err != nil {
 		return nil, nil, 0, err
 	}
+	sk.ownsCtx = ownsCtx
+	sk.pendingLeases = pendingLeases
+	sk.loadReport.LostSigs = lostSigs
+	if root == nil {
+		// Key files written before the root was stored, see fsKeyVersion.
+		if err := setContainerRoot(ctr, sk.root); err != nil {
+			log.Logf("%v", err)
+		}
+	}
+	if rebuild {
+		sk.loadReport.RebuildingCache = true
+		sk.wg.Add(1)
+		go sk.rebuildCache()
+	}
 	pk = sk.PublicKey()
 	return
 }
 
 // Returns the PublicKey for this PrivateKey.
 func (sk *PrivateKey) PublicKey() *PublicKey {
+	// The precomputed hashes are computed once and shared by the returned
+	// PublicKeys, which never modify them.
+	sk.pkOnce.Do(func() {
+		sk.pkPh = sk.ctx.precomputeHashes(sk.pubSeed, nil)
+	})
 	ret := PublicKey{
 		ctx:     sk.ctx,
 		pubSeed: sk.pubSeed,
-		ph:      sk.ctx.precomputeHashes(sk.pubSeed, nil),
+		ph:      sk.pkPh,
 		root:    sk.root,
 	}
 	return &ret
@@ -799,22 +1862,217 @@ func (sk *PrivateKey) PublicKey() *PublicKey {
 func (sk *PrivateKey) UnretiredSeqNos() uint32 {
 	sk.mux.Lock()
 	defer sk.mux.Unlock()
+	return sk.unretiredSeqNos()
+}
+
+// Implementation of UnretiredSeqNos().  Requires sk.mux lock.
+func (sk *PrivateKey) unretiredSeqNos() uint32 {
 	return uint32(sk.seqNo) - uint32(sk.leastSeqNoInUse) -
 		uint32(sk.retiredSeqNos.Len())
 }
 
+// Returns the number of signatures that can still be created with this
+// private key, taking into account the range set by SetSeqNoRange().
+func (sk *PrivateKey) RemainingSignatures() uint64 {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	return sk.remainingSignatures()
+}
+
+// Implementation of RemainingSignatures().  Requires sk.mux lock.
+func (sk *PrivateKey) remainingSignatures() uint64 {
+	if sk.seqNo >= sk.seqNoEnd {
+		return 0
+	}
+	if sk.seqNo < sk.seqNoStart {
+		return uint64(sk.seqNoEnd - sk.seqNoStart)
+	}
+	return uint64(sk.seqNoEnd - sk.seqNo)
+}
+
+// Returns the fraction of the signatures of this private key that have been
+// used as a number between 0 and 1.  Only the signatures in the range set
+// by SetSeqNoRange() are counted.  Useful to decide when to rotate keys.
+func (sk *PrivateKey) UsedFraction() float64 {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	total := sk.seqNoEnd - sk.seqNoStart
+	if total == 0 {
+		return 1
+	}
+	return 1 - float64(sk.remainingSignatures())/float64(total)
+}
+
 // Returns the number of subtrees that are cached
 func (sk *PrivateKey) CachedSubTrees() int {
 	return len(sk.subTreeReady)
 }
 
+// Limits the number of cached subtrees to maxSubTrees.  Zero, the default,
+// means no limit.
+//
+// When a new subtree is needed and the cache is full, cached subtrees are
+// dropped, preferring those of the lowest layer and oldest trees first.
+// Subtrees that might still be used by a Sign() in progress are never
+// dropped, so the limit might be exceeded temporarily.  The filesystem
+// container reuses the space of dropped subtrees, so this also bounds the
+// size of the cache file.
+//
+// To sign, a subtree on each of the D layers is needed, so a limit below
+// D (or D+1 with EnableSubTreePrecomputation) leads to subtrees being
+// generated over and over again.
+func (sk *PrivateKey) SetCacheLimit(maxSubTrees int) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	sk.maxCachedSubTrees = maxSubTrees
+}
+
+// Limits the total size of the cached subtrees to maxBytes.
+// See SetCacheLimit().
+func (sk *PrivateKey) SetCacheSizeLimit(maxBytes uint64) {
+	maxSubTrees := int(maxBytes / uint64(sk.ctx.p.CachedSubTreeSize()))
+	if maxSubTrees == 0 {
+		maxSubTrees = 1
+	}
+	sk.SetCacheLimit(maxSubTrees)
+}
+
+// Information on a cached subtree.  See ListCachedSubTrees().
+type CachedSubTreeInfo struct {
+	Address SubTreeAddress
+
+	// The subtree is used by the signatures with sequence numbers in the
+	// range [FirstSeqNo, EndSeqNo).
+	FirstSeqNo SignatureSeqNo
+	EndSeqNo   SignatureSeqNo
+
+	// Whether the checksum of the subtree has been checked since the
+	// private key was loaded, or the subtree was generated since.
+	Checked bool
+
+	// Whether the subtree might be used by a Sign() in progress or is
+	// needed for the next signature.  Such a subtree is not dropped by
+	// DropCachedSubTree() and PruneCache().
+	InUse bool
+}
+
+// Returns the subtrees that are cached, ordered by layer and then by tree.
+// Subtrees that are still being generated are not included.
+func (sk *PrivateKey) ListCachedSubTrees() []CachedSubTreeInfo {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	ret := make([]CachedSubTreeInfo, 0, len(sk.subTreeReady))
+	for sta, ready := range sk.subTreeReady {
+		if !ready {
+			continue
+		}
+		shift := (sta.Layer + 1) * sk.ctx.treeHeight
+		ret = append(ret, CachedSubTreeInfo{
+			Address:    sta,
+			FirstSeqNo: SignatureSeqNo(sta.Tree << shift),
+			EndSeqNo:   SignatureSeqNo((sta.Tree + 1) << shift),
+			Checked:    sk.subTreeChecked[sta],
+			InUse:      sk.subTreeInUse(sta),
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Address.Layer != ret[j].Address.Layer {
+			return ret[i].Address.Layer < ret[j].Address.Layer
+		}
+		return ret[i].Address.Tree < ret[j].Address.Tree
+	})
+	return ret
+}
+
+// Returns whether the subtree might be used by a Sign() in progress or is
+// needed for the next signature.
+//
+// NOTE Assumes a lock on sk.mux.
+func (sk *PrivateKey) subTreeInUse(sta SubTreeAddress) bool {
+	shift := (sta.Layer + 1) * sk.ctx.treeHeight
+	return uint64(sk.leastSeqNoInUse)>>shift <= sta.Tree &&
+		sta.Tree <= uint64(sk.seqNo)>>shift
+}
+
+// Removes the given subtree from the cache.  If it is needed later on,
+// it is generated again.  Subtrees that are in use, see CachedSubTreeInfo,
+// cannot be dropped.  Dropping a subtree that is not cached is a no-op.
+func (sk *PrivateKey) DropCachedSubTree(sta SubTreeAddress) Error {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	ready, cached := sk.subTreeReady[sta]
+	if !cached {
+		return nil
+	}
+	if !ready || sk.subTreeInUse(sta) {
+		return errorf("Subtree %v is in use", sta)
+	}
+	return sk.dropCachedSubTree(sta)
+}
+
+// Removes the given subtree from the container and forgets about it.
+//
+// NOTE Assumes a lock on sk.mux.
+func (sk *PrivateKey) dropCachedSubTree(sta SubTreeAddress) Error {
+	log.Logf("Dropping cached subtree %v ...", sta)
+	if err := sk.ctr.DropSubTree(sta); err != nil {
+		return wrapErrorf(err, "Failed to drop subtree %v", sta)
+	}
+	delete(sk.subTreeReady, sta)
+	delete(sk.subTreeChecked, sta)
+	return nil
+}
+
+// Removes the cached subtrees that are only used by signatures with
+// sequence numbers before beforeSeqNo, for instance to free the space of
+// the subtrees that were skipped by a large jump with DangerousSetSeqNo().
+// Subtrees that are in use are kept.  Returns the number of subtrees
+// dropped.
+func (sk *PrivateKey) PruneCache(beforeSeqNo SignatureSeqNo) (int, Error) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	var stas []SubTreeAddress
+	for sta, ready := range sk.subTreeReady {
+		shift := (sta.Layer + 1) * sk.ctx.treeHeight
+		if ready && (sta.Tree+1)<<shift <= uint64(beforeSeqNo) &&
+			!sk.subTreeInUse(sta) {
+			stas = append(stas, sta)
+		}
+	}
+	for i, sta := range stas {
+		if err := sk.dropCachedSubTree(sta); err != nil {
+			return i, err
+		}
+	}
+	return len(stas), nil
+}
+
 // You probably should not use this function
 //
 // Sets the signature sequence number.  Be very careful not to use the same
 // signature sequence number twice.
-func (sk *PrivateKey) DangerousSetSeqNo(seqNo SignatureSeqNo) {
+//
+// The new signature sequence number (plus the borrowed ones) is stored in
+// the container right away, so that skipping ahead survives a crash.
+// If the container keeps a high-water mark, see HighWaterMark(), the
+// signature sequence number can't be set below it, unless
+// DangerousAllowSeqNoRegression() has been called.
+func (sk *PrivateKey) DangerousSetSeqNo(seqNo SignatureSeqNo) Error {
 	sk.mux.Lock()
 	defer sk.mux.Unlock()
+	if marker, ok := sk.ctr.(HighWaterMarker); ok && !sk.allowSeqNoRegression {
+		mark, err := marker.HighWaterMark()
+		if err != nil {
+			return wrapErrorf(err, "Failed to get high-water mark")
+		}
+		if seqNo < mark {
+			return errorf("Signature sequence number %d is below the "+
+				"high-water mark %d: signatures might be reused", seqNo, mark)
+		}
+	}
+	if err := sk.ctr.SetSeqNo(seqNo + SignatureSeqNo(sk.borrowed)); err != nil {
+		return err
+	}
 	sk.seqNo = seqNo
 
 	// We might forget to drop some cached subtrees, but that is probably
@@ -823,6 +2081,37 @@ func (sk *PrivateKey) DangerousSetSeqNo(seqNo SignatureSeqNo) {
 	sk.retiredSeqNos = &emptyHeap
 	heap.Init(sk.retiredSeqNos)
 	sk.leastSeqNoInUse = seqNo
+	return nil
+}
+
+// You really should not use this function
+//
+// Allows DangerousSetSeqNo() to set the signature sequence number below
+// the high-water mark, for instance to recreate a signature for a test
+// vector.  Signatures created afterwards reuse signature sequence numbers,
+// which breaks the security of XMSS[MT].
+func (sk *PrivateKey) DangerousAllowSeqNoRegression() {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	sk.allowSeqNoRegression = true
+}
+
+// Returns the high-water mark of the container: the largest signature
+// sequence number it has ever stored, including signatures that have been
+// borrowed and returned.  All signatures created with this private key have
+// a smaller signature sequence number, unless DangerousSetSeqNo() was used
+// with DangerousAllowSeqNoRegression().  Useful to audit the use of the key.
+//
+// Returns an error if the container does not keep a high-water mark.
+// See HighWaterMarker.
+func (sk *PrivateKey) HighWaterMark() (SignatureSeqNo, Error) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	marker, ok := sk.ctr.(HighWaterMarker)
+	if !ok {
+		return 0, errorf("Container does not keep a high-water mark")
+	}
+	return marker.HighWaterMark()
 }
 
 // Returns the signature sequence used next.
@@ -830,6 +2119,59 @@ func (sk *PrivateKey) SeqNo() SignatureSeqNo {
 	return sk.seqNo
 }
 
+// Generates and caches the subtrees needed for the next n signatures,
+// such that these are fast.  Blocks until done.  This is useful to warm
+// up the cache before a server starts to sign.
+//
+// The subtrees are generated one after the other, but the leafs of
+// each subtree are generated in parallel; see Context.Threads.
+// If progress is not nil, it is called after each subtree with the number
+// of subtrees generated so far and the total number of subtrees to generate.
+//
+// NOTE With SetCacheLimit() the precomputed subtrees might be dropped
+// before they are used.
+func (sk *PrivateKey) Precompute(n uint64,
+	progress func(done, total uint64)) Error {
+	sk.mux.Lock()
+	start := uint64(sk.seqNo)
+	end := uint64(sk.seqNoEnd)
+	sk.mux.Unlock()
+
+	if start >= end || n == 0 {
+		return nil
+	}
+	if n < end-start {
+		end = start + n
+	}
+
+	// We start at the top layer, such that the parent of each subtree
+	// is ready when it is generated.
+	var total, done uint64
+	for layer := uint32(0); layer < sk.ctx.p.D; layer++ {
+		shift := (layer + 1) * sk.ctx.treeHeight
+		total += ((end - 1) >> shift) - (start >> shift) + 1
+	}
+	pad := sk.ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	for layer := int(sk.ctx.p.D) - 1; layer >= 0; layer-- {
+		shift := uint32(layer+1) * sk.ctx.treeHeight
+		for tree := start >> shift; tree <= (end-1)>>shift; tree++ {
+			_, _, err := sk.getSubTree(pad, SubTreeAddress{
+				Layer: uint32(layer),
+				Tree:  tree,
+			})
+			if err != nil {
+				return err
+			}
+			done++
+			if progress != nil {
+				progress(done, total)
+			}
+		}
+	}
+	return nil
+}
+
 // Enable subtree precomputation.
 //
 // By default, a subtree is computed when it's needed.  So with subtrees of
@@ -840,23 +2182,26 @@ func (sk *PrivateKey) SeqNo() SignatureSeqNo {
 // in a separate thread when the previous subtree is consumed.  This is useful
 // when running a server which cannot tolerate a sudden spike in the duration
 // of the Sign() function.
+//
+// Equivalent to SetPrecomputeAhead(1).
 func (sk *PrivateKey) EnableSubTreePrecomputation() {
-	sk.mux.Lock()
-	sk.precomputeNextSubTree = true
-
-	// ensure the next subtree is computed
-	nextSta := SubTreeAddress{
-		Layer: 0,
-		Tree:  (uint64(sk.seqNo) >> sk.ctx.treeHeight) + 1,
-	}
-	_, nextTreeExists := sk.subTreeReady[nextSta]
-	sk.mux.Unlock()
+	sk.SetPrecomputeAhead(1)
+}
 
-	if !nextTreeExists {
+// Keeps the next k subtrees on the lowest layer precomputed.  Zero, the
+// default, disables precomputation.  See EnableSubTreePrecomputation().
+//
+// The subtrees are generated in a separate thread when a subtree is
+// consumed.  Their ancestors on the upper layers are generated with them,
+// so the subtrees of the upper layers are ready well before the signatures
+// roll over to them.  A larger k smooths out the latency when subtrees are
+// generated slower than they are consumed.
+func (sk *PrivateKey) SetPrecomputeAhead(k uint64) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	sk.precomputeAhead = k
+	if k != 0 && !sk.closing {
 		sk.wg.Add(1)
-		go func() {
-			sk.getSubTree(sk.ctx.newScratchPad(), nextSta)
-			sk.wg.Done()
-		}()
+		go sk.precomputeSubTrees(sk.seqNo, k)
 	}
 }
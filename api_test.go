@@ -2,9 +2,14 @@ package xmssmt
 
 import (
 	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"os"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -160,6 +165,27 @@ func TestDeriveSignVerify(t *testing.T) {
 		t.Fatalf("Verifying signature with unmarshaled PublicKeyfailed: %v", err)
 	}
 
+	if !bytes.Equal(pkBytes[4:], append(pk2.Root(), pk2.PubSeed()...)) ||
+		!bytes.Equal(pk2.Root(), sk.Root()) {
+		t.Fatalf("Root() and PubSeed() do not match the public key")
+	}
+	pk2.Root()[0] ^= 1
+	pk2.PubSeed()[0] ^= 1
+	if sigOk, _ = pk2.Verify(sig, msg); !sigOk {
+		t.Fatalf("Root() and PubSeed() do not return copies")
+	}
+
+	if !pk.Equal(pk2) || !sk.PublicKeyEqual(pk2) {
+		t.Fatalf("Unmarshaled PublicKey is not equal")
+	}
+	if pk.Equal(sig) || sk.PublicKeyEqual(nil) {
+		t.Fatalf("PublicKey equals something else")
+	}
+	pk2.root[0] ^= 1
+	if pk.Equal(pk2) || sk.PublicKeyEqual(pk2) {
+		t.Fatalf("PublicKey with different root is equal")
+	}
+
 	if err = sk.Close(); err != nil {
 		t.Fatalf("sk.Close(): %v", err)
 	}
@@ -247,6 +273,15 @@ func TestWotsW256(t *testing.T) {
 	testGenerateSignVerify(Params{SHAKE, 64, 10, 5, 256, RFC}, t)
 }
 
+func TestSHA512_256(t *testing.T) {
+	testGenerateSignVerify(Params{SHA512_256, 16, 10, 5, 16, RFC}, t)
+	testGenerateSignVerify(Params{SHA512_256, 24, 10, 5, 16, NIST}, t)
+	testGenerateSignVerify(Params{SHA512_256, 32, 10, 5, 16, RFC}, t)
+	if _, err := NewContext(Params{SHA512_256, 64, 10, 5, 16, RFC}); err == nil {
+		t.Fatalf("NewContext() should fail for SHA512_256 with N=64")
+	}
+}
+
 func TestPrivateKeyContainer(t *testing.T) {
 	SetLogger(t)
 	defer SetLogger(nil)
@@ -293,3 +328,972 @@ func TestPrivateKeyContainer(t *testing.T) {
 		t.Fatalf("sk2.Close(): %v", err)
 	}
 }
+
+func TestShard(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+
+	var prevEnd SignatureSeqNo
+	for i := uint64(0); i < 3; i++ {
+		start, end, err := ctx.ShardRange(i, 3)
+		if err != nil {
+			t.Fatalf("ShardRange(%d, 3): %v", i, err)
+		}
+		if start != prevEnd || end <= start {
+			t.Fatalf("ShardRange(%d, 3) = [%d, %d)", i, start, end)
+		}
+		prevEnd = end
+	}
+	if uint64(prevEnd) != ctx.p.MaxSignatureSeqNo() {
+		t.Fatalf("Shards do not cover all signatures")
+	}
+
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+
+	if err = sk.Shard(1, 4); err != nil {
+		t.Fatalf("Shard(): %v", err)
+	}
+	start, end := sk.SeqNoRange()
+	if start != 64 || end != 128 {
+		t.Fatalf("SeqNoRange() = [%d, %d)", start, end)
+	}
+
+	msg := []byte("test message")
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if sig.SeqNo() != 64 {
+		t.Fatalf("Signature has seqNo %d instead of 64", sig.SeqNo())
+	}
+	if sk.RemainingSignatures() != 63 {
+		t.Fatalf("RemainingSignatures() = %d", sk.RemainingSignatures())
+	}
+	if sk.UsedFraction() != 1.0/64 {
+		t.Fatalf("UsedFraction() = %f", sk.UsedFraction())
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+
+	sk.DangerousSetSeqNo(127)
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if _, err = sk.Sign(msg); err == nil {
+		t.Fatalf("Sign() outside of range should fail")
+	}
+	if sk.RemainingSignatures() != 0 || sk.UsedFraction() != 1 {
+		t.Fatalf("Range should be exhausted")
+	}
+
+	if err = sk.SetSeqNoRange(0, 64); err == nil {
+		t.Fatalf("SetSeqNoRange() below seqNo should fail")
+	}
+
+	if err = sk.Close(); err != nil {
+		t.Fatalf("sk.Close(): %v", err)
+	}
+}
+
+func TestProgressFunc(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+
+	var finished uint64
+	ctx.SetProgressFunc(func(done, total uint64) {
+		if total != 16 || done == 0 || done > total {
+			t.Errorf("Unexpected progress %d/%d", done, total)
+		}
+		if done == total {
+			finished++
+		}
+	})
+
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	if finished != 2 {
+		t.Fatalf("Expected two finished subtrees; got %d", finished)
+	}
+}
+
+func TestSignHash(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	digest := make([]byte, 16)
+	for i := 0; i < len(digest); i++ {
+		digest[i] = byte(i)
+	}
+	domain := []byte("SHAKE128")
+
+	if _, err = sk.SignHash(digest[:15], domain); err == nil {
+		t.Fatalf("SignHash() should reject a digest of the wrong length")
+	}
+	if _, err = sk.SignHash(digest, make([]byte, 256)); err == nil {
+		t.Fatalf("SignHash() should reject a domain that is too long")
+	}
+
+	sig, err := sk.SignHash(digest, domain)
+	if err != nil {
+		t.Fatalf("SignHash(): %v", err)
+	}
+	if ok, err := pk.VerifyHash(sig, digest, domain); !ok {
+		t.Fatalf("VerifyHash(): %v", err)
+	}
+	if ok, _ := pk.VerifyHash(sig, digest, []byte("SHA-256")); ok {
+		t.Fatalf("VerifyHash() accepted a different domain")
+	}
+	digest[0] ^= 1
+	if ok, _ := pk.VerifyHash(sig, digest, domain); ok {
+		t.Fatalf("VerifyHash() accepted a different digest")
+	}
+}
+
+func TestVerifyWithin(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	if err = sk.DangerousSetSeqNo(20); err != nil {
+		t.Fatalf("DangerousSetSeqNo(): %v", err)
+	}
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.VerifyWithin(sig, msg, 20, 20); !ok {
+		t.Fatalf("VerifyWithin(): %v", err)
+	}
+	if ok, err := pk.VerifyWithin(sig, msg, 0, 100); !ok {
+		t.Fatalf("VerifyWithin(): %v", err)
+	}
+	if ok, _ := pk.VerifyWithin(sig, msg, 21, 100); ok {
+		t.Fatalf("VerifyWithin() accepted a signature before the range")
+	}
+	if ok, _ := pk.VerifyWithin(sig, msg, 0, 19); ok {
+		t.Fatalf("VerifyWithin() accepted a signature after the range")
+	}
+	if ok, _ := pk.VerifyWithin(sig, []byte("other message"), 0, 100); ok {
+		t.Fatalf("VerifyWithin() accepted a different message")
+	}
+}
+
+func TestCacheLimit(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 4, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	// Fill the cache with subtrees we will need later on.
+	for i := uint64(20); i < 25; i++ {
+		_, _, err = sk.getSubTree(ctx.newScratchPad(), SubTreeAddress{0, i})
+		if err != nil {
+			t.Fatalf("getSubTree(): %v", err)
+		}
+	}
+	if sk.CachedSubTrees() <= 4 {
+		t.Fatalf("Expected more than 4 cached subtrees")
+	}
+
+	sk.SetCacheLimit(4)
+	msg := []byte("test message")
+	for i := 0; i < 40; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+		if i >= 4 && sk.CachedSubTrees() > 4 {
+			t.Fatalf("%d subtrees cached, but the limit is 4",
+				sk.CachedSubTrees())
+		}
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 4, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	for _, info := range sk.ListCachedSubTrees() {
+		if !info.InUse || info.FirstSeqNo != 0 {
+			t.Fatalf("Unexpected cached subtree %+v", info)
+		}
+	}
+	if err = sk.DropCachedSubTree(SubTreeAddress{0, 0}); err == nil {
+		t.Fatalf("DropCachedSubTree() dropped a subtree in use")
+	}
+
+	sk.DangerousSetSeqNo(100)
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	infos := sk.ListCachedSubTrees()
+	if len(infos) != 7 || infos[0].Address != (SubTreeAddress{0, 0}) ||
+		infos[0].EndSeqNo != 4 || infos[0].InUse {
+		t.Fatalf("Unexpected cached subtrees %+v", infos)
+	}
+
+	if err = sk.DropCachedSubTree(SubTreeAddress{0, 0}); err != nil {
+		t.Fatalf("DropCachedSubTree(): %v", err)
+	}
+	dropped, err := sk.PruneCache(100)
+	if err != nil {
+		t.Fatalf("PruneCache(): %v", err)
+	}
+	if dropped != 2 || sk.CachedSubTrees() != 4 {
+		t.Fatalf("PruneCache() dropped %d subtrees; %d left", dropped,
+			sk.CachedSubTrees())
+	}
+	for _, info := range sk.ListCachedSubTrees() {
+		if !info.InUse {
+			t.Fatalf("PruneCache() left %+v", info)
+		}
+	}
+}
+
+func TestHighWaterMark(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	msg := []byte("test message")
+	for i := 0; i < 2; i++ {
+		if _, err = sk.Sign(msg); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+	if err = sk.DangerousSetSeqNo(1); err == nil {
+		t.Fatalf("DangerousSetSeqNo() went below the high-water mark")
+	}
+	if err = sk.DangerousSetSeqNo(10); err != nil {
+		t.Fatalf("DangerousSetSeqNo(): %v", err)
+	}
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	sk, _, _, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk.Close()
+	if mark, err := sk.HighWaterMark(); err != nil || mark != 11 {
+		t.Fatalf("HighWaterMark() = %d, %v", mark, err)
+	}
+	if err = sk.DangerousSetSeqNo(5); err == nil {
+		t.Fatalf("DangerousSetSeqNo() went below the high-water mark")
+	}
+	sk.DangerousAllowSeqNoRegression()
+	if err = sk.DangerousSetSeqNo(5); err != nil {
+		t.Fatalf("DangerousSetSeqNo(): %v", err)
+	}
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if mark, err := sk.HighWaterMark(); err != nil || mark != 11 {
+		t.Fatalf("HighWaterMark() = %d, %v after regression", mark, err)
+	}
+
+	// Skipping ahead is stored right away.
+	if err = sk.DangerousSetSeqNo(20); err != nil {
+		t.Fatalf("DangerousSetSeqNo(): %v", err)
+	}
+	if seqNo, _, err := sk.ctr.GetSeqNo(); err != nil || seqNo != 20 {
+		t.Fatalf("GetSeqNo() = %d, %v", seqNo, err)
+	}
+}
+
+func TestPrecompute(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 4, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	// The next 20 signatures require five subtrees on the lowest layer,
+	// two on the next and one on the others.
+	var lastDone, lastTotal uint64
+	err = sk.Precompute(20, func(done, total uint64) {
+		if done != lastDone+1 {
+			t.Fatalf("Progress skipped from %d to %d", lastDone, done)
+		}
+		lastDone, lastTotal = done, total
+	})
+	if err != nil {
+		t.Fatalf("Precompute(): %v", err)
+	}
+	if lastDone != 9 || lastTotal != 9 {
+		t.Fatalf("Progress ended at %d/%d instead of 9/9", lastDone, lastTotal)
+	}
+	for i := uint64(0); i < 5; i++ {
+		if !sk.ctr.HasSubTree(SubTreeAddress{0, i}) {
+			t.Fatalf("Subtree {0 %d} was not precomputed", i)
+		}
+	}
+	if !sk.ctr.HasSubTree(SubTreeAddress{1, 1}) {
+		t.Fatalf("Subtree {1 1} was not precomputed")
+	}
+	if sk.ctr.HasSubTree(SubTreeAddress{0, 5}) {
+		t.Fatalf("Subtree {0 5} should not have been precomputed")
+	}
+
+	msg := []byte("test message")
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+}
+
+func TestPrecomputeAhead(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 4, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	sk.SetPrecomputeAhead(5)
+	sk.wg.Wait()
+	for i := uint64(1); i < 6; i++ {
+		if !sk.ctr.HasSubTree(SubTreeAddress{0, i}) {
+			t.Fatalf("Subtree {0 %d} was not precomputed", i)
+		}
+	}
+	if !sk.ctr.HasSubTree(SubTreeAddress{1, 1}) {
+		t.Fatalf("Subtree {1 1} was not precomputed")
+	}
+
+	// Consuming the first subtree should trigger precomputation of {0 6}.
+	msg := []byte("test message")
+	for i := 0; i < 4; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+	sk.wg.Wait()
+	if !sk.ctr.HasSubTree(SubTreeAddress{0, 6}) {
+		t.Fatalf("Subtree {0 6} was not precomputed")
+	}
+}
+
+// Close() should not wait for all subtrees to be precomputed.
+func TestCloseStopsPrecomputation(t *testing.T) {
+	ctx, err := NewContext(Params{SHAKE, 16, 20, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPairInto(NewMemoryPrivateKeyContainer())
+	if err != nil {
+		t.Fatalf("GenerateKeyPairInto(): %v", err)
+	}
+	sk.SetPrecomputeAhead(1000)
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	if len(sk.subTreeReady) > 100 {
+		t.Fatalf("Close() waited for %d subtrees", len(sk.subTreeReady))
+	}
+}
+
+func TestAutoBorrow(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+
+	if err = sk.SetSeqNoRange(0, 8); err != nil {
+		t.Fatalf("SetSeqNoRange(): %v", err)
+	}
+	sk.SetAutoBorrow(5)
+	msg := []byte("test message")
+	for i := 0; i < 7; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+
+	// We borrowed 5 and then the remaining 3 of the range.
+	if sk.BorrowedSeqNos() != 1 {
+		t.Fatalf("BorrowedSeqNos() = %d, expected 1", sk.BorrowedSeqNos())
+	}
+	seqNo, _, err := sk.ctr.GetSeqNo()
+	if err != nil {
+		t.Fatalf("GetSeqNo(): %v", err)
+	}
+	if seqNo != 8 {
+		t.Fatalf("Container seqNo is %d, expected 8", seqNo)
+	}
+
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	sk, _, lostSigs, err := LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk.Close()
+	if lostSigs != 0 {
+		t.Fatalf("Lost %d signatures", lostSigs)
+	}
+}
+
+func TestSignMany(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	if _, err = sk.Sign([]byte("first")); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+
+	// Crosses the boundary of the subtrees on the lowest layer.
+	msgs := make([][]byte, 40)
+	for i := range msgs {
+		msgs[i] = []byte{byte(i)}
+	}
+	sigs, err := sk.SignMany(msgs)
+	if err != nil {
+		t.Fatalf("SignMany(): %v", err)
+	}
+	for i, sig := range sigs {
+		if sig.SeqNo() != SignatureSeqNo(i+1) {
+			t.Fatalf("Signature %d has seqNo %d", i, sig.SeqNo())
+		}
+		if ok, err := pk.Verify(sig, msgs[i]); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+		if ok, _ := pk.Verify(sig, []byte("other")); ok {
+			t.Fatalf("Verify() accepted signature on other message")
+		}
+	}
+	if sk.SeqNo() != 41 {
+		t.Fatalf("SeqNo() = %d, expected 41", sk.SeqNo())
+	}
+	if sk.UnretiredSeqNos() != 0 {
+		t.Fatalf("UnretiredSeqNos() = %d", sk.UnretiredSeqNos())
+	}
+
+	// Either all or no signatures are created.
+	if err = sk.SetSeqNoRange(41, 50); err != nil {
+		t.Fatalf("SetSeqNoRange(): %v", err)
+	}
+	if _, err = sk.SignMany(msgs); err == nil {
+		t.Fatalf("SignMany() should fail beyond the range")
+	}
+	if sk.SeqNo() != 41 {
+		t.Fatalf("SignMany() used signatures on failure")
+	}
+}
+
+// io.Reader that returns size bytes of a fixed pattern.
+type patternReader struct {
+	left int64
+}
+
+func (r *patternReader) Read(buf []byte) (int, error) {
+	if r.left == 0 {
+		return 0, io.EOF
+	}
+	if int64(len(buf)) > r.left {
+		buf = buf[:r.left]
+	}
+	for i := range buf {
+		buf[i] = byte(r.left - int64(i))
+	}
+	r.left -= int64(len(buf))
+	return len(buf), nil
+}
+
+func TestSignLargeMessage(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	// Signing a large message should not use memory proportional to it.
+	const size = 32 << 20
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	sig, err := sk.SignFrom(&patternReader{size})
+	if err != nil {
+		t.Fatalf("SignFrom(): %v", err)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Fatalf("SignFrom() allocated %d bytes", allocated)
+	}
+	if ok, err := pk.VerifyFrom(sig, &patternReader{size}); !ok {
+		t.Fatalf("VerifyFrom(): %v", err)
+	}
+
+	// Sign using an io.ReaderAt
+	msg := make([]byte, 3*MessageChunkSize+1)
+	io.ReadFull(&patternReader{int64(len(msg))}, msg)
+	sig, err = sk.SignReaderAt(bytes.NewReader(msg), int64(len(msg)))
+	if err != nil {
+		t.Fatalf("SignReaderAt(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	ok, err := pk.VerifyReaderAt(sig, bytes.NewReader(msg), int64(len(msg)))
+	if !ok {
+		t.Fatalf("VerifyReaderAt(): %v", err)
+	}
+	ok, _ = pk.VerifyReaderAt(sig, bytes.NewReader(msg), int64(len(msg)-1))
+	if ok {
+		t.Fatalf("VerifyReaderAt() accepted a truncated message")
+	}
+}
+
+func TestDeriveSeeds(t *testing.T) {
+	params := Params{SHAKE, 16, 8, 2, 16, RFC}
+	master := []byte("master secret")
+	pubSeed, skSeed, skPrf := DeriveSeeds(master, []byte("key 1"), params)
+	if hex.EncodeToString(pubSeed) != "3cd59ca06bd140ea854b3bf5596d305e" ||
+		hex.EncodeToString(skSeed) != "c516b9370befc058a410374d0dcf8e6b" ||
+		hex.EncodeToString(skPrf) != "a8439cdd222cd2ccd1d4e971ac8a4546" {
+		t.Fatalf("DeriveSeeds() returned unexpected seeds")
+	}
+
+	pubSeed2, _, _ := DeriveSeeds(master, []byte("key 2"), params)
+	if bytes.Equal(pubSeed, pubSeed2) {
+		t.Fatalf("Different keyIDs yield the same seeds")
+	}
+	params.FullHeight = 16
+	pubSeed2, _, _ = DeriveSeeds(master, []byte("key 1"), params)
+	if bytes.Equal(pubSeed, pubSeed2) {
+		t.Fatalf("Different params yield the same seeds")
+	}
+}
+
+func TestConcurrentVerify(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPairInto(NewMemoryPrivateKeyContainer())
+	if err != nil {
+		t.Fatalf("GenerateKeyPairInto(): %v", err)
+	}
+	defer sk.Close()
+
+	const count = 16
+	sigs := make([]*Signature, count)
+	for i := 0; i < count; i++ {
+		if sigs[i], err = sk.Sign([]byte{byte(i)}); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+
+	// A PublicKey returned by PublicKey() shares the precomputed hashes.
+	pk2 := sk.PublicKey()
+
+	errs := make(chan Error, 8*count)
+	for g := 0; g < 8; g++ {
+		go func(g int) {
+			thePk := pk
+			if g%2 == 1 {
+				thePk = pk2
+			}
+			for i := 0; i < count; i++ {
+				ok, err := thePk.Verify(sigs[i], []byte{byte(i)})
+				if !ok {
+					errs <- err
+					continue
+				}
+				if ok, _ = thePk.Verify(sigs[i], []byte{byte(i + 1)}); ok {
+					errs <- errorf("Verified signature on wrong message")
+					continue
+				}
+				errs <- nil
+			}
+		}(g)
+	}
+	for i := 0; i < 8*count; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+}
+
+func TestVerifyAllocs(t *testing.T) {
+	for _, params := range []Params{
+		{SHAKE, 32, 8, 2, 16, RFC},
+		{SHA2, 24, 8, 2, 16, NIST},
+		{SHA2, 16, 8, 2, 4, RFC},
+	} {
+		ctx, err := NewContext(params)
+		if err != nil {
+			t.Fatalf("NewContext(): %v", err)
+		}
+		sk, pk, err := ctx.GenerateKeyPairInto(NewMemoryPrivateKeyContainer())
+		if err != nil {
+			t.Fatalf("GenerateKeyPairInto(): %v", err)
+		}
+		msg := []byte("test message")
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		sk.Close()
+
+		buf := ctx.NewVerifyBuffer()
+		if ok, err := pk.VerifyWithBuffer(buf, sig, msg); !ok {
+			t.Fatalf("%s: VerifyWithBuffer(): %v", params, err)
+		}
+		if !raceEnabled {
+			allocs := testing.AllocsPerRun(10, func() {
+				pk.VerifyWithBuffer(buf, sig, msg)
+			})
+			if allocs != 0 {
+				t.Fatalf("%s: VerifyWithBuffer() allocates %v times", params,
+					allocs)
+			}
+			if allocs = testing.AllocsPerRun(10, func() {
+				pk.Verify(sig, msg)
+			}); allocs >= 1 {
+				t.Fatalf("%s: Verify() allocates %v times", params, allocs)
+			}
+		}
+		if ok, _ := pk.VerifyWithBuffer(buf, sig, msg[1:]); ok {
+			t.Fatalf("%s: VerifyWithBuffer() accepts wrong message", params)
+		}
+	}
+}
+
+// Checks that the number of allocations of Sign() and SignFrom() depends
+// neither on the number of subtrees nor on the size of the message, and
+// that the number of bytes allocated does not depend on the latter.
+func TestSignAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("The race detector allocates")
+	}
+	small := []byte("test message")
+	large := make([]byte, 1<<20)
+	var base float64
+	var baseBytes [2]uint64 // per run of Sign() and SignFrom()
+	for _, d := range []uint32{1, 2, 4, 8} {
+		ctx, err := NewContext(Params{SHA2, 16, 8 * d, d, 16, RFC})
+		if err != nil {
+			t.Fatalf("NewContext(): %v", err)
+		}
+		ctx.Threads = 1
+		sk, _, err := ctx.GenerateKeyPairInto(NewMemoryPrivateKeyContainer())
+		if err != nil {
+			t.Fatalf("GenerateKeyPairInto(): %v", err)
+		}
+		if _, err = sk.Sign(small); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		sk.wg.Wait() // for the subtrees generated in the background
+
+		for _, msg := range [][]byte{small, large} {
+			signAllocs := testing.AllocsPerRun(10, func() {
+				sk.Sign(msg)
+			})
+			signFromAllocs := testing.AllocsPerRun(10, func() {
+				sk.SignFrom(bytes.NewReader(msg))
+			})
+			if base == 0 {
+				base = signAllocs
+			}
+			if signAllocs > base+1 || signFromAllocs > base+2 {
+				t.Fatalf("D=%d: Sign() allocates %v and SignFrom() %v "+
+					"times for a message of %d bytes instead of %v",
+					d, signAllocs, signFromAllocs, len(msg), base)
+			}
+
+			signBytes := allocatedBytes(10, func() {
+				sk.Sign(msg)
+			})
+			signFromBytes := allocatedBytes(10, func() {
+				sk.SignFrom(bytes.NewReader(msg))
+			})
+			if len(msg) == len(small) {
+				baseBytes = [2]uint64{signBytes, signFromBytes}
+			} else if signBytes > baseBytes[0]+4096 ||
+				signFromBytes > baseBytes[1]+4096 {
+				t.Fatalf("D=%d: Sign() allocates %d and SignFrom() %d "+
+					"bytes for a message of %d bytes instead of %d and %d",
+					d, signBytes, signFromBytes, len(msg), baseBytes[0],
+					baseBytes[1])
+			}
+		}
+		sk.Close()
+	}
+}
+
+// Returns the average number of bytes allocated by a call to f.
+func allocatedBytes(runs int, f func()) uint64 {
+	var before, after runtime.MemStats
+	f() // warm up, as testing.AllocsPerRun does
+	runtime.ReadMemStats(&before)
+	for i := 0; i < runs; i++ {
+		f()
+	}
+	runtime.ReadMemStats(&after)
+	return (after.TotalAlloc - before.TotalAlloc) / uint64(runs)
+}
+
+func TestCompatibleContexts(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx1 := NewContextFromName("XMSSMT-SHAKE_20/4_256")
+	ctx2, _ := NewContext(ctx1.Params())
+	if err = ctx1.CheckCompatible(ctx2); err != nil {
+		t.Fatalf("CheckCompatible(): %v", err)
+	}
+	ctx3, _ := NewContext(Params{SHAKE, 16, 20, 4, 16, RFC})
+	err = ctx1.CheckCompatible(ctx3)
+	if err == nil || !errors.Is(err, ErrMalformed) ||
+		!strings.Contains(err.Error(), "N is 32 instead of 16") {
+		t.Fatalf("CheckCompatible() = %v", err)
+	}
+
+	sk1, pk1, err := ctx1.GenerateKeyPair(dir + "/key1")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk1.Close()
+	sk3, _, err := ctx3.GenerateKeyPair(dir + "/key3")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk3.Close()
+
+	msg := []byte("test message")
+	sig1, _ := sk1.Sign(msg)
+	sig3, _ := sk3.Sign(msg)
+	pkBuf, _ := pk1.MarshalBinary()
+	sig1Buf, _ := sig1.MarshalBinary()
+	sig3Buf, _ := sig3.MarshalBinary()
+
+	// Both the reference format and the prefixed format are accepted.
+	for _, buf := range [][]byte{sig1.MarshalStandard(), sig1Buf} {
+		if ok, err := Verify(pkBuf, buf, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+
+	// Signatures with other parameters are rejected with a clear error.
+	if _, err = Verify(pkBuf, sig3Buf, msg); err == nil ||
+		!errors.Is(err, ErrMalformed) ||
+		!strings.Contains(err.Error(), "N is 16 instead of 32") {
+		t.Fatalf("Verify() accepted signature with other parameters: %v", err)
+	}
+	if _, err = pk1.Verify(sig3, msg); err == nil ||
+		!strings.Contains(err.Error(), "N is 16 instead of 32") {
+		t.Fatalf("Verify() = %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package xmssmt
+
+// Contains the optional audit log of the signatures created with
+// a private key.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry of the audit log of a private key.  See PrivateKey.EnableAuditLog().
+type AuditEntry struct {
+	SeqNo SignatureSeqNo // signature sequence number used
+	Time  time.Time      // when the signature was created
+
+	// The digest of the message that is signed by the WOTS+ key, which is
+	// the hash of the message together with the randomizer of the
+	// signature, the root of the public key and the sequence number.
+	// See Context.MessageDigest().
+	Digest []byte
+}
+
+func (e AuditEntry) String() string {
+	return fmt.Sprintf("%d %s %x", e.SeqNo,
+		e.Time.UTC().Format(time.RFC3339Nano), e.Digest)
+}
+
+// Optional interface for a PrivateKeyContainer that keeps an append-only
+// audit log of the signatures created.
+type AuditLogger interface {
+	// Appends the entry to the audit log.
+	AppendAuditEntry(entry AuditEntry) Error
+
+	// Returns the entries of the audit log in the order they were appended.
+	AuditEntries() ([]AuditEntry, Error)
+}
+
+// Records every signature created from now on with the private key in the
+// audit log of the container, which has to implement AuditLogger.
+//
+// The audit log records the signature sequence number, the time and the
+// message digest of each signature.  If an entry can't be appended,
+// the signature is not returned.  Signature sequence numbers that are
+// reserved but not used to sign are not recorded.
+func (sk *PrivateKey) EnableAuditLog() Error {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	logger, ok := sk.ctr.(AuditLogger)
+	if !ok {
+		return errorf("Container does not support an audit log")
+	}
+	sk.auditLog = logger
+	return nil
+}
+
+// Returns the entries of the audit log of the container.
+// See EnableAuditLog().
+func (sk *PrivateKey) AuditLog() ([]AuditEntry, Error) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	logger, ok := sk.ctr.(AuditLogger)
+	if !ok {
+		return nil, errorf("Container does not support an audit log")
+	}
+	return logger.AuditEntries()
+}
+
+// Appends an entry to the audit log, if enabled.
+func (sk *PrivateKey) recordAuditEntry(seqNo SignatureSeqNo,
+	digest []byte) Error {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	if sk.auditLog == nil {
+		return nil
+	}
+	entry := AuditEntry{
+		SeqNo:  seqNo,
+		Time:   time.Now(),
+		Digest: append([]byte{}, digest...),
+	}
+	if err := sk.auditLog.AppendAuditEntry(entry); err != nil {
+		return wrapErrorf(err, "Failed to append to audit log")
+	}
+	return nil
+}
+
+// Computes the digest of the message that is signed by the WOTS+ key of
+// the signature, as recorded in the audit log.  Together with the signature
+// and public key, this allows to check which message an entry of the audit
+// log corresponds to.
+func (ctx *Context) MessageDigest(pk *PublicKey, sig *Signature,
+	msg []byte) ([]byte, Error) {
+	if pk.ctx.p != ctx.p || sig.ctx.p != ctx.p {
+		return nil, errorf("Parameters of the public key or signature " +
+			"do not match those of the Context")
+	}
+	pad := ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	digest, err := ctx.hashMessage(pad, nil, bytes.NewReader(msg),
+		sig.drv, pk.root, uint64(sig.seqNo))
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to hash message")
+	}
+	return digest, nil
+}
+
+// Reads the audit log of the private key container at the given path,
+// as created by OpenFSPrivateKeyContainer().  This does not require the
+// lock on the container, so it can be used while the key is in use.
+func ReadFSAuditLog(path string) ([]AuditEntry, Error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, wrapErrorf(err, "Could not find absolute path")
+	}
+	return readFSAuditLog(path + ".audit")
+}
+
+func readFSAuditLog(auditPath string) ([]AuditEntry, Error) {
+	file, err := os.Open(auditPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to open audit log")
+	}
+	defer file.Close()
+
+	var ret []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			return nil, errorf("Audit log: malformed line %d", lineNo)
+		}
+		seqNo, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, wrapErrorf(err,
+				"Audit log: malformed sequence number on line %d", lineNo)
+		}
+		when, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return nil, wrapErrorf(err,
+				"Audit log: malformed time on line %d", lineNo)
+		}
+		digest, err := hex.DecodeString(fields[2])
+		if err != nil {
+			return nil, wrapErrorf(err,
+				"Audit log: malformed digest on line %d", lineNo)
+		}
+		ret = append(ret, AuditEntry{
+			SeqNo:  SignatureSeqNo(seqNo),
+			Time:   when,
+			Digest: digest,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, wrapErrorf(err, "Failed to read audit log")
+	}
+	return ret, nil
+}
+
+// Appends the entry to path/to/key.audit.  The file is opened on first use
+// and synced according to the SyncPolicy.
+func (ctr *fsContainer) AppendAuditEntry(entry AuditEntry) Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
+
+	if ctr.auditFile == nil {
+		file, err := os.OpenFile(ctr.path+".audit",
+			os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return wrapErrorf(err, "Failed to open audit log")
+		}
+		ctr.auditFile = file
+	}
+
+	if _, err := ctr.auditFile.WriteString(entry.String() + "\n"); err != nil {
+		return wrapErrorf(err, "Failed to write audit log")
+	}
+	if ctr.syncPolicy.period == 0 {
+		if err := ctr.auditFile.Sync(); err != nil {
+			return wrapErrorf(err, "Failed to sync audit log")
+		}
+	} else {
+		ctr.auditDirty = true
+	}
+	return nil
+}
+
+func (ctr *fsContainer) AuditEntries() ([]AuditEntry, Error) {
+	return readFSAuditLog(ctr.path + ".audit")
+}
+
+// Syncs the audit log, if there are unsynced entries.
+// Requires ctr.keyMux lock.
+func (ctr *fsContainer) syncAuditLog() Error {
+	if ctr.auditFile == nil || !ctr.auditDirty {
+		return nil
+	}
+	if err := ctr.auditFile.Sync(); err != nil {
+		return wrapErrorf(err, "Failed to sync audit log")
+	}
+	ctr.auditDirty = false
+	return nil
+}
@@ -0,0 +1,107 @@
+package xmssmt
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+
+	// Signatures before the audit log is enabled are not recorded.
+	if _, err = sk.Sign([]byte("unrecorded")); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = sk.EnableAuditLog(); err != nil {
+		t.Fatalf("EnableAuditLog(): %v", err)
+	}
+
+	var msgs [][]byte
+	var sigs []*Signature
+	for i := 0; i < 3; i++ {
+		msg := []byte(fmt.Sprintf("message %d", i))
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		msgs = append(msgs, msg)
+		sigs = append(sigs, sig)
+	}
+	manyMsgs := [][]byte{[]byte("a"), []byte("b")}
+	manySigs, err := sk.SignMany(manyMsgs)
+	if err != nil {
+		t.Fatalf("SignMany(): %v", err)
+	}
+	msgs = append(msgs, manyMsgs...)
+	sigs = append(sigs, manySigs...)
+
+	entries, err := sk.AuditLog()
+	if err != nil {
+		t.Fatalf("AuditLog(): %v", err)
+	}
+	if len(entries) != len(sigs) {
+		t.Fatalf("AuditLog() returned %d entries instead of %d",
+			len(entries), len(sigs))
+	}
+
+	// SignMany() might append its entries in any order.
+	bySeqNo := make(map[SignatureSeqNo]AuditEntry)
+	for _, entry := range entries {
+		bySeqNo[entry.SeqNo] = entry
+	}
+	for i, sig := range sigs {
+		entry, ok := bySeqNo[sig.SeqNo()]
+		if !ok {
+			t.Fatalf("Signature %d is missing from the audit log", sig.SeqNo())
+		}
+		digest, err := ctx.MessageDigest(pk, sig, msgs[i])
+		if err != nil {
+			t.Fatalf("MessageDigest(): %v", err)
+		}
+		if !bytes.Equal(digest, entry.Digest) {
+			t.Fatalf("Audit log has wrong digest for signature %d",
+				sig.SeqNo())
+		}
+		if entry.Time.IsZero() {
+			t.Fatalf("Audit log entry lacks time")
+		}
+	}
+
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	entries2, err := ReadFSAuditLog(dir + "/key")
+	if err != nil {
+		t.Fatalf("ReadFSAuditLog(): %v", err)
+	}
+	if len(entries2) != len(entries) {
+		t.Fatalf("ReadFSAuditLog() returned %d entries instead of %d",
+			len(entries2), len(entries))
+	}
+	for i := range entries {
+		if entries[i].String() != entries2[i].String() {
+			t.Fatalf("ReadFSAuditLog() returned %v instead of %v",
+				entries2[i], entries[i])
+		}
+	}
+}
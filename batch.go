@@ -0,0 +1,257 @@
+package xmssmt
+
+// Contains the hash-then-sign aggregation mode, in which a batch of
+// messages is signed with a single XMSS[MT] signature.
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+)
+
+// Prefix of the batch roots signed by PrivateKey.SignBatch()
+var batchPrefix = []byte("XMSSMT-BATCH")
+
+// Maximum number of messages in a batch.  See PrivateKey.SignBatch().
+const MaxBatchSize = 1 << 24
+
+// Signature on a single message of a batch signed by PrivateKey.SignBatch().
+//
+// It consists of the XMSS[MT] signature on the root of the Merkle tree of
+// the batch, which is the same for all messages in the batch, and the
+// proof that the message is included in the tree.
+type BatchSignature struct {
+	sig   *Signature // signature on the root of the batch
+	nonce []byte     // randomizes the leafs of the batch
+	count uint32     // number of messages in the batch
+	index uint32     // index of the message in the batch
+	path  []byte     // authentication path of the leaf of the message
+}
+
+// Signs the messages using a single signature sequence number.
+//
+// The messages are hashed into the leafs of a Merkle tree and only its root
+// is signed with Sign().  The BatchSignature for each message contains that
+// signature together with the authentication path of the message.  Thus
+// a key can sign many more messages, at the cost of larger signatures: the
+// authentication path adds N bytes for each doubling of the batch size.
+// These signatures can only be checked with PublicKey.VerifyBatch().
+//
+// The leafs are randomized with a fresh nonce, so that whoever supplies the
+// messages can't prepare collisions in advance.
+func (sk *PrivateKey) SignBatch(msgs [][]byte) ([]*BatchSignature, Error) {
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+	if len(msgs) > MaxBatchSize {
+		return nil, errorf("Batch is too large: %d > %d",
+			len(msgs), MaxBatchSize)
+	}
+
+	ctx := sk.ctx
+	pad := ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	nonce := make([]byte, ctx.p.N)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, wrapErrorf(err, "crypto.rand.Read()")
+	}
+
+	// Compute the Merkle tree of the batch.  The unused leafs are left zero.
+	count := uint32(len(msgs))
+	height := batchHeight(count)
+	mt := newMerkleTree(height+1, ctx.p.N)
+	for i, msg := range msgs {
+		ctx.batchLeafInto(pad, nonce, msg, mt.Node(0, uint32(i)))
+	}
+	for h := uint32(0); h < height; h++ {
+		for i := uint32(0); i < 1<<(height-h-1); i++ {
+			ctx.batchNodeInto(pad, nonce, h+1, i, mt.Node(h, 2*i),
+				mt.Node(h, 2*i+1), mt.Node(h+1, i))
+		}
+	}
+
+	sig, err := sk.Sign(ctx.batchMessage(nonce, count, mt.Root()))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*BatchSignature, count)
+	for i := uint32(0); i < count; i++ {
+		ret[i] = &BatchSignature{
+			sig:   sig,
+			nonce: nonce,
+			count: count,
+			index: i,
+			path:  mt.AuthPath(i),
+		}
+	}
+	return ret, nil
+}
+
+// Checks whether bsig is a valid signature on msg as part of a batch
+// signed by PrivateKey.SignBatch().
+func (pk *PublicKey) VerifyBatch(bsig *BatchSignature, msg []byte) (
+	bool, Error) {
+	ctx := pk.ctx
+	if bsig.sig == nil || bsig.sig.ctx == nil || bsig.sig.ctx.p != ctx.p {
+		return false, kindErrorf(ErrMalformed,
+			"Signature is for a different instance")
+	}
+	if bsig.index >= bsig.count || bsig.count > MaxBatchSize {
+		return false, kindErrorf(ErrMalformed,
+			"Message index %d out of range", bsig.index)
+	}
+	height := batchHeight(bsig.count)
+	n := ctx.p.N
+	if uint32(len(bsig.path)) != height*n || uint32(len(bsig.nonce)) != n {
+		return false, kindErrorf(ErrMalformed,
+			"Authentication path has wrong length")
+	}
+
+	pad := ctx.getScratchPad()
+	node := make([]byte, n)
+	ctx.batchLeafInto(*pad, bsig.nonce, msg, node)
+	idx := bsig.index
+	for h := uint32(0); h < height; h++ {
+		sibling := bsig.path[h*n : (h+1)*n]
+		if idx&1 == 0 {
+			ctx.batchNodeInto(*pad, bsig.nonce, h+1, idx>>1, node, sibling,
+				node)
+		} else {
+			ctx.batchNodeInto(*pad, bsig.nonce, h+1, idx>>1, sibling, node,
+				node)
+		}
+		idx >>= 1
+	}
+	ctx.putScratchPad(pad)
+
+	return pk.Verify(bsig.sig, ctx.batchMessage(bsig.nonce, bsig.count, node))
+}
+
+// Returns the height of the Merkle tree of a batch of count messages.
+func batchHeight(count uint32) (height uint32) {
+	for (uint32(1) << height) < count {
+		height++
+	}
+	return
+}
+
+// Computes the leaf 0x00 ‖ nonce ‖ msg of a batch.  out must not
+// alias msg.
+func (ctx *Context) batchLeafInto(pad scratchPad, nonce, msg, out []byte) {
+	buf := make([]byte, 0, 1+len(nonce)+len(msg))
+	buf = append(buf, 0)
+	buf = append(buf, nonce...)
+	buf = append(buf, msg...)
+	ctx.hashInto(pad, buf, out)
+}
+
+// Computes the internal node
+//
+//   H(0x01 ‖ nonce ‖ toByte(height, 4) ‖ toByte(index, 4) ‖ left ‖ right)
+//
+// at the given height and index of a batch.  As in the hash trees of XMSS,
+// binding each node to its position, and to the nonce of the batch, means
+// that a second preimage has to be found for a single node, instead of for
+// any of the nodes of all batches ever signed.  out may alias left or right.
+func (ctx *Context) batchNodeInto(pad scratchPad, nonce []byte,
+	height, index uint32, left, right, out []byte) {
+	buf := make([]byte, 0, 9+3*ctx.p.N)
+	buf = append(buf, 1)
+	buf = append(buf, nonce...)
+	buf = append(buf, encodeUint64(uint64(height), 4)...)
+	buf = append(buf, encodeUint64(uint64(index), 4)...)
+	buf = append(buf, left...)
+	buf = append(buf, right...)
+	ctx.hashInto(pad, buf, out)
+}
+
+// Returns the message signed for a batch:
+//
+//   "XMSSMT-BATCH" ‖ nonce ‖ toByte(count, 4) ‖ root.
+func (ctx *Context) batchMessage(nonce []byte, count uint32,
+	root []byte) []byte {
+	var buf bytes.Buffer
+	buf.Write(batchPrefix)
+	buf.Write(nonce)
+	buf.Write(encodeUint64(uint64(count), 4))
+	buf.Write(root)
+	return buf.Bytes()
+}
+
+// Returns the signature on the root of the batch, which is shared by all
+// messages in the batch.
+func (bsig *BatchSignature) Signature() *Signature {
+	return bsig.sig
+}
+
+// Returns the index of the message in the batch.
+func (bsig *BatchSignature) Index() uint32 {
+	return bsig.index
+}
+
+// Returns the number of messages in the batch.
+func (bsig *BatchSignature) Count() uint32 {
+	return bsig.count
+}
+
+func (bsig BatchSignature) String() string {
+	return fmt.Sprintf("%s batch=%d/%d", bsig.sig, bsig.index, bsig.count)
+}
+
+// Returns representation of the batch signature with parameters:
+//
+//   signature as in Signature.MarshalBinary() ‖ nonce ‖
+//     toByte(count, 4) ‖ toByte(index, 4) ‖ authentication path
+func (bsig *BatchSignature) MarshalBinary() ([]byte, error) {
+	sigBuf, err := bsig.sig.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]byte, 0, len(sigBuf)+len(bsig.nonce)+8+len(bsig.path))
+	ret = append(ret, sigBuf...)
+	ret = append(ret, bsig.nonce...)
+	ret = append(ret, encodeUint64(uint64(bsig.count), 4)...)
+	ret = append(ret, encodeUint64(uint64(bsig.index), 4)...)
+	ret = append(ret, bsig.path...)
+	return ret, nil
+}
+
+// Initializes the BatchSignature as stored by MarshalBinary.
+func (bsig *BatchSignature) UnmarshalBinary(buf []byte) error {
+	var params Params
+	if len(buf) < 4 {
+		return kindErrorf(ErrMalformed, "Batch signature is too short")
+	}
+	if err := params.UnmarshalBinary(buf[:4]); err != nil {
+		return err
+	}
+	ctx, err := NewContext(params)
+	if err != nil {
+		return err
+	}
+	n := ctx.p.N
+	sigLen := 4 + ctx.sigBytes
+	if uint32(len(buf)) < sigLen+n+8 {
+		return kindErrorf(ErrMalformed, "Batch signature is too short")
+	}
+	count := uint32(decodeUint64(buf[sigLen+n : sigLen+n+4]))
+	if count == 0 || count > MaxBatchSize {
+		return kindErrorf(ErrMalformed, "Invalid batch size %d", count)
+	}
+	if uint32(len(buf)) != sigLen+n+8+batchHeight(count)*n {
+		return kindErrorf(ErrMalformed,
+			"Batch signature has wrong length: %d", len(buf))
+	}
+
+	var sig Signature
+	if err := sig.decodeFrom(ctx, buf[4:sigLen]); err != nil {
+		return err
+	}
+	bsig.sig = &sig
+	bsig.nonce = append([]byte{}, buf[sigLen:sigLen+n]...)
+	bsig.count = count
+	bsig.index = uint32(decodeUint64(buf[sigLen+n+4 : sigLen+n+8]))
+	bsig.path = append([]byte{}, buf[sigLen+n+8:]...)
+	return nil
+}
@@ -0,0 +1,96 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSignBatch(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	for _, count := range []int{1, 2, 5, 16, 33} {
+		msgs := make([][]byte, count)
+		for i := range msgs {
+			msgs[i] = []byte{byte(count), byte(i)}
+		}
+		seqNo := sk.SeqNo()
+		bsigs, err := sk.SignBatch(msgs)
+		if err != nil {
+			t.Fatalf("SignBatch(): %v", err)
+		}
+		if sk.SeqNo() != seqNo+1 {
+			t.Fatalf("SignBatch() should use a single signature")
+		}
+		for i, bsig := range bsigs {
+			if bsig.Index() != uint32(i) || bsig.Count() != uint32(count) {
+				t.Fatalf("Wrong index or count: %v", bsig)
+			}
+			if ok, err := pk.VerifyBatch(bsig, msgs[i]); !ok {
+				t.Fatalf("VerifyBatch(): %v", err)
+			}
+			if ok, _ := pk.VerifyBatch(bsig, []byte("other")); ok {
+				t.Fatalf("VerifyBatch() accepted other message")
+			}
+
+			buf, err := bsig.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary(): %v", err)
+			}
+			var bsig2 BatchSignature
+			if err := bsig2.UnmarshalBinary(buf); err != nil {
+				t.Fatalf("UnmarshalBinary(): %v", err)
+			}
+			if ok, err := pk.VerifyBatch(&bsig2, msgs[i]); !ok {
+				t.Fatalf("VerifyBatch() after unmarshaling: %v", err)
+			}
+			if err := bsig2.UnmarshalBinary(buf[:len(buf)-1]); err == nil {
+				t.Fatalf("UnmarshalBinary() accepted truncated signature")
+			}
+		}
+
+		// The proof of one message doesn't work for another.
+		if count > 1 {
+			bsigs[0].index = 1
+			if ok, _ := pk.VerifyBatch(bsigs[0], msgs[1]); ok {
+				t.Fatalf("VerifyBatch() accepted proof for other index")
+			}
+		}
+	}
+
+	// The internal nodes depend on the nonce and their position.
+	pad := ctx.newScratchPad()
+	children := make([]byte, 2*ctx.p.N)
+	nonce := make([]byte, ctx.p.N)
+	var nodes [][]byte
+	for _, pos := range [][3]uint32{{0, 1, 0}, {0, 2, 0}, {0, 1, 1}, {1, 1, 0}} {
+		nonce[0] = byte(pos[0])
+		node := make([]byte, ctx.p.N)
+		ctx.batchNodeInto(pad, nonce, pos[1], pos[2],
+			children[:ctx.p.N], children[ctx.p.N:], node)
+		for _, other := range nodes {
+			if bytes.Equal(node, other) {
+				t.Fatalf("Internal nodes at %v collide", pos)
+			}
+		}
+		nodes = append(nodes, node)
+	}
+}
@@ -0,0 +1,444 @@
+// +build !js,!wasip1
+
+package xmssmt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash"
+	bolt "go.etcd.io/bbolt"
+)
+
+// PrivateKeyContainer backed by a single bbolt database file.
+//
+// Unlike the filesystem container, it does not use mmap directly on the
+// cached subtrees nor does it rely on renames and directory fsyncs, which
+// makes it usable on network filesystems and Windows.  The key record and
+// the subtrees are stored in transactions.
+//
+// The database contains two buckets
+//
+//   key    with the key record: the parameters, private key, signature
+//          seqno, number of borrowed signatures and high-water mark.
+//   cache  with the cached subtrees indexed by their address.
+type boltContainer struct {
+	db *bolt.DB
+
+	params      Params
+	privateKey  []byte
+	seqNo       SignatureSeqNo
+	borrowed    uint32
+	initialized bool
+
+	// Largest seqNo ever stored.  See HighWaterMarker.
+	highWaterMark SignatureSeqNo
+
+	cacheInitialized bool
+	subTrees         map[SubTreeAddress][]byte // subtrees in the cache
+	pending          map[SubTreeAddress][]byte // subtrees not yet written
+	committed        map[SubTreeAddress]bool   // see CommitSubTree
+
+	// Protects pending and committed against CommitSubTree(), which may
+	// be called concurrently with the other methods.
+	pendingMux sync.Mutex
+}
+
+var (
+	boltKeyBucket   = []byte("key")
+	boltCacheBucket = []byte("cache")
+
+	boltParamsKey     = []byte("params")
+	boltPrivateKeyKey = []byte("privateKey")
+	boltSeqNoKey      = []byte("seqNo")
+	boltBorrowedKey   = []byte("borrowed")
+
+	// Added later: databases without it have the seqNo as high-water mark.
+	boltHighWaterMarkKey = []byte("highWaterMark")
+)
+
+// Returns a PrivateKeyContainer backed by a bbolt database at the given path.
+func OpenBoltPrivateKeyContainer(path string) (PrivateKeyContainer, Error) {
+	var ctr boltContainer
+	var err error
+
+	ctr.db, err = bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err == bolt.ErrTimeout {
+		err2 := errorf("%s is locked", path)
+		err2.kind = ErrLocked
+		return nil, err2
+	}
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to open database %s", path)
+	}
+
+	err = ctr.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltKeyBucket)
+		if bucket == nil {
+			return nil
+		}
+		paramsBuf := bucket.Get(boltParamsKey)
+		if paramsBuf == nil {
+			return nil
+		}
+		err := binary.Read(bytes.NewReader(paramsBuf), binary.BigEndian,
+			&ctr.params)
+		if err != nil {
+			return wrapErrorf(err, "Failed to read parameters")
+		}
+		ctr.privateKey = append([]byte{}, bucket.Get(boltPrivateKeyKey)...)
+		if len(ctr.privateKey) != ctr.params.PrivateKeySize() {
+			return errorf("Private key has the wrong length")
+		}
+		seqNoBuf := bucket.Get(boltSeqNoKey)
+		borrowedBuf := bucket.Get(boltBorrowedKey)
+		if len(seqNoBuf) != 8 || len(borrowedBuf) != 4 {
+			return errorf("Key record is malformed")
+		}
+		ctr.seqNo = SignatureSeqNo(binary.BigEndian.Uint64(seqNoBuf))
+		ctr.borrowed = binary.BigEndian.Uint32(borrowedBuf)
+		ctr.highWaterMark = ctr.seqNo
+		if buf := bucket.Get(boltHighWaterMarkKey); buf != nil {
+			if len(buf) != 8 {
+				return errorf("Key record is malformed")
+			}
+			ctr.highWaterMark = SignatureSeqNo(binary.BigEndian.Uint64(buf))
+		}
+		ctr.initialized = true
+
+		cacheBucket := tx.Bucket(boltCacheBucket)
+		if cacheBucket == nil {
+			return nil
+		}
+		ctr.subTrees = make(map[SubTreeAddress][]byte)
+		ctr.pending = make(map[SubTreeAddress][]byte)
+		ctr.committed = make(map[SubTreeAddress]bool)
+		err = cacheBucket.ForEach(func(k, v []byte) error {
+			if len(v) != ctr.params.CachedSubTreeSize() || len(k) != 12 {
+				return nil // ignore
+			}
+			ctr.subTrees[decodeBoltAddress(k)] = append([]byte{}, v...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		ctr.cacheInitialized = true
+		return nil
+	})
+	if err != nil {
+		ctr.db.Close()
+		return nil, wrapErrorf(err, "Failed to read database %s", path)
+	}
+
+	return &ctr, nil
+}
+
+func encodeBoltAddress(address SubTreeAddress) []byte {
+	var buf [12]byte
+	binary.BigEndian.PutUint32(buf[:4], address.Layer)
+	binary.BigEndian.PutUint64(buf[4:], address.Tree)
+	return buf[:]
+}
+
+func decodeBoltAddress(buf []byte) SubTreeAddress {
+	return SubTreeAddress{
+		Layer: binary.BigEndian.Uint32(buf[:4]),
+		Tree:  binary.BigEndian.Uint64(buf[4:]),
+	}
+}
+
+// Runs f in a read-write transaction in which also the finished
+// subtrees that have not been written yet are stored.
+func (ctr *boltContainer) update(f func(tx *bolt.Tx) error) Error {
+	ctr.pendingMux.Lock()
+	defer ctr.pendingMux.Unlock()
+	var written []SubTreeAddress
+	err := ctr.db.Update(func(tx *bolt.Tx) error {
+		written = written[:0]
+		if ctr.cacheInitialized && len(ctr.pending) != 0 {
+			bucket := tx.Bucket(boltCacheBucket)
+			for address, buf := range ctr.pending {
+				// Subtrees end with a checksum.  We only write subtrees
+				// that are committed or whose (unkeyed) checksum is
+				// correct as others are being generated.
+				if !ctr.committed[address] &&
+					binary.BigEndian.Uint64(buf[len(buf)-8:]) !=
+						xxhash.Sum64(buf[:len(buf)-8]) {
+					continue
+				}
+				err := bucket.Put(encodeBoltAddress(address), buf)
+				if err != nil {
+					return err
+				}
+				written = append(written, address)
+			}
+		}
+		if f == nil {
+			return nil
+		}
+		return f(tx)
+	})
+	if err != nil {
+		return wrapErrorf(err, "Transaction failed")
+	}
+	for _, address := range written {
+		delete(ctr.pending, address)
+		delete(ctr.committed, address)
+	}
+	return nil
+}
+
+// Writes the key record in the transaction.
+func (ctr *boltContainer) putKeyRecord(tx *bolt.Tx, seqNo SignatureSeqNo,
+	borrowed uint32) error {
+	bucket, err := tx.CreateBucketIfNotExists(boltKeyBucket)
+	if err != nil {
+		return err
+	}
+	var paramsBuf bytes.Buffer
+	if err = binary.Write(&paramsBuf, binary.BigEndian, &ctr.params); err != nil {
+		return err
+	}
+	highWaterMark := ctr.highWaterMark
+	if seqNo > highWaterMark {
+		highWaterMark = seqNo
+	}
+	var seqNoBuf, highWaterMarkBuf [8]byte
+	var borrowedBuf [4]byte
+	binary.BigEndian.PutUint64(seqNoBuf[:], uint64(seqNo))
+	binary.BigEndian.PutUint32(borrowedBuf[:], borrowed)
+	binary.BigEndian.PutUint64(highWaterMarkBuf[:], uint64(highWaterMark))
+	if err = bucket.Put(boltParamsKey, paramsBuf.Bytes()); err != nil {
+		return err
+	}
+	if err = bucket.Put(boltPrivateKeyKey, ctr.privateKey); err != nil {
+		return err
+	}
+	if err = bucket.Put(boltSeqNoKey, seqNoBuf[:]); err != nil {
+		return err
+	}
+	if err = bucket.Put(boltBorrowedKey, borrowedBuf[:]); err != nil {
+		return err
+	}
+	return bucket.Put(boltHighWaterMarkKey, highWaterMarkBuf[:])
+}
+
+// Raises the high-water mark after the key record with seqNo is written.
+func (ctr *boltContainer) raiseHighWaterMark(seqNo SignatureSeqNo) {
+	if seqNo > ctr.highWaterMark {
+		ctr.highWaterMark = seqNo
+	}
+}
+
+func (ctr *boltContainer) ResetCache() Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	ctr.cacheInitialized = false
+	err := ctr.update(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltCacheBucket) != nil {
+			if err := tx.DeleteBucket(boltCacheBucket); err != nil {
+				return err
+			}
+		}
+		_, err := tx.CreateBucket(boltCacheBucket)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	ctr.subTrees = make(map[SubTreeAddress][]byte)
+	ctr.pending = make(map[SubTreeAddress][]byte)
+	ctr.committed = make(map[SubTreeAddress]bool)
+	ctr.cacheInitialized = true
+	return nil
+}
+
+func (ctr *boltContainer) GetSubTree(address SubTreeAddress) (
+	buf []byte, exists bool, err Error) {
+	if !ctr.cacheInitialized {
+		return nil, false, kindErrorf(ErrNotInitialized,
+			"Cache is not initialized")
+	}
+	if buf, ok := ctr.subTrees[address]; ok {
+		return buf, true, nil
+	}
+	buf = make([]byte, ctr.params.CachedSubTreeSize())
+	ctr.subTrees[address] = buf
+	ctr.pendingMux.Lock()
+	ctr.pending[address] = buf
+	ctr.pendingMux.Unlock()
+	return buf, false, nil
+}
+
+func (ctr *boltContainer) CommitSubTree(address SubTreeAddress) Error {
+	if !ctr.cacheInitialized {
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+	ctr.pendingMux.Lock()
+	_, ok := ctr.pending[address]
+	if ok {
+		ctr.committed[address] = true
+	}
+	ctr.pendingMux.Unlock()
+	if !ok {
+		return nil
+	}
+	return ctr.update(nil)
+}
+
+func (ctr *boltContainer) HasSubTree(address SubTreeAddress) bool {
+	if !ctr.cacheInitialized {
+		return false
+	}
+	_, ok := ctr.subTrees[address]
+	return ok
+}
+
+func (ctr *boltContainer) DropSubTree(address SubTreeAddress) Error {
+	if !ctr.cacheInitialized {
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+	if _, ok := ctr.subTrees[address]; !ok {
+		return nil
+	}
+	delete(ctr.subTrees, address)
+	ctr.pendingMux.Lock()
+	delete(ctr.pending, address)
+	delete(ctr.committed, address)
+	ctr.pendingMux.Unlock()
+	return ctr.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltCacheBucket).Delete(encodeBoltAddress(address))
+	})
+}
+
+func (ctr *boltContainer) ListSubTrees() ([]SubTreeAddress, Error) {
+	if !ctr.cacheInitialized {
+		return nil, kindErrorf(ErrNotInitialized,
+			"Cache is not initialized")
+	}
+	ret := make([]SubTreeAddress, 0, len(ctr.subTrees))
+	for address := range ctr.subTrees {
+		ret = append(ret, address)
+	}
+	return ret, nil
+}
+
+func (ctr *boltContainer) Reset(privateKey []byte, params Params) Error {
+	if ctr.db == nil {
+		return errorf("Container is closed")
+	}
+	ctr.params = params
+	ctr.privateKey = privateKey
+	ctr.highWaterMark = 0
+	ctr.cacheInitialized = false
+	err := ctr.update(func(tx *bolt.Tx) error {
+		return ctr.putKeyRecord(tx, 0, 0)
+	})
+	if err != nil {
+		ctr.initialized = false
+		return err
+	}
+	ctr.seqNo = 0
+	ctr.borrowed = 0
+	ctr.initialized = true
+	return ctr.ResetCache()
+}
+
+func (ctr *boltContainer) BorrowSeqNos(amount uint32) (SignatureSeqNo, Error) {
+	if !ctr.initialized {
+		return 0, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	err := ctr.update(func(tx *bolt.Tx) error {
+		return ctr.putKeyRecord(tx, ctr.seqNo+SignatureSeqNo(amount),
+			ctr.borrowed+amount)
+	})
+	if err != nil {
+		return 0, err
+	}
+	ctr.borrowed += amount
+	ctr.seqNo += SignatureSeqNo(amount)
+	ctr.raiseHighWaterMark(ctr.seqNo)
+	return ctr.seqNo - SignatureSeqNo(amount), nil
+}
+
+func (ctr *boltContainer) SetSeqNo(seqNo SignatureSeqNo) Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	err := ctr.update(func(tx *bolt.Tx) error {
+		return ctr.putKeyRecord(tx, seqNo, 0)
+	})
+	if err != nil {
+		return err
+	}
+	ctr.seqNo = seqNo
+	ctr.borrowed = 0
+	ctr.raiseHighWaterMark(seqNo)
+	return nil
+}
+
+func (ctr *boltContainer) GetSeqNo() (
+	seqNo SignatureSeqNo, lostSigs uint32, err Error) {
+	if !ctr.initialized {
+		err = kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+		return
+	}
+	return ctr.seqNo, ctr.borrowed, nil
+}
+
+func (ctr *boltContainer) HighWaterMark() (SignatureSeqNo, Error) {
+	if !ctr.initialized {
+		return 0, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	return ctr.highWaterMark, nil
+}
+
+func (ctr *boltContainer) GetPrivateKey() ([]byte, Error) {
+	if !ctr.initialized {
+		return nil, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	return ctr.privateKey, nil
+}
+
+func (ctr *boltContainer) Initialized() *Params {
+	if !ctr.initialized {
+		return nil
+	}
+	return &ctr.params
+}
+
+func (ctr *boltContainer) CacheInitialized() bool {
+	return ctr.cacheInitialized
+}
+
+func (ctr *boltContainer) Close() Error {
+	if ctr.db == nil {
+		return nil
+	}
+
+	// Write the remaining subtrees.  If this fails, we will simply
+	// have to regenerate them.
+	var err Error
+	if ctr.cacheInitialized {
+		err = ctr.update(nil)
+	}
+
+	err2 := ctr.db.Close()
+	ctr.db = nil
+	ctr.initialized = false
+	ctr.cacheInitialized = false
+	if err2 != nil {
+		return wrapErrorf(err2, "Failed to close database")
+	}
+	return err
+}
@@ -0,0 +1,147 @@
+// +build !js,!wasip1
+
+package xmssmt
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestBoltPrivateKeyContainer(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+
+	ctr, err := OpenBoltPrivateKeyContainer(dir + "/key.db")
+	if err != nil {
+		t.Fatalf("OpenBoltPrivateKeyContainer: %v", err)
+	}
+	if ctr.Initialized() != nil {
+		t.Fatalf("Container should not be initialized at this point")
+	}
+
+	seed := make([]byte, 16)
+	sk, pk, err := ctx.DeriveInto(ctr, seed, seed, seed)
+	if err != nil {
+		t.Fatalf("DeriveInto(): %v", err)
+	}
+
+	msg := []byte("test message")
+	for i := 0; i < 20; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+	if err = sk.BorrowExactly(5); err != nil {
+		t.Fatalf("BorrowExactly(): %v", err)
+	}
+	cached, err := ctr.ListSubTrees()
+	if err != nil {
+		t.Fatalf("ListSubTrees(): %v", err)
+	}
+
+	// Simulate a crash: don't return the borrowed signatures.
+	if err = ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	ctr, err = OpenBoltPrivateKeyContainer(dir + "/key.db")
+	if err != nil {
+		t.Fatalf("OpenBoltPrivateKeyContainer: %v", err)
+	}
+	if _, err = OpenBoltPrivateKeyContainer(dir + "/key.db"); err == nil ||
+		!err.(Error).Locked() || !errors.Is(err, ErrLocked) {
+		t.Fatalf("Container should be locked")
+	}
+	cached2, err := ctr.ListSubTrees()
+	if err != nil {
+		t.Fatalf("ListSubTrees(): %v", err)
+	}
+	if len(cached) != len(cached2) {
+		t.Fatalf("Cache has %d subtrees instead of %d", len(cached2),
+			len(cached))
+	}
+
+	sk, _, lostSigs, err := LoadPrivateKeyFrom(ctr)
+	if err != nil {
+		t.Fatalf("LoadPrivateKeyFrom(): %v", err)
+	}
+	if sk.SeqNo() != 25 || lostSigs != 5 {
+		t.Fatalf("Key has seqNo %d and %d lost signatures", sk.SeqNo(),
+			lostSigs)
+	}
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+}
+
+func TestKeyedCacheChecksumBolt(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	ctr, err := OpenBoltPrivateKeyContainer(dir + "/key.db")
+	if err != nil {
+		t.Fatalf("OpenBoltPrivateKeyContainer(): %v", err)
+	}
+	seed := make([]byte, 16)
+	sk, _, err := ctx.DeriveInto(ctr, seed, seed, seed)
+	if err != nil {
+		t.Fatalf("DeriveInto(): %v", err)
+	}
+	if err = sk.SetKeyedCacheChecksum(true); err != nil {
+		t.Fatalf("SetKeyedCacheChecksum(): %v", err)
+	}
+	if _, err = sk.Sign([]byte("test message")); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// Subtrees with a keyed checksum should be written as well.
+	ctr, err = OpenBoltPrivateKeyContainer(dir + "/key.db")
+	if err != nil {
+		t.Fatalf("OpenBoltPrivateKeyContainer(): %v", err)
+	}
+	defer ctr.Close()
+	stas, err := ctr.ListSubTrees()
+	if err != nil {
+		t.Fatalf("ListSubTrees(): %v", err)
+	}
+	if len(stas) != 2 {
+		t.Fatalf("Cache contains %d subtrees instead of 2", len(stas))
+	}
+}
@@ -0,0 +1,249 @@
+package xmssmt
+
+// Contains the expiry of borrowed signature sequence numbers, which
+// distinguishes reservations that might still be in use from those
+// that are lost.
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range [Start, Start+Amount) of borrowed signature sequence numbers
+// that were not returned to the container, but might still be in use
+// until Expiry.  See PrivateKey.BorrowWithLease().
+type BorrowLease struct {
+	Start  SignatureSeqNo
+	Amount uint32
+	Expiry time.Time
+}
+
+// Optional interface for a PrivateKeyContainer that records the expiry
+// of borrowed signature sequence numbers.  See PrivateKey.BorrowWithLease().
+type BorrowLeaseStore interface {
+	// Returns the expiry of the currently borrowed signature sequence
+	// numbers (zero if there is none) and the unexpired leases of
+	// signature sequence numbers borrowed before the last load.
+	GetBorrowLeases() (expiry time.Time, pending []BorrowLease, err Error)
+
+	// Durably stores the expiry of the currently borrowed signature
+	// sequence numbers and the pending leases.
+	SetBorrowLeases(expiry time.Time, pending []BorrowLease) Error
+}
+
+// Like BorrowExactly(), but the borrowed signature sequence numbers are
+// leased until ttl from now.  The container has to implement
+// BorrowLeaseStore.
+//
+// If the private key is not properly closed, for instance because the
+// process crashed, the borrowed signature sequence numbers are not lost
+// right away.  Instead, on the next load, they are recorded as a pending
+// lease, see PendingBorrowLeases().  Only when the private key is loaded
+// after the lease expired, they are counted as definitively lost in the
+// lostSigs returned by LoadPrivateKey().  Signature sequence numbers that
+// are borrowed without lease, by BorrowExactly(), are lost immediately.
+//
+// Sequence numbers borrowed later by SetAutoBorrow() fall under the same
+// lease.  BorrowExactly() removes the lease.
+func (sk *PrivateKey) BorrowWithLease(amount uint32, ttl time.Duration) Error {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	store, ok := sk.ctr.(BorrowLeaseStore)
+	if !ok {
+		return errorf("Container does not support borrow leases")
+	}
+	if err := sk.borrowExactly(amount); err != nil {
+		return err
+	}
+	_, pending, err := store.GetBorrowLeases()
+	if err != nil {
+		return err
+	}
+	return store.SetBorrowLeases(time.Now().Add(ttl), pending)
+}
+
+// Returns the leases of borrowed signature sequence numbers that were not
+// returned before the private key was loaded and that have not expired yet.
+// See BorrowWithLease().
+func (sk *PrivateKey) PendingBorrowLeases() []BorrowLease {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	return append([]BorrowLease{}, sk.pendingLeases...)
+}
+
+// Removes the expiry of the currently borrowed signature sequence numbers,
+// if any.  Requires sk.mux lock.
+func (sk *PrivateKey) clearBorrowLease() Error {
+	store, ok := sk.ctr.(BorrowLeaseStore)
+	if !ok {
+		return nil
+	}
+	expiry, pending, err := store.GetBorrowLeases()
+	if err != nil {
+		return err
+	}
+	if expiry.IsZero() {
+		return nil
+	}
+	return store.SetBorrowLeases(time.Time{}, pending)
+}
+
+// Splits the lostSigs signature sequence numbers before seqNo, which were
+// borrowed but not returned, into those that are definitively lost and
+// the leases that have not expired.  Called on load.
+func settleBorrowLeases(store BorrowLeaseStore, seqNo SignatureSeqNo,
+	lostSigs uint32) (lost uint32, pending []BorrowLease, err Error) {
+	expiry, oldPending, err := store.GetBorrowLeases()
+	if err != nil {
+		return 0, nil, err
+	}
+	if expiry.IsZero() && len(oldPending) == 0 {
+		return lostSigs, nil, nil
+	}
+
+	now := time.Now()
+	start := seqNo - SignatureSeqNo(lostSigs)
+	lost = lostSigs
+	for _, lease := range oldPending {
+		// If the container hasn't been used since the last load, the
+		// borrowed signature sequence numbers are already a lease.
+		if lease.Start == start && lease.Amount == lostSigs {
+			lost = 0
+		}
+	}
+	if lost > 0 && now.Before(expiry) {
+		lost = 0
+		pending = append(pending, BorrowLease{
+			Start:  start,
+			Amount: lostSigs,
+			Expiry: expiry,
+		})
+	}
+	for _, lease := range oldPending {
+		if now.Before(lease.Expiry) {
+			pending = append(pending, lease)
+		} else {
+			lost += lease.Amount
+		}
+	}
+
+	// The accounting is recomputed on the next load if this fails, for
+	// instance because the container is read-only.
+	if err2 := store.SetBorrowLeases(time.Time{}, pending); err2 != nil {
+		log.Logf("Failed to update borrow leases: %v", err2)
+	}
+	return lost, pending, nil
+}
+
+// Returns the leases stored at path/to/key.borrows, which has on the first
+// line the expiry of the currently borrowed signature sequence numbers
+// or "-" and on each following line a pending lease.
+func (ctr *fsContainer) GetBorrowLeases() (
+	expiry time.Time, pending []BorrowLease, err Error) {
+	if !ctr.initialized {
+		return time.Time{}, nil, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	file, err2 := os.Open(ctr.path + ".borrows")
+	if os.IsNotExist(err2) {
+		return time.Time{}, nil, nil
+	}
+	if err2 != nil {
+		return time.Time{}, nil, wrapErrorf(err2, "Failed to open leases")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if lineNo == 1 {
+			if len(fields) != 1 {
+				return time.Time{}, nil, errorf("Leases: malformed line 1")
+			}
+			if fields[0] == "-" {
+				continue
+			}
+			expiry, err2 = time.Parse(time.RFC3339Nano, fields[0])
+			if err2 != nil {
+				return time.Time{}, nil, wrapErrorf(err2,
+					"Leases: malformed expiry on line 1")
+			}
+			continue
+		}
+		if len(fields) != 3 {
+			return time.Time{}, nil, errorf(
+				"Leases: malformed line %d", lineNo)
+		}
+		start, err3 := strconv.ParseUint(fields[0], 10, 64)
+		amount, err4 := strconv.ParseUint(fields[1], 10, 32)
+		leaseExpiry, err5 := time.Parse(time.RFC3339Nano, fields[2])
+		if err3 != nil || err4 != nil || err5 != nil {
+			return time.Time{}, nil, errorf(
+				"Leases: malformed line %d", lineNo)
+		}
+		pending = append(pending, BorrowLease{
+			Start:  SignatureSeqNo(start),
+			Amount: uint32(amount),
+			Expiry: leaseExpiry,
+		})
+	}
+	if err2 = scanner.Err(); err2 != nil {
+		return time.Time{}, nil, wrapErrorf(err2, "Failed to read leases")
+	}
+	return expiry, pending, nil
+}
+
+func (ctr *fsContainer) SetBorrowLeases(expiry time.Time,
+	pending []BorrowLease) Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+
+	path := ctr.path + ".borrows"
+	if expiry.IsZero() && len(pending) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return wrapErrorf(err, "Failed to remove leases")
+		}
+		return nil
+	}
+
+	var b strings.Builder
+	if expiry.IsZero() {
+		b.WriteString("-\n")
+	} else {
+		fmt.Fprintf(&b, "%s\n", expiry.UTC().Format(time.RFC3339Nano))
+	}
+	for _, lease := range pending {
+		fmt.Fprintf(&b, "%d %d %s\n", lease.Start, lease.Amount,
+			lease.Expiry.UTC().Format(time.RFC3339Nano))
+	}
+
+	// The leases are written before the signature sequence numbers are
+	// used, so write them out durably.
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, []byte(b.String()), 0600); err != nil {
+		return wrapErrorf(err, "Failed to write leases")
+	}
+	tmpFile, err := os.OpenFile(tmpPath, os.O_WRONLY, 0600)
+	if err != nil {
+		return wrapErrorf(err, "Failed to open leases")
+	}
+	err = tmpFile.Sync()
+	tmpFile.Close()
+	if err != nil {
+		return wrapErrorf(err, "Failed to sync leases")
+	}
+	if err = replaceFile(tmpPath, path); err != nil {
+		return wrapErrorf(err, "Failed to replace leases")
+	}
+	return nil
+}
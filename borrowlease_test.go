@@ -0,0 +1,120 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBorrowWithLease(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	msg := []byte("test message")
+	for i := 0; i < 3; i++ {
+		if _, err = sk.Sign(msg); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+	if err = sk.BorrowWithLease(5, time.Hour); err != nil {
+		t.Fatalf("BorrowWithLease(): %v", err)
+	}
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+
+	// Simulate a crash: don't return the borrowed signatures.
+	if err = sk.ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	sk, _, lostSigs, err := LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if lostSigs != 0 {
+		t.Fatalf("Leased signatures are counted as lost: %d", lostSigs)
+	}
+	pending := sk.PendingBorrowLeases()
+	if len(pending) != 1 || pending[0].Start != 3 || pending[0].Amount != 5 {
+		t.Fatalf("PendingBorrowLeases() returned %v", pending)
+	}
+	if sk.SeqNo() != 8 {
+		t.Fatalf("Leased signatures are reused: seqNo is %d", sk.SeqNo())
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// The lease is still pending on the next load.
+	sk, _, lostSigs, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if lostSigs != 0 || len(sk.PendingBorrowLeases()) != 1 {
+		t.Fatalf("Lease is not pending anymore")
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// Let the lease expire.
+	ctr, err := OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer(): %v", err)
+	}
+	store := ctr.(BorrowLeaseStore)
+	expiry, pending, err := store.GetBorrowLeases()
+	if err != nil {
+		t.Fatalf("GetBorrowLeases(): %v", err)
+	}
+	pending[0].Expiry = time.Now().Add(-time.Minute)
+	if err = store.SetBorrowLeases(expiry, pending); err != nil {
+		t.Fatalf("SetBorrowLeases(): %v", err)
+	}
+	if err = ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	sk, _, lostSigs, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if lostSigs != 5 || len(sk.PendingBorrowLeases()) != 0 {
+		t.Fatalf("Expired lease is not counted as lost: %d", lostSigs)
+	}
+
+	// Borrowing without lease after a crash is lost right away.
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = sk.BorrowExactly(2); err != nil {
+		t.Fatalf("BorrowExactly(): %v", err)
+	}
+	if err = sk.ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	sk, _, lostSigs, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk.Close()
+	if lostSigs != 2 || len(sk.PendingBorrowLeases()) != 0 {
+		t.Fatalf("Borrowed signatures are not lost: %d", lostSigs)
+	}
+}
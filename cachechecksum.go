@@ -0,0 +1,154 @@
+package xmssmt
+
+// Contains the checksums of cached subtrees.
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+
+	"github.com/cespare/xxhash"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Domain separator for the key of the keyed checksum of cached subtrees.
+var cacheChecksumKeyDomain = []byte("XMSSMT-CACHE-CHECKSUM")
+
+// Optional interface for a PrivateKeyContainer that stores whether the
+// cached subtrees have a keyed checksum.  See
+// PrivateKey.SetKeyedCacheChecksum().
+type KeyedChecksumStore interface {
+	// Returns whether the cached subtrees have a keyed checksum.
+	KeyedCacheChecksum() (bool, Error)
+
+	// Stores whether the cached subtrees have a keyed checksum.
+	SetKeyedCacheChecksum(enabled bool) Error
+}
+
+// Enables or disables the keyed checksum of cached subtrees.  It's disabled
+// by default.
+//
+// Every cached subtree ends with a 64-bit checksum, which is checked when
+// the subtree is first used.  By default this is an unkeyed xxhash, which
+// detects corruption, but not tampering: anyone with write access to the
+// cache can replace a subtree, for instance by the one at another address,
+// and fix up its checksum.  Signatures with the wrong authentication paths
+// would be the result.  The keyed checksum is a BLAKE2b MAC of the address
+// and contents of the subtree, with a key derived from the secret key,
+// which can't be forged without it.
+//
+// Caches with the unkeyed checksum are migrated transparently: a subtree
+// with an unkeyed checksum is regenerated when it's first used and stored
+// with the keyed checksum.  The root subtree is checked again right away,
+// which takes as long as generating a subtree if it has not been migrated
+// yet.  Disabling the keyed checksum migrates back in the same way.
+//
+// If the container implements KeyedChecksumStore, as the filesystem
+// container does, the setting is stored and the keyed checksum is enabled
+// again when the private key is loaded.
+func (sk *PrivateKey) SetKeyedCacheChecksum(enabled bool) Error {
+	sk.mux.Lock()
+	if enabled == (sk.checksumKey != nil) {
+		sk.mux.Unlock()
+		return nil
+	}
+	if sk.zeroized {
+		sk.mux.Unlock()
+		return errorf("Private key has been zeroized")
+	}
+	if store, ok := sk.ctr.(KeyedChecksumStore); ok {
+		if err := store.SetKeyedCacheChecksum(enabled); err != nil {
+			sk.mux.Unlock()
+			return wrapErrorf(err, "Failed to store checksum mode")
+		}
+	}
+	if enabled {
+		sk.checksumKey = sk.deriveChecksumKey()
+	} else {
+		zeroize(sk.checksumKey)
+		sk.checksumKey = nil
+	}
+
+	// All cached subtrees have to be checked again.
+	for sta := range sk.subTreeChecked {
+		sk.subTreeChecked[sta] = false
+	}
+	sk.mux.Unlock()
+
+	// Recheck the root.  Its root is the public key, which we got from the
+	// cache as well.
+	pad := sk.ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	mt, _, err := sk.getSubTree(pad, SubTreeAddress{Layer: sk.ctx.p.D - 1})
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(mt.Root(), sk.root) != 1 {
+		return errorf("Root of the cached subtrees does not match the " +
+			"public key: the cache has been tampered with")
+	}
+	return nil
+}
+
+// Returns whether the keyed checksum of cached subtrees is enabled.
+// See SetKeyedCacheChecksum().
+func (sk *PrivateKey) KeyedCacheChecksum() bool {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	return sk.checksumKey != nil
+}
+
+// Derives the key of the keyed checksum from the secret key.
+func (sk *PrivateKey) deriveChecksumKey() []byte {
+	h, _ := blake2b.New256(sk.skPrf)
+	h.Write(cacheChecksumKeyDomain)
+	return h.Sum(nil)
+}
+
+// Returns whether the container stores that the cached subtrees have
+// a keyed checksum.
+func containerKeyedCacheChecksum(ctr PrivateKeyContainer) (bool, Error) {
+	store, ok := ctr.(KeyedChecksumStore)
+	if !ok {
+		return false, nil
+	}
+	enabled, err := store.KeyedCacheChecksum()
+	if err != nil {
+		return false, wrapErrorf(err, "Failed to get checksum mode")
+	}
+	return enabled, nil
+}
+
+// Computes the checksum of the cached subtree at sta with the given
+// contents, without the checksum itself.
+func (sk *PrivateKey) cacheChecksum(sta SubTreeAddress, buf []byte) uint64 {
+	return cacheChecksumWithKey(sk.checksumKey, sta, buf)
+}
+
+// Computes the checksum as cacheChecksum() does with the given key, which
+// is nil for the unkeyed checksum.
+func cacheChecksumWithKey(key []byte, sta SubTreeAddress, buf []byte) uint64 {
+	if key == nil {
+		return xxhash.Sum64(buf)
+	}
+	h, _ := blake2b.New(8, key)
+	var addr [12]byte
+	binary.BigEndian.PutUint32(addr[:4], sta.Layer)
+	binary.BigEndian.PutUint64(addr[4:], sta.Tree)
+	h.Write(addr[:])
+	h.Write(buf)
+	return binary.BigEndian.Uint64(h.Sum(nil))
+}
+
+// Returns whether the cached subtree at sta, which ends with its checksum,
+// is intact.  If not, legacy is set if it has the other kind of checksum.
+func (sk *PrivateKey) checkCachedSubTree(sta SubTreeAddress, buf []byte) (
+	intact, legacy bool) {
+	stored := binary.BigEndian.Uint64(buf[len(buf)-8:])
+	if stored == sk.cacheChecksum(sta, buf[:len(buf)-8]) {
+		return true, false
+	}
+	if sk.checksumKey != nil {
+		return false, stored == xxhash.Sum64(buf[:len(buf)-8])
+	}
+	return false, false
+}
@@ -0,0 +1,111 @@
+package xmssmt
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/cespare/xxhash"
+)
+
+func TestKeyedCacheChecksum(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	msg := []byte("test message")
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// Migrate the cache.
+	sk, _, _, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if err = sk.SetKeyedCacheChecksum(true); err != nil {
+		t.Fatalf("SetKeyedCacheChecksum(): %v", err)
+	}
+	if !sk.KeyedCacheChecksum() {
+		t.Fatalf("KeyedCacheChecksum() should be true")
+	}
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	report, err := sk.Check(2)
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if !report.Ok() || report.CheckedSubTrees != 2 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if len(sk.LoadReport().RepairedSubTrees) != 0 {
+		t.Fatalf("Migrated subtrees are reported as repaired")
+	}
+
+	// Tamper with a subtree and fix up its unkeyed checksum.
+	sta := SubTreeAddress{Layer: 0, Tree: 0}
+	buf, _, err := sk.ctr.GetSubTree(sta)
+	if err != nil {
+		t.Fatalf("GetSubTree(): %v", err)
+	}
+	buf[0] ^= 1
+	binary.BigEndian.PutUint64(buf[len(buf)-8:],
+		xxhash.Sum64(buf[:len(buf)-8]))
+	report, err = sk.Check(2)
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if len(report.CorruptedSubTrees) != 1 ||
+		report.CorruptedSubTrees[0] != sta {
+		t.Fatalf("Check() did not notice the tampered subtree: %+v", report)
+	}
+	sig, err = sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// The keyed checksum is stored in the cache, so it's enabled again.
+	sk, _, _, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk.Close()
+	if !sk.KeyedCacheChecksum() {
+		t.Fatalf("KeyedCacheChecksum() should be true after loading")
+	}
+	report, err = sk.Check(3)
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if !report.Ok() || len(sk.LoadReport().RepairedSubTrees) != 0 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+}
@@ -0,0 +1,105 @@
+package xmssmt
+
+// Contains the caching of only the upper nodes of subtrees, of which the
+// lower nodes are computed again when they are needed.
+
+// Optional interface for a PrivateKeyContainer that can cache only the
+// nodes at a given height and above of each subtree.  See
+// Context.SetLowestCachedHeight().  Only private keys of which all nodes
+// are cached can be stored in a container that does not implement it.
+type CachedHeightStore interface {
+	// Returns the lowest height of the nodes that are cached.
+	LowestCachedHeight() (uint32, Error)
+
+	// Sets the lowest height of the nodes that are cached and resets the
+	// cache.  Afterwards, GetSubTree() returns buffers of size
+	// params.CachedSubTreeSizeFrom(height) instead.  Reset() sets it to 0.
+	SetLowestCachedHeight(height uint32) Error
+}
+
+// Sets the lowest height of the nodes of each subtree that are cached for
+// private keys generated or derived with this Context.  The default, 0,
+// caches all nodes.  Private keys that are loaded use the height stored
+// in their container instead.  See CachedHeightStore.
+//
+// The nodes below the given height are computed again when they are
+// needed for an authentication path: every signature then computes 2^height
+// extra leafs for each subtree on its path, while the cache shrinks by
+// about a factor 2^height.  Thus a small height, such as 4 or 5, is
+// a useful trade-off for devices with little storage.
+func (ctx *Context) SetLowestCachedHeight(height uint32) {
+	ctx.lowestCachedHeight = height
+}
+
+// Returns the lowest height of the nodes of each subtree that are cached.
+// See Context.SetLowestCachedHeight().
+func (sk *PrivateKey) LowestCachedHeight() uint32 {
+	return sk.lowestCachedHeight
+}
+
+// Returns the lowest height of the nodes that are cached by the container.
+func containerLowestCachedHeight(ctx *Context, ctr PrivateKeyContainer) (
+	uint32, Error) {
+	store, ok := ctr.(CachedHeightStore)
+	if !ok {
+		return 0, nil
+	}
+	height, err := store.LowestCachedHeight()
+	if err != nil {
+		return 0, wrapErrorf(err, "Failed to get lowest cached height")
+	}
+	if height > ctx.treeHeight {
+		return 0, kindErrorf(ErrMalformed,
+			"Lowest cached height %d is out of range", height)
+	}
+	return height, nil
+}
+
+// Stores the lowest height of the nodes to cache in the container, which
+// has just been reset.
+func setContainerLowestCachedHeight(ctx *Context,
+	ctr PrivateKeyContainer, height uint32) Error {
+	if height == 0 {
+		return nil
+	}
+	if height > ctx.treeHeight {
+		return errorf("Lowest cached height %d is out of range", height)
+	}
+	store, ok := ctr.(CachedHeightStore)
+	if !ok {
+		return errorf("Container can't cache only the upper nodes")
+	}
+	return store.SetLowestCachedHeight(height)
+}
+
+// Returns the merkleTree of the subtree sta cached in buf, which computes
+// the nodes that are not cached when they are needed.
+func (sk *PrivateKey) cachedMerkleTree(sta SubTreeAddress,
+	buf []byte) merkleTree {
+	mt := merkleTreeFromBuf(buf, sk.ctx.treeHeight+1, sk.ctx.p.N)
+	if sk.lowestCachedHeight == 0 {
+		return mt
+	}
+	mt.lowest = sk.lowestCachedHeight
+	mt.genBlock = func(block uint32, bt merkleTree) {
+		pad := sk.ctx.getScratchPad()
+		defer sk.ctx.putScratchPad(pad)
+		_, ph, release := sk.subTreeHashes(*pad, sta)
+		sk.ctx.genSubTreeBlockInto(*pad, ph, sta, block, bt)
+		release()
+	}
+	return mt
+}
+
+// Writes the authentication path for the given leaf into out for
+// a merkleTree that only stores the nodes at height mt.lowest and above.
+// The nodes below are computed by mt.genBlock.
+func (mt *merkleTree) authPathFromTopInto(leaf uint32, out []byte) {
+	block := newMerkleTree(mt.lowest+1, mt.n)
+	mt.genBlock(leaf>>mt.lowest, block)
+	block.AuthPathInto(leaf&(1<<mt.lowest-1), out[:mt.lowest*mt.n])
+	for height := mt.lowest; height < mt.height-1; height++ {
+		copy(out[height*mt.n:(height+1)*mt.n],
+			mt.Node(height, (leaf>>height)^1))
+	}
+}
@@ -0,0 +1,72 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLowestCachedHeight(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err2 := NewContext(Params{SHA2, 16, 10, 2, 16, RFC})
+	if err2 != nil {
+		t.Fatalf("NewContext(): %v", err2)
+	}
+	ctx.SetLowestCachedHeight(3)
+	sk, pk, err2 := ctx.GenerateKeyPair(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err2)
+	}
+	if sk.LowestCachedHeight() != 3 {
+		t.Fatalf("LowestCachedHeight() is %d instead of 3",
+			sk.LowestCachedHeight())
+	}
+
+	// Sign past the end of the first subtree on the lowest layer.
+	msg := []byte("test message")
+	for i := 0; i < 40; i++ {
+		sig, err2 := sk.Sign(msg)
+		if err2 != nil {
+			t.Fatalf("Sign(): %v", err2)
+		}
+		if ok, err2 := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err2)
+		}
+	}
+	report, err2 := sk.Check(0)
+	if err2 != nil || !report.Ok() {
+		t.Fatalf("Check(): %v %v", report, err2)
+	}
+	sk.Close()
+
+	// The height is stored with the key.
+	sk, _, _, err2 = LoadPrivateKey(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err2)
+	}
+	if sk.LowestCachedHeight() != 3 {
+		t.Fatalf("LowestCachedHeight() is %d after loading",
+			sk.LowestCachedHeight())
+	}
+	sig, err2 := sk.Sign(msg)
+	if err2 != nil {
+		t.Fatalf("Sign(): %v", err2)
+	}
+	if ok, err2 := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err2)
+	}
+	sk.Close()
+
+	ctx.SetLowestCachedHeight(6)
+	if _, _, err2 = ctx.GenerateKeyPair(dir + "/key2"); err2 == nil {
+		t.Fatalf("GenerateKeyPair() accepted a height out of range")
+	}
+}
@@ -0,0 +1,95 @@
+package xmssmt
+
+// Contains PrivateKey.Check(), a self-test of a private key.
+
+import (
+	"crypto/subtle"
+)
+
+// Result of PrivateKey.Check().
+type CheckReport struct {
+	// Whether the root of the public key matches the root computed
+	// from the secret seeds.
+	RootOk bool
+
+	// Number of cached subtrees whose checksums were checked.
+	CheckedSubTrees int
+
+	// Cached subtrees with an invalid checksum.  They are regenerated
+	// when they are needed.
+	CorruptedSubTrees []SubTreeAddress
+
+	// Signature sequence number stored in the container.
+	SeqNo SignatureSeqNo
+
+	// Whether SeqNo is at least the last known sequence number passed
+	// to Check() and the sequence number of the private key.
+	SeqNoOk bool
+}
+
+// Returns whether no problems were found.
+func (r *CheckReport) Ok() bool {
+	return r.RootOk && r.SeqNoOk && len(r.CorruptedSubTrees) == 0
+}
+
+// Checks the consistency of the private key, which is useful as a self-test
+// before a signer is put into use.  Check
+//
+//  1. recomputes the root of the public key from the secret seeds,
+//  2. checks the checksums of all cached subtrees and
+//  3. checks whether the signature sequence number in the container has
+//     not gone back compared to lastSeqNo, which should be the last
+//     sequence number known to be used, eg. SeqNo() at a previous run.
+//
+// Recomputing the root takes as long as generating a single subtree, but
+// only the root is kept in memory.
+// An error is returned only if the checks could not be performed;
+// problems found are returned in the CheckReport.
+func (sk *PrivateKey) Check(lastSeqNo SignatureSeqNo) (*CheckReport, Error) {
+	var report CheckReport
+
+	// Recompute the root
+	pad := sk.ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	mt := newMerkleTreeTop(sk.ctx.treeHeight+1, sk.ctx.treeHeight, sk.ctx.p.N)
+	rootSta := SubTreeAddress{Layer: sk.ctx.p.D - 1}
+	skSeed, ph, release := sk.subTreeHashes(pad, rootSta)
+	sk.ctx.genSubTreeInto(pad, skSeed, ph, rootSta, mt)
+	release()
+	report.RootOk = subtle.ConstantTimeCompare(mt.Root(), sk.root) == 1
+
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+
+	// Check the cached subtrees
+	for sta, ready := range sk.subTreeReady {
+		if !ready {
+			continue // being generated
+		}
+		buf, exists, err := sk.ctr.GetSubTree(sta)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			panic("This should not be possible")
+		}
+		report.CheckedSubTrees++
+		if intact, _ := sk.checkCachedSubTree(sta, buf); intact {
+			sk.subTreeChecked[sta] = true
+			continue
+		}
+		log.Logf("Subtree %v is corrupted", sta)
+		report.CorruptedSubTrees = append(report.CorruptedSubTrees, sta)
+		sk.subTreeChecked[sta] = false // getSubTree() will correct it
+	}
+
+	// Check the sequence number
+	seqNo, _, err := sk.ctr.GetSeqNo()
+	if err != nil {
+		return nil, err
+	}
+	report.SeqNo = seqNo
+	report.SeqNoOk = seqNo >= lastSeqNo && seqNo >= sk.seqNo
+
+	return &report, nil
+}
@@ -0,0 +1,91 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	for i := 0; i < 3; i++ {
+		if _, err = sk.Sign(msg); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+
+	report, err := sk.Check(3)
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if !report.Ok() || report.CheckedSubTrees != 2 || report.SeqNo != 3 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	// The container seqNo went back.
+	report, err = sk.Check(4)
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if report.Ok() || report.SeqNoOk {
+		t.Fatalf("Check() did not notice the seqNo went back")
+	}
+
+	// Corrupt a cached subtree
+	sta := SubTreeAddress{Layer: 0, Tree: 0}
+	buf, _, err := sk.ctr.GetSubTree(sta)
+	if err != nil {
+		t.Fatalf("GetSubTree(): %v", err)
+	}
+	buf[0] ^= 1
+	report, err = sk.Check(3)
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if report.Ok() || len(report.CorruptedSubTrees) != 1 ||
+		report.CorruptedSubTrees[0] != sta {
+		t.Fatalf("Check() did not notice the corrupted subtree: %+v", report)
+	}
+
+	// Signing should correct the subtree.
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	report, err = sk.Check(4)
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if !report.Ok() {
+		t.Fatalf("Subtree was not corrected: %+v", report)
+	}
+
+	// Corrupt the root
+	sk.root[0] ^= 1
+	report, err = sk.Check(4)
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if report.RootOk {
+		t.Fatalf("Check() did not notice the corrupted root")
+	}
+	sk.root[0] ^= 1
+}
@@ -0,0 +1,78 @@
+package xmssmt
+
+// Contains the checkpointing of the generation of a subtree, such that it
+// can be resumed after a crash.
+
+import (
+	"encoding/binary"
+)
+
+// Default for SetSubTreeCheckpointInterval().
+const defaultSubTreeCheckpointInterval = 1 << 14
+
+// Sets the number of leafs of a subtree that are generated between two
+// checkpoints, for containers that implement SubTreeCheckpointer, such as
+// the filesystem container.  After a crash, the generation of the subtree
+// resumes from the last checkpoint.  Passing 0 restores the default of
+// 2^14 leafs.  Has to be called before the Context is used.
+//
+// Only large subtrees, such as those of height 16 or 20, which take
+// minutes to hours to generate, benefit.  Each checkpoint flushes the
+// subtree to storage and the workers wait for each other on it.
+func (ctx *Context) SetSubTreeCheckpointInterval(leafs uint32) {
+	ctx.checkpointInterval = leafs
+}
+
+// Returns the number of leafs between two checkpoints, see
+// SetSubTreeCheckpointInterval().
+func (ctx *Context) subTreeCheckpointInterval() uint32 {
+	if ctx.checkpointInterval == 0 {
+		return defaultSubTreeCheckpointInterval
+	}
+	return ctx.checkpointInterval
+}
+
+// Returns where the checksum of the leafs of a checkpoint is stored in the
+// buffer buf of a subtree: at the start of its WOTS+ signature, which is
+// only written after the subtree has been generated.
+func (sk *PrivateKey) checkpointChecksum(buf []byte) []byte {
+	offset := sk.ctx.p.BareSubTreeSize()
+	return buf[offset : offset+8]
+}
+
+// Records that the first leafs leafs of the subtree sta have been
+// written to buf.
+func (sk *PrivateKey) checkpointSubTree(checkpointer SubTreeCheckpointer,
+	sta SubTreeAddress, buf []byte, leafs uint32) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	binary.BigEndian.PutUint64(sk.checkpointChecksum(buf),
+		sk.cacheChecksum(sta, buf[:leafs*sk.ctx.p.N]))
+	if err := checkpointer.CheckpointSubTree(sta, leafs); err != nil {
+		log.Logf("Failed to checkpoint subtree %v: %v", sta, err)
+	}
+}
+
+// Returns the number of leafs of the subtree sta that have been written
+// to buf before the container was reopened, or 0 if there are none or
+// they can't be trusted.  Assumes sk.mux is held.
+func (sk *PrivateKey) subTreeProgress(checkpointer SubTreeCheckpointer,
+	sta SubTreeAddress, buf []byte) uint32 {
+	leafs, err := checkpointer.SubTreeProgress(sta)
+	if err != nil {
+		log.Logf("Failed to get progress of subtree %v: %v", sta, err)
+		return 0
+	}
+	if leafs == 0 {
+		return 0
+	}
+	if leafs > 1<<sk.ctx.treeHeight || sk.cacheChecksum(sta,
+		buf[:leafs*sk.ctx.p.N]) !=
+		binary.BigEndian.Uint64(sk.checkpointChecksum(buf)) {
+		log.Logf("Checkpoint of subtree %v is corrupted.  Starting over ...",
+			sta)
+		return 0
+	}
+	log.Logf("Resuming subtree %v from leaf %d ...", sta, leafs)
+	return leafs
+}
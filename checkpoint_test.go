@@ -0,0 +1,87 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestSubTreeCheckpoint(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, threads := range []int{1, 0} {
+		for _, corrupt := range []bool{false, true} {
+			ctx, err2 := NewContext(Params{SHA2, 16, 10, 2, 16, RFC})
+			if err2 != nil {
+				t.Fatalf("NewContext(): %v", err2)
+			}
+			ctx.Threads = threads
+			ctx.SetSubTreeCheckpointInterval(8)
+			sk, pk, err2 := ctx.GenerateKeyPair(dir + "/key")
+			if err2 != nil {
+				t.Fatalf("GenerateKeyPair(): %v", err2)
+			}
+
+			// Simulate a crash halfway through the generation of the root
+			// subtree, after its first 16 of 32 leafs have been checkpointed.
+			rootSta := SubTreeAddress{Layer: 1}
+			buf, _, err2 := sk.ctr.GetSubTree(rootSta)
+			if err2 != nil {
+				t.Fatalf("GetSubTree(): %v", err2)
+			}
+			for i := 16 * 16; i < len(buf); i++ {
+				buf[i] = 0
+			}
+			sk.checkpointSubTree(sk.ctr.(SubTreeCheckpointer), rootSta,
+				buf, 16)
+			if corrupt {
+				buf[0] ^= 1
+			}
+			sk.Close()
+
+			var mux sync.Mutex
+			var firstDone uint64 = 1 << 10
+			ctx.SetProgressFunc(func(done, total uint64) {
+				mux.Lock()
+				if done < firstDone {
+					firstDone = done
+				}
+				mux.Unlock()
+			})
+			ctr, err2 := OpenFSPrivateKeyContainer(dir + "/key")
+			if err2 != nil {
+				t.Fatalf("OpenFSPrivateKeyContainer(): %v", err2)
+			}
+			sk, _, _, err2 = loadPrivateKeyFrom(ctx, ctr)
+			if err2 != nil {
+				t.Fatalf("loadPrivateKeyFrom(): %v", err2)
+			}
+			msg := []byte("test message")
+			sig, err2 := sk.Sign(msg)
+			if err2 != nil {
+				t.Fatalf("Sign(): %v", err2)
+			}
+			if ok, err2 := pk.Verify(sig, msg); !ok {
+				t.Fatalf("Verify(): %v", err2)
+			}
+			sk.Close()
+
+			mux.Lock()
+			if !corrupt && firstDone != 16 {
+				t.Fatalf("Generation did not resume at leaf 16")
+			}
+			if corrupt && firstDone >= 16 {
+				t.Fatalf("Generation resumed from a corrupted checkpoint")
+			}
+			mux.Unlock()
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bwesterb/go-xmssmt/agent"
+)
+
+func agentCmd(args []string) {
+	if len(args) == 0 {
+		usage()
+	}
+	switch args[0] {
+	case "serve":
+		agentServe(args[1:])
+	case "sign":
+		agentSign(args[1:])
+	default:
+		usage()
+	}
+}
+
+func agentServe(args []string) {
+	fs := flag.NewFlagSet("agent serve", flag.ExitOnError)
+	borrow := fs.Uint("borrow", 0,
+		"borrow signatures in batches of this size; see SetAutoBorrow")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+
+	sk := loadKey(fs.Arg(1))
+	if *borrow != 0 {
+		sk.SetAutoBorrow(uint32(*borrow))
+	}
+	l, err := agent.Listen(fs.Arg(0))
+	if err != nil {
+		sk.Close()
+		fatalf("%v", err)
+	}
+
+	// Stop serving on SIGINT or SIGTERM, such that the socket is removed
+	// and the borrowed signatures are returned.
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ch
+		l.Close()
+	}()
+
+	agent.Serve(l, sk)
+	if err := sk.Close(); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func agentSign(args []string) {
+	fs := flag.NewFlagSet("agent sign", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	msg, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	c, err := agent.Dial(fs.Arg(0))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	defer c.Close()
+	sig, err := c.Sign(msg)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	buf, err := sig.MarshalBinary()
+	if err != nil {
+		fatalf("%v", err)
+	}
+	os.Stdout.Write(buf)
+}
+
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Result of measuring an operation.
+type measurement struct {
+	ops    int
+	total  time.Duration
+	allocs uint64 // number of allocations
+	bytes  uint64 // number of bytes allocated
+}
+
+// Calls f n times and measures the time and allocations.
+func measure(n int, f func(i int)) measurement {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		f(i)
+	}
+	total := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return measurement{
+		ops:    n,
+		total:  total,
+		allocs: after.Mallocs - before.Mallocs,
+		bytes:  after.TotalAlloc - before.TotalAlloc,
+	}
+}
+
+func (m measurement) String() string {
+	if m.ops == 0 {
+		return "n/a"
+	}
+	perOp := m.total / time.Duration(m.ops)
+	return fmt.Sprintf("%12s/op %10.1f ops/s %8d allocs/op %10d B/op",
+		perOp, float64(m.ops)/m.total.Seconds(),
+		m.allocs/uint64(m.ops), m.bytes/uint64(m.ops))
+}
+
+func bench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	n := fs.Int("n", 100, "number of signatures to create and verify")
+	rollovers := fs.Int("rollovers", 3,
+		"number of signatures that start a new subtree")
+	rates := fs.String("rates", "1,10,100",
+		"signatures per second for which to estimate exhaustion")
+	cpuProfile := fs.String("cpuprofile", "", "write a CPU profile to file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	ctx, err := xmssmt.NewContextFromName2(fs.Arg(0))
+	if err != nil {
+		fatalf("%s: %v", fs.Arg(0), err)
+	}
+	params := ctx.Params()
+
+	var ratesPerSec []float64
+	for _, rate := range strings.Split(*rates, ",") {
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil || r <= 0 {
+			fatalf("Invalid rate: %s", rate)
+		}
+		ratesPerSec = append(ratesPerSec, r)
+	}
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			fatalf("%v", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	fmt.Printf("%s\n", params)
+	fmt.Printf("  signatures      %d\n", params.MaxSignatureSeqNo()+1)
+	fmt.Printf("  signature size  %d B\n", params.SignatureSize())
+	fmt.Println()
+
+	// The key is kept in memory, so we only measure the computation.
+	var sk *xmssmt.PrivateKey
+	var pk *xmssmt.PublicKey
+	keygen := measure(1, func(int) {
+		var err xmssmt.Error
+		sk, pk, err = ctx.GenerateKeyPairInto(
+			xmssmt.NewMemoryPrivateKeyContainer())
+		if err != nil {
+			fatalf("GenerateKeyPairInto(): %v", err)
+		}
+	})
+	defer sk.Close()
+
+	msg := []byte("benchmark message")
+	if uint64(*n) > params.MaxSignatureSeqNo() {
+		*n = int(params.MaxSignatureSeqNo())
+	}
+	sigs := make([]*xmssmt.Signature, *n)
+	sign := measure(*n, func(i int) {
+		var err xmssmt.Error
+		if sigs[i], err = sk.Sign(msg); err != nil {
+			fatalf("Sign(): %v", err)
+		}
+	})
+	verify := measure(*n, func(i int) {
+		if ok, err := pk.Verify(sigs[i], msg); !ok {
+			fatalf("Verify(): %v", err)
+		}
+	})
+
+	// Sign with the first signature sequence number of the next subtrees
+	// on the lowest layer, which have to be generated first.
+	var rollover measurement
+	treeHeight := params.FullHeight / params.D
+	if params.D > 1 {
+		first := uint64(*n)>>treeHeight + 1
+		last := params.MaxSignatureSeqNo() >> treeHeight
+		if first > last {
+			*rollovers = 0
+		} else if first+uint64(*rollovers) > last+1 {
+			*rollovers = int(last + 1 - first)
+		}
+		rollover = measure(*rollovers, func(i int) {
+			seqNo := (first + uint64(i)) << treeHeight
+			err := sk.DangerousSetSeqNo(xmssmt.SignatureSeqNo(seqNo))
+			if err != nil {
+				fatalf("DangerousSetSeqNo(): %v", err)
+			}
+			if _, err := sk.Sign(msg); err != nil {
+				fatalf("Sign(): %v", err)
+			}
+		})
+	}
+
+	fmt.Printf("keygen            %s\n", keygen)
+	fmt.Printf("sign (cached)     %s\n", sign)
+	fmt.Printf("sign (rollover)   %s\n", rollover)
+	fmt.Printf("verify            %s\n", verify)
+	fmt.Println()
+
+	// Estimate when the key is exhausted.
+	total := float64(params.MaxSignatureSeqNo()) + 1
+	for _, rate := range ratesPerSec {
+		fmt.Printf("exhausted after   %s at %g signatures/s",
+			formatDuration(total/rate), rate)
+		if perSec := float64(sign.ops) / sign.total.Seconds(); rate > perSec {
+			fmt.Printf(" (exceeds %.1f signatures/s)", perSec)
+		}
+		fmt.Println()
+	}
+}
+
+// Formats a duration in seconds, which might not fit in a time.Duration.
+func formatDuration(secs float64) string {
+	const year = 365.25 * 24 * 3600
+	switch {
+	case secs >= 1e6*year:
+		return fmt.Sprintf("%.1e years", secs/year)
+	case secs >= year:
+		return fmt.Sprintf("%.1f years", secs/year)
+	case secs >= 24*3600:
+		return fmt.Sprintf("%.1f days", secs/(24*3600))
+	default:
+		return time.Duration(math.Round(secs) * float64(time.Second)).String()
+	}
+}
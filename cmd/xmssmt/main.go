@@ -0,0 +1,203 @@
+// Command xmssmt contains tools for the go-xmssmt package.
+//
+//   xmssmt kat generate [-full] [-seed hex] [-seqno n] [-msg hex] alg...
+//   xmssmt kat check file...
+//   xmssmt bench [-n n] [-rollovers n] [-rates r,...] [-cpuprofile file] alg
+//   xmssmt unlock [-force] key
+//   xmssmt reserve [-yes] key n
+//   xmssmt set-seqno [-force] [-yes] key seqno
+//   xmssmt agent serve [-borrow n] socket key
+//   xmssmt agent sign socket
+//   xmssmt sign [-o sigfile] key file
+//   xmssmt sign [-o sigfile] -agent socket file
+//   xmssmt verify [-sig sigfile] pubkey file
+//   xmssmt pubkey key
+//   xmssmt pubkey -agent socket
+//
+// "kat generate" writes known-answer tests for the given instances to
+// stdout.  Without options the inputs of test/vectors of the reference
+// implementation are used.  "kat check" recomputes the known-answer tests
+// in the given files.  See the internal/kat package for the file format.
+//
+// "bench" measures key generation, signing (within a cached subtree and when
+// a new subtree has to be generated) and verification for the given
+// instance and estimates when a key is exhausted at the given rates.
+//
+// "unlock" shows the status of the lockfile of the private key at the given
+// path and removes it if no running process holds it, for instance after
+// the process that used the key was killed.  With -force the lockfile is
+// removed even if the process with the ID in it is still running.
+//
+// "reserve" skips the next n signature sequence numbers of the private key,
+// for instance to hand them to a copy of the key on a cloned machine image.
+// "set-seqno" sets the signature sequence number of the private key.  It
+// refuses to go below the high-water mark of the key, unless -force is given.
+// Both ask for confirmation, unless -yes is given.
+//
+// "agent serve" keeps the private key open and signs the messages sent to
+// the unix domain socket at the given path until it receives SIGINT or
+// SIGTERM.  See the agent package for the protocol.  "agent sign" signs
+// stdin with the agent and writes the signature to stdout.
+//
+// "sign" signs the file with the private key, or with the agent, and writes
+// a detached signature file, by default to file.sig.  See
+// xmssmt.WriteSignatureFile() for the format.  "verify" checks such
+// a signature with the public key in the file pubkey, which is written by
+// "pubkey".
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bwesterb/go-xmssmt/internal/kat"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage:\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt kat generate [-full] [-seed hex] "+
+		"[-seqno n] [-msg hex] alg...\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt kat check file...\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt bench [-n n] [-rollovers n] "+
+		"[-rates r,...] [-cpuprofile file] alg\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt unlock [-force] key\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt reserve [-yes] key n\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt set-seqno [-force] [-yes] key seqno\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt agent serve [-borrow n] socket key\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt agent sign socket\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt sign [-o sigfile] key file\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt sign [-o sigfile] -agent socket file\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt verify [-sig sigfile] pubkey file\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt pubkey key\n")
+	fmt.Fprintf(os.Stderr, "  xmssmt pubkey -agent socket\n")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) >= 2 && os.Args[1] == "bench" {
+		bench(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "unlock" {
+		unlock(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "reserve" {
+		reserve(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "set-seqno" {
+		setSeqNo(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "agent" {
+		agentCmd(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "sign" {
+		sign(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "verify" {
+		verify(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "pubkey" {
+		pubkey(os.Args[2:])
+		return
+	}
+	if len(os.Args) < 3 || os.Args[1] != "kat" {
+		usage()
+	}
+	switch os.Args[2] {
+	case "generate":
+		katGenerate(os.Args[3:])
+	case "check":
+		katCheck(os.Args[3:])
+	default:
+		usage()
+	}
+}
+
+func katGenerate(args []string) {
+	fs := flag.NewFlagSet("kat generate", flag.ExitOnError)
+	full := fs.Bool("full", false, "write full public keys and signatures")
+	seedHex := fs.String("seed", "", "seed in hex; default 0, 1, ..., 3N-1")
+	seqNo := fs.Int64("seqno", -1,
+		"signature sequence number; default 2^(FullHeight-1)")
+	msgHex := fs.String("msg", "25", "message in hex")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		usage()
+	}
+
+	seed, err := hex.DecodeString(*seedHex)
+	if err != nil {
+		fatalf("Invalid seed: %v", err)
+	}
+	msg, err := hex.DecodeString(*msgHex)
+	if err != nil {
+		fatalf("Invalid message: %v", err)
+	}
+
+	var vs []*kat.Vector
+	for _, alg := range fs.Args() {
+		// Start with the inputs of the reference implementation and
+		// override those that are given.
+		v, err := kat.ReferenceInputs(alg)
+		if err != nil {
+			fatalf("%s: %v", alg, err)
+		}
+		if *seedHex != "" {
+			v.Seed = seed
+		}
+		if *seqNo >= 0 {
+			v.SeqNo = uint64(*seqNo)
+		}
+		v, err = kat.Generate(alg, v.Seed, v.SeqNo, msg)
+		if err != nil {
+			fatalf("%s: %v", alg, err)
+		}
+		vs = append(vs, v)
+	}
+
+	if err := kat.Write(os.Stdout, vs, *full); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func katCheck(args []string) {
+	if len(args) == 0 {
+		usage()
+	}
+	failed := 0
+	for _, path := range args {
+		f, err := os.Open(path)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		vs, err := kat.Read(f)
+		f.Close()
+		if err != nil {
+			fatalf("%s: %v", path, err)
+		}
+		for _, v := range vs {
+			if err := v.Check(); err != nil {
+				fmt.Printf("FAIL %s\n", err)
+				failed++
+				continue
+			}
+			fmt.Printf("ok   %s\n", v.Alg)
+		}
+	}
+	if failed != 0 {
+		os.Exit(1)
+	}
+}
+
+func fatalf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	os.Exit(1)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+func reserve(args []string) {
+	fs := flag.NewFlagSet("reserve", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+	path := fs.Arg(0)
+	n, err := strconv.ParseUint(fs.Arg(1), 10, 32)
+	if err != nil || n == 0 {
+		fatalf("Invalid number of signatures: %s", fs.Arg(1))
+	}
+
+	// Signature sequence numbers below the high-water mark might have been
+	// handed out before, so start above it.
+	sk := loadKey(path)
+	start := sk.SeqNo()
+	if mark, err := sk.HighWaterMark(); err == nil && mark > start {
+		start = mark
+	}
+	params := sk.Context().Params()
+	if uint64(start)+n > params.MaxSignatureSeqNo() {
+		sk.Close()
+		fatalf("Not enough signatures left")
+	}
+	end := start + xmssmt.SignatureSeqNo(n)
+	if !confirm(*yes, fmt.Sprintf("Reserve signature sequence numbers "+
+		"[%d, %d) of %s?  The key will continue at %d.", start, end,
+		path, end)) {
+		sk.Close()
+		fatalf("Aborted")
+	}
+	if err := sk.DangerousSetSeqNo(end); err != nil {
+		sk.Close()
+		fatalf("%v", err)
+	}
+	if err := sk.Close(); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Reserved [%d, %d)\n", start, end)
+}
+
+func setSeqNo(args []string) {
+	fs := flag.NewFlagSet("set-seqno", flag.ExitOnError)
+	force := fs.Bool("force", false,
+		"allow setting the sequence number below the high-water mark")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+	path := fs.Arg(0)
+	seqNo, err := strconv.ParseUint(fs.Arg(1), 10, 64)
+	if err != nil {
+		fatalf("Invalid signature sequence number: %s", fs.Arg(1))
+	}
+
+	sk := loadKey(path)
+	mark, err2 := sk.HighWaterMark()
+	if err2 != nil {
+		sk.Close()
+		fatalf("%v", err2)
+	}
+	msg := fmt.Sprintf("Set the signature sequence number of %s from %d "+
+		"to %d?", path, sk.SeqNo(), seqNo)
+	if xmssmt.SignatureSeqNo(seqNo) < mark {
+		if !*force {
+			sk.Close()
+			fatalf("%d is below the high-water mark %d; use -force to set "+
+				"it anyway.  Signatures might be reused.", seqNo, mark)
+		}
+		msg += fmt.Sprintf("  This is below the high-water mark %d: "+
+			"signatures might be reused, which breaks the key.", mark)
+		sk.DangerousAllowSeqNoRegression()
+	}
+	if !confirm(*yes, msg) {
+		sk.Close()
+		fatalf("Aborted")
+	}
+	if err := sk.DangerousSetSeqNo(xmssmt.SignatureSeqNo(seqNo)); err != nil {
+		sk.Close()
+		fatalf("%v", err)
+	}
+	if err := sk.Close(); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Signature sequence number set to %d\n", seqNo)
+}
+
+func loadKey(path string) *xmssmt.PrivateKey {
+	sk, _, lostSigs, err := xmssmt.LoadPrivateKey(path)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if lostSigs != 0 {
+		fmt.Fprintf(os.Stderr, "%d signatures were lost\n", lostSigs)
+	}
+	return sk
+}
+
+// Asks the user to confirm msg, unless yes is set.
+func confirm(yes bool, msg string) bool {
+	if yes {
+		return true
+	}
+	fmt.Printf("%s [y/N] ", msg)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
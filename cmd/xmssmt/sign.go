@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+	"github.com/bwesterb/go-xmssmt/agent"
+)
+
+func sign(args []string) {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	agentPath := fs.String("agent", "",
+		"sign with the agent on this socket instead of with key")
+	out := fs.String("o", "", "signature file; default file.sig")
+	fs.Parse(args)
+	if (*agentPath == "" && fs.NArg() != 2) ||
+		(*agentPath != "" && fs.NArg() != 1) {
+		usage()
+	}
+	path := fs.Arg(fs.NArg() - 1)
+	if *out == "" {
+		*out = path + ".sig"
+	}
+	msg, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	// Create the signature file first, so that we don't waste a signature
+	// if it can't be written.
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	fail := func(err error) {
+		f.Close()
+		os.Remove(*out)
+		fatalf("%v", err)
+	}
+
+	var pk *xmssmt.PublicKey
+	var sig *xmssmt.Signature
+	if *agentPath != "" {
+		c, err := agent.Dial(*agentPath)
+		if err != nil {
+			fail(err)
+		}
+		pk, err = c.PublicKey()
+		if err == nil {
+			sig, err = c.Sign(msg)
+		}
+		c.Close()
+		if err != nil {
+			fail(err)
+		}
+	} else {
+		sk := loadKey(fs.Arg(0))
+		pk = sk.PublicKey()
+		sig, err = sk.Sign(msg)
+		if err2 := sk.Close(); err == nil {
+			err = err2
+		}
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	if err := xmssmt.WriteSignatureFile(f, pk, sig); err != nil {
+		fail(err)
+	}
+	if err := f.Close(); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func verify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	sigPath := fs.String("sig", "", "signature file; default file.sig")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+	path := fs.Arg(1)
+	if *sigPath == "" {
+		*sigPath = path + ".sig"
+	}
+
+	pkBuf, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	var pk xmssmt.PublicKey
+	if err := json.Unmarshal(pkBuf, &pk); err != nil {
+		fatalf("Failed to parse public key: %v", err)
+	}
+	msg, err := ioutil.ReadFile(path)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	f, err := os.Open(*sigPath)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	file, err2 := xmssmt.ReadSignatureFile(f)
+	f.Close()
+	if err2 != nil {
+		fatalf("%s: %v", *sigPath, err2)
+	}
+	if ok, err := pk.VerifySignatureFile(file, msg); !ok {
+		fatalf("Invalid signature: %v", err)
+	}
+	fmt.Printf("Valid signature by %s with sequence number %d\n",
+		file.KeyID, file.Signature.SeqNo())
+}
+
+func pubkey(args []string) {
+	fs := flag.NewFlagSet("pubkey", flag.ExitOnError)
+	agentPath := fs.String("agent", "",
+		"use the agent on this socket instead of key")
+	fs.Parse(args)
+	if (*agentPath == "") != (fs.NArg() == 1) {
+		usage()
+	}
+
+	var pk *xmssmt.PublicKey
+	if *agentPath != "" {
+		c, err := agent.Dial(*agentPath)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		pk, err = c.PublicKey()
+		c.Close()
+		if err != nil {
+			fatalf("%v", err)
+		}
+	} else {
+		sk := loadKey(fs.Arg(0))
+		pk = sk.PublicKey()
+		sk.Close()
+	}
+	buf, err := json.Marshal(pk)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("%s\n", buf)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+func unlock(args []string) {
+	fs := flag.NewFlagSet("unlock", flag.ExitOnError)
+	force := fs.Bool("force", false,
+		"remove the lockfile even if its process is still running")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+	path := fs.Arg(0)
+
+	status, err := xmssmt.InspectLock(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if !status.Locked {
+		fmt.Printf("%s is not locked\n", path)
+		return
+	}
+	fmt.Printf("%s.lock: pid %d (%s), %s, age %s\n", path, status.Pid,
+		map[bool]string{true: "running", false: "not running"}[status.OwnerAlive],
+		map[bool]string{true: "held", false: "not held"}[status.Held],
+		status.Age)
+
+	if !status.Stale() && !*force {
+		fmt.Fprintf(os.Stderr, "Lock is not stale; use -force to remove "+
+			"it anyway.  Make sure no process uses the key.\n")
+		os.Exit(1)
+	}
+	if err = xmssmt.ForceUnlock(path); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Removed %s.lock\n", path)
+}
@@ -0,0 +1,94 @@
+// Command xmssmtd is a signer daemon: it owns an XMSS[MT] private key and
+// signs for application servers over the protocol of the signer package.
+//
+//   xmssmtd -key path -token-file path [-listen addr] [-tls-cert path
+//           -tls-key path] [-borrow n] [-precompute-ahead k]
+//
+// The token file contains the token clients have to present.
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+	"github.com/bwesterb/go-xmssmt/signer"
+)
+
+func main() {
+	keyPath := flag.String("key", "", "path to the private key")
+	tokenPath := flag.String("token-file", "", "path to the client token")
+	listen := flag.String("listen", "localhost:8443", "address to listen on")
+	tlsCert := flag.String("tls-cert", "", "path to TLS certificate")
+	tlsKey := flag.String("tls-key", "", "path to TLS private key")
+	borrow := flag.Uint("borrow", 100,
+		"number of sequence numbers to borrow from the container at once")
+	ahead := flag.Uint64("precompute-ahead", 1,
+		"number of subtrees to precompute ahead")
+	flag.Parse()
+
+	if *keyPath == "" || *tokenPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	tokenBuf, err := ioutil.ReadFile(*tokenPath)
+	if err != nil {
+		log.Fatalf("Reading token: %v", err)
+	}
+	token := strings.TrimSpace(string(tokenBuf))
+	if token == "" {
+		log.Fatalf("Token file is empty")
+	}
+
+	sk, _, lostSigs, err2 := xmssmt.LoadPrivateKey(*keyPath)
+	if err2 != nil {
+		log.Fatalf("Loading private key: %v", err2)
+	}
+	if lostSigs != 0 {
+		log.Printf("%d signatures were lost at the last shutdown", lostSigs)
+	}
+	sk.SetAutoBorrow(uint32(*borrow))
+	sk.SetPrecomputeAhead(*ahead)
+
+	server := &http.Server{
+		Addr:    *listen,
+		Handler: signer.NewHandler(sk, token),
+	}
+
+	// Close the private key on shutdown, so borrowed sequence numbers are
+	// returned to the container.
+	done := make(chan struct{})
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		<-sigs
+
+		// Wait for the signatures in flight, which use the private key.
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Printf("Shutting down: %v", err)
+		}
+		if err := sk.Close(); err != nil {
+			log.Printf("Closing private key: %v", err)
+		}
+		close(done)
+	}()
+
+	log.Printf("Listening on %s", *listen)
+	if *tlsCert != "" {
+		err = server.ListenAndServeTLS(*tlsCert, *tlsKey)
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		log.Fatalf("Serving: %v", err)
+	}
+	<-done
+}
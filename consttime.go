@@ -0,0 +1,94 @@
+package xmssmt
+
+// Contains the constant-time signing mode.
+
+import (
+	"crypto/subtle"
+)
+
+// Enables or disables the constant-time signing mode.  It's disabled
+// by default.
+//
+// When creating a WOTS+ signature, the chains are computed up to the
+// digits of the message digest.  Normally, the computation stops early
+// at those digits, and the vectorized implementation groups the chains by
+// their length.  Hence the time and memory access pattern of signing
+// depend on the digest.
+//
+// Threat model.  The digest is derived from the message and the secret
+// randomizer R = PRF(SK_PRF, idx).  Both are public once the signature is
+// published, so in the usual setting nothing secret leaks.  However, an
+// attacker that observes signing through a side channel, such as a
+// co-located VM, could learn the digest before, or without, the signature
+// being released, for instance if the signature is discarded or only sent
+// to a third party.  In constant-time mode every chain is computed in full
+// in a fixed order, just as when generating the WOTS+ public key, and the
+// value at the digit is selected with constant-time copies.  Thus the
+// sequence of hashes and memory accesses is independent of the message.
+// This roughly doubles the time needed to create the WOTS+ signature.
+//
+// The computation of the digest itself (SHA2 or SHAKE) and the generation
+// of subtrees do not depend on secret-dependent branches.  Verification
+// only processes public data and is not affected.
+//
+// Has to be called before the Context is used.
+func (ctx *Context) SetConstantTime(enabled bool) {
+	ctx.constantTime = enabled
+}
+
+// Returns whether the constant-time signing mode is enabled.
+// See SetConstantTime().
+func (ctx *Context) ConstantTime() bool {
+	return ctx.constantTime
+}
+
+// Computes the WOTS+ signature with the given chain lengths in a way that
+// doesn't depend on the lengths.  wotsSig should contain the WOTS+ secret
+// key and is overwritten by the signature.
+func (ctx *Context) wotsSignConstantTimeInto(pad scratchPad, lengths []uint8,
+	ph precomputedHashes, addr address, wotsSig []byte) {
+	n := ctx.p.N
+	lanes := ctx.lanes
+	if lanes == 0 {
+		lanes = 1
+	}
+	cur := make([]byte, lanes*n)
+
+	var addrs [8]address
+	for j := 0; j < 8; j++ {
+		addrs[j] = addr
+	}
+	for i := uint32(0); i < ctx.wotsLen; i += lanes {
+		// Start with the secret keys of this group of chains.
+		var bufs [8][]byte
+		for j := uint32(0); j < lanes && i+j < ctx.wotsLen; j++ {
+			addrs[j].setChain(i + j)
+			bufs[j] = cur[n*j : n*(j+1)]
+			copy(bufs[j], wotsSig[n*(i+j):n*(i+j+1)])
+		}
+
+		for k := uint16(0); ; k++ {
+			// Select the current value of each chain whose length is k.
+			for j := uint32(0); j < lanes && i+j < ctx.wotsLen; j++ {
+				subtle.ConstantTimeCopy(
+					subtle.ConstantTimeEq(int32(k), int32(lengths[i+j])),
+					wotsSig[n*(i+j):n*(i+j+1)],
+					bufs[j])
+			}
+			if k == ctx.p.WotsW-1 {
+				break
+			}
+
+			if ctx.lanes == 0 {
+				addrs[0].setHash(uint32(k))
+				ctx.fInto(pad, bufs[0], ph, addrs[0], bufs[0])
+				continue
+			}
+			for j := uint32(0); j < lanes; j++ {
+				addrs[j].setHash(uint32(k))
+			}
+			ctx.fXNInto(pad, bufs, ph, addrs, bufs)
+		}
+	}
+	zeroize(cur)
+}
@@ -7,11 +7,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/bwesterb/byteswriter"
-	"github.com/edsrzf/mmap-go"
 	"github.com/hashicorp/go-multierror"
-	"github.com/nightlyone/lockfile"
 )
 
 // A PrivateKeyContainer has two tasks
@@ -20,7 +21,14 @@ import (
 //     unused signature.
 //  2. It has to cache the precomputed subtrees to increase signing performance.
 //
+// These two tasks can also be implemented separately by a SeqNoStore
+// and a SubTreeCache, which are combined by NewSplitPrivateKeyContainer().
+//
 // NOTE A PrivateKeyContainer does not have to be thread safe.
+//
+// A PrivateKeyContainer may also implement SubTreeCommitter,
+// SubTreeCheckpointer, CacheCompacter, SyncPolicySetter, HighWaterMarker,
+// KeyStructureStore, RootStore and CachedHeightStore.
 type PrivateKeyContainer interface {
 	// Reset (or initialize) the cache that stores the subtrees.  It is always
 	// called before use.
@@ -28,7 +36,8 @@ type PrivateKeyContainer interface {
 
 	// Returns the buffer for the given subtree.  If the subtree does not
 	// have a buffer yet, allocate it of the size params.CachedSubTreeSize()
-	// with params as specified in the last call to Reset().
+	// with params as specified in the last call to Reset(), or see
+	// CachedHeightStore.
 	// The exists return value indicates whether the subtree was present.
 	// The container should write changes to buf back to the storage.
 	// The containe does not have to ensure integrity, a checksum is added
@@ -83,9 +92,48 @@ type PrivateKeyContainer interface {
 	Close() Error
 }
 
-type mmapedSubTree struct {
-	mmap mmap.MMap
-	buf  []byte
+// Optional interface for a PrivateKeyContainer (or SubTreeCache) that can
+// reclaim the space of dropped subtrees.
+type CacheCompacter interface {
+	// Reclaims the space of dropped subtrees.  Invalidates the buffers
+	// returned by GetSubTree().
+	Compact() Error
+}
+
+// Optional interface for a PrivateKeyContainer (or SubTreeCache) to learn
+// when a subtree returned by GetSubTree() has been written completely.
+// Until then, the container may discard the subtree on recovery from
+// a crash, instead of keeping a corrupted subtree that has to be
+// regenerated later on.
+//
+// The cache is disposable, so failures of CommitSubTree() are only logged,
+// as are those of CheckpointSubTree() and of Compact() on Close().
+type SubTreeCommitter interface {
+	// Called when the subtree has been written completely.  Should persist
+	// the subtree before it records that the subtree is complete.
+	//
+	// NOTE As it's called without holding the lock of the PrivateKey, it
+	//      may run concurrently with the other methods of the container.
+	CommitSubTree(address SubTreeAddress) Error
+}
+
+// Optional interface for a PrivateKeyContainer (or SubTreeCache) that can
+// keep a partially generated subtree across a restart, such that the
+// generation of a large subtree resumes where it left off after a crash.
+// See Context.SetSubTreeCheckpointInterval().
+//
+// The last 8 bytes of the buffer of a subtree, which hold its checksum once
+// it is complete, are unused before and may be used to store the progress.
+type SubTreeCheckpointer interface {
+	// Called when the first leafs leafs of the subtree returned by
+	// GetSubTree() have been written.  Should persist them before it
+	// records the progress.
+	CheckpointSubTree(address SubTreeAddress, leafs uint32) Error
+
+	// Returns the number of leafs recorded by CheckpointSubTree() before
+	// the container was reopened, for a subtree for which GetSubTree()
+	// returned exists=false, or 0 if there are none.
+	SubTreeProgress(address SubTreeAddress) (uint32, Error)
 }
 
 // PrivateKeyContainer backed by three files:
@@ -93,13 +141,21 @@ type mmapedSubTree struct {
 //   path/to/key        contains the secret key and signature sequence number
 //   path/to/key.lock   a lockfile
 //   path/to/key.cache  cached subtrees
+//
+// and optionally path/to/key.audit, the audit log.
 type fsContainer struct {
 	// Fields relevant to a container, initialized or not
-	flock            lockfile.Lockfile // file lock
-	path             string            // absolute base path
+	flock            fileLock // file lock
+	path             string   // absolute base path
 	initialized      bool
 	cacheInitialized bool
 	closed           bool
+	readOnly         bool // see OpenFSPrivateKeyContainerReadOnly
+
+	// Format versions of the key and cache file as read.  See fsKeyVersion
+	// and fsCacheVersion.
+	keyVersion   uint8
+	cacheVersion uint8
 
 	// Fields set in an initialized container
 	params     Params // parameters of the algorithm
@@ -107,21 +163,116 @@ type fsContainer struct {
 	seqNo      SignatureSeqNo
 	borrowed   uint32
 
+	// Largest seqNo ever written to the key file.  See HighWaterMarker.
+	highWaterMark SignatureSeqNo
+
+	// See KeyStructureStore.
+	keyStructure KeyStructure
+
+	// See RootStore.  Nil if not known.
+	root []byte
+
+	// See CachedHeightStore.
+	lowestCachedHeight uint32
+
+	// See KeyedChecksumStore.  Stored in the header of the cache file.
+	keyedChecksum bool
+
 	// Fields relevant to a container with an initialized cache
 	cacheFile         *os.File // the opened cache file
 	allocatedSubTrees uint32   // number of allocated cached subtrees
 	// maps subtree address to the index of the subtree in the cache
 	cacheIdxLut map[SubTreeAddress]uint32
+	// maps subtree address to the index of a partially generated subtree
+	// in the cache, which is moved to cacheIdxLut when it is resumed.
+	cachePartialLut map[SubTreeAddress]uint32
 	// maps subtree address to an mmaped buffer
-	cacheBufLut      map[SubTreeAddress]mmapedSubTree
+	cacheBufLut map[SubTreeAddress]mmapedSubTree
+	// protects cacheBufLut against CommitSubTree(), which may be called
+	// concurrently with GetSubTree() and DropSubTree()
+	cacheBufMux      sync.Mutex
 	cacheFreeIdx     *uint32Heap // list of allocated but unused subtrees
 	subTreeAlignment int         // multiple to which subtrees are aligned
 	pageSize         int
+
+	// Fields for the durability of the key file.  See SetSyncPolicy().
+	syncPolicy SyncPolicy
+	keyMux     sync.Mutex    // protects the key file against the syncer
+	dirty      bool          // true if the key file has not been synced
+	stopSyncer chan struct{} // closed to stop the syncer goroutine
+	syncerDone chan struct{} // closed when the syncer goroutine has stopped
+
+	// Fields for the audit log.  See AuditLogger.
+	auditFile  *os.File // opened on the first entry
+	auditDirty bool     // true if the audit log has not been synced
+}
+
+// Determines when a PrivateKeyContainer forces changes of the signature
+// sequence number to disk.  See SyncPolicySetter.
+//
+// Changes that are not synced are still written, so they survive a crash
+// of the process.  However, if the operating system crashes or the power
+// fails before they are synced, the signature sequence number might be
+// reverted, which will lead to the reuse of signatures.
+type SyncPolicy struct {
+	onBorrow bool          // only sync on BorrowSeqNos()
+	period   time.Duration // if non-zero, sync periodically
+}
+
+var (
+	// Sync every change of the signature sequence number, which
+	// is the default.  Combine with PrivateKey.SetAutoBorrow() for speed.
+	SyncEverySignature = SyncPolicy{}
+
+	// Only sync when signature sequence numbers are borrowed.
+	SyncOnBorrow = SyncPolicy{onBorrow: true}
+)
+
+// Sync changes of the signature sequence number at most d after they
+// are made, from a background goroutine.
+func SyncPeriodic(d time.Duration) SyncPolicy {
+	return SyncPolicy{period: d}
+}
+
+// Optional interface for a PrivateKeyContainer that allows to trade
+// durability of the signature sequence number for speed.
+type SyncPolicySetter interface {
+	// Sets the SyncPolicy.  Syncs any changes that have not been synced
+	// yet under the previous policy.
+	SetSyncPolicy(policy SyncPolicy) Error
+}
+
+// Optional interface for a PrivateKeyContainer (or SeqNoStore) that keeps
+// a high-water mark: the largest signature sequence number it has stored.
+// Unlike the signature sequence number, the high-water mark never
+// decreases, not even when borrowed signatures are returned with SetSeqNo().
+// See PrivateKey.HighWaterMark().
+type HighWaterMarker interface {
+	// Returns the largest signature sequence number ever stored with
+	// SetSeqNo() or BorrowSeqNos().
+	HighWaterMark() (SignatureSeqNo, Error)
+}
+
+// Optional interface for a PrivateKeyContainer (or SeqNoStore) that stores
+// the root of the public key next to the private key, which contains the
+// public seed.  Thus the public key of a private key in such a container
+// is known without reading or generating the root subtree, which might not
+// be cached, for instance because the cache was lost.  The root subtree is
+// checked against the stored root when it is used.  See
+// LoadPrivateKeyFrom().
+type RootStore interface {
+	// Returns the stored root or nil if it is not known.
+	Root() ([]byte, Error)
+
+	// Stores the root.  Reset() forgets it.
+	SetRoot(root []byte) Error
 }
 
 const (
-	// First 8 bytes (in hex) of the secret key file
-	FS_CONTAINER_KEY_MAGIC = "4089430a5ced6844"
+	// First 8 bytes (in hex) of the secret key file for version 0
+	// and for version ≥1, see fsKeyVersion.
+	FS_CONTAINER_KEY_MAGIC  = "4089430a5ced6844"
+	FS_CONTAINER_KEY_MAGIC2 = "c3a5d0f6e1b28e47"
 
 	// First 8 bytes (in hex) of the subtree cache file
 	FS_CONTAINER_CACHE_MAGIC  = "e77957607ef79446"
@@ -130,18 +281,45 @@ const (
 
 // Returns a PrivateKeyContainer backed by the filesystem.
 func OpenFSPrivateKeyContainer(path string) (PrivateKeyContainer, Error) {
+	return openFSPrivateKeyContainer(path, false)
+}
+
+// Returns a PrivateKeyContainer backed by the filesystem that can only be
+// used to inspect the key, for instance the signature sequence number and
+// the cached subtrees, while it is used by another process.
+//
+// The container does not take the lock and refuses any change: changing
+// the signature sequence number, creating or dropping cached subtrees.
+// The state is read when the container is opened and is not updated
+// afterwards.  Cached subtrees are read when GetSubTree() is called and
+// might have been changed by the other process in the meantime.
+func OpenFSPrivateKeyContainerReadOnly(path string) (
+	PrivateKeyContainer, Error) {
+	return openFSPrivateKeyContainer(path, true)
+}
+
+func openFSPrivateKeyContainer(path string, readOnly bool) (
+	PrivateKeyContainer, Error) {
 	var ctr fsContainer
 	var err error
 
+	ctr.readOnly = readOnly
 	ctr.path, err = filepath.Abs(path)
 	if err != nil {
 		return nil, wrapErrorf(err,
 			"Could not turn %s into an absolute path", path)
 	}
 
+	if readOnly {
+		if _, err = os.Stat(ctr.path); os.IsNotExist(err) {
+			return nil, errorf("%s does not exist", path)
+		}
+		return ctr.readKeyFile()
+	}
+
 	// Acquire lock
 	lockFilePath := ctr.path + ".lock"
-	ctr.flock, err = lockfile.New(lockFilePath)
+	ctr.flock, err = newFileLock(lockFilePath)
 	if err != nil {
 		return nil, wrapErrorf(err,
 			"Failed to create lockfile %s", lockFilePath)
@@ -152,7 +330,7 @@ func OpenFSPrivateKeyContainer(path string) (PrivateKeyContainer, Error) {
 		Temporary() bool
 	}); ok {
 		err2 := errorf("%s is locked", path)
-		err2.locked = true
+		err2.kind = ErrLocked
 		return nil, err2
 	}
 
@@ -161,21 +339,55 @@ func OpenFSPrivateKeyContainer(path string) (PrivateKeyContainer, Error) {
 		return &ctr, nil
 	}
 
-	// Open the container.
+	return ctr.readKeyFile()
+}
+
+// Reads the key file and opens the cache.  Files in an older format are
+// upgraded, unless the container is read-only.
+func (ctr *fsContainer) readKeyFile() (PrivateKeyContainer, Error) {
+	if err := ctr.readKey(); err != nil {
+		return ctr, err
+	}
+	if err := ctr.migrateKey(); err != nil {
+		return ctr, err
+	}
+	if err := ctr.openCache(); err != nil {
+		return ctr, err
+	}
+	return ctr, ctr.migrateCache()
+}
+
+// Reads the key file.
+func (ctr *fsContainer) readKey() Error {
 	file, err := os.Open(ctr.path)
 	if err != nil {
-		return &ctr, wrapErrorf(err, "Failed to open keyfile %s", path)
+		return wrapErrorf(err, "Failed to open keyfile %s", ctr.path)
 	}
 	defer file.Close()
 
+	var magic [8]byte
+	if err = binary.Read(file, binary.BigEndian, &magic); err != nil {
+		return wrapErrorf(err, "Failed to read keyfile header")
+	}
+	switch hex.EncodeToString(magic[:]) {
+	case FS_CONTAINER_KEY_MAGIC:
+		ctr.keyVersion = 0
+	case FS_CONTAINER_KEY_MAGIC2:
+		err = binary.Read(file, binary.BigEndian, &ctr.keyVersion)
+		if err != nil {
+			return wrapErrorf(err, "Failed to read keyfile header")
+		}
+		if ctr.keyVersion == 0 || ctr.keyVersion > fsKeyVersion {
+			return errorf("Unsupported keyfile version: %d", ctr.keyVersion)
+		}
+	default:
+		return kindErrorf(ErrWrongMagic, "Keyfile has invalid magic")
+	}
+
 	var keyHeader fsKeyHeader
 	err = binary.Read(file, binary.BigEndian, &keyHeader)
 	if err != nil {
-		return &ctr, wrapErrorf(err, "Failed to read keyfile header")
-	}
-
-	if FS_CONTAINER_KEY_MAGIC != hex.EncodeToString(keyHeader.Magic[:]) {
-		return &ctr, wrapErrorf(err, "Keyfile has invalid magic")
+		return wrapErrorf(err, "Failed to read keyfile header")
 	}
 
 	ctr.params = keyHeader.Params
@@ -184,18 +396,62 @@ func OpenFSPrivateKeyContainer(path string) (PrivateKeyContainer, Error) {
 	ctr.borrowed = keyHeader.Borrowed
 	_, err = io.ReadAtLeast(file, ctr.privateKey, ctr.params.PrivateKeySize())
 	if err != nil {
-		return &ctr, wrapErrorf(err, "Failed to read private key")
+		return wrapErrorf(err, "Failed to read private key")
 	}
 
-	ctr.initialized = true
+	// Before version 2 there was no high-water mark, so the best we know
+	// is the current sequence number.
+	ctr.highWaterMark = ctr.seqNo
+	if ctr.keyVersion >= 2 {
+		err = binary.Read(file, binary.BigEndian, &ctr.highWaterMark)
+		if err != nil {
+			return wrapErrorf(err, "Failed to read high-water mark")
+		}
+	}
+	ctr.keyStructure = FlatKeyStructure
+	if ctr.keyVersion >= 3 {
+		err = binary.Read(file, binary.BigEndian, &ctr.keyStructure)
+		if err != nil {
+			return wrapErrorf(err, "Failed to read key structure")
+		}
+	}
+	ctr.root = nil
+	if ctr.keyVersion >= 4 {
+		var rootLen uint8
+		err = binary.Read(file, binary.BigEndian, &rootLen)
+		if err != nil {
+			return wrapErrorf(err, "Failed to read root")
+		}
+		if rootLen != 0 {
+			ctr.root = make([]byte, rootLen)
+			if _, err = io.ReadFull(file, ctr.root); err != nil {
+				return wrapErrorf(err, "Failed to read root")
+			}
+		}
+	}
+	ctr.lowestCachedHeight = 0
+	if ctr.keyVersion >= 5 {
+		var height uint8
+		err = binary.Read(file, binary.BigEndian, &height)
+		if err != nil {
+			return wrapErrorf(err, "Failed to read lowest cached height")
+		}
+		if uint32(height) > ctr.params.FullHeight/ctr.params.D {
+			return kindErrorf(ErrMalformed,
+				"Lowest cached height %d is out of range", height)
+		}
+		ctr.lowestCachedHeight = uint32(height)
+	}
 
-	return &ctr, ctr.openCache()
+	ctr.initialized = true
+	return nil
 }
 
 func (ctr *fsContainer) openCache() Error {
 	var err error
 
 	ctr.cacheIdxLut = make(map[SubTreeAddress]uint32)
+	ctr.cachePartialLut = make(map[SubTreeAddress]uint32)
 	ctr.cacheBufLut = make(map[SubTreeAddress]mmapedSubTree)
 	emptyHeap := uint32Heap([]uint32{})
 	ctr.cacheFreeIdx = &emptyHeap
@@ -203,7 +459,11 @@ func (ctr *fsContainer) openCache() Error {
 
 	// Open cache file
 	cachePath := ctr.path + ".cache"
-	ctr.cacheFile, err = os.OpenFile(cachePath, os.O_RDWR, 0)
+	flag := os.O_RDWR
+	if ctr.readOnly {
+		flag = os.O_RDONLY
+	}
+	ctr.cacheFile, err = os.OpenFile(cachePath, flag, 0)
 	if err != nil {
 		return wrapErrorf(err, "Failed to open cache file")
 	}
@@ -212,28 +472,33 @@ func (ctr *fsContainer) openCache() Error {
 	var header fsCacheHeader
 	err = binary.Read(ctr.cacheFile, binary.BigEndian, &header)
 	if err != nil {
-		return wrapErrorf(err, "Failed to read cache file header")
+		err2 := wrapErrorf(err, "Failed to read cache file header")
+		err2.kind = ErrCorruptCache
+		return err2
 	}
 
 	magic := hex.EncodeToString(header.Magic[:])
 	if magic != FS_CONTAINER_CACHE_MAGIC && magic != FS_CONTAINER_CACHE_MAGIC2 {
-		return wrapErrorf(err, "Cache file magic is wrong")
+		return kindErrorf(ErrWrongMagic, "Cache file magic is wrong")
 	}
 
 	if magic == FS_CONTAINER_CACHE_MAGIC {
 		if header.Version != 0 {
-			return wrapErrorf(err, "Cache file version does not match magic")
+			return kindErrorf(ErrCorruptCache,
+				"Cache file version does not match magic")
 		}
 
 		ctr.subTreeAlignment = 4096
 	} else {
-		if header.Version != 1 {
-			return wrapErrorf(err, "Unsupported cache file version: %d",
+		if header.Version == 0 || header.Version > fsCacheVersion {
+			return errorf("Unsupported cache file version: %d",
 				header.Version)
 		}
 
 		ctr.subTreeAlignment = int(header.SubTreeAlignment)
 	}
+	ctr.cacheVersion = header.Version
+	ctr.keyedChecksum = header.Version >= 2 && header.KeyedChecksum != 0
 
 	ctr.pageSize = os.Getpagesize()
 	ctr.allocatedSubTrees = header.AllocatedSubTrees
@@ -249,24 +514,43 @@ func (ctr *fsContainer) openCache() Error {
 		var treeHeader fsSubTreeHeader
 		err = binary.Read(ctr.cacheFile, binary.BigEndian, &treeHeader)
 		if err != nil {
-			return wrapErrorf(err, "Failed to read subtree header in cache")
+			err2 := wrapErrorf(err, "Failed to read subtree header in cache")
+			err2.kind = ErrCorruptCache
+			return err2
 		}
 
-		if treeHeader.Allocated == 0 {
+		// A subtree that was never committed was still being generated
+		// when the container was closed, eg. because of a crash.  We don't
+		// bother checking it and simply reuse the space.
+		if treeHeader.Allocated == FS_SUBTREE_FREE ||
+			treeHeader.Allocated == FS_SUBTREE_PENDING {
 			heap.Push(ctr.cacheFreeIdx, idx)
+		} else if treeHeader.Allocated == FS_SUBTREE_PARTIAL {
+			ctr.cachePartialLut[treeHeader.Address] = idx
 		} else {
 			ctr.cacheIdxLut[treeHeader.Address] = idx
 		}
 	}
 
+	// A complete copy of a subtree takes precedence over a partial one.
+	for address, idx := range ctr.cachePartialLut {
+		if _, ok := ctr.cacheIdxLut[address]; ok {
+			heap.Push(ctr.cacheFreeIdx, idx)
+			delete(ctr.cachePartialLut, address)
+		}
+	}
+
 	ctr.cacheInitialized = true
 
 	return nil
 }
 
-// Header of the key file
+// Header of the key file, which is preceded by the magic and, for format
+// version ≥1, the version.  See fsKeyVersion.  It is followed by the
+// private key, skSeed ‖ skPrf ‖ pubSeed, and the fields added by later
+// versions, among which the root.  Together with the public seed, the
+// latter gives the public key.
 type fsKeyHeader struct {
-	Magic    [8]byte        // Should be FS_CONTAINER_KEY_MAGIC
 	Params   Params         // Parameters
 	SeqNo    SignatureSeqNo // Signature seqno
 	Borrowed uint32         // Number of signatures borrowed.
@@ -281,25 +565,49 @@ type fsCacheHeader struct {
 
 	// The following fields are nonzero for format version ≥1.
 
-	// Version of the cache format.
-	//
-	//   0 Original with magic FS_CONTAINER_CACHE_MAGIC2
-	//   1 Second version which includes subtree alignment.
-	//     Has magic FS_CONTAINER_CACHE_MAGIC2.
+	// Version of the cache format.  See fsCacheVersion.
 	Version uint8
 
 	// Multiple to which subtrees are aligned.  Zero is interpreted
 	// as 4096.
 	SubTreeAlignment uint32
+
+	// The following field is nonzero for format version ≥2.
+
+	// 1 if the subtrees have a keyed checksum.  See KeyedChecksumStore.
+	KeyedChecksum uint8
 }
 
 // Header of a cached subtree
 type fsSubTreeHeader struct {
-	// In older versions of Go, binary.Read/Write do not support bool
+	// One of FS_SUBTREE_FREE, FS_SUBTREE_COMMITTED, FS_SUBTREE_PENDING or
+	// FS_SUBTREE_PARTIAL.
 	Allocated uint8
 	Address   SubTreeAddress
 }
 
+// Values of fsSubTreeHeader.Allocated
+const (
+	// The space for the subtree is unused.
+	FS_SUBTREE_FREE uint8 = 0
+
+	// The subtree has been written completely.  Older versions of the
+	// cache use this value for all allocated subtrees.
+	FS_SUBTREE_COMMITTED uint8 = 1
+
+	// The space for the subtree is allocated, but the subtree has not been
+	// committed, see CommitSubTree().  Older versions of this package treat
+	// these as committed, which is safe as the subtree's checksum is checked.
+	FS_SUBTREE_PENDING uint8 = 2
+
+	// The subtree is being generated and its first leafs have been written,
+	// see CheckpointSubTree().  Their number is stored in the last 8 bytes
+	// of the subtree, where its checksum goes.  Older versions of this
+	// package treat these as committed, which is safe as the subtree's
+	// checksum is checked.
+	FS_SUBTREE_PARTIAL uint8 = 3
+)
+
 func (ctr *fsContainer) CacheInitialized() bool {
 	return ctr.cacheInitialized
 }
@@ -316,20 +624,26 @@ func (ctr *fsContainer) ResetCache() Error {
 	var err2 error
 
 	if !ctr.initialized {
-		err = errorf("Container is not initialized")
+		err = kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
 		return err
 	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
 
-	// Close old cache
-	if ctr.cacheInitialized {
+	// Close old cache, which might be open even if it is not initialized,
+	// because it could not be read.
+	if ctr.cacheInitialized || ctr.cacheFile != nil {
 		ctr.closeCache() // we ignore munmap failures
 	}
 	ctr.cacheBufLut = make(map[SubTreeAddress]mmapedSubTree)
 	ctr.cacheIdxLut = make(map[SubTreeAddress]uint32)
+	ctr.cachePartialLut = make(map[SubTreeAddress]uint32)
 	ctr.pageSize = os.Getpagesize()
-	ctr.subTreeAlignment = ctr.pageSize
-	if ctr.subTreeAlignment < 4096 {
-		ctr.subTreeAlignment = 4096
+	ctr.subTreeAlignment = 4096
+	if subTreesMmaped && ctr.pageSize > 4096 {
+		ctr.subTreeAlignment = ctr.pageSize
 	}
 	ctr.allocatedSubTrees = 0
 	emptyHeap := uint32Heap([]uint32{})
@@ -362,9 +676,12 @@ func (ctr *fsContainer) writeCacheHeader() Error {
 	}
 	cacheHeader := fsCacheHeader{
 		AllocatedSubTrees: ctr.allocatedSubTrees,
-		Version:           1,
+		Version:           fsCacheVersion,
 		SubTreeAlignment:  uint32(ctr.subTreeAlignment),
 	}
+	if ctr.keyedChecksum {
+		cacheHeader.KeyedChecksum = 1
+	}
 	magic, _ := hex.DecodeString(FS_CONTAINER_CACHE_MAGIC2)
 	copy(cacheHeader.Magic[:], magic)
 	err = binary.Write(ctr.cacheFile, binary.BigEndian, &cacheHeader)
@@ -375,42 +692,23 @@ func (ctr *fsContainer) writeCacheHeader() Error {
 	return nil
 }
 
+// Returns the size of the buffer of a cached subtree.
+func (ctr *fsContainer) subTreeSize() int {
+	return ctr.params.CachedSubTreeSizeFrom(ctr.lowestCachedHeight)
+}
+
 // Returns the offset of the given cached subtree entry in the cache file.
 // This offset point to the 13-byte header just in front of the actual data.
 func (ctr *fsContainer) subTreeOffset(idx uint32) int {
-	// Find the smallest multiple of ctr.subTreeAlignment
-	// above CachedSubTreeSize() + 13,  where 13 is the size of fsSubTreeHeader.
-	paddedSize := ((((ctr.params.CachedSubTreeSize() + 13) - 1) /
-		ctr.subTreeAlignment) + 1) * ctr.subTreeAlignment
+	paddedSize := ctr.params.paddedSubTreeSize(ctr.lowestCachedHeight,
+		ctr.subTreeAlignment)
 	return int(idx)*paddedSize + ctr.subTreeAlignment
 }
 
-func (ctr *fsContainer) mmapSubTree(idx uint32) (mmapedSubTree, error) {
-	realOffset := ctr.subTreeOffset(idx)
-	offset := realOffset % ctr.pageSize
-
-	buf, err := mmap.MapRegion(
-		ctr.cacheFile,
-		ctr.params.CachedSubTreeSize()+13+offset, // length
-		mmap.RDWR, // prot
-		0,         // flags
-		int64(realOffset-offset),
-	)
-
-	if err != nil {
-		return mmapedSubTree{}, err
-	}
-
-	return mmapedSubTree{
-		mmap: buf,
-		buf:  buf[offset:],
-	}, nil
-}
-
 func (ctr *fsContainer) GetSubTree(address SubTreeAddress) (
 	ret []byte, exists bool, err Error) {
 	if !ctr.cacheInitialized {
-		err = errorf("Cache is not initialized")
+		err = kindErrorf(ErrNotInitialized, "Cache is not initialized")
 		return nil, false, err
 	}
 
@@ -420,16 +718,37 @@ func (ctr *fsContainer) GetSubTree(address SubTreeAddress) (
 		return []byte(buf.buf)[13:], true, nil
 	}
 
+	if ctr.readOnly {
+		return ctr.readSubTree(address)
+	}
+
 	// Check if the subtree exists
 	if idx, ok := ctr.cacheIdxLut[address]; ok {
 		buf, err2 := ctr.mmapSubTree(idx)
 		if err2 != nil {
 			return nil, false, wrapErrorf(err2, "Failed to mmap subtree")
 		}
+		ctr.cacheBufMux.Lock()
 		ctr.cacheBufLut[address] = buf
+		ctr.cacheBufMux.Unlock()
 		return []byte(buf.buf)[13:], true, nil
 	}
 
+	// Resume a subtree that was partially generated before.  We keep its
+	// header, so that SubTreeProgress() can find the number of leafs.
+	if idx, ok := ctr.cachePartialLut[address]; ok {
+		buf, err2 := ctr.mmapSubTree(idx)
+		if err2 != nil {
+			return nil, false, wrapErrorf(err2, "Failed to mmap subtree")
+		}
+		delete(ctr.cachePartialLut, address)
+		ctr.cacheBufMux.Lock()
+		ctr.cacheBufLut[address] = buf
+		ctr.cacheBufMux.Unlock()
+		ctr.cacheIdxLut[address] = idx
+		return buf.buf[13:], false, nil
+	}
+
 	// Find a free cached subtree index
 	var idx uint32
 	if ctr.cacheFreeIdx.Len() != 0 {
@@ -456,7 +775,7 @@ func (ctr *fsContainer) GetSubTree(address SubTreeAddress) (
 
 	// Write information
 	header := fsSubTreeHeader{
-		Allocated: 1,
+		Allocated: FS_SUBTREE_PENDING,
 		Address:   address,
 	}
 	bufWriter := byteswriter.NewWriter(buf.buf)
@@ -466,15 +785,101 @@ func (ctr *fsContainer) GetSubTree(address SubTreeAddress) (
 		return
 	}
 
+	ctr.cacheBufMux.Lock()
 	ctr.cacheBufLut[address] = buf
+	ctr.cacheBufMux.Unlock()
 	ctr.cacheIdxLut[address] = idx
 
 	return buf.buf[13:], false, nil
 }
 
+// Implementation of GetSubTree() for a read-only container, which returns
+// a copy of the subtree.
+func (ctr *fsContainer) readSubTree(address SubTreeAddress) (
+	[]byte, bool, Error) {
+	idx, ok := ctr.cacheIdxLut[address]
+	if !ok {
+		return nil, false, errorf("Container is read-only")
+	}
+	buf := make([]byte, ctr.subTreeSize())
+	_, err := ctr.cacheFile.ReadAt(buf, int64(ctr.subTreeOffset(idx)+13))
+	if err != nil {
+		return nil, false, wrapErrorf(err, "Failed to read subtree from cache")
+	}
+	return buf, true, nil
+}
+
+func (ctr *fsContainer) CommitSubTree(address SubTreeAddress) Error {
+	if !ctr.cacheInitialized {
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+
+	ctr.cacheBufMux.Lock()
+	buf, ok := ctr.cacheBufLut[address]
+	ctr.cacheBufMux.Unlock()
+	if !ok {
+		return errorf("Subtree %v is not in use", address)
+	}
+
+	// First write out the subtree and only then mark it as committed.
+	if err := buf.flush(); err != nil {
+		return wrapErrorf(err, "Failed to flush subtree")
+	}
+	buf.buf[0] = FS_SUBTREE_COMMITTED
+	if err := buf.flush(); err != nil {
+		return wrapErrorf(err, "Failed to flush subtree header")
+	}
+	return nil
+}
+
+func (ctr *fsContainer) CheckpointSubTree(address SubTreeAddress,
+	leafs uint32) Error {
+	if !ctr.cacheInitialized {
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+
+	buf, ok := ctr.cacheBufLut[address]
+	if !ok {
+		return errorf("Subtree %v is not in use", address)
+	}
+
+	// As in CommitSubTree(), we first write out the leafs and their number
+	// and only then mark the subtree as partial.
+	binary.BigEndian.PutUint64(buf.buf[len(buf.buf)-8:], uint64(leafs))
+	if err := buf.flush(); err != nil {
+		return wrapErrorf(err, "Failed to flush subtree")
+	}
+	if buf.buf[0] != FS_SUBTREE_PARTIAL {
+		buf.buf[0] = FS_SUBTREE_PARTIAL
+		if err := buf.flush(); err != nil {
+			return wrapErrorf(err, "Failed to flush subtree header")
+		}
+	}
+	return nil
+}
+
+func (ctr *fsContainer) SubTreeProgress(address SubTreeAddress) (
+	uint32, Error) {
+	if !ctr.cacheInitialized {
+		return 0, kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+
+	buf, ok := ctr.cacheBufLut[address]
+	if !ok || buf.buf[0] != FS_SUBTREE_PARTIAL {
+		return 0, nil
+	}
+	leafs := binary.BigEndian.Uint64(buf.buf[len(buf.buf)-8:])
+	if leafs > uint64(^uint32(0)) {
+		return 0, kindErrorf(ErrCorruptCache,
+			"Subtree %v has an invalid number of leafs", address)
+	}
+	return uint32(leafs), nil
+}
+
 func (ctr *fsContainer) ListSubTrees() ([]SubTreeAddress, Error) {
 	if !ctr.cacheInitialized {
-		return nil, errorf("Cache is not initialized")
+		return nil, kindErrorf(ErrNotInitialized,
+			"Cache is not initialized")
 	}
 
 	ret := make([]SubTreeAddress, len(ctr.cacheIdxLut))
@@ -497,16 +902,29 @@ func (ctr *fsContainer) HasSubTree(address SubTreeAddress) bool {
 
 func (ctr *fsContainer) DropSubTree(address SubTreeAddress) Error {
 	if !ctr.cacheInitialized {
-		return errorf("Cache is not initialized")
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
 	}
 
-	// TODO decrement allocatedSubTrees and cacheFile.Truncate when
-	//      applicable to free disk space.
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+
+	// The space is reused for the next subtree and reclaimed by Compact().
 
 	var err2 error
 
 	idx, ok := ctr.cacheIdxLut[address]
 	if !ok {
+		if idx, ok = ctr.cachePartialLut[address]; !ok {
+			return nil
+		}
+		_, err2 = ctr.cacheFile.WriteAt([]byte{FS_SUBTREE_FREE},
+			int64(ctr.subTreeOffset(idx)))
+		if err2 != nil {
+			return wrapErrorf(err2, "Failed to write subtree header in cache")
+		}
+		heap.Push(ctr.cacheFreeIdx, idx)
+		delete(ctr.cachePartialLut, address)
 		return nil
 	}
 
@@ -527,9 +945,11 @@ func (ctr *fsContainer) DropSubTree(address SubTreeAddress) Error {
 
 	heap.Push(ctr.cacheFreeIdx, idx)
 	delete(ctr.cacheIdxLut, address)
+	ctr.cacheBufMux.Lock()
 	delete(ctr.cacheBufLut, address)
+	ctr.cacheBufMux.Unlock()
 
-	err2 = buf.mmap.Unmap()
+	err2 = buf.unmap()
 	if err2 != nil {
 		return wrapErrorf(err2, "Failed to unmap sub tree")
 	}
@@ -540,17 +960,28 @@ func (ctr *fsContainer) Reset(privateKey []byte, params Params) Error {
 	if ctr.closed {
 		return errorf("Container is closed")
 	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
 
 	// Even if closing the cache fails, we will try to write the key file.
 	closeCacheErr := ctr.closeCache()
 
+	ctr.keyMux.Lock()
 	ctr.params = params
 	ctr.privateKey = privateKey
 	ctr.seqNo = 0
 	ctr.borrowed = 0
+	ctr.highWaterMark = 0
+	ctr.keyStructure = FlatKeyStructure
+	ctr.root = nil
+	ctr.lowestCachedHeight = 0
+	ctr.keyedChecksum = false
 	ctr.cacheInitialized = false
+	err := ctr.writeKeyFile(true)
+	ctr.keyMux.Unlock()
 
-	if err := ctr.writeKeyFile(); err != nil {
+	if err != nil {
 		return err
 	}
 
@@ -569,13 +1000,20 @@ func (ctr *fsContainer) Reset(privateKey []byte, params Params) Error {
 
 func (ctr *fsContainer) BorrowSeqNos(amount uint32) (SignatureSeqNo, Error) {
 	if !ctr.initialized {
-		return 0, errorf("Container is not initialized")
+		return 0, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
 	}
+	if ctr.readOnly {
+		return 0, errorf("Container is read-only")
+	}
+
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
 
 	ctr.borrowed += amount
 	ctr.seqNo += SignatureSeqNo(amount)
 
-	if err := ctr.writeKeyFile(); err != nil {
+	if err := ctr.writeKeyFile(ctr.syncPolicy.period == 0); err != nil {
 		// rollback
 		ctr.borrowed -= amount
 		ctr.seqNo -= SignatureSeqNo(amount)
@@ -585,8 +1023,10 @@ func (ctr *fsContainer) BorrowSeqNos(amount uint32) (SignatureSeqNo, Error) {
 	return ctr.seqNo - SignatureSeqNo(amount), nil
 }
 
-// Write key file to disk
-func (ctr *fsContainer) writeKeyFile() Error {
+// Write key file to disk.  If sync is false, step (4) below is skipped
+// and the key file is marked dirty.  Step (2) is never skipped: otherwise
+// the key file might be empty after a power loss.  Requires ctr.keyMux lock.
+func (ctr *fsContainer) writeKeyFile(sync bool) Error {
 	var err error
 
 	// (1) Write to a temp file.  (2) fsync this tempfile to get the data out.
@@ -607,8 +1047,11 @@ func (ctr *fsContainer) writeKeyFile() Error {
 		SeqNo:    ctr.seqNo,
 		Borrowed: ctr.borrowed,
 	}
-	magic, _ := hex.DecodeString(FS_CONTAINER_KEY_MAGIC)
-	copy(keyHeader.Magic[:], magic)
+	magic, _ := hex.DecodeString(FS_CONTAINER_KEY_MAGIC2)
+	if _, err = tmpFile.Write(append(magic, fsKeyVersion)); err != nil {
+		tmpFile.Close()
+		return wrapErrorf(err, "failed to write temporary key file")
+	}
 	if err = binary.Write(tmpFile, binary.BigEndian, &keyHeader); err != nil {
 		tmpFile.Close()
 		return wrapErrorf(err, "failed to write temporary key file")
@@ -619,6 +1062,31 @@ func (ctr *fsContainer) writeKeyFile() Error {
 		return wrapErrorf(err, "failed to write temporary key file")
 	}
 
+	highWaterMark := ctr.highWaterMark
+	if ctr.seqNo > highWaterMark {
+		highWaterMark = ctr.seqNo
+	}
+	err = binary.Write(tmpFile, binary.BigEndian, highWaterMark)
+	if err != nil {
+		tmpFile.Close()
+		return wrapErrorf(err, "failed to write temporary key file")
+	}
+	err = binary.Write(tmpFile, binary.BigEndian, ctr.keyStructure)
+	if err != nil {
+		tmpFile.Close()
+		return wrapErrorf(err, "failed to write temporary key file")
+	}
+	_, err = tmpFile.Write(append([]byte{uint8(len(ctr.root))}, ctr.root...))
+	if err != nil {
+		tmpFile.Close()
+		return wrapErrorf(err, "failed to write temporary key file")
+	}
+	_, err = tmpFile.Write([]byte{uint8(ctr.lowestCachedHeight)})
+	if err != nil {
+		tmpFile.Close()
+		return wrapErrorf(err, "failed to write temporary key file")
+	}
+
 	// (2) Sync the tempfile
 	if err = tmpFile.Sync(); err != nil {
 		tmpFile.Close()
@@ -629,43 +1097,116 @@ func (ctr *fsContainer) writeKeyFile() Error {
 		return wrapErrorf(err, "failed to close temporary key file")
 	}
 
-	// (3) Rename the tempfile
-	if err = os.Rename(tmpPath, ctr.path); err != nil {
-		return wrapErrorf(err, "failed to replace key file")
+	// If replacing the key file fails, it might have been replaced
+	// anyway, so we err on the side of the larger high-water mark.
+	ctr.highWaterMark = highWaterMark
+
+	if !sync {
+		if err = os.Rename(tmpPath, ctr.path); err != nil {
+			return wrapErrorf(err, "failed to replace key file")
+		}
+		ctr.dirty = true
+		return nil
 	}
 
-	// (4) Sync the parent directory.  If this fails we have no way of knowing
-	// whether  the changes have been written out to disk.  We will assume that
-	// it did not, so that we won't reuse signatures.
-	dirName := filepath.Dir(ctr.path)
-	dir, err := os.Open(dirName)
-	if err != nil {
-		return wrapErrorf(err, "failed to sync key file: open(%s):", dirName)
+	// (3) Rename the tempfile and (4) sync the parent directory.  If the
+	// latter fails we have no way of knowing whether the changes have been
+	// written out to disk.  We will assume that it did not, so that we won't
+	// reuse signatures.
+	if err = replaceFile(tmpPath, ctr.path); err != nil {
+		return wrapErrorf(err, "failed to replace key file")
 	}
 
-	if err = dir.Sync(); err != nil {
-		dir.Close()
-		return wrapErrorf(err, "failed to sync key file")
+	ctr.dirty = false
+	return nil
+}
+
+func (ctr *fsContainer) SetSyncPolicy(policy SyncPolicy) Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
 	}
 
-	if err = dir.Close(); err != nil {
-		return wrapErrorf(err, "failed to sync key file (close)")
+	ctr.stopSyncGoroutine()
+
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
+	ctr.syncPolicy = policy
+	if ctr.dirty {
+		if err := ctr.writeKeyFile(true); err != nil {
+			return err
+		}
+	}
+	if err := ctr.syncAuditLog(); err != nil {
+		return err
 	}
 
+	if policy.period != 0 {
+		ctr.stopSyncer = make(chan struct{})
+		ctr.syncerDone = make(chan struct{})
+		go ctr.syncGoroutine(policy.period, ctr.stopSyncer, ctr.syncerDone)
+	}
 	return nil
 }
 
+// Periodically syncs the key file if it is dirty.  See SyncPeriodic().
+func (ctr *fsContainer) syncGoroutine(period time.Duration,
+	stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctr.keyMux.Lock()
+		if ctr.dirty {
+			if err := ctr.writeKeyFile(true); err != nil {
+				log.Logf("Failed to sync key file: %v", err)
+			}
+		}
+		if err := ctr.syncAuditLog(); err != nil {
+			log.Logf("%v", err)
+		}
+		ctr.keyMux.Unlock()
+	}
+}
+
+// Stops the goroutine started for SyncPeriodic(), if any.
+func (ctr *fsContainer) stopSyncGoroutine() {
+	if ctr.stopSyncer == nil {
+		return
+	}
+	close(ctr.stopSyncer)
+	<-ctr.syncerDone
+	ctr.stopSyncer = nil
+	ctr.syncerDone = nil
+}
+
 func (ctr *fsContainer) SetSeqNo(seqNo SignatureSeqNo) Error {
 	if !ctr.initialized {
-		return errorf("Container is not initialized")
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
 	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
 
 	oldBorrowed := ctr.borrowed
 	oldSeqNo := ctr.seqNo
 	ctr.borrowed = 0
 	ctr.seqNo = seqNo
 
-	if err := ctr.writeKeyFile(); err != nil {
+	if err := ctr.writeKeyFile(ctr.syncPolicy == SyncEverySignature); err != nil {
 		// rollback
 		ctr.borrowed = oldBorrowed
 		ctr.seqNo = oldSeqNo
@@ -678,16 +1219,144 @@ func (ctr *fsContainer) SetSeqNo(seqNo SignatureSeqNo) Error {
 func (ctr *fsContainer) GetSeqNo() (
 	seqNo SignatureSeqNo, lostSigs uint32, err Error) {
 	if !ctr.initialized {
-		err = errorf("Container is not initialized")
+		err = kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
 		return
 	}
 
 	return ctr.seqNo, ctr.borrowed, nil
 }
 
+func (ctr *fsContainer) HighWaterMark() (SignatureSeqNo, Error) {
+	if !ctr.initialized {
+		return 0, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
+	return ctr.highWaterMark, nil
+}
+
+func (ctr *fsContainer) KeyStructure() (KeyStructure, Error) {
+	if !ctr.initialized {
+		return 0, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	return ctr.keyStructure, nil
+}
+
+func (ctr *fsContainer) SetKeyStructure(ks KeyStructure) Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
+	old := ctr.keyStructure
+	ctr.keyStructure = ks
+	if err := ctr.writeKeyFile(true); err != nil {
+		ctr.keyStructure = old
+		return err
+	}
+	return nil
+}
+
+func (ctr *fsContainer) Root() ([]byte, Error) {
+	if !ctr.initialized {
+		return nil, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	return ctr.root, nil
+}
+
+func (ctr *fsContainer) SetRoot(root []byte) Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
+	old := ctr.root
+	ctr.root = append([]byte{}, root...)
+	if err := ctr.writeKeyFile(true); err != nil {
+		ctr.root = old
+		return err
+	}
+	return nil
+}
+
+func (ctr *fsContainer) LowestCachedHeight() (uint32, Error) {
+	if !ctr.initialized {
+		return 0, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	return ctr.lowestCachedHeight, nil
+}
+
+func (ctr *fsContainer) SetLowestCachedHeight(height uint32) Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+	if height > ctr.params.FullHeight/ctr.params.D {
+		return errorf("Lowest cached height %d is out of range", height)
+	}
+	ctr.keyMux.Lock()
+	old := ctr.lowestCachedHeight
+	ctr.lowestCachedHeight = height
+	if err := ctr.writeKeyFile(true); err != nil {
+		ctr.lowestCachedHeight = old
+		ctr.keyMux.Unlock()
+		return err
+	}
+	ctr.keyMux.Unlock()
+
+	// The cached subtrees have a different size now.
+	return ctr.ResetCache()
+}
+
+func (ctr *fsContainer) KeyedCacheChecksum() (bool, Error) {
+	if !ctr.cacheInitialized {
+		return false, kindErrorf(ErrNotInitialized,
+			"Cache is not initialized")
+	}
+	return ctr.keyedChecksum, nil
+}
+
+func (ctr *fsContainer) SetKeyedCacheChecksum(enabled bool) Error {
+	if !ctr.cacheInitialized {
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+	if enabled == ctr.keyedChecksum {
+		return nil
+	}
+	ctr.keyedChecksum = enabled
+	if err := ctr.writeCacheHeader(); err != nil {
+		ctr.keyedChecksum = !enabled
+		return err
+	}
+	if err := ctr.cacheFile.Sync(); err != nil {
+		return wrapErrorf(err, "Failed to sync cache file")
+	}
+	return nil
+}
+
 func (ctr *fsContainer) GetPrivateKey() ([]byte, Error) {
 	if !ctr.initialized {
-		return nil, errorf("Container is not initialized")
+		return nil, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
 	}
 	return ctr.privateKey, nil
 }
@@ -696,7 +1365,7 @@ func (ctr *fsContainer) closeCache() (err error) {
 	ctr.cacheInitialized = false
 	if ctr.cacheBufLut != nil {
 		for _, buf := range ctr.cacheBufLut {
-			if err2 := buf.mmap.Unmap(); err2 != nil {
+			if err2 := buf.unmap(); err2 != nil {
 				err = multierror.Append(err, wrapErrorf(err2,
 					"Failed to unmap cached subtree"))
 			}
@@ -713,13 +1382,130 @@ func (ctr *fsContainer) closeCache() (err error) {
 	return
 }
 
+// Moves the cached subtrees to the front of the cache file and truncates
+// it, such that no space is wasted on dropped subtrees.  Compact is called
+// automatically on Close().
+//
+// NOTE The buffers returned by GetSubTree() are invalidated.  Thus Compact()
+// should not be called while the container is in use by a PrivateKey.
+func (ctr *fsContainer) Compact() Error {
+	if !ctr.cacheInitialized {
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+
+	if ctr.cacheFreeIdx.Len() == 0 {
+		return nil // there are no holes
+	}
+
+	// Unmap the subtrees as we are going to move them.
+	for address, buf := range ctr.cacheBufLut {
+		if err := buf.unmap(); err != nil {
+			return wrapErrorf(err, "Failed to unmap cached subtree")
+		}
+		delete(ctr.cacheBufLut, address)
+	}
+
+	// Sort the subtrees, including the partial ones, by their index.
+	idxs := make([]uint32, 0, len(ctr.cacheIdxLut)+len(ctr.cachePartialLut))
+	addresses := make(map[uint32]SubTreeAddress)
+	partial := make(map[uint32]bool)
+	for address, idx := range ctr.cacheIdxLut {
+		idxs = append(idxs, idx)
+		addresses[idx] = address
+	}
+	for address, idx := range ctr.cachePartialLut {
+		idxs = append(idxs, idx)
+		addresses[idx] = address
+		partial[idx] = true
+	}
+	sort.Slice(idxs, func(i, j int) bool { return idxs[i] < idxs[j] })
+
+	// Move the subtrees down.  We first write the copy and only then
+	// mark the old slot as free.  A crash in between leaves two intact
+	// copies, which is harmless.
+	slotSize := ctr.subTreeOffset(1) - ctr.subTreeOffset(0)
+	slot := make([]byte, slotSize)
+	for newIdx, oldIdx := range idxs {
+		if uint32(newIdx) == oldIdx {
+			continue
+		}
+		_, err := ctr.cacheFile.ReadAt(slot, int64(ctr.subTreeOffset(oldIdx)))
+		if err != nil {
+			return wrapErrorf(err, "Failed to read subtree from cache")
+		}
+		_, err = ctr.cacheFile.WriteAt(slot,
+			int64(ctr.subTreeOffset(uint32(newIdx))))
+		if err != nil {
+			return wrapErrorf(err, "Failed to write subtree to cache")
+		}
+		if err = ctr.cacheFile.Sync(); err != nil {
+			return wrapErrorf(err, "Failed to sync cache")
+		}
+		_, err = ctr.cacheFile.WriteAt([]byte{FS_SUBTREE_FREE},
+			int64(ctr.subTreeOffset(oldIdx)))
+		if err != nil {
+			return wrapErrorf(err, "Failed to free subtree in cache")
+		}
+		if partial[oldIdx] {
+			ctr.cachePartialLut[addresses[oldIdx]] = uint32(newIdx)
+		} else {
+			ctr.cacheIdxLut[addresses[oldIdx]] = uint32(newIdx)
+		}
+	}
+
+	// Truncate the cache file.
+	ctr.allocatedSubTrees = uint32(len(idxs))
+	emptyHeap := uint32Heap([]uint32{})
+	ctr.cacheFreeIdx = &emptyHeap
+	heap.Init(ctr.cacheFreeIdx)
+	if err := ctr.writeCacheHeader(); err != nil {
+		return err
+	}
+	err := ctr.cacheFile.Truncate(int64(
+		ctr.subTreeOffset(ctr.allocatedSubTrees)))
+	if err != nil {
+		return wrapErrorf(err, "Failed to truncate cache")
+	}
+	if err = ctr.cacheFile.Sync(); err != nil {
+		return wrapErrorf(err, "Failed to sync cache")
+	}
+	return nil
+}
+
 func (ctr *fsContainer) Close() Error {
 	var err error
+	ctr.stopSyncGoroutine()
+	if ctr.initialized && ctr.dirty {
+		if err2 := ctr.writeKeyFile(true); err2 != nil {
+			err = multierror.Append(err, wrapErrorf(err2,
+				"Could not sync key file"))
+		}
+	}
+	if ctr.auditFile != nil {
+		if err2 := ctr.syncAuditLog(); err2 != nil {
+			err = multierror.Append(err, err2)
+		}
+		if err2 := ctr.auditFile.Close(); err2 != nil {
+			err = multierror.Append(err, wrapErrorf(err2,
+				"Could not close audit log"))
+		}
+		ctr.auditFile = nil
+	}
+	if ctr.cacheInitialized && !ctr.readOnly {
+		if err2 := ctr.Compact(); err2 != nil {
+			log.Logf("Failed to compact cache: %v", err2)
+		}
+	}
 	if err2 := ctr.closeCache(); err2 != nil {
 		err = multierror.Append(err, wrapErrorf(err2,
 			"Could not close cache"))
 	}
-	if err2 := ctr.flock.Unlock(); err2 != nil {
+	if ctr.readOnly {
+		// We did not take the lock.
+	} else if err2 := ctr.flock.Unlock(); err2 != nil {
 		err = multierror.Append(err, wrapErrorf(err2,
 			"Could not release file lock"))
 	}
@@ -0,0 +1,47 @@
+// +build windows js wasip1
+
+package xmssmt
+
+import (
+	"os"
+)
+
+// Whether the subtrees are mmap'd, in which case they are aligned to pages.
+const subTreesMmaped = false
+
+// A subtree in the cache file of a fsContainer.
+//
+// On Windows, a view of a file can only be mapped at multiples of the
+// allocation granularity and the file cannot be resized while mapped.
+// On js and wasip1, there is no mmap at all.  Thus instead of using mmap,
+// buf is a copy of the subtree that is written back to the cache file
+// on unmap().
+type mmapedSubTree struct {
+	file   *os.File
+	offset int64
+	buf    []byte
+}
+
+func (ctr *fsContainer) mmapSubTree(idx uint32) (mmapedSubTree, error) {
+	offset := int64(ctr.subTreeOffset(idx))
+	buf := make([]byte, ctr.subTreeSize()+13)
+	if _, err := ctr.cacheFile.ReadAt(buf, offset); err != nil {
+		return mmapedSubTree{}, err
+	}
+	return mmapedSubTree{
+		file:   ctr.cacheFile,
+		offset: offset,
+		buf:    buf,
+	}, nil
+}
+
+func (st mmapedSubTree) flush() error {
+	if _, err := st.file.WriteAt(st.buf, st.offset); err != nil {
+		return err
+	}
+	return st.file.Sync()
+}
+
+func (st mmapedSubTree) unmap() error {
+	return st.flush()
+}
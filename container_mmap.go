@@ -0,0 +1,47 @@
+// +build !windows,!js,!wasip1
+
+package xmssmt
+
+import (
+	"github.com/edsrzf/mmap-go"
+)
+
+// Whether the subtrees are mmap'd, in which case they are aligned to pages.
+const subTreesMmaped = true
+
+// A subtree in the cache file of a fsContainer, which is mmap'd such that
+// changes to buf are written back to the cache file.
+type mmapedSubTree struct {
+	mmap mmap.MMap
+	buf  []byte
+}
+
+func (ctr *fsContainer) mmapSubTree(idx uint32) (mmapedSubTree, error) {
+	realOffset := ctr.subTreeOffset(idx)
+	offset := realOffset % ctr.pageSize
+
+	buf, err := mmap.MapRegion(
+		ctr.cacheFile,
+		ctr.subTreeSize()+13+offset, // length
+		mmap.RDWR,                   // prot
+		0,                           // flags
+		int64(realOffset-offset),
+	)
+
+	if err != nil {
+		return mmapedSubTree{}, err
+	}
+
+	return mmapedSubTree{
+		mmap: buf,
+		buf:  buf[offset:],
+	}, nil
+}
+
+func (st mmapedSubTree) flush() error {
+	return st.mmap.Flush()
+}
+
+func (st mmapedSubTree) unmap() error {
+	return st.mmap.Unmap()
+}
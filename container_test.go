@@ -1,10 +1,12 @@
 package xmssmt
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestFSContainerCache(t *testing.T) {
@@ -100,6 +102,13 @@ func TestFSContainerCache(t *testing.T) {
 		buf1[i] = byte(i * 2)
 	}
 
+	// Subtrees that are not committed are dropped on reopening.
+	for _, addr := range []SubTreeAddress{addr1, addr2} {
+		if err = ctr.(SubTreeCommitter).CommitSubTree(addr); err != nil {
+			t.Fatalf("CommitSubTree: %v", err)
+		}
+	}
+
 	if err = ctr.Close(); err != nil {
 		t.Fatalf("Close(): %v", err)
 	}
@@ -164,3 +173,458 @@ func TestFSContainerCache(t *testing.T) {
 		t.Fatalf("Close(): %v", err)
 	}
 }
+
+func TestSplitPrivateKeyContainer(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+
+	// We use the filesystem container only for the seqno.
+	store, err := OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	cache := NewMemorySubTreeCache()
+	ctr := NewSplitPrivateKeyContainer(store, cache)
+	if ctr.CacheInitialized() {
+		t.Fatalf("Cache should not be initialized at this point")
+	}
+
+	seed := make([]byte, 16)
+	sk, pk, err := ctx.DeriveInto(ctr, seed, seed, seed)
+	if err != nil {
+		t.Fatalf("DeriveInto(): %v", err)
+	}
+	if !ctr.CacheInitialized() || !cache.HasSubTree(SubTreeAddress{0, 0}) {
+		t.Fatalf("Subtrees should be cached in the SubTreeCache")
+	}
+
+	msg := []byte("test message")
+	for i := 0; i < 20; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// The cache is gone, but the seqno has been stored.
+	store, err = OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	ctr = NewSplitPrivateKeyContainer(store, NewMemorySubTreeCache())
+	sk, _, _, err = LoadPrivateKeyFrom(ctr)
+	if err != nil {
+		t.Fatalf("LoadPrivateKeyFrom(): %v", err)
+	}
+	if sk.SeqNo() != 20 {
+		t.Fatalf("SeqNo() = %d instead of 20", sk.SeqNo())
+	}
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+}
+
+func TestMemoryPrivateKeyContainer(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPairInto(NewMemoryPrivateKeyContainer())
+	if err != nil {
+		t.Fatalf("GenerateKeyPairInto(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	for i := 0; i < 20; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+	if sk.SeqNo() != 20 {
+		t.Fatalf("SeqNo() = %d instead of 20", sk.SeqNo())
+	}
+}
+
+func TestFSContainerCommitSubTree(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctr, err := OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	params := Params{SHAKE, 16, 8, 2, 16, RFC}
+	err = ctr.Reset(make([]byte, params.PrivateKeySize()), params)
+	if err != nil {
+		t.Fatalf("Reset(): %v", err)
+	}
+
+	addr1 := SubTreeAddress{0, 1}
+	addr2 := SubTreeAddress{0, 2}
+	for _, addr := range []SubTreeAddress{addr1, addr2} {
+		buf, _, err := ctr.GetSubTree(addr)
+		if err != nil {
+			t.Fatalf("GetSubTree: %v", err)
+		}
+		for i := 0; i < len(buf); i++ {
+			buf[i] = byte(i)
+		}
+	}
+	err = ctr.(SubTreeCommitter).CommitSubTree(addr1)
+	if err != nil {
+		t.Fatalf("CommitSubTree: %v", err)
+	}
+	if err = ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// Only the committed subtree should survive
+	ctr, err = OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	if !ctr.HasSubTree(addr1) || ctr.HasSubTree(addr2) {
+		t.Fatalf("Only the committed subtree should be cached")
+	}
+	buf, exists, err := ctr.GetSubTree(addr1)
+	if err != nil || !exists {
+		t.Fatalf("GetSubTree: %v", err)
+	}
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != byte(i) {
+			t.Fatalf("Committed subtree differs")
+		}
+	}
+	if err = ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+}
+
+func TestFSContainerCompact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctr, err := OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	params := Params{SHAKE, 16, 8, 2, 16, RFC}
+	err = ctr.Reset(make([]byte, params.PrivateKeySize()), params)
+	if err != nil {
+		t.Fatalf("Reset(): %v", err)
+	}
+
+	for i := uint64(0); i < 4; i++ {
+		addr := SubTreeAddress{0, i}
+		buf, _, err := ctr.GetSubTree(addr)
+		if err != nil {
+			t.Fatalf("GetSubTree: %v", err)
+		}
+		for j := 0; j < len(buf); j++ {
+			buf[j] = byte(i) + byte(j)
+		}
+		err = ctr.(SubTreeCommitter).CommitSubTree(addr)
+		if err != nil {
+			t.Fatalf("CommitSubTree: %v", err)
+		}
+	}
+
+	fileSize := func() int64 {
+		fi, err := os.Stat(dir + "/key.cache")
+		if err != nil {
+			t.Fatalf("Stat: %v", err)
+		}
+		return fi.Size()
+	}
+	sizeBefore := fileSize()
+
+	for i := uint64(0); i < 2; i++ {
+		if err = ctr.DropSubTree(SubTreeAddress{0, i}); err != nil {
+			t.Fatalf("DropSubTree: %v", err)
+		}
+	}
+	if err = ctr.(CacheCompacter).Compact(); err != nil {
+		t.Fatalf("Compact(): %v", err)
+	}
+	if sizeAfter := fileSize(); sizeAfter >= sizeBefore {
+		t.Fatalf("Compact() did not shrink the cache: %d >= %d",
+			sizeAfter, sizeBefore)
+	}
+	if err = ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	ctr, err = OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	addrs, err := ctr.ListSubTrees()
+	if err != nil {
+		t.Fatalf("ListSubTrees: %v", err)
+	}
+	if len(addrs) != 2 {
+		t.Fatalf("Expected 2 cached subtrees, got %d", len(addrs))
+	}
+	for i := uint64(2); i < 4; i++ {
+		buf, exists, err := ctr.GetSubTree(SubTreeAddress{0, i})
+		if err != nil || !exists {
+			t.Fatalf("GetSubTree: %v", err)
+		}
+		for j := 0; j < len(buf); j++ {
+			if buf[j] != byte(i)+byte(j) {
+				t.Fatalf("Subtree %d differs after compaction", i)
+			}
+		}
+	}
+	if err = ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+}
+
+func TestFSContainerSyncPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctr, err := OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	params := Params{SHAKE, 16, 8, 2, 16, RFC}
+	err = ctr.Reset(make([]byte, params.PrivateKeySize()), params)
+	if err != nil {
+		t.Fatalf("Reset(): %v", err)
+	}
+	fsCtr := ctr.(*fsContainer)
+
+	if err = ctr.(SyncPolicySetter).SetSyncPolicy(SyncOnBorrow); err != nil {
+		t.Fatalf("SetSyncPolicy(): %v", err)
+	}
+	if err = ctr.SetSeqNo(5); err != nil {
+		t.Fatalf("SetSeqNo(): %v", err)
+	}
+	if !fsCtr.dirty {
+		t.Fatalf("SetSeqNo() should not sync with SyncOnBorrow")
+	}
+	if _, err = ctr.BorrowSeqNos(3); err != nil {
+		t.Fatalf("BorrowSeqNos(): %v", err)
+	}
+	if fsCtr.dirty {
+		t.Fatalf("BorrowSeqNos() should sync with SyncOnBorrow")
+	}
+
+	err = ctr.(SyncPolicySetter).SetSyncPolicy(SyncPeriodic(time.Millisecond))
+	if err != nil {
+		t.Fatalf("SetSyncPolicy(): %v", err)
+	}
+	if err = ctr.SetSeqNo(7); err != nil {
+		t.Fatalf("SetSeqNo(): %v", err)
+	}
+	for i := 0; ; i++ {
+		fsCtr.keyMux.Lock()
+		dirty := fsCtr.dirty
+		fsCtr.keyMux.Unlock()
+		if !dirty {
+			break
+		}
+		if i == 1000 {
+			t.Fatalf("Key file was not synced periodically")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err = ctr.SetSeqNo(9); err != nil {
+		t.Fatalf("SetSeqNo(): %v", err)
+	}
+	if err = ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	ctr, err = OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	defer ctr.Close()
+	seqNo, lostSigs, err := ctr.GetSeqNo()
+	if err != nil {
+		t.Fatalf("GetSeqNo(): %v", err)
+	}
+	if seqNo != 9 || lostSigs != 0 {
+		t.Fatalf("GetSeqNo() = %d, %d; expected 9, 0", seqNo, lostSigs)
+	}
+}
+
+func TestFSContainerReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err = OpenFSPrivateKeyContainerReadOnly(dir + "/key"); err == nil {
+		t.Fatalf("Opening a missing container read-only should fail")
+	}
+
+	ctr, err := OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer: %v", err)
+	}
+	defer ctr.Close()
+	params := Params{SHAKE, 16, 8, 2, 16, RFC}
+	err = ctr.Reset(make([]byte, params.PrivateKeySize()), params)
+	if err != nil {
+		t.Fatalf("Reset(): %v", err)
+	}
+	if err = ctr.SetSeqNo(3); err != nil {
+		t.Fatalf("SetSeqNo(): %v", err)
+	}
+	addr := SubTreeAddress{0, 1}
+	buf, _, err := ctr.GetSubTree(addr)
+	if err != nil {
+		t.Fatalf("GetSubTree: %v", err)
+	}
+	for i := 0; i < len(buf); i++ {
+		buf[i] = byte(i)
+	}
+	if err = ctr.(SubTreeCommitter).CommitSubTree(addr); err != nil {
+		t.Fatalf("CommitSubTree: %v", err)
+	}
+
+	// Open it read-only while it is still in use.
+	roCtr, err := OpenFSPrivateKeyContainerReadOnly(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainerReadOnly: %v", err)
+	}
+	seqNo, _, err := roCtr.GetSeqNo()
+	if err != nil || seqNo != 3 {
+		t.Fatalf("GetSeqNo() = %d, %v", seqNo, err)
+	}
+	if !roCtr.HasSubTree(addr) {
+		t.Fatalf("Subtree is missing")
+	}
+	roBuf, exists, err := roCtr.GetSubTree(addr)
+	if err != nil || !exists {
+		t.Fatalf("GetSubTree: %v", err)
+	}
+	if !reflect.DeepEqual(roBuf, buf) {
+		t.Fatalf("Subtree differs")
+	}
+
+	if _, _, err = roCtr.GetSubTree(SubTreeAddress{0, 2}); err == nil {
+		t.Fatalf("GetSubTree() should not allocate subtrees")
+	}
+	if err = roCtr.SetSeqNo(4); err == nil {
+		t.Fatalf("SetSeqNo() should fail")
+	}
+	if _, err = roCtr.BorrowSeqNos(4); err == nil {
+		t.Fatalf("BorrowSeqNos() should fail")
+	}
+	if err = roCtr.DropSubTree(addr); err == nil {
+		t.Fatalf("DropSubTree() should fail")
+	}
+	if err = roCtr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// The original container should still be usable.
+	if err = ctr.SetSeqNo(4); err != nil {
+		t.Fatalf("SetSeqNo(): %v", err)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 2, 1, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err = sk.Sign([]byte("test message")); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+	_, err = sk.Sign([]byte("test message"))
+	if !errors.Is(err, ErrExhausted) || errors.Is(err, ErrLocked) {
+		t.Fatalf("Sign() of exhausted key: %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	err = ioutil.WriteFile(dir+"/key.cache", []byte("short"), 0600)
+	if err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	ctr, err := OpenFSPrivateKeyContainer(dir + "/key")
+	if !errors.Is(err, ErrCorruptCache) {
+		t.Fatalf("OpenFSPrivateKeyContainer() with corrupt cache: %v", err)
+	}
+	ctr.Close()
+
+	err = ioutil.WriteFile(dir+"/key", make([]byte, 100), 0600)
+	if err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	_, _, _, err = LoadPrivateKey(dir + "/key")
+	if !errors.Is(err, ErrWrongMagic) {
+		t.Fatalf("LoadPrivateKey() of garbage: %v", err)
+	}
+
+	err = NewMemoryPrivateKeyContainer().ResetCache()
+	if !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("ResetCache() of new container: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+// +build !windows
+
+package xmssmt
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Renames the file at from to to and syncs the parent directory such that
+// the rename is persisted.
+func replaceFile(from, to string) error {
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+
+	dirName := filepath.Dir(to)
+	dir, err := os.Open(dirName)
+	if err != nil {
+		return err
+	}
+
+	if err = dir.Sync(); err != nil {
+		dir.Close()
+		return err
+	}
+
+	return dir.Close()
+}
+
+// Syncs the directory such that the creation and removal of files in it
+// are persisted.
+func syncDir(dirName string) error {
+	dir, err := os.Open(dirName)
+	if err != nil {
+		return err
+	}
+
+	if err = dir.Sync(); err != nil {
+		dir.Close()
+		return err
+	}
+
+	return dir.Close()
+}
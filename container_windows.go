@@ -0,0 +1,28 @@
+// +build windows
+
+package xmssmt
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// Replaces the file at to by the file at from.  MOVEFILE_WRITE_THROUGH
+// ensures the move is persisted before we return.
+func replaceFile(from, to string) error {
+	fromPtr, err := windows.UTF16PtrFromString(from)
+	if err != nil {
+		return err
+	}
+	toPtr, err := windows.UTF16PtrFromString(to)
+	if err != nil {
+		return err
+	}
+	return windows.MoveFileEx(fromPtr, toPtr,
+		windows.MOVEFILE_REPLACE_EXISTING|windows.MOVEFILE_WRITE_THROUGH)
+}
+
+// Windows does not support syncing a directory.  Metadata changes on NTFS
+// are journaled.
+func syncDir(dirName string) error {
+	return nil
+}
@@ -3,12 +3,12 @@ package xmssmt
 // The core of XMSS and XMSSMT.
 
 import (
-	"github.com/cespare/xxhash"
-
+	"bytes"
 	"container/heap"
 	"encoding/binary"
-	"runtime"
+	"sort"
 	"sync"
+	"time"
 )
 
 // Represents a height t merkle tree of n-byte strings T[i,j] as
@@ -22,10 +22,20 @@ import (
 //     T[0,0] T[0,1] T[0,2]  T[0,3]  ...  T[0,2^(t-1)-1]
 //
 // as an (2^t-1)*n byte array.
+//
+// If lowest is not zero, only the nodes T[i,j] with i ≥ lowest are stored,
+// in the same order, which is an (2^(t-lowest)-1)*n byte array.
+// See genSubTreeStreamInto().
 type merkleTree struct {
 	height uint32
 	n      uint32
 	buf    []byte
+	lowest uint32
+
+	// If not nil, computes the nodes T[i,j] with i ≤ lowest of the given
+	// block of 2^lowest leafs into a tree of height lowest+1, so that
+	// AuthPathInto() can be used although lowest is not zero.
+	genBlock func(block uint32, bt merkleTree)
 }
 
 // A scratchpad used by a single goroutine to avoid memory allocation.
@@ -35,6 +45,15 @@ type scratchPad struct {
 	wotsLen uint32
 
 	hash hashScratchPad
+
+	// Buffers used to verify and create WOTS+ signatures.
+	wotsLengths []uint8     // see wotsChainLengthsInto()
+	wotsStart   []uint16    // see wotsPkFromSigInto()
+	wotsSteps   []uint16    // see wotsPkFromSigInto()
+	wotsChains  []wotsChain // see wotsGenChainsXNInto()
+
+	msgBuf  []byte // 64 bytes for the message hash, see hashMessageInto()
+	nodeBuf []byte // message hash and nodes while signing and verifying
 }
 
 // Allocates memory for a merkle tree of n-byte strings of the given height.
@@ -51,29 +70,57 @@ func merkleTreeFromBuf(buf []byte, height, n uint32) merkleTree {
 	}
 }
 
+// Allocates memory for the nodes at height lowest and above of a merkle
+// tree of n-byte strings of the given height.
+func newMerkleTreeTop(height, lowest, n uint32) merkleTree {
+	mt := newMerkleTree(height-lowest, n)
+	mt.height = height
+	mt.lowest = lowest
+	return mt
+}
+
 // Returns the root of the tree
 func (mt *merkleTree) Root() []byte {
-	return mt.Node(mt.height-1, 0)
+	// The root is the last node in the buffer.
+	return mt.buf[len(mt.buf)-int(mt.n):]
 }
 
 // Returns a slice to the given node.
 func (mt *merkleTree) Node(height, index uint32) []byte {
-	ptr := mt.n * ((1 << mt.height) - (1 << (mt.height - height)) + index)
+	if height < mt.lowest {
+		panic("Node is not stored")
+	}
+	top, height := mt.height-mt.lowest, height-mt.lowest
+	ptr := mt.n * ((1 << top) - (1 << (top - height)) + index)
 	return mt.buf[ptr : ptr+mt.n]
 }
 
 // Returns the authentication path for the given leaf
 func (mt *merkleTree) AuthPath(leaf uint32) []byte {
 	ret := make([]byte, mt.n*(mt.height-1))
+	mt.AuthPathInto(leaf, ret)
+	return ret
+}
+
+// Writes the authentication path for the given leaf into out, which
+// should be n*(height-1) bytes.  If not all nodes are stored, the others
+// are computed with mt.genBlock.
+func (mt *merkleTree) AuthPathInto(leaf uint32, out []byte) {
+	if mt.lowest != 0 {
+		mt.authPathFromTopInto(leaf, out)
+		return
+	}
 	node := leaf
+	offset := uint32(0) // offset of the first node at height i in buf
 	var i uint32
 	for i = 0; i < mt.height-1; i++ {
 		// node ^ 1 is the offset of the sibling of node
-		copy(ret[i*mt.n:], mt.Node(i, node^1))
+		ptr := (offset + (node ^ 1)) * mt.n
+		copy(out[i*mt.n:(i+1)*mt.n], mt.buf[ptr:ptr+mt.n])
 		// node / 2 is the offset of the parent of node.
 		node = node / 2
+		offset += 1 << (mt.height - 1 - i)
 	}
-	return ret
 }
 
 // Compute a subtree by expanding the secret seed into WOTS+ keypairs
@@ -91,9 +138,25 @@ func (ctx *Context) genSubTree(pad scratchPad, skSeed, pubSeed []byte,
 // mt should have height=ctx.treeHeight+1 and n=ctx.p.N.
 func (ctx *Context) genSubTreeInto(pad scratchPad, skSeed []byte,
 	ph precomputedHashes, sta SubTreeAddress, mt merkleTree) {
+	ctx.genSubTreeFromInto(pad, skSeed, ph, sta, mt, 0, nil)
+}
+
+// Like genSubTreeInto, but assumes the first start leafs are already in mt.
+// If checkpoint is not nil, it is called with the number of leafs
+// written so far, every ctx.subTreeCheckpointInterval() leafs, while
+// no other leafs are being written.  See SubTreeCheckpointer.
+//
+// If mt only stores the nodes at height mt.lowest and above, the subtree
+// is computed by genSubTreeStreamInto(), which does not support start and
+// checkpoint.
+func (ctx *Context) genSubTreeFromInto(pad scratchPad, skSeed []byte,
+	ph precomputedHashes, sta SubTreeAddress, mt merkleTree, start uint32,
+	checkpoint func(leafs uint32)) {
+	if mt.lowest != 0 {
+		ctx.genSubTreeStreamInto(pad, skSeed, ph, sta, mt)
+		return
+	}
 
-	// TODO we compute the leafs in parallel.  Is it worth computing
-	// the internal nodes in parallel?
 	log.Logf("Generating subtree %v ...", sta)
 
 	var otsAddr, lTreeAddr, nodeAddr address
@@ -107,41 +170,83 @@ func (ctx *Context) genSubTreeInto(pad scratchPad, skSeed []byte,
 
 	// First, compute the leafs
 	var idx uint32
+	var total uint32 = 1 << ctx.treeHeight
+
+	// The height up to which the internal nodes have been computed.
+	var doneHeight uint32
+
+	reportProgress := ctx.newProgressReporter()
+
+	if gen := ctx.leafGenerator; gen != nil {
+		// The leafs are stored consecutively at the start of mt.buf.
+		err := gen.GenerateLeafs(ph.pubSeed, skSeed, sta, 0,
+			mt.buf[:ctx.p.N<<ctx.treeHeight])
+		if err == nil {
+			reportProgress(total)
+			ctx.genInternalNodesInto(pad, ph, nodeAddr, mt,
+				1, ctx.treeHeight, 0, total)
+			return
+		}
+		log.Logf("LeafGenerator failed for subtree %v: %v", sta, err)
+
+		// It might have overwritten the leafs we were to resume from.
+		start = 0
+	}
+
+	// The leafs are computed in chunks, after each of which we checkpoint.
+	chunk := total
+	if checkpoint != nil {
+		chunk = ctx.subTreeCheckpointInterval()
+	}
 
 	if ctx.Threads == 1 {
-		for idx = 0; idx < (1 << ctx.treeHeight); idx++ {
+		if start != 0 {
+			reportProgress(start)
+		}
+		for idx = start; idx < total; idx++ {
 			lTreeAddr.setLTree(idx)
 			otsAddr.setOTS(idx)
 			ctx.genLeafInto(pad, ph, lTreeAddr, otsAddr, mt.Node(0, idx))
+			reportProgress(1)
+			if checkpoint != nil && (idx+1-start)%chunk == 0 &&
+				idx+1 < total {
+				checkpoint(idx + 1)
+			}
 		}
 	} else {
 		// The code in this branch does exactly the same as in
-		// the branch above, but then in parallel.
+		// the branch above, but then in parallel on the workers of
+		// the Context.  Each batch of leafs is the bottom of a small
+		// subtree of which we compute the internal nodes in the same job.
 		wg := &sync.WaitGroup{}
-		mux := &sync.Mutex{}
-		var perBatch uint32 = 32
-		threads := ctx.Threads
-		if threads == 0 {
-			threads = runtime.NumCPU()
-		}
-		wg.Add(threads)
-		for i := 0; i < threads; i++ {
-			go func(lTreeAddr, otsAddr address) {
-				pad := ctx.newScratchPad()
-				var ourIdx uint32
-				for {
-					mux.Lock()
-					ourIdx = idx
-					idx += perBatch
-					mux.Unlock()
-					if ourIdx >= 1<<ctx.treeHeight {
-						break
-					}
-					ourEnd := ourIdx + perBatch
-					if ourEnd > 1<<ctx.treeHeight {
-						ourEnd = 1 << ctx.treeHeight
-					}
-					for ; ourIdx < ourEnd; ourIdx++ {
+		pool := ctx.workerPool()
+		batchHeight := ctx.leafBatchHeightFor(total)
+		var perBatch uint32 = 1 << batchHeight
+
+		// We resume at a whole batch and compute the internal nodes
+		// of the batches before it ourselves.
+		start &^= perBatch - 1
+		if start != 0 {
+			reportProgress(start)
+			ctx.genInternalNodesInto(pad, ph, nodeAddr, mt,
+				1, batchHeight, 0, start)
+		}
+
+		if chunk < perBatch {
+			chunk = perBatch
+		}
+		chunk &^= perBatch - 1
+		for chunkStart := start; chunkStart < total; chunkStart += chunk {
+			chunkEnd := total
+			if chunk < total-chunkStart {
+				chunkEnd = chunkStart + chunk
+			}
+			for idx = chunkStart; idx < chunkEnd; idx += perBatch {
+				wg.Add(1)
+				start := idx
+				pool.jobs <- func(pad scratchPad) {
+					lTreeAddr, otsAddr := lTreeAddr, otsAddr
+					for ourIdx := start; ourIdx < start+perBatch; ourIdx++ {
 						lTreeAddr.setLTree(ourIdx)
 						otsAddr.setOTS(ourIdx)
 						ctx.genLeafInto(
@@ -151,19 +256,73 @@ func (ctx *Context) genSubTreeInto(pad scratchPad, skSeed []byte,
 							otsAddr,
 							mt.Node(0, ourIdx))
 					}
+					ctx.genInternalNodesInto(pad, ph, nodeAddr, mt,
+						1, batchHeight, start, start+perBatch)
+					reportProgress(perBatch)
+					wg.Done()
 				}
-				wg.Done()
-			}(lTreeAddr, otsAddr)
+			}
+
+			wg.Wait() // wait for all jobs of this chunk to finish
+			if checkpoint != nil && chunkEnd < total {
+				checkpoint(chunkEnd)
+			}
 		}
+		doneHeight = batchHeight
+	}
 
-		wg.Wait() // wait for all workers to finish
+	// Next, compute the remaining internal nodes and root
+	ctx.genInternalNodesInto(pad, ph, nodeAddr, mt,
+		doneHeight+1, ctx.treeHeight, 0, total)
+}
+
+// Returns a function to which the number of leafs of a subtree that have
+// been generated is reported, which in turn reports the total so far to the
+// callback set by SetProgressFunc, if any.
+func (ctx *Context) newProgressReporter() func(leafs uint32) {
+	progress := ctx.progress
+	var doneLeafs uint64
+	progressMux := &sync.Mutex{}
+	return func(leafs uint32) {
+		if progress == nil {
+			return
+		}
+		progressMux.Lock()
+		doneLeafs += uint64(leafs)
+		progress(doneLeafs, 1<<ctx.treeHeight)
+		progressMux.Unlock()
 	}
+}
 
-	// Next, compute the internal nodes and root
-	var height uint32
-	for height = 1; height <= ctx.treeHeight; height++ {
+// Computes the internal nodes of mt at heights fromHeight up to and
+// including toHeight above the leafs with index in [start, end).
+// Assumes the nodes at height fromHeight-1 are already computed and
+// that start and end are multiples of 2^toHeight.
+func (ctx *Context) genInternalNodesInto(pad scratchPad, ph precomputedHashes,
+	nodeAddr address, mt merkleTree, fromHeight, toHeight, start,
+	end uint32) {
+	var height, idx uint32
+	lanes := ctx.hLanes()
+	for height = fromHeight; height <= toHeight; height++ {
 		nodeAddr.setTreeHeight(height - 1)
-		for idx = 0; idx < (1 << (ctx.treeHeight - height)); idx++ {
+		idx = start >> height
+		last := end >> height
+
+		// Two- or fourway vectorized
+		for ; lanes != 0 && idx+lanes <= last; idx += lanes {
+			var left, right, out [4][]byte
+			var addrs [4]address
+			for j := uint32(0); j < lanes; j++ {
+				addrs[j] = nodeAddr
+				addrs[j].setTreeIndex(idx + j)
+				left[j] = mt.Node(height-1, 2*(idx+j))
+				right[j] = mt.Node(height-1, 2*(idx+j)+1)
+				out[j] = mt.Node(height, idx+j)
+			}
+			ctx.hX4Into(pad, left, right, ph.pubSeed, addrs, out)
+		}
+
+		for ; idx < last; idx++ {
 			nodeAddr.setTreeIndex(idx)
 			ctx.hInto(pad, mt.Node(height-1, 2*idx),
 				mt.Node(height-1, 2*idx+1),
@@ -178,11 +337,29 @@ func (ctx *Context) lTreeInto(pad scratchPad, wotsPk []byte, ph precomputedHashe
 	addr address, out []byte) {
 	var height uint32 = 0
 	var l uint32 = ctx.wotsLen
+	lanes := ctx.hLanes()
+	n := ctx.p.N
 	for l > 1 {
 		addr.setTreeHeight(height)
 		parentNodes := l >> 1
 		var i uint32
-		for i = 0; i < parentNodes; i++ {
+
+		// Two- or fourway vectorized.  The nodes of a batch are read before
+		// they are overwritten, so we can hash in place.
+		for ; lanes != 0 && i+lanes <= parentNodes; i += lanes {
+			var left, right, out [4][]byte
+			var addrs [4]address
+			for j := uint32(0); j < lanes; j++ {
+				addrs[j] = addr
+				addrs[j].setTreeIndex(i + j)
+				left[j] = wotsPk[2*(i+j)*n : (2*(i+j)+1)*n]
+				right[j] = wotsPk[(2*(i+j)+1)*n : (2*(i+j)+2)*n]
+				out[j] = wotsPk[(i+j)*n : (i+j+1)*n]
+			}
+			ctx.hX4Into(pad, left, right, ph.pubSeed, addrs, out)
+		}
+
+		for ; i < parentNodes; i++ {
 			addr.setTreeIndex(i)
 			ctx.hInto(pad, wotsPk[2*i*ctx.p.N:(2*i+1)*ctx.p.N],
 				wotsPk[(2*i+1)*ctx.p.N:(2*i+2)*ctx.p.N],
@@ -229,6 +406,46 @@ func (ctx *Context) subTreePathForSeqNo(seqNo SignatureSeqNo) (
 	return
 }
 
+// The subtrees used by the signature with a given sequence number.
+// See Context.SubTreePlanForSeqNo().
+type SubTreePlan struct {
+	SeqNo SignatureSeqNo
+
+	// SubTrees[i] is the subtree on layer i, starting at the lowest layer,
+	// and Leafs[i] the index of the leaf in it that signs the message
+	// (for i=0) or the root of SubTrees[i-1].
+	SubTrees []SubTreeAddress
+	Leafs    []uint32
+}
+
+// Returns the subtrees that are used for the first time by this signature,
+// which are those of which it uses the leftmost leaf.  Unless these are
+// precomputed, see PrivateKey.Precompute(), they are generated when the
+// signature is created, which is slow.
+func (plan SubTreePlan) NewSubTrees() []SubTreeAddress {
+	var ret []SubTreeAddress
+	for i, leaf := range plan.Leafs {
+		if leaf != 0 {
+			break
+		}
+		ret = append(ret, plan.SubTrees[i])
+	}
+	return ret
+}
+
+// Returns the subtrees and their leafs that are used by the signature with
+// the given sequence number, so that the rollover to new subtrees can be
+// planned for.
+func (ctx *Context) SubTreePlanForSeqNo(seqNo SignatureSeqNo) (
+	SubTreePlan, Error) {
+	if uint64(seqNo) > ctx.p.MaxSignatureSeqNo() {
+		return SubTreePlan{}, errorf(
+			"Signature sequence number %d is out of range", seqNo)
+	}
+	stas, leafs := ctx.subTreePathForSeqNo(seqNo)
+	return SubTreePlan{SeqNo: seqNo, SubTrees: stas, Leafs: leafs}, nil
+}
+
 // Returns the given subtree, either by loading it from the cache,
 // or generating it.
 func (sk *PrivateKey) getSubTree(pad scratchPad, sta SubTreeAddress) (
@@ -243,9 +460,17 @@ func (sk *PrivateKey) getSubTree(pad scratchPad, sta SubTreeAddress) (
 	var parentTreeReady bool
 	var exists bool
 	var buf []byte
+	var resume uint32 // leafs generated before, see SubTreeCheckpointer
+	checkpointer, canCheckpoint := sk.ctr.(SubTreeCheckpointer)
+
+	// Only subtrees of which the leafs are cached are checkpointed.
+	canCheckpoint = canCheckpoint && sk.lowestCachedHeight == 0
 
 	sk.mux.Lock()
 	for {
+		if _, cached := sk.subTreeReady[sta]; !cached {
+			sk.evictSubTrees()
+		}
 		buf, exists, err = sk.ctr.GetSubTree(sta)
 		subTreeReady, exists2 := sk.subTreeReady[sta]
 		if err != nil {
@@ -276,6 +501,9 @@ func (sk *PrivateKey) getSubTree(pad scratchPad, sta SubTreeAddress) (
 		// The sub tree does not yet exist.  We will have to fill it.
 		sk.subTreeReady[sta] = false
 		sk.subTreeChecked[sta] = true
+		if canCheckpoint {
+			resume = sk.subTreeProgress(checkpointer, sta, buf)
+		}
 		break
 	}
 
@@ -285,10 +513,18 @@ func (sk *PrivateKey) getSubTree(pad scratchPad, sta SubTreeAddress) (
 	}
 	sk.mux.Unlock()
 
-	treeBuf := buf[:sk.ctx.p.BareSubTreeSize()]
-	mtDeref := merkleTreeFromBuf(treeBuf, sk.ctx.treeHeight+1, sk.ctx.p.N)
+	bareSize := sk.ctx.p.bareSubTreeSizeFrom(sk.lowestCachedHeight)
+	mtDeref := sk.cachedMerkleTree(sta, buf[:bareSize])
 	mt = &mtDeref
-	wotsSig = buf[sk.ctx.p.BareSubTreeSize() : sk.ctx.p.BareSubTreeSize()+int(sk.ctx.p.WotsSignatureSize())]
+	wotsSig = buf[bareSize : bareSize+int(sk.ctx.p.WotsSignatureSize())]
+
+	if sk.ctx.metrics != nil {
+		if alreadyDone {
+			sk.ctx.metrics.CacheHit(sta)
+		} else {
+			sk.ctx.metrics.CacheMiss(sta)
+		}
+	}
 
 	if alreadyDone {
 		if !justCheckTheChecksum {
@@ -299,10 +535,15 @@ func (sk *PrivateKey) getSubTree(pad scratchPad, sta SubTreeAddress) (
 
 		// The tree seems ready, but we just need to check whether it
 		// hasn't been corrupted.
-		storedCheckSum := binary.BigEndian.Uint64(buf[len(buf)-8:])
-
 		sk.mux.Lock()
-		intact := storedCheckSum == xxhash.Sum64(buf[:len(buf)-8])
+		intact, legacy := sk.checkCachedSubTree(sta, buf)
+		if intact && isRoot && sk.root != nil &&
+			!bytes.Equal(sk.root, mt.Root()) {
+			// The root might have been taken from the container, see
+			// RootStore.  The cached root subtree is probably wrong.
+			log.Logf("Root of subtree %v does not match the stored root", sta)
+			intact = false
+		}
 		if intact {
 			sk.subTreeChecked[sta] = true
 			sk.mux.Unlock()
@@ -330,12 +571,31 @@ func (sk *PrivateKey) getSubTree(pad scratchPad, sta SubTreeAddress) (
 		}
 
 		// Mark the subtree not-ready
-		log.Logf("Subtree %v is corrupted.  Correcting it ...", sta)
+		if legacy {
+			log.Logf("Subtree %v has an unkeyed checksum.  "+
+				"Regenerating it ...", sta)
+		} else {
+			log.Logf("Subtree %v is corrupted.  Correcting it ...", sta)
+			sk.repairedSubTrees = append(sk.repairedSubTrees, sta)
+		}
 		sk.subTreeReady[sta] = false
 		sk.mux.Unlock()
 	}
 
-	sk.ctx.genSubTreeInto(pad, sk.skSeed, sk.ph, sta, mtDeref)
+	genStart := time.Now()
+	skSeed, ph, release := sk.subTreeHashes(pad, sta)
+	var checkpoint func(leafs uint32)
+	if canCheckpoint {
+		checkpoint = func(leafs uint32) {
+			sk.checkpointSubTree(checkpointer, sta, buf, leafs)
+		}
+	}
+	sk.ctx.genSubTreeFromInto(pad, skSeed, ph, sta, mtDeref, resume,
+		checkpoint)
+	release()
+	if sk.ctx.metrics != nil {
+		sk.ctx.metrics.SubTreeGenerated(sta, time.Since(genStart))
+	}
 
 	// We're not done yet.  We need to generate the WOTS+ signature
 	// (and checksum) and for this, possibly, a few other sub trees.
@@ -349,20 +609,44 @@ func (sk *PrivateKey) getSubTree(pad scratchPad, sta SubTreeAddress) (
 		sk.mux.Unlock()
 	}
 
-	// Called when we were sucessful in the end.
+	// Called when we were sucessful in the end.  Committing the subtree
+	// syncs it to storage, so we don't hold sk.mux meanwhile: no one else
+	// touches buf before the subtree is ready.
 	succeed := func() {
+		sk.mux.Lock()
+		key := append([]byte(nil), sk.checksumKey...)
+		sk.mux.Unlock()
+		defer zeroize(key)
 		binary.BigEndian.PutUint64(buf[len(buf)-8:],
-			xxhash.Sum64(buf[:len(buf)-8]))
-
+			cacheChecksumWithKey(key, sta, buf[:len(buf)-8]))
+		if committer, ok := sk.ctr.(SubTreeCommitter); ok {
+			if err := committer.CommitSubTree(sta); err != nil {
+				log.Logf("Failed to commit subtree %v: %v", sta, err)
+			}
+		}
 		sk.mux.Lock()
 		sk.subTreeReady[sta] = true
-		sk.subTreeChecked[sta] = true
+		// If the checksum mode changed meanwhile, it's checked again.
+		sk.subTreeChecked[sta] = bytes.Equal(key, sk.checksumKey)
 		sk.cond.Broadcast()
 		sk.mux.Unlock()
 	}
 
 	// Generate WOTS+ signature --- at least, if we're not the root.
 	if isRoot {
+		// The root might have been taken from the container, see RootStore.
+		if sk.root != nil && !bytes.Equal(sk.root, mt.Root()) {
+			sk.mux.Lock()
+			if err = sk.ctr.DropSubTree(sta); err != nil {
+				log.Logf("Failed to drop subtree %v: %v", sta, err)
+			}
+			delete(sk.subTreeReady, sta)
+			delete(sk.subTreeChecked, sta)
+			sk.cond.Broadcast()
+			sk.mux.Unlock()
+			return nil, nil, errorf(
+				"Generated root does not match the stored root")
+		}
 		succeed()
 		return
 	}
@@ -401,56 +685,157 @@ func (sk *PrivateKey) getSubTree(pad scratchPad, sta SubTreeAddress) (
 	otsAddr := parentSta.address()
 	leafIdx := uint32(sta.Tree & ((1 << sk.ctx.treeHeight) - 1))
 	otsAddr.setOTS(leafIdx)
+	_, ph, release = sk.subTreeHashes(pad, parentSta)
 	sk.ctx.wotsSignInto(
 		pad,
 		mt.Root(),
-		sk.ph,
+		ph,
 		otsAddr,
 		wotsSig)
+	release()
 	succeed()
 	return
 }
 
 // Gets the next free sequence number
 func (sk *PrivateKey) getSeqNo() (SignatureSeqNo, Error) {
+	return sk.getSeqNos(1)
+}
+
+// Reserves the given number of consecutive signature sequence numbers
+// and returns the first.  See getSeqNo().
+func (sk *PrivateKey) getSeqNos(amount uint32) (SignatureSeqNo, Error) {
 	sk.mux.Lock()
 	defer sk.mux.Unlock()
 
+	// See MoveTo().
+	for sk.relocating {
+		sk.cond.Wait()
+	}
+
+	if sk.zeroized {
+		return 0, errorf("Private key has been zeroized")
+	}
+
 	if uint64(sk.seqNo) == sk.ctx.p.MaxSignatureSeqNo() {
-		return 0, errorf("No unused signatures left")
+		return 0, kindErrorf(ErrExhausted, "No unused signatures left")
+	}
+
+	if sk.monotonicErr != nil {
+		return 0, sk.monotonicErr
+	}
+
+	end := sk.seqNoEnd
+	if sk.leaser != nil {
+		if uint64(sk.seqNo)+uint64(amount) > uint64(sk.leaseEnd) &&
+			sk.seqNo < sk.seqNoEnd {
+			// The sequence numbers left in the current lease are lost.
+			if err := sk.lease(amount); err != nil {
+				return 0, err
+			}
+		}
+		if sk.leaseEnd < end {
+			end = sk.leaseEnd
+		}
+	}
+
+	if sk.seqNo >= end {
+		return 0, kindErrorf(ErrExhausted,
+			"No unused signatures left in range [%d, %d)",
+			sk.seqNoStart, end)
+	}
+
+	if uint64(sk.seqNo)+uint64(amount) > uint64(end) {
+		return 0, errorf(
+			"Only %d of %d signatures left in range [%d, %d)",
+			end-sk.seqNo, amount, sk.seqNoStart, end)
+	}
+
+	if sk.seqNo < sk.seqNoStart {
+		return 0, errorf(
+			"Signature sequence number %d is below the range [%d, %d)",
+			sk.seqNo, sk.seqNoStart, sk.seqNoEnd)
+	}
+
+	if sk.borrowed == 0 && sk.autoBorrow != 0 {
+		// Don't borrow beyond the range we're allowed to use.
+		amount := sk.autoBorrow
+		if uint64(end-sk.seqNo) < uint64(amount) {
+			amount = uint32(end - sk.seqNo)
+		}
+		if err := sk.borrowExactly(amount); err != nil {
+			return 0, err
+		}
+	}
+
+	if sk.ctx.metrics != nil {
+		for i := uint32(0); i < amount; i++ {
+			sk.ctx.metrics.SeqNoUsed(i < sk.borrowed)
+		}
 	}
 
-	if sk.borrowed > 0 {
-		// If we have some borrowed sequence numbers, we can simply use one
-		// of them.
-		sk.borrowed -= 1
+	if sk.borrowed >= amount {
+		// If we have enough borrowed sequence numbers, we can simply use
+		// them.
+		sk.borrowed -= amount
 	} else {
-		// If we didn't borrow sequence numbers, then we have to increment
-		// the sequence number in the container before we continue.
-		err := sk.ctr.SetSeqNo(sk.seqNo + 1)
+		// Otherwise we have to increment the sequence number in the
+		// container before we continue.  This also returns the borrowed
+		// sequence numbers.
+		if sk.monotonic != nil &&
+			uint64(sk.seqNo)+uint64(sk.borrowed) < sk.monotonicValue {
+			return 0, errorf(
+				"Signature sequence number %d might have been used: "+
+					"the monotonic counter is at %d",
+				sk.seqNo, sk.monotonicValue)
+		}
+		err := sk.ctr.SetSeqNo(sk.seqNo + SignatureSeqNo(amount))
+		if err != nil {
+			return 0, err
+		}
+		sk.borrowed = 0
+		err = sk.advanceMonotonic(uint64(sk.seqNo) + uint64(amount))
 		if err != nil {
 			return 0, err
 		}
 	}
 
-	sk.seqNo += 1
+	first := sk.seqNo
+	sk.seqNo += SignatureSeqNo(amount)
 
-	// Check if we need to precompute a subtree
-	if sk.precomputeNextSubTree &&
-		(uint64(sk.seqNo)&((1<<sk.ctx.treeHeight)-1) == 0) {
+	// Check if we need to precompute subtrees
+	if sk.precomputeAhead != 0 && !sk.closing &&
+		uint64(first)>>sk.ctx.treeHeight !=
+			uint64(sk.seqNo)>>sk.ctx.treeHeight {
 		sk.wg.Add(1)
-		go func(sta SubTreeAddress) {
-			log.Logf("Precomputing subtree %v", sta)
-			sk.getSubTree(sk.ctx.newScratchPad(), sta)
-			log.Logf("Finished precomputing subtree %v", sta)
-			sk.wg.Done()
-		}(SubTreeAddress{
-			Layer: 0,
-			Tree:  (uint64(sk.seqNo) >> sk.ctx.treeHeight) + 1,
-		})
+		go sk.precomputeSubTrees(sk.seqNo, sk.precomputeAhead)
 	}
 
-	return sk.seqNo - 1, nil
+	return first, nil
+}
+
+// Leases a new range of at least the given number of sequence numbers
+// from the container.  Assumes sk.mux is held.
+func (sk *PrivateKey) lease(atLeast uint32) Error {
+	amount := sk.leaseSize
+	if amount < atLeast {
+		amount = atLeast
+	}
+	start, end, err := sk.leaser.LeaseSeqNos(amount)
+	if err != nil {
+		return err
+	}
+	log.Logf("Leased signature sequence numbers [%d, %d)", start, end)
+	sk.seqNo = start
+	sk.leaseEnd = end
+	sk.borrowed = 0
+
+	// See DangerousSetSeqNo().
+	emptyHeap := uint32Heap([]uint32{})
+	sk.retiredSeqNos = &emptyHeap
+	heap.Init(sk.retiredSeqNos)
+	sk.leastSeqNoInUse = start
+	return nil
 }
 
 func (pad scratchPad) fBuf() []byte {
@@ -488,17 +873,25 @@ func (pad scratchPad) fX4Buf() []byte {
 func (ctx *Context) newScratchPad() scratchPad {
 	n := ctx.p.N
 	pad := scratchPad{
-		buf:     make([]byte, 19*n+64+n*ctx.wotsLen),
-		n:       n,
-		wotsLen: ctx.wotsLen,
-		hash:    ctx.newHashScratchPad(),
+		buf:         make([]byte, 23*n+64+n*ctx.wotsLen),
+		n:           n,
+		wotsLen:     ctx.wotsLen,
+		hash:        ctx.newHashScratchPad(),
+		wotsLengths: make([]uint8, ctx.wotsLen),
+		wotsStart:   make([]uint16, ctx.wotsLen),
+		wotsSteps:   make([]uint16, ctx.wotsLen),
+		wotsChains:  make([]wotsChain, ctx.wotsLen),
+		msgBuf:      make([]byte, 64),
+		nodeBuf:     make([]byte, n),
 	}
 	return pad
 }
 
+// Creates the PrivateKey.  If root is nil, it is taken from the cached root
+// subtree, which is generated if it is not cached.
 func (ctx *Context) newPrivateKey(pad scratchPad, pubSeed, skSeed, skPrf []byte,
-	seqNo SignatureSeqNo, ctr PrivateKeyContainer) (
-	*PrivateKey, Error) {
+	seqNo SignatureSeqNo, keyStructure KeyStructure, root []byte,
+	ctr PrivateKeyContainer) (*PrivateKey, Error) {
 
 	if uint64(seqNo) > ctx.p.MaxSignatureSeqNo() {
 		return nil, errorf(
@@ -513,6 +906,10 @@ func (ctx *Context) newPrivateKey(pad scratchPad, pubSeed, skSeed, skPrf []byte,
 		seqNo:   seqNo,
 		ctr:     ctr,
 		ph:      ctx.precomputeHashes(pubSeed, skSeed),
+
+		keyStructure: keyStructure,
+
+		seqNoEnd: SignatureSeqNo(ctx.p.MaxSignatureSeqNo()),
 	}
 
 	// Initialize helper data structures
@@ -524,15 +921,45 @@ func (ctx *Context) newPrivateKey(pad scratchPad, pubSeed, skSeed, skPrf []byte,
 	heap.Init(ret.retiredSeqNos)
 	ret.leastSeqNoInUse = seqNo
 
+	// If the container is shared with other processes, we have to lease
+	// sequence numbers before we can use them.
+	if leaser, ok := ctr.(SeqNoLeaser); ok {
+		ret.leaser = leaser
+		ret.leaseSize = DefaultLeaseSize
+		ret.leaseEnd = seqNo
+	}
+
 	// Register the cached subtrees
 	stas, err := ctr.ListSubTrees()
 	if err != nil {
 		return nil, err
 	}
+	ret.lowestCachedHeight, err = containerLowestCachedHeight(ctx, ctr)
+	if err != nil {
+		return nil, err
+	}
+	keyed, err := containerKeyedCacheChecksum(ctr)
+	if err != nil {
+		return nil, err
+	}
+	if keyed {
+		ret.checksumKey = ret.deriveChecksumKey()
+	}
 	for _, sta := range stas {
 		ret.subTreeReady[sta] = true
 		ret.subTreeChecked[sta] = false
 	}
+	ret.loadReport = LoadReport{
+		Params:              ctx.p,
+		SeqNo:               seqNo,
+		CachedSubTrees:      len(stas),
+		RemainingSignatures: ret.remainingSignatures(),
+	}
+
+	if root != nil {
+		ret.root = append([]byte{}, root...)
+		return &ret, nil
+	}
 
 	// Compute (or fetch from cache) the root
 	mt, _, err := ret.getSubTree(pad, SubTreeAddress{Layer: ctx.p.D - 1})
@@ -551,6 +978,14 @@ func (ctx *Context) newPrivateKey(pad scratchPad, pubSeed, skSeed, skPrf []byte,
 func (sk *PrivateKey) retireSeqNo(seqNo SignatureSeqNo) {
 	sk.mux.Lock()
 	defer sk.mux.Unlock()
+	if sk.relocating {
+		defer sk.cond.Broadcast()
+	}
+	if seqNo < sk.leastSeqNoInUse {
+		// The sequence number was in use before we jumped to a new
+		// range.  See lease().
+		return
+	}
 	if sk.leastSeqNoInUse != seqNo {
 		heap.Push(sk.retiredSeqNos, uint32(seqNo))
 		return
@@ -593,3 +1028,89 @@ func (sk *PrivateKey) incLeastSeqNoInUse() {
 		}
 	}
 }
+
+// Generates the k subtrees on the lowest layer following the one that
+// contains seqNo, together with their ancestors.  Run in a separate
+// goroutine; calls sk.wg.Done() when finished.
+func (sk *PrivateKey) precomputeSubTrees(seqNo SignatureSeqNo, k uint64) {
+	defer sk.wg.Done()
+	pad := sk.ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	first := (uint64(seqNo) >> sk.ctx.treeHeight) + 1
+	for tree := first; tree < first+k; tree++ {
+		sta := SubTreeAddress{Layer: 0, Tree: tree}
+		sk.mux.Lock()
+		_, exists := sk.subTreeReady[sta]
+		pastEnd := tree<<sk.ctx.treeHeight >= uint64(sk.seqNoEnd)
+		stop := sk.zeroized || sk.closing
+		sk.mux.Unlock()
+		if pastEnd || stop {
+			return
+		}
+		if exists {
+			continue
+		}
+		log.Logf("Precomputing subtree %v", sta)
+		if _, _, err := sk.getSubTree(pad, sta); err != nil {
+			log.Logf("Failed to precompute subtree %v: %v", sta, err)
+			return
+		}
+		log.Logf("Finished precomputing subtree %v", sta)
+	}
+}
+
+// Drops cached subtrees until there is room for another one in the cache.
+// See PrivateKey.SetCacheLimit().
+//
+// Subtrees that are being generated or might be used by a Sign() in
+// progress are never dropped.  Of the other subtrees, we first drop those
+// that precede the subtrees in use, and then those that are precomputed.
+// Within each group, the subtrees of the lowest layer are dropped first
+// and within a layer the oldest ones.
+//
+// NOTE Assumes a lock on sk.mux.
+func (sk *PrivateKey) evictSubTrees() {
+	if sk.maxCachedSubTrees == 0 || len(sk.subTreeReady) < sk.maxCachedSubTrees {
+		return
+	}
+
+	inUse, _ := sk.ctx.subTreePathForSeqNo(sk.leastSeqNoInUse)
+	next, _ := sk.ctx.subTreePathForSeqNo(sk.seqNo)
+	var stale, ahead []SubTreeAddress
+	for sta, ready := range sk.subTreeReady {
+		if !ready {
+			continue
+		}
+		if sta.Tree < inUse[sta.Layer].Tree {
+			stale = append(stale, sta)
+		} else if sta.Tree > next[sta.Layer].Tree {
+			ahead = append(ahead, sta)
+		}
+	}
+	for _, stas := range [][]SubTreeAddress{stale, ahead} {
+		sort.Slice(stas, func(i, j int) bool {
+			if stas[i].Layer != stas[j].Layer {
+				return stas[i].Layer < stas[j].Layer
+			}
+			return stas[i].Tree < stas[j].Tree
+		})
+	}
+
+	for _, sta := range append(stale, ahead...) {
+		if len(sk.subTreeReady) < sk.maxCachedSubTrees {
+			return
+		}
+		log.Logf("Evicting cached subtree %v ...", sta)
+		if err := sk.ctr.DropSubTree(sta); err != nil {
+			log.Logf("  failed to evict subtree %v: %v", sta, err)
+			continue
+		}
+		delete(sk.subTreeReady, sta)
+		delete(sk.subTreeChecked, sta)
+	}
+
+	if len(sk.subTreeReady) >= sk.maxCachedSubTrees {
+		log.Logf("Cache limit of %d subtrees exceeded: all are in use",
+			sk.maxCachedSubTrees)
+	}
+}
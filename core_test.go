@@ -1,10 +1,13 @@
 package xmssmt
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestMerkleTree(t *testing.T) {
@@ -25,6 +28,122 @@ func TestMerkleTree(t *testing.T) {
 			}
 		}
 	}
+	if !bytes.Equal(mt.Root(), mt.Node(th-1, 0)) {
+		t.Errorf("Root() is not the top node")
+	}
+	authPath := make([]byte, 2*(th-1))
+	for i = 0; i < 1<<(th-1); i++ {
+		mt.AuthPathInto(i, authPath)
+		node := i
+		for h = 0; h < th-1; h++ {
+			if !bytes.Equal(authPath[2*h:2*h+2], mt.Node(h, node^1)) {
+				t.Errorf("AuthPathInto(%d) has wrong node at height %d", i, h)
+			}
+			node /= 2
+		}
+	}
+}
+
+func TestSubTreePlan(t *testing.T) {
+	ctx, _ := NewContext(Params{SHAKE, 16, 6, 3, 16, RFC})
+	plan, err := ctx.SubTreePlanForSeqNo(0x1b) // 01 10 11
+	if err != nil {
+		t.Fatalf("SubTreePlanForSeqNo(): %v", err)
+	}
+	if !reflect.DeepEqual(plan.SubTrees, []SubTreeAddress{
+		{0, 0x6}, {1, 0x1}, {2, 0}}) ||
+		!reflect.DeepEqual(plan.Leafs, []uint32{3, 2, 1}) {
+		t.Fatalf("Wrong plan %+v", plan)
+	}
+	if len(plan.NewSubTrees()) != 0 {
+		t.Fatalf("Wrong new subtrees %v", plan.NewSubTrees())
+	}
+	plan, _ = ctx.SubTreePlanForSeqNo(0x30) // 11 00 00
+	if !reflect.DeepEqual(plan.NewSubTrees(), []SubTreeAddress{
+		{0, 0xc}, {1, 0x3}}) {
+		t.Fatalf("Wrong new subtrees %v", plan.NewSubTrees())
+	}
+	if _, err = ctx.SubTreePlanForSeqNo(64); err == nil {
+		t.Fatalf("SubTreePlanForSeqNo() accepted seqNo out of range")
+	}
+}
+
+func TestGenSubTreeThreads(t *testing.T) {
+	for _, th := range []uint32{1, 2, 4, 6} {
+		ctx, _ := NewContext(Params{SHAKE, 16, 2 * th, 2, 16, RFC})
+		skSeed := make([]byte, ctx.p.N)
+		pubSeed := make([]byte, ctx.p.N)
+		for i := 0; i < len(skSeed); i++ {
+			skSeed[i] = byte(i)
+			pubSeed[i] = byte(2 * i)
+		}
+		sta := SubTreeAddress{Layer: 1, Tree: 1}
+		ctx.Threads = 1
+		mt1 := ctx.genSubTree(ctx.newScratchPad(), skSeed, pubSeed, sta)
+		ctx.Threads = 3
+		mt2 := ctx.genSubTree(ctx.newScratchPad(), skSeed, pubSeed, sta)
+		if !bytes.Equal(mt1.buf, mt2.buf) {
+			t.Fatalf("Subtrees of height %d differ", ctx.treeHeight)
+		}
+
+		// Check the workers are restarted after Close()
+		ctx.Close()
+		mt3 := ctx.genSubTree(ctx.newScratchPad(), skSeed, pubSeed, sta)
+		if !bytes.Equal(mt1.buf, mt3.buf) {
+			t.Fatalf("Subtrees of height %d differ after Close()",
+				ctx.treeHeight)
+		}
+		ctx.Close()
+	}
+}
+
+func TestCloseStopsOwnWorkers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sk, _, err2 := GenerateKeyPair("XMSSMT-SHA2_20/2_256", dir+"/key")
+	if err2 != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err2)
+	}
+	sk.Close()
+	sk, _, _, err2 = LoadPrivateKey(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err2)
+	}
+	if _, err2 = sk.Sign([]byte("test message")); err2 != nil {
+		t.Fatalf("Sign(): %v", err2)
+	}
+	ctx := sk.ctx
+	sk.Close()
+	if ctx.pool != nil {
+		t.Fatalf("Close() did not stop the workers of the Context")
+	}
+}
+
+func TestLeafBatchHeight(t *testing.T) {
+	ctx, _ := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	skSeed := make([]byte, ctx.p.N)
+	pubSeed := make([]byte, ctx.p.N)
+	sta := SubTreeAddress{Layer: 1, Tree: 0}
+	ctx.Threads = 3
+	defer ctx.Close()
+	if h := ctx.LeafBatchHeight(); h < 1 || h > 4 {
+		t.Fatalf("Default LeafBatchHeight() is %d", h)
+	}
+	mt1 := ctx.genSubTree(ctx.newScratchPad(), skSeed, pubSeed, sta)
+	for _, h := range []uint32{1, 4, 7} {
+		ctx.SetLeafBatchHeight(h)
+		if h == 7 && ctx.LeafBatchHeight() != 4 {
+			t.Fatalf("LeafBatchHeight() exceeds subtree height")
+		}
+		mt2 := ctx.genSubTree(ctx.newScratchPad(), skSeed, pubSeed, sta)
+		if !bytes.Equal(mt1.buf, mt2.buf) {
+			t.Fatalf("Subtree differs with batch height %d", h)
+		}
+	}
 }
 
 func BenchmarkGenSubTree5SHA2_256(b *testing.B) {
@@ -88,6 +207,42 @@ func benchmarkGenLeaf(ctx *Context, b *testing.B) {
 	}
 }
 
+func TestMaxWorkers(t *testing.T) {
+	SetMaxWorkers(2)
+	defer SetMaxWorkers(0)
+
+	ctxs := make([]*Context, 3)
+	for i := range ctxs {
+		ctxs[i], _ = NewContext(Params{SHAKE, 16, 2, 1, 16, RFC})
+		ctxs[i].Threads = 4
+		defer ctxs[i].Close()
+	}
+
+	var mux sync.Mutex
+	var running, maxRunning int
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 24; i++ {
+		wg.Add(1)
+		ctxs[i%len(ctxs)].workerPool().jobs <- func(pad scratchPad) {
+			mux.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mux.Unlock()
+			time.Sleep(time.Millisecond)
+			mux.Lock()
+			running--
+			mux.Unlock()
+			wg.Done()
+		}
+	}
+	wg.Wait()
+	if maxRunning > 2 {
+		t.Fatalf("%d workers ran at the same time", maxRunning)
+	}
+}
+
 func TestSeqNoRetirement(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping TestSeqNoRetirement")
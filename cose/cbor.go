@@ -0,0 +1,223 @@
+package cose
+
+// Contains the minimal subset of CBOR (RFC 8949) used by COSE_Sign1.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+// Maximum nesting depth accepted by the decoder.
+const maxDepth = 16
+
+// CBOR map with the entries in the order in which they are encoded.
+type cborMap []cborEntry
+
+type cborEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// CBOR tag.
+type cborTag struct {
+	number  uint64
+	content interface{}
+}
+
+// Returns the value with the given key and whether it is present.
+func (m cborMap) get(key interface{}) (interface{}, bool) {
+	for _, e := range m {
+		if e.key == key {
+			return e.value, true
+		}
+	}
+	return nil, false
+}
+
+func appendHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major<<5|24, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, major<<5|25, 0, 0)
+		binary.BigEndian.PutUint16(buf[len(buf)-2:], uint16(n))
+	case n <= math.MaxUint32:
+		buf = append(buf, major<<5|26, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(buf[len(buf)-4:], uint32(n))
+	default:
+		buf = append(buf, major<<5|27, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(buf[len(buf)-8:], n)
+	}
+	return buf
+}
+
+// Appends the CBOR encoding of v, which must be one of the types returned
+// by decode() or an int.
+func appendValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, majorSimple<<5|22)
+	case int:
+		return appendValue(buf, int64(v))
+	case int64:
+		if v < 0 {
+			return appendHead(buf, majorNegInt, uint64(-1-v))
+		}
+		return appendHead(buf, majorUint, uint64(v))
+	case []byte:
+		buf = appendHead(buf, majorBytes, uint64(len(v)))
+		return append(buf, v...)
+	case string:
+		buf = appendHead(buf, majorText, uint64(len(v)))
+		return append(buf, v...)
+	case []interface{}:
+		buf = appendHead(buf, majorArray, uint64(len(v)))
+		for _, x := range v {
+			buf = appendValue(buf, x)
+		}
+		return buf
+	case cborMap:
+		buf = appendHead(buf, majorMap, uint64(len(v)))
+		for _, e := range v {
+			buf = appendValue(buf, e.key)
+			buf = appendValue(buf, e.value)
+		}
+		return buf
+	case cborTag:
+		buf = appendHead(buf, majorTag, v.number)
+		return appendValue(buf, v.content)
+	}
+	panic(fmt.Sprintf("cbor: can't encode %T", v))
+}
+
+var errTruncated = errors.New("cbor: unexpected end of data")
+
+// Decodes a single CBOR value from buf, which must not contain trailing
+// data.  Only definite lengths, integers that fit an int64, byte and text
+// strings, arrays, maps with integer or text keys, tags, and null are
+// supported.
+func decode(buf []byte) (interface{}, error) {
+	v, rest, err := decodeValue(buf, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("cbor: trailing data")
+	}
+	return v, nil
+}
+
+func decodeHead(buf []byte) (major byte, n uint64, rest []byte, err error) {
+	if len(buf) == 0 {
+		return 0, 0, nil, errTruncated
+	}
+	major = buf[0] >> 5
+	info := buf[0] & 31
+	buf = buf[1:]
+	switch {
+	case info < 24:
+		return major, uint64(info), buf, nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(buf) < size {
+			return 0, 0, nil, errTruncated
+		}
+		for i := 0; i < size; i++ {
+			n = n<<8 | uint64(buf[i])
+		}
+		return major, n, buf[size:], nil
+	}
+	return 0, 0, nil, errors.New("cbor: indefinite lengths are not supported")
+}
+
+func decodeValue(buf []byte, depth int) (interface{}, []byte, error) {
+	if depth > maxDepth {
+		return nil, nil, errors.New("cbor: nested too deeply")
+	}
+	major, n, buf, err := decodeHead(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case majorUint, majorNegInt:
+		if n > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer out of range")
+		}
+		if major == majorNegInt {
+			return -1 - int64(n), buf, nil
+		}
+		return int64(n), buf, nil
+	case majorBytes, majorText:
+		if uint64(len(buf)) < n {
+			return nil, nil, errTruncated
+		}
+		if major == majorText {
+			return string(buf[:n]), buf[n:], nil
+		}
+		return append([]byte{}, buf[:n]...), buf[n:], nil
+	case majorArray:
+		// Every item takes at least one byte; this prevents large
+		// allocations for bogus lengths.
+		if uint64(len(buf)) < n {
+			return nil, nil, errTruncated
+		}
+		ret := make([]interface{}, n)
+		for i := range ret {
+			ret[i], buf, err = decodeValue(buf, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return ret, buf, nil
+	case majorMap:
+		if uint64(len(buf)) < 2*n {
+			return nil, nil, errTruncated
+		}
+		ret := make(cborMap, n)
+		for i := range ret {
+			ret[i].key, buf, err = decodeValue(buf, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch ret[i].key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("cbor: unsupported map key")
+			}
+			if _, ok := ret[:i].get(ret[i].key); ok {
+				return nil, nil, errors.New("cbor: duplicate map key")
+			}
+			ret[i].value, buf, err = decodeValue(buf, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return ret, buf, nil
+	case majorTag:
+		content, buf, err := decodeValue(buf, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cborTag{number: n, content: content}, buf, nil
+	case majorSimple:
+		if n == 22 {
+			return nil, buf, nil
+		}
+	}
+	return nil, nil, errors.New("cbor: unsupported value")
+}
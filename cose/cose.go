@@ -0,0 +1,167 @@
+// Package cose creates and verifies XMSS[MT] signatures in COSE_Sign1
+// structures (RFC 9052) and, experimentally, in JWS compact serialization
+// (RFC 7515).
+//
+// XMSS[MT] has no registered COSE or JOSE algorithm identifier yet.  By
+// default the private use algorithm AlgExperimental is used, which can be
+// overridden with Options.Alg.  As the signature encoding of RFC 8391 does
+// not identify the instance, the name of the instance, for example
+// "XMSSMT-SHA2_20/2_256", is put in the protected header parameter
+// HeaderAlgName.  The signature is encoded as described in RFC 8391.
+package cose
+
+import (
+	"errors"
+	"fmt"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Algorithm identifier from the COSE private use range used by default.
+const AlgExperimental = -65537
+
+// Label of the protected header parameter with the name of the instance.
+// From the COSE private use range.
+const HeaderAlgName = -65537
+
+// Labels of the standard COSE header parameters.
+const (
+	headerAlg = 1
+	headerKid = 4
+)
+
+// CBOR tag of COSE_Sign1.
+const tagSign1 = 18
+
+// Options for Sign1() and Verify1().  The zero value uses AlgExperimental
+// without external data and key identifier.
+type Options struct {
+	// Algorithm identifier in the protected header.  Zero means
+	// AlgExperimental.
+	Alg int64
+
+	// Externally supplied data that is signed, but not included in the
+	// COSE_Sign1 structure.  Has to be the same for Verify1().
+	ExternalAAD []byte
+
+	// If set, put in the unprotected header as kid.  Ignored by Verify1().
+	KeyID []byte
+}
+
+func (opts *Options) alg() int64 {
+	if opts == nil || opts.Alg == 0 {
+		return AlgExperimental
+	}
+	return opts.Alg
+}
+
+func (opts *Options) externalAAD() []byte {
+	if opts == nil || opts.ExternalAAD == nil {
+		return []byte{}
+	}
+	return opts.ExternalAAD
+}
+
+// Returns the name of the instance as put in the HeaderAlgName and the
+// JWS header.
+func algName(ctx *xmssmt.Context) string {
+	if name := ctx.Name(); name != "" {
+		return name
+	}
+	return ctx.Params().String()
+}
+
+// Returns the Sig_structure that is signed for a COSE_Sign1.
+func sigStructure(protected, externalAAD, payload []byte) []byte {
+	return appendValue(nil, []interface{}{
+		"Signature1",
+		protected,
+		externalAAD,
+		payload,
+	})
+}
+
+// Signs the payload and returns the tagged COSE_Sign1 structure.
+func Sign1(sk *xmssmt.PrivateKey, payload []byte, opts *Options) (
+	[]byte, error) {
+	protected := appendValue(nil, cborMap{
+		{int64(headerAlg), opts.alg()},
+		{int64(HeaderAlgName), algName(sk.Context())},
+	})
+	unprotected := cborMap{}
+	if opts != nil && opts.KeyID != nil {
+		unprotected = append(unprotected,
+			cborEntry{int64(headerKid), opts.KeyID})
+	}
+
+	sig, err := sk.Sign(sigStructure(protected, opts.externalAAD(), payload))
+	if err != nil {
+		return nil, err
+	}
+
+	return appendValue(nil, cborTag{tagSign1, []interface{}{
+		protected,
+		unprotected,
+		payload,
+		sig.MarshalStandard(),
+	}}), nil
+}
+
+// Checks the COSE_Sign1 structure msg, which may be tagged, against the
+// public key and returns the payload.  Detached payloads are not supported.
+func Verify1(pk *xmssmt.PublicKey, msg []byte, opts *Options) (
+	[]byte, error) {
+	v, err := decode(msg)
+	if err != nil {
+		return nil, err
+	}
+	if tag, ok := v.(cborTag); ok {
+		if tag.number != tagSign1 {
+			return nil, fmt.Errorf("cose: unexpected tag %d", tag.number)
+		}
+		v = tag.content
+	}
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) != 4 {
+		return nil, errors.New("cose: COSE_Sign1 should be an array of 4")
+	}
+	protected, ok1 := arr[0].([]byte)
+	_, ok2 := arr[1].(cborMap)
+	payload, ok3 := arr[2].([]byte)
+	sigBuf, ok4 := arr[3].([]byte)
+	if !ok1 || !ok2 || !ok4 {
+		return nil, errors.New("cose: malformed COSE_Sign1")
+	}
+	if !ok3 {
+		return nil, errors.New("cose: detached payloads are not supported")
+	}
+
+	// Check the protected header.
+	v, err = decode(protected)
+	if err != nil {
+		return nil, err
+	}
+	hdr, ok := v.(cborMap)
+	if !ok {
+		return nil, errors.New("cose: protected header should be a map")
+	}
+	if alg, _ := hdr.get(int64(headerAlg)); alg != opts.alg() {
+		return nil, fmt.Errorf("cose: unexpected algorithm %v", alg)
+	}
+	ctx := pk.Context()
+	if name, _ := hdr.get(int64(HeaderAlgName)); name != algName(ctx) {
+		return nil, fmt.Errorf("cose: signature is for %v instead of %s",
+			name, algName(ctx))
+	}
+
+	sig, err := ctx.UnmarshalSignature(sigBuf)
+	if err != nil {
+		return nil, err
+	}
+	ok, err = pk.Verify(sig,
+		sigStructure(protected, opts.externalAAD(), payload))
+	if !ok {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,138 @@
+package cose
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+func generateKey(t *testing.T, dir string) (
+	*xmssmt.PrivateKey, *xmssmt.PublicKey) {
+	ctx, err := xmssmt.NewContext(xmssmt.Params{
+		Func:       xmssmt.SHAKE,
+		N:          16,
+		FullHeight: 8,
+		D:          2,
+		WotsW:      16,
+		Prf:        xmssmt.RFC,
+	})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	return sk, pk
+}
+
+func TestSign1(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	sk, pk := generateKey(t, dir)
+	defer sk.Close()
+
+	payload := []byte("firmware manifest")
+	opts := &Options{ExternalAAD: []byte("aad"), KeyID: []byte("key 1")}
+	msg, err := Sign1(sk, payload, opts)
+	if err != nil {
+		t.Fatalf("Sign1(): %v", err)
+	}
+	if msg[0] != 0xd2 {
+		t.Fatalf("COSE_Sign1 should be tagged")
+	}
+
+	payload2, err := Verify1(pk, msg, opts)
+	if err != nil {
+		t.Fatalf("Verify1(): %v", err)
+	}
+	if !bytes.Equal(payload, payload2) {
+		t.Fatalf("Verify1() returned wrong payload")
+	}
+
+	if _, err = Verify1(pk, msg, nil); err == nil {
+		t.Fatalf("Verify1() accepted wrong external data")
+	}
+	if _, err = Verify1(pk, msg, &Options{Alg: -65538,
+		ExternalAAD: []byte("aad")}); err == nil {
+		t.Fatalf("Verify1() accepted wrong algorithm")
+	}
+	tampered := bytes.Replace(msg, payload, []byte("firmware manifesT"), 1)
+	if _, err = Verify1(pk, tampered, opts); err == nil {
+		t.Fatalf("Verify1() accepted tampered payload")
+	}
+	if _, err = Verify1(pk, msg[:len(msg)-1], opts); err == nil {
+		t.Fatalf("Verify1() accepted truncated message")
+	}
+}
+
+func TestCBOR(t *testing.T) {
+	v := []interface{}{int64(-1), int64(0), int64(23), int64(24),
+		int64(1000), int64(-65537), []byte{1, 2}, "text", nil,
+		cborMap{{int64(1), int64(2)}, {"a", []interface{}{}}},
+		cborTag{18, int64(1)}}
+	buf := appendValue(nil, v)
+	v2, err := decode(buf)
+	if err != nil {
+		t.Fatalf("decode(): %v", err)
+	}
+	if !bytes.Equal(appendValue(nil, v2), buf) {
+		t.Fatalf("decode() did not round trip")
+	}
+
+	// Examples from RFC 8949 appendix A.
+	if !bytes.Equal(appendValue(nil, int64(-1000)), []byte{0x39, 0x03, 0xe7}) {
+		t.Fatalf("Wrong encoding of -1000")
+	}
+	if !bytes.Equal(appendValue(nil, int64(1000000)),
+		[]byte{0x1a, 0x00, 0x0f, 0x42, 0x40}) {
+		t.Fatalf("Wrong encoding of 1000000")
+	}
+
+	for _, bad := range [][]byte{
+		{0x5f},                         // indefinite length
+		{0x9a, 0xff, 0xff, 0xff, 0xff}, // huge array
+		{0xa2, 0x01, 0x01, 0x01, 0x01}, // duplicate key
+		{0x01, 0x01},                   // trailing data
+	} {
+		if _, err := decode(bad); err == nil {
+			t.Fatalf("decode(%x) should fail", bad)
+		}
+	}
+}
+
+func TestJWS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	sk, pk := generateKey(t, dir)
+	defer sk.Close()
+
+	payload := []byte(`{"version":2}`)
+	jws, err := SignJWS(sk, payload, "key 1")
+	if err != nil {
+		t.Fatalf("SignJWS(): %v", err)
+	}
+	payload2, err := VerifyJWS(pk, jws)
+	if err != nil {
+		t.Fatalf("VerifyJWS(): %v", err)
+	}
+	if !bytes.Equal(payload, payload2) {
+		t.Fatalf("VerifyJWS() returned wrong payload")
+	}
+
+	parts := strings.Split(jws, ".")
+	parts[1] = b64.EncodeToString([]byte(`{"version":3}`))
+	if _, err = VerifyJWS(pk, strings.Join(parts, ".")); err == nil {
+		t.Fatalf("VerifyJWS() accepted tampered payload")
+	}
+}
@@ -0,0 +1,94 @@
+package cose
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Experimental JWS algorithm name for XMSS[MT].  The instance is put in the
+// header parameter "xmssmt".
+const JWSAlg = "X-XMSSMT"
+
+// JOSE header of the JWS created by SignJWS().
+type jwsHeader struct {
+	Alg    string          `json:"alg"`
+	XMSSMT string          `json:"xmssmt"`
+	Kid    string          `json:"kid,omitempty"`
+	Crit   json.RawMessage `json:"crit,omitempty"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// Signs the payload and returns a JWS in compact serialization.  If kid is
+// not empty, it's put in the header.
+//
+// NOTE The algorithm is not registered, so other JOSE libraries will not
+// accept the JWS.
+func SignJWS(sk *xmssmt.PrivateKey, payload []byte, kid string) (
+	string, error) {
+	hdr, err := json.Marshal(jwsHeader{
+		Alg:    JWSAlg,
+		XMSSMT: algName(sk.Context()),
+		Kid:    kid,
+	})
+	if err != nil {
+		return "", err
+	}
+	signingInput := b64.EncodeToString(hdr) + "." + b64.EncodeToString(payload)
+	sig, err2 := sk.Sign([]byte(signingInput))
+	if err2 != nil {
+		return "", err2
+	}
+	return signingInput + "." + b64.EncodeToString(sig.MarshalStandard()), nil
+}
+
+// Checks a JWS in compact serialization created by SignJWS() against the
+// public key and returns the payload.
+func VerifyJWS(pk *xmssmt.PublicKey, jws string) ([]byte, error) {
+	parts := strings.Split(jws, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jws: should consist of three parts")
+	}
+	hdrBuf, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("jws: header: %v", err)
+	}
+	payload, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("jws: payload: %v", err)
+	}
+	sigBuf, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("jws: signature: %v", err)
+	}
+
+	var hdr jwsHeader
+	if err = json.Unmarshal(hdrBuf, &hdr); err != nil {
+		return nil, fmt.Errorf("jws: header: %v", err)
+	}
+	if hdr.Alg != JWSAlg {
+		return nil, fmt.Errorf("jws: unexpected algorithm %q", hdr.Alg)
+	}
+	ctx := pk.Context()
+	if hdr.XMSSMT != algName(ctx) {
+		return nil, fmt.Errorf("jws: signature is for %q instead of %s",
+			hdr.XMSSMT, algName(ctx))
+	}
+	if hdr.Crit != nil {
+		return nil, errors.New("jws: critical header parameters are not supported")
+	}
+
+	sig, err2 := ctx.UnmarshalSignature(sigBuf)
+	if err2 != nil {
+		return nil, err2
+	}
+	if ok, err2 := pk.Verify(sig, []byte(parts[0]+"."+parts[1])); !ok {
+		return nil, err2
+	}
+	return payload, nil
+}
@@ -0,0 +1,208 @@
+package xmssmt
+
+// Contains the envelope format for public keys and signatures.
+
+import (
+	"encoding/binary"
+)
+
+// Magic bytes at the start of an envelope.  See PublicKey.MarshalEnvelope().
+var envelopeMagic = []byte("HBSE")
+
+// Current version of the envelope format.
+const EnvelopeVersion = 1
+
+// Kind of the payload of an envelope.
+type EnvelopeKind uint8
+
+const (
+	EnvelopePublicKey EnvelopeKind = 1
+	EnvelopeSignature EnvelopeKind = 2
+)
+
+// Hash-based signature scheme of the payload of an envelope.  Values
+// other than those below are reserved for other schemes, such as LMS.
+type EnvelopeScheme uint8
+
+const (
+	EnvelopeXMSS   EnvelopeScheme = 1
+	EnvelopeXMSSMT EnvelopeScheme = 2
+)
+
+// Maximum length of the algorithm name in an envelope.
+const maxEnvelopeAlgLen = 255
+
+// Header of an envelope, as returned by ParseEnvelopeHeader().
+type EnvelopeHeader struct {
+	Version    uint8
+	Kind       EnvelopeKind
+	Scheme     EnvelopeScheme
+	Oid        uint32 // zero if the instance has no OID
+	Alg        string // name of the instance
+	PayloadLen uint32
+
+	// Length of the header in bytes; the payload starts after it.
+	HeaderLen int
+}
+
+// Returns the public key in an envelope:
+//
+//   "HBSE" ‖ version (1 byte) ‖ kind (1 byte) ‖ scheme (1 byte) ‖
+//     OID (4 bytes) ‖ len(alg) (1 byte) ‖ alg ‖
+//     len(payload) (4 bytes) ‖ payload,
+//
+// where the payload is root ‖ pubSeed and alg is the name of the instance.
+// In contrast to MarshalBinary(), the envelope doesn't depend on the
+// (compressed) OID to identify the instance and can be extended to other
+// schemes and future parameters.
+func (pk *PublicKey) MarshalEnvelope() ([]byte, Error) {
+	return pk.ctx.marshalEnvelope(EnvelopePublicKey, pk.MarshalRaw())
+}
+
+// Returns the signature in an envelope.  The payload is the signature as
+// described in the RFC.  See PublicKey.MarshalEnvelope().
+func (sig *Signature) MarshalEnvelope() ([]byte, Error) {
+	return sig.ctx.marshalEnvelope(EnvelopeSignature, sig.MarshalRaw())
+}
+
+func (ctx *Context) marshalEnvelope(kind EnvelopeKind, payload []byte) (
+	[]byte, Error) {
+	alg := ctx.algName()
+	if len(alg) > maxEnvelopeAlgLen {
+		return nil, errorf("Algorithm name is too long")
+	}
+	scheme := EnvelopeXMSS
+	if ctx.MT() {
+		scheme = EnvelopeXMSSMT
+	}
+	ret := make([]byte, 0, len(envelopeMagic)+12+len(alg)+len(payload))
+	ret = append(ret, envelopeMagic...)
+	ret = append(ret, EnvelopeVersion, byte(kind), byte(scheme))
+	ret = append(ret, encodeUint64(uint64(ctx.Oid()), 4)...)
+	ret = append(ret, byte(len(alg)))
+	ret = append(ret, alg...)
+	ret = append(ret, encodeUint64(uint64(len(payload)), 4)...)
+	ret = append(ret, payload...)
+	return ret, nil
+}
+
+// Parses and checks the header of an envelope without looking at the
+// payload.
+//
+// This allows the caller to decide on the algorithm, for instance to
+// reject unwanted instances, before the payload is allocated or copied.
+// The header is returned even if the error is set, as long as it could be
+// read, so that envelopes with other schemes can be passed on to another
+// library.  The error is set if
+//
+//   - the envelope is truncated or has trailing data;
+//   - the version is unknown;
+//   - the scheme is not XMSS or XMSSMT;
+//   - the algorithm is unknown or doesn't match the scheme or OID;
+//   - the payload length doesn't match the algorithm and kind.
+func ParseEnvelopeHeader(buf []byte) (*EnvelopeHeader, Error) {
+	hdr, err := parseEnvelopeHeader(buf)
+	if err != nil {
+		return hdr, err
+	}
+	_, err = hdr.context()
+	return hdr, err
+}
+
+// Parses the header of an envelope without checking the algorithm.
+func parseEnvelopeHeader(buf []byte) (*EnvelopeHeader, Error) {
+	m := len(envelopeMagic)
+	if len(buf) < m+8 {
+		return nil, errorf("Envelope is too short")
+	}
+	if string(buf[:m]) != string(envelopeMagic) {
+		return nil, errorf("Not an envelope")
+	}
+	hdr := EnvelopeHeader{
+		Version: buf[m],
+		Kind:    EnvelopeKind(buf[m+1]),
+		Scheme:  EnvelopeScheme(buf[m+2]),
+		Oid:     binary.BigEndian.Uint32(buf[m+3 : m+7]),
+	}
+	if hdr.Version != EnvelopeVersion {
+		return nil, errorf("Unsupported envelope version %d", hdr.Version)
+	}
+	algLen := int(buf[m+7])
+	off := m + 8 + algLen
+	if len(buf) < off+4 {
+		return nil, errorf("Envelope is too short")
+	}
+	hdr.Alg = string(buf[m+8 : off])
+	hdr.PayloadLen = binary.BigEndian.Uint32(buf[off : off+4])
+	hdr.HeaderLen = off + 4
+	if uint64(len(buf)) != uint64(hdr.HeaderLen)+uint64(hdr.PayloadLen) {
+		return &hdr, errorf("Envelope has wrong length")
+	}
+	if hdr.Kind != EnvelopePublicKey && hdr.Kind != EnvelopeSignature {
+		return &hdr, errorf("Unknown envelope kind %d", hdr.Kind)
+	}
+	return &hdr, nil
+}
+
+// Returns the Context for the algorithm in the header and checks the
+// scheme, OID and payload length.
+func (hdr *EnvelopeHeader) context() (*Context, Error) {
+	if hdr.Scheme != EnvelopeXMSS && hdr.Scheme != EnvelopeXMSSMT {
+		return nil, errorf("Unsupported scheme %d", hdr.Scheme)
+	}
+	ctx, err := NewContextFromName2(hdr.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.MT() != (hdr.Scheme == EnvelopeXMSSMT) {
+		return nil, errorf("Algorithm %s does not match scheme", hdr.Alg)
+	}
+	if hdr.Oid != ctx.Oid() {
+		return nil, errorf("OID %d does not match algorithm %s",
+			hdr.Oid, hdr.Alg)
+	}
+	expected := 2 * ctx.p.N
+	if hdr.Kind == EnvelopeSignature {
+		expected = ctx.sigBytes
+	}
+	if hdr.PayloadLen != expected {
+		return nil, errorf("Payload has wrong length: %d", hdr.PayloadLen)
+	}
+	return ctx, nil
+}
+
+// Parses an envelope of the given kind and returns the Context and payload.
+func parseEnvelope(buf []byte, kind EnvelopeKind) (*Context, []byte, Error) {
+	hdr, err := parseEnvelopeHeader(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hdr.Kind != kind {
+		return nil, nil, errorf("Envelope contains wrong kind %d", hdr.Kind)
+	}
+	ctx, err := hdr.context()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctx, buf[hdr.HeaderLen:], nil
+}
+
+// Decodes a public key from an envelope written by
+// PublicKey.MarshalEnvelope().
+func UnmarshalPublicKeyEnvelope(buf []byte) (*PublicKey, Error) {
+	ctx, payload, err := parseEnvelope(buf, EnvelopePublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.UnmarshalRawPublicKey(payload)
+}
+
+// Decodes a signature from an envelope written by
+// Signature.MarshalEnvelope().
+func UnmarshalSignatureEnvelope(buf []byte) (*Signature, Error) {
+	ctx, payload, err := parseEnvelope(buf, EnvelopeSignature)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.UnmarshalRawSignature(payload)
+}
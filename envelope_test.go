@@ -0,0 +1,87 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := NewContextFromName("XMSSMT-SHAKE_20/4_256")
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+	msg := []byte("test message")
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+
+	pkBuf, err := pk.MarshalEnvelope()
+	if err != nil {
+		t.Fatalf("PublicKey.MarshalEnvelope(): %v", err)
+	}
+	sigBuf, err := sig.MarshalEnvelope()
+	if err != nil {
+		t.Fatalf("Signature.MarshalEnvelope(): %v", err)
+	}
+
+	hdr, err := ParseEnvelopeHeader(sigBuf)
+	if err != nil {
+		t.Fatalf("ParseEnvelopeHeader(): %v", err)
+	}
+	if hdr.Kind != EnvelopeSignature || hdr.Scheme != EnvelopeXMSSMT ||
+		hdr.Alg != "XMSSMT-SHAKE_20/4_256" || hdr.Oid != ctx.Oid() ||
+		hdr.PayloadLen != ctx.sigBytes {
+		t.Fatalf("ParseEnvelopeHeader() returned %+v", hdr)
+	}
+
+	pk2, err := UnmarshalPublicKeyEnvelope(pkBuf)
+	if err != nil {
+		t.Fatalf("UnmarshalPublicKeyEnvelope(): %v", err)
+	}
+	sig2, err := UnmarshalSignatureEnvelope(sigBuf)
+	if err != nil {
+		t.Fatalf("UnmarshalSignatureEnvelope(): %v", err)
+	}
+	if ok, err := pk2.Verify(sig2, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+
+	if _, err = UnmarshalSignatureEnvelope(pkBuf); err == nil {
+		t.Fatalf("UnmarshalSignatureEnvelope() accepted public key")
+	}
+	if _, err = UnmarshalSignatureEnvelope(sigBuf[:len(sigBuf)-1]); err == nil {
+		t.Fatalf("UnmarshalSignatureEnvelope() accepted truncated envelope")
+	}
+
+	// The header of other schemes is still returned.
+	other := append([]byte{}, pkBuf...)
+	other[6] = 42
+	hdr, err = ParseEnvelopeHeader(other)
+	if err == nil || hdr == nil || hdr.Scheme != 42 {
+		t.Fatalf("ParseEnvelopeHeader() should return header of other scheme")
+	}
+
+	// A wrong OID is rejected.
+	other = append([]byte{}, pkBuf...)
+	other[10] ^= 1
+	if _, err = UnmarshalPublicKeyEnvelope(other); err == nil {
+		t.Fatalf("UnmarshalPublicKeyEnvelope() accepted wrong OID")
+	}
+	if !bytes.HasPrefix(pkBuf, []byte("HBSE")) {
+		t.Fatalf("Envelope does not start with magic")
+	}
+}
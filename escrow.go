@@ -0,0 +1,270 @@
+package xmssmt
+
+// Contains the export of the minimal state of a private key for escrow
+// or backup, and its restore.
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+)
+
+// Magic bytes at the start of an escrow.  See PrivateKey.ExportEscrow().
+var escrowMagic = []byte("XESC")
+
+// Current version of the escrow format.
+const escrowVersion = 1
+
+// Label for RSA-OAEP, so that the wrapped key can't be confused with
+// a ciphertext of another protocol.
+var escrowOAEPLabel = []byte("go-xmssmt escrow")
+
+// Length of the AES-256 key that encrypts the state.
+const escrowKeyLen = 32
+
+// Returns the minimal state needed to recover the private key: its
+// parameters, seeds and a signature sequence number from which it is
+// safe to restart, encrypted to the public key of the operator.
+// Restore it with RestoreEscrow().
+//
+// Backups of stateful keys are dangerous: a restored backup reuses the
+// signature sequence numbers that were used after the backup was made,
+// which breaks the security of XMSS[MT].  Therefore the escrow does not
+// contain the cached subtrees nor the current signature sequence number,
+// but the high-water mark of the container (see HighWaterMark()) plus
+// margin.  Choose margin larger than the number of signatures that will
+// be created before the next escrow is exported.
+//
+// The state is encrypted with AES-256-GCM under a random key, which is
+// in turn encrypted with RSA-OAEP (SHA-256) to operator.
+func (sk *PrivateKey) ExportEscrow(operator *rsa.PublicKey, margin uint32) (
+	[]byte, Error) {
+	if sk.keyStructure != FlatKeyStructure {
+		return nil, errorf("Can't escrow a key with the %v key structure",
+			sk.keyStructure)
+	}
+	restart, err := sk.escrowRestartSeqNo()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(restart)+uint64(margin) > sk.ctx.p.MaxSignatureSeqNo() {
+		return nil, kindErrorf(ErrExhausted,
+			"No signatures left after a margin of %d", margin)
+	}
+	restart += SignatureSeqNo(margin)
+
+	alg := sk.ctx.algName()
+	if len(alg) > 255 {
+		return nil, errorf("Algorithm name is too long")
+	}
+	n := int(sk.ctx.p.N)
+	state := make([]byte, 0, 1+len(alg)+8+4*n)
+	state = append(state, byte(len(alg)))
+	state = append(state, alg...)
+	state = append(state, encodeUint64(uint64(restart), 8)...)
+	state = append(state, sk.skSeed...)
+	state = append(state, sk.skPrf...)
+	state = append(state, sk.pubSeed...)
+	state = append(state, sk.root...)
+	defer zeroize(state)
+
+	key := make([]byte, escrowKeyLen)
+	defer zeroize(key)
+	if _, err := rand.Read(key); err != nil {
+		return nil, wrapErrorf(err, "Failed to generate escrow key")
+	}
+	wrapped, err2 := rsa.EncryptOAEP(sha256.New(), rand.Reader, operator,
+		key, escrowOAEPLabel)
+	if err2 != nil {
+		return nil, wrapErrorf(err2, "Failed to encrypt escrow key")
+	}
+	aead, err := newEscrowAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, wrapErrorf(err, "Failed to generate nonce")
+	}
+
+	// The header is authenticated as additional data.
+	ret := make([]byte, 0, len(escrowMagic)+3+len(wrapped)+len(nonce)+
+		len(state)+aead.Overhead())
+	ret = append(ret, escrowMagic...)
+	ret = append(ret, escrowVersion)
+	ret = append(ret, encodeUint64(uint64(len(wrapped)), 2)...)
+	ret = append(ret, wrapped...)
+	ret = append(ret, nonce...)
+	return aead.Seal(ret, nonce, state, ret), nil
+}
+
+// Returns the signature sequence number from which a restored key may
+// sign: none of the signatures created so far, including those that have
+// been borrowed, has a larger one.
+func (sk *PrivateKey) escrowRestartSeqNo() (SignatureSeqNo, Error) {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	restart, _, err := sk.ctr.GetSeqNo()
+	if err != nil {
+		return 0, wrapErrorf(err, "Failed to get signature sequence number")
+	}
+	if restart < sk.seqNo {
+		restart = sk.seqNo
+	}
+	if marker, ok := sk.ctr.(HighWaterMarker); ok {
+		mark, err := marker.HighWaterMark()
+		if err != nil {
+			return 0, wrapErrorf(err, "Failed to get high-water mark")
+		}
+		if restart < mark {
+			restart = mark
+		}
+	}
+	return restart, nil
+}
+
+func newEscrowAEAD(key []byte) (cipher.AEAD, Error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to create cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to create cipher")
+	}
+	return aead, nil
+}
+
+// Restores a private key from an escrow created with
+// PrivateKey.ExportEscrow() into a new filesystem container at the given
+// path.  See RestoreEscrowInto().
+func RestoreEscrow(path string, escrow []byte, operator crypto.Decrypter,
+	reserve uint32) (*PrivateKey, *PublicKey, Error) {
+	ctr, err := OpenFSPrivateKeyContainer(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return RestoreEscrowInto(ctr, escrow, operator, reserve)
+}
+
+// Restores a private key from an escrow created with
+// PrivateKey.ExportEscrow() into the given container, which must not be
+// initialized yet.  operator is the private key of the operator, for
+// instance an *rsa.PrivateKey or a key in an HSM.
+//
+// The restored key starts signing at the signature sequence number of
+// the escrow plus reserve, which must not be zero: a restored key should
+// never pick up exactly where the escrow left off, as the escrow might
+// be older than one thinks.  The subtrees are not part of the escrow and
+// are generated again when needed.
+//
+// NOTE Takes ownership of ctr, even if an error is returned.
+func RestoreEscrowInto(ctr PrivateKeyContainer, escrow []byte,
+	operator crypto.Decrypter, reserve uint32) (
+	*PrivateKey, *PublicKey, Error) {
+	sk, pk, err := restoreEscrowInto(ctr, escrow, operator, reserve)
+	if err != nil {
+		ctr.Close()
+		return nil, nil, err
+	}
+	return sk, pk, nil
+}
+
+func restoreEscrowInto(ctr PrivateKeyContainer, escrow []byte,
+	operator crypto.Decrypter, reserve uint32) (
+	*PrivateKey, *PublicKey, Error) {
+	if reserve == 0 {
+		return nil, nil, errorf("Refusing to restore without a reserve")
+	}
+	if ctr.Initialized() != nil {
+		return nil, nil, errorf("Container is already initialized")
+	}
+
+	// Decrypt the state
+	m := len(escrowMagic)
+	if len(escrow) < m+3 {
+		return nil, nil, kindErrorf(ErrMalformed, "Escrow is too short")
+	}
+	if !bytes.Equal(escrow[:m], escrowMagic) {
+		return nil, nil, kindErrorf(ErrWrongMagic, "Not an escrow")
+	}
+	if escrow[m] != escrowVersion {
+		return nil, nil, errorf("Unsupported escrow version %d", escrow[m])
+	}
+	wrappedLen := int(decodeUint64(escrow[m+1 : m+3]))
+	headerLen := m + 3 + wrappedLen
+	if len(escrow) < headerLen {
+		return nil, nil, kindErrorf(ErrMalformed, "Escrow is too short")
+	}
+	key, err2 := operator.Decrypt(rand.Reader, escrow[m+3:headerLen],
+		&rsa.OAEPOptions{Hash: crypto.SHA256, Label: escrowOAEPLabel})
+	if err2 != nil {
+		return nil, nil, wrapErrorf(err2, "Failed to decrypt escrow key")
+	}
+	defer zeroize(key)
+	if len(key) != escrowKeyLen {
+		return nil, nil, kindErrorf(ErrMalformed, "Escrow key has wrong length")
+	}
+	aead, err := newEscrowAEAD(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonceLen := aead.NonceSize()
+	if len(escrow) < headerLen+nonceLen {
+		return nil, nil, kindErrorf(ErrMalformed, "Escrow is too short")
+	}
+	state, err2 := aead.Open(nil, escrow[headerLen:headerLen+nonceLen],
+		escrow[headerLen+nonceLen:], escrow[:headerLen+nonceLen])
+	if err2 != nil {
+		return nil, nil, wrapErrorf(err2, "Failed to decrypt escrow")
+	}
+	defer zeroize(state)
+
+	// Parse the state
+	if len(state) < 1 || len(state) < 1+int(state[0])+8 {
+		return nil, nil, kindErrorf(ErrMalformed, "Escrow state is too short")
+	}
+	algLen := int(state[0])
+	ctx, err := NewContextFromName2(string(state[1 : 1+algLen]))
+	if err != nil {
+		return nil, nil, err
+	}
+	state2 := state[1+algLen:]
+	restart := SignatureSeqNo(decodeUint64(state2[:8]))
+	n := int(ctx.p.N)
+	if len(state2) != 8+4*n {
+		return nil, nil, kindErrorf(ErrMalformed,
+			"Escrow state has wrong length")
+	}
+	if uint64(restart)+uint64(reserve) > ctx.p.MaxSignatureSeqNo() {
+		return nil, nil, kindErrorf(ErrExhausted,
+			"No signatures left after a reserve of %d", reserve)
+	}
+	root := state2[8+3*n:]
+
+	// Store the key with the new signature sequence number before it is
+	// loaded, so that it is never used from the old one.  The container
+	// might keep concatSk, so it is not part of state, which is wiped.
+	concatSk := append([]byte{}, state2[8:8+3*n]...)
+	err = ctr.Reset(concatSk, ctx.p)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = ctr.SetSeqNo(restart + SignatureSeqNo(reserve))
+	if err != nil {
+		return nil, nil, err
+	}
+	sk, pk, _, err := loadPrivateKeyFrom(ctx, ctr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(sk.root, root) {
+		sk.zeroize()
+		return nil, nil, errorf("Restored key has the wrong root")
+	}
+	return sk, pk, nil
+}
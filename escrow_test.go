@@ -0,0 +1,98 @@
+package xmssmt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestEscrow(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	operator, err2 := rsa.GenerateKey(rand.Reader, 2048)
+	if err2 != nil {
+		t.Fatalf("rsa.GenerateKey(): %v", err2)
+	}
+	other, err2 := rsa.GenerateKey(rand.Reader, 2048)
+	if err2 != nil {
+		t.Fatalf("rsa.GenerateKey(): %v", err2)
+	}
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+	if err = sk.BorrowExactly(5); err != nil {
+		t.Fatalf("BorrowExactly(): %v", err)
+	}
+	if _, err = sk.Sign([]byte("test message")); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+
+	escrow, err := sk.ExportEscrow(&operator.PublicKey, 10)
+	if err != nil {
+		t.Fatalf("ExportEscrow(): %v", err)
+	}
+
+	if _, _, err = RestoreEscrow(dir+"/key2", escrow, operator, 0); err == nil {
+		t.Fatalf("RestoreEscrow() accepted zero reserve")
+	}
+	if _, _, err = RestoreEscrow(dir+"/key2", escrow, other, 5); err == nil {
+		t.Fatalf("RestoreEscrow() accepted wrong operator key")
+	}
+	if _, _, err = RestoreEscrow(dir+"/key", escrow, operator, 5); err == nil {
+		t.Fatalf("RestoreEscrow() overwrote existing key")
+	}
+	escrow[len(escrow)-1] ^= 1
+	if _, _, err = RestoreEscrow(dir+"/key2", escrow, operator, 5); err == nil {
+		t.Fatalf("RestoreEscrow() accepted corrupted escrow")
+	}
+	escrow[len(escrow)-1] ^= 1
+
+	sk2, pk2, err := RestoreEscrow(dir+"/key2", escrow, operator, 5)
+	if err != nil {
+		t.Fatalf("RestoreEscrow(): %v", err)
+	}
+	if !pk.Equal(pk2) {
+		t.Fatalf("Restored key has a different public key")
+	}
+
+	// The borrowed signatures, the margin and the reserve are skipped.
+	if sk2.SeqNo() != 5+10+5 {
+		t.Fatalf("Restored key starts at %d", sk2.SeqNo())
+	}
+	sig, err := sk2.Sign([]byte("test message"))
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, []byte("test message")); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if err = sk2.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// The signature sequence number was stored before the key was used.
+	sk2, _, _, err = LoadPrivateKey(dir + "/key2")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk2.Close()
+	if sk2.SeqNo() != 21 {
+		t.Fatalf("Loaded restored key at %d", sk2.SeqNo())
+	}
+}
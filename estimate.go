@@ -0,0 +1,188 @@
+package xmssmt
+
+import (
+	"time"
+)
+
+// Estimated storage of the cached subtrees.  See Params.CacheSizeEstimate().
+type CacheSizeReport struct {
+	// Size of a single merkle subtree.  See Params.BareSubTreeSize().
+	BareSubTreeSize int
+
+	// Size of a cached subtree, which includes the WOTS+ signature of its
+	// root and a checksum.  See Params.CachedSubTreeSize().
+	CachedSubTreeSize int
+
+	// Size of a cached subtree in the cache file of the filesystem
+	// container, which includes a header and padding.
+	PaddedSubTreeSize int
+
+	// Number of subtrees that are cached at the same time.
+	SubTrees int
+
+	// Memory required for the cached subtrees.
+	MemorySize uint64
+
+	// Size of the cache file of the filesystem container.
+	FileSize uint64
+}
+
+// Estimated work to generate a keypair.  See Params.KeygenWorkEstimate().
+type KeygenWorkReport struct {
+	// Number of subtrees generated: one on every layer.
+	SubTrees int
+
+	// Number of WOTS+ keypairs (leafs) generated.
+	Leafs uint64
+
+	// Number of calls to the hash function.
+	Hashes uint64
+
+	// Rough estimate of the time it takes on a single amd64 core.
+	// Zero if no costs are known for this hash function and N.
+	Time time.Duration
+}
+
+// Estimated latency of Sign().  See Context.LatencyProfile().
+//
+// Most calls to Sign() only create a WOTS+ signature, but the first
+// signature with a new subtree on the lowest layer has to generate it,
+// unless it has been precomputed, which takes much longer.  When a new
+// subtree is needed on a higher layer as well, it is generated too.
+type LatencyProfile struct {
+	// Expected time of a Sign() for which the subtrees are cached.
+	FastSign time.Duration
+
+	// Expected time of a Sign() that has to generate a single subtree.
+	// This assumes the subtree is generated on all workers of the Context.
+	SlowSign time.Duration
+
+	// Number of signatures with the same subtree on the lowest layer.
+	// Without precomputation, one Sign() in SlowSignInterval is slow.
+	SlowSignInterval uint64
+
+	// Whether the private key precomputes subtrees in the background,
+	// see PrivateKey.SetPrecomputeAhead().  If so, a Sign() is only slow
+	// if the precomputation did not keep up.
+	Precomputed bool
+
+	// Number of calls to Sign() after which the next one has to generate
+	// subtrees that are not cached yet, or the number of remaining
+	// signatures, if there is no such call.  Only set if a PrivateKey was
+	// passed to LatencyProfile().
+	NextSlowSign uint64
+
+	// Expected time of that call to Sign(), which depends on the number of
+	// subtrees it has to generate.  Zero if there is no such call.
+	NextSlowSignTime time.Duration
+}
+
+// Estimates the latency of Sign() for the parameters of this Context and,
+// if sk is not nil, for the subtrees that are currently cached by sk.
+//
+// The estimates use rough costs of the hash functions on a single amd64
+// core, see SuggestParams(), and are zero if no costs are known for the
+// hash function and N.  Load balancers can use NextSlowSign to route
+// around signers that are about to generate a subtree.
+func (ctx *Context) LatencyProfile(sk *PrivateKey) (*LatencyProfile, Error) {
+	p := ctx.p
+	hashCost := tabulatedHashCosts[p.Func][p.N]
+	l := uint64(p.WotsLen())
+	wotsHalf := l * uint64(p.WotsW-1) / 2
+	subTree := time.Duration(p.subTreeHashes()) * hashCost /
+		time.Duration(ctx.threads())
+
+	ret := LatencyProfile{
+		FastSign:         time.Duration(l+wotsHalf) * hashCost,
+		SlowSignInterval: 1 << ctx.treeHeight,
+	}
+	ret.SlowSign = ret.FastSign + subTree
+	if sk == nil {
+		return &ret, nil
+	}
+	if err := checkCompatibleParams("Private key", sk.ctx.p, p); err != nil {
+		return nil, err
+	}
+
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	ret.Precomputed = sk.precomputeAhead != 0
+
+	// Find the first signature after which the subtree on the lowest layer
+	// changes to one that is not cached.  As only a few subtrees are
+	// cached, this loop ends quickly.
+	seqNo := uint64(sk.seqNo)
+	if sk.seqNo < sk.seqNoStart {
+		seqNo = uint64(sk.seqNoStart)
+	}
+	end := uint64(sk.seqNoEnd)
+	for tree := seqNo >> ctx.treeHeight; ; tree++ {
+		start := tree << ctx.treeHeight
+		if start < seqNo {
+			start = seqNo
+		}
+		if start >= end {
+			ret.NextSlowSign = sk.remainingSignatures()
+			return &ret, nil
+		}
+		missing := 0
+		for layer := uint32(0); layer < p.D; layer++ {
+			sta := SubTreeAddress{
+				Layer: layer,
+				Tree:  tree >> (ctx.treeHeight * layer),
+			}
+			if !sk.subTreeReady[sta] {
+				missing++
+			}
+		}
+		if missing > 0 {
+			ret.NextSlowSign = start - seqNo
+			ret.NextSlowSignTime = ret.FastSign +
+				time.Duration(missing)*subTree
+			return &ret, nil
+		}
+	}
+}
+
+// Estimates the size of the cache of a private key that keeps the next
+// precomputeAhead subtrees on the lowest layer precomputed, see
+// PrivateKey.SetPrecomputeAhead().
+//
+// To sign, one subtree on every layer is required, so the cache holds
+// D + precomputeAhead subtrees.  Subtrees that are not needed anymore
+// are dropped, unless retained with PrivateKey.SetCacheLimit().
+// FileSize assumes pages of 4096 bytes.
+func (params *Params) CacheSizeEstimate(precomputeAhead int) CacheSizeReport {
+	const alignment = 4096
+	var ret CacheSizeReport
+	ret.BareSubTreeSize = params.BareSubTreeSize()
+	ret.CachedSubTreeSize = params.CachedSubTreeSize()
+	ret.PaddedSubTreeSize = params.paddedSubTreeSize(0, alignment)
+	ret.SubTrees = int(params.D) + precomputeAhead
+	ret.MemorySize = uint64(ret.SubTrees) * uint64(ret.CachedSubTreeSize)
+
+	// The cache file starts with a header that takes up a whole page.
+	ret.FileSize = alignment +
+		uint64(ret.SubTrees)*uint64(ret.PaddedSubTreeSize)
+	return ret
+}
+
+// Estimates the work required to generate a keypair, which is
+// dominated by the generation of the first subtree on every layer.
+func (params *Params) KeygenWorkEstimate() KeygenWorkReport {
+	var ret KeygenWorkReport
+	ret.SubTrees = int(params.D)
+	ret.Leafs = uint64(params.D) << (params.FullHeight / params.D)
+	ret.Hashes = uint64(params.D) * params.subTreeHashes()
+	ret.Time = time.Duration(ret.Hashes) *
+		tabulatedHashCosts[params.Func][params.N]
+	return ret
+}
+
+// Returns the size of a cached subtree in the cache file: the smallest
+// multiple of alignment above CachedSubTreeSizeFrom(lowest) + 13, where 13
+// is the size of fsSubTreeHeader.
+func (params *Params) paddedSubTreeSize(lowest uint32, alignment int) int {
+	return ((((params.CachedSubTreeSizeFrom(lowest) + 13) - 1) /
+		alignment) + 1) * alignment
+}
@@ -0,0 +1,101 @@
+package xmssmt
+
+import (
+	"encoding/hex"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// A stable digest of a public key.  See PublicKey.Fingerprint().
+type Fingerprint [32]byte
+
+// A short identifier of a public key: the first eight bytes of its
+// Fingerprint.  Unlike the full Fingerprint, a KeyID is not collision
+// resistant and should only be used to look up candidate keys.
+type KeyID [8]byte
+
+// Returns the SHAKE256 digest of the parameters, root and public seed of
+// the public key, which can be used to index public keys.
+func (pk *PublicKey) Fingerprint() (ret Fingerprint) {
+	h := sha3.NewShake256()
+	h.Write([]byte("go-xmssmt fingerprint"))
+	h.Write(pk.ctx.p.fixedEncoding())
+	h.Write(pk.root)
+	h.Write(pk.pubSeed)
+	h.Read(ret[:])
+	return
+}
+
+// Returns the short KeyID of the public key.
+func (pk *PublicKey) KeyID() KeyID {
+	return pk.Fingerprint().KeyID()
+}
+
+// Returns the KeyID corresponding to this fingerprint.
+func (fp Fingerprint) KeyID() (ret KeyID) {
+	copy(ret[:], fp[:8])
+	return
+}
+
+// Returns the fingerprint hex encoded.
+func (fp Fingerprint) String() string {
+	return hex.EncodeToString(fp[:])
+}
+
+// Returns the KeyID hex encoded.
+func (id KeyID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Parses a hex encoded fingerprint as returned by Fingerprint.String().
+func ParseFingerprint(s string) (ret Fingerprint, err Error) {
+	err = parseHexInto(s, ret[:])
+	return
+}
+
+// Parses a hex encoded KeyID as returned by KeyID.String().
+func ParseKeyID(s string) (ret KeyID, err Error) {
+	err = parseHexInto(s, ret[:])
+	return
+}
+
+func parseHexInto(s string, out []byte) Error {
+	if len(s) != 2*len(out) {
+		return errorf("Expected %d hexadecimal characters", 2*len(out))
+	}
+	_, err := hex.Decode(out, []byte(s))
+	if err != nil {
+		return wrapErrorf(err, "Failed to decode hexadecimal")
+	}
+	return nil
+}
+
+// Returns the fingerprint hex encoded.
+func (fp Fingerprint) MarshalText() ([]byte, error) {
+	return []byte(fp.String()), nil
+}
+
+// Parses a hex encoded fingerprint.
+func (fp *Fingerprint) UnmarshalText(text []byte) error {
+	var err Error
+	*fp, err = ParseFingerprint(string(text))
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Returns the KeyID hex encoded.
+func (id KeyID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// Parses a hex encoded KeyID.
+func (id *KeyID) UnmarshalText(text []byte) error {
+	var err Error
+	*id, err = ParseKeyID(string(text))
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package xmssmt
+
+import (
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	pubSeed, _, _ := DeriveSeeds([]byte("master secret"), nil, ctx.p)
+	pk := PublicKey{ctx: ctx, pubSeed: pubSeed, root: make([]byte, 16)}
+
+	fp := pk.Fingerprint()
+	fp2, err := ParseFingerprint(fp.String())
+	if err != nil {
+		t.Fatalf("ParseFingerprint(): %v", err)
+	}
+	if fp2 != fp {
+		t.Fatalf("ParseFingerprint() does not invert String()")
+	}
+
+	id, err := ParseKeyID(pk.KeyID().String())
+	if err != nil {
+		t.Fatalf("ParseKeyID(): %v", err)
+	}
+	if id != fp.KeyID() || len(id.String()) != 16 {
+		t.Fatalf("ParseKeyID() does not invert String()")
+	}
+
+	if _, err = ParseKeyID(fp.String()); err == nil {
+		t.Fatalf("ParseKeyID() accepted a fingerprint")
+	}
+	if _, err = ParseKeyID("zz" + id.String()[2:]); err == nil {
+		t.Fatalf("ParseKeyID() accepted invalid hex")
+	}
+
+	pk.root[0] = 1
+	if pk.Fingerprint() == fp {
+		t.Fatalf("Different public keys have the same fingerprint")
+	}
+}
@@ -0,0 +1,133 @@
+package xmssmt
+
+// Contains the versions of the file formats of the filesystem container
+// and the migrations between them.
+
+const (
+	// Version of the key file written by this package.
+	//
+	//   0 Original, without version field.  Has magic FS_CONTAINER_KEY_MAGIC.
+	//   1 Adds the version after the magic.  Has magic
+	//     FS_CONTAINER_KEY_MAGIC2.
+	//   2 Adds the high-water mark after the private key.
+	//   3 Adds the KeyStructure after the high-water mark.
+	//   4 Adds the root, preceded by its length, after the KeyStructure.
+	//   5 Adds the lowest cached height after the root.
+	fsKeyVersion uint8 = 5
+
+	// Version of the cache file written by this package.
+	//
+	//   0 Original.  Has magic FS_CONTAINER_CACHE_MAGIC.
+	//   1 Second version which includes subtree alignment.
+	//     Has magic FS_CONTAINER_CACHE_MAGIC2.
+	//   2 Adds whether the subtrees have a keyed checksum after the
+	//     subtree alignment.
+	fsCacheVersion uint8 = 2
+)
+
+// Upgrades a file of the filesystem container from version from to
+// version from+1.
+//
+// The key file is read completely into memory, so its migrations only
+// have to update the fields of the fsContainer: the key file is written
+// in the current format afterwards.  The cache file is read on demand, so
+// its migrations have to change the file in place.  Its header is
+// written afterwards.
+type fsMigration struct {
+	from  uint8
+	name  string
+	apply func(ctr *fsContainer) Error
+}
+
+// Migrations of the key file, in order.
+var fsKeyMigrations = []fsMigration{
+	{0, "add version to header", func(ctr *fsContainer) Error {
+		return nil
+	}},
+	{1, "add high-water mark", func(ctr *fsContainer) Error {
+		// readKey() already set the high-water mark to the sequence number.
+		return nil
+	}},
+	{2, "add key structure", func(ctr *fsContainer) Error {
+		// Older key files only contain keys with the FlatKeyStructure.
+		return nil
+	}},
+	{3, "add root", func(ctr *fsContainer) Error {
+		// The root is not known, which is stored as an empty root, until
+		// the private key is loaded, see LoadPrivateKeyFrom().
+		return nil
+	}},
+	{4, "add lowest cached height", func(ctr *fsContainer) Error {
+		// Older caches contain all nodes of the subtrees.
+		return nil
+	}},
+}
+
+// Migrations of the cache file, in order.
+var fsCacheMigrations = []fsMigration{
+	{0, "add subtree alignment to header", func(ctr *fsContainer) Error {
+		// Version 0 caches are aligned to 4096 bytes, which is already
+		// set in ctr.subTreeAlignment.
+		return nil
+	}},
+	{1, "add checksum mode to header", func(ctr *fsContainer) Error {
+		// Older caches only contain subtrees with the unkeyed checksum.
+		return nil
+	}},
+}
+
+// Runs the migrations from version to the latest version.
+func (ctr *fsContainer) runMigrations(file string, version uint8,
+	migrations []fsMigration) Error {
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		log.Logf("Migrating %s file %s from version %d: %s ...",
+			file, ctr.path, m.from, m.name)
+		if err := m.apply(ctr); err != nil {
+			return wrapErrorf(err, "Failed to migrate %s file from "+
+				"version %d", file, m.from)
+		}
+	}
+	return nil
+}
+
+// Upgrades the key file, if it's in an older format.
+func (ctr *fsContainer) migrateKey() Error {
+	if ctr.readOnly || ctr.keyVersion == fsKeyVersion {
+		return nil
+	}
+	err := ctr.runMigrations("key", ctr.keyVersion, fsKeyMigrations)
+	if err != nil {
+		return err
+	}
+
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
+	if err = ctr.writeKeyFile(true); err != nil {
+		return wrapErrorf(err, "Failed to write migrated key file")
+	}
+	ctr.keyVersion = fsKeyVersion
+	return nil
+}
+
+// Upgrades the cache file, if it's in an older format.
+func (ctr *fsContainer) migrateCache() Error {
+	if ctr.readOnly || ctr.cacheVersion == fsCacheVersion {
+		return nil
+	}
+	err := ctr.runMigrations("cache", ctr.cacheVersion, fsCacheMigrations)
+	if err != nil {
+		return err
+	}
+
+	if err = ctr.writeCacheHeader(); err != nil {
+		return wrapErrorf(err, "Failed to write migrated cache header")
+	}
+	if err2 := ctr.cacheFile.Sync(); err2 != nil {
+		return wrapErrorf(err2, "Failed to sync migrated cache file")
+	}
+	ctr.cacheVersion = fsCacheVersion
+	return nil
+}
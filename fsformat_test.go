@@ -0,0 +1,127 @@
+package xmssmt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestFSContainerMigration(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	msg := []byte("test message")
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// Turn the files into version 0: drop the version and high-water mark
+	// of the key file and clear the fields of the cache header added in
+	// version 1.
+	keyBuf, err := ioutil.ReadFile(dir + "/key")
+	if err != nil {
+		t.Fatalf("ReadFile(): %v", err)
+	}
+	if hex.EncodeToString(keyBuf[:8]) != FS_CONTAINER_KEY_MAGIC2 ||
+		keyBuf[8] != fsKeyVersion {
+		t.Fatalf("Key file is not in the current format")
+	}
+	magic, _ := hex.DecodeString(FS_CONTAINER_KEY_MAGIC)
+	keyBuf = append(magic, keyBuf[9:len(keyBuf)-8]...)
+	if err = ioutil.WriteFile(dir+"/key", keyBuf, 0600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	cacheFile, err := os.OpenFile(dir+"/key.cache", os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("OpenFile(): %v", err)
+	}
+	magic, _ = hex.DecodeString(FS_CONTAINER_CACHE_MAGIC)
+	if _, err = cacheFile.WriteAt(magic, 0); err != nil {
+		t.Fatalf("WriteAt(): %v", err)
+	}
+	if _, err = cacheFile.WriteAt(make([]byte, 5), 12); err != nil {
+		t.Fatalf("WriteAt(): %v", err)
+	}
+	cacheFile.Close()
+
+	// A read-only container reads, but does not upgrade, old files.
+	ctr, err := OpenFSPrivateKeyContainerReadOnly(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainerReadOnly(): %v", err)
+	}
+	if ctr.(*fsContainer).keyVersion != 0 ||
+		ctr.(*fsContainer).cacheVersion != 0 {
+		t.Fatalf("Files are not recognized as version 0")
+	}
+	if err = ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	sk, _, lostSigs, err := LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if lostSigs != 0 || sk.SeqNo() != 1 {
+		t.Fatalf("Migrated key has seqNo %d and %d lost signatures",
+			sk.SeqNo(), lostSigs)
+	}
+	if report := sk.LoadReport(); report.CachedSubTrees != 2 {
+		t.Fatalf("Migrated cache has %d subtrees", report.CachedSubTrees)
+	}
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	keyBuf, _ = ioutil.ReadFile(dir + "/key")
+	if hex.EncodeToString(keyBuf[:8]) != FS_CONTAINER_KEY_MAGIC2 {
+		t.Fatalf("Key file has not been migrated")
+	}
+	ctr, err = OpenFSPrivateKeyContainerReadOnly(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainerReadOnly(): %v", err)
+	}
+	if root, _ := ctr.(RootStore).Root(); !bytes.Equal(root, pk.root) {
+		t.Fatalf("Root has not been stored in the migrated key file")
+	}
+	ctr.Close()
+	cacheBuf, _ := ioutil.ReadFile(dir + "/key.cache")
+	if hex.EncodeToString(cacheBuf[:8]) != FS_CONTAINER_CACHE_MAGIC2 ||
+		cacheBuf[12] != fsCacheVersion {
+		t.Fatalf("Cache file has not been migrated")
+	}
+
+	// Files from a future version are rejected.
+	keyBuf[8] = fsKeyVersion + 1
+	if err = ioutil.WriteFile(dir+"/key", keyBuf, 0600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	if _, _, _, err = LoadPrivateKey(dir + "/key"); err == nil {
+		t.Fatalf("LoadPrivateKey() accepted future key file version")
+	}
+}
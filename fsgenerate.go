@@ -0,0 +1,233 @@
+package xmssmt
+
+// Contains the atomic creation of private keys on the filesystem and the
+// detection and repair of partially created ones.
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Returns the prefix of the names of the temporary directories in which
+// the private key at the (absolute) path is generated.
+func fsGenerationDirPrefix(path string) string {
+	return "." + filepath.Base(path) + ".generating-"
+}
+
+// Derives the private key into a temporary directory next to path and
+// moves it into place when it's complete, so that no partially initialized
+// key is left at path if generation fails or the process dies.
+// See Context.Derive().
+func (ctx *Context) deriveFS(path string, pubSeed, skSeed, skPrf []byte) (
+	*PrivateKey, *PublicKey, Error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, wrapErrorf(err,
+			"Could not turn %s into an absolute path", path)
+	}
+
+	// Take the lock on the final path, which we hand over to the container
+	// we open there in the end.
+	lockFilePath := path + ".lock"
+	flock, err := newFileLock(lockFilePath)
+	if err != nil {
+		return nil, nil, wrapErrorf(err,
+			"Failed to create lockfile %s", lockFilePath)
+	}
+	err = flock.TryLock()
+	if _, ok := err.(interface {
+		Temporary() bool
+	}); ok {
+		err2 := errorf("%s is locked", path)
+		err2.kind = ErrLocked
+		return nil, nil, err2
+	}
+	handedOver := false
+	defer func() {
+		if !handedOver {
+			flock.Unlock()
+		}
+	}()
+
+	tmpDir, err := ioutil.TempDir(filepath.Dir(path),
+		fsGenerationDirPrefix(path))
+	if err != nil {
+		return nil, nil, wrapErrorf(err, "Failed to create temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpPath := filepath.Join(tmpDir, filepath.Base(path))
+
+	ctr, err2 := OpenFSPrivateKeyContainer(tmpPath)
+	if err2 != nil {
+		return nil, nil, err2
+	}
+	sk, _, err2 := ctx.DeriveInto(ctr, pubSeed, skSeed, skPrf)
+	if err2 != nil {
+		ctr.Close()
+		return nil, nil, err2
+	}
+	if err2 = sk.Close(); err2 != nil {
+		return nil, nil, err2
+	}
+
+	// Move the files into place.  The key file is moved first: if we crash
+	// before the cache is moved, the key is left without cache, which
+	// RepairFSPrivateKey() recreates.  The other way around the key file
+	// of a key we replace would be left with the cache of the new key.
+	if err = os.Remove(path + ".cache"); err != nil && !os.IsNotExist(err) {
+		return nil, nil, wrapErrorf(err, "Failed to remove old cache file")
+	}
+	if err = replaceFile(tmpPath, path); err != nil {
+		return nil, nil, wrapErrorf(err, "Failed to move key file into place")
+	}
+	if err = replaceFile(tmpPath+".cache", path+".cache"); err != nil {
+		return nil, nil, wrapErrorf(err,
+			"Failed to move cache file into place")
+	}
+
+	// We already hold the lock, so opening the container won't fail on it.
+	ctr, err2 = OpenFSPrivateKeyContainer(path)
+	if err2 != nil {
+		return nil, nil, err2
+	}
+	handedOver = true
+	sk, pk, _, err2 := loadPrivateKeyFrom(ctx, ctr)
+	if err2 != nil {
+		ctr.Close()
+		return nil, nil, err2
+	}
+	return sk, pk, nil
+}
+
+// Leftovers of an interrupted GenerateKeyPair() or of a crash, found by
+// DetectPartialFSPrivateKey().
+type FSPartialState struct {
+	// Temporary directories of key generations that did not finish.
+	GenerationDirs []string
+
+	// Temporary files that were not moved into place.
+	TempFiles []string
+
+	// Whether there is a key file without cache file.  The key is fine,
+	// but the cache has to be recreated before it can be loaded.
+	MissingCache bool
+
+	// Whether there is a cache file without key file.
+	OrphanedCache bool
+}
+
+// Returns whether anything was found.
+func (s *FSPartialState) Partial() bool {
+	return len(s.GenerationDirs) != 0 || len(s.TempFiles) != 0 ||
+		s.MissingCache || s.OrphanedCache
+}
+
+// Looks for the leftovers of an interrupted GenerateKeyPair() or of a crash
+// for the private key container at path.  If the key is being generated
+// or used by another process, its temporary files are reported as well.
+// Use RepairFSPrivateKey() to clean up.
+func DetectPartialFSPrivateKey(path string) (*FSPartialState, Error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, wrapErrorf(err,
+			"Could not turn %s into an absolute path", path)
+	}
+
+	var state FSPartialState
+	entries, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to list directory")
+	}
+	prefix := fsGenerationDirPrefix(path)
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			state.GenerationDirs = append(state.GenerationDirs,
+				filepath.Join(filepath.Dir(path), entry.Name()))
+		}
+	}
+
+	for _, tmpPath := range []string{path + ".tmp", path + ".borrows.tmp"} {
+		if fileExists(tmpPath) {
+			state.TempFiles = append(state.TempFiles, tmpPath)
+		}
+	}
+
+	keyExists := fileExists(path)
+	cacheExists := fileExists(path + ".cache")
+	state.MissingCache = keyExists && !cacheExists
+	state.OrphanedCache = !keyExists && cacheExists
+	return &state, nil
+}
+
+// Cleans up the leftovers of an interrupted GenerateKeyPair() or of a crash
+// for the private key container at path, see DetectPartialFSPrivateKey():
+// temporary files and directories and an orphaned cache are removed and
+// a missing cache is recreated.  Returns what was repaired.
+//
+// Takes the lock on the container, so it fails if the key is in use.
+func RepairFSPrivateKey(path string) (*FSPartialState, Error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, wrapErrorf(err,
+			"Could not turn %s into an absolute path", path)
+	}
+
+	lockFilePath := path + ".lock"
+	flock, err := newFileLock(lockFilePath)
+	if err != nil {
+		return nil, wrapErrorf(err,
+			"Failed to create lockfile %s", lockFilePath)
+	}
+	if err = flock.TryLock(); err != nil {
+		err2 := wrapErrorf(err, "%s is locked", path)
+		err2.kind = ErrLocked
+		return nil, err2
+	}
+	defer flock.Unlock()
+
+	state, err2 := DetectPartialFSPrivateKey(path)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	for _, dir := range state.GenerationDirs {
+		log.Logf("Removing %s", dir)
+		if err = os.RemoveAll(dir); err != nil {
+			return nil, wrapErrorf(err, "Failed to remove %s", dir)
+		}
+	}
+	for _, tmpPath := range state.TempFiles {
+		log.Logf("Removing %s", tmpPath)
+		if err = os.Remove(tmpPath); err != nil {
+			return nil, wrapErrorf(err, "Failed to remove %s", tmpPath)
+		}
+	}
+	if state.OrphanedCache {
+		log.Logf("Removing orphaned cache %s.cache", path)
+		if err = os.Remove(path + ".cache"); err != nil {
+			return nil, wrapErrorf(err, "Failed to remove orphaned cache")
+		}
+	}
+	if state.MissingCache {
+		log.Logf("Recreating cache %s.cache", path)
+		ctr := fsContainer{path: path}
+		if err2 = ctr.readKey(); err2 != nil {
+			return nil, err2
+		}
+		if err2 = ctr.ResetCache(); err2 != nil {
+			return nil, err2
+		}
+		if err = ctr.closeCache(); err != nil {
+			return nil, wrapErrorf(err, "Failed to close cache")
+		}
+	}
+	return state, nil
+}
+
+// Returns whether there is a file at path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
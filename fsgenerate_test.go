@@ -0,0 +1,119 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestDeriveAtomic(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+
+	// A failed generation leaves nothing behind.
+	if _, _, err = ctx.Derive(dir+"/key", nil, nil, nil); err == nil {
+		t.Fatalf("Derive() accepted empty seeds")
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("Failed Derive() left %d files behind", len(entries))
+	}
+
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	msg := []byte("test message")
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if report := sk.LoadReport(); report.CachedSubTrees != 2 {
+		t.Fatalf("Generated key has %d cached subtrees",
+			report.CachedSubTrees)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	state, err := DetectPartialFSPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("DetectPartialFSPrivateKey(): %v", err)
+	}
+	if state.Partial() {
+		t.Fatalf("Generated key is partial: %+v", state)
+	}
+
+	// Simulate crashes during generation and while writing the key file.
+	if err = os.Remove(dir + "/key.cache"); err != nil {
+		t.Fatalf("Remove(): %v", err)
+	}
+	if err = os.Mkdir(dir+"/.key.generating-123", 0700); err != nil {
+		t.Fatalf("Mkdir(): %v", err)
+	}
+	copyFile(t, dir+"/key", dir+"/.key.generating-123/key")
+	copyFile(t, dir+"/key", dir+"/key.tmp")
+	copyFile(t, dir+"/key", dir+"/other.cache")
+
+	state, err = DetectPartialFSPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("DetectPartialFSPrivateKey(): %v", err)
+	}
+	if len(state.GenerationDirs) != 1 || len(state.TempFiles) != 1 ||
+		!state.MissingCache || state.OrphanedCache {
+		t.Fatalf("Unexpected state: %+v", state)
+	}
+	if _, err = RepairFSPrivateKey(dir + "/key"); err != nil {
+		t.Fatalf("RepairFSPrivateKey(): %v", err)
+	}
+	state, err = DetectPartialFSPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("DetectPartialFSPrivateKey(): %v", err)
+	}
+	if state.Partial() {
+		t.Fatalf("Repaired key is partial: %+v", state)
+	}
+
+	sk, _, lostSigs, err := LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if lostSigs != 0 || sk.SeqNo() != 1 {
+		t.Fatalf("Repaired key has seqNo %d and %d lost signatures",
+			sk.SeqNo(), lostSigs)
+	}
+	sig, err = sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// A cache without key file is removed.
+	state, err = RepairFSPrivateKey(dir + "/other")
+	if err != nil {
+		t.Fatalf("RepairFSPrivateKey(): %v", err)
+	}
+	if !state.OrphanedCache {
+		t.Fatalf("Orphaned cache was not detected")
+	}
+	if _, err = os.Stat(dir + "/other.cache"); !os.IsNotExist(err) {
+		t.Fatalf("Orphaned cache was not removed")
+	}
+}
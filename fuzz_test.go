@@ -0,0 +1,128 @@
+// +build go1.18
+
+package xmssmt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Adds seeds derived from the registry: the compressed parameters of
+// every named instance together with the given encoder applied to them.
+func addRegistrySeeds(f *testing.F, enc func(ctx *Context) []byte) {
+	for _, name := range ListNames() {
+		ctx := NewContextFromName(name)
+		if ctx.p.FullHeight > 20 {
+			continue // keep the corpus small
+		}
+		f.Add(enc(ctx))
+	}
+}
+
+// Returns an all-zero buffer of the given size prefixed by the compressed
+// parameters of ctx.
+func zeroWithParams(ctx *Context, size uint32) []byte {
+	buf := make([]byte, 4+size)
+	ctx.p.WriteInto(buf)
+	return buf
+}
+
+func FuzzParseParams(f *testing.F) {
+	addRegistrySeeds(f, func(ctx *Context) []byte {
+		buf, _ := ctx.p.MarshalBinary()
+		return buf
+	})
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		params, err := ParseParams(buf)
+		if err != nil {
+			return
+		}
+		buf2, err2 := params.MarshalBinary()
+		if err2 != nil {
+			t.Fatalf("MarshalBinary(): %v", err2)
+		}
+		if !bytes.Equal(buf, buf2) {
+			t.Fatalf("Parameters did not round trip")
+		}
+	})
+}
+
+func FuzzParsePublicKey(f *testing.F) {
+	addRegistrySeeds(f, func(ctx *Context) []byte {
+		return zeroWithParams(ctx, ctx.pkBytes)
+	})
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		pk, err := ParsePublicKey(buf)
+		if err != nil {
+			return
+		}
+		buf2, err2 := pk.MarshalBinary()
+		if err2 != nil {
+			t.Fatalf("MarshalBinary(): %v", err2)
+		}
+		if !bytes.Equal(buf, buf2) {
+			t.Fatalf("Public key did not round trip")
+		}
+	})
+}
+
+func FuzzParseSignature(f *testing.F) {
+	addRegistrySeeds(f, func(ctx *Context) []byte {
+		return zeroWithParams(ctx, ctx.sigBytes)
+	})
+	f.Fuzz(func(t *testing.T, buf []byte) {
+		sig, err := ParseSignature(buf)
+		if err != nil {
+			return
+		}
+		buf2, err2 := sig.MarshalBinary()
+		if err2 != nil {
+			t.Fatalf("MarshalBinary(): %v", err2)
+		}
+		if !bytes.Equal(buf, buf2) {
+			t.Fatalf("Signature did not round trip")
+		}
+	})
+}
+
+// Verifies arbitrary signatures against arbitrary public keys.  The public
+// key has to be valid for the fuzzer to get anywhere, so the seeds
+// contain a real one.
+func FuzzVerify(f *testing.F) {
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		f.Fatalf("NewContext(): %v", err)
+	}
+	seed := make([]byte, 3*ctx.p.N)
+	pkBuf, sigBuf, err := ctx.KnownAnswer(seed, 7, []byte("msg"))
+	if err != nil {
+		f.Fatalf("KnownAnswer(): %v", err)
+	}
+	pk, err := ctx.UnmarshalRawPublicKey(pkBuf)
+	if err != nil {
+		f.Fatalf("UnmarshalRawPublicKey(): %v", err)
+	}
+	pkBin, _ := pk.MarshalBinary()
+	sig, err := ctx.UnmarshalRawSignature(sigBuf)
+	if err != nil {
+		f.Fatalf("UnmarshalRawSignature(): %v", err)
+	}
+	sigBin, _ := sig.MarshalBinary()
+	f.Add(pkBin, sigBin, []byte("msg"))
+
+	// A signature for other parameters.
+	ctx2 := NewContextFromName("XMSS-SHAKE_10_256")
+	f.Add(pkBin, zeroWithParams(ctx2, ctx2.sigBytes), []byte("msg"))
+
+	f.Fuzz(func(t *testing.T, pkBuf, sigBuf, msg []byte) {
+		pk, err := ParsePublicKey(pkBuf)
+		if err != nil {
+			return
+		}
+		sig, err := ParseSignature(sigBuf)
+		if err != nil {
+			return
+		}
+		pk.Verify(sig, msg)
+	})
+}
@@ -12,6 +12,7 @@ require (
 	github.com/templexxx/cpufeat v0.0.0-20180724012125-cef66df7f161 // indirect
 	github.com/templexxx/xor v0.0.0-20191217153810-f85b25db303b
 	github.com/templexxx/xorsimd v0.4.1
+	go.etcd.io/bbolt v1.3.6
 	golang.org/x/crypto v0.0.0-20220518034528-6f7dac969898
 	golang.org/x/sys v0.0.0-20220519141025-dcacdad47464
 )
@@ -5,12 +5,15 @@ package xmssmt
 import (
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding"
 	"encoding/binary"
 	"hash"
 	"io"
 	"reflect"
 
+	"github.com/bwesterb/go-xmssmt/internal/f1600x2"
 	"github.com/bwesterb/go-xmssmt/internal/f1600x4"
+	"github.com/bwesterb/go-xmssmt/internal/sha256x8"
 	"github.com/templexxx/xorsimd"
 	"golang.org/x/crypto/sha3"
 )
@@ -35,8 +38,28 @@ type precomputedHashes struct {
 	// Precomputed prfAddrInto for the current skSeed
 	prfAddrSkSeedInto func(pad scratchPad, addr address, out []byte)
 
+	// Precomputed prfKeyGenInto for the current skSeed and pubSeed.
+	// Only set for SHA2.
+	prfKeyGenInto func(pad scratchPad, addr address, out []byte)
+
 	pubSeed []byte
 	skSeed  []byte
+
+	// If set, wipes the precomputed hash state that depends on skSeed.
+	zeroizeSkState func()
+
+	// State of SHA-256 after absorbing the whole blocks of the common prefix
+	// of prfAddrPubSeedInto, the number of bytes absorbed and the remaining
+	// bytes of the prefix.  Only set when the eightway SHA-256 is used.
+	prfPubSeedIV   [8]uint32
+	prfPubSeedLen  uint64
+	prfPubSeedTail []byte
+}
+
+// A multi-way Keccak-f[1600] such as f1600x4.State or f1600x2.State.
+type keccakXN interface {
+	Zero()
+	Permute()
 }
 
 // Contains preallocated hashes to prevent allocation.  See scratchPad.
@@ -44,8 +67,11 @@ type hashScratchPad struct {
 	h        hash.Hash
 	hV       reflect.Value
 	shake    sha3.ShakeHash
-	shakeX4  *f1600x4.State
-	shakeX4A []uint64
+	shakeXN  keccakXN // either a fourway or a twoway Keccak-f[1600]
+	shakeXNA []uint64 // the interleaved states of shakeXN
+	shakeXNL int      // the number of states interleaved in shakeXNA
+	sha2X8   *sha256x8.State
+	sha2X8B  []byte // buffer used by fX8Into
 }
 
 func (ctx *Context) precomputeHashes(pubSeed, skSeed []byte) (
@@ -53,16 +79,9 @@ func (ctx *Context) precomputeHashes(pubSeed, skSeed []byte) (
 	ph.pubSeed = pubSeed
 	ph.skSeed = skSeed
 	switch ctx.p.Func {
-	case SHA2:
-		var hPrfSk, hPrfPub hash.Hash
-		switch ctx.p.N {
-		case 16, 24, 32:
-			hPrfSk = sha256.New()
-			hPrfPub = sha256.New()
-		case 64:
-			hPrfSk = sha512.New()
-			hPrfPub = sha512.New()
-		}
+	case SHA2, SHA512_256:
+		hPrfSk := ctx.newSha2()
+		hPrfPub := ctx.newSha2()
 
 		if skSeed != nil {
 			hPrfSk.Write(encodeUint64(HASH_PADDING_PRF, int(ctx.prefixLen)))
@@ -72,6 +91,19 @@ func (ctx *Context) precomputeHashes(pubSeed, skSeed []byte) (
 		hPrfPub.Write(encodeUint64(HASH_PADDING_PRF, int(ctx.prefixLen)))
 		hPrfPub.Write(pubSeed)
 
+		if ctx.x8Available {
+			// The marshaled state of crypto/sha256 consists of a four byte
+			// magic, the eight words of the state, the buffered block
+			// and the number of bytes written.
+			buf, _ := hPrfPub.(encoding.BinaryMarshaler).MarshalBinary()
+			for i := 0; i < 8; i++ {
+				ph.prfPubSeedIV[i] = binary.BigEndian.Uint32(buf[4+4*i:])
+			}
+			written := binary.BigEndian.Uint64(buf[len(buf)-8:])
+			ph.prfPubSeedLen = written - written%64
+			ph.prfPubSeedTail = buf[36 : 36+written%64]
+		}
+
 		// This might break if sha{256,512}.digest is changed in the future,
 		// but it's much better than using the encoding.Binary(Un)marshaler
 		// interface as that forces allocations.
@@ -99,6 +131,25 @@ func (ctx *Context) precomputeHashes(pubSeed, skSeed []byte) (
 			return
 		}
 
+		// The prefix HASH_PADDING_PRF_KEYGEN ‖ skSeed ‖ pubSeed of
+		// prfKeyGenInto is at least one block for n=32 and n=64.
+		hPrfKeyGen := ctx.newSha2()
+		hPrfKeyGen.Write(encodeUint64(HASH_PADDING_PRF_KEYGEN,
+			int(ctx.prefixLen)))
+		hPrfKeyGen.Write(skSeed)
+		hPrfKeyGen.Write(pubSeed)
+		hVprfKeyGen := reflect.ValueOf(hPrfKeyGen).Elem()
+
+		ph.zeroizeSkState = func() {
+			// Reset() does not clear the buffered block, so we overwrite
+			// it first with a write that is shorter than a block.
+			for _, h := range []hash.Hash{hPrfSk, hPrfKeyGen} {
+				h.Reset()
+				h.Write(make([]byte, h.BlockSize()-1))
+				h.Reset()
+			}
+		}
+
 		ph.prfAddrSkSeedInto = func(pad scratchPad, addr address, out []byte) {
 			pad.hash.hV.Set(hVskPub)
 			addrBuf := pad.prfAddrBuf()
@@ -111,6 +162,19 @@ func (ctx *Context) precomputeHashes(pubSeed, skSeed []byte) (
 				copy(out[:], addrBuf[:ctx.p.N])
 			}
 		}
+
+		ph.prfKeyGenInto = func(pad scratchPad, addr address, out []byte) {
+			pad.hash.hV.Set(hVprfKeyGen)
+			addrBuf := pad.prfAddrBuf()
+			addr.writeInto(addrBuf)
+			pad.hash.h.Write(addrBuf)
+			if ctx.p.N >= 32 {
+				pad.hash.h.Sum(out[:0]) // see above
+			} else {
+				pad.hash.h.Sum(addrBuf[:0])
+				copy(out[:], addrBuf[:ctx.p.N])
+			}
+		}
 	case SHAKE, SHAKE256:
 		// The rates of Shake128 and Shake256 are so high (136 resp. 168)
 		// that precomputing does not have merit.
@@ -161,6 +225,9 @@ func (ctx *Context) hashInto(pad scratchPad, in, out []byte) {
 			ret := sha512.Sum512(in)
 			copy(out, ret[:])
 		}
+	case SHA512_256:
+		ret := sha512.Sum512_256(in)
+		copy(out, ret[:ctx.p.N])
 	case SHAKE, SHAKE256:
 		h := pad.hash.shake
 		h.Reset()
@@ -171,6 +238,10 @@ func (ctx *Context) hashInto(pad scratchPad, in, out []byte) {
 
 func (ctx *Context) prfKeyGenInto(pad scratchPad, ph precomputedHashes,
 	addr address, out []byte) {
+	if ph.prfKeyGenInto != nil {
+		ph.prfKeyGenInto(pad, addr, out)
+		return
+	}
 	n := ctx.p.N
 	pl := ctx.prefixLen
 	buf := pad.prfKeyGenBuf()
@@ -217,139 +288,170 @@ func (ctx *Context) prfAddrInto(pad scratchPad, addr address, key, out []byte) {
 	ctx.hashInto(pad, buf[:n+pl+32], out)
 }
 
-// Set out[i] = PRF(key, addr[i]) for i=0,1,2,3.
+// Set out[i] = PRF(key, addr[i]) for i=0,1,2,3, or only for
+// i=0,1 when the twoway permutation is used.
 //
-// Assumes SHAKE with N either 16 or 32 and f1600x4.Available is true.
+// Assumes SHAKE with N either 16 or 32 and either f1600x4.Available or
+// f1600x2.Available is true.
 func (ctx *Context) prfAddrX4Into(pad scratchPad, addr [4]address, key []byte,
 	out [4][]byte) {
 	// We're computing hash( HASH_PADDING_PRF ‖ key ‖ addr ).
-	a := pad.hash.shakeX4A
-	pad.hash.shakeX4.Zero()
+	a := pad.hash.shakeXNA
+	L := pad.hash.shakeXNL
+	pad.hash.shakeXN.Zero()
 	if ctx.p.N == 16 {
-		for j := 0; j < 4; j++ {
+		for j := 0; j < L; j++ {
 			if out[j] == nil {
 				continue
 			}
 
-			a[4+j] = HASH_PADDING_PRF << 56
-			a[4*2+j] = binary.LittleEndian.Uint64(key[:8])
-			a[4*3+j] = binary.LittleEndian.Uint64(key[8:])
+			a[L+j] = HASH_PADDING_PRF << 56
+			a[L*2+j] = binary.LittleEndian.Uint64(key[:8])
+			a[L*3+j] = binary.LittleEndian.Uint64(key[8:])
 
 			var buf [8]byte
 			for i := 0; i < 4; i++ {
 				binary.BigEndian.PutUint32(buf[:4], addr[j][2*i])
 				binary.BigEndian.PutUint32(buf[4:], addr[j][2*i+1])
-				a[4*(4+i)+j] = binary.LittleEndian.Uint64(buf[:])
+				a[L*(4+i)+j] = binary.LittleEndian.Uint64(buf[:])
 			}
 
 			// SHAKE128 domain separator (0b1111) and padding (0b100...001).
-			a[4*8+j] = 0x1f
-			a[4*20+j] = 0x80 << 56
+			a[L*8+j] = 0x1f
+			a[L*20+j] = 0x80 << 56
 		}
 
-		pad.hash.shakeX4.Permute()
+		pad.hash.shakeXN.Permute()
 
-		for j := 0; j < 4; j++ {
+		for j := 0; j < L; j++ {
 			if out[j] == nil {
 				continue
 			}
 			binary.LittleEndian.PutUint64(out[j][0:8], a[j])
-			binary.LittleEndian.PutUint64(out[j][8:16], a[4+j])
+			binary.LittleEndian.PutUint64(out[j][8:16], a[L+j])
 		}
 	} else if ctx.p.N == 32 {
-		for j := 0; j < 4; j++ {
+		for j := 0; j < L; j++ {
 			if out[j] == nil {
 				continue
 			}
 
-			a[4*3+j] = HASH_PADDING_PRF << 56
-			a[4*4+j] = binary.LittleEndian.Uint64(key[:8])
-			a[4*5+j] = binary.LittleEndian.Uint64(key[8:16])
-			a[4*6+j] = binary.LittleEndian.Uint64(key[16:24])
-			a[4*7+j] = binary.LittleEndian.Uint64(key[24:32])
+			a[L*3+j] = HASH_PADDING_PRF << 56
+			a[L*4+j] = binary.LittleEndian.Uint64(key[:8])
+			a[L*5+j] = binary.LittleEndian.Uint64(key[8:16])
+			a[L*6+j] = binary.LittleEndian.Uint64(key[16:24])
+			a[L*7+j] = binary.LittleEndian.Uint64(key[24:32])
 
 			var buf [8]byte
 			for i := 0; i < 4; i++ {
 				binary.BigEndian.PutUint32(buf[:4], addr[j][2*i])
 				binary.BigEndian.PutUint32(buf[4:], addr[j][2*i+1])
-				a[4*(8+i)+j] = binary.LittleEndian.Uint64(buf[:])
+				a[L*(8+i)+j] = binary.LittleEndian.Uint64(buf[:])
 			}
 
 			// SHAKE128 domain separator (0b1111) and padding (0b100...001).
-			a[4*12+j] = 0x1f
-			a[4*20+j] = 0x80 << 56
+			a[L*12+j] = 0x1f
+			a[L*20+j] = 0x80 << 56
 		}
 
-		pad.hash.shakeX4.Permute()
+		pad.hash.shakeXN.Permute()
 
-		for j := 0; j < 4; j++ {
+		for j := 0; j < L; j++ {
 			if out[j] == nil {
 				continue
 			}
 			binary.LittleEndian.PutUint64(out[j][0:8], a[j])
-			binary.LittleEndian.PutUint64(out[j][8:16], a[4+j])
-			binary.LittleEndian.PutUint64(out[j][16:24], a[8+j])
-			binary.LittleEndian.PutUint64(out[j][24:32], a[12+j])
+			binary.LittleEndian.PutUint64(out[j][8:16], a[L+j])
+			binary.LittleEndian.PutUint64(out[j][16:24], a[2*L+j])
+			binary.LittleEndian.PutUint64(out[j][24:32], a[3*L+j])
 		}
 	} else {
 		panic("not implemented")
 	}
 }
 
-// Compute hash of a message and put it into out
-func (ctx *Context) hashMessage(pad scratchPad, msg io.Reader,
+// Size of the chunks in which messages are read by SignFrom() and
+// PublicKey.VerifyFrom(), unless the io.Reader implements io.WriterTo.
+const MessageChunkSize = 64 * 1024
+
+// Compute hash of a message, prepended by prefix if not nil.
+// See writeMessage().
+func (ctx *Context) hashMessage(pad scratchPad, prefix []byte, msg io.Reader,
 	R, root []byte, idx uint64) ([]byte, error) {
 	ret := make([]byte, ctx.p.N)
-	err := ctx.hashMessageInto(pad, msg, R, root, idx, ret)
+	err := ctx.hashMessageInto(pad, prefix, msg, R, root, idx, ret)
 	if err != nil {
 		return nil, err
 	}
 	return ret, nil
 }
 
-// Compute hash of a message and put it into out
-func (ctx *Context) hashMessageInto(pad scratchPad, msg io.Reader,
-	R, root []byte, idx uint64, out []byte) error {
+// Compute hash of a message, prepended by prefix if not nil, and put it
+// into out
+func (ctx *Context) hashMessageInto(pad scratchPad, prefix []byte,
+	msg io.Reader, R, root []byte, idx uint64, out []byte) error {
+	h := ctx.startMessageHash(pad, R, root, idx)
+	if err := ctx.writeMessage(h, prefix, msg); err != nil {
+		return err
+	}
+	ctx.finishMessageHash(pad, h, out)
+	return nil
+}
 
+// Like hashMessageInto(), but for a message that is in memory, in which
+// case nothing is allocated.
+func (ctx *Context) hashMessageBytesInto(pad scratchPad, prefix []byte,
+	msg []byte, R, root []byte, idx uint64, out []byte) Error {
+	if err := ctx.checkMessageBytes(prefix, msg); err != nil {
+		return err
+	}
+	h := ctx.startMessageHash(pad, R, root, idx)
+	h.Write(prefix)
+	h.Write(msg)
+	ctx.finishMessageHash(pad, h, out)
+	return nil
+}
+
+// Returns the hash of the scratchpad to which the message should be
+// written after the padding, R, root and index.
+func (ctx *Context) startMessageHash(pad scratchPad, R, root []byte,
+	idx uint64) io.Writer {
 	var h io.Writer
 	switch ctx.p.Func {
-	case SHA2:
-		switch ctx.p.N {
-		case 16, 24, 32:
-			h = sha256.New()
-		case 64:
-			h = sha512.New()
-		}
+	case SHA2, SHA512_256:
+		h2 := pad.hash.h
+		h2.Reset()
+		h = h2
 	case SHAKE, SHAKE256:
 		h2 := pad.hash.shake
 		h2.Reset()
 		h = h2
 	}
 
-	h.Write(encodeUint64(HASH_PADDING_HASH, int(ctx.prefixLen)))
+	buf := pad.msgBuf
+	encodeUint64Into(HASH_PADDING_HASH, buf[:ctx.prefixLen])
+	h.Write(buf[:ctx.prefixLen])
 	h.Write(R)
 	h.Write(root)
-	h.Write(encodeUint64(idx, int(ctx.p.N)))
-
-	_, err := io.Copy(h, msg)
-	if err != nil {
-		return err
-	}
+	encodeUint64Into(idx, buf[:ctx.p.N])
+	h.Write(buf[:ctx.p.N])
+	return h
+}
 
+// Puts the message hash started by startMessageHash() into out.
+func (ctx *Context) finishMessageHash(pad scratchPad, h io.Writer,
+	out []byte) {
 	switch ctx.p.Func {
-	case SHA2:
+	case SHA2, SHA512_256:
 		if ctx.p.N >= 32 {
 			(h.(hash.Hash)).Sum(out[:0])
 		} else {
-			var buf [32]byte
-			(h.(hash.Hash)).Sum(buf[:0])
-			copy(out[:], buf[:ctx.p.N])
+			buf := pad.msgBuf[:0]
+			copy(out[:], (h.(hash.Hash)).Sum(buf)[:ctx.p.N])
 		}
 	case SHAKE, SHAKE256:
 		(h.(io.Reader)).Read(out)
 	}
-
-	return nil
 }
 
 // Compute the hash f used in WOTS+
@@ -360,9 +462,11 @@ func (ctx *Context) f(in, pubSeed []byte, addr address) []byte {
 	return ret
 }
 
-// Set out[i] = f(addr[i], key, in[i]) for i=0,1,2,3.
+// Set out[i] = f(addr[i], key, in[i]) for i=0,1,2,3, or only for
+// i=0,1 when the twoway permutation is used.
 //
-// Assumes SHAKE with N either 16 or 32 and f1600x4.Available is true.
+// Assumes SHAKE with N either 16 or 32 and either f1600x4.Available or
+// f1600x2.Available is true.
 func (ctx *Context) fX4Into(pad scratchPad, in [4][]byte, key []byte,
 	addr [4]address, out [4][]byte) {
 	buf := pad.fX4Buf()
@@ -382,75 +486,216 @@ func (ctx *Context) fX4Into(pad scratchPad, in [4][]byte, key []byte,
 		buf[6*n : 7*n], buf[7*n : 8*n],
 	})
 
-	a := pad.hash.shakeX4A
-	pad.hash.shakeX4.Zero()
+	a := pad.hash.shakeXNA
+	L := pad.hash.shakeXNL
+	pad.hash.shakeXN.Zero()
 	if ctx.p.N == 16 {
-		for j := 0; j < 4; j++ {
+		for j := 0; j < L; j++ {
 			if in[j] == nil {
 				continue
 			}
 
-			a[4*2+j] = binary.LittleEndian.Uint64(buf[j*16 : j*16+8])
-			a[4*3+j] = binary.LittleEndian.Uint64(buf[j*16+8 : j*16+16])
-			a[4*4+j] = (binary.LittleEndian.Uint64(buf[j*16+64:j*16+72]) ^
+			a[L*2+j] = binary.LittleEndian.Uint64(buf[j*16 : j*16+8])
+			a[L*3+j] = binary.LittleEndian.Uint64(buf[j*16+8 : j*16+16])
+			a[L*4+j] = (binary.LittleEndian.Uint64(buf[j*16+64:j*16+72]) ^
 				binary.LittleEndian.Uint64(in[j][:8]))
-			a[4*5+j] = (binary.LittleEndian.Uint64(buf[j*16+72:j*16+80]) ^
+			a[L*5+j] = (binary.LittleEndian.Uint64(buf[j*16+72:j*16+80]) ^
 				binary.LittleEndian.Uint64(in[j][8:]))
 
 			// SHAKE128 domain separator (0b1111) and padding (0b100...001).
-			a[4*6+j] = 0x1f
-			a[4*20+j] = 0x80 << 56
+			a[L*6+j] = 0x1f
+			a[L*20+j] = 0x80 << 56
 		}
 
-		pad.hash.shakeX4.Permute()
+		pad.hash.shakeXN.Permute()
 
-		for j := 0; j < 4; j++ {
+		for j := 0; j < L; j++ {
 			if in[j] == nil {
 				continue
 			}
 			binary.LittleEndian.PutUint64(out[j][0:8], a[j])
-			binary.LittleEndian.PutUint64(out[j][8:16], a[4+j])
+			binary.LittleEndian.PutUint64(out[j][8:16], a[L+j])
 		}
 	} else if ctx.p.N == 32 {
-		for j := 0; j < 4; j++ {
+		for j := 0; j < L; j++ {
 			if in[j] == nil {
 				continue
 			}
 
-			a[4*4+j] = binary.LittleEndian.Uint64(buf[j*32 : j*32+8])
-			a[4*5+j] = binary.LittleEndian.Uint64(buf[j*32+8 : j*32+16])
-			a[4*6+j] = binary.LittleEndian.Uint64(buf[j*32+16 : j*32+24])
-			a[4*7+j] = binary.LittleEndian.Uint64(buf[j*32+24 : j*32+32])
-			a[4*8+j] = (binary.LittleEndian.Uint64(buf[j*32+128:j*32+136]) ^
+			a[L*4+j] = binary.LittleEndian.Uint64(buf[j*32 : j*32+8])
+			a[L*5+j] = binary.LittleEndian.Uint64(buf[j*32+8 : j*32+16])
+			a[L*6+j] = binary.LittleEndian.Uint64(buf[j*32+16 : j*32+24])
+			a[L*7+j] = binary.LittleEndian.Uint64(buf[j*32+24 : j*32+32])
+			a[L*8+j] = (binary.LittleEndian.Uint64(buf[j*32+128:j*32+136]) ^
 				binary.LittleEndian.Uint64(in[j][:8]))
-			a[4*9+j] = (binary.LittleEndian.Uint64(buf[j*32+136:j*32+144]) ^
+			a[L*9+j] = (binary.LittleEndian.Uint64(buf[j*32+136:j*32+144]) ^
 				binary.LittleEndian.Uint64(in[j][8:16]))
-			a[4*10+j] = (binary.LittleEndian.Uint64(buf[j*32+144:j*32+152]) ^
+			a[L*10+j] = (binary.LittleEndian.Uint64(buf[j*32+144:j*32+152]) ^
 				binary.LittleEndian.Uint64(in[j][16:24]))
-			a[4*11+j] = (binary.LittleEndian.Uint64(buf[j*32+152:j*32+160]) ^
+			a[L*11+j] = (binary.LittleEndian.Uint64(buf[j*32+152:j*32+160]) ^
 				binary.LittleEndian.Uint64(in[j][24:32]))
 
 			// SHAKE128 domain separator (0b1111) and padding (0b100...001).
-			a[4*12+j] = 0x1f
-			a[4*20+j] = 0x80 << 56
+			a[L*12+j] = 0x1f
+			a[L*20+j] = 0x80 << 56
 		}
 
-		pad.hash.shakeX4.Permute()
+		pad.hash.shakeXN.Permute()
 
-		for j := 0; j < 4; j++ {
+		for j := 0; j < L; j++ {
 			if in[j] == nil {
 				continue
 			}
 			binary.LittleEndian.PutUint64(out[j][0:8], a[j])
-			binary.LittleEndian.PutUint64(out[j][8:16], a[4+j])
-			binary.LittleEndian.PutUint64(out[j][16:24], a[8+j])
-			binary.LittleEndian.PutUint64(out[j][24:32], a[12+j])
+			binary.LittleEndian.PutUint64(out[j][8:16], a[L+j])
+			binary.LittleEndian.PutUint64(out[j][16:24], a[2*L+j])
+			binary.LittleEndian.PutUint64(out[j][24:32], a[3*L+j])
 		}
 	} else {
 		panic("not implemented")
 	}
 }
 
+// Set out[i] = f(addr[i], pubSeed, in[i]) for i=0,...,7.
+//
+// Assumes SHA2 with N at most 32 and sha256x8.Available is true.
+func (ctx *Context) fX8Into(pad scratchPad, in [8][]byte,
+	ph precomputedHashes, addr [8]address, out [8][]byte) {
+	s := pad.hash.sha2X8
+	buf := pad.hash.sha2X8B
+	n := ctx.p.N
+	pl := ctx.prefixLen
+	tl := uint32(len(ph.prfPubSeedTail))
+	var msgs, outs [8][]byte
+
+	// Each lane uses 160 bytes of buf: 64 bytes for the key and bitmask
+	// followed by 96 bytes for the message.
+	lane := func(j int) []byte { return buf[160*j : 160*(j+1)] }
+
+	// Compute the keys and bitmasks
+	for km := uint32(0); km < 2; km++ {
+		for j := 0; j < 8; j++ {
+			if in[j] == nil {
+				msgs[j] = nil
+				continue
+			}
+			msg := lane(j)[64 : 64+tl+32]
+			copy(msg, ph.prfPubSeedTail)
+			addr[j].setKeyAndMask(km)
+			addr[j].writeInto(msg[tl:])
+			msgs[j] = msg
+			outs[j] = lane(j)[32*km : 32*(km+1)]
+		}
+		s.Sum256(&ph.prfPubSeedIV, ph.prfPubSeedLen, &msgs, &outs)
+	}
+
+	// Compute hash( HASH_PADDING_F ‖ key ‖ bitmask ⊕ in )
+	for j := 0; j < 8; j++ {
+		if in[j] == nil {
+			continue
+		}
+		msg := lane(j)[64 : 64+pl+2*n]
+		encodeUint64Into(HASH_PADDING_F, msg[:pl])
+		copy(msg[pl:pl+n], lane(j)[:n])
+		xorsimd.Bytes(msg[pl+n:pl+2*n], in[j], lane(j)[32:32+n])
+		msgs[j] = msg
+		outs[j] = lane(j)[:32]
+	}
+	s.Sum256(&sha256x8.IV, 0, &msgs, &outs)
+
+	for j := 0; j < 8; j++ {
+		if in[j] == nil {
+			continue
+		}
+		copy(out[j], lane(j)[:n])
+	}
+}
+
+// Set out[i] = f(addr[i], pubSeed, in[i]) for i=0,...,ctx.lanes-1
+// using either the twoway, fourway or eightway hashes.
+func (ctx *Context) fXNInto(pad scratchPad, in [8][]byte,
+	ph precomputedHashes, addr [8]address, out [8][]byte) {
+	if ctx.x8Available {
+		ctx.fX8Into(pad, in, ph, addr, out)
+		return
+	}
+	ctx.fX4Into(pad,
+		[4][]byte{in[0], in[1], in[2], in[3]},
+		ph.pubSeed,
+		[4]address{addr[0], addr[1], addr[2], addr[3]},
+		[4][]byte{out[0], out[1], out[2], out[3]})
+}
+
+// Set out[i] = h(addr[i], key, left[i], right[i]) for i=0,1,2,3, or only
+// for i=0,1 when the twoway permutation is used.  Lanes with left[i] set
+// to nil are skipped.
+//
+// Assumes SHAKE with N either 16 or 32 and either f1600x4.Available or
+// f1600x2.Available is true.
+func (ctx *Context) hX4Into(pad scratchPad, left, right [4][]byte,
+	key []byte, addr [4]address, out [4][]byte) {
+	buf := pad.fX4Buf()
+	n := ctx.p.N
+	for km := uint32(0); km < 3; km++ {
+		var prfOut [4][]byte
+		for j := uint32(0); j < 4; j++ {
+			addr[j].setKeyAndMask(km)
+			if left[j] != nil {
+				prfOut[j] = buf[(4*km+j)*n : (4*km+j+1)*n]
+			}
+		}
+		ctx.prfAddrX4Into(pad, addr, key, prfOut)
+	}
+
+	// We're computing hash( HASH_PADDING_H ‖ key ‖ bm0 ⊕ left ‖ bm1 ⊕ right ),
+	// where each part is w words.
+	w := int(n / 8)
+	a := pad.hash.shakeXNA
+	L := pad.hash.shakeXNL
+	pad.hash.shakeXN.Zero()
+	for j := 0; j < L; j++ {
+		if left[j] == nil {
+			continue
+		}
+
+		a[L*(w-1)+j] = HASH_PADDING_H << 56
+		for i := 0; i < w; i++ {
+			key := buf[(j*w+i)*8:]
+			bm0 := buf[((4+j)*w+i)*8:]
+			bm1 := buf[((8+j)*w+i)*8:]
+			a[L*(w+i)+j] = binary.LittleEndian.Uint64(key)
+			a[L*(2*w+i)+j] = (binary.LittleEndian.Uint64(bm0) ^
+				binary.LittleEndian.Uint64(left[j][8*i:]))
+			a[L*(3*w+i)+j] = (binary.LittleEndian.Uint64(bm1) ^
+				binary.LittleEndian.Uint64(right[j][8*i:]))
+		}
+
+		// SHAKE128 domain separator (0b1111) and padding (0b100...001).
+		a[L*4*w+j] = 0x1f
+		a[L*20+j] = 0x80 << 56
+	}
+
+	pad.hash.shakeXN.Permute()
+
+	for j := 0; j < L; j++ {
+		if left[j] == nil {
+			continue
+		}
+		for i := 0; i < w; i++ {
+			binary.LittleEndian.PutUint64(out[j][8*i:], a[L*i+j])
+		}
+	}
+}
+
+// Returns the number of nodes that are hashed at once with hX4Into()
+// when hashing up trees, or zero if hInto() should be used.
+func (ctx *Context) hLanes() uint32 {
+	if ctx.x4Available || ctx.x2Available {
+		return ctx.lanes
+	}
+	return 0
+}
+
 // Compute the hash f used in WOTS+ and put it into out
 func (ctx *Context) fInto(pad scratchPad, in []byte, ph precomputedHashes,
 	addr address, out []byte) {
@@ -492,23 +737,36 @@ func (ctx *Context) hInto(pad scratchPad, left, right []byte,
 	ctx.hashInto(pad, buf[:pl+3*n], out)
 }
 
+// Returns a new instance of the SHA2 hash for ctx.p.Func and ctx.p.N.
+func (ctx *Context) newSha2() hash.Hash {
+	if ctx.p.Func == SHA512_256 {
+		return sha512.New512_256()
+	}
+	if ctx.p.N == 64 {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
 func (ctx *Context) newHashScratchPad() (pad hashScratchPad) {
 	switch ctx.p.Func {
-	case SHA2:
-		switch ctx.p.N {
-		case 16, 24, 32:
-			pad.h = sha256.New()
-		case 64:
-			pad.h = sha512.New()
-		}
+	case SHA2, SHA512_256:
+		pad.h = ctx.newSha2()
 		pad.hV = reflect.ValueOf(pad.h).Elem()
+		if ctx.x8Available {
+			pad.sha2X8 = new(sha256x8.State)
+			pad.sha2X8B = make([]byte, 8*160)
+		}
 	case SHAKE:
 		switch ctx.p.N {
 		case 16, 24, 32:
 			pad.shake = sha3.NewShake128()
-			if f1600x4.Available {
-				pad.shakeX4 = new(f1600x4.State)
-				pad.shakeX4A = pad.shakeX4.Initialize()
+			if ctx.x4Available {
+				s := new(f1600x4.State)
+				pad.shakeXN, pad.shakeXNA, pad.shakeXNL = s, s.Initialize(), 4
+			} else if ctx.x2Available {
+				s := new(f1600x2.State)
+				pad.shakeXN, pad.shakeXNA, pad.shakeXNL = s, s.Initialize(), 2
 			}
 		case 64:
 			pad.shake = sha3.NewShake256()
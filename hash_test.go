@@ -17,7 +17,7 @@ func testHashMessage(ctx *Context, expect string, t *testing.T) {
 		R[i] = byte(2 * i)
 		root[i] = byte(i)
 	}
-	hVal, err := ctx.hashMessage(ctx.newScratchPad(),
+	hVal, err := ctx.hashMessage(ctx.newScratchPad(), nil,
 		bytes.NewReader(msg), R, root, idx)
 	if err != nil {
 		t.Errorf("%s hashMessage: %v", ctx.Name(), err)
@@ -74,6 +74,218 @@ func testFX4(t *testing.T, N uint32) {
 	}
 }
 
+func TestFX2(t *testing.T) {
+	testFX2(t, 16)
+	testFX2(t, 32)
+}
+
+// Returns a context that uses the twoway Keccak-f[1600], even if it's not
+// marked as available.
+func newX2Context(N uint32) *Context {
+	ctx, _ := NewContext(Params{Func: SHAKE, N: N, WotsW: 16,
+		FullHeight: 1, D: 1})
+	ctx.x4Available = false
+	ctx.x2Available = true
+	ctx.lanes = 2
+	return ctx
+}
+
+func testFX2(t *testing.T, N uint32) {
+	ctx := newX2Context(N)
+	var addr [4]address
+	var buf1 [4][]byte
+	var in [4][]byte
+	buf2 := make([]byte, ctx.p.N)
+	var key []byte = make([]byte, ctx.p.N)
+	for j := uint32(0); j < 2; j++ {
+		buf1[j] = make([]byte, ctx.p.N)
+		in[j] = make([]byte, ctx.p.N)
+		for i := uint32(0); i < 8; i++ {
+			addr[j][i] = i + 8*j
+		}
+		for i := uint32(0); i < ctx.p.N; i++ {
+			in[j][i] = byte(j*ctx.p.N + i)
+		}
+	}
+	for i := 0; i < int(ctx.p.N); i++ {
+		key[i] = byte(i)
+	}
+	pad := ctx.newScratchPad()
+	ctx.fX4Into(pad, in, key, addr, buf1)
+	ph := ctx.precomputeHashes(key, key)
+	for j := 0; j < 2; j++ {
+		ctx.fInto(pad, in[j], ph, addr[j], buf2)
+		if !bytes.Equal(buf2, buf1[j]) {
+			t.Fatal()
+		}
+	}
+
+	// Check the vectorized WOTS+ against the plain one.
+	ctx2, _ := NewContext(ctx.p)
+	ctx2.x4Available = false
+	ctx2.lanes = 0
+	pad2 := ctx2.newScratchPad()
+	ph2 := ctx2.precomputeHashes(key, key)
+	pk := ctx.wotsPkGen(pad, ph, addr[0])
+	if !bytes.Equal(pk, ctx2.wotsPkGen(pad2, ph2, addr[0])) {
+		t.Fatal("wotsPkGen differs")
+	}
+	sig := ctx.wotsSign(pad, key, key, key, addr[0])
+	if !bytes.Equal(sig, ctx2.wotsSign(pad2, key, key, key, addr[0])) {
+		t.Fatal("wotsSign differs")
+	}
+	if !bytes.Equal(pk, ctx.wotsPkFromSig(pad, sig, key, ph, addr[0])) {
+		t.Fatal("wotsPkFromSig differs")
+	}
+}
+
+func TestFX8(t *testing.T) {
+	testFX8(t, Params{Func: SHA2, N: 16, WotsW: 16, FullHeight: 1, D: 1})
+	testFX8(t, Params{Func: SHA2, N: 24, WotsW: 16, FullHeight: 1, D: 1,
+		Prf: NIST})
+	testFX8(t, Params{Func: SHA2, N: 32, WotsW: 16, FullHeight: 1, D: 1})
+}
+
+// Returns a context that uses the eightway SHA-256, even if it's not
+// marked as available.
+func newX8Context(params Params) *Context {
+	ctx, _ := NewContext(params)
+	ctx.x8Available = true
+	ctx.lanes = 8
+	return ctx
+}
+
+func testFX8(t *testing.T, params Params) {
+	ctx := newX8Context(params)
+	var addr [8]address
+	var buf1 [8][]byte
+	var in [8][]byte
+	buf2 := make([]byte, ctx.p.N)
+	var pubSeed []byte = make([]byte, ctx.p.N)
+	for j := uint32(0); j < 8; j++ {
+		buf1[j] = make([]byte, ctx.p.N)
+		in[j] = make([]byte, ctx.p.N)
+		for i := uint32(0); i < 8; i++ {
+			addr[j][i] = i + 8*j
+		}
+		for i := uint32(0); i < ctx.p.N; i++ {
+			in[j][i] = byte(j*ctx.p.N + i)
+		}
+	}
+	for i := 0; i < int(ctx.p.N); i++ {
+		pubSeed[i] = byte(i)
+	}
+	in[3] = nil
+	pad := ctx.newScratchPad()
+	ph := ctx.precomputeHashes(pubSeed, nil)
+	ctx.fX8Into(pad, in, ph, addr, buf1)
+	for j := 0; j < 8; j++ {
+		if in[j] == nil {
+			continue
+		}
+		ctx.fInto(pad, in[j], ph, addr[j], buf2)
+		if !bytes.Equal(buf2, buf1[j]) {
+			t.Fatalf("%s: lane %d differs", params, j)
+		}
+	}
+
+	// Check the vectorized WOTS+ against the plain one.
+	ctx2, _ := NewContext(params)
+	ctx2.x4Available = false
+	ctx2.x8Available = false
+	ctx2.lanes = 0
+	pad2 := ctx2.newScratchPad()
+	ph = ctx.precomputeHashes(pubSeed, pubSeed)
+	ph2 := ctx2.precomputeHashes(pubSeed, pubSeed)
+	pk := ctx.wotsPkGen(pad, ph, addr[0])
+	if !bytes.Equal(pk, ctx2.wotsPkGen(pad2, ph2, addr[0])) {
+		t.Fatalf("%s: wotsPkGen differs", params)
+	}
+	msg := make([]byte, ctx.p.N)
+	for i := 0; i < len(msg); i++ {
+		msg[i] = byte(7 * i)
+	}
+	sig := ctx.wotsSign(pad, msg, pubSeed, pubSeed, addr[0])
+	if !bytes.Equal(sig, ctx2.wotsSign(pad2, msg, pubSeed, pubSeed, addr[0])) {
+		t.Fatalf("%s: wotsSign differs", params)
+	}
+	if !bytes.Equal(pk, ctx.wotsPkFromSig(pad, sig, msg, ph, addr[0])) {
+		t.Fatalf("%s: wotsPkFromSig differs", params)
+	}
+}
+
+func TestHX4(t *testing.T) {
+	for _, N := range []uint32{16, 32} {
+		ctx, _ := NewContext(Params{Func: SHAKE, N: N, WotsW: 16,
+			FullHeight: 4, D: 1})
+		if f1600x4.Available {
+			testHX4(t, ctx)
+		}
+		ctx.x4Available = false
+		ctx.x2Available = true
+		ctx.lanes = 2
+		testHX4(t, ctx)
+	}
+}
+
+func testHX4(t *testing.T, ctx *Context) {
+	var addr [4]address
+	var left, right, buf1 [4][]byte
+	buf2 := make([]byte, ctx.p.N)
+	var pubSeed []byte = make([]byte, ctx.p.N)
+	for j := uint32(0); j < 4; j++ {
+		buf1[j] = make([]byte, ctx.p.N)
+		left[j] = make([]byte, ctx.p.N)
+		right[j] = make([]byte, ctx.p.N)
+		for i := uint32(0); i < 8; i++ {
+			addr[j][i] = i + 8*j
+		}
+		for i := uint32(0); i < ctx.p.N; i++ {
+			left[j][i] = byte(j*ctx.p.N + i)
+			right[j][i] = byte(3*j*ctx.p.N + i)
+		}
+	}
+	for i := 0; i < int(ctx.p.N); i++ {
+		pubSeed[i] = byte(i)
+	}
+	left[1] = nil
+	pad := ctx.newScratchPad()
+	ph := ctx.precomputeHashes(pubSeed, nil)
+	ctx.hX4Into(pad, left, right, pubSeed, addr, buf1)
+	for j := 0; j < int(ctx.lanes); j++ {
+		if left[j] == nil {
+			continue
+		}
+		ctx.hInto(pad, left[j], right[j], ph, addr[j], buf2)
+		if !bytes.Equal(buf2, buf1[j]) {
+			t.Fatalf("%s: lane %d differs", ctx.Name(), j)
+		}
+	}
+
+	// Check the vectorized L-tree and subtree against the plain ones.
+	ctx2, _ := NewContext(ctx.p)
+	ctx2.x4Available = false
+	ctx2.x2Available = false
+	ctx2.lanes = 0
+	pad2 := ctx2.newScratchPad()
+	ph = ctx.precomputeHashes(pubSeed, pubSeed)
+	ph2 := ctx2.precomputeHashes(pubSeed, pubSeed)
+	pk := ctx.wotsPkGen(pad, ph, addr[0])
+	leaf1 := make([]byte, ctx.p.N)
+	leaf2 := make([]byte, ctx.p.N)
+	ctx.lTreeInto(pad, append([]byte{}, pk...), ph, addr[0], leaf1)
+	ctx2.lTreeInto(pad2, pk, ph2, addr[0], leaf2)
+	if !bytes.Equal(leaf1, leaf2) {
+		t.Fatalf("%s: lTree differs", ctx.Name())
+	}
+	sta := SubTreeAddress{Layer: 0, Tree: 0}
+	mt1 := ctx.genSubTree(pad, pubSeed, pubSeed, sta)
+	mt2 := ctx2.genSubTree(pad2, pubSeed, pubSeed, sta)
+	if !bytes.Equal(mt1.buf, mt2.buf) {
+		t.Fatalf("%s: genSubTree differs", ctx.Name())
+	}
+}
+
 func TestPrfX4(t *testing.T) {
 	if !f1600x4.Available {
 		t.Skip()
@@ -107,6 +319,38 @@ func testPrfX4(t *testing.T, N uint32) {
 	}
 }
 
+func TestPrecomputedPrfKeyGen(t *testing.T) {
+	for _, params := range []Params{
+		{SHA2, 16, 2, 1, 16, RFC},
+		{SHA2, 24, 2, 1, 16, NIST},
+		{SHA2, 32, 2, 1, 16, RFC},
+		{SHA2, 64, 2, 1, 16, RFC},
+		{SHA512_256, 32, 2, 1, 16, RFC},
+	} {
+		ctx, _ := NewContext(params)
+		var addr address
+		for i := 0; i < 8; i++ {
+			addr[i] = uint32(3 * i)
+		}
+		skSeed := make([]byte, ctx.p.N)
+		pubSeed := make([]byte, ctx.p.N)
+		for i := 0; i < int(ctx.p.N); i++ {
+			skSeed[i] = byte(i)
+			pubSeed[i] = byte(2 * i)
+		}
+		pad := ctx.newScratchPad()
+		ph := ctx.precomputeHashes(pubSeed, skSeed)
+		buf1 := make([]byte, ctx.p.N)
+		buf2 := make([]byte, ctx.p.N)
+		ctx.prfKeyGenInto(pad, ph, addr, buf1)
+		ph.prfKeyGenInto = nil
+		ctx.prfKeyGenInto(pad, ph, addr, buf2)
+		if !bytes.Equal(buf1, buf2) {
+			t.Fatalf("%s: precomputed prfKeyGen differs", params)
+		}
+	}
+}
+
 func testPrf(ctx *Context, expect string, t *testing.T) {
 	var addr address
 	var key []byte = make([]byte, ctx.p.N)
@@ -0,0 +1,125 @@
+// f1600x2 implements a twoway KeccaK-f[1600] permutation.  On arm64
+// processors with the SHA3 extensions this is fast, as the two
+// permutations are computed in parallel in the 128-bit NEON registers.
+// KeccaK-f[1600] is the permutation underlying KeccaK, SHA3 and SHAKE.
+//
+// The arm64 assembly is adapted from the single KeccaK-f[1600] in the
+// crypto/internal/fips140/sha3 package of the Go standard library.
+package f1600x2
+
+import (
+	"math/bits"
+)
+
+// Contains state for the twoway permutation including the two
+// interleaved [25]uint64 buffers.  Call Initialize() before use to get
+// the interleaved buffer.
+type State struct {
+	a [50]uint64
+}
+
+// Initialize the state and returns the buffer on which the two permutations
+// will act: a uint64 slice of length 50.  The first permutation will act
+// on {a[0], a[2], ..., a[48]} and the second on {a[1], a[3], ..., a[49]}.
+func (s *State) Initialize() []uint64 {
+	return s.a[:]
+}
+
+// Zeroes internal buffer.
+func (s *State) Zero() {
+	s.a = [50]uint64{}
+}
+
+// Perform the two parallel KeccaK-f[1600]s interleaved on the slice returned
+// from Initialize().
+func (s *State) Permute() {
+	if Available {
+		f1600x2(&s.a[0])
+		return
+	}
+	for j := 0; j < 2; j++ {
+		var a [25]uint64
+		for i := 0; i < 25; i++ {
+			a[i] = s.a[2*i+j]
+		}
+		keccakF1600Generic(&a)
+		for i := 0; i < 25; i++ {
+			s.a[2*i+j] = a[i]
+		}
+	}
+}
+
+// Round constants
+var rc = [24]uint64{
+	0x0000000000000001,
+	0x0000000000008082,
+	0x800000000000808A,
+	0x8000000080008000,
+	0x000000000000808B,
+	0x0000000080000001,
+	0x8000000080008081,
+	0x8000000000008009,
+	0x000000000000008A,
+	0x0000000000000088,
+	0x0000000080008009,
+	0x000000008000000A,
+	0x000000008000808B,
+	0x800000000000008B,
+	0x8000000000008089,
+	0x8000000000008003,
+	0x8000000000008002,
+	0x8000000000000080,
+	0x000000000000800A,
+	0x800000008000000A,
+	0x8000000080008081,
+	0x8000000000008080,
+	0x0000000080000001,
+	0x8000000080008008,
+}
+
+// Rotation offsets of rho and the lanes in the order visited by pi.
+var rotc = [24]int{
+	1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14,
+	27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44,
+}
+var piln = [24]int{
+	10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4,
+	15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1,
+}
+
+// Portable KeccaK-f[1600].
+func keccakF1600Generic(a *[25]uint64) {
+	var c [5]uint64
+	for r := 0; r < 24; r++ {
+		// θ
+		for i := 0; i < 5; i++ {
+			c[i] = a[i] ^ a[i+5] ^ a[i+10] ^ a[i+15] ^ a[i+20]
+		}
+		for i := 0; i < 5; i++ {
+			d := c[(i+4)%5] ^ bits.RotateLeft64(c[(i+1)%5], 1)
+			for j := 0; j < 25; j += 5 {
+				a[j+i] ^= d
+			}
+		}
+
+		// ρ and π
+		t := a[1]
+		for i := 0; i < 24; i++ {
+			j := piln[i]
+			t, a[j] = a[j], bits.RotateLeft64(t, rotc[i])
+		}
+
+		// χ
+		for j := 0; j < 25; j += 5 {
+			for i := 0; i < 5; i++ {
+				c[i] = a[j+i]
+			}
+			for i := 0; i < 5; i++ {
+				a[j+i] ^= ^c[(i+1)%5] & c[(i+2)%5]
+			}
+		}
+
+		// ι
+		a[0] ^= rc[r]
+	}
+}
@@ -0,0 +1,11 @@
+package f1600x2
+
+import (
+	"golang.org/x/sys/cpu"
+)
+
+// Available is true when this system supports a fast twoway KeccaK-f[1600].
+var Available = cpu.ARM64.HasSHA3
+
+//go:noescape
+func f1600x2(state *uint64)
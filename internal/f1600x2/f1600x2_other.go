@@ -0,0 +1,10 @@
+// +build !arm64
+
+package f1600x2
+
+// Available is true when this system supports a fast twoway KeccaK-f[1600].
+var Available = false
+
+func f1600x2(state *uint64) {
+	panic("Not available")
+}
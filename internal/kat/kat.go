@@ -0,0 +1,224 @@
+// kat reads, writes, generates and checks known-answer tests (KATs) of
+// XMSS[MT].
+//
+// A KAT file consists of records separated by blank lines.  Each record
+// contains lines of the form "key = value" with the following keys.
+//
+//   alg      name of the instance, eg. XMSSMT-SHA2_20/2_256
+//   seed     skSeed ‖ skPrf ‖ pubSeed in hex
+//   seqno    signature sequence number in decimal
+//   msg      message in hex
+//   pk       root ‖ pubSeed in hex
+//   sig      signature as described in RFC 8391 in hex
+//   pkhash   first 10 bytes of SHAKE128(pk) in hex
+//   sighash  first 10 bytes of SHAKE128(sig) in hex
+//
+// Either pk or pkhash and either sig or sighash have to be present.  The
+// hashes are the values printed by test/vectors of the reference
+// implementation.  Lines starting with # are ignored.
+package kat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bwesterb/go-xmssmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// A single known-answer test.
+type Vector struct {
+	Alg   string // name of the instance
+	Seed  []byte // skSeed ‖ skPrf ‖ pubSeed
+	SeqNo uint64 // signature sequence number
+	Msg   []byte
+
+	// Expected public key and signature.  Might be nil if only their
+	// hashes are known.
+	Pk  []byte
+	Sig []byte
+
+	// Expected hashes of the public key and signature.  See Hash().
+	PkHash  []byte
+	SigHash []byte
+}
+
+// Returns the first 10 bytes of SHAKE128(buf), which is the hash used by
+// test/vectors of the reference implementation.
+func Hash(buf []byte) []byte {
+	ret := make([]byte, 10)
+	h := sha3.NewShake128()
+	h.Write(buf)
+	h.Read(ret)
+	return ret
+}
+
+// Computes the known-answer test for the given instance and inputs.
+func Generate(alg string, seed []byte, seqNo uint64, msg []byte) (
+	*Vector, error) {
+	ctx, err := xmssmt.NewContextFromName2(alg)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+	pk, sig, err := ctx.KnownAnswer(seed, xmssmt.SignatureSeqNo(seqNo), msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Vector{
+		Alg:     alg,
+		Seed:    seed,
+		SeqNo:   seqNo,
+		Msg:     msg,
+		Pk:      pk,
+		Sig:     sig,
+		PkHash:  Hash(pk),
+		SigHash: Hash(sig),
+	}, nil
+}
+
+// Returns a Vector with the inputs used by test/vectors of the reference
+// implementation and no expected outputs: the seed 0, 1, ..., 3N-1, the
+// message 37 and the sequence number 2^(FullHeight-1).
+func ReferenceInputs(alg string) (*Vector, error) {
+	params, err := xmssmt.ParamsFromName2(alg)
+	if err != nil {
+		return nil, err
+	}
+	seed := make([]byte, 3*params.N)
+	for i := 0; i < len(seed); i++ {
+		seed[i] = byte(i)
+	}
+	return &Vector{
+		Alg:   alg,
+		Seed:  seed,
+		SeqNo: 1 << (params.FullHeight - 1),
+		Msg:   []byte{37},
+	}, nil
+}
+
+// Computes the known-answer test with the inputs of ReferenceInputs().
+func Reference(alg string) (*Vector, error) {
+	v, err := ReferenceInputs(alg)
+	if err != nil {
+		return nil, err
+	}
+	return Generate(alg, v.Seed, v.SeqNo, v.Msg)
+}
+
+// Recomputes the known-answer test and returns an error if the public key
+// or signature differs from the expected value.
+func (v *Vector) Check() error {
+	if v.Pk == nil && v.PkHash == nil {
+		return fmt.Errorf("%s: missing pk or pkhash", v.Alg)
+	}
+	if v.Sig == nil && v.SigHash == nil {
+		return fmt.Errorf("%s: missing sig or sighash", v.Alg)
+	}
+	got, err := Generate(v.Alg, v.Seed, v.SeqNo, v.Msg)
+	if err != nil {
+		return fmt.Errorf("%s: %v", v.Alg, err)
+	}
+	if v.Pk != nil && !bytes.Equal(v.Pk, got.Pk) ||
+		v.PkHash != nil && !bytes.Equal(v.PkHash, got.PkHash) {
+		return fmt.Errorf("%s: public key differs", v.Alg)
+	}
+	if v.Sig != nil && !bytes.Equal(v.Sig, got.Sig) ||
+		v.SigHash != nil && !bytes.Equal(v.SigHash, got.SigHash) {
+		return fmt.Errorf("%s: signature differs", v.Alg)
+	}
+	return nil
+}
+
+// Writes the known-answer tests in the format described above.  If full
+// is false, only the hashes of the public keys and signatures are written.
+func Write(w io.Writer, vs []*Vector, full bool) error {
+	bw := bufio.NewWriter(w)
+	for i, v := range vs {
+		if i != 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "alg = %s\n", v.Alg)
+		fmt.Fprintf(bw, "seed = %x\n", v.Seed)
+		fmt.Fprintf(bw, "seqno = %d\n", v.SeqNo)
+		fmt.Fprintf(bw, "msg = %x\n", v.Msg)
+		if full && v.Pk != nil {
+			fmt.Fprintf(bw, "pk = %x\n", v.Pk)
+		}
+		if v.PkHash != nil {
+			fmt.Fprintf(bw, "pkhash = %x\n", v.PkHash)
+		}
+		if full && v.Sig != nil {
+			fmt.Fprintf(bw, "sig = %x\n", v.Sig)
+		}
+		if v.SigHash != nil {
+			fmt.Fprintf(bw, "sighash = %x\n", v.SigHash)
+		}
+	}
+	return bw.Flush()
+}
+
+// Reads known-answer tests in the format described above.
+func Read(r io.Reader) ([]*Vector, error) {
+	var ret []*Vector
+	var cur *Vector
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24) // signatures can be long
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "" {
+			cur = nil
+			continue
+		}
+		bits := strings.SplitN(line, "=", 2)
+		if len(bits) != 2 {
+			return nil, fmt.Errorf("line %d: missing =", lineNo)
+		}
+		key := strings.TrimSpace(bits[0])
+		val := strings.TrimSpace(bits[1])
+		if cur == nil {
+			cur = new(Vector)
+			ret = append(ret, cur)
+		}
+
+		var err error
+		switch key {
+		case "alg":
+			cur.Alg = val
+		case "seqno":
+			cur.SeqNo, err = strconv.ParseUint(val, 10, 64)
+		case "seed":
+			cur.Seed, err = hex.DecodeString(val)
+		case "msg":
+			cur.Msg, err = hex.DecodeString(val)
+		case "pk":
+			cur.Pk, err = hex.DecodeString(val)
+		case "sig":
+			cur.Sig, err = hex.DecodeString(val)
+		case "pkhash":
+			cur.PkHash, err = hex.DecodeString(val)
+		case "sighash":
+			cur.SigHash, err = hex.DecodeString(val)
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %s", lineNo, key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,82 @@
+package kat
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Generated by test/vectors of XMSS reference implementation.
+const referenceKats = `# From the reference implementation
+alg = XMSS-SHA2_10_256
+seed = 000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f
+seqno = 512
+msg = 25
+pkhash = 7de72d192121f414d4bb
+sighash = 8b6cb278d50a3694ca38
+
+alg = XMSSMT-SHA2_20/4_256
+seed = 000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f
+seqno = 524288
+msg = 25
+pkhash = 9df4c75282451bf2bc53
+sighash = fd4ff4c18801147b2804
+`
+
+func TestReadAndCheck(t *testing.T) {
+	vs, err := Read(strings.NewReader(referenceKats))
+	if err != nil {
+		t.Fatalf("Read(): %v", err)
+	}
+	if len(vs) != 2 {
+		t.Fatalf("Read() returned %d vectors", len(vs))
+	}
+	for _, v := range vs {
+		if err := v.Check(); err != nil {
+			t.Fatalf("Check(): %v", err)
+		}
+	}
+	vs[1].SigHash[0] ^= 1
+	if vs[1].Check() == nil {
+		t.Fatalf("Check() accepted wrong signature hash")
+	}
+}
+
+func TestReference(t *testing.T) {
+	v, err := Reference("XMSSMT-SHA2_20/4_256")
+	if err != nil {
+		t.Fatalf("Reference(): %v", err)
+	}
+	if hex.EncodeToString(v.PkHash) != "9df4c75282451bf2bc53" ||
+		hex.EncodeToString(v.SigHash) != "fd4ff4c18801147b2804" {
+		t.Fatalf("Reference() does not match reference implementation")
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	v, err := Generate("XMSSMT-SHAKE_20/4_256", make([]byte, 96), 1234,
+		[]byte("test message"))
+	if err != nil {
+		t.Fatalf("Generate(): %v", err)
+	}
+	for _, full := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := Write(&buf, []*Vector{v, v}, full); err != nil {
+			t.Fatalf("Write(): %v", err)
+		}
+		vs, err := Read(&buf)
+		if err != nil {
+			t.Fatalf("Read(): %v", err)
+		}
+		if len(vs) != 2 {
+			t.Fatalf("Read() returned %d vectors", len(vs))
+		}
+		if full != (vs[0].Sig != nil) {
+			t.Fatalf("Write() did not respect full=%v", full)
+		}
+		if err := vs[1].Check(); err != nil {
+			t.Fatalf("Check(): %v", err)
+		}
+	}
+}
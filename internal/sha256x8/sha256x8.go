@@ -0,0 +1,174 @@
+// sha256x8 implements an eightway SHA-256 on platforms that support it.
+//
+// The eight computations are interleaved such that a single vector
+// instruction can act on the same word of all eight states at once.
+package sha256x8
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Initial value of SHA-256.
+var IV = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// Contains the state of eight interleaved SHA-256 computations and buffers
+// for the messages.
+type State struct {
+	// h[8*i+j] is the i-th word of the state of the j-th computation.
+	h [64]uint32
+
+	// w[8*i+j] is the i-th word of the current block of the j-th message.
+	w [128]uint32
+
+	// Buffer used to pad the messages.
+	buf [8][256]byte
+}
+
+// Computes the SHA-256 hashes of eight messages of the same length
+// and writes them to the 32-byte slices out[j].  The messages are
+// assumed to be preceded by a prefix of prefixLen bytes, which must
+// be a multiple of 64, that has already been processed into iv.
+// Use IV and a prefixLen of zero for ordinary hashes.
+//
+// A message of at most 247 bytes is supported.  Lanes j with a nil
+// msgs[j] are skipped.
+func (s *State) Sum256(iv *[8]uint32, prefixLen uint64,
+	msgs *[8][]byte, out *[8][]byte) {
+	var msgLen int
+	for j := 0; j < 8; j++ {
+		if msgs[j] != nil {
+			msgLen = len(msgs[j])
+			break
+		}
+	}
+
+	// Pad the messages.
+	blocks := (msgLen + 9 + 63) / 64
+	for j := 0; j < 8; j++ {
+		buf := s.buf[j][:blocks*64]
+		if msgs[j] == nil {
+			continue
+		}
+		copy(buf, msgs[j])
+		buf[msgLen] = 0x80
+		for i := msgLen + 1; i < len(buf)-8; i++ {
+			buf[i] = 0
+		}
+		binary.BigEndian.PutUint64(buf[len(buf)-8:],
+			(prefixLen+uint64(msgLen))*8)
+	}
+
+	for i := 0; i < 8; i++ {
+		for j := 0; j < 8; j++ {
+			s.h[8*i+j] = iv[i]
+		}
+	}
+
+	for b := 0; b < blocks; b++ {
+		for j := 0; j < 8; j++ {
+			if msgs[j] == nil {
+				continue
+			}
+			block := s.buf[j][64*b : 64*(b+1)]
+			for i := 0; i < 16; i++ {
+				s.w[8*i+j] = binary.BigEndian.Uint32(block[4*i:])
+			}
+		}
+		s.Block()
+	}
+
+	for j := 0; j < 8; j++ {
+		if msgs[j] == nil {
+			continue
+		}
+		for i := 0; i < 8; i++ {
+			binary.BigEndian.PutUint32(out[j][4*i:], s.h[8*i+j])
+		}
+	}
+}
+
+// Returns the buffers on which Block() acts: the interleaved states and
+// the interleaved message blocks.
+func (s *State) Buffers() (h *[64]uint32, w *[128]uint32) {
+	return &s.h, &s.w
+}
+
+// Applies the SHA-256 compression function to each of the eight
+// states with the corresponding message block.
+func (s *State) Block() {
+	if hasAVX2 {
+		block8(&s.h, &s.w)
+		return
+	}
+	blockGeneric(&s.h, &s.w)
+}
+
+var k = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5,
+	0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3,
+	0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc,
+	0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7,
+	0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13,
+	0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3,
+	0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5,
+	0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208,
+	0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+// Portable implementation of block8.
+func blockGeneric(h *[64]uint32, msg *[128]uint32) {
+	var w [64]uint32
+	for j := 0; j < 8; j++ {
+		for i := 0; i < 16; i++ {
+			w[i] = msg[8*i+j]
+		}
+		for i := 16; i < 64; i++ {
+			v1 := w[i-2]
+			t1 := bits.RotateLeft32(v1, -17) ^
+				bits.RotateLeft32(v1, -19) ^ (v1 >> 10)
+			v2 := w[i-15]
+			t2 := bits.RotateLeft32(v2, -7) ^
+				bits.RotateLeft32(v2, -18) ^ (v2 >> 3)
+			w[i] = t1 + w[i-7] + t2 + w[i-16]
+		}
+
+		a, b, c, d := h[j], h[8+j], h[16+j], h[24+j]
+		e, f, g, hh := h[32+j], h[40+j], h[48+j], h[56+j]
+
+		for i := 0; i < 64; i++ {
+			t1 := hh + (bits.RotateLeft32(e, -6) ^
+				bits.RotateLeft32(e, -11) ^ bits.RotateLeft32(e, -25)) +
+				((e & f) ^ (^e & g)) + k[i] + w[i]
+			t2 := (bits.RotateLeft32(a, -2) ^ bits.RotateLeft32(a, -13) ^
+				bits.RotateLeft32(a, -22)) + ((a & b) ^ (a & c) ^ (b & c))
+			hh = g
+			g = f
+			f = e
+			e = d + t1
+			d = c
+			c = b
+			b = a
+			a = t1 + t2
+		}
+
+		h[j] += a
+		h[8+j] += b
+		h[16+j] += c
+		h[24+j] += d
+		h[32+j] += e
+		h[40+j] += f
+		h[48+j] += g
+		h[56+j] += hh
+	}
+}
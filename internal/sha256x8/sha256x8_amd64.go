@@ -0,0 +1,32 @@
+//go:generate go run sha256x8_amd64_src.go -out sha256x8_amd64.s
+
+package sha256x8
+
+import (
+	"golang.org/x/sys/cpu"
+)
+
+// Available is true when this system supports a fast eightway SHA-256.
+//
+// crypto/sha256 uses the SHA extensions if the processor has them, which
+// outperforms our AVX2 implementation.  Thus we only report the latter
+// as available on processors without the SHA extensions.
+var Available = hasAVX2 && !hasSHAExtensions()
+
+// Whether block8 can be used.
+var hasAVX2 = cpu.X86.HasAVX2
+
+// Returns whether the processor supports the SHA extensions.
+func hasSHAExtensions() bool {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 7 {
+		return false
+	}
+	_, ebx, _, _ := cpuid(7, 0)
+	return ebx&(1<<29) != 0
+}
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+//go:noescape
+func block8(state *[64]uint32, msg *[128]uint32)
@@ -0,0 +1,3655 @@
+// Code generated by command: go run sha256x8_amd64_src.go -out sha256x8_amd64.s. DO NOT EDIT.
+
+// +build amd64
+
+#include "textflag.h"
+
+DATA k<>+0(SB)/4, $0x428a2f98
+DATA k<>+4(SB)/4, $0x71374491
+DATA k<>+8(SB)/4, $0xb5c0fbcf
+DATA k<>+12(SB)/4, $0xe9b5dba5
+DATA k<>+16(SB)/4, $0x3956c25b
+DATA k<>+20(SB)/4, $0x59f111f1
+DATA k<>+24(SB)/4, $0x923f82a4
+DATA k<>+28(SB)/4, $0xab1c5ed5
+DATA k<>+32(SB)/4, $0xd807aa98
+DATA k<>+36(SB)/4, $0x12835b01
+DATA k<>+40(SB)/4, $0x243185be
+DATA k<>+44(SB)/4, $0x550c7dc3
+DATA k<>+48(SB)/4, $0x72be5d74
+DATA k<>+52(SB)/4, $0x80deb1fe
+DATA k<>+56(SB)/4, $0x9bdc06a7
+DATA k<>+60(SB)/4, $0xc19bf174
+DATA k<>+64(SB)/4, $0xe49b69c1
+DATA k<>+68(SB)/4, $0xefbe4786
+DATA k<>+72(SB)/4, $0x0fc19dc6
+DATA k<>+76(SB)/4, $0x240ca1cc
+DATA k<>+80(SB)/4, $0x2de92c6f
+DATA k<>+84(SB)/4, $0x4a7484aa
+DATA k<>+88(SB)/4, $0x5cb0a9dc
+DATA k<>+92(SB)/4, $0x76f988da
+DATA k<>+96(SB)/4, $0x983e5152
+DATA k<>+100(SB)/4, $0xa831c66d
+DATA k<>+104(SB)/4, $0xb00327c8
+DATA k<>+108(SB)/4, $0xbf597fc7
+DATA k<>+112(SB)/4, $0xc6e00bf3
+DATA k<>+116(SB)/4, $0xd5a79147
+DATA k<>+120(SB)/4, $0x06ca6351
+DATA k<>+124(SB)/4, $0x14292967
+DATA k<>+128(SB)/4, $0x27b70a85
+DATA k<>+132(SB)/4, $0x2e1b2138
+DATA k<>+136(SB)/4, $0x4d2c6dfc
+DATA k<>+140(SB)/4, $0x53380d13
+DATA k<>+144(SB)/4, $0x650a7354
+DATA k<>+148(SB)/4, $0x766a0abb
+DATA k<>+152(SB)/4, $0x81c2c92e
+DATA k<>+156(SB)/4, $0x92722c85
+DATA k<>+160(SB)/4, $0xa2bfe8a1
+DATA k<>+164(SB)/4, $0xa81a664b
+DATA k<>+168(SB)/4, $0xc24b8b70
+DATA k<>+172(SB)/4, $0xc76c51a3
+DATA k<>+176(SB)/4, $0xd192e819
+DATA k<>+180(SB)/4, $0xd6990624
+DATA k<>+184(SB)/4, $0xf40e3585
+DATA k<>+188(SB)/4, $0x106aa070
+DATA k<>+192(SB)/4, $0x19a4c116
+DATA k<>+196(SB)/4, $0x1e376c08
+DATA k<>+200(SB)/4, $0x2748774c
+DATA k<>+204(SB)/4, $0x34b0bcb5
+DATA k<>+208(SB)/4, $0x391c0cb3
+DATA k<>+212(SB)/4, $0x4ed8aa4a
+DATA k<>+216(SB)/4, $0x5b9cca4f
+DATA k<>+220(SB)/4, $0x682e6ff3
+DATA k<>+224(SB)/4, $0x748f82ee
+DATA k<>+228(SB)/4, $0x78a5636f
+DATA k<>+232(SB)/4, $0x84c87814
+DATA k<>+236(SB)/4, $0x8cc70208
+DATA k<>+240(SB)/4, $0x90befffa
+DATA k<>+244(SB)/4, $0xa4506ceb
+DATA k<>+248(SB)/4, $0xbef9a3f7
+DATA k<>+252(SB)/4, $0xc67178f2
+GLOBL k<>(SB), RODATA|NOPTR, $256
+
+// func block8(state *[64]uint32, msg *[128]uint32)
+// Requires: AVX, AVX2
+TEXT ·block8(SB), NOSPLIT, $512-16
+	MOVQ         state+0(FP), AX
+	MOVQ         msg+8(FP), BX
+	VMOVDQU      0(AX), Y0
+	VMOVDQU      32(AX), Y1
+	VMOVDQU      64(AX), Y2
+	VMOVDQU      96(AX), Y3
+	VMOVDQU      128(AX), Y4
+	VMOVDQU      160(AX), Y5
+	VMOVDQU      192(AX), Y6
+	VMOVDQU      224(AX), Y7
+	VMOVDQU      0(BX), Y8
+	VMOVDQU      Y8, 0(SP)
+	VMOVDQU      32(BX), Y8
+	VMOVDQU      Y8, 32(SP)
+	VMOVDQU      64(BX), Y8
+	VMOVDQU      Y8, 64(SP)
+	VMOVDQU      96(BX), Y8
+	VMOVDQU      Y8, 96(SP)
+	VMOVDQU      128(BX), Y8
+	VMOVDQU      Y8, 128(SP)
+	VMOVDQU      160(BX), Y8
+	VMOVDQU      Y8, 160(SP)
+	VMOVDQU      192(BX), Y8
+	VMOVDQU      Y8, 192(SP)
+	VMOVDQU      224(BX), Y8
+	VMOVDQU      Y8, 224(SP)
+	VMOVDQU      256(BX), Y8
+	VMOVDQU      Y8, 256(SP)
+	VMOVDQU      288(BX), Y8
+	VMOVDQU      Y8, 288(SP)
+	VMOVDQU      320(BX), Y8
+	VMOVDQU      Y8, 320(SP)
+	VMOVDQU      352(BX), Y8
+	VMOVDQU      Y8, 352(SP)
+	VMOVDQU      384(BX), Y8
+	VMOVDQU      Y8, 384(SP)
+	VMOVDQU      416(BX), Y8
+	VMOVDQU      Y8, 416(SP)
+	VMOVDQU      448(BX), Y8
+	VMOVDQU      Y8, 448(SP)
+	VMOVDQU      480(BX), Y8
+	VMOVDQU      Y8, 480(SP)
+	VPSRLD       $6, Y4, Y8
+	VPSLLD       $26, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPXOR        Y5, Y6, Y9
+	VPAND        Y4, Y9, Y9
+	VPXOR        Y6, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPBROADCASTD k<>+0(SB), Y9
+	VPADDD       Y9, Y7, Y7
+	VPADDD       0(SP), Y7, Y7
+	VPADDD       Y7, Y3, Y3
+	VPSRLD       $2, Y0, Y8
+	VPSLLD       $30, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPOR         Y0, Y1, Y9
+	VPAND        Y2, Y9, Y9
+	VPAND        Y0, Y1, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPSRLD       $6, Y3, Y8
+	VPSLLD       $26, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPXOR        Y4, Y5, Y9
+	VPAND        Y3, Y9, Y9
+	VPXOR        Y5, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPBROADCASTD k<>+4(SB), Y9
+	VPADDD       Y9, Y6, Y6
+	VPADDD       32(SP), Y6, Y6
+	VPADDD       Y6, Y2, Y2
+	VPSRLD       $2, Y7, Y8
+	VPSLLD       $30, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPOR         Y7, Y0, Y9
+	VPAND        Y1, Y9, Y9
+	VPAND        Y7, Y0, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPSRLD       $6, Y2, Y8
+	VPSLLD       $26, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPXOR        Y3, Y4, Y9
+	VPAND        Y2, Y9, Y9
+	VPXOR        Y4, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPBROADCASTD k<>+8(SB), Y9
+	VPADDD       Y9, Y5, Y5
+	VPADDD       64(SP), Y5, Y5
+	VPADDD       Y5, Y1, Y1
+	VPSRLD       $2, Y6, Y8
+	VPSLLD       $30, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPOR         Y6, Y7, Y9
+	VPAND        Y0, Y9, Y9
+	VPAND        Y6, Y7, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPSRLD       $6, Y1, Y8
+	VPSLLD       $26, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPXOR        Y2, Y3, Y9
+	VPAND        Y1, Y9, Y9
+	VPXOR        Y3, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPBROADCASTD k<>+12(SB), Y9
+	VPADDD       Y9, Y4, Y4
+	VPADDD       96(SP), Y4, Y4
+	VPADDD       Y4, Y0, Y0
+	VPSRLD       $2, Y5, Y8
+	VPSLLD       $30, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPOR         Y5, Y6, Y9
+	VPAND        Y7, Y9, Y9
+	VPAND        Y5, Y6, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPSRLD       $6, Y0, Y8
+	VPSLLD       $26, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPXOR        Y1, Y2, Y9
+	VPAND        Y0, Y9, Y9
+	VPXOR        Y2, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPBROADCASTD k<>+16(SB), Y9
+	VPADDD       Y9, Y3, Y3
+	VPADDD       128(SP), Y3, Y3
+	VPADDD       Y3, Y7, Y7
+	VPSRLD       $2, Y4, Y8
+	VPSLLD       $30, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPOR         Y4, Y5, Y9
+	VPAND        Y6, Y9, Y9
+	VPAND        Y4, Y5, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPSRLD       $6, Y7, Y8
+	VPSLLD       $26, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPXOR        Y0, Y1, Y9
+	VPAND        Y7, Y9, Y9
+	VPXOR        Y1, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPBROADCASTD k<>+20(SB), Y9
+	VPADDD       Y9, Y2, Y2
+	VPADDD       160(SP), Y2, Y2
+	VPADDD       Y2, Y6, Y6
+	VPSRLD       $2, Y3, Y8
+	VPSLLD       $30, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPOR         Y3, Y4, Y9
+	VPAND        Y5, Y9, Y9
+	VPAND        Y3, Y4, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPSRLD       $6, Y6, Y8
+	VPSLLD       $26, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPXOR        Y7, Y0, Y9
+	VPAND        Y6, Y9, Y9
+	VPXOR        Y0, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPBROADCASTD k<>+24(SB), Y9
+	VPADDD       Y9, Y1, Y1
+	VPADDD       192(SP), Y1, Y1
+	VPADDD       Y1, Y5, Y5
+	VPSRLD       $2, Y2, Y8
+	VPSLLD       $30, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPOR         Y2, Y3, Y9
+	VPAND        Y4, Y9, Y9
+	VPAND        Y2, Y3, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPSRLD       $6, Y5, Y8
+	VPSLLD       $26, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPXOR        Y6, Y7, Y9
+	VPAND        Y5, Y9, Y9
+	VPXOR        Y7, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPBROADCASTD k<>+28(SB), Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       224(SP), Y0, Y0
+	VPADDD       Y0, Y4, Y4
+	VPSRLD       $2, Y1, Y8
+	VPSLLD       $30, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPOR         Y1, Y2, Y9
+	VPAND        Y3, Y9, Y9
+	VPAND        Y1, Y2, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPSRLD       $6, Y4, Y8
+	VPSLLD       $26, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPXOR        Y5, Y6, Y9
+	VPAND        Y4, Y9, Y9
+	VPXOR        Y6, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPBROADCASTD k<>+32(SB), Y9
+	VPADDD       Y9, Y7, Y7
+	VPADDD       256(SP), Y7, Y7
+	VPADDD       Y7, Y3, Y3
+	VPSRLD       $2, Y0, Y8
+	VPSLLD       $30, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPOR         Y0, Y1, Y9
+	VPAND        Y2, Y9, Y9
+	VPAND        Y0, Y1, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPSRLD       $6, Y3, Y8
+	VPSLLD       $26, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPXOR        Y4, Y5, Y9
+	VPAND        Y3, Y9, Y9
+	VPXOR        Y5, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPBROADCASTD k<>+36(SB), Y9
+	VPADDD       Y9, Y6, Y6
+	VPADDD       288(SP), Y6, Y6
+	VPADDD       Y6, Y2, Y2
+	VPSRLD       $2, Y7, Y8
+	VPSLLD       $30, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPOR         Y7, Y0, Y9
+	VPAND        Y1, Y9, Y9
+	VPAND        Y7, Y0, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPSRLD       $6, Y2, Y8
+	VPSLLD       $26, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPXOR        Y3, Y4, Y9
+	VPAND        Y2, Y9, Y9
+	VPXOR        Y4, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPBROADCASTD k<>+40(SB), Y9
+	VPADDD       Y9, Y5, Y5
+	VPADDD       320(SP), Y5, Y5
+	VPADDD       Y5, Y1, Y1
+	VPSRLD       $2, Y6, Y8
+	VPSLLD       $30, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPOR         Y6, Y7, Y9
+	VPAND        Y0, Y9, Y9
+	VPAND        Y6, Y7, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPSRLD       $6, Y1, Y8
+	VPSLLD       $26, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPXOR        Y2, Y3, Y9
+	VPAND        Y1, Y9, Y9
+	VPXOR        Y3, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPBROADCASTD k<>+44(SB), Y9
+	VPADDD       Y9, Y4, Y4
+	VPADDD       352(SP), Y4, Y4
+	VPADDD       Y4, Y0, Y0
+	VPSRLD       $2, Y5, Y8
+	VPSLLD       $30, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPOR         Y5, Y6, Y9
+	VPAND        Y7, Y9, Y9
+	VPAND        Y5, Y6, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPSRLD       $6, Y0, Y8
+	VPSLLD       $26, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPXOR        Y1, Y2, Y9
+	VPAND        Y0, Y9, Y9
+	VPXOR        Y2, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPBROADCASTD k<>+48(SB), Y9
+	VPADDD       Y9, Y3, Y3
+	VPADDD       384(SP), Y3, Y3
+	VPADDD       Y3, Y7, Y7
+	VPSRLD       $2, Y4, Y8
+	VPSLLD       $30, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPOR         Y4, Y5, Y9
+	VPAND        Y6, Y9, Y9
+	VPAND        Y4, Y5, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPSRLD       $6, Y7, Y8
+	VPSLLD       $26, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPXOR        Y0, Y1, Y9
+	VPAND        Y7, Y9, Y9
+	VPXOR        Y1, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPBROADCASTD k<>+52(SB), Y9
+	VPADDD       Y9, Y2, Y2
+	VPADDD       416(SP), Y2, Y2
+	VPADDD       Y2, Y6, Y6
+	VPSRLD       $2, Y3, Y8
+	VPSLLD       $30, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPOR         Y3, Y4, Y9
+	VPAND        Y5, Y9, Y9
+	VPAND        Y3, Y4, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPSRLD       $6, Y6, Y8
+	VPSLLD       $26, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPXOR        Y7, Y0, Y9
+	VPAND        Y6, Y9, Y9
+	VPXOR        Y0, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPBROADCASTD k<>+56(SB), Y9
+	VPADDD       Y9, Y1, Y1
+	VPADDD       448(SP), Y1, Y1
+	VPADDD       Y1, Y5, Y5
+	VPSRLD       $2, Y2, Y8
+	VPSLLD       $30, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPOR         Y2, Y3, Y9
+	VPAND        Y4, Y9, Y9
+	VPAND        Y2, Y3, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPSRLD       $6, Y5, Y8
+	VPSLLD       $26, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPXOR        Y6, Y7, Y9
+	VPAND        Y5, Y9, Y9
+	VPXOR        Y7, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPBROADCASTD k<>+60(SB), Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       480(SP), Y0, Y0
+	VPADDD       Y0, Y4, Y4
+	VPSRLD       $2, Y1, Y8
+	VPSLLD       $30, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPOR         Y1, Y2, Y9
+	VPAND        Y3, Y9, Y9
+	VPAND        Y1, Y2, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VMOVDQU      448(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      32(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       288(SP), Y11, Y11
+	VPADDD       0(SP), Y11, Y11
+	VMOVDQU      Y11, 0(SP)
+	VPSRLD       $6, Y4, Y8
+	VPSLLD       $26, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPXOR        Y5, Y6, Y9
+	VPAND        Y4, Y9, Y9
+	VPXOR        Y6, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPBROADCASTD k<>+64(SB), Y9
+	VPADDD       Y9, Y7, Y7
+	VPADDD       0(SP), Y7, Y7
+	VPADDD       Y7, Y3, Y3
+	VPSRLD       $2, Y0, Y8
+	VPSLLD       $30, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPOR         Y0, Y1, Y9
+	VPAND        Y2, Y9, Y9
+	VPAND        Y0, Y1, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VMOVDQU      480(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      64(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       320(SP), Y11, Y11
+	VPADDD       32(SP), Y11, Y11
+	VMOVDQU      Y11, 32(SP)
+	VPSRLD       $6, Y3, Y8
+	VPSLLD       $26, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPXOR        Y4, Y5, Y9
+	VPAND        Y3, Y9, Y9
+	VPXOR        Y5, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPBROADCASTD k<>+68(SB), Y9
+	VPADDD       Y9, Y6, Y6
+	VPADDD       32(SP), Y6, Y6
+	VPADDD       Y6, Y2, Y2
+	VPSRLD       $2, Y7, Y8
+	VPSLLD       $30, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPOR         Y7, Y0, Y9
+	VPAND        Y1, Y9, Y9
+	VPAND        Y7, Y0, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VMOVDQU      0(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      96(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       352(SP), Y11, Y11
+	VPADDD       64(SP), Y11, Y11
+	VMOVDQU      Y11, 64(SP)
+	VPSRLD       $6, Y2, Y8
+	VPSLLD       $26, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPXOR        Y3, Y4, Y9
+	VPAND        Y2, Y9, Y9
+	VPXOR        Y4, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPBROADCASTD k<>+72(SB), Y9
+	VPADDD       Y9, Y5, Y5
+	VPADDD       64(SP), Y5, Y5
+	VPADDD       Y5, Y1, Y1
+	VPSRLD       $2, Y6, Y8
+	VPSLLD       $30, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPOR         Y6, Y7, Y9
+	VPAND        Y0, Y9, Y9
+	VPAND        Y6, Y7, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VMOVDQU      32(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      128(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       384(SP), Y11, Y11
+	VPADDD       96(SP), Y11, Y11
+	VMOVDQU      Y11, 96(SP)
+	VPSRLD       $6, Y1, Y8
+	VPSLLD       $26, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPXOR        Y2, Y3, Y9
+	VPAND        Y1, Y9, Y9
+	VPXOR        Y3, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPBROADCASTD k<>+76(SB), Y9
+	VPADDD       Y9, Y4, Y4
+	VPADDD       96(SP), Y4, Y4
+	VPADDD       Y4, Y0, Y0
+	VPSRLD       $2, Y5, Y8
+	VPSLLD       $30, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPOR         Y5, Y6, Y9
+	VPAND        Y7, Y9, Y9
+	VPAND        Y5, Y6, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VMOVDQU      64(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      160(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       416(SP), Y11, Y11
+	VPADDD       128(SP), Y11, Y11
+	VMOVDQU      Y11, 128(SP)
+	VPSRLD       $6, Y0, Y8
+	VPSLLD       $26, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPXOR        Y1, Y2, Y9
+	VPAND        Y0, Y9, Y9
+	VPXOR        Y2, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPBROADCASTD k<>+80(SB), Y9
+	VPADDD       Y9, Y3, Y3
+	VPADDD       128(SP), Y3, Y3
+	VPADDD       Y3, Y7, Y7
+	VPSRLD       $2, Y4, Y8
+	VPSLLD       $30, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPOR         Y4, Y5, Y9
+	VPAND        Y6, Y9, Y9
+	VPAND        Y4, Y5, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VMOVDQU      96(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      192(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       448(SP), Y11, Y11
+	VPADDD       160(SP), Y11, Y11
+	VMOVDQU      Y11, 160(SP)
+	VPSRLD       $6, Y7, Y8
+	VPSLLD       $26, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPXOR        Y0, Y1, Y9
+	VPAND        Y7, Y9, Y9
+	VPXOR        Y1, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPBROADCASTD k<>+84(SB), Y9
+	VPADDD       Y9, Y2, Y2
+	VPADDD       160(SP), Y2, Y2
+	VPADDD       Y2, Y6, Y6
+	VPSRLD       $2, Y3, Y8
+	VPSLLD       $30, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPOR         Y3, Y4, Y9
+	VPAND        Y5, Y9, Y9
+	VPAND        Y3, Y4, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VMOVDQU      128(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      224(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       480(SP), Y11, Y11
+	VPADDD       192(SP), Y11, Y11
+	VMOVDQU      Y11, 192(SP)
+	VPSRLD       $6, Y6, Y8
+	VPSLLD       $26, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPXOR        Y7, Y0, Y9
+	VPAND        Y6, Y9, Y9
+	VPXOR        Y0, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPBROADCASTD k<>+88(SB), Y9
+	VPADDD       Y9, Y1, Y1
+	VPADDD       192(SP), Y1, Y1
+	VPADDD       Y1, Y5, Y5
+	VPSRLD       $2, Y2, Y8
+	VPSLLD       $30, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPOR         Y2, Y3, Y9
+	VPAND        Y4, Y9, Y9
+	VPAND        Y2, Y3, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VMOVDQU      160(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      256(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       0(SP), Y11, Y11
+	VPADDD       224(SP), Y11, Y11
+	VMOVDQU      Y11, 224(SP)
+	VPSRLD       $6, Y5, Y8
+	VPSLLD       $26, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPXOR        Y6, Y7, Y9
+	VPAND        Y5, Y9, Y9
+	VPXOR        Y7, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPBROADCASTD k<>+92(SB), Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       224(SP), Y0, Y0
+	VPADDD       Y0, Y4, Y4
+	VPSRLD       $2, Y1, Y8
+	VPSLLD       $30, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPOR         Y1, Y2, Y9
+	VPAND        Y3, Y9, Y9
+	VPAND        Y1, Y2, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VMOVDQU      192(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      288(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       32(SP), Y11, Y11
+	VPADDD       256(SP), Y11, Y11
+	VMOVDQU      Y11, 256(SP)
+	VPSRLD       $6, Y4, Y8
+	VPSLLD       $26, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPXOR        Y5, Y6, Y9
+	VPAND        Y4, Y9, Y9
+	VPXOR        Y6, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPBROADCASTD k<>+96(SB), Y9
+	VPADDD       Y9, Y7, Y7
+	VPADDD       256(SP), Y7, Y7
+	VPADDD       Y7, Y3, Y3
+	VPSRLD       $2, Y0, Y8
+	VPSLLD       $30, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPOR         Y0, Y1, Y9
+	VPAND        Y2, Y9, Y9
+	VPAND        Y0, Y1, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VMOVDQU      224(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      320(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       64(SP), Y11, Y11
+	VPADDD       288(SP), Y11, Y11
+	VMOVDQU      Y11, 288(SP)
+	VPSRLD       $6, Y3, Y8
+	VPSLLD       $26, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPXOR        Y4, Y5, Y9
+	VPAND        Y3, Y9, Y9
+	VPXOR        Y5, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPBROADCASTD k<>+100(SB), Y9
+	VPADDD       Y9, Y6, Y6
+	VPADDD       288(SP), Y6, Y6
+	VPADDD       Y6, Y2, Y2
+	VPSRLD       $2, Y7, Y8
+	VPSLLD       $30, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPOR         Y7, Y0, Y9
+	VPAND        Y1, Y9, Y9
+	VPAND        Y7, Y0, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VMOVDQU      256(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      352(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       96(SP), Y11, Y11
+	VPADDD       320(SP), Y11, Y11
+	VMOVDQU      Y11, 320(SP)
+	VPSRLD       $6, Y2, Y8
+	VPSLLD       $26, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPXOR        Y3, Y4, Y9
+	VPAND        Y2, Y9, Y9
+	VPXOR        Y4, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPBROADCASTD k<>+104(SB), Y9
+	VPADDD       Y9, Y5, Y5
+	VPADDD       320(SP), Y5, Y5
+	VPADDD       Y5, Y1, Y1
+	VPSRLD       $2, Y6, Y8
+	VPSLLD       $30, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPOR         Y6, Y7, Y9
+	VPAND        Y0, Y9, Y9
+	VPAND        Y6, Y7, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VMOVDQU      288(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      384(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       128(SP), Y11, Y11
+	VPADDD       352(SP), Y11, Y11
+	VMOVDQU      Y11, 352(SP)
+	VPSRLD       $6, Y1, Y8
+	VPSLLD       $26, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPXOR        Y2, Y3, Y9
+	VPAND        Y1, Y9, Y9
+	VPXOR        Y3, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPBROADCASTD k<>+108(SB), Y9
+	VPADDD       Y9, Y4, Y4
+	VPADDD       352(SP), Y4, Y4
+	VPADDD       Y4, Y0, Y0
+	VPSRLD       $2, Y5, Y8
+	VPSLLD       $30, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPOR         Y5, Y6, Y9
+	VPAND        Y7, Y9, Y9
+	VPAND        Y5, Y6, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VMOVDQU      320(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      416(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       160(SP), Y11, Y11
+	VPADDD       384(SP), Y11, Y11
+	VMOVDQU      Y11, 384(SP)
+	VPSRLD       $6, Y0, Y8
+	VPSLLD       $26, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPXOR        Y1, Y2, Y9
+	VPAND        Y0, Y9, Y9
+	VPXOR        Y2, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPBROADCASTD k<>+112(SB), Y9
+	VPADDD       Y9, Y3, Y3
+	VPADDD       384(SP), Y3, Y3
+	VPADDD       Y3, Y7, Y7
+	VPSRLD       $2, Y4, Y8
+	VPSLLD       $30, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPOR         Y4, Y5, Y9
+	VPAND        Y6, Y9, Y9
+	VPAND        Y4, Y5, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VMOVDQU      352(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      448(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       192(SP), Y11, Y11
+	VPADDD       416(SP), Y11, Y11
+	VMOVDQU      Y11, 416(SP)
+	VPSRLD       $6, Y7, Y8
+	VPSLLD       $26, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPXOR        Y0, Y1, Y9
+	VPAND        Y7, Y9, Y9
+	VPXOR        Y1, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPBROADCASTD k<>+116(SB), Y9
+	VPADDD       Y9, Y2, Y2
+	VPADDD       416(SP), Y2, Y2
+	VPADDD       Y2, Y6, Y6
+	VPSRLD       $2, Y3, Y8
+	VPSLLD       $30, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPOR         Y3, Y4, Y9
+	VPAND        Y5, Y9, Y9
+	VPAND        Y3, Y4, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VMOVDQU      384(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      480(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       224(SP), Y11, Y11
+	VPADDD       448(SP), Y11, Y11
+	VMOVDQU      Y11, 448(SP)
+	VPSRLD       $6, Y6, Y8
+	VPSLLD       $26, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPXOR        Y7, Y0, Y9
+	VPAND        Y6, Y9, Y9
+	VPXOR        Y0, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPBROADCASTD k<>+120(SB), Y9
+	VPADDD       Y9, Y1, Y1
+	VPADDD       448(SP), Y1, Y1
+	VPADDD       Y1, Y5, Y5
+	VPSRLD       $2, Y2, Y8
+	VPSLLD       $30, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPOR         Y2, Y3, Y9
+	VPAND        Y4, Y9, Y9
+	VPAND        Y2, Y3, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VMOVDQU      416(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      0(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       256(SP), Y11, Y11
+	VPADDD       480(SP), Y11, Y11
+	VMOVDQU      Y11, 480(SP)
+	VPSRLD       $6, Y5, Y8
+	VPSLLD       $26, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPXOR        Y6, Y7, Y9
+	VPAND        Y5, Y9, Y9
+	VPXOR        Y7, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPBROADCASTD k<>+124(SB), Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       480(SP), Y0, Y0
+	VPADDD       Y0, Y4, Y4
+	VPSRLD       $2, Y1, Y8
+	VPSLLD       $30, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPOR         Y1, Y2, Y9
+	VPAND        Y3, Y9, Y9
+	VPAND        Y1, Y2, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VMOVDQU      448(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      32(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       288(SP), Y11, Y11
+	VPADDD       0(SP), Y11, Y11
+	VMOVDQU      Y11, 0(SP)
+	VPSRLD       $6, Y4, Y8
+	VPSLLD       $26, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPXOR        Y5, Y6, Y9
+	VPAND        Y4, Y9, Y9
+	VPXOR        Y6, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPBROADCASTD k<>+128(SB), Y9
+	VPADDD       Y9, Y7, Y7
+	VPADDD       0(SP), Y7, Y7
+	VPADDD       Y7, Y3, Y3
+	VPSRLD       $2, Y0, Y8
+	VPSLLD       $30, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPOR         Y0, Y1, Y9
+	VPAND        Y2, Y9, Y9
+	VPAND        Y0, Y1, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VMOVDQU      480(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      64(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       320(SP), Y11, Y11
+	VPADDD       32(SP), Y11, Y11
+	VMOVDQU      Y11, 32(SP)
+	VPSRLD       $6, Y3, Y8
+	VPSLLD       $26, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPXOR        Y4, Y5, Y9
+	VPAND        Y3, Y9, Y9
+	VPXOR        Y5, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPBROADCASTD k<>+132(SB), Y9
+	VPADDD       Y9, Y6, Y6
+	VPADDD       32(SP), Y6, Y6
+	VPADDD       Y6, Y2, Y2
+	VPSRLD       $2, Y7, Y8
+	VPSLLD       $30, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPOR         Y7, Y0, Y9
+	VPAND        Y1, Y9, Y9
+	VPAND        Y7, Y0, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VMOVDQU      0(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      96(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       352(SP), Y11, Y11
+	VPADDD       64(SP), Y11, Y11
+	VMOVDQU      Y11, 64(SP)
+	VPSRLD       $6, Y2, Y8
+	VPSLLD       $26, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPXOR        Y3, Y4, Y9
+	VPAND        Y2, Y9, Y9
+	VPXOR        Y4, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPBROADCASTD k<>+136(SB), Y9
+	VPADDD       Y9, Y5, Y5
+	VPADDD       64(SP), Y5, Y5
+	VPADDD       Y5, Y1, Y1
+	VPSRLD       $2, Y6, Y8
+	VPSLLD       $30, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPOR         Y6, Y7, Y9
+	VPAND        Y0, Y9, Y9
+	VPAND        Y6, Y7, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VMOVDQU      32(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      128(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       384(SP), Y11, Y11
+	VPADDD       96(SP), Y11, Y11
+	VMOVDQU      Y11, 96(SP)
+	VPSRLD       $6, Y1, Y8
+	VPSLLD       $26, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPXOR        Y2, Y3, Y9
+	VPAND        Y1, Y9, Y9
+	VPXOR        Y3, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPBROADCASTD k<>+140(SB), Y9
+	VPADDD       Y9, Y4, Y4
+	VPADDD       96(SP), Y4, Y4
+	VPADDD       Y4, Y0, Y0
+	VPSRLD       $2, Y5, Y8
+	VPSLLD       $30, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPOR         Y5, Y6, Y9
+	VPAND        Y7, Y9, Y9
+	VPAND        Y5, Y6, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VMOVDQU      64(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      160(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       416(SP), Y11, Y11
+	VPADDD       128(SP), Y11, Y11
+	VMOVDQU      Y11, 128(SP)
+	VPSRLD       $6, Y0, Y8
+	VPSLLD       $26, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPXOR        Y1, Y2, Y9
+	VPAND        Y0, Y9, Y9
+	VPXOR        Y2, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPBROADCASTD k<>+144(SB), Y9
+	VPADDD       Y9, Y3, Y3
+	VPADDD       128(SP), Y3, Y3
+	VPADDD       Y3, Y7, Y7
+	VPSRLD       $2, Y4, Y8
+	VPSLLD       $30, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPOR         Y4, Y5, Y9
+	VPAND        Y6, Y9, Y9
+	VPAND        Y4, Y5, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VMOVDQU      96(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      192(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       448(SP), Y11, Y11
+	VPADDD       160(SP), Y11, Y11
+	VMOVDQU      Y11, 160(SP)
+	VPSRLD       $6, Y7, Y8
+	VPSLLD       $26, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPXOR        Y0, Y1, Y9
+	VPAND        Y7, Y9, Y9
+	VPXOR        Y1, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPBROADCASTD k<>+148(SB), Y9
+	VPADDD       Y9, Y2, Y2
+	VPADDD       160(SP), Y2, Y2
+	VPADDD       Y2, Y6, Y6
+	VPSRLD       $2, Y3, Y8
+	VPSLLD       $30, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPOR         Y3, Y4, Y9
+	VPAND        Y5, Y9, Y9
+	VPAND        Y3, Y4, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VMOVDQU      128(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      224(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       480(SP), Y11, Y11
+	VPADDD       192(SP), Y11, Y11
+	VMOVDQU      Y11, 192(SP)
+	VPSRLD       $6, Y6, Y8
+	VPSLLD       $26, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPXOR        Y7, Y0, Y9
+	VPAND        Y6, Y9, Y9
+	VPXOR        Y0, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPBROADCASTD k<>+152(SB), Y9
+	VPADDD       Y9, Y1, Y1
+	VPADDD       192(SP), Y1, Y1
+	VPADDD       Y1, Y5, Y5
+	VPSRLD       $2, Y2, Y8
+	VPSLLD       $30, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPOR         Y2, Y3, Y9
+	VPAND        Y4, Y9, Y9
+	VPAND        Y2, Y3, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VMOVDQU      160(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      256(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       0(SP), Y11, Y11
+	VPADDD       224(SP), Y11, Y11
+	VMOVDQU      Y11, 224(SP)
+	VPSRLD       $6, Y5, Y8
+	VPSLLD       $26, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPXOR        Y6, Y7, Y9
+	VPAND        Y5, Y9, Y9
+	VPXOR        Y7, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPBROADCASTD k<>+156(SB), Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       224(SP), Y0, Y0
+	VPADDD       Y0, Y4, Y4
+	VPSRLD       $2, Y1, Y8
+	VPSLLD       $30, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPOR         Y1, Y2, Y9
+	VPAND        Y3, Y9, Y9
+	VPAND        Y1, Y2, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VMOVDQU      192(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      288(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       32(SP), Y11, Y11
+	VPADDD       256(SP), Y11, Y11
+	VMOVDQU      Y11, 256(SP)
+	VPSRLD       $6, Y4, Y8
+	VPSLLD       $26, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPXOR        Y5, Y6, Y9
+	VPAND        Y4, Y9, Y9
+	VPXOR        Y6, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPBROADCASTD k<>+160(SB), Y9
+	VPADDD       Y9, Y7, Y7
+	VPADDD       256(SP), Y7, Y7
+	VPADDD       Y7, Y3, Y3
+	VPSRLD       $2, Y0, Y8
+	VPSLLD       $30, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPOR         Y0, Y1, Y9
+	VPAND        Y2, Y9, Y9
+	VPAND        Y0, Y1, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VMOVDQU      224(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      320(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       64(SP), Y11, Y11
+	VPADDD       288(SP), Y11, Y11
+	VMOVDQU      Y11, 288(SP)
+	VPSRLD       $6, Y3, Y8
+	VPSLLD       $26, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPXOR        Y4, Y5, Y9
+	VPAND        Y3, Y9, Y9
+	VPXOR        Y5, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPBROADCASTD k<>+164(SB), Y9
+	VPADDD       Y9, Y6, Y6
+	VPADDD       288(SP), Y6, Y6
+	VPADDD       Y6, Y2, Y2
+	VPSRLD       $2, Y7, Y8
+	VPSLLD       $30, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPOR         Y7, Y0, Y9
+	VPAND        Y1, Y9, Y9
+	VPAND        Y7, Y0, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VMOVDQU      256(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      352(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       96(SP), Y11, Y11
+	VPADDD       320(SP), Y11, Y11
+	VMOVDQU      Y11, 320(SP)
+	VPSRLD       $6, Y2, Y8
+	VPSLLD       $26, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPXOR        Y3, Y4, Y9
+	VPAND        Y2, Y9, Y9
+	VPXOR        Y4, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPBROADCASTD k<>+168(SB), Y9
+	VPADDD       Y9, Y5, Y5
+	VPADDD       320(SP), Y5, Y5
+	VPADDD       Y5, Y1, Y1
+	VPSRLD       $2, Y6, Y8
+	VPSLLD       $30, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPOR         Y6, Y7, Y9
+	VPAND        Y0, Y9, Y9
+	VPAND        Y6, Y7, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VMOVDQU      288(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      384(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       128(SP), Y11, Y11
+	VPADDD       352(SP), Y11, Y11
+	VMOVDQU      Y11, 352(SP)
+	VPSRLD       $6, Y1, Y8
+	VPSLLD       $26, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPXOR        Y2, Y3, Y9
+	VPAND        Y1, Y9, Y9
+	VPXOR        Y3, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPBROADCASTD k<>+172(SB), Y9
+	VPADDD       Y9, Y4, Y4
+	VPADDD       352(SP), Y4, Y4
+	VPADDD       Y4, Y0, Y0
+	VPSRLD       $2, Y5, Y8
+	VPSLLD       $30, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPOR         Y5, Y6, Y9
+	VPAND        Y7, Y9, Y9
+	VPAND        Y5, Y6, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VMOVDQU      320(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      416(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       160(SP), Y11, Y11
+	VPADDD       384(SP), Y11, Y11
+	VMOVDQU      Y11, 384(SP)
+	VPSRLD       $6, Y0, Y8
+	VPSLLD       $26, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPXOR        Y1, Y2, Y9
+	VPAND        Y0, Y9, Y9
+	VPXOR        Y2, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPBROADCASTD k<>+176(SB), Y9
+	VPADDD       Y9, Y3, Y3
+	VPADDD       384(SP), Y3, Y3
+	VPADDD       Y3, Y7, Y7
+	VPSRLD       $2, Y4, Y8
+	VPSLLD       $30, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPOR         Y4, Y5, Y9
+	VPAND        Y6, Y9, Y9
+	VPAND        Y4, Y5, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VMOVDQU      352(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      448(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       192(SP), Y11, Y11
+	VPADDD       416(SP), Y11, Y11
+	VMOVDQU      Y11, 416(SP)
+	VPSRLD       $6, Y7, Y8
+	VPSLLD       $26, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPXOR        Y0, Y1, Y9
+	VPAND        Y7, Y9, Y9
+	VPXOR        Y1, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPBROADCASTD k<>+180(SB), Y9
+	VPADDD       Y9, Y2, Y2
+	VPADDD       416(SP), Y2, Y2
+	VPADDD       Y2, Y6, Y6
+	VPSRLD       $2, Y3, Y8
+	VPSLLD       $30, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPOR         Y3, Y4, Y9
+	VPAND        Y5, Y9, Y9
+	VPAND        Y3, Y4, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VMOVDQU      384(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      480(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       224(SP), Y11, Y11
+	VPADDD       448(SP), Y11, Y11
+	VMOVDQU      Y11, 448(SP)
+	VPSRLD       $6, Y6, Y8
+	VPSLLD       $26, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPXOR        Y7, Y0, Y9
+	VPAND        Y6, Y9, Y9
+	VPXOR        Y0, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPBROADCASTD k<>+184(SB), Y9
+	VPADDD       Y9, Y1, Y1
+	VPADDD       448(SP), Y1, Y1
+	VPADDD       Y1, Y5, Y5
+	VPSRLD       $2, Y2, Y8
+	VPSLLD       $30, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPOR         Y2, Y3, Y9
+	VPAND        Y4, Y9, Y9
+	VPAND        Y2, Y3, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VMOVDQU      416(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      0(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       256(SP), Y11, Y11
+	VPADDD       480(SP), Y11, Y11
+	VMOVDQU      Y11, 480(SP)
+	VPSRLD       $6, Y5, Y8
+	VPSLLD       $26, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPXOR        Y6, Y7, Y9
+	VPAND        Y5, Y9, Y9
+	VPXOR        Y7, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPBROADCASTD k<>+188(SB), Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       480(SP), Y0, Y0
+	VPADDD       Y0, Y4, Y4
+	VPSRLD       $2, Y1, Y8
+	VPSLLD       $30, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPOR         Y1, Y2, Y9
+	VPAND        Y3, Y9, Y9
+	VPAND        Y1, Y2, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VMOVDQU      448(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      32(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       288(SP), Y11, Y11
+	VPADDD       0(SP), Y11, Y11
+	VMOVDQU      Y11, 0(SP)
+	VPSRLD       $6, Y4, Y8
+	VPSLLD       $26, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPXOR        Y5, Y6, Y9
+	VPAND        Y4, Y9, Y9
+	VPXOR        Y6, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPBROADCASTD k<>+192(SB), Y9
+	VPADDD       Y9, Y7, Y7
+	VPADDD       0(SP), Y7, Y7
+	VPADDD       Y7, Y3, Y3
+	VPSRLD       $2, Y0, Y8
+	VPSLLD       $30, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPOR         Y0, Y1, Y9
+	VPAND        Y2, Y9, Y9
+	VPAND        Y0, Y1, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VMOVDQU      480(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      64(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       320(SP), Y11, Y11
+	VPADDD       32(SP), Y11, Y11
+	VMOVDQU      Y11, 32(SP)
+	VPSRLD       $6, Y3, Y8
+	VPSLLD       $26, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPXOR        Y4, Y5, Y9
+	VPAND        Y3, Y9, Y9
+	VPXOR        Y5, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPBROADCASTD k<>+196(SB), Y9
+	VPADDD       Y9, Y6, Y6
+	VPADDD       32(SP), Y6, Y6
+	VPADDD       Y6, Y2, Y2
+	VPSRLD       $2, Y7, Y8
+	VPSLLD       $30, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPOR         Y7, Y0, Y9
+	VPAND        Y1, Y9, Y9
+	VPAND        Y7, Y0, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VMOVDQU      0(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      96(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       352(SP), Y11, Y11
+	VPADDD       64(SP), Y11, Y11
+	VMOVDQU      Y11, 64(SP)
+	VPSRLD       $6, Y2, Y8
+	VPSLLD       $26, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPXOR        Y3, Y4, Y9
+	VPAND        Y2, Y9, Y9
+	VPXOR        Y4, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPBROADCASTD k<>+200(SB), Y9
+	VPADDD       Y9, Y5, Y5
+	VPADDD       64(SP), Y5, Y5
+	VPADDD       Y5, Y1, Y1
+	VPSRLD       $2, Y6, Y8
+	VPSLLD       $30, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPOR         Y6, Y7, Y9
+	VPAND        Y0, Y9, Y9
+	VPAND        Y6, Y7, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VMOVDQU      32(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      128(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       384(SP), Y11, Y11
+	VPADDD       96(SP), Y11, Y11
+	VMOVDQU      Y11, 96(SP)
+	VPSRLD       $6, Y1, Y8
+	VPSLLD       $26, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPXOR        Y2, Y3, Y9
+	VPAND        Y1, Y9, Y9
+	VPXOR        Y3, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPBROADCASTD k<>+204(SB), Y9
+	VPADDD       Y9, Y4, Y4
+	VPADDD       96(SP), Y4, Y4
+	VPADDD       Y4, Y0, Y0
+	VPSRLD       $2, Y5, Y8
+	VPSLLD       $30, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPOR         Y5, Y6, Y9
+	VPAND        Y7, Y9, Y9
+	VPAND        Y5, Y6, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VMOVDQU      64(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      160(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       416(SP), Y11, Y11
+	VPADDD       128(SP), Y11, Y11
+	VMOVDQU      Y11, 128(SP)
+	VPSRLD       $6, Y0, Y8
+	VPSLLD       $26, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPXOR        Y1, Y2, Y9
+	VPAND        Y0, Y9, Y9
+	VPXOR        Y2, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPBROADCASTD k<>+208(SB), Y9
+	VPADDD       Y9, Y3, Y3
+	VPADDD       128(SP), Y3, Y3
+	VPADDD       Y3, Y7, Y7
+	VPSRLD       $2, Y4, Y8
+	VPSLLD       $30, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPOR         Y4, Y5, Y9
+	VPAND        Y6, Y9, Y9
+	VPAND        Y4, Y5, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VMOVDQU      96(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      192(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       448(SP), Y11, Y11
+	VPADDD       160(SP), Y11, Y11
+	VMOVDQU      Y11, 160(SP)
+	VPSRLD       $6, Y7, Y8
+	VPSLLD       $26, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPXOR        Y0, Y1, Y9
+	VPAND        Y7, Y9, Y9
+	VPXOR        Y1, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPBROADCASTD k<>+212(SB), Y9
+	VPADDD       Y9, Y2, Y2
+	VPADDD       160(SP), Y2, Y2
+	VPADDD       Y2, Y6, Y6
+	VPSRLD       $2, Y3, Y8
+	VPSLLD       $30, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPOR         Y3, Y4, Y9
+	VPAND        Y5, Y9, Y9
+	VPAND        Y3, Y4, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VMOVDQU      128(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      224(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       480(SP), Y11, Y11
+	VPADDD       192(SP), Y11, Y11
+	VMOVDQU      Y11, 192(SP)
+	VPSRLD       $6, Y6, Y8
+	VPSLLD       $26, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPXOR        Y7, Y0, Y9
+	VPAND        Y6, Y9, Y9
+	VPXOR        Y0, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPBROADCASTD k<>+216(SB), Y9
+	VPADDD       Y9, Y1, Y1
+	VPADDD       192(SP), Y1, Y1
+	VPADDD       Y1, Y5, Y5
+	VPSRLD       $2, Y2, Y8
+	VPSLLD       $30, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPOR         Y2, Y3, Y9
+	VPAND        Y4, Y9, Y9
+	VPAND        Y2, Y3, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VMOVDQU      160(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      256(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       0(SP), Y11, Y11
+	VPADDD       224(SP), Y11, Y11
+	VMOVDQU      Y11, 224(SP)
+	VPSRLD       $6, Y5, Y8
+	VPSLLD       $26, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPXOR        Y6, Y7, Y9
+	VPAND        Y5, Y9, Y9
+	VPXOR        Y7, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPBROADCASTD k<>+220(SB), Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       224(SP), Y0, Y0
+	VPADDD       Y0, Y4, Y4
+	VPSRLD       $2, Y1, Y8
+	VPSLLD       $30, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPOR         Y1, Y2, Y9
+	VPAND        Y3, Y9, Y9
+	VPAND        Y1, Y2, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VMOVDQU      192(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      288(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       32(SP), Y11, Y11
+	VPADDD       256(SP), Y11, Y11
+	VMOVDQU      Y11, 256(SP)
+	VPSRLD       $6, Y4, Y8
+	VPSLLD       $26, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPXOR        Y5, Y6, Y9
+	VPAND        Y4, Y9, Y9
+	VPXOR        Y6, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VPBROADCASTD k<>+224(SB), Y9
+	VPADDD       Y9, Y7, Y7
+	VPADDD       256(SP), Y7, Y7
+	VPADDD       Y7, Y3, Y3
+	VPSRLD       $2, Y0, Y8
+	VPSLLD       $30, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y7, Y7
+	VPOR         Y0, Y1, Y9
+	VPAND        Y2, Y9, Y9
+	VPAND        Y0, Y1, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y7, Y7
+	VMOVDQU      224(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      320(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       64(SP), Y11, Y11
+	VPADDD       288(SP), Y11, Y11
+	VMOVDQU      Y11, 288(SP)
+	VPSRLD       $6, Y3, Y8
+	VPSLLD       $26, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPXOR        Y4, Y5, Y9
+	VPAND        Y3, Y9, Y9
+	VPXOR        Y5, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VPBROADCASTD k<>+228(SB), Y9
+	VPADDD       Y9, Y6, Y6
+	VPADDD       288(SP), Y6, Y6
+	VPADDD       Y6, Y2, Y2
+	VPSRLD       $2, Y7, Y8
+	VPSLLD       $30, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y6, Y6
+	VPOR         Y7, Y0, Y9
+	VPAND        Y1, Y9, Y9
+	VPAND        Y7, Y0, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y6, Y6
+	VMOVDQU      256(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      352(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       96(SP), Y11, Y11
+	VPADDD       320(SP), Y11, Y11
+	VMOVDQU      Y11, 320(SP)
+	VPSRLD       $6, Y2, Y8
+	VPSLLD       $26, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPXOR        Y3, Y4, Y9
+	VPAND        Y2, Y9, Y9
+	VPXOR        Y4, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VPBROADCASTD k<>+232(SB), Y9
+	VPADDD       Y9, Y5, Y5
+	VPADDD       320(SP), Y5, Y5
+	VPADDD       Y5, Y1, Y1
+	VPSRLD       $2, Y6, Y8
+	VPSLLD       $30, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y5, Y5
+	VPOR         Y6, Y7, Y9
+	VPAND        Y0, Y9, Y9
+	VPAND        Y6, Y7, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y5, Y5
+	VMOVDQU      288(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      384(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       128(SP), Y11, Y11
+	VPADDD       352(SP), Y11, Y11
+	VMOVDQU      Y11, 352(SP)
+	VPSRLD       $6, Y1, Y8
+	VPSLLD       $26, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPXOR        Y2, Y3, Y9
+	VPAND        Y1, Y9, Y9
+	VPXOR        Y3, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VPBROADCASTD k<>+236(SB), Y9
+	VPADDD       Y9, Y4, Y4
+	VPADDD       352(SP), Y4, Y4
+	VPADDD       Y4, Y0, Y0
+	VPSRLD       $2, Y5, Y8
+	VPSLLD       $30, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y4, Y4
+	VPOR         Y5, Y6, Y9
+	VPAND        Y7, Y9, Y9
+	VPAND        Y5, Y6, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y4, Y4
+	VMOVDQU      320(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      416(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       160(SP), Y11, Y11
+	VPADDD       384(SP), Y11, Y11
+	VMOVDQU      Y11, 384(SP)
+	VPSRLD       $6, Y0, Y8
+	VPSLLD       $26, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y0, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPXOR        Y1, Y2, Y9
+	VPAND        Y0, Y9, Y9
+	VPXOR        Y2, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VPBROADCASTD k<>+240(SB), Y9
+	VPADDD       Y9, Y3, Y3
+	VPADDD       384(SP), Y3, Y3
+	VPADDD       Y3, Y7, Y7
+	VPSRLD       $2, Y4, Y8
+	VPSLLD       $30, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y4, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y3, Y3
+	VPOR         Y4, Y5, Y9
+	VPAND        Y6, Y9, Y9
+	VPAND        Y4, Y5, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y3, Y3
+	VMOVDQU      352(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      448(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       192(SP), Y11, Y11
+	VPADDD       416(SP), Y11, Y11
+	VMOVDQU      Y11, 416(SP)
+	VPSRLD       $6, Y7, Y8
+	VPSLLD       $26, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y7, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPXOR        Y0, Y1, Y9
+	VPAND        Y7, Y9, Y9
+	VPXOR        Y1, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VPBROADCASTD k<>+244(SB), Y9
+	VPADDD       Y9, Y2, Y2
+	VPADDD       416(SP), Y2, Y2
+	VPADDD       Y2, Y6, Y6
+	VPSRLD       $2, Y3, Y8
+	VPSLLD       $30, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y3, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y2, Y2
+	VPOR         Y3, Y4, Y9
+	VPAND        Y5, Y9, Y9
+	VPAND        Y3, Y4, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y2, Y2
+	VMOVDQU      384(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      480(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       224(SP), Y11, Y11
+	VPADDD       448(SP), Y11, Y11
+	VMOVDQU      Y11, 448(SP)
+	VPSRLD       $6, Y6, Y8
+	VPSLLD       $26, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y6, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPXOR        Y7, Y0, Y9
+	VPAND        Y6, Y9, Y9
+	VPXOR        Y0, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VPBROADCASTD k<>+248(SB), Y9
+	VPADDD       Y9, Y1, Y1
+	VPADDD       448(SP), Y1, Y1
+	VPADDD       Y1, Y5, Y5
+	VPSRLD       $2, Y2, Y8
+	VPSLLD       $30, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y2, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y1, Y1
+	VPOR         Y2, Y3, Y9
+	VPAND        Y4, Y9, Y9
+	VPAND        Y2, Y3, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y1, Y1
+	VMOVDQU      416(SP), Y10
+	VPSRLD       $17, Y10, Y11
+	VPSLLD       $15, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $19, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSLLD       $13, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VPSRLD       $10, Y10, Y12
+	VPXOR        Y12, Y11, Y11
+	VMOVDQU      0(SP), Y10
+	VPSRLD       $7, Y10, Y13
+	VPSLLD       $25, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $18, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSLLD       $14, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPSRLD       $3, Y10, Y12
+	VPXOR        Y12, Y13, Y13
+	VPADDD       Y13, Y11, Y11
+	VPADDD       256(SP), Y11, Y11
+	VPADDD       480(SP), Y11, Y11
+	VMOVDQU      Y11, 480(SP)
+	VPSRLD       $6, Y5, Y8
+	VPSLLD       $26, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $11, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $21, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $25, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $7, Y5, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPXOR        Y6, Y7, Y9
+	VPAND        Y5, Y9, Y9
+	VPXOR        Y7, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPBROADCASTD k<>+252(SB), Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       480(SP), Y0, Y0
+	VPADDD       Y0, Y4, Y4
+	VPSRLD       $2, Y1, Y8
+	VPSLLD       $30, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $13, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $19, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSRLD       $22, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPSLLD       $10, Y1, Y9
+	VPXOR        Y9, Y8, Y8
+	VPADDD       Y8, Y0, Y0
+	VPOR         Y1, Y2, Y9
+	VPAND        Y3, Y9, Y9
+	VPAND        Y1, Y2, Y10
+	VPOR         Y10, Y9, Y9
+	VPADDD       Y9, Y0, Y0
+	VPADDD       0(AX), Y0, Y0
+	VMOVDQU      Y0, 0(AX)
+	VPADDD       32(AX), Y1, Y1
+	VMOVDQU      Y1, 32(AX)
+	VPADDD       64(AX), Y2, Y2
+	VMOVDQU      Y2, 64(AX)
+	VPADDD       96(AX), Y3, Y3
+	VMOVDQU      Y3, 96(AX)
+	VPADDD       128(AX), Y4, Y4
+	VMOVDQU      Y4, 128(AX)
+	VPADDD       160(AX), Y5, Y5
+	VMOVDQU      Y5, 160(AX)
+	VPADDD       192(AX), Y6, Y6
+	VMOVDQU      Y6, 192(AX)
+	VPADDD       224(AX), Y7, Y7
+	VMOVDQU      Y7, 224(AX)
+	VZEROUPPER   
+	RET          
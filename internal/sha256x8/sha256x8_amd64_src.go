@@ -0,0 +1,176 @@
+// +build ignore
+
+// AVX2 eightway parallelized SHA-256 compression function.
+//
+// Prints Go assembly.  We do not use avo here as the generated code is a
+// straight-line sequence of instructions with a fixed register allocation.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+var k = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5,
+	0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3,
+	0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc,
+	0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7,
+	0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13,
+	0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3,
+	0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5,
+	0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208,
+	0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+var w io.Writer
+
+func ins(op string, args ...interface{}) {
+	fmt.Fprintf(w, "\t%-13s", op)
+	for i, arg := range args {
+		if i != 0 {
+			fmt.Fprint(w, ", ")
+		}
+		fmt.Fprint(w, arg)
+	}
+	fmt.Fprintln(w)
+}
+
+// Returns the stack slot that holds the message schedule word W[t].
+func slot(t int) string {
+	return fmt.Sprintf("%d(SP)", 32*(t%16))
+}
+
+// Computes into dst the xor of the given right-rotations and right-shifts
+// of src using tmp as scratch.
+func sigma(src, dst, tmp string, rots []int, shift int) {
+	first := true
+	xor := func() {
+		if first {
+			first = false
+			return
+		}
+		ins("VPXOR", tmp, dst, dst)
+	}
+	for _, r := range rots {
+		if first {
+			ins("VPSRLD", fmt.Sprintf("$%d", r), src, dst)
+		} else {
+			ins("VPSRLD", fmt.Sprintf("$%d", r), src, tmp)
+		}
+		xor()
+		ins("VPSLLD", fmt.Sprintf("$%d", 32-r), src, tmp)
+		xor()
+	}
+	if shift != 0 {
+		ins("VPSRLD", fmt.Sprintf("$%d", shift), src, tmp)
+		xor()
+	}
+}
+
+func main() {
+	out := flag.String("out", "sha256x8_amd64.s", "output file")
+	flag.Parse()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	w = f
+
+	fmt.Fprintln(w, "// Code generated by command: go run sha256x8_amd64_src.go "+
+		"-out sha256x8_amd64.s. DO NOT EDIT.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "// +build amd64")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "#include \"textflag.h\"")
+	fmt.Fprintln(w)
+
+	for i := 0; i < 64; i++ {
+		fmt.Fprintf(w, "DATA k<>+%d(SB)/4, $0x%08x\n", 4*i, k[i])
+	}
+	fmt.Fprintln(w, "GLOBL k<>(SB), RODATA|NOPTR, $256")
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "// func block8(state *[64]uint32, msg *[128]uint32)")
+	fmt.Fprintln(w, "// Requires: AVX, AVX2")
+	fmt.Fprintln(w, "TEXT ·block8(SB), NOSPLIT, $512-16")
+	ins("MOVQ", "state+0(FP)", "AX")
+	ins("MOVQ", "msg+8(FP)", "BX")
+
+	// Y0, ..., Y7 hold the working variables a, ..., h.
+	for i := 0; i < 8; i++ {
+		ins("VMOVDQU", fmt.Sprintf("%d(AX)", 32*i), fmt.Sprintf("Y%d", i))
+	}
+
+	// Copy the message block to the stack.
+	for i := 0; i < 16; i++ {
+		ins("VMOVDQU", fmt.Sprintf("%d(BX)", 32*i), "Y8")
+		ins("VMOVDQU", "Y8", slot(i))
+	}
+
+	regs := []string{"Y0", "Y1", "Y2", "Y3", "Y4", "Y5", "Y6", "Y7"}
+	for t := 0; t < 64; t++ {
+		if t >= 16 {
+			// W[t] = σ1(W[t-2]) + W[t-7] + σ0(W[t-15]) + W[t-16]
+			ins("VMOVDQU", slot(t-2), "Y10")
+			sigma("Y10", "Y11", "Y12", []int{17, 19}, 10)
+			ins("VMOVDQU", slot(t-15), "Y10")
+			sigma("Y10", "Y13", "Y12", []int{7, 18}, 3)
+			ins("VPADDD", "Y13", "Y11", "Y11")
+			ins("VPADDD", slot(t-7), "Y11", "Y11")
+			ins("VPADDD", slot(t-16), "Y11", "Y11")
+			ins("VMOVDQU", "Y11", slot(t))
+		}
+
+		a, b, c, d := regs[0], regs[1], regs[2], regs[3]
+		e, f, g, h := regs[4], regs[5], regs[6], regs[7]
+
+		// h ← h + Σ1(e) + Ch(e, f, g) + K[t] + W[t]
+		sigma(e, "Y8", "Y9", []int{6, 11, 25}, 0)
+		ins("VPADDD", "Y8", h, h)
+		ins("VPXOR", f, g, "Y9")
+		ins("VPAND", e, "Y9", "Y9")
+		ins("VPXOR", g, "Y9", "Y9")
+		ins("VPADDD", "Y9", h, h)
+		ins("VPBROADCASTD", fmt.Sprintf("k<>+%d(SB)", 4*t), "Y9")
+		ins("VPADDD", "Y9", h, h)
+		ins("VPADDD", slot(t), h, h)
+
+		// d ← d + h
+		ins("VPADDD", h, d, d)
+
+		// h ← h + Σ0(a) + Maj(a, b, c)
+		sigma(a, "Y8", "Y9", []int{2, 13, 22}, 0)
+		ins("VPADDD", "Y8", h, h)
+		ins("VPOR", a, b, "Y9")
+		ins("VPAND", c, "Y9", "Y9")
+		ins("VPAND", a, b, "Y10")
+		ins("VPOR", "Y10", "Y9", "Y9")
+		ins("VPADDD", "Y9", h, h)
+
+		// Rename (a, b, ..., h) ← (h, a, ..., g)
+		regs = append([]string{h}, regs[:7]...)
+	}
+
+	// Add the working variables to the state.
+	for i := 0; i < 8; i++ {
+		y := fmt.Sprintf("Y%d", i)
+		ins("VPADDD", fmt.Sprintf("%d(AX)", 32*i), y, y)
+		ins("VMOVDQU", y, fmt.Sprintf("%d(AX)", 32*i))
+	}
+
+	ins("VZEROUPPER")
+	ins("RET")
+}
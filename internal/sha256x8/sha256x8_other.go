@@ -0,0 +1,13 @@
+// +build !amd64
+
+package sha256x8
+
+// Available is true when this system supports a fast eightway SHA-256.
+var Available = false
+
+// Whether block8 can be used.
+var hasAVX2 = false
+
+func block8(state *[64]uint32, msg *[128]uint32) {
+	panic("Not available")
+}
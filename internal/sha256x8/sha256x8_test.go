@@ -0,0 +1,84 @@
+package sha256x8
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding"
+	"encoding/binary"
+	"testing"
+)
+
+func testSum256(t *testing.T, prefixLen, msgLen int) {
+	var s State
+	var msgs, out [8][]byte
+	prefix := make([]byte, prefixLen)
+	for i := 0; i < prefixLen; i++ {
+		prefix[i] = byte(3 * i)
+	}
+	for j := 0; j < 8; j++ {
+		msgs[j] = make([]byte, msgLen)
+		out[j] = make([]byte, 32)
+		for i := 0; i < msgLen; i++ {
+			msgs[j][i] = byte(i + 17*j)
+		}
+	}
+	msgs[5] = nil
+
+	// Extract the state after processing the prefix.
+	h := sha256.New()
+	h.Write(prefix)
+	buf, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	var iv [8]uint32
+	for i := 0; i < 8; i++ {
+		iv[i] = binary.BigEndian.Uint32(buf[4+4*i:])
+	}
+
+	s.Sum256(&iv, uint64(prefixLen), &msgs, &out)
+
+	for j := 0; j < 8; j++ {
+		if msgs[j] == nil {
+			continue
+		}
+		expected := sha256.Sum256(append(prefix, msgs[j]...))
+		if !bytes.Equal(out[j], expected[:]) {
+			t.Fatalf("prefixLen=%d msgLen=%d lane=%d: %x != %x",
+				prefixLen, msgLen, j, out[j], expected)
+		}
+	}
+}
+
+func TestSum256(t *testing.T) {
+	for _, prefixLen := range []int{0, 64, 128} {
+		for msgLen := 0; msgLen < 248; msgLen++ {
+			testSum256(t, prefixLen, msgLen)
+		}
+	}
+}
+
+func TestBlockGeneric(t *testing.T) {
+	if !hasAVX2 {
+		t.Skip()
+	}
+	var s State
+	h, w := s.Buffers()
+	for i := 0; i < 64; i++ {
+		h[i] = uint32(i) * 0x9e3779b9
+	}
+	for i := 0; i < 128; i++ {
+		w[i] = uint32(i) * 0x85ebca6b
+	}
+	h2 := *h
+	w2 := *w
+	s.Block()
+	blockGeneric(&h2, &w2)
+	if *h != h2 {
+		t.Fatal()
+	}
+}
+
+func BenchmarkBlock(b *testing.B) {
+	var s State
+	for i := 0; i < b.N; i++ {
+		s.Block()
+	}
+}
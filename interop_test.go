@@ -0,0 +1,177 @@
+// +build interop
+
+package xmssmt
+
+// Checks interoperability with the reference implementation at
+// https://github.com/XMSS/xmss-reference.  Build its ui tools with
+//
+//    make xmss_keypair xmss_sign xmss_open xmssmt_keypair xmssmt_sign xmssmt_open
+//
+// and run
+//
+//    XMSS_REFERENCE_DIR=/path/to/xmss-reference go test -tags interop -run Interop
+//
+// By default only the instances with subtrees of height at most 10 are
+// checked, as generating larger trees with the reference implementation
+// takes very long.  Set XMSS_INTEROP_ALL=1 to check all instances.
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Returns the directory with the reference implementation and skips
+// the test if it is not set.
+func referenceDir(t *testing.T) string {
+	dir := os.Getenv("XMSS_REFERENCE_DIR")
+	if dir == "" {
+		t.Skip("XMSS_REFERENCE_DIR is not set")
+	}
+	return dir
+}
+
+// Runs the given ui tool of the reference implementation and returns
+// its stdout.
+func runReference(t *testing.T, ctx *Context, tool string,
+	args ...string) ([]byte, error) {
+	prefix := "xmss_"
+	if ctx.MT() {
+		prefix = "xmssmt_"
+	}
+	cmd := exec.Command(filepath.Join(referenceDir(t), prefix+tool), args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Logf("%s%s: %s", prefix, tool, stderr.String())
+	}
+	return out, err
+}
+
+// Returns the RFC instances to check.
+func interopNames() (names []string) {
+	all := os.Getenv("XMSS_INTEROP_ALL") != ""
+	for _, name := range ListNames() {
+		ctx := NewContextFromName(name)
+		if !ctx.FromRFC() && !ctx.FromNIST() {
+			continue
+		}
+		if !all && ctx.treeHeight > 10 {
+			continue
+		}
+		names = append(names, name)
+	}
+	return
+}
+
+// Signatures created by us should be accepted by the reference
+// implementation.
+func TestInteropReferenceVerifies(t *testing.T) {
+	referenceDir(t)
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	msg := []byte("test message")
+	for _, name := range interopNames() {
+		ctx := NewContextFromName(name)
+		keyDir, err := ioutil.TempDir(dir, "")
+		if err != nil {
+			t.Fatalf("TempDir: %v", err)
+		}
+		sk, pk, err := ctx.GenerateKeyPair(filepath.Join(keyDir, "key"))
+		if err != nil {
+			t.Fatalf("%s: GenerateKeyPair(): %v", name, err)
+		}
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("%s: Sign(): %v", name, err)
+		}
+		sk.Close()
+
+		// The reference implementation only reads the public key from
+		// the keypair file.
+		pkBuf, err2 := pk.MarshalStandard()
+		if err2 != nil {
+			t.Fatalf("%s: MarshalStandard(): %v", name, err2)
+		}
+		keyPath := filepath.Join(dir, "pk")
+		smPath := filepath.Join(dir, "sm")
+		if err := ioutil.WriteFile(keyPath, pkBuf, 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		sm := append(sig.MarshalStandard(), msg...)
+		if err := ioutil.WriteFile(smPath, sm, 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+
+		out, err3 := runReference(t, ctx, "open", keyPath, smPath)
+		if err3 != nil {
+			t.Fatalf("%s: reference rejected signature: %v", name, err3)
+		}
+		if !bytes.Equal(out, msg) {
+			t.Fatalf("%s: reference returned wrong message", name)
+		}
+	}
+}
+
+// Signatures created by the reference implementation should be accepted
+// by us.
+func TestInteropVerifyReference(t *testing.T) {
+	referenceDir(t)
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	msg := []byte("test message")
+	msgPath := filepath.Join(dir, "msg")
+	if err := ioutil.WriteFile(msgPath, msg, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	for _, name := range interopNames() {
+		ctx := NewContextFromName(name)
+		keyPair, err := runReference(t, ctx, "keypair", name)
+		if err != nil {
+			t.Fatalf("%s: reference keypair: %v", name, err)
+		}
+		keyPath := filepath.Join(dir, "keypair")
+		if err := ioutil.WriteFile(keyPath, keyPair, 0600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		sm, err := runReference(t, ctx, "sign", keyPath, msgPath)
+		if err != nil {
+			t.Fatalf("%s: reference sign: %v", name, err)
+		}
+
+		pkLen := 4 + 2*ctx.p.N
+		if len(keyPair) < int(pkLen) || len(sm) < int(ctx.SignatureSize()) {
+			t.Fatalf("%s: reference output is too short", name)
+		}
+		if err := ctx.RequireStandard(); err != nil {
+			t.Fatalf("%s: RequireStandard(): %v", name, err)
+		}
+		pk, err2 := ctx.UnmarshalPublicKey(keyPair[:pkLen])
+		if err2 != nil {
+			t.Fatalf("%s: UnmarshalPublicKey(): %v", name, err2)
+		}
+		sig, err2 := ctx.UnmarshalSignature(sm[:ctx.SignatureSize()])
+		if err2 != nil {
+			t.Fatalf("%s: UnmarshalSignature(): %v", name, err2)
+		}
+		if !bytes.Equal(sm[ctx.SignatureSize():], msg) {
+			t.Fatalf("%s: reference signed wrong message", name)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("%s: Verify(): %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package xmssmt
+
+// Contains the encoding/json support for Params, PublicKey and Signature.
+
+import (
+	"encoding/json"
+)
+
+// JSON representation of a public key or signature.
+type jsonEnvelope struct {
+	Alg string `json:"alg"`
+	Pk  []byte `json:"pk,omitempty"`
+	Sig []byte `json:"sig,omitempty"`
+}
+
+// Returns the name by which the instance is stored in JSON and envelopes:
+// the registered name if it has one and otherwise the name returned by
+// Params.String().
+func (ctx *Context) algName() string {
+	if name := ctx.Name(); name != "" {
+		return name
+	}
+	return ctx.p.String()
+}
+
+// Returns the Context for the name of the instance stored in JSON.
+func contextFromJSONName(name string) (*Context, Error) {
+	if name == "" {
+		return nil, errorf("Missing algorithm")
+	}
+	return NewContextFromName2(name)
+}
+
+// Encodes the parameters as a JSON string with their name,
+// eg. "XMSSMT-SHA2_20/2_256".
+func (p Params) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// Initializes the parameters from a JSON string as written by MarshalJSON.
+// For backwards compatibility, a JSON object with the fields of Params,
+// as encoding/json wrote it before Params had a MarshalJSON, is accepted
+// as well.
+func (p *Params) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '{' {
+		// Params without its methods, such that encoding/json decodes
+		// the fields.
+		type paramsFields Params
+		var fields paramsFields
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		ctx, err := NewContext(Params(fields))
+		if err != nil {
+			return err
+		}
+		*p = ctx.p
+		return nil
+	}
+
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	ctx, err := contextFromJSONName(name)
+	if err != nil {
+		return err
+	}
+	*p = ctx.p
+	return nil
+}
+
+// Encodes the public key as a JSON object
+//
+//   {"alg": "XMSSMT-SHA2_20/2_256", "pk": "<base64 of root ‖ pubSeed>"}.
+func (pk *PublicKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonEnvelope{Alg: pk.ctx.algName(), Pk: pk.MarshalRaw()})
+}
+
+// Initializes the public key from JSON as written by MarshalJSON.  For
+// backwards compatibility, a JSON string as written by MarshalText is
+// accepted as well.
+func (pk *PublicKey) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		return pk.UnmarshalText([]byte(text))
+	}
+
+	var env jsonEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return err
+	}
+	ctx, err := contextFromJSONName(env.Alg)
+	if err != nil {
+		return err
+	}
+	pk2, err := ctx.UnmarshalRawPublicKey(env.Pk)
+	if err != nil {
+		return err
+	}
+	*pk = *pk2
+	return nil
+}
+
+// Encodes the signature as a JSON object
+//
+//   {"alg": "XMSSMT-SHA2_20/2_256", "sig": "<base64 of signature>"},
+//
+// where the signature is encoded as described in the RFC.
+func (sig *Signature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonEnvelope{Alg: sig.ctx.algName(), Sig: sig.MarshalRaw()})
+}
+
+// Initializes the signature from JSON as written by MarshalJSON.
+func (sig *Signature) UnmarshalJSON(data []byte) error {
+	var env jsonEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return err
+	}
+	ctx, err := contextFromJSONName(env.Alg)
+	if err != nil {
+		return err
+	}
+	sig2, err := ctx.UnmarshalRawSignature(env.Sig)
+	if err != nil {
+		return err
+	}
+	*sig = *sig2
+	return nil
+}
@@ -0,0 +1,89 @@
+package xmssmt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := NewContextFromName("XMSSMT-SHAKE_20/4_256")
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+	msg := []byte("test message")
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+
+	type config struct {
+		Params    Params
+		PublicKey *PublicKey
+		Signature *Signature
+	}
+	buf, err2 := json.Marshal(config{ctx.Params(), pk, sig})
+	if err2 != nil {
+		t.Fatalf("json.Marshal(): %v", err2)
+	}
+	if !bytes.Contains(buf, []byte(`"alg":"XMSSMT-SHAKE_20/4_256"`)) {
+		t.Fatalf("JSON does not contain algorithm name: %s", buf)
+	}
+
+	var cfg config
+	if err2 = json.Unmarshal(buf, &cfg); err2 != nil {
+		t.Fatalf("json.Unmarshal(): %v", err2)
+	}
+	if cfg.Params != ctx.Params() {
+		t.Fatalf("Params unmarshaled as %v", cfg.Params)
+	}
+	if ok, err := cfg.PublicKey.Verify(cfg.Signature, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+
+	// Public keys encoded by MarshalText are accepted as well.
+	text, err2 := pk.MarshalText()
+	if err2 != nil {
+		t.Fatalf("MarshalText(): %v", err2)
+	}
+	var pk2 PublicKey
+	if err2 = json.Unmarshal([]byte(`"`+string(text)+`"`), &pk2); err2 != nil {
+		t.Fatalf("json.Unmarshal(): %v", err2)
+	}
+	if !bytes.Equal(pk2.root, pk.root) {
+		t.Fatalf("Public key unmarshaled incorrectly")
+	}
+
+	var sig2 Signature
+	if json.Unmarshal([]byte(`{"alg":"XMSSMT-SHAKE_20/4_256","sig":"AAAA"}`),
+		&sig2) == nil {
+		t.Fatalf("json.Unmarshal() accepted short signature")
+	}
+	// So are Params encoded as an object with their fields.
+	var params Params
+	err2 = json.Unmarshal([]byte(`{"Func":1,"N":32,"FullHeight":20,"D":4,`+
+		`"WotsW":16,"Prf":0}`), &params)
+	if err2 != nil {
+		t.Fatalf("json.Unmarshal(): %v", err2)
+	}
+	if params != ctx.Params() {
+		t.Fatalf("Params unmarshaled as %v", params)
+	}
+
+	if json.Unmarshal([]byte(`"XMSS-FOO"`), &cfg.Params) == nil {
+		t.Fatalf("json.Unmarshal() accepted unknown algorithm")
+	}
+}
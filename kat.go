@@ -0,0 +1,45 @@
+package xmssmt
+
+// Contains the helpers to compute known-answer tests.
+
+// Computes a known-answer test: derives the keypair from the seed, which
+// consists of skSeed, skPrf and pubSeed (each N bytes) in that order, and
+// signs msg with the given signature sequence number.  Returns the public
+// key as root followed by public seed and the signature as encoded by
+// Signature.MarshalStandard().  These are the formats used by the core
+// functions of the reference implementation.  The private key is kept in
+// memory and thrown away afterwards.
+//
+// With the seed 0, 1, 2, ..., 3N-1, the message 37 and the sequence number
+// 2^(FullHeight-1) this recomputes the test vectors of the reference
+// implementation.
+func (ctx *Context) KnownAnswer(seed []byte, seqNo SignatureSeqNo,
+	msg []byte) (pk, sig []byte, err Error) {
+	n := ctx.p.N
+	if len(seed) != int(3*n) {
+		return nil, nil, errorf("Seed should have length %d", 3*n)
+	}
+	if uint64(seqNo) > ctx.p.MaxSignatureSeqNo() {
+		return nil, nil, errorf("Signature sequence number out of bounds")
+	}
+
+	ctr := NewMemoryPrivateKeyContainer()
+	sk, pub, err := ctx.DeriveInto(ctr, seed[2*n:], seed[:n], seed[n:2*n])
+	if err != nil {
+		return nil, nil, err
+	}
+	defer sk.Close()
+
+	if err = sk.DangerousSetSeqNo(seqNo); err != nil {
+		return nil, nil, err
+	}
+	s, err := sk.Sign(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pk = make([]byte, 2*n)
+	copy(pk, pub.root)
+	copy(pk[n:], pub.pubSeed)
+	return pk, s.MarshalStandard(), nil
+}
@@ -0,0 +1,228 @@
+package xmssmt
+
+// Contains the KeyStore, which manages many private keys.
+
+import (
+	"sort"
+	"sync"
+)
+
+// Manages many private keys, for instance one per tenant, indexed by
+// their KeyID.
+//
+// Keys with the same parameters share a Context and with it the worker
+// goroutines used to generate subtrees, so the number of workers does not
+// grow with the number of keys.
+type KeyStore struct {
+	threads int    // number of workers for each Context
+	borrow  uint32 // see NewKeyStore
+
+	mux    sync.Mutex
+	keys   map[KeyID]*PrivateKey
+	ctxs   map[Params]*Context
+	closed bool // set by Close()
+
+	// Calls to Add(), Sign() and Remove() in progress, which Close() waits
+	// for before it closes the keys and Contexts.
+	inFlight sync.WaitGroup
+}
+
+// Creates a new KeyStore.
+//
+// threads is the number of worker goroutines for the keys with the same
+// parameters; see Context.Threads.  If borrow is not zero, the KeyStore
+// borrows that many signature sequence numbers at a time for each key,
+// see PrivateKey.SetAutoBorrow().  This speeds up signing at the cost of
+// losing up to borrow signatures for each key on a crash.
+//
+// NOTE Do not forget to Close() the KeyStore.
+func NewKeyStore(threads int, borrow uint32) *KeyStore {
+	return &KeyStore{
+		threads: threads,
+		borrow:  borrow,
+		keys:    make(map[KeyID]*PrivateKey),
+		ctxs:    make(map[Params]*Context),
+	}
+}
+
+// Returns the shared Context for the given parameters.  Requires ks.mux lock.
+func (ks *KeyStore) context(params Params) (*Context, Error) {
+	if ctx, ok := ks.ctxs[params]; ok {
+		return ctx, nil
+	}
+	ctx, err := NewContext(params)
+	if err != nil {
+		return nil, err
+	}
+	ctx.Threads = ks.threads
+	ks.ctxs[params] = ctx
+	return ctx, nil
+}
+
+// Registers a call in progress, unless the KeyStore is closed.  Call
+// ks.inFlight.Done() when done.
+func (ks *KeyStore) enter() Error {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+	if ks.closed {
+		return errorf("KeyStore is closed")
+	}
+	ks.inFlight.Add(1)
+	return nil
+}
+
+// Loads the private key from the filesystem container at the given path
+// and adds it to the KeyStore.  See LoadPrivateKey().
+func (ks *KeyStore) Open(path string) (KeyID, Error) {
+	ctr, err := OpenFSPrivateKeyContainer(path)
+	if err != nil {
+		return KeyID{}, err
+	}
+	return ks.Add(ctr)
+}
+
+// Loads the private key from the container and adds it to the KeyStore.
+//
+// NOTE Takes ownership of ctr, even if an error is returned.
+func (ks *KeyStore) Add(ctr PrivateKeyContainer) (KeyID, Error) {
+	params := ctr.Initialized()
+	if params == nil {
+		ctr.Close()
+		return KeyID{}, kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+
+	if err := ks.enter(); err != nil {
+		ctr.Close()
+		return KeyID{}, err
+	}
+	defer ks.inFlight.Done()
+
+	ks.mux.Lock()
+	ctx, err := ks.context(*params)
+	ks.mux.Unlock()
+	if err != nil {
+		ctr.Close()
+		return KeyID{}, err
+	}
+
+	// Loading might regenerate the cache, which takes a while, so we don't
+	// block the other keys meanwhile.
+	sk, pk, lostSigs, err := loadPrivateKeyFrom(ctx, ctr)
+	if err != nil {
+		ctr.Close()
+		return KeyID{}, err
+	}
+	if lostSigs != 0 {
+		log.Logf("Lost %d signatures of key %v", lostSigs, pk.KeyID())
+	}
+	sk.SetAutoBorrow(ks.borrow)
+
+	id := pk.KeyID()
+	ks.mux.Lock()
+	if ks.closed {
+		ks.mux.Unlock()
+		sk.Close()
+		return KeyID{}, errorf("KeyStore is closed")
+	}
+	if _, ok := ks.keys[id]; ok {
+		ks.mux.Unlock()
+		sk.Close()
+		return KeyID{}, errorf("Key %v is already in the KeyStore", id)
+	}
+	ks.keys[id] = sk
+	ks.mux.Unlock()
+	return id, nil
+}
+
+// Returns the private key with the given KeyID.
+func (ks *KeyStore) get(id KeyID) (*PrivateKey, Error) {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+	sk, ok := ks.keys[id]
+	if !ok {
+		return nil, errorf("No key %v in the KeyStore", id)
+	}
+	return sk, nil
+}
+
+// Signs the message with the key with the given KeyID.
+func (ks *KeyStore) Sign(id KeyID, msg []byte) (*Signature, Error) {
+	if err := ks.enter(); err != nil {
+		return nil, err
+	}
+	defer ks.inFlight.Done()
+	sk, err := ks.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return sk.Sign(msg)
+}
+
+// Returns the public key of the key with the given KeyID.
+func (ks *KeyStore) PublicKey(id KeyID) (*PublicKey, Error) {
+	sk, err := ks.get(id)
+	if err != nil {
+		return nil, err
+	}
+	return sk.PublicKey(), nil
+}
+
+// Returns the KeyIDs of the keys in the KeyStore in increasing order.
+func (ks *KeyStore) KeyIDs() []KeyID {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+	ret := make([]KeyID, 0, len(ks.keys))
+	for id := range ks.keys {
+		ret = append(ret, id)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return string(ret[i][:]) < string(ret[j][:])
+	})
+	return ret
+}
+
+// Closes the key with the given KeyID and removes it from the KeyStore.
+func (ks *KeyStore) Remove(id KeyID) Error {
+	if err := ks.enter(); err != nil {
+		return err
+	}
+	defer ks.inFlight.Done()
+	ks.mux.Lock()
+	sk, ok := ks.keys[id]
+	delete(ks.keys, id)
+	ks.mux.Unlock()
+	if !ok {
+		return errorf("No key %v in the KeyStore", id)
+	}
+	return sk.Close()
+}
+
+// Closes all keys and stops the worker goroutines, after the calls in
+// progress are done.  Returns the first error encountered, if any.  The
+// KeyStore can't be used afterwards.
+func (ks *KeyStore) Close() Error {
+	ks.mux.Lock()
+	if ks.closed {
+		ks.mux.Unlock()
+		return nil
+	}
+	ks.closed = true
+	ks.mux.Unlock()
+	ks.inFlight.Wait()
+
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+	var ret Error
+	for id, sk := range ks.keys {
+		if err := sk.Close(); err != nil && ret == nil {
+			ret = err
+		}
+		delete(ks.keys, id)
+	}
+	for params, ctx := range ks.ctxs {
+		ctx.Close()
+		delete(ks.ctxs, params)
+	}
+	return ret
+}
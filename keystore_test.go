@@ -0,0 +1,131 @@
+package xmssmt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestKeyStore(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+
+	var pks []*PublicKey
+	for i := 0; i < 3; i++ {
+		path := fmt.Sprintf("%s/key-%d", dir, i)
+		sk, pk, err := ctx.GenerateKeyPair(path)
+		if err != nil {
+			t.Fatalf("GenerateKeyPair(): %v", err)
+		}
+		if err = sk.Close(); err != nil {
+			t.Fatalf("Close(): %v", err)
+		}
+		pks = append(pks, pk)
+	}
+
+	ks := NewKeyStore(2, 10)
+	for i := 0; i < 3; i++ {
+		id, err := ks.Open(fmt.Sprintf("%s/key-%d", dir, i))
+		if err != nil {
+			t.Fatalf("Open(): %v", err)
+		}
+		if id != pks[i].KeyID() {
+			t.Fatalf("Open() returned the wrong KeyID")
+		}
+	}
+	if len(ks.KeyIDs()) != 3 || len(ks.ctxs) != 1 {
+		t.Fatalf("KeyStore should have 3 keys sharing one Context")
+	}
+
+	msg := []byte("test message")
+	for i := 0; i < 3; i++ {
+		sig, err := ks.Sign(pks[i].KeyID(), msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pks[i].Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+
+	if err = ks.Remove(pks[0].KeyID()); err != nil {
+		t.Fatalf("Remove(): %v", err)
+	}
+	if _, err = ks.Sign(pks[0].KeyID(), msg); err == nil {
+		t.Fatalf("Sign() with a removed key should fail")
+	}
+
+	if err = ks.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	if _, err = ks.Sign(pks[1].KeyID(), msg); err == nil {
+		t.Fatalf("Sign() on a closed KeyStore should fail")
+	}
+	if _, err = ks.Open(dir + "/key-0"); err == nil {
+		t.Fatalf("Open() on a closed KeyStore should fail")
+	}
+
+	// The borrowed signatures should have been returned on Close()
+	sk, _, lostSigs, err := LoadPrivateKey(dir + "/key-1")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if lostSigs != 0 || sk.SeqNo() != 1 {
+		t.Fatalf("Key has seqNo %d and %d lost signatures", sk.SeqNo(),
+			lostSigs)
+	}
+	sk.Close()
+}
+
+func TestKeyStoreCloseWhileAdding(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		sk, _, err := ctx.GenerateKeyPair(fmt.Sprintf("%s/key-%d", dir, i))
+		if err != nil {
+			t.Fatalf("GenerateKeyPair(): %v", err)
+		}
+		sk.Close()
+	}
+
+	ks := NewKeyStore(2, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			ks.Open(fmt.Sprintf("%s/key-%d", dir, i))
+			wg.Done()
+		}(i)
+	}
+	if err = ks.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	wg.Wait()
+	if len(ks.KeyIDs()) != 0 || len(ks.ctxs) != 0 {
+		t.Fatalf("Keys were added to a closed KeyStore")
+	}
+}
@@ -0,0 +1,140 @@
+package xmssmt
+
+// Contains the structures in which the WOTS+ secret keys can be derived
+// from the secret seed.
+
+// How the WOTS+ secret keys of a private key are derived from its secret
+// seed.  This only affects the private key: signatures and public keys
+// are the same for every KeyStructure.  See Context.SetKeyStructure().
+type KeyStructure uint8
+
+const (
+	// Every WOTS+ secret key is derived directly from the secret seed,
+	// as described in RFC 8391.  This is the default.
+	FlatKeyStructure KeyStructure = iota
+
+	// Every subtree has its own seed, from which the WOTS+ secret keys of
+	// its leafs are derived as they would be from the secret seed.  The
+	// seed of a subtree is derived from the seed of its parent and the seed
+	// of the root subtree from the secret seed:
+	//
+	//   seed(sta) = PRF(seed(parent of sta), ADRS)
+	//
+	// where ADRS is the address of the subtree sta with type
+	// ADDR_TYPE_SEED.  Thus the seed of a subtree gives the WOTS+ secret
+	// keys of the subtrees below it, but not of any other.  Private keys
+	// with this structure can't be used by other implementations.
+	PrfTreeKeyStructure
+)
+
+func (ks KeyStructure) String() string {
+	switch ks {
+	case FlatKeyStructure:
+		return "flat"
+	case PrfTreeKeyStructure:
+		return "prf-tree"
+	}
+	return "unknown"
+}
+
+// Optional interface for a PrivateKeyContainer (or SeqNoStore) that stores
+// the KeyStructure of its private key.  Only private keys with the
+// FlatKeyStructure can be stored in a container that does not implement
+// it.
+type KeyStructureStore interface {
+	// Returns the KeyStructure of the private key.
+	KeyStructure() (KeyStructure, Error)
+
+	// Sets the KeyStructure of the private key.  Reset() sets it to
+	// FlatKeyStructure.
+	SetKeyStructure(ks KeyStructure) Error
+}
+
+// Sets the KeyStructure of private keys generated or derived with this
+// Context.  The default is FlatKeyStructure.  Private keys that are loaded
+// use the KeyStructure stored in their container instead.
+//
+// The PrfTreeKeyStructure limits the damage of leaking the seed of a
+// subtree and allows handing out the signing capability of a few subtrees
+// compactly.  It is slightly slower: the seed of a subtree is derived for
+// every signature.
+func (ctx *Context) SetKeyStructure(ks KeyStructure) {
+	ctx.keyStructure = ks
+}
+
+// Returns the KeyStructure of private keys generated with this Context.
+// See SetKeyStructure().
+func (ctx *Context) KeyStructure() KeyStructure {
+	return ctx.keyStructure
+}
+
+// Returns the KeyStructure of the private key.
+func (sk *PrivateKey) KeyStructure() KeyStructure {
+	return sk.keyStructure
+}
+
+// Returns the KeyStructure stored in the container.
+func containerKeyStructure(ctr PrivateKeyContainer) (KeyStructure, Error) {
+	store, ok := ctr.(KeyStructureStore)
+	if !ok {
+		return FlatKeyStructure, nil
+	}
+	ks, err := store.KeyStructure()
+	if err != nil {
+		return 0, wrapErrorf(err, "Failed to get key structure")
+	}
+	if ks > PrfTreeKeyStructure {
+		return 0, kindErrorf(ErrMalformed, "Unknown key structure %d", ks)
+	}
+	return ks, nil
+}
+
+// Stores the KeyStructure in the container, which has just been reset.
+func setContainerKeyStructure(ctr PrivateKeyContainer, ks KeyStructure) Error {
+	if ks == FlatKeyStructure {
+		return nil
+	}
+	store, ok := ctr.(KeyStructureStore)
+	if !ok {
+		return errorf("Container can't store the %v key structure", ks)
+	}
+	return store.SetKeyStructure(ks)
+}
+
+// Returns the seed of the subtree sta for the PrfTreeKeyStructure.
+func (ctx *Context) subTreeSeed(pad scratchPad, skSeed []byte,
+	sta SubTreeAddress) []byte {
+	seed := make([]byte, ctx.p.N)
+	key := skSeed
+	for layer := ctx.p.D - 1; ; layer-- {
+		anc := SubTreeAddress{
+			Layer: layer,
+			Tree:  sta.Tree >> (ctx.treeHeight * (layer - sta.Layer)),
+		}
+		addr := anc.address()
+		addr.setType(ADDR_TYPE_SEED)
+		ctx.prfAddrInto(pad, addr, key, seed)
+		key = seed
+		if layer == sta.Layer {
+			return seed
+		}
+	}
+}
+
+// Returns the seed and precomputed hashes from which the WOTS+ secret keys
+// of the subtree sta are derived.  Call release() when done with them,
+// which wipes them if they are derived for sta.
+func (sk *PrivateKey) subTreeHashes(pad scratchPad, sta SubTreeAddress) (
+	skSeed []byte, ph precomputedHashes, release func()) {
+	if sk.keyStructure == FlatKeyStructure {
+		return sk.skSeed, sk.ph, func() {}
+	}
+	skSeed = sk.ctx.subTreeSeed(pad, sk.skSeed, sta)
+	ph = sk.ctx.precomputeHashes(sk.pubSeed, skSeed)
+	return skSeed, ph, func() {
+		zeroize(skSeed)
+		if ph.zeroizeSkState != nil {
+			ph.zeroizeSkState()
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestPrfTreeKeyStructure(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHA2, 16, 6, 3, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	seeds := make([]byte, 3*ctx.p.N)
+	for i := range seeds {
+		seeds[i] = byte(i)
+	}
+	n := ctx.p.N
+	pubSeed, skSeed, skPrf := seeds[:n], seeds[n:2*n], seeds[2*n:]
+	sk1, pk1, err := ctx.Derive(dir+"/key1", pubSeed, skSeed, skPrf)
+	if err != nil {
+		t.Fatalf("Derive(): %v", err)
+	}
+	defer sk1.Close()
+
+	ctx.SetKeyStructure(PrfTreeKeyStructure)
+	sk2, pk2, err := ctx.Derive(dir+"/key2", pubSeed, skSeed, skPrf)
+	if err != nil {
+		t.Fatalf("Derive(): %v", err)
+	}
+	if sk2.KeyStructure() != PrfTreeKeyStructure {
+		t.Fatalf("KeyStructure() is %v", sk2.KeyStructure())
+	}
+	if bytes.Equal(pk1.root, pk2.root) {
+		t.Fatalf("Key structures yield the same root")
+	}
+
+	// The seed of a subtree only depends on its ancestors.
+	pad := ctx.newScratchPad()
+	seed := ctx.subTreeSeed(pad, skSeed, SubTreeAddress{Layer: 1, Tree: 2})
+	sta := SubTreeAddress{Layer: 0, Tree: 9}
+	addr := sta.address()
+	addr.setType(ADDR_TYPE_SEED)
+	if !bytes.Equal(ctx.subTreeSeed(pad, skSeed, sta),
+		ctx.prfAddr(pad, addr, seed)) {
+		t.Fatalf("Seed of subtree is not derived from its parent")
+	}
+
+	msg := []byte("test message")
+	for i := 0; i < 10; i++ {
+		sig, err := sk2.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk2.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+	report, err := sk2.Check(sk2.SeqNo())
+	if err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if !report.RootOk {
+		t.Fatalf("Check() recomputed the wrong root")
+	}
+	if err = sk2.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	// The key structure is stored with the key.
+	sk2, pk3, _, err := LoadPrivateKey(dir + "/key2")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk2.Close()
+	if sk2.KeyStructure() != PrfTreeKeyStructure ||
+		!bytes.Equal(pk3.root, pk2.root) {
+		t.Fatalf("LoadPrivateKey() lost the key structure")
+	}
+	if report, err = sk2.Check(sk2.SeqNo()); err != nil || !report.RootOk {
+		t.Fatalf("Check() after load: %v", err)
+	}
+	sig, err := sk2.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk2.Verify(sig, msg); !ok {
+		t.Fatalf("Verify() after load: %v", err)
+	}
+
+	// The LayerSigner works with either key structure.
+	ls, err := sk2.DeriveLayerSigner(0, SubTreeRange{6, 7})
+	if err != nil {
+		t.Fatalf("DeriveLayerSigner(): %v", err)
+	}
+	ps, err := ls.SignPartial(25, msg)
+	if err != nil {
+		t.Fatalf("SignPartial(): %v", err)
+	}
+	cert, err := sk2.CertifySubTree(6)
+	if err != nil {
+		t.Fatalf("CertifySubTree(): %v", err)
+	}
+	sig, err = cert.Combine(ps)
+	if err != nil {
+		t.Fatalf("Combine(): %v", err)
+	}
+	if ok, err := pk2.Verify(sig, msg); !ok {
+		t.Fatalf("Verify() of LayerSigner signature: %v", err)
+	}
+
+	// Containers that can't store the key structure are refused.
+	ctr := NewSplitPrivateKeyContainer(
+		&noKeyStructureStore{&memorySeqNoStore{}}, NewMemorySubTreeCache())
+	if _, _, err = ctx.DeriveInto(ctr, pubSeed, skSeed, skPrf); err == nil {
+		t.Fatalf("DeriveInto() accepted container without key structure")
+	}
+}
+
+// SeqNoStore that does not implement KeyStructureStore.
+type noKeyStructureStore struct {
+	SeqNoStore
+}
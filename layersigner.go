@@ -0,0 +1,360 @@
+package xmssmt
+
+// Contains the LayerSigner: a signer restricted to a few subtrees, that
+// can be handed to an online machine.
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// Magic bytes at the start of an encoded LayerSigner.
+var layerSignerMagic = []byte("XLSG")
+
+// Current version of the encoding of a LayerSigner.  Version 1 lacks the
+// KeyStructure and only has the FlatKeyStructure.
+const layerSignerVersion = 2
+
+// A range [Start, End) of subtrees on a layer.
+type SubTreeRange struct {
+	Start, End uint64
+}
+
+// Creates partial signatures, see PartialSignature, with a restricted set
+// of subtrees on the lowest layer.  See PrivateKey.DeriveLayerSigner().
+type LayerSigner struct {
+	ctx          *Context
+	layer        uint32
+	keyStructure KeyStructure // that of the private key
+	pubSeed      []byte
+	root         []byte
+	skPrf        []byte // random key for the randomizers; not that of the key
+
+	subTrees map[uint64]*layerSubTree
+}
+
+// A subtree of a LayerSigner.  Its WOTS+ secret keys are derived from
+// the seed of the subtree with the PrfTreeKeyStructure and otherwise
+// stored in full.
+type layerSubTree struct {
+	mt      merkleTree
+	seed    []byte // the seed of the subtree; see PrfTreeKeyStructure
+	wotsSks []byte // the WOTS+ secret keys of all leafs, in order
+}
+
+// Returns a signer that can only create signatures with the subtrees in
+// the given ranges on the given layer.  Combined with CertifySubTree(),
+// this splits the private key like an offline root CA and an online
+// intermediate CA: if the machine with the LayerSigner is compromised,
+// only the signature sequence numbers of its subtrees are burnt.
+//
+// The LayerSigner does not contain the seeds of the private key.  With the
+// PrfTreeKeyStructure, it contains the seed of each of its subtrees, see
+// SetKeyStructure(), and otherwise the WOTS+ secret keys of every leaf of
+// its subtrees, which is 2^h·len·N bytes per subtree, where h is the height
+// of a subtree and len the number of WOTS+ chains.  Neither can be used to
+// derive the WOTS+ secret keys of the other subtrees on the lowest layer.
+// As they would give those of the subtrees below, only subtrees on the
+// lowest layer (0) can be delegated.
+//
+// The signature sequence numbers of the subtrees must not be used by this
+// private key anymore: use SetSeqNoRange() to keep it out of them.
+func (sk *PrivateKey) DeriveLayerSigner(layer uint32,
+	ranges ...SubTreeRange) (*LayerSigner, Error) {
+	ctx := sk.ctx
+	if layer != 0 {
+		return nil, errorf("Only subtrees on layer 0 can be delegated")
+	}
+	maxTree := uint64(1) << (ctx.p.FullHeight - ctx.treeHeight)
+	for _, r := range ranges {
+		if r.Start >= r.End || r.End > maxTree {
+			return nil, errorf("Subtree range [%d, %d) is invalid",
+				r.Start, r.End)
+		}
+	}
+
+	ls := LayerSigner{
+		ctx:          ctx,
+		layer:        layer,
+		keyStructure: sk.keyStructure,
+		pubSeed:      sk.pubSeed,
+		root:         sk.root,
+		skPrf:        make([]byte, ctx.p.N),
+		subTrees:     make(map[uint64]*layerSubTree),
+	}
+	if _, err := rand.Read(ls.skPrf); err != nil {
+		return nil, wrapErrorf(err, "Failed to generate randomizer key")
+	}
+
+	pad := ctx.getScratchPad()
+	defer ctx.putScratchPad(pad)
+	skBytes := ctx.wotsSigBytes
+	for _, r := range ranges {
+		for tree := r.Start; tree < r.End; tree++ {
+			if _, ok := ls.subTrees[tree]; ok {
+				continue
+			}
+			sta := SubTreeAddress{Layer: layer, Tree: tree}
+			mt, _, err := sk.getSubTree(*pad, sta)
+			if err != nil {
+				ls.Zeroize()
+				return nil, err
+			}
+			var st layerSubTree
+			skSeed, ph, release := sk.subTreeHashes(*pad, sta)
+			if mt.lowest == 0 {
+				st.mt = merkleTreeFromBuf(append([]byte{}, mt.buf...),
+					ctx.treeHeight+1, ctx.p.N)
+			} else {
+				// Not all nodes are cached, see SetLowestCachedHeight().
+				st.mt = newMerkleTree(ctx.treeHeight+1, ctx.p.N)
+				ctx.genSubTreeInto(*pad, skSeed, ph, sta, st.mt)
+			}
+			if sk.keyStructure == PrfTreeKeyStructure {
+				st.seed = append([]byte{}, skSeed...)
+			} else {
+				st.wotsSks = make([]byte, skBytes<<ctx.treeHeight)
+				otsAddr := sta.address()
+				for leaf := uint32(0); leaf < 1<<ctx.treeHeight; leaf++ {
+					otsAddr.setOTS(leaf)
+					ctx.genWotsSk(*pad, ph, otsAddr,
+						st.wotsSks[leaf*skBytes:(leaf+1)*skBytes])
+				}
+			}
+			release()
+			ls.subTrees[tree] = &st
+		}
+	}
+	return &ls, nil
+}
+
+// Returns whether the LayerSigner can sign with the given signature
+// sequence number.
+func (ls *LayerSigner) Covers(seqNo SignatureSeqNo) bool {
+	if uint64(seqNo) > ls.ctx.p.MaxSignatureSeqNo() {
+		return false
+	}
+	_, ok := ls.subTrees[uint64(seqNo)>>ls.ctx.treeHeight]
+	return ok
+}
+
+// Returns the public key of the private key the LayerSigner was derived
+// from.  Signatures assembled from its partial signatures verify under it.
+func (ls *LayerSigner) PublicKey() *PublicKey {
+	pk := PublicKey{
+		ctx:     ls.ctx,
+		pubSeed: ls.pubSeed,
+		root:    ls.root,
+	}
+	pk.ph = ls.ctx.precomputeHashes(pk.pubSeed, nil)
+	return &pk
+}
+
+// Signs the given message with the given signature sequence number, which
+// must be covered by the LayerSigner.  Combine the partial signature with
+// the certificate of its subtree, see PrivateKey.CertifySubTree().
+//
+// NOTE The LayerSigner does not keep track of the signature sequence
+// numbers it used.  The caller must never use the same one twice, for
+// instance by storing the next one before the signature is returned.
+func (ls *LayerSigner) SignPartial(seqNo SignatureSeqNo, msg []byte) (
+	*PartialSignature, Error) {
+	ctx := ls.ctx
+	if err := ctx.checkMessageContext(nil); err != nil {
+		return nil, err
+	}
+	if ls.subTrees == nil {
+		return nil, errorf("LayerSigner has been zeroized")
+	}
+	if !ls.Covers(seqNo) {
+		return nil, kindErrorf(ErrExhausted,
+			"Signature sequence number %d is not covered by the LayerSigner",
+			seqNo)
+	}
+	_, leafs := ctx.subTreePathForSeqNo(seqNo)
+	tree := uint64(seqNo) >> ctx.treeHeight
+	st := ls.subTrees[tree]
+
+	pad := ctx.getScratchPad()
+	defer ctx.putScratchPad(pad)
+	n := ctx.p.N
+	buf := make([]byte, n+ctx.subTreeSigBytes())
+	ps := PartialSignature{
+		ctx:   ctx,
+		seqNo: seqNo,
+		drv:   buf[:n:n],
+		sig: subTreeSig{
+			wotsSig:  buf[n : n+ctx.wotsSigBytes : n+ctx.wotsSigBytes],
+			authPath: buf[n+ctx.wotsSigBytes:],
+		},
+	}
+	ctx.prfUint64Into(*pad, uint64(seqNo), ls.skPrf, ps.drv)
+	if err := ctx.mixRandomizerEntropy(ps.drv); err != nil {
+		return nil, err
+	}
+	mhash := pad.nodeBuf
+	err := ctx.hashMessageInto(*pad, nil, bytes.NewReader(msg), ps.drv,
+		ls.root, uint64(seqNo), mhash)
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to hash message")
+	}
+
+	var ph precomputedHashes
+	if st.seed != nil {
+		ph = ctx.precomputeHashes(ls.pubSeed, st.seed)
+		if ph.zeroizeSkState != nil {
+			defer ph.zeroizeSkState()
+		}
+	} else {
+		// Instead of deriving the WOTS+ secret keys from a seed, look them
+		// up.
+		ph = ctx.precomputeHashes(ls.pubSeed, nil)
+		ph.prfKeyGenInto = func(pad scratchPad, addr address, out []byte) {
+			off := (addr[4]*ctx.wotsLen + addr[5]) * n
+			copy(out, st.wotsSks[off:off+n])
+		}
+	}
+	sta := SubTreeAddress{Layer: ls.layer, Tree: tree}
+	otsAddr := sta.address()
+	otsAddr.setOTS(leafs[0])
+	ctx.wotsSignInto(*pad, mhash, ph, otsAddr, ps.sig.wotsSig)
+	st.mt.AuthPathInto(leafs[0], ps.sig.authPath)
+	return &ps, nil
+}
+
+// Wipes the seeds or WOTS+ secret keys of the subtrees and the randomizer
+// key of the LayerSigner from memory.  It can't sign afterwards.
+func (ls *LayerSigner) Zeroize() {
+	zeroize(ls.skPrf)
+	for _, st := range ls.subTrees {
+		zeroize(st.seed)
+		zeroize(st.wotsSks)
+	}
+	ls.subTrees = nil
+}
+
+// Returns the LayerSigner encoded for transfer to the machine that will
+// sign with it.  Decode it with UnmarshalLayerSigner().
+//
+// NOTE The encoding contains the seeds or WOTS+ secret keys of the
+//      subtrees: keep it secret.
+func (ls *LayerSigner) MarshalBinary() ([]byte, error) {
+	ctx := ls.ctx
+	params, err := ctx.p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	n := int(ctx.p.N)
+	stLen := 8 + ctx.p.BareSubTreeSize() + ls.subTreeSecretSize()
+	ret := make([]byte, 0, len(layerSignerMagic)+1+4+4+1+3*n+4+
+		len(ls.subTrees)*stLen)
+	ret = append(ret, layerSignerMagic...)
+	ret = append(ret, layerSignerVersion)
+	ret = append(ret, params...)
+	ret = append(ret, encodeUint64(uint64(ls.layer), 4)...)
+	ret = append(ret, byte(ls.keyStructure))
+	ret = append(ret, ls.pubSeed...)
+	ret = append(ret, ls.root...)
+	ret = append(ret, ls.skPrf...)
+	ret = append(ret, encodeUint64(uint64(len(ls.subTrees)), 4)...)
+	for tree, st := range ls.subTrees {
+		ret = append(ret, encodeUint64(tree, 8)...)
+		ret = append(ret, st.mt.buf...)
+		ret = append(ret, st.seed...)
+		ret = append(ret, st.wotsSks...)
+	}
+	return ret, nil
+}
+
+// Returns the size of the seed or WOTS+ secret keys of a subtree in the
+// encoding of the LayerSigner.
+func (ls *LayerSigner) subTreeSecretSize() int {
+	if ls.keyStructure == PrfTreeKeyStructure {
+		return int(ls.ctx.p.N)
+	}
+	return int(ls.ctx.wotsSigBytes << ls.ctx.treeHeight)
+}
+
+// Decodes a LayerSigner encoded by LayerSigner.MarshalBinary().
+func UnmarshalLayerSigner(buf []byte) (*LayerSigner, Error) {
+	m := len(layerSignerMagic)
+	if len(buf) < m+1+4+4 {
+		return nil, kindErrorf(ErrMalformed, "LayerSigner is too short")
+	}
+	if !bytes.Equal(buf[:m], layerSignerMagic) {
+		return nil, kindErrorf(ErrWrongMagic, "Not a LayerSigner")
+	}
+	version := buf[m]
+	if version != 1 && version != layerSignerVersion {
+		return nil, errorf("Unsupported LayerSigner version %d", version)
+	}
+	var params Params
+	if err := params.UnmarshalBinary(buf[m+1 : m+5]); err != nil {
+		return nil, wrapErrorf(err, "LayerSigner has invalid parameters")
+	}
+	ctx, err := NewContext(params)
+	if err != nil {
+		return nil, err
+	}
+	layer := binary.BigEndian.Uint32(buf[m+5 : m+9])
+	if layer != 0 {
+		return nil, kindErrorf(ErrMalformed, "LayerSigner has layer %d", layer)
+	}
+	buf = buf[m+9:]
+
+	keyStructure := FlatKeyStructure
+	if version != 1 {
+		if len(buf) < 1 {
+			return nil, kindErrorf(ErrMalformed, "LayerSigner is too short")
+		}
+		keyStructure = KeyStructure(buf[0])
+		if keyStructure > PrfTreeKeyStructure {
+			return nil, kindErrorf(ErrMalformed,
+				"Unknown key structure %d", keyStructure)
+		}
+		buf = buf[1:]
+	}
+
+	n := int(ctx.p.N)
+	if len(buf) < 3*n+4 {
+		return nil, kindErrorf(ErrMalformed, "LayerSigner is too short")
+	}
+	ls := LayerSigner{
+		ctx:          ctx,
+		layer:        layer,
+		keyStructure: keyStructure,
+		pubSeed:      append([]byte{}, buf[:n]...),
+		root:         append([]byte{}, buf[n:2*n]...),
+		skPrf:        append([]byte{}, buf[2*n:3*n]...),
+		subTrees:     make(map[uint64]*layerSubTree),
+	}
+	count := uint64(binary.BigEndian.Uint32(buf[3*n : 3*n+4]))
+	buf = buf[3*n+4:]
+	mtLen := ctx.p.BareSubTreeSize()
+	sksLen := ls.subTreeSecretSize()
+	if uint64(len(buf)) != count*uint64(8+mtLen+sksLen) {
+		return nil, kindErrorf(ErrMalformed, "LayerSigner has wrong length")
+	}
+	maxTree := uint64(1) << (ctx.p.FullHeight - ctx.treeHeight)
+	for i := uint64(0); i < count; i++ {
+		tree := binary.BigEndian.Uint64(buf[:8])
+		if tree >= maxTree {
+			return nil, kindErrorf(ErrMalformed,
+				"Subtree %d is out of range", tree)
+		}
+		st := &layerSubTree{
+			mt: merkleTreeFromBuf(append([]byte{}, buf[8:8+mtLen]...),
+				ctx.treeHeight+1, ctx.p.N),
+		}
+		secret := append([]byte{}, buf[8+mtLen:8+mtLen+sksLen]...)
+		if keyStructure == PrfTreeKeyStructure {
+			st.seed = secret
+		} else {
+			st.wotsSks = secret
+		}
+		ls.subTrees[tree] = st
+		buf = buf[8+mtLen+sksLen:]
+	}
+	return &ls, nil
+}
@@ -0,0 +1,93 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLayerSigner(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var flatLen int
+	for _, ks := range []KeyStructure{FlatKeyStructure, PrfTreeKeyStructure} {
+		ctx, err := NewContext(Params{SHA2, 16, 6, 3, 16, RFC})
+		if err != nil {
+			t.Fatalf("NewContext(): %v", err)
+		}
+		ctx.SetKeyStructure(ks)
+		sk, pk, err := ctx.GenerateKeyPair(dir + "/key-" + ks.String())
+		if err != nil {
+			t.Fatalf("GenerateKeyPair(): %v", err)
+		}
+
+		// Delegate the subtrees 2, 3 and 5 on the lowest layer.
+		ls, err := sk.DeriveLayerSigner(0, SubTreeRange{2, 4},
+			SubTreeRange{5, 6})
+		if err != nil {
+			t.Fatalf("DeriveLayerSigner(): %v", err)
+		}
+		buf, err2 := ls.MarshalBinary()
+		if err2 != nil {
+			t.Fatalf("MarshalBinary(): %v", err2)
+		}
+		ls, err = UnmarshalLayerSigner(buf)
+		if err != nil {
+			t.Fatalf("UnmarshalLayerSigner(): %v", err)
+		}
+
+		// Only the seeds of the subtrees are handed out, if possible.
+		if ks == FlatKeyStructure {
+			flatLen = len(buf)
+		} else if len(buf) >= flatLen/2 {
+			t.Fatalf("LayerSigner with %v key structure is %d bytes",
+				ks, len(buf))
+		}
+
+		msg := []byte("test message")
+		for _, seqNo := range []SignatureSeqNo{8, 9, 15, 20, 23} {
+			ps, err := ls.SignPartial(seqNo, msg)
+			if err != nil {
+				t.Fatalf("SignPartial(%d): %v", seqNo, err)
+			}
+			cert, err := sk.CertifySubTree(ps.SubTree())
+			if err != nil {
+				t.Fatalf("CertifySubTree(): %v", err)
+			}
+			sig, err := cert.Combine(ps)
+			if err != nil {
+				t.Fatalf("Combine(): %v", err)
+			}
+			for _, pk := range []*PublicKey{pk, ls.PublicKey()} {
+				ok, err := pk.Verify(sig, msg)
+				if !ok {
+					t.Fatalf("Verify() rejected signature %d: %v", seqNo, err)
+				}
+			}
+		}
+
+		for _, seqNo := range []SignatureSeqNo{0, 7, 16, 24, 64} {
+			if ls.Covers(seqNo) {
+				t.Fatalf("LayerSigner covers %d", seqNo)
+			}
+			if _, err = ls.SignPartial(seqNo, msg); err == nil {
+				t.Fatalf("SignPartial() accepted %d", seqNo)
+			}
+		}
+		if _, err = sk.DeriveLayerSigner(1, SubTreeRange{0, 1}); err == nil {
+			t.Fatalf("DeriveLayerSigner() accepted layer 1")
+		}
+		if _, err = sk.DeriveLayerSigner(0, SubTreeRange{15, 17}); err == nil {
+			t.Fatalf("DeriveLayerSigner() accepted range out of bounds")
+		}
+		ls.Zeroize()
+		sk.Close()
+	}
+}
@@ -0,0 +1,58 @@
+package xmssmt
+
+// Contains the option to set the number of leafs computed per job.
+
+// Largest batch height chosen by default.
+const maxDefaultLeafBatchHeight = 5
+
+// Sets the number of leafs of a subtree that are computed in a single
+// job on the workers to 2^height.  Each job also computes the internal
+// nodes of the small subtree on top of its leafs.  Passing 0 restores the
+// default, which picks the batch size based on the number of workers and
+// the cost of computing a leaf.  Has to be called before the Context is
+// used.
+//
+// Larger batches have less overhead, but smaller batches spread the work
+// better over the workers: with 64 workers and subtrees of height 10,
+// batches of 32 leafs would leave half of the workers idle.
+func (ctx *Context) SetLeafBatchHeight(height uint32) {
+	ctx.batchHeight = height
+}
+
+// Returns the number of leafs computed per job on the workers as a power
+// of two, see SetLeafBatchHeight().
+func (ctx *Context) LeafBatchHeight() uint32 {
+	return ctx.leafBatchHeightFor(1 << ctx.treeHeight)
+}
+
+// Returns the batch height to use for computing total leafs.
+func (ctx *Context) leafBatchHeightFor(total uint32) uint32 {
+	height := ctx.batchHeight
+	if height == 0 {
+		height = ctx.defaultLeafBatchHeight(total)
+	}
+	for height > 0 && uint32(1)<<height > total {
+		height--
+	}
+	return height
+}
+
+// Picks the largest batch height such that every worker gets at least
+// four batches, so that workers that finish early can take over some of
+// the work of the others.
+//
+// A leaf costs about wotsLen*(w-1) hashes, which takes much longer than
+// handing a job to a worker.  Only when leafs are cheap, as with small N
+// and w, the batches are kept large for the sake of the overhead.
+func (ctx *Context) defaultLeafBatchHeight(total uint32) uint32 {
+	var minHeight uint32
+	if ctx.p.N*ctx.wotsLen*uint32(ctx.p.WotsW-1) < 1<<14 {
+		minHeight = 3
+	}
+	jobs := uint64(4 * ctx.threads())
+	height := uint32(maxDefaultLeafBatchHeight)
+	for height > minHeight && uint64(total)>>height < jobs {
+		height--
+	}
+	return height
+}
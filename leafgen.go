@@ -0,0 +1,101 @@
+package xmssmt
+
+// Contains the hook to offload the generation of leafs, which is the bulk
+// of the work of generating a subtree, to a GPU or remote service.
+
+import (
+	"sync"
+)
+
+// Computes the leafs of subtrees: the L-trees of the WOTS+ public keys.
+// See Context.SetLeafGenerator().
+//
+// NOTE The methods are called from different goroutines concurrently.
+type LeafGenerator interface {
+	// Computes the leafs with index start, start+1, ..., start+k-1 of the
+	// subtree sta, where k = len(out)/N, for the parameters of the
+	// Context and writes them consecutively into out.
+	GenerateLeafs(pubSeed, skSeed []byte, sta SubTreeAddress, start uint32,
+		out []byte) Error
+}
+
+// Sets the LeafGenerator used to compute the leafs of subtrees, which is
+// the bulk of the work of key generation and signing.  Passing nil restores
+// the default, which computes the leafs on the workers of the Context.
+// If the LeafGenerator returns an error, the leafs are computed by the
+// default instead.
+//
+// The LeafGenerator is not used for private keys of which only the upper
+// nodes of subtrees are cached, see SetLowestCachedHeight(): their subtrees
+// are computed in small batches, for which it is not worth the overhead.
+//
+// NOTE The LeafGenerator is passed the secret seed of the private key:
+//      only use one that is trusted with it.
+func (ctx *Context) SetLeafGenerator(g LeafGenerator) {
+	ctx.leafGenerator = g
+}
+
+// Returns a LeafGenerator that computes the leafs on the workers of the
+// Context, as is done by default.  This is useful for implementing, for
+// instance, the server side of a remote LeafGenerator.
+func (ctx *Context) CPULeafGenerator() LeafGenerator {
+	return cpuLeafGenerator{ctx}
+}
+
+type cpuLeafGenerator struct {
+	ctx *Context
+}
+
+func (g cpuLeafGenerator) GenerateLeafs(pubSeed, skSeed []byte,
+	sta SubTreeAddress, start uint32, out []byte) Error {
+	ctx := g.ctx
+	n := ctx.p.N
+	if len(pubSeed) != int(n) || len(skSeed) != int(n) {
+		return errorf("pubSeed and skSeed should have length %d", n)
+	}
+	if len(out)%int(n) != 0 {
+		return errorf("Length of out should be a multiple of %d", n)
+	}
+	count := uint32(len(out)) / n
+	if uint64(start)+uint64(count) > 1<<ctx.treeHeight {
+		return errorf("Leafs out of range")
+	}
+
+	ph := ctx.precomputeHashes(pubSeed, skSeed)
+	wg := &sync.WaitGroup{}
+	pool := ctx.workerPool()
+	perBatch := uint32(1) << ctx.leafBatchHeightFor(count)
+	for idx := uint32(0); idx < count; idx += perBatch {
+		end := idx + perBatch
+		if end > count {
+			end = count
+		}
+		wg.Add(1)
+		first := idx
+		pool.jobs <- func(pad scratchPad) {
+			ctx.genLeafsInto(pad, ph, sta, start+first,
+				out[first*n:end*n])
+			wg.Done()
+		}
+	}
+	wg.Wait()
+	return nil
+}
+
+// Computes the leafs with index start, start+1, ... of the subtree sta and
+// writes them consecutively into out.
+func (ctx *Context) genLeafsInto(pad scratchPad, ph precomputedHashes,
+	sta SubTreeAddress, start uint32, out []byte) {
+	var otsAddr, lTreeAddr address
+	addr := sta.address()
+	otsAddr.setSubTreeFrom(addr)
+	otsAddr.setType(ADDR_TYPE_OTS)
+	lTreeAddr.setSubTreeFrom(addr)
+	lTreeAddr.setType(ADDR_TYPE_LTREE)
+	n := ctx.p.N
+	for i := uint32(0); i < uint32(len(out))/n; i++ {
+		lTreeAddr.setLTree(start + i)
+		otsAddr.setOTS(start + i)
+		ctx.genLeafInto(pad, ph, lTreeAddr, otsAddr, out[i*n:(i+1)*n])
+	}
+}
@@ -0,0 +1,87 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+type testLeafGenerator struct {
+	inner LeafGenerator
+	calls uint32
+	fail  bool
+}
+
+func (g *testLeafGenerator) GenerateLeafs(pubSeed, skSeed []byte,
+	sta SubTreeAddress, start uint32, out []byte) Error {
+	atomic.AddUint32(&g.calls, 1)
+	if g.fail {
+		return errorf("Failing on purpose")
+	}
+	return g.inner.GenerateLeafs(pubSeed, skSeed, sta, start, out)
+}
+
+func TestLeafGenerator(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	params := Params{SHAKE, 16, 8, 2, 16, RFC}
+	seed := make([]byte, 16)
+	ctx, _ := NewContext(params)
+	sk, pk, err := ctx.Derive(dir+"/key1", seed, seed, seed)
+	if err != nil {
+		t.Fatalf("Derive(): %v", err)
+	}
+	sk.Close()
+
+	for _, fail := range []bool{false, true} {
+		ctx2, _ := NewContext(params)
+		gen := &testLeafGenerator{inner: ctx2.CPULeafGenerator(), fail: fail}
+		ctx2.SetLeafGenerator(gen)
+		os.RemoveAll(dir + "/key2")
+		os.RemoveAll(dir + "/key2.cache")
+		sk2, pk2, err := ctx2.Derive(dir+"/key2", seed, seed, seed)
+		if err != nil {
+			t.Fatalf("Derive(): %v", err)
+		}
+		testSignThenVerify(sk2, pk2, t)
+		sk2.Close()
+		if gen.calls == 0 {
+			t.Fatalf("LeafGenerator was not used")
+		}
+		if !bytes.Equal(pk.root, pk2.root) {
+			t.Fatalf("LeafGenerator (fail=%v) changed the root", fail)
+		}
+	}
+}
+
+func TestCPULeafGenerator(t *testing.T) {
+	ctx, _ := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	pubSeed := make([]byte, 16)
+	skSeed := make([]byte, 16)
+	sta := SubTreeAddress{Layer: 1, Tree: 0}
+	out := make([]byte, 16*5)
+	err := ctx.CPULeafGenerator().GenerateLeafs(pubSeed, skSeed, sta, 3, out)
+	if err != nil {
+		t.Fatalf("GenerateLeafs(): %v", err)
+	}
+	for i := uint32(0); i < 5; i++ {
+		wotsPk, _ := ctx.WotsPublicKey(pubSeed, skSeed, WotsAddress(sta, 3+i))
+		leaf, _ := ctx.LTree(wotsPk, pubSeed, sta, 3+i)
+		if !bytes.Equal(leaf, out[16*i:16*(i+1)]) {
+			t.Fatalf("Leaf %d is wrong", 3+i)
+		}
+	}
+	err = ctx.CPULeafGenerator().GenerateLeafs(pubSeed, skSeed, sta, 14, out)
+	if err == nil {
+		t.Fatalf("GenerateLeafs() allowed leafs out of range")
+	}
+}
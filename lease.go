@@ -0,0 +1,270 @@
+package xmssmt
+
+// Contains the cooperative mode of the filesystem container, in which
+// several processes share a private key by leasing disjoint ranges of
+// signature sequence numbers.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Number of signature sequence numbers leased at once by default.
+// See PrivateKey.SetLeaseSize().
+const DefaultLeaseSize = 1024
+
+// How long to wait for the lock on the key file when leasing.
+const leaseLockTimeout = 10 * time.Second
+
+// Serializes the leases of this process from the same key file, indexed
+// by its path.  The lock on the key file does not, as it can be taken
+// again from the same process.
+var leaseMuxes = struct {
+	mux   sync.Mutex
+	muxes map[string]*sync.Mutex
+}{muxes: make(map[string]*sync.Mutex)}
+
+// Returns the mutex that serializes the leases from the key file at path.
+func leaseMux(path string) *sync.Mutex {
+	leaseMuxes.mux.Lock()
+	defer leaseMuxes.mux.Unlock()
+	mux, ok := leaseMuxes.muxes[path]
+	if !ok {
+		mux = &sync.Mutex{}
+		leaseMuxes.muxes[path] = mux
+	}
+	return mux
+}
+
+// A PrivateKeyContainer shared by several processes, each of which leases
+// disjoint ranges of signature sequence numbers, implements SeqNoLeaser.
+// A PrivateKey loaded from such a container leases a new range whenever it
+// has used up its current one.
+type SeqNoLeaser interface {
+	// Leases a range [start, end) of at most amount unused signature
+	// sequence numbers for exclusive use by this process.  The
+	// sequence number of the container is set to start.
+	LeaseSeqNos(amount uint32) (start, end SignatureSeqNo, err Error)
+}
+
+// SeqNoStore that leases ranges of sequence numbers from a key file
+// of the filesystem container.
+type fsLeaseStore struct {
+	path         string
+	params       *Params
+	privateKey   []byte
+	keyStructure KeyStructure
+	root         []byte
+	seqNo        SignatureSeqNo // next sequence number to use
+	end          SignatureSeqNo // end of the current lease
+}
+
+// PrivateKeyContainer in which the sequence numbers are leased from
+// a key file and the subtrees are cached in memory.
+type fsLeaseContainer struct {
+	splitContainer
+	store *fsLeaseStore
+}
+
+// Opens the private key stored by the filesystem container at path in
+// cooperative mode, in which several processes, for instance the old and
+// new version during a blue/green deployment, can use the private key at
+// the same time.
+//
+// Instead of locking the key file for as long as the container is open,
+// the key file is only locked briefly to lease a range of signature
+// sequence numbers: the sequence number in the key file is advanced past
+// the range, which is synced to disk, and the lease is recorded in the
+// file path.leases.  A process that opens the key file with
+// OpenFSPrivateKeyContainer() starts after all leases, and no new leases
+// can be taken while it has the key file open.  Sequence numbers left in
+// a lease when a process exits are lost.
+//
+// The subtrees are cached in memory by each process.
+func OpenFSLeasedPrivateKeyContainer(path string) (
+	PrivateKeyContainer, Error) {
+	absPath, err2 := filepath.Abs(path)
+	if err2 != nil {
+		return nil, wrapErrorf(err2,
+			"Could not turn %s into an absolute path", path)
+	}
+	path = absPath
+	ctr := fsContainer{path: path}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, errorf("%s does not exist", path)
+	}
+	if err := ctr.readKey(); err != nil {
+		return nil, err
+	}
+	store := &fsLeaseStore{
+		path:         path,
+		params:       &ctr.params,
+		privateKey:   ctr.privateKey,
+		keyStructure: ctr.keyStructure,
+		root:         ctr.root,
+		seqNo:        ctr.seqNo,
+		end:          ctr.seqNo, // no lease yet
+	}
+	return &fsLeaseContainer{
+		splitContainer: splitContainer{
+			store: store,
+			cache: NewMemorySubTreeCache(),
+		},
+		store: store,
+	}, nil
+}
+
+func (ctr *fsLeaseContainer) LeaseSeqNos(amount uint32) (
+	start, end SignatureSeqNo, err Error) {
+	return ctr.store.lease(amount)
+}
+
+// Leases a new range of sequence numbers from the key file.
+func (store *fsLeaseStore) lease(amount uint32) (
+	start, end SignatureSeqNo, err Error) {
+	mux := leaseMux(store.path)
+	mux.Lock()
+	defer mux.Unlock()
+
+	lockFilePath := store.path + ".lock"
+	flock, err2 := newFileLock(lockFilePath)
+	if err2 != nil {
+		return 0, 0, wrapErrorf(err2,
+			"Failed to create lockfile %s", lockFilePath)
+	}
+
+	// Other processes only hold the lock briefly, unless the key file is
+	// opened with OpenFSPrivateKeyContainer().  If this process has it
+	// opened so, we must neither lease nor release its lock.
+	deadline := time.Now().Add(leaseLockTimeout)
+	for {
+		err2 = tryLockExclusively(flock)
+		if err2 == nil {
+			break
+		}
+		if _, ok := err2.(interface {
+			Temporary() bool
+		}); !ok || time.Now().After(deadline) {
+			err3 := wrapErrorf(err2, "%s is locked", store.path)
+			err3.kind = ErrLocked
+			return 0, 0, err3
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer flock.Unlock()
+
+	ctr := fsContainer{path: store.path}
+	if err = ctr.readKey(); err != nil {
+		return 0, 0, err
+	}
+
+	max := SignatureSeqNo(ctr.params.MaxSignatureSeqNo())
+	start = ctr.seqNo
+	if start >= max {
+		return 0, 0, kindErrorf(ErrExhausted, "No unused signatures left")
+	}
+	end = start + SignatureSeqNo(amount)
+	if end > max || end < start {
+		end = max
+	}
+
+	// First advance the sequence number in the key file, so that the
+	// range can't be leased twice even if we crash now.
+	ctr.seqNo = end
+	if err = ctr.writeKeyFile(true); err != nil {
+		return 0, 0, err
+	}
+
+	if err = store.recordLease(start, end); err != nil {
+		log.Logf("Failed to record lease: %v", err)
+	}
+
+	store.seqNo = start
+	store.end = end
+	return start, end, nil
+}
+
+// Appends a record of the lease to the leases file for auditing.
+func (store *fsLeaseStore) recordLease(start, end SignatureSeqNo) Error {
+	file, err := os.OpenFile(store.path+".leases",
+		os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return wrapErrorf(err, "Failed to open leases file")
+	}
+	defer file.Close()
+	host, _ := os.Hostname()
+	_, err = fmt.Fprintf(file, "%s %s %d %d %d\n",
+		time.Now().UTC().Format(time.RFC3339), host, os.Getpid(), start, end)
+	if err != nil {
+		return wrapErrorf(err, "Failed to write leases file")
+	}
+	if err = file.Sync(); err != nil {
+		return wrapErrorf(err, "Failed to sync leases file")
+	}
+	return nil
+}
+
+func (store *fsLeaseStore) Reset(privateKey []byte, params Params) Error {
+	return errorf("Can't reset a leased container")
+}
+
+func (store *fsLeaseStore) BorrowSeqNos(amount uint32) (
+	SignatureSeqNo, Error) {
+	if store.seqNo+SignatureSeqNo(amount) > store.end {
+		return 0, errorf("Can't borrow beyond the lease")
+	}
+	// The lease is durable already.
+	store.seqNo += SignatureSeqNo(amount)
+	return store.seqNo - SignatureSeqNo(amount), nil
+}
+
+func (store *fsLeaseStore) SetSeqNo(seqNo SignatureSeqNo) Error {
+	if seqNo > store.end {
+		return errorf("Signature sequence number %d is beyond the lease",
+			seqNo)
+	}
+	store.seqNo = seqNo
+	return nil
+}
+
+func (store *fsLeaseStore) GetSeqNo() (SignatureSeqNo, uint32, Error) {
+	return store.seqNo, 0, nil
+}
+
+// The end of the current lease, which is stored in the key file.  Other
+// processes might have leased beyond it since.
+func (store *fsLeaseStore) HighWaterMark() (SignatureSeqNo, Error) {
+	return store.end, nil
+}
+
+func (store *fsLeaseStore) KeyStructure() (KeyStructure, Error) {
+	return store.keyStructure, nil
+}
+
+func (store *fsLeaseStore) SetKeyStructure(ks KeyStructure) Error {
+	return errorf("Can't reset a leased container")
+}
+
+func (store *fsLeaseStore) Root() ([]byte, Error) {
+	return store.root, nil
+}
+
+func (store *fsLeaseStore) SetRoot(root []byte) Error {
+	return errorf("Can't change the root of a leased container")
+}
+
+func (store *fsLeaseStore) GetPrivateKey() ([]byte, Error) {
+	return store.privateKey, nil
+}
+
+func (store *fsLeaseStore) Initialized() *Params {
+	return store.params
+}
+
+func (store *fsLeaseStore) Close() Error {
+	zeroize(store.privateKey)
+	return nil
+}
@@ -0,0 +1,144 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFSLeasedPrivateKeyContainer(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := dir + "/key"
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(path)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	sk.Close()
+
+	// Two processes sharing the key.
+	var sks [2]*PrivateKey
+	for i := 0; i < 2; i++ {
+		ctr, err := OpenFSLeasedPrivateKeyContainer(path)
+		if err != nil {
+			t.Fatalf("OpenFSLeasedPrivateKeyContainer(): %v", err)
+		}
+		sks[i], _, _, err = LoadPrivateKeyFrom(ctr)
+		if err != nil {
+			t.Fatalf("LoadPrivateKeyFrom(): %v", err)
+		}
+		sks[i].SetLeaseSize(10)
+	}
+
+	msg := []byte("test message")
+	seen := make(map[SignatureSeqNo]bool)
+	for j := 0; j < 15; j++ {
+		for i := 0; i < 2; i++ {
+			sig, err := sks[i].Sign(msg)
+			if err != nil {
+				t.Fatalf("Sign(): %v", err)
+			}
+			if seen[sig.SeqNo()] {
+				t.Fatalf("Signature sequence number %d used twice",
+					sig.SeqNo())
+			}
+			seen[sig.SeqNo()] = true
+			if ok, err := pk.Verify(sig, msg); !ok {
+				t.Fatalf("Verify(): %v", err)
+			}
+		}
+	}
+	for i := 0; i < 2; i++ {
+		sks[i].Close()
+	}
+
+	buf, err2 := ioutil.ReadFile(path + ".leases")
+	if err2 != nil {
+		t.Fatalf("ReadFile(): %v", err2)
+	}
+	if n := strings.Count(string(buf), "\n"); n != 4 {
+		t.Fatalf("Expected 4 lease records, got %d", n)
+	}
+
+	// Opening the key normally continues after the leases.
+	sk, _, _, err = LoadPrivateKey(path)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk.Close()
+	if sk.SeqNo() != 40 {
+		t.Fatalf("SeqNo() = %d instead of 40", sk.SeqNo())
+	}
+}
+
+func TestConcurrentLeases(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := dir + "/key"
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(path)
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	sk.Close()
+
+	// Several containers for the same key in this process lease at once.
+	var wg sync.WaitGroup
+	var mux sync.Mutex
+	leased := make(map[SignatureSeqNo]bool)
+	for i := 0; i < 4; i++ {
+		ctr, err := OpenFSLeasedPrivateKeyContainer(path)
+		if err != nil {
+			t.Fatalf("OpenFSLeasedPrivateKeyContainer(): %v", err)
+		}
+		leaser := ctr.(SeqNoLeaser)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				start, end, err := leaser.LeaseSeqNos(3)
+				if err != nil {
+					t.Errorf("LeaseSeqNos(): %v", err)
+					return
+				}
+				mux.Lock()
+				for seqNo := start; seqNo < end; seqNo++ {
+					if leased[seqNo] {
+						t.Errorf("Signature sequence number %d leased twice",
+							seqNo)
+					}
+					leased[seqNo] = true
+				}
+				mux.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(leased) != 120 {
+		t.Fatalf("Leased %d signature sequence numbers instead of 120",
+			len(leased))
+	}
+}
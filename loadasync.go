@@ -0,0 +1,90 @@
+package xmssmt
+
+// Contains the loading of a private key in the background.
+
+// A private key that is being loaded by LoadPrivateKeyAsync().
+type PendingPrivateKey struct {
+	ready chan struct{} // closed when loading has finished
+
+	// Set when ready is closed.
+	sk       *PrivateKey
+	pk       *PublicKey
+	lostSigs uint32
+	err      Error
+}
+
+// Loads the private key from the given filesystem container in the
+// background.  See LoadPrivateKeyFromAsync().
+func LoadPrivateKeyAsync(path string) *PendingPrivateKey {
+	return loadPrivateKeyAsync(func() (PrivateKeyContainer, Error) {
+		return openFSPrivateKeyContainerToLoad(path)
+	})
+}
+
+// Loads the private key from the given container in the background, such
+// that a service can start accepting requests while a large private key is
+// loaded, which might take minutes if its cache has to be rebuilt.
+//
+// The returned PendingPrivateKey is ready when the private key has been
+// loaded and the subtrees for the next signature have been generated or
+// checked, including the root subtree, which is checked against the stored
+// root, see RootStore.  Calls to its Sign() wait until then.
+//
+// NOTE Takes ownership of ctr.  Do not forget to Close() the PrivateKey
+// returned by Wait().
+func LoadPrivateKeyFromAsync(ctr PrivateKeyContainer) *PendingPrivateKey {
+	return loadPrivateKeyAsync(func() (PrivateKeyContainer, Error) {
+		return ctr, nil
+	})
+}
+
+func loadPrivateKeyAsync(
+	open func() (PrivateKeyContainer, Error)) *PendingPrivateKey {
+	p := PendingPrivateKey{ready: make(chan struct{})}
+	go func() {
+		defer close(p.ready)
+		ctr, err := open()
+		if err != nil {
+			p.err = err
+			return
+		}
+		sk, pk, lostSigs, err := LoadPrivateKeyFrom(ctr)
+		if err != nil {
+			p.err = err
+			return
+		}
+		pad := sk.ctx.newScratchPad()
+		defer pad.zeroizeIfEnabled()
+		if err = sk.prepareNextSignature(pad); err != nil {
+			sk.Close()
+			p.err = wrapErrorf(err, "Failed to check the private key")
+			return
+		}
+		p.sk, p.pk, p.lostSigs = sk, pk, lostSigs
+	}()
+	return &p
+}
+
+// Returns a channel that is closed when the private key has been loaded,
+// or loading it has failed.  See Wait().
+func (p *PendingPrivateKey) Ready() <-chan struct{} {
+	return p.ready
+}
+
+// Waits until the private key has been loaded and returns the same as
+// LoadPrivateKeyFrom().
+func (p *PendingPrivateKey) Wait() (
+	sk *PrivateKey, pk *PublicKey, lostSigs uint32, err Error) {
+	<-p.ready
+	return p.sk, p.pk, p.lostSigs, p.err
+}
+
+// Waits until the private key has been loaded and signs the message with
+// it.  See PrivateKey.Sign().
+func (p *PendingPrivateKey) Sign(msg []byte) (*Signature, Error) {
+	sk, _, _, err := p.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return sk.Sign(msg)
+}
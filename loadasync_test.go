@@ -0,0 +1,62 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLoadPrivateKeyAsync(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err2 := NewContext(Params{SHA2, 16, 6, 3, 16, RFC})
+	if err2 != nil {
+		t.Fatalf("NewContext(): %v", err2)
+	}
+	sk, pk, err2 := ctx.GenerateKeyPair(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err2)
+	}
+	sk.Close()
+	if err = os.Remove(dir + "/key.cache"); err != nil {
+		t.Fatalf("Remove(): %v", err)
+	}
+
+	// Sign() waits for the private key to be loaded.
+	pending := LoadPrivateKeyAsync(dir + "/key")
+	msg := []byte("test message")
+	sig, err2 := pending.Sign(msg)
+	if err2 != nil {
+		t.Fatalf("Sign(): %v", err2)
+	}
+	<-pending.Ready()
+	sk, pk2, _, err2 := pending.Wait()
+	if err2 != nil {
+		t.Fatalf("Wait(): %v", err2)
+	}
+	defer sk.Close()
+	if !pk.Equal(pk2) || sig.SeqNo() != 0 {
+		t.Fatalf("Wait() returned the wrong private key")
+	}
+	if ok, err2 := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err2)
+	}
+	if sk.CachedSubTrees() != 3 {
+		t.Fatalf("%d subtrees are cached instead of 3", sk.CachedSubTrees())
+	}
+
+	pending = LoadPrivateKeyAsync(dir + "/missing")
+	if _, _, _, err2 = pending.Wait(); err2 == nil {
+		t.Fatalf("Wait() for a missing key succeeded")
+	}
+	if _, err2 = pending.Sign(msg); err2 == nil {
+		t.Fatalf("Sign() with a missing key succeeded")
+	}
+}
@@ -0,0 +1,53 @@
+package xmssmt
+
+// Contains PrivateKey.LoadReport(), the details of loading a private key.
+
+// Result of PrivateKey.LoadReport().
+type LoadReport struct {
+	// Parameters of the private key.
+	Params Params
+
+	// Signature sequence number when the private key was loaded.
+	SeqNo SignatureSeqNo
+
+	// Number of signatures lost because the private key wasn't properly
+	// closed, as returned by LoadPrivateKey().
+	LostSigs uint32
+
+	// Leases of borrowed signature sequence numbers that are not counted
+	// in LostSigs, as they have not expired.  See BorrowWithLease().
+	PendingBorrowLeases []BorrowLease
+
+	// Number of subtrees found in the cache.
+	CachedSubTrees int
+
+	// Cached subtrees that turned out to be corrupted and were regenerated
+	// since the private key was loaded.  The checksums of cached subtrees
+	// are checked when they're first used or by Check().
+	RepairedSubTrees []SubTreeAddress
+
+	// Whether the root subtree was not cached, such that the stored root
+	// was used and the subtrees for the next signature are being rebuilt
+	// in the background.  See LoadPrivateKeyFrom().
+	RebuildingCache bool
+
+	// Number of signatures that could be created when the private key
+	// was loaded.  See RemainingSignatures().
+	RemainingSignatures uint64
+}
+
+// Returns what was found when the private key was loaded by
+// LoadPrivateKey() or LoadPrivateKeyFrom(), such as the number of lost
+// signatures and the state of the cache.
+//
+// For a private key that was generated instead, the report is that of
+// a fresh private key.
+func (sk *PrivateKey) LoadReport() *LoadReport {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	report := sk.loadReport
+	report.PendingBorrowLeases = append([]BorrowLease{}, sk.pendingLeases...)
+	report.RepairedSubTrees = append([]SubTreeAddress{},
+		sk.repairedSubTrees...)
+	return &report
+}
@@ -0,0 +1,71 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestLoadReport(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	params := Params{SHAKE, 16, 8, 2, 16, RFC}
+	ctx, err := NewContext(params)
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	msg := []byte("test message")
+	for i := 0; i < 3; i++ {
+		if _, err = sk.Sign(msg); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+	if err = sk.BorrowExactly(2); err != nil {
+		t.Fatalf("BorrowExactly(): %v", err)
+	}
+
+	// Simulate a crash: don't return the borrowed signatures.
+	if err = sk.ctr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	sk, _, lostSigs, err := LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk.Close()
+	report := sk.LoadReport()
+	if report.Params != params || report.SeqNo != 5 ||
+		report.LostSigs != lostSigs || lostSigs != 2 ||
+		report.CachedSubTrees != 2 || report.RemainingSignatures != 250 ||
+		len(report.RepairedSubTrees) != 0 ||
+		len(report.PendingBorrowLeases) != 0 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	// Corrupt a cached subtree, which is repaired when it's used.
+	sta := SubTreeAddress{Layer: 0, Tree: 0}
+	buf, _, err := sk.ctr.GetSubTree(sta)
+	if err != nil {
+		t.Fatalf("GetSubTree(): %v", err)
+	}
+	buf[0] ^= 1
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	report = sk.LoadReport()
+	if len(report.RepairedSubTrees) != 1 || report.RepairedSubTrees[0] != sta {
+		t.Fatalf("Unexpected repaired subtrees: %v", report.RepairedSubTrees)
+	}
+}
@@ -0,0 +1,59 @@
+// +build !js,!wasip1,!linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package xmssmt
+
+import (
+	"os"
+
+	"github.com/nightlyone/lockfile"
+)
+
+// A lock on a file, such as path/to/key.lock, which is held by at most
+// one process.  Locking it again from the same process succeeds.
+//
+// On platforms without flock(), the lock is the process ID written
+// in the file.  See lock_flock.go for the other platforms.
+type fileLock = lockfile.Lockfile
+
+// Returns the lock on the file at the absolute path.
+func newFileLock(path string) (fileLock, error) {
+	return lockfile.New(path)
+}
+
+// Like TryLock(), but fails if this process holds the lock already.  Unlike
+// on platforms with flock(), the lock can still be taken again from this
+// process afterwards.
+func tryLockExclusively(l fileLock) error {
+	if readLockPid(string(l)) == os.Getpid() {
+		return lockfile.ErrBusy
+	}
+	return l.TryLock()
+}
+
+// Returns whether a process holds the lock on the file at the path.
+// Without flock(), the best we can do is to check whether the process
+// with the ID in the file is still running.
+func fileLockHeld(path string) bool {
+	_, alive := fileLockOwner(path)
+	return alive
+}
+
+// Returns the process ID written in the lockfile and whether the
+// process is running.
+func fileLockOwner(path string) (pid int, alive bool) {
+	pid = readLockPid(path)
+	if pid == 0 {
+		return 0, false
+	}
+	_, err := lockfile.Lockfile(path).GetOwner()
+	return pid, err == nil
+}
+
+// Removes the lockfile at the path, unless this process holds the lock.
+// See ForceUnlock().
+func forceUnlockFile(path string) error {
+	if readLockPid(path) == os.Getpid() {
+		return errorf("%s is locked by this process", path)
+	}
+	return os.Remove(path)
+}
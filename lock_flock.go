@@ -0,0 +1,201 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package xmssmt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// A lock on a file, such as path/to/key.lock, which is held by at most
+// one process.  Locking it again from the same process succeeds, unless
+// it was taken by tryLockExclusively().
+//
+// The lock is an flock() on the file, which the operating system releases
+// when the process dies, so a crash does not leave a stale lock behind.
+// The process ID is written in the file as well, which is how the lock was
+// taken by earlier versions of this package: a lockfile with the ID of
+// another running process is still respected.
+type fileLock string
+
+// The lockfiles on which this process holds the flock(), indexed by path.
+var heldFileLocks = struct {
+	mux   sync.Mutex
+	locks map[string]*heldFileLock
+}{locks: make(map[string]*heldFileLock)}
+
+// A lockfile on which this process holds the flock().
+type heldFileLock struct {
+	file *os.File
+
+	// Set if the lock was taken by tryLockExclusively(), in which case
+	// locking it again from this process fails.
+	exclusive bool
+}
+
+// Returned by TryLock() if another process holds the lock.
+type fileLockBusyError struct {
+	path string
+	pid  int // process ID in the lockfile; 0 if unknown
+}
+
+func (err fileLockBusyError) Error() string {
+	if err.pid == 0 {
+		return fmt.Sprintf("%s is locked by another process", err.path)
+	}
+	return fmt.Sprintf("%s is locked by process %d", err.path, err.pid)
+}
+
+// As with the errors of github.com/nightlyone/lockfile, to signal that
+// the lock might be free later.
+func (err fileLockBusyError) Temporary() bool { return true }
+
+// Returns the lock on the file at the absolute path.
+func newFileLock(path string) (fileLock, error) {
+	if !filepath.IsAbs(path) {
+		return "", errorf("Lockfile path %s is not absolute", path)
+	}
+	return fileLock(path), nil
+}
+
+func (l fileLock) TryLock() error {
+	return l.tryLock(false)
+}
+
+// Like TryLock(), but fails if this process holds the lock already, and
+// the lock can't be taken again from this process until it is released.
+func tryLockExclusively(l fileLock) error {
+	return l.tryLock(true)
+}
+
+func (l fileLock) tryLock(exclusive bool) error {
+	path := string(l)
+	heldFileLocks.mux.Lock()
+	defer heldFileLocks.mux.Unlock()
+	if held, ok := heldFileLocks.locks[path]; ok {
+		if exclusive || held.exclusive {
+			return fileLockBusyError{path, os.Getpid()}
+		}
+		return nil
+	}
+
+	f, err := openAndFlock(path)
+	if err != nil {
+		return err
+	}
+
+	// Respect locks taken by earlier versions of this package.
+	if pid, alive := fileLockOwner(path); alive && pid != os.Getpid() {
+		f.Close()
+		return fileLockBusyError{path, pid}
+	}
+
+	if err = f.Truncate(0); err == nil {
+		_, err = f.WriteAt([]byte(fmt.Sprintf("%d\n", os.Getpid())), 0)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	heldFileLocks.locks[path] = &heldFileLock{f, exclusive}
+	return nil
+}
+
+// Opens the file at path and takes the flock() on it.
+func openAndFlock(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == syscall.EWOULDBLOCK {
+			f.Close()
+			return nil, fileLockBusyError{path, readLockPid(path)}
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		// The previous holder removes the file when it releases the lock,
+		// so we might have locked a file that isn't at path anymore.
+		var st1, st2 syscall.Stat_t
+		if syscall.Fstat(int(f.Fd()), &st1) == nil &&
+			syscall.Stat(path, &st2) == nil &&
+			st1.Dev == st2.Dev && st1.Ino == st2.Ino {
+			return f, nil
+		}
+		f.Close()
+	}
+}
+
+func (l fileLock) Unlock() error {
+	path := string(l)
+	heldFileLocks.mux.Lock()
+	defer heldFileLocks.mux.Unlock()
+	held, ok := heldFileLocks.locks[path]
+	if !ok {
+		return errorf("%s is not locked by this process", path)
+	}
+	delete(heldFileLocks.locks, path)
+
+	// Remove the file while we still hold the flock(), see openAndFlock().
+	err := os.Remove(path)
+	if err2 := held.file.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// Returns whether a process holds the flock() on the file at the path.
+func fileLockHeld(path string) bool {
+	heldFileLocks.mux.Lock()
+	_, ours := heldFileLocks.locks[path]
+	heldFileLocks.mux.Unlock()
+	if ours {
+		return true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB)
+	return err == syscall.EWOULDBLOCK
+}
+
+// Returns the process ID written in the lockfile and whether the
+// process is running.
+func fileLockOwner(path string) (pid int, alive bool) {
+	pid = readLockPid(path)
+	if pid == 0 {
+		return 0, false
+	}
+	err := syscall.Kill(pid, 0)
+	return pid, err == nil || err == syscall.EPERM
+}
+
+// Removes the lockfile at the path, unless a process holds the flock()
+// on it.  See ForceUnlock().
+func forceUnlockFile(path string) error {
+	heldFileLocks.mux.Lock()
+	defer heldFileLocks.mux.Unlock()
+	if _, ok := heldFileLocks.locks[path]; ok {
+		return errorf("%s is locked by this process", path)
+	}
+	f, err := openAndFlock(path)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+// +build js wasip1
+
+package xmssmt
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// A lock on a file, such as path/to/key.lock.  On js and wasip1 there are
+// no process IDs to tell whether the process that holds the lock is still
+// running, so the lock is a no-op: it does not protect against the use of
+// the same key by another process.  As with the lock on other platforms,
+// locking it again from the same process succeeds.
+type fileLock string
+
+// Returns the lock on the file at the absolute path.
+func newFileLock(path string) (fileLock, error) {
+	if !filepath.IsAbs(path) {
+		return "", errorf("Lockfile path %s is not absolute", path)
+	}
+	return fileLock(path), nil
+}
+
+func (l fileLock) TryLock() error {
+	return nil
+}
+
+// As the lock is a no-op, there is nothing to take exclusively.
+func tryLockExclusively(l fileLock) error {
+	return nil
+}
+
+func (l fileLock) Unlock() error {
+	return nil
+}
+
+// As the lock is a no-op, no process holds it.
+func fileLockHeld(path string) bool {
+	return false
+}
+
+// There are no process IDs to check.
+func fileLockOwner(path string) (pid int, alive bool) {
+	return readLockPid(path), false
+}
+
+// Removes the lockfile at the path.  See ForceUnlock().
+func forceUnlockFile(path string) error {
+	return os.Remove(path)
+}
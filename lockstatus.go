@@ -0,0 +1,124 @@
+package xmssmt
+
+// Contains InspectLock() and ForceUnlock() to recover from lockfiles
+// left behind by a process that did not exit cleanly.
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Status of the lockfile of a private key, see InspectLock().
+type LockStatus struct {
+	// Whether the lockfile exists.
+	Locked bool
+
+	// The process ID written in the lockfile, or 0 if there is none.
+	Pid int
+
+	// Whether the process with ID Pid is running.  The process ID might
+	// have been reused by an unrelated process since the lockfile was
+	// written.
+	OwnerAlive bool
+
+	// Whether a process holds the lock.  On platforms with flock(), this
+	// is determined by the operating system, which releases the lock when
+	// the process holding it dies.  Elsewhere it equals OwnerAlive.
+	//
+	// If Held is false, but OwnerAlive is true, then the lock was either
+	// taken by an earlier version of this package, which is still
+	// respected, or the process ID has been reused.
+	Held bool
+
+	// Time since the lockfile was last written.
+	Age time.Duration
+}
+
+// Returns whether the lockfile exists, but no running process holds it.
+// A stale lockfile can safely be removed with ForceUnlock().
+func (s LockStatus) Stale() bool {
+	return s.Locked && !s.Held && !s.OwnerAlive
+}
+
+// Returns the path of the lockfile of the private key at path.
+func lockFilePathFor(path string) (string, Error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", wrapErrorf(err,
+			"Could not turn %s into an absolute path", path)
+	}
+	return absPath + ".lock", nil
+}
+
+// Returns the process ID in the lockfile at path, or 0 if there is none.
+func readLockPid(path string) int {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf)))
+	if err != nil || pid <= 0 {
+		return 0
+	}
+	return pid
+}
+
+// Returns the status of the lockfile of the private key stored at path,
+// which is path.lock.
+//
+// A process that is killed might leave its lockfile behind, in which
+// case LoadPrivateKey() reports the private key as locked.  Use Stale()
+// on the returned status to check whether that is the case.
+func InspectLock(path string) (LockStatus, Error) {
+	var status LockStatus
+	lockFilePath, err := lockFilePathFor(path)
+	if err != nil {
+		return status, err
+	}
+	fi, err2 := os.Stat(lockFilePath)
+	if os.IsNotExist(err2) {
+		return status, nil
+	}
+	if err2 != nil {
+		return status, wrapErrorf(err2, "Failed to stat %s", lockFilePath)
+	}
+	status.Locked = true
+	status.Age = time.Since(fi.ModTime())
+	status.Pid, status.OwnerAlive = fileLockOwner(lockFilePath)
+	status.Held = fileLockHeld(lockFilePath)
+	return status, nil
+}
+
+// Removes the lockfile of the private key stored at path, even if the
+// process with the ID in it is still running.
+//
+// Only use this when you are sure the process that created the lockfile
+// does not use the private key anymore: two processes signing with the
+// same private key will reuse signature sequence numbers, which breaks
+// the security of XMSS[MT].  On platforms with flock(), a lock that is
+// held by a running process is never removed.
+func ForceUnlock(path string) Error {
+	lockFilePath, err := lockFilePathFor(path)
+	if err != nil {
+		return err
+	}
+	err2 := forceUnlockFile(lockFilePath)
+	if os.IsNotExist(err2) {
+		return nil
+	}
+	if err2 != nil {
+		if _, ok := err2.(interface {
+			Temporary() bool
+		}); ok {
+			err3 := wrapErrorf(err2, "%s is locked", path)
+			err3.kind = ErrLocked
+			return err3
+		}
+		return wrapErrorf(err2, "Failed to remove %s", lockFilePath)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// +build !js,!wasip1
+
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestInspectAndForceUnlock(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 2, 1, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+
+	status, err := InspectLock(dir + "/key")
+	if err != nil {
+		t.Fatalf("InspectLock(): %v", err)
+	}
+	if !status.Locked || !status.Held || !status.OwnerAlive ||
+		status.Pid != os.Getpid() || status.Stale() {
+		t.Fatalf("InspectLock() of open key: %+v", status)
+	}
+	if err = ForceUnlock(dir + "/key"); err == nil {
+		t.Fatalf("ForceUnlock() removed the lock of an open key")
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	status, err = InspectLock(dir + "/key")
+	if err != nil {
+		t.Fatalf("InspectLock(): %v", err)
+	}
+	if status.Locked {
+		t.Fatalf("InspectLock() of closed key: %+v", status)
+	}
+
+	// A lockfile of an unrelated running process, as if its process ID
+	// was reused.
+	err = ioutil.WriteFile(dir+"/key.lock", []byte("1\n"), 0644)
+	if err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	_, _, _, err = LoadPrivateKey(dir + "/key")
+	if err == nil || !err.(Error).Locked() {
+		t.Fatalf("LoadPrivateKey() ignored the lockfile: %v", err)
+	}
+	status, err = InspectLock(dir + "/key")
+	if err != nil {
+		t.Fatalf("InspectLock(): %v", err)
+	}
+	if !status.Locked || status.Pid != 1 || !status.OwnerAlive {
+		t.Fatalf("InspectLock() of foreign lockfile: %+v", status)
+	}
+	if err = ForceUnlock(dir + "/key"); err != nil {
+		t.Fatalf("ForceUnlock(): %v", err)
+	}
+	sk, _, _, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey() after ForceUnlock(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+}
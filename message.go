@@ -0,0 +1,203 @@
+package xmssmt
+
+// Contains the policy on the messages that are signed and verified,
+// and the domain-separation context strings.
+
+import (
+	"bytes"
+	"io"
+)
+
+// Restrictions on the messages signed and verified with a Context.
+// The zero value imposes none.  See Context.SetMessagePolicy().
+type MessagePolicy struct {
+	// If positive, messages longer than MaxLength bytes are rejected.
+	// This is checked while the message is read, so an oversized
+	// message is never read in full.  If the length of the message is
+	// known beforehand, as with Sign(), it is checked before a signature
+	// sequence number is used.
+	MaxLength int64
+
+	// If set, empty messages are rejected.  A nil message is the same
+	// as an empty one.
+	RejectEmpty bool
+
+	// If set, only signatures with a non-empty context string can be
+	// created and verified, see PrivateKey.SignFromWithContext().  All other
+	// methods that sign or verify, such as Sign() and SignHash(), fail.
+	RequireContext bool
+}
+
+// Prefix of the messages signed by PrivateKey.SignFromWithContext()
+var messageContextPrefix = []byte("XMSSMT-CTX")
+
+// Sets the restrictions on the messages that are signed and verified with
+// this Context.  Has to be called before the Context is used.
+//
+// Without a policy, the empty (or nil) message can be signed like any other
+// and messages of any length are accepted: they are streamed through the
+// hash function, see SignFrom(), and do not have to fit in memory.
+func (ctx *Context) SetMessagePolicy(policy MessagePolicy) {
+	ctx.msgPolicy = policy
+}
+
+// Returns the restrictions on messages.  See SetMessagePolicy().
+func (ctx *Context) MessagePolicy() MessagePolicy {
+	return ctx.msgPolicy
+}
+
+// Returns the prefix
+//
+//   "XMSSMT-CTX" ‖ len(ctxStr) ‖ ctxStr
+//
+// that is prepended to a message signed with the context string ctxStr.
+func (ctx *Context) messageContext(ctxStr []byte) ([]byte, Error) {
+	if len(ctxStr) > 255 {
+		return nil, errorf("Context string can be at most 255 bytes")
+	}
+	if ctx.msgPolicy.RequireContext && len(ctxStr) == 0 {
+		return nil, errorf("Message policy requires a non-empty context string")
+	}
+	ret := make([]byte, 0, len(messageContextPrefix)+1+len(ctxStr))
+	ret = append(ret, messageContextPrefix...)
+	ret = append(ret, byte(len(ctxStr)))
+	ret = append(ret, ctxStr...)
+	return ret, nil
+}
+
+// Signs the given message together with the domain-separation context
+// string ctxStr.  See SignFromWithContext().
+func (sk *PrivateKey) SignWithContext(msg, ctxStr []byte) (*Signature, Error) {
+	prefix, err := sk.ctx.messageContext(ctxStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := sk.ctx.checkMessageBytes(prefix, msg); err != nil {
+		return nil, err
+	}
+	return sk.signFrom(bytes.NewReader(msg), prefix)
+}
+
+// Checks whether sig is a valid signature of this public key for the
+// message and the context string.  See PrivateKey.SignWithContext().
+func (pk *PublicKey) VerifyWithContext(sig *Signature, msg, ctxStr []byte) (
+	bool, Error) {
+	prefix, err := pk.ctx.messageContext(ctxStr)
+	if err != nil {
+		return false, err
+	}
+	pad := pk.ctx.getScratchPad()
+	defer pk.ctx.putScratchPad(pad)
+	return pk.verifyBytes(*pad, sig, msg, prefix)
+}
+
+// Reads a message from the io.Reader and signs it together with the
+// domain-separation context string ctxStr of at most 255 bytes.  The
+// signature can only be verified with the same context string, so a key
+// shared by several protocols or services can't be tricked into creating
+// a signature that is valid in another.  The signature is on
+//
+//   "XMSSMT-CTX" ‖ len(ctxStr) ‖ ctxStr ‖ msg
+//
+// which, in particular, differs from the message signed by SignFrom(), even
+// if ctxStr is empty.  To rule out that signatures from SignFrom() and the
+// like are confused with these, set MessagePolicy.RequireContext.
+func (sk *PrivateKey) SignFromWithContext(msg io.Reader, ctxStr []byte) (
+	*Signature, Error) {
+	prefix, err := sk.ctx.messageContext(ctxStr)
+	if err != nil {
+		return nil, err
+	}
+	return sk.signFrom(msg, prefix)
+}
+
+// Reads a message from the io.Reader and verifies whether the provided
+// signature is valid for this public key, the message and the context
+// string.  See PrivateKey.SignFromWithContext().
+func (pk *PublicKey) VerifyFromWithContext(sig *Signature, msg io.Reader,
+	ctxStr []byte) (bool, Error) {
+	prefix, err := pk.ctx.messageContext(ctxStr)
+	if err != nil {
+		return false, err
+	}
+	return pk.verifyFrom(sig, msg, prefix)
+}
+
+// Checks whether a message with the given prefix, see messageContext(),
+// may be signed or verified.  Used to fail early, before signature sequence
+// numbers are used up.
+func (ctx *Context) checkMessageContext(prefix []byte) Error {
+	if ctx.msgPolicy.RequireContext && prefix == nil {
+		return errorf("Message policy requires a context string")
+	}
+	return nil
+}
+
+// Checks a message in memory with the given prefix against the
+// MessagePolicy.  See writeMessage().
+func (ctx *Context) checkMessageBytes(prefix, msg []byte) Error {
+	return ctx.checkMessageLength(prefix, int64(len(msg)))
+}
+
+// Checks a message of the given length with the given prefix against the
+// MessagePolicy.  Used to fail early, before signature sequence numbers
+// are used up.
+func (ctx *Context) checkMessageLength(prefix []byte, length int64) Error {
+	if err := ctx.checkMessageContext(prefix); err != nil {
+		return err
+	}
+	policy := ctx.msgPolicy
+	if policy.MaxLength > 0 && length > policy.MaxLength {
+		return errorf("Message is longer than %d bytes", policy.MaxLength)
+	}
+	if policy.RejectEmpty && length == 0 {
+		return errorf("Message policy does not allow empty messages")
+	}
+	return nil
+}
+
+// Checks the message against the MessagePolicy, while it is written
+// to the hash.
+type policyWriter struct {
+	w      io.Writer
+	policy MessagePolicy
+	n      int64 // number of bytes written
+}
+
+func (w *policyWriter) Write(buf []byte) (int, error) {
+	w.n += int64(len(buf))
+	if w.policy.MaxLength > 0 && w.n > w.policy.MaxLength {
+		return 0, errorf("Message is longer than %d bytes",
+			w.policy.MaxLength)
+	}
+	return w.w.Write(buf)
+}
+
+// Writes the message, prepended by prefix, if not nil, to h, while
+// enforcing the MessagePolicy.  prefix is the encoded context string
+// of the message, see messageContext().
+func (ctx *Context) writeMessage(h io.Writer, prefix []byte,
+	msg io.Reader) error {
+	if err := ctx.checkMessageContext(prefix); err != nil {
+		return err
+	}
+	policy := ctx.msgPolicy
+	h.Write(prefix)
+
+	// The message is streamed through the hash, so the memory used does
+	// not depend on the length of the message.
+	w := &policyWriter{w: h, policy: policy}
+	var err error
+	if wt, ok := msg.(io.WriterTo); ok {
+		_, err = wt.WriteTo(w)
+	} else {
+		_, err = io.CopyBuffer(w, msg, make([]byte, MessageChunkSize))
+	}
+	if err != nil {
+		return err
+	}
+	if policy.RejectEmpty && w.n == 0 {
+		return errorf("Message policy does not allow empty messages")
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// Reads an endless stream of zeroes and does not implement io.WriterTo.
+type zeroReader struct{}
+
+func (zeroReader) Read(buf []byte) (int, error) {
+	for i := range buf {
+		buf[i] = 0
+	}
+	return len(buf), nil
+}
+
+func TestMessagePolicy(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	// Without a policy, nil and the empty message are the same.
+	sig, err := sk.Sign(nil)
+	if err != nil {
+		t.Fatalf("Sign(nil): %v", err)
+	}
+	if ok, err := pk.Verify(sig, []byte{}); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+
+	ctx.SetMessagePolicy(MessagePolicy{MaxLength: 10, RejectEmpty: true})
+	if _, err = sk.Sign(nil); err == nil {
+		t.Fatalf("Sign(nil) should fail with RejectEmpty")
+	}
+	if ok, _ := pk.Verify(sig, nil); ok {
+		t.Fatalf("Verify(nil) should fail with RejectEmpty")
+	}
+	msg := []byte("0123456789")
+	sig, err = sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	seqNo := sk.SeqNo()
+	if _, err = sk.Sign(append(msg, 'a')); err == nil {
+		t.Fatalf("Sign() should fail on message longer than MaxLength")
+	}
+	if _, err = sk.SignMany([][]byte{msg, nil}); err == nil {
+		t.Fatalf("SignMany() should fail with RejectEmpty")
+	}
+	if sk.SeqNo() != seqNo {
+		t.Fatalf("Rejected messages used up signature sequence numbers")
+	}
+	if _, err = sk.SignFrom(zeroReader{}); err == nil {
+		t.Fatalf("SignFrom() should fail on endless message")
+	}
+
+	ctx.SetMessagePolicy(MessagePolicy{RequireContext: true})
+	seqNo = sk.SeqNo()
+	if _, err = sk.Sign(msg); err == nil {
+		t.Fatalf("Sign() should fail with RequireContext")
+	}
+	if _, err = sk.SignMany([][]byte{msg}); err == nil {
+		t.Fatalf("SignMany() should fail with RequireContext")
+	}
+	if sk.SeqNo() != seqNo {
+		t.Fatalf("Signature sequence numbers were used up")
+	}
+	if _, err = sk.SignFromWithContext(bytes.NewReader(msg), nil); err == nil {
+		t.Fatalf("SignFromWithContext() should fail with empty context")
+	}
+	sig, err = sk.SignFromWithContext(bytes.NewReader(msg), []byte("service-a"))
+	if err != nil {
+		t.Fatalf("SignFromWithContext(): %v", err)
+	}
+	if ok, err := pk.VerifyFromWithContext(sig, bytes.NewReader(msg),
+		[]byte("service-a")); !ok {
+		t.Fatalf("VerifyFromWithContext(): %v", err)
+	}
+	if ok, _ := pk.VerifyFromWithContext(sig, bytes.NewReader(msg),
+		[]byte("service-b")); ok {
+		t.Fatalf("VerifyFromWithContext() accepted wrong context")
+	}
+	if ok, _ := pk.Verify(sig, msg); ok {
+		t.Fatalf("Verify() should fail with RequireContext")
+	}
+
+	// Even without policy, a signature with context is not valid
+	// without it.
+	ctx.SetMessagePolicy(MessagePolicy{})
+	if ok, _ := pk.Verify(sig, msg); ok {
+		t.Fatalf("Verify() accepted signature with context")
+	}
+}
+
+func TestSignWithContext(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("transfer 100 coins")
+	sig, err := sk.SignWithContext(msg, []byte("payments-v1"))
+	if err != nil {
+		t.Fatalf("SignWithContext(): %v", err)
+	}
+	if ok, err := pk.VerifyWithContext(sig, msg, []byte("payments-v1")); !ok {
+		t.Fatalf("VerifyWithContext(): %v", err)
+	}
+	for _, other := range [][]byte{nil, []byte("payments-v2"),
+		[]byte("payments-v1\x00")} {
+		if ok, _ := pk.VerifyWithContext(sig, msg, other); ok {
+			t.Fatalf("VerifyWithContext() accepted context %q", other)
+		}
+	}
+
+	// The context string is length-prefixed, so shifting bytes between
+	// the context string and the message gives a different signature.
+	if ok, _ := pk.VerifyWithContext(sig, msg[1:],
+		[]byte("payments-v1t")); ok {
+		t.Fatalf("VerifyWithContext() accepted shifted context")
+	}
+
+	if _, err := sk.SignWithContext(msg, make([]byte, 256)); err == nil {
+		t.Fatalf("SignWithContext() accepted too long context")
+	}
+
+	v := NewStatefulVerifier(pk, NewMemorySeenSeqNoStore())
+	if ok, err := v.VerifyWithContext(sig, msg, []byte("payments-v1")); !ok {
+		t.Fatalf("StatefulVerifier.VerifyWithContext(): %v", err)
+	}
+	if ok, _ := v.VerifyWithContext(sig, msg, []byte("other")); ok {
+		t.Fatalf("StatefulVerifier.VerifyWithContext() accepted " +
+			"wrong context")
+	}
+}
@@ -0,0 +1,92 @@
+package xmssmt
+
+// Contains the hooks to instrument signing and subtree generation.
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Receives measurements from the private keys of a Context.
+// See Context.SetMetrics().
+//
+// NOTE The methods are called from different goroutines concurrently
+// and should return quickly.
+type Metrics interface {
+	// Called after a signature has been created successfully with the
+	// time it took, including the generation of any subtrees.
+	SignDuration(d time.Duration)
+
+	// Called after the leafs and internal nodes of a subtree have been
+	// generated with the time it took.
+	SubTreeGenerated(sta SubTreeAddress, d time.Duration)
+
+	// Called when a subtree is found in the cache.
+	CacheHit(sta SubTreeAddress)
+
+	// Called when a subtree is not in the cache and has to be generated.
+	CacheMiss(sta SubTreeAddress)
+
+	// Called when a signature sequence number is used.  borrowed is true
+	// if it was reserved earlier, see PrivateKey.BorrowExactly(), and
+	// false if the container had to be updated first.
+	SeqNoUsed(borrowed bool)
+}
+
+// Metrics implementation which simply counts.  The counters can be read
+// while the Context is in use.  MetricsCounters implements expvar.Var,
+// so it can be published with expvar.Publish().
+type MetricsCounters struct {
+	Signatures       uint64 // number of signatures created
+	SignNanos        uint64 // total time spent creating signatures
+	SubTrees         uint64 // number of subtrees generated
+	SubTreeNanos     uint64 // total time spent generating subtrees
+	CacheHits        uint64
+	CacheMisses      uint64
+	BorrowedSeqNos   uint64 // number of borrowed sequence numbers used
+	UnborrowedSeqNos uint64 // number of sequence numbers synced one by one
+}
+
+func (c *MetricsCounters) SignDuration(d time.Duration) {
+	atomic.AddUint64(&c.Signatures, 1)
+	atomic.AddUint64(&c.SignNanos, uint64(d))
+}
+
+func (c *MetricsCounters) SubTreeGenerated(sta SubTreeAddress,
+	d time.Duration) {
+	atomic.AddUint64(&c.SubTrees, 1)
+	atomic.AddUint64(&c.SubTreeNanos, uint64(d))
+}
+
+func (c *MetricsCounters) CacheHit(sta SubTreeAddress) {
+	atomic.AddUint64(&c.CacheHits, 1)
+}
+
+func (c *MetricsCounters) CacheMiss(sta SubTreeAddress) {
+	atomic.AddUint64(&c.CacheMisses, 1)
+}
+
+func (c *MetricsCounters) SeqNoUsed(borrowed bool) {
+	if borrowed {
+		atomic.AddUint64(&c.BorrowedSeqNos, 1)
+	} else {
+		atomic.AddUint64(&c.UnborrowedSeqNos, 1)
+	}
+}
+
+// Returns the counters as a JSON object.
+func (c *MetricsCounters) String() string {
+	return fmt.Sprintf(`{"signatures": %d, "signNanos": %d, `+
+		`"subTrees": %d, "subTreeNanos": %d, `+
+		`"cacheHits": %d, "cacheMisses": %d, `+
+		`"borrowedSeqNos": %d, "unborrowedSeqNos": %d}`,
+		atomic.LoadUint64(&c.Signatures),
+		atomic.LoadUint64(&c.SignNanos),
+		atomic.LoadUint64(&c.SubTrees),
+		atomic.LoadUint64(&c.SubTreeNanos),
+		atomic.LoadUint64(&c.CacheHits),
+		atomic.LoadUint64(&c.CacheMisses),
+		atomic.LoadUint64(&c.BorrowedSeqNos),
+		atomic.LoadUint64(&c.UnborrowedSeqNos))
+}
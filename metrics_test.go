@@ -0,0 +1,73 @@
+package xmssmt
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	var counters MetricsCounters
+	ctx.SetMetrics(&counters)
+
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	for i := 0; i < 3; i++ {
+		if _, err = sk.Sign(msg); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+	if err = sk.BorrowExactly(10); err != nil {
+		t.Fatalf("BorrowExactly(): %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = sk.Sign(msg); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+
+	if counters.Signatures != 5 {
+		t.Fatalf("Signatures = %d, expected 5", counters.Signatures)
+	}
+	if counters.UnborrowedSeqNos != 3 || counters.BorrowedSeqNos != 2 {
+		t.Fatalf("Unborrowed/borrowed seqnos are %d/%d, expected 3/2",
+			counters.UnborrowedSeqNos, counters.BorrowedSeqNos)
+	}
+
+	// Key generation computes the root subtree, the first signature
+	// the subtree on the lowest layer.
+	if counters.SubTrees != 2 || counters.CacheMisses != 2 {
+		t.Fatalf("Generated %d subtrees with %d misses, expected 2",
+			counters.SubTrees, counters.CacheMisses)
+	}
+	if counters.CacheHits == 0 {
+		t.Fatalf("No cache hits")
+	}
+
+	var parsed map[string]uint64
+	if err := json.Unmarshal([]byte(counters.String()), &parsed); err != nil {
+		t.Fatalf("String() is not valid JSON: %v", err)
+	}
+	if parsed["signatures"] != 5 {
+		t.Fatalf("String() reports %d signatures", parsed["signatures"])
+	}
+}
@@ -38,13 +38,19 @@ func decodeUint64(in []byte) (ret uint64) {
 }
 
 type errorImpl struct {
-	msg    string
-	locked bool
-	inner  error
+	msg   string
+	kind  error // one of ErrLocked, ErrExhausted, ...; nil if none
+	inner error
 }
 
-func (err *errorImpl) Locked() bool { return err.locked }
-func (err *errorImpl) Inner() error { return err.inner }
+func (err *errorImpl) Locked() bool  { return err.kind == ErrLocked }
+func (err *errorImpl) Inner() error  { return err.inner }
+func (err *errorImpl) Unwrap() error { return err.inner }
+
+// Used by errors.Is() to check the kind of the error.
+func (err *errorImpl) Is(target error) bool {
+	return err.kind != nil && err.kind == target
+}
 
 func (err *errorImpl) Error() string {
 	if err.inner != nil {
@@ -63,6 +69,11 @@ func wrapErrorf(err error, format string, a ...interface{}) *errorImpl {
 	return &errorImpl{msg: fmt.Sprintf(format, a...), inner: err}
 }
 
+// Formats a new Error of the given kind, such as ErrNotInitialized.
+func kindErrorf(kind error, format string, a ...interface{}) *errorImpl {
+	return &errorImpl{msg: fmt.Sprintf(format, a...), kind: kind}
+}
+
 type dummyLogger struct{}
 type stdlibLogger struct{}
 
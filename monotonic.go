@@ -0,0 +1,163 @@
+package xmssmt
+
+// Contains the protection against reuse of signature sequence numbers
+// after the private key container has been cloned or rolled back.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A counter that can only increase and that is stored outside of the
+// private key container, for instance a TPM NV counter or a file on
+// another machine.  See PrivateKey.SetMonotonicSource().
+type MonotonicSource interface {
+	// Returns the current value of the counter.
+	Read() (uint64, error)
+
+	// Atomically increases the counter from old to new.  Has to fail
+	// if the counter doesn't equal old.
+	Advance(old, new uint64) error
+}
+
+// Cross-checks the signature sequence number stored in the container
+// against the monotonic counter src, to detect that the state of the
+// private key has been rolled back, for instance by restoring a VM
+// snapshot or a backup, or that it has been cloned.
+//
+// The counter tracks the signature sequence number stored in the container:
+// it is advanced each time the container is.  If the counter is ahead of
+// the container, either now or later because another clone advanced it,
+// signature sequence numbers might have been used already and Sign() will
+// refuse to create signatures.  The counter has to be stored somewhere that
+// is not rolled back or cloned together with the container.
+//
+// With a monotonic counter, borrowed signature sequence numbers are not
+// returned to the container by Close() or BorrowExactly(), as the container
+// can't go back below the counter.
+func (sk *PrivateKey) SetMonotonicSource(src MonotonicSource) Error {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+
+	stored, _, err := sk.ctr.GetSeqNo()
+	if err != nil {
+		return err
+	}
+	value, err2 := src.Read()
+	if err2 != nil {
+		return wrapErrorf(err2, "Failed to read monotonic counter")
+	}
+
+	sk.monotonic = src
+	sk.monotonicValue = value
+	if value > uint64(stored) {
+		sk.monotonicErr = errorf(
+			"State regression detected: the monotonic counter is at %d, "+
+				"but the container at %d", value, stored)
+		return sk.monotonicErr
+	}
+
+	// We might have crashed after updating the container, but before
+	// updating the counter, or the counter is new.
+	return sk.advanceMonotonic(uint64(stored))
+}
+
+// Advances the monotonic counter, if set, to the given value.
+// Assumes sk.mux is held.
+func (sk *PrivateKey) advanceMonotonic(value uint64) Error {
+	if sk.monotonic == nil || value <= sk.monotonicValue {
+		return nil
+	}
+	err := sk.monotonic.Advance(sk.monotonicValue, value)
+	if err != nil {
+		sk.monotonicErr = wrapErrorf(err,
+			"Failed to advance monotonic counter from %d to %d: "+
+				"the state of the private key might have been cloned",
+			sk.monotonicValue, value)
+		return sk.monotonicErr
+	}
+	sk.monotonicValue = value
+	return nil
+}
+
+// MonotonicSource that stores the counter in a file.  It's only useful
+// if the file is not rolled back or cloned together with the container,
+// for instance if it's on a network share.
+type fileMonotonicSource struct {
+	path string
+}
+
+// Returns a MonotonicSource that stores the counter in decimal in the file
+// at path.  A missing file is treated as a zero counter.  The file is
+// locked with path.lock while it's advanced.
+func NewFileMonotonicSource(path string) (MonotonicSource, Error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, wrapErrorf(err,
+			"Could not turn %s into an absolute path", path)
+	}
+	return &fileMonotonicSource{path: absPath}, nil
+}
+
+func (src *fileMonotonicSource) Read() (uint64, error) {
+	buf, err := ioutil.ReadFile(src.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(buf)), 10, 64)
+}
+
+func (src *fileMonotonicSource) Advance(old, new uint64) error {
+	if new < old {
+		return fmt.Errorf("Monotonic counter can't decrease")
+	}
+	flock, err := newFileLock(src.path + ".lock")
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(leaseLockTimeout)
+	for {
+		err = flock.TryLock()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer flock.Unlock()
+
+	cur, err := src.Read()
+	if err != nil {
+		return err
+	}
+	if cur != old {
+		return fmt.Errorf("Monotonic counter is at %d instead of %d",
+			cur, old)
+	}
+
+	tmpPath := src.path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
+		0600)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(file, "%d\n", new)
+	if err == nil {
+		err = file.Sync()
+	}
+	file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, src.path)
+}
@@ -0,0 +1,108 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func copyFile(t *testing.T, from, to string) {
+	buf, err := ioutil.ReadFile(from)
+	if err != nil {
+		t.Fatalf("ReadFile(): %v", err)
+	}
+	if err = ioutil.WriteFile(to, buf, 0600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+}
+
+func TestMonotonicSource(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	src, err := NewFileMonotonicSource(dir + "/counter")
+	if err != nil {
+		t.Fatalf("NewFileMonotonicSource(): %v", err)
+	}
+	if err = sk.SetMonotonicSource(src); err != nil {
+		t.Fatalf("SetMonotonicSource(): %v", err)
+	}
+	sk.SetAutoBorrow(5)
+	msg := []byte("test message")
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if value, _ := src.Read(); value != 5 {
+		t.Fatalf("Counter is at %d instead of 5", value)
+	}
+	sk.Close()
+
+	// Borrowed sequence numbers are not returned, so reloading is fine.
+	copyFile(t, dir+"/key", dir+"/snapshot")
+	sk, _, _, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if err = sk.SetMonotonicSource(src); err != nil {
+		t.Fatalf("SetMonotonicSource(): %v", err)
+	}
+	if _, err = sk.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	sk.Close()
+
+	// Rolling back the key is detected.
+	copyFile(t, dir+"/snapshot", dir+"/key")
+	sk, _, _, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if err = sk.SetMonotonicSource(src); err == nil {
+		t.Fatalf("SetMonotonicSource() did not detect rollback")
+	}
+	if _, err = sk.Sign(msg); err == nil {
+		t.Fatalf("Sign() should fail after rollback")
+	}
+	sk.Close()
+
+	// Clones are detected when the second one signs.
+	os.Remove(dir + "/counter")
+	copyFile(t, dir+"/snapshot", dir+"/clone")
+	copyFile(t, dir+"/key.cache", dir+"/clone.cache")
+	sk1, _, _, err := LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk1.Close()
+	sk2, _, _, err := LoadPrivateKey(dir + "/clone")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk2.Close()
+	if err = sk1.SetMonotonicSource(src); err != nil {
+		t.Fatalf("SetMonotonicSource(): %v", err)
+	}
+	if err = sk2.SetMonotonicSource(src); err != nil {
+		t.Fatalf("SetMonotonicSource(): %v", err)
+	}
+	if _, err = sk1.Sign(msg); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if _, err = sk2.Sign(msg); err == nil {
+		t.Fatalf("Sign() should fail on the clone")
+	}
+}
@@ -0,0 +1,6 @@
+// +build !race
+
+package xmssmt
+
+// See race_test.go.
+const raceEnabled = false
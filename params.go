@@ -22,6 +22,11 @@ const (
 
 	// SHAKE-256.  (From NIST SP 800-208.)
 	SHAKE256
+
+	// SHA-512/256, which is faster than SHA-256 on 64-bit platforms.
+	// Only supports n≤32.  This is not standardized: instances using it
+	// have no OID and are not supported by other implementations.
+	SHA512_256
 )
 
 // Way to construct the various PRFs from the hash function.
@@ -188,6 +193,19 @@ func (params *Params) MarshalBinary() ([]byte, error) {
 	return ret, nil
 }
 
+// Returns an encoding of the parameters of fixed length, which, unlike
+// MarshalBinary(), works for every combination of parameters.
+func (params *Params) fixedEncoding() []byte {
+	var buf [16]byte
+	buf[0] = byte(params.Func)
+	binary.BigEndian.PutUint32(buf[1:5], params.N)
+	binary.BigEndian.PutUint32(buf[5:9], params.FullHeight)
+	binary.BigEndian.PutUint32(buf[9:13], params.D)
+	binary.BigEndian.PutUint16(buf[13:15], params.WotsW)
+	buf[15] = byte(params.Prf)
+	return buf[:]
+}
+
 // Write parameters into buf as encoded by MarshalBinary().
 func (params *Params) WriteInto(buf []byte) error {
 	var val uint32
@@ -199,7 +217,7 @@ func (params *Params) WriteInto(buf []byte) error {
 	if params.N > 128 {
 		return errorf("N is too large")
 	}
-	if params.Func > 2 {
+	if params.Func > 3 {
 		return errorf("Func is too large")
 	}
 	if params.FullHeight > 63 {
@@ -245,7 +263,8 @@ func (params *Params) UnmarshalBinary(buf []byte) error {
 	val := binary.BigEndian.Uint32(buf)
 	magic := val >> 24
 	if magic != 0xea {
-		return errorf("These are not compressed parameters (magic is wrong).")
+		return kindErrorf(ErrWrongMagic,
+			"These are not compressed parameters (magic is wrong).")
 	}
 	version := (val >> 21) & ((1 << 3) - 1)
 	if version != 0 {
@@ -278,16 +297,30 @@ func (params *Params) UnmarshalBinary(buf []byte) error {
 
 // Returns the size of the subtrees for this parameter.
 func (params *Params) BareSubTreeSize() int {
-	height := (params.FullHeight / params.D) + 1
+	return params.bareSubTreeSizeFrom(0)
+}
+
+// Returns the size of the nodes at the given height and above of
+// a subtree for this parameter.
+func (params *Params) bareSubTreeSizeFrom(lowest uint32) int {
+	height := (params.FullHeight / params.D) + 1 - lowest
 	return int(((1 << height) - 1) * params.N)
 }
 
 // Returns the size of the cached subtrees for this parameter.
 func (params *Params) CachedSubTreeSize() int {
+	return params.CachedSubTreeSizeFrom(0)
+}
+
+// Returns the size of the cached subtrees for this parameter, of which
+// only the nodes at the given height and above are cached.  See
+// CachedHeightStore.
+func (params *Params) CachedSubTreeSizeFrom(lowest uint32) int {
 	// A cached subtree contains the merkle subtree,
 	// space for  a WOTS+ signature of the substree above it (if it's not
 	// the root) and a 64bit checksum.
-	return params.BareSubTreeSize() + int(params.WotsSignatureSize()) + 8
+	return params.bareSubTreeSizeFrom(lowest) +
+		int(params.WotsSignatureSize()) + 8
 }
 
 // Size of the private key as stored by PrivateKeyContainer.
@@ -345,6 +378,10 @@ func parseParamsFromName(name string) (*Params, Error) {
 	}
 
 	bits = strings.Split(bits[1], "_")
+	if len(bits) >= 2 && bits[0] == "SHA512" && bits[1] == "256" {
+		// The name of the hash function itself contains a separator.
+		bits = append([]string{"SHA512_256"}, bits[2:]...)
+	}
 	switch bits[0] {
 	case "SHA2":
 		ret.Func = SHA2
@@ -352,6 +389,8 @@ func parseParamsFromName(name string) (*Params, Error) {
 		ret.Func = SHAKE
 	case "SHAKE256":
 		ret.Func = SHAKE256
+	case "SHA512_256":
+		ret.Func = SHA512_256
 	default:
 		return nil, errorf("No such hash function: %s", bits[0])
 	}
@@ -434,7 +473,8 @@ func parseParamsFromName(name string) (*Params, Error) {
 	return &ret, nil
 }
 
-// List all named XMSS[MT] instances from RFC8391.
+// List all named XMSS[MT] instances from RFC8391 and NIST SP 800-208
+// and those added with RegisterAlgorithm().
 func ListNames() (names []string) {
 	names = make([]string, len(registry))
 	for i, entry := range registry {
@@ -452,12 +492,15 @@ func ListNames2() (names []string) {
 		p.D = d
 		names = append(names, p.String())
 	}
-	for _, h := range []HashFunc{SHA2, SHAKE, SHAKE256} {
+	for _, h := range []HashFunc{SHA2, SHAKE, SHAKE256, SHA512_256} {
 		for _, w := range []uint16{4, 16, 256} {
 			for _, n := range []uint32{16, 24, 32, 64} {
 				if h == SHAKE256 && (n == 64 || n == 16) {
 					continue
 				}
+				if h == SHA512_256 && n == 64 {
+					continue
+				}
 				p.Func = h
 				p.WotsW = w
 				p.N = n
@@ -529,6 +572,16 @@ func (params *Params) WotsSignatureSize() uint32 {
 	return params.WotsLen() * params.N
 }
 
+// Returns the size of signatures with these parameters.
+func (params *Params) SignatureSize() uint32 {
+	indexBytes := uint32(4)
+	if params.D > 1 {
+		indexBytes = (params.FullHeight + 7) / 8
+	}
+	return indexBytes + params.N + params.D*params.WotsSignatureSize() +
+		params.FullHeight*params.N
+}
+
 // Returns the maximum signature sequence number
 func (params *Params) MaxSignatureSeqNo() uint64 {
 	return (1 << params.FullHeight) - 1
@@ -559,6 +612,40 @@ func (ctx *Context) ensureNameAndOidAreSet() bool {
 	return false
 }
 
+// Returns nil if the public keys and signatures of this Context can be
+// used with other and vice versa.  This is the case if they have the same
+// parameters, even if, for instance, only one of them is in strict mode.
+// Otherwise returns an ErrMalformed error that lists the parameters that
+// differ.
+func (ctx *Context) CheckCompatible(other *Context) Error {
+	return checkCompatibleParams("Context", ctx.p, other.p)
+}
+
+// Returns nil if what, which has parameters got, can be used with
+// parameters expected.  Otherwise returns an error that lists the
+// parameters that differ.
+func checkCompatibleParams(what string, got, expected Params) Error {
+	if got == expected {
+		return nil
+	}
+	var diffs []string
+	diff := func(name string, x, y interface{}) {
+		if x != y {
+			diffs = append(diffs, fmt.Sprintf("%s is %v instead of %v",
+				name, x, y))
+		}
+	}
+	diff("Func", got.Func, expected.Func)
+	diff("N", got.N, expected.N)
+	diff("FullHeight", got.FullHeight, expected.FullHeight)
+	diff("D", got.D, expected.D)
+	diff("WotsW", got.WotsW, expected.WotsW)
+	prfNames := map[PrfConstruction]string{RFC: "RFC", NIST: "NIST"}
+	diff("Prf", prfNames[got.Prf], prfNames[expected.Prf])
+	return kindErrorf(ErrMalformed, "%s is for %s instead of %s: %s",
+		what, got, expected, strings.Join(diffs, ", "))
+}
+
 // Returns the name of the XMSSMT instance and an empty string if it has
 // no name.
 func (ctx *Context) Name() string {
@@ -616,9 +703,48 @@ var registryNameLut map[string]regEntry
 var registryOidLut map[uint32]regEntry
 var registryOidMTLut map[uint32]regEntry
 
-// Initializes algorithm lookup tables.
+// Adds a named XMSS[MT] algorithm with the given OID to the registry, such
+// that it can be used with NewContextFromName(), NewContextFromOid() and
+// ParamsFromName() and is listed by ListNames().  This allows the use of
+// parameter sets with privately allocated OIDs.  mt should be true for
+// XMSSMT and false for XMSS.
+//
+// NOTE The registry is not safe for concurrent modification: register
+//      algorithms before using the package, for instance in an init().
+func RegisterAlgorithm(name string, oid uint32, params Params, mt bool) Error {
+	if oid == 0 {
+		return errorf("OID 0 is reserved")
+	}
+	if _, ok := registryNameLut[name]; ok {
+		return errorf("An algorithm named %s already exists", name)
+	}
+	lut := registryOidLut
+	if mt {
+		lut = registryOidMTLut
+	}
+	if entry, ok := lut[oid]; ok {
+		return errorf("OID %d is already used by %s", oid, entry.name)
+	}
+	if !mt && params.D != 1 {
+		return errorf("XMSS requires D=1")
+	}
+	if _, err := NewContext(params); err != nil {
+		return wrapErrorf(err, "Invalid parameters")
+	}
+	entry := regEntry{name, mt, oid, params}
+	registry = append(registry, entry)
+	registryNameLut[name] = entry
+	lut[oid] = entry
+	return nil
+}
+
 func init() {
 	log = &dummyLogger{}
+	initRegistryLuts()
+}
+
+// Initializes algorithm lookup tables.
+func initRegistryLuts() {
 	registryNameLut = make(map[string]regEntry)
 	registryOidLut = make(map[uint32]regEntry)
 	registryOidMTLut = make(map[uint32]regEntry)
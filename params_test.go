@@ -1,8 +1,12 @@
 package xmssmt
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestBinaryUnmarshalingNamedParams(t *testing.T) {
@@ -80,3 +84,231 @@ func TestParseParams(t *testing.T) {
 		}
 	}
 }
+
+func TestSignatureSize(t *testing.T) {
+	for _, name := range ListNames2() {
+		ctx, err := NewContextFromName2(name)
+		if err != nil {
+			t.Fatalf("NewContextFromName2(%s): %v", name, err)
+		}
+		if ctx.p.SignatureSize() != ctx.SignatureSize() {
+			t.Fatalf("%s: Params.SignatureSize() = %d != %d", name,
+				ctx.p.SignatureSize(), ctx.SignatureSize())
+		}
+	}
+}
+
+func TestParseParamsSHA512_256(t *testing.T) {
+	params, err := ParamsFromName2("XMSSMT-SHA512_256_20/2_256")
+	if err != nil {
+		t.Fatalf("ParamsFromName2(): %v", err)
+	}
+	expected := Params{SHA512_256, 32, 20, 2, 16, RFC}
+	if *params != expected {
+		t.Fatalf("Parsed as %v instead of %v", *params, expected)
+	}
+	if params.String() != "XMSSMT-SHA512_256_20/2_256" {
+		t.Fatalf("String() = %s", params.String())
+	}
+	testBinaryUnmarshalingCustomParams(params, t)
+	if name, oid := params.LookupNameAndOid(); name != "" || oid != 0 {
+		t.Fatalf("SHA512_256 instance should not have a name or OID")
+	}
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	// Restore the registry afterwards, as other tests iterate over it.
+	oldRegistry := registry
+	defer func() {
+		registry = oldRegistry
+		initRegistryLuts()
+	}()
+
+	params := Params{SHAKE, 16, 20, 4, 16, RFC}
+	err := RegisterAlgorithm("XMSSMT-PRIVATE", 0x80000001, params, true)
+	if err != nil {
+		t.Fatalf("RegisterAlgorithm(): %v", err)
+	}
+
+	ctx := NewContextFromName("XMSSMT-PRIVATE")
+	if ctx == nil || ctx.Params() != params || ctx.Oid() != 0x80000001 {
+		t.Fatalf("NewContextFromName() did not find registered algorithm")
+	}
+	ctx = NewContextFromOid(true, 0x80000001)
+	if ctx == nil || ctx.Name() != "XMSSMT-PRIVATE" {
+		t.Fatalf("NewContextFromOid() did not find registered algorithm")
+	}
+	if NewContextFromOid(false, 0x80000001) != nil {
+		t.Fatalf("NewContextFromOid() found XMSSMT algorithm for XMSS")
+	}
+	found := false
+	for _, name := range ListNames() {
+		if name == "XMSSMT-PRIVATE" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("ListNames() does not list registered algorithm")
+	}
+
+	if RegisterAlgorithm("XMSSMT-PRIVATE", 0x80000002, params, true) == nil {
+		t.Fatalf("RegisterAlgorithm() allowed duplicate name")
+	}
+	if RegisterAlgorithm("XMSSMT-PRIVATE2", 0x1, params, true) == nil {
+		t.Fatalf("RegisterAlgorithm() allowed duplicate OID")
+	}
+	if RegisterAlgorithm("XMSS-PRIVATE", 0x80000002, params, false) == nil {
+		t.Fatalf("RegisterAlgorithm() allowed XMSS with D>1")
+	}
+	params.N = 8
+	if RegisterAlgorithm("XMSSMT-PRIVATE2", 0x80000002, params, true) == nil {
+		t.Fatalf("RegisterAlgorithm() allowed invalid parameters")
+	}
+}
+
+func TestSuggestParams(t *testing.T) {
+	ss, err := SuggestParams(SuggestOptions{
+		Signatures:     1 << 30,
+		MaxSignLatency: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("SuggestParams(): %v", err)
+	}
+	if len(ss) == 0 {
+		t.Fatalf("SuggestParams(): no suggestions")
+	}
+	for i, s := range ss {
+		if s.Params.MaxSignatureSeqNo()+1 < 1<<30 {
+			t.Fatalf("%s allows too few signatures", s.Params)
+		}
+		if s.SignLatency > 10*time.Second {
+			t.Fatalf("%s exceeds the sign latency", s.Params)
+		}
+		if _, err := NewContext(s.Params); err != nil {
+			t.Fatalf("%s: NewContext(): %v", s.Params, err)
+		}
+		if i > 0 && ss[i-1].SignatureSize > s.SignatureSize {
+			t.Fatalf("Suggestions are not sorted by signature size")
+		}
+	}
+
+	ss, err = SuggestParams(SuggestOptions{
+		Signatures: 1000,
+		Funcs:      []HashFunc{SHAKE},
+		N:          16,
+		Measure:    true,
+	})
+	if err != nil {
+		t.Fatalf("SuggestParams(): %v", err)
+	}
+	if ss[0].Params.FullHeight != 10 || ss[0].VerifyTime == 0 {
+		t.Fatalf("Unexpected suggestion %v", ss[0])
+	}
+
+	if _, err = SuggestParams(SuggestOptions{Signatures: 1000,
+		Funcs: []HashFunc{SHA512_256}, N: 64}); err == nil {
+		t.Fatalf("SuggestParams() should reject SHA512_256 with N=64")
+	}
+}
+
+func TestCacheSizeEstimate(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	params := Params{SHAKE, 16, 8, 2, 16, RFC}
+	ctx, err2 := NewContext(params)
+	if err2 != nil {
+		t.Fatalf("NewContext(): %v", err2)
+	}
+	path := filepath.Join(dir, "key")
+	sk, _, err2 := ctx.GenerateKeyPair(path)
+	if err2 != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err2)
+	}
+	sk.Close()
+
+	est := params.CacheSizeEstimate(0)
+	if est.SubTrees != 2 || est.CachedSubTreeSize != params.CachedSubTreeSize() {
+		t.Fatalf("Unexpected estimate %+v", est)
+	}
+	fi, err := os.Stat(path + ".cache")
+	if err != nil {
+		t.Fatalf("Stat(): %v", err)
+	}
+	if subTreesMmaped && uint64(fi.Size()) > est.FileSize {
+		t.Fatalf("Cache file is %d bytes; estimated %d", fi.Size(),
+			est.FileSize)
+	}
+
+	work := params.KeygenWorkEstimate()
+	if work.SubTrees != 2 || work.Leafs != 32 || work.Time == 0 {
+		t.Fatalf("Unexpected estimate %+v", work)
+	}
+	if work.Hashes != 2*(16*params.leafHashes()+15) {
+		t.Fatalf("Unexpected number of hashes %d", work.Hashes)
+	}
+}
+
+func TestLatencyProfile(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err2 := NewContext(Params{SHAKE, 16, 6, 3, 16, RFC})
+	if err2 != nil {
+		t.Fatalf("NewContext(): %v", err2)
+	}
+	ctx.Threads = 1
+	defer ctx.Close()
+	sk, _, err2 := ctx.GenerateKeyPair(filepath.Join(dir, "key"))
+	if err2 != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err2)
+	}
+	defer sk.Close()
+
+	prof, err2 := ctx.LatencyProfile(nil)
+	if err2 != nil {
+		t.Fatalf("LatencyProfile(): %v", err2)
+	}
+	if prof.FastSign == 0 || prof.SlowSign <= prof.FastSign ||
+		prof.SlowSignInterval != 4 {
+		t.Fatalf("Unexpected profile %+v", prof)
+	}
+
+	// The subtrees for the first four signatures are cached.  The fifth
+	// needs a new subtree on the lowest layer and the seventeenth one on
+	// the layer above as well.
+	subTree := prof.SlowSign - prof.FastSign
+	for seqNo := uint64(0); seqNo < 17; seqNo++ {
+		prof, err2 = ctx.LatencyProfile(sk)
+		if err2 != nil {
+			t.Fatalf("LatencyProfile(): %v", err2)
+		}
+		next := 4 - seqNo%4
+		if seqNo%4 == 0 && seqNo != 0 {
+			next = 0
+		}
+		missing := time.Duration(1)
+		if seqNo+next == 16 {
+			missing = 2
+		}
+		if prof.NextSlowSign != next ||
+			prof.NextSlowSignTime != prof.FastSign+missing*subTree {
+			t.Fatalf("Unexpected profile %+v at %d", prof, seqNo)
+		}
+		if _, err2 = sk.Sign([]byte("message")); err2 != nil {
+			t.Fatalf("Sign(): %v", err2)
+		}
+	}
+}
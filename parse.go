@@ -0,0 +1,35 @@
+package xmssmt
+
+// Contains the parsers for untrusted input, which are the entry points
+// for fuzzing.
+
+// Parses compressed parameters as written by Params.MarshalBinary() and
+// checks that they are supported.
+func ParseParams(buf []byte) (*Params, Error) {
+	var params Params
+	if err := params.UnmarshalBinary(buf); err != nil {
+		return nil, wrapErrorf(err, "Failed to parse parameters")
+	}
+	if _, err := NewContext(params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// Parses a signature as written by Signature.MarshalBinary().
+func ParseSignature(buf []byte) (*Signature, Error) {
+	var sig Signature
+	if err := sig.UnmarshalBinary(buf); err != nil {
+		return nil, wrapErrorf(err, "Failed to parse signature")
+	}
+	return &sig, nil
+}
+
+// Parses a public key as written by PublicKey.MarshalBinary().
+func ParsePublicKey(buf []byte) (*PublicKey, Error) {
+	var pk PublicKey
+	if err := pk.UnmarshalBinary(buf); err != nil {
+		return nil, wrapErrorf(err, "Failed to parse public key")
+	}
+	return &pk, nil
+}
@@ -0,0 +1,139 @@
+package xmssmt
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Pool of worker goroutines shared by all operations on a Context.
+// Each worker has its own scratchpad.
+type workerPool struct {
+	jobs chan func(pad scratchPad)
+	wg   sync.WaitGroup
+}
+
+// Returns the worker pool of the Context, starting it if it isn't
+// running yet.
+func (ctx *Context) workerPool() *workerPool {
+	ctx.poolMux.Lock()
+	defer ctx.poolMux.Unlock()
+
+	if ctx.pool != nil {
+		return ctx.pool
+	}
+
+	threads := ctx.threads()
+	pool := &workerPool{jobs: make(chan func(pad scratchPad))}
+	pool.wg.Add(threads)
+	for i := 0; i < threads; i++ {
+		go func() {
+			pad := ctx.newScratchPad()
+			for job := range pool.jobs {
+				sem := acquireWorkerSlot()
+				job(pad)
+				pad.zeroizeIfEnabled()
+				releaseWorkerSlot(sem)
+			}
+			pad.zeroize()
+			pool.wg.Done()
+		}()
+	}
+	ctx.pool = pool
+	return pool
+}
+
+// Returns the number of workers to start, see Context.Threads.
+func (ctx *Context) threads() int {
+	if ctx.Threads <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return ctx.Threads
+}
+
+// Stops the worker goroutines of this Context.
+//
+// Close must not be called concurrently with other operations that use
+// this Context.  The Context can still be used after it has been closed,
+// in which case the workers will be started again.
+func (ctx *Context) Close() {
+	ctx.poolMux.Lock()
+	pool := ctx.pool
+	ctx.pool = nil
+	ctx.poolMux.Unlock()
+
+	if pool == nil {
+		return
+	}
+
+	close(pool.jobs)
+	pool.wg.Wait()
+}
+
+// Returns a scratchpad from the pool of scratchpads of this Context,
+// which are reused by concurrent short operations such as Verify().
+// Return it with putScratchPad().
+func (ctx *Context) getScratchPad() *scratchPad {
+	if pad, ok := ctx.padPool.Get().(*scratchPad); ok {
+		return pad
+	}
+	pad := ctx.newScratchPad()
+	return &pad
+}
+
+// Returns a scratchpad obtained with getScratchPad() to the pool.
+func (ctx *Context) putScratchPad(pad *scratchPad) {
+	pad.zeroizeIfEnabled()
+	ctx.padPool.Put(pad)
+}
+
+// Limits the number of workers of all Contexts that run a job at the
+// same time.  See SetMaxWorkers().
+var workerSlots struct {
+	mux sync.Mutex
+	max int           // maximum set by SetMaxWorkers(); 0 for GOMAXPROCS
+	sem chan struct{} // semaphore; nil if not created yet
+}
+
+// Sets the maximum number of workers that run at the same time, summed
+// over all Contexts.  This prevents, for instance, many Contexts with
+// Threads set to the number of CPUs from oversubscribing the machine
+// when their subtrees are generated concurrently.  If max is zero or
+// negative, the limit is GOMAXPROCS at the time the limit is first needed,
+// which is the default.
+//
+// Jobs that have already started keep their slot until they are done, so
+// when the limit is lowered, more workers might briefly run at the same
+// time.
+func SetMaxWorkers(max int) {
+	if max < 0 {
+		max = 0
+	}
+	workerSlots.mux.Lock()
+	workerSlots.max = max
+	workerSlots.sem = nil
+	workerSlots.mux.Unlock()
+}
+
+// Waits until a worker may run a job.  Returns the semaphore to pass to
+// releaseWorkerSlot() when the job is done.
+func acquireWorkerSlot() chan struct{} {
+	workerSlots.mux.Lock()
+	sem := workerSlots.sem
+	if sem == nil {
+		max := workerSlots.max
+		if max == 0 {
+			max = runtime.GOMAXPROCS(0)
+		}
+		sem = make(chan struct{}, max)
+		workerSlots.sem = sem
+	}
+	workerSlots.mux.Unlock()
+
+	sem <- struct{}{}
+	return sem
+}
+
+// Gives up the slot obtained with acquireWorkerSlot().
+func releaseWorkerSlot(sem chan struct{}) {
+	<-sem
+}
@@ -0,0 +1,7 @@
+// +build race
+
+package xmssmt
+
+// The race detector drops entries of a sync.Pool at random, so the tests
+// that count allocations are skipped when it is enabled.
+const raceEnabled = true
@@ -0,0 +1,48 @@
+package xmssmt
+
+// Contains the option to mix additional entropy into the randomizer R
+// of signatures.
+
+import (
+	"io"
+)
+
+// Sets the source of additional entropy that is mixed into the randomizer
+// R of the signatures created with this Context.  Passing nil restores the
+// default.  Has to be called before the Context is used.
+//
+// By default signing is deterministic, as in RFC 8391: R is derived from
+// the secret SK_PRF and the signature sequence number, so signing the same
+// message with the same sequence number gives the same signature.  With
+// a source of entropy, such as crypto/rand.Reader, N bytes are read for
+// every signature and XORed into R.  R is part of the signature, so
+// verification is not affected.  As R is still derived from SK_PRF as
+// well, a source that is predictable does not make the signatures weaker
+// than those created without it.
+//
+// NOTE The source is read from different goroutines concurrently.
+func (ctx *Context) SetRandomizerEntropy(r io.Reader) {
+	ctx.drvEntropy = r
+}
+
+// Returns the source of entropy for the randomizer, or nil if signing is
+// deterministic.  See SetRandomizerEntropy().
+func (ctx *Context) RandomizerEntropy() io.Reader {
+	return ctx.drvEntropy
+}
+
+// XORs N bytes from the source of entropy, if any, into drv.
+func (ctx *Context) mixRandomizerEntropy(drv []byte) Error {
+	if ctx.drvEntropy == nil {
+		return nil
+	}
+	buf := make([]byte, len(drv))
+	if _, err := io.ReadFull(ctx.drvEntropy, buf); err != nil {
+		return wrapErrorf(err, "Failed to read entropy for randomizer")
+	}
+	for i := range drv {
+		drv[i] ^= buf[i]
+	}
+	zeroize(buf)
+	return nil
+}
@@ -0,0 +1,66 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRandomizerEntropy(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+	sk.DangerousAllowSeqNoRegression()
+
+	// By default, signing is deterministic.
+	msg := []byte("test message")
+	sig1, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	sk.DangerousSetSeqNo(0)
+	sig2, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if !bytes.Equal(sig1.drv, sig2.drv) {
+		t.Fatalf("Signing is not deterministic")
+	}
+
+	entropy := make([]byte, 16)
+	entropy[3] = 0x42
+	ctx.SetRandomizerEntropy(bytes.NewReader(entropy))
+	sk.DangerousSetSeqNo(0)
+	sig3, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	sig1.drv[3] ^= 0x42
+	if !bytes.Equal(sig1.drv, sig3.drv) {
+		t.Fatalf("Entropy was not mixed into the randomizer")
+	}
+	if ok, err := pk.Verify(sig3, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+
+	// The source is exhausted.
+	if _, err = sk.Sign(msg); err == nil {
+		t.Fatalf("Sign() ignored failure to read entropy")
+	}
+}
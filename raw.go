@@ -0,0 +1,71 @@
+package xmssmt
+
+// Contains the raw encodings of public keys and signatures, which do not
+// identify the instance, for protocols that carry the algorithm identifier
+// separately, such as DNSSEC or TUF metadata.
+
+// Returns the public key as root ‖ pubSeed, without OID or compressed
+// parameters.  The receiver has to know the instance, see
+// Context.UnmarshalRawPublicKey().
+func (pk *PublicKey) MarshalRaw() []byte {
+	ret := make([]byte, 0, 2*pk.ctx.p.N)
+	ret = append(ret, pk.root...)
+	return append(ret, pk.pubSeed...)
+}
+
+// Decodes a public key for this instance encoded by PublicKey.MarshalRaw().
+func (ctx *Context) UnmarshalRawPublicKey(buf []byte) (*PublicKey, Error) {
+	N := ctx.p.N
+	if uint32(len(buf)) != 2*N {
+		return nil, kindErrorf(ErrMalformed,
+			"Public key has wrong length: %d", len(buf))
+	}
+	pk := PublicKey{
+		ctx:     ctx,
+		root:    append([]byte{}, buf[:N]...),
+		pubSeed: append([]byte{}, buf[N:]...),
+	}
+	pk.ph = ctx.precomputeHashes(pk.pubSeed, nil)
+	return &pk, nil
+}
+
+// Returns the signature encoded as described in RFC 8391, without any
+// prefix.  The encoding is deterministic and its length,
+// Context.SignatureSize(), only depends on the instance.  Same as
+// MarshalStandard().
+func (sig *Signature) MarshalRaw() []byte {
+	return sig.MarshalStandard()
+}
+
+// Decodes a signature for this instance encoded by Signature.MarshalRaw().
+// Unlike Context.UnmarshalSignature(), never accepts a prefix.
+func (ctx *Context) UnmarshalRawSignature(buf []byte) (*Signature, Error) {
+	if uint32(len(buf)) != ctx.sigBytes {
+		return nil, kindErrorf(ErrMalformed,
+			"Signature has wrong length: %d", len(buf))
+	}
+	var sig Signature
+	if err := sig.decodeFrom(ctx, buf); err != nil {
+		return nil, err
+	}
+	return &sig, nil
+}
+
+// Checks whether sig is a valid signature on msg by the public key pk,
+// where the instance is given by the Context instead of the encodings.
+// Both pk and sig are in the raw encoding, see PublicKey.MarshalRaw()
+// and Signature.MarshalRaw().
+//
+// This is the entry point for protocols that carry the algorithm
+// identifier separately from the key and signature.
+func (ctx *Context) VerifyRaw(pk, sig, msg []byte) (bool, Error) {
+	rawPk, err := ctx.UnmarshalRawPublicKey(pk)
+	if err != nil {
+		return false, err
+	}
+	rawSig, err := ctx.UnmarshalRawSignature(sig)
+	if err != nil {
+		return false, err
+	}
+	return rawPk.Verify(rawSig, msg)
+}
@@ -0,0 +1,109 @@
+package xmssmt
+
+// Contains the rebuild of the cache in the background, when a private key
+// is loaded whose root is stored, but not cached.
+
+import (
+	"errors"
+	"os"
+)
+
+// Returns the root stored in the container or nil if it is not known.
+func containerRoot(ctx *Context, ctr PrivateKeyContainer) ([]byte, Error) {
+	store, ok := ctr.(RootStore)
+	if !ok {
+		return nil, nil
+	}
+	root, err := store.Root()
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to get root")
+	}
+	if len(root) == 0 {
+		return nil, nil
+	}
+	if len(root) != int(ctx.p.N) {
+		return nil, kindErrorf(ErrMalformed,
+			"Stored root has length %d instead of %d", len(root), ctx.p.N)
+	}
+	return root, nil
+}
+
+// Stores the root in the container, if it can store it.
+func setContainerRoot(ctr PrivateKeyContainer, root []byte) Error {
+	store, ok := ctr.(RootStore)
+	if !ok {
+		return nil
+	}
+	if err := store.SetRoot(root); err != nil {
+		return wrapErrorf(err, "Failed to store root")
+	}
+	return nil
+}
+
+// Opens the filesystem container for LoadPrivateKey(), which recreates
+// its cache if it can't be read.
+func openFSPrivateKeyContainerToLoad(path string) (
+	PrivateKeyContainer, Error) {
+	ctr, err := OpenFSPrivateKeyContainer(path)
+	if err != nil && fsCacheUnreadable(ctr, err) {
+		log.Logf("Cache of %s is unreadable: %v", path, err)
+		err = nil
+	}
+	return ctr, err
+}
+
+// Returns whether err, returned by OpenFSPrivateKeyContainer() together
+// with ctr, only means that the key file could be read and its cache not.
+func fsCacheUnreadable(ctr PrivateKeyContainer, err Error) bool {
+	fsCtr, ok := ctr.(*fsContainer)
+	if !ok || !fsCtr.initialized || fsCtr.cacheInitialized {
+		return false
+	}
+	return errors.Is(err, ErrCorruptCache) || errors.Is(err, ErrWrongMagic) ||
+		errors.Is(err, os.ErrNotExist)
+}
+
+// Generates the subtrees needed for the next signature in the background,
+// see prepareNextSignature().  A Sign() in the meantime waits for the
+// subtrees it needs.  Run in a separate goroutine; calls sk.wg.Done() when
+// finished.
+func (sk *PrivateKey) rebuildCache() {
+	defer sk.wg.Done()
+	pad := sk.ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	log.Logf("Rebuilding the cache from the root ...")
+	if err := sk.prepareNextSignature(pad); err != nil {
+		log.Logf("Failed to rebuild the cache: %v", err)
+		return
+	}
+	log.Logf("Finished rebuilding the cache")
+}
+
+// Generates, or checks, the subtrees needed for the next signature,
+// starting with the root subtree, which getSubTree() checks against the
+// stored root.
+func (sk *PrivateKey) prepareNextSignature(pad scratchPad) Error {
+	sk.mux.Lock()
+	seqNo := sk.seqNo
+	if seqNo < sk.seqNoStart {
+		seqNo = sk.seqNoStart
+	}
+	sk.mux.Unlock()
+
+	stas := []SubTreeAddress{{Layer: sk.ctx.p.D - 1}}
+	if uint64(seqNo) < sk.ctx.p.MaxSignatureSeqNo() {
+		stas, _ = sk.ctx.subTreePathForSeqNo(seqNo)
+	}
+	for i := len(stas) - 1; i >= 0; i-- {
+		sk.mux.Lock()
+		stop := sk.zeroized || sk.closing
+		sk.mux.Unlock()
+		if stop {
+			return errorf("Private key is being closed")
+		}
+		if _, _, err := sk.getSubTree(pad, stas[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRebuildCache(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err2 := NewContext(Params{SHA2, 16, 6, 3, 16, RFC})
+	if err2 != nil {
+		t.Fatalf("NewContext(): %v", err2)
+	}
+	sk, pk, err2 := ctx.GenerateKeyPair(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err2)
+	}
+	msg := []byte("test message")
+	if _, err2 = sk.Sign(msg); err2 != nil {
+		t.Fatalf("Sign(): %v", err2)
+	}
+	if err2 = sk.Close(); err2 != nil {
+		t.Fatalf("Close(): %v", err2)
+	}
+
+	// A cached root is used as before.
+	sk, _, _, err2 = LoadPrivateKey(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err2)
+	}
+	if sk.LoadReport().RebuildingCache {
+		t.Fatalf("Cache is rebuilt although it is intact")
+	}
+	sk.Close()
+
+	for _, cache := range [][]byte{nil, []byte("short")} {
+		if cache == nil {
+			err = os.Remove(dir + "/key.cache")
+		} else {
+			err = ioutil.WriteFile(dir+"/key.cache", cache, 0600)
+		}
+		if err != nil {
+			t.Fatalf("Failed to damage cache: %v", err)
+		}
+		sk, pk2, _, err2 := LoadPrivateKey(dir + "/key")
+		if err2 != nil {
+			t.Fatalf("LoadPrivateKey() without cache: %v", err2)
+		}
+		if !sk.LoadReport().RebuildingCache || !pk.Equal(pk2) {
+			t.Fatalf("LoadPrivateKey() did not use the stored root")
+		}
+		sig, err2 := sk.Sign(msg)
+		if err2 != nil {
+			t.Fatalf("Sign(): %v", err2)
+		}
+		if ok, err2 := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err2)
+		}
+		if err2 = sk.Close(); err2 != nil {
+			t.Fatalf("Close(): %v", err2)
+		}
+	}
+
+	// A wrong stored root is caught when the root subtree is rebuilt.
+	ctr, err2 := OpenFSPrivateKeyContainer(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer(): %v", err2)
+	}
+	if err2 = ctr.(RootStore).SetRoot(bytes.Repeat([]byte{1}, 16)); err2 != nil {
+		t.Fatalf("SetRoot(): %v", err2)
+	}
+	if err2 = ctr.ResetCache(); err2 != nil {
+		t.Fatalf("ResetCache(): %v", err2)
+	}
+	sk, _, _, err2 = LoadPrivateKeyFrom(ctr)
+	if err2 != nil {
+		t.Fatalf("LoadPrivateKeyFrom(): %v", err2)
+	}
+	defer sk.Close()
+	if _, err2 = sk.Sign(msg); err2 == nil {
+		t.Fatalf("Sign() with the wrong stored root succeeded")
+	}
+}
+
+func TestStoredRoot(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err2 := NewContext(Params{SHAKE, 16, 6, 3, 16, RFC})
+	if err2 != nil {
+		t.Fatalf("NewContext(): %v", err2)
+	}
+	sk, pk, err2 := ctx.GenerateKeyPair(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err2)
+	}
+	sk.Close()
+
+	// The public key is returned without the root subtree, which is
+	// only read when it is first used.
+	wrongRoot := bytes.Repeat([]byte{1}, 16)
+	ctr, err2 := OpenFSPrivateKeyContainer(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer(): %v", err2)
+	}
+	if err2 = ctr.(RootStore).SetRoot(wrongRoot); err2 != nil {
+		t.Fatalf("SetRoot(): %v", err2)
+	}
+	sk, pk2, _, err2 := LoadPrivateKeyFrom(ctr)
+	if err2 != nil {
+		t.Fatalf("LoadPrivateKeyFrom(): %v", err2)
+	}
+	if !bytes.Equal(pk2.root, wrongRoot) || sk.LoadReport().RebuildingCache {
+		t.Fatalf("LoadPrivateKeyFrom() did not use the stored root")
+	}
+	msg := []byte("test message")
+	if _, err2 = sk.Sign(msg); err2 == nil {
+		t.Fatalf("Sign() with the wrong stored root succeeded")
+	}
+	sk.Close()
+
+	// A key file without root gets it stored when it is loaded.
+	ctr, err2 = OpenFSPrivateKeyContainer(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer(): %v", err2)
+	}
+	if err2 = ctr.(RootStore).SetRoot(nil); err2 != nil {
+		t.Fatalf("SetRoot(): %v", err2)
+	}
+	sk, pk2, _, err2 = LoadPrivateKeyFrom(ctr)
+	if err2 != nil {
+		t.Fatalf("LoadPrivateKeyFrom(): %v", err2)
+	}
+	if !pk.Equal(pk2) {
+		t.Fatalf("LoadPrivateKeyFrom() returned the wrong public key")
+	}
+	if root, _ := ctr.(RootStore).Root(); !bytes.Equal(root, pk.root) {
+		t.Fatalf("Root has not been stored")
+	}
+	sig, err2 := sk.Sign(msg)
+	if err2 != nil {
+		t.Fatalf("Sign(): %v", err2)
+	}
+	if ok, err2 := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err2)
+	}
+	if err2 = sk.Close(); err2 != nil {
+		t.Fatalf("Close(): %v", err2)
+	}
+}
@@ -0,0 +1,238 @@
+package xmssmt
+
+// Contains the relocation of a private key to another path while it is open.
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Optional interface for a PrivateKeyContainer that can move its files
+// to another location while it is open.  See PrivateKey.MoveTo().
+type Relocator interface {
+	// Moves the container to path, where it continues to be used.  Fails
+	// if there already is a container at path.  On failure, the container
+	// is left at its old location.
+	Relocate(path string) Error
+}
+
+// Moves the private key to path, for instance to migrate it to another
+// disk, without closing it.  The container has to implement Relocator,
+// as the one returned by OpenFSPrivateKeyContainer() does.
+//
+// Waits for subtrees that are being generated and for signatures in
+// flight, as the buffers of the cached subtrees are invalidated.  Signatures
+// started in the meantime wait until the private key has been moved.
+func (sk *PrivateKey) MoveTo(path string) Error {
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+	relocator, ok := sk.ctr.(Relocator)
+	if !ok {
+		return errorf("Container does not support relocation")
+	}
+	for sk.relocating {
+		sk.cond.Wait()
+	}
+
+	// Subtrees that are being generated are written to the cache, and
+	// signatures in flight read it.
+	sk.relocating = true
+	defer func() {
+		sk.relocating = false
+		sk.cond.Broadcast()
+	}()
+	for sk.generatingSubTrees() || sk.unretiredSeqNos() != 0 {
+		sk.cond.Wait()
+	}
+
+	return relocator.Relocate(path)
+}
+
+// Returns whether a subtree is being generated.  Requires sk.mux lock.
+func (sk *PrivateKey) generatingSubTrees() bool {
+	for _, ready := range sk.subTreeReady {
+		if !ready {
+			return true
+		}
+	}
+	return false
+}
+
+// Moves the key file, cache, audit log and borrow leases to path.
+//
+// The files are copied first and then the key file is moved.  The new
+// location is locked throughout and the lock on the old location is
+// released at the end.  If path is on the same filesystem, the key file is
+// renamed, so there is always exactly one copy of it.  Otherwise, the
+// key file is written to path before it is removed from the old location.
+// If we crash in between, two copies are left behind, of which only the
+// one at path should be used.
+//
+// A crash before the key file is moved leaves an orphaned cache at path,
+// which is removed by RepairFSPrivateKey().
+func (ctr *fsContainer) Relocate(path string) Error {
+	if !ctr.initialized {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	if ctr.readOnly {
+		return errorf("Container is read-only")
+	}
+	if !ctr.cacheInitialized {
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return wrapErrorf(err, "Could not turn %s into an absolute path", path)
+	}
+	if path == ctr.path {
+		return nil
+	}
+
+	lockFilePath := path + ".lock"
+	flock, err := newFileLock(lockFilePath)
+	if err != nil {
+		return wrapErrorf(err, "Failed to create lockfile %s", lockFilePath)
+	}
+	if err = flock.TryLock(); err != nil {
+		err2 := wrapErrorf(err, "%s is locked", path)
+		err2.kind = ErrLocked
+		return err2
+	}
+	if fileExists(path) || fileExists(path+".cache") {
+		flock.Unlock()
+		return errorf("%s already exists", path)
+	}
+
+	// The syncer would write the key file while we move it.
+	ctr.stopSyncGoroutine()
+	defer func() {
+		if ctr.syncPolicy.period != 0 {
+			ctr.stopSyncer = make(chan struct{})
+			ctr.syncerDone = make(chan struct{})
+			go ctr.syncGoroutine(ctr.syncPolicy.period,
+				ctr.stopSyncer, ctr.syncerDone)
+		}
+	}()
+
+	ctr.keyMux.Lock()
+	defer ctr.keyMux.Unlock()
+
+	// Write back and close the cache and audit log, so we can copy them.
+	if err2 := ctr.syncAuditLog(); err2 != nil {
+		flock.Unlock()
+		return err2
+	}
+	if ctr.auditFile != nil {
+		ctr.auditFile.Close()
+		ctr.auditFile = nil
+	}
+	if err = ctr.closeCache(); err != nil {
+		flock.Unlock()
+		ctr.reopenCache()
+		return wrapErrorf(err, "Failed to close cache")
+	}
+
+	// Copy the files next to the key file.
+	var copied []string
+	for _, suffix := range []string{".audit", ".borrows", ".cache"} {
+		if !fileExists(ctr.path + suffix) {
+			continue
+		}
+		copied = append(copied, path+suffix)
+		if err = copyFileSynced(ctr.path+suffix, path+suffix); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = syncDir(filepath.Dir(path))
+	}
+	if err != nil {
+		for _, copyPath := range copied {
+			os.Remove(copyPath)
+		}
+		flock.Unlock()
+		ctr.reopenCache()
+		return wrapErrorf(err, "Failed to copy files to %s", path)
+	}
+
+	// Move the key file.
+	oldPath := ctr.path
+	if err = replaceFile(oldPath, path); err == nil {
+		ctr.path = path
+		if ctr.dirty {
+			if err2 := ctr.writeKeyFile(true); err2 != nil {
+				log.Logf("Failed to sync moved key file: %v", err2)
+			}
+		}
+	} else {
+		log.Logf("Could not rename %s to %s: %v.  Copying it instead.",
+			oldPath, path, err)
+		ctr.path = path
+		if err2 := ctr.writeKeyFile(true); err2 != nil {
+			ctr.path = oldPath
+			for _, copyPath := range copied {
+				os.Remove(copyPath)
+			}
+			flock.Unlock()
+			ctr.reopenCache()
+			return err2
+		}
+		if err = os.Remove(oldPath); err != nil {
+			log.Logf("Failed to remove old key file %s: %v", oldPath, err)
+		}
+	}
+
+	// Clean up the old location.  The key has been moved, so we only log
+	// failures.
+	for _, suffix := range []string{".audit", ".borrows", ".cache"} {
+		err = os.Remove(oldPath + suffix)
+		if err != nil && !os.IsNotExist(err) {
+			log.Logf("Failed to remove %s%s: %v", oldPath, suffix, err)
+		}
+	}
+	if err = syncDir(filepath.Dir(oldPath)); err != nil {
+		log.Logf("Failed to sync %s: %v", filepath.Dir(oldPath), err)
+	}
+	if err = ctr.flock.Unlock(); err != nil {
+		log.Logf("Failed to release lock on %s: %v", oldPath, err)
+	}
+	ctr.flock = flock
+
+	if err2 := ctr.openCache(); err2 != nil {
+		return wrapErrorf(err2, "Failed to open moved cache")
+	}
+	return nil
+}
+
+// Reopens the cache after a failed Relocate(), which is logged.
+func (ctr *fsContainer) reopenCache() {
+	if err := ctr.openCache(); err != nil {
+		log.Logf("Failed to reopen cache %s.cache: %v", ctr.path, err)
+	}
+}
+
+// Copies the file at from to the new file at to, which is synced.
+func copyFileSynced(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,142 @@
+package xmssmt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestMoveTo(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err = os.Mkdir(dir+"/new", 0700); err != nil {
+		t.Fatalf("Mkdir(): %v", err)
+	}
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	sk2, _, err := ctx.GenerateKeyPair(dir + "/other")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	sk2.Close()
+	if err = sk.EnableAuditLog(); err != nil {
+		t.Fatalf("EnableAuditLog(): %v", err)
+	}
+
+	msg := []byte("test message")
+	for i := 0; i < 20; i++ {
+		if _, err = sk.Sign(msg); err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+	}
+
+	if err = sk.MoveTo(dir + "/other"); err == nil {
+		t.Fatalf("MoveTo() overwrote another key")
+	}
+	if err = sk.MoveTo(dir + "/new/key"); err != nil {
+		t.Fatalf("MoveTo(): %v", err)
+	}
+	for _, suffix := range []string{"", ".cache", ".lock", ".audit"} {
+		if fileExists(dir + "/key" + suffix) {
+			t.Fatalf("MoveTo() left %s behind", "key"+suffix)
+		}
+	}
+
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+
+	sk, _, lostSigs, err := LoadPrivateKey(dir + "/new/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	defer sk.Close()
+	if lostSigs != 0 || sk.SeqNo() != 21 {
+		t.Fatalf("Moved key has seqNo %d and %d lost signatures",
+			sk.SeqNo(), lostSigs)
+	}
+	if report := sk.LoadReport(); report.CachedSubTrees != 2 {
+		t.Fatalf("Moved key has %d cached subtrees", report.CachedSubTrees)
+	}
+	entries, err := sk.AuditLog()
+	if err != nil {
+		t.Fatalf("AuditLog(): %v", err)
+	}
+	if len(entries) != 21 {
+		t.Fatalf("Moved audit log has %d entries", len(entries))
+	}
+}
+
+func TestMoveToWhileSigning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key0")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				sig, err := sk.Sign(msg)
+				if err != nil {
+					t.Errorf("Sign(): %v", err)
+					return
+				}
+				if ok, err := pk.Verify(sig, msg); !ok {
+					t.Errorf("Verify(): %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 4; i++ {
+		if err = sk.MoveTo(fmt.Sprintf("%s/key%d", dir, i)); err != nil {
+			t.Errorf("MoveTo(): %v", err)
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
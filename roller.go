@@ -0,0 +1,382 @@
+package xmssmt
+
+// Contains the KeyRoller, which rotates keys automatically.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Prefix of the messages signed to certify the successor of a key.
+var keyCertPrefix = []byte("XMSSMT-KEY-ROTATION")
+
+// Certifies the successor of a key: Signature is a signature by the previous
+// key on PublicKey.  See KeyRoller.
+type KeyCertificate struct {
+	PublicKey *PublicKey
+	Signature *Signature
+}
+
+// Manages a chain of private keys stored in a directory.  When the active
+// key is nearly exhausted, the KeyRoller generates a successor key and
+// certifies it with the active key.  Verifiers that trust the first
+// key of the chain can use VerifyKeyChain() to establish trust in the
+// current key.
+//
+// The directory contains the private keys key-0, key-1, ... (together with
+// their .cache and .lock files), a file chain with the certificates and
+// a file root with the public key of key-0.
+type KeyRoller struct {
+	ctx       *Context
+	dir       string
+	threshold uint64
+
+	mux    sync.Mutex
+	root   *PublicKey       // public key of key-0
+	chain  []KeyCertificate // certificates of key-1, key-2, ...
+	active *PrivateKey      // the key with index len(chain)
+}
+
+// Opens the KeyRoller in the given directory, generating the first
+// key with the given Context if it does not exist yet.
+//
+// The active key is rotated when it has threshold signatures or fewer left.
+// As one signature is required to certify the successor, threshold must be
+// at least 1.
+//
+// NOTE Do not forget to Close() the KeyRoller.
+func OpenKeyRoller(ctx *Context, dir string, threshold uint64) (
+	*KeyRoller, Error) {
+	if threshold == 0 {
+		return nil, errorf("Threshold should be at least 1")
+	}
+
+	kr := &KeyRoller{
+		ctx:       ctx,
+		dir:       dir,
+		threshold: threshold,
+	}
+
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to create directory %s", dir)
+	}
+
+	if err2 := kr.readChain(); err2 != nil {
+		return nil, err2
+	}
+	if err2 := kr.readRoot(); err2 != nil {
+		return nil, err2
+	}
+
+	if kr.root == nil {
+		_, err = os.Stat(kr.keyPath(0))
+		if os.IsNotExist(err) && len(kr.chain) == 0 {
+			var err2 Error
+			kr.active, kr.root, err2 = ctx.GenerateKeyPair(kr.keyPath(0))
+			if err2 != nil {
+				return nil, err2
+			}
+			if err2 = kr.writeRoot(); err2 != nil {
+				kr.active.Close()
+				return nil, err2
+			}
+			return kr, nil
+		}
+
+		// Directories of older versions of this package don't store the
+		// root.  Then we open the first key to find it.
+		var err2 Error
+		kr.active, kr.root, _, err2 = LoadPrivateKey(kr.keyPath(0))
+		if err2 != nil {
+			return nil, err2
+		}
+		if err2 = kr.writeRoot(); err2 != nil {
+			kr.active.Close()
+			return nil, err2
+		}
+		if len(kr.chain) == 0 {
+			return kr, nil
+		}
+		if err2 = kr.active.Close(); err2 != nil {
+			return nil, err2
+		}
+		kr.active = nil
+	}
+
+	if _, err2 := VerifyKeyChain(kr.root, kr.chain); err2 != nil {
+		return nil, err2
+	}
+	active, pk, _, err2 := LoadPrivateKey(kr.keyPath(len(kr.chain)))
+	if err2 != nil {
+		return nil, err2
+	}
+	kr.active = active
+	if pk.KeyID() != kr.PublicKey().KeyID() {
+		kr.active.Close()
+		return nil, errorf("%s does not match the chain",
+			kr.keyPath(len(kr.chain)))
+	}
+
+	return kr, nil
+}
+
+// Returns the path of the i-th private key.
+func (kr *KeyRoller) keyPath(i int) string {
+	return filepath.Join(kr.dir, fmt.Sprintf("key-%d", i))
+}
+
+// Returns the path of the file with the certificates.
+func (kr *KeyRoller) chainPath() string {
+	return filepath.Join(kr.dir, "chain")
+}
+
+// Returns the path of the file with the public key of the first key.
+func (kr *KeyRoller) rootPath() string {
+	return filepath.Join(kr.dir, "root")
+}
+
+// Reads the public key of the first key from the root file, if it exists.
+func (kr *KeyRoller) readRoot() Error {
+	buf, err := ioutil.ReadFile(kr.rootPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return wrapErrorf(err, "Failed to read %s", kr.rootPath())
+	}
+	root := new(PublicKey)
+	if err = root.UnmarshalText(bytes.TrimSpace(buf)); err != nil {
+		return wrapErrorf(err, "Failed to parse public key in %s",
+			kr.rootPath())
+	}
+	kr.root = root
+	return nil
+}
+
+// Atomically writes the public key of the first key to the root file.
+func (kr *KeyRoller) writeRoot() Error {
+	pkText, err := kr.root.MarshalText()
+	if err != nil {
+		return wrapErrorf(err, "Failed to marshal public key")
+	}
+	return writeFileAtomically(kr.rootPath(), append(pkText, '\n'))
+}
+
+// Reads the certificates from the chain file.
+//
+// Each line of the chain file contains a base64 encoded public key
+// and a base64 encoded signature separated by a space.
+func (kr *KeyRoller) readChain() Error {
+	buf, err := ioutil.ReadFile(kr.chainPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return wrapErrorf(err, "Failed to read %s", kr.chainPath())
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 {
+			continue
+		}
+		if len(parts) != 2 {
+			return errorf("Malformed line in %s", kr.chainPath())
+		}
+		var cert KeyCertificate
+		cert.PublicKey = new(PublicKey)
+		cert.Signature = new(Signature)
+		if err = cert.PublicKey.UnmarshalText([]byte(parts[0])); err != nil {
+			return wrapErrorf(err, "Failed to parse public key")
+		}
+		sigBuf, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return wrapErrorf(err, "Failed to decode signature")
+		}
+		if err = cert.Signature.UnmarshalBinary(sigBuf); err != nil {
+			return wrapErrorf(err, "Failed to parse signature")
+		}
+		kr.chain = append(kr.chain, cert)
+	}
+	if err = scanner.Err(); err != nil {
+		return wrapErrorf(err, "Failed to read %s", kr.chainPath())
+	}
+	return nil
+}
+
+// Atomically replaces the chain file by the current certificates.
+func (kr *KeyRoller) writeChain() Error {
+	var buf bytes.Buffer
+	for _, cert := range kr.chain {
+		pkText, err := cert.PublicKey.MarshalText()
+		if err != nil {
+			return wrapErrorf(err, "Failed to marshal public key")
+		}
+		sigBuf, err := cert.Signature.MarshalBinary()
+		if err != nil {
+			return wrapErrorf(err, "Failed to marshal signature")
+		}
+		fmt.Fprintf(&buf, "%s %s\n", pkText,
+			base64.StdEncoding.EncodeToString(sigBuf))
+	}
+
+	return writeFileAtomically(kr.chainPath(), buf.Bytes())
+}
+
+// Atomically replaces the file at path by one with the given contents.
+func writeFileAtomically(path string, buf []byte) Error {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC,
+		0600)
+	if err != nil {
+		return wrapErrorf(err, "Failed to create %s", tmpPath)
+	}
+	if _, err = file.Write(buf); err != nil {
+		file.Close()
+		return wrapErrorf(err, "Failed to write %s", tmpPath)
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return wrapErrorf(err, "Failed to sync %s", tmpPath)
+	}
+	if err = file.Close(); err != nil {
+		return wrapErrorf(err, "Failed to close %s", tmpPath)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return wrapErrorf(err, "Failed to rename %s", tmpPath)
+	}
+	return nil
+}
+
+// Returns the message signed to certify the given public key.
+func keyCertMessage(pk *PublicKey) ([]byte, Error) {
+	pkBuf, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to marshal public key")
+	}
+	return append(append([]byte{}, keyCertPrefix...), pkBuf...), nil
+}
+
+// Generates a successor of the active key, certifies it and makes it the
+// active key.  Requires kr.mux lock.
+func (kr *KeyRoller) rotate() Error {
+	next := len(kr.chain) + 1
+	log.Logf("Rotating to %s", kr.keyPath(next))
+
+	// If we crashed during a previous rotation, there might be an
+	// uncertified key at this path.  It has not been used, so it's safe
+	// to overwrite it.
+	sk, pk, err := kr.ctx.GenerateKeyPair(kr.keyPath(next))
+	if err != nil {
+		return err
+	}
+
+	msg, err := keyCertMessage(pk)
+	if err != nil {
+		sk.Close()
+		return err
+	}
+	sig, err := kr.active.Sign(msg)
+	if err != nil {
+		sk.Close()
+		return wrapErrorf(err, "Failed to certify successor key")
+	}
+
+	kr.chain = append(kr.chain, KeyCertificate{PublicKey: pk, Signature: sig})
+	if err = kr.writeChain(); err != nil {
+		kr.chain = kr.chain[:len(kr.chain)-1]
+		sk.Close()
+		return err
+	}
+
+	// The old key is retired.  We ignore errors on closing it, as the
+	// successor is already certified.
+	if err = kr.active.Close(); err != nil {
+		log.Logf("Failed to close retired key: %v", err)
+	}
+	kr.active = sk
+	return nil
+}
+
+// Signs the message with the active key, rotating it first if needed.
+func (kr *KeyRoller) Sign(msg []byte) (*Signature, Error) {
+	kr.mux.Lock()
+	defer kr.mux.Unlock()
+
+	if kr.active == nil {
+		return nil, errorf("KeyRoller is closed")
+	}
+
+	if kr.active.RemainingSignatures() <= kr.threshold {
+		if err := kr.rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return kr.active.Sign(msg)
+}
+
+// Returns the public key of the first key, which verifiers should trust.
+func (kr *KeyRoller) Root() *PublicKey {
+	return kr.root
+}
+
+// Returns the public key of the active key.
+func (kr *KeyRoller) PublicKey() *PublicKey {
+	kr.mux.Lock()
+	defer kr.mux.Unlock()
+	if len(kr.chain) == 0 {
+		return kr.root
+	}
+	return kr.chain[len(kr.chain)-1].PublicKey
+}
+
+// Returns the certificates of the successors of the first key.
+func (kr *KeyRoller) Chain() []KeyCertificate {
+	kr.mux.Lock()
+	defer kr.mux.Unlock()
+	return append([]KeyCertificate{}, kr.chain...)
+}
+
+// Closes the active key.
+func (kr *KeyRoller) Close() Error {
+	kr.mux.Lock()
+	defer kr.mux.Unlock()
+	if kr.active == nil {
+		return nil
+	}
+	err := kr.active.Close()
+	kr.active = nil
+	return err
+}
+
+// Checks the certificates of a chain starting at the trusted root and
+// returns the last public key of the chain.
+func VerifyKeyChain(root *PublicKey, chain []KeyCertificate) (
+	*PublicKey, Error) {
+	pk := root
+	for i, cert := range chain {
+		msg, err := keyCertMessage(cert.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := pk.Verify(cert.Signature, msg)
+		if err != nil {
+			return nil, wrapErrorf(err, "Certificate %d is invalid", i)
+		}
+		if !ok {
+			return nil, errorf("Certificate %d is invalid", i)
+		}
+		pk = cert.PublicKey
+	}
+	return pk, nil
+}
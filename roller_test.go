@@ -0,0 +1,97 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestKeyRoller(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 4, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+
+	if _, err = OpenKeyRoller(ctx, dir, 0); err == nil {
+		t.Fatalf("OpenKeyRoller() should reject threshold 0")
+	}
+
+	kr, err := OpenKeyRoller(ctx, dir, 2)
+	if err != nil {
+		t.Fatalf("OpenKeyRoller(): %v", err)
+	}
+	root := kr.Root()
+
+	msg := []byte("test message")
+	for i := 0; i < 40; i++ {
+		if i == 20 {
+			// Check that the KeyRoller can be reopened without opening
+			// the retired first key, which we move away meanwhile.
+			if err = kr.Close(); err != nil {
+				t.Fatalf("Close(): %v", err)
+			}
+			if err = os.Rename(dir+"/key-0", dir+"/retired"); err != nil {
+				t.Fatalf("Rename(): %v", err)
+			}
+			kr, err = OpenKeyRoller(ctx, dir, 2)
+			if err != nil {
+				t.Fatalf("OpenKeyRoller(): %v", err)
+			}
+			if err = os.Rename(dir+"/retired", dir+"/key-0"); err != nil {
+				t.Fatalf("Rename(): %v", err)
+			}
+		}
+		if i == 30 {
+			// Check that a directory without the root file can be opened.
+			if err = kr.Close(); err != nil {
+				t.Fatalf("Close(): %v", err)
+			}
+			if err = os.Remove(dir + "/root"); err != nil {
+				t.Fatalf("Remove(): %v", err)
+			}
+			kr, err = OpenKeyRoller(ctx, dir, 2)
+			if err != nil {
+				t.Fatalf("OpenKeyRoller(): %v", err)
+			}
+			if kr.Root().KeyID() != root.KeyID() {
+				t.Fatalf("OpenKeyRoller() found the wrong root")
+			}
+		}
+
+		sig, err := kr.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		pk, err := VerifyKeyChain(root, kr.Chain())
+		if err != nil {
+			t.Fatalf("VerifyKeyChain(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+
+	// Each key has 15 signatures of which 13 are used for messages
+	chain := kr.Chain()
+	if len(chain) != 3 {
+		t.Fatalf("Expected 3 certificates; got %d", len(chain))
+	}
+
+	chain[1], chain[2] = chain[2], chain[1]
+	if _, err = VerifyKeyChain(root, chain); err == nil {
+		t.Fatalf("VerifyKeyChain() accepted a broken chain")
+	}
+
+	if err = kr.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+}
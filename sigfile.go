@@ -0,0 +1,106 @@
+package xmssmt
+
+// Contains the detached signature file format.
+
+import (
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+// PEM type of a signature file.  See WriteSignatureFile().
+const signatureFileType = "XMSSMT SIGNATURE"
+
+// Headers of a signature file.
+const (
+	sigFileAlgHeader   = "Alg"
+	sigFileKeyIDHeader = "Key-Id"
+	sigFileSeqNoHeader = "Seq-No"
+)
+
+// Detached signature as read by ReadSignatureFile().
+type SignatureFile struct {
+	Alg       string // name of the instance
+	KeyID     KeyID  // of the public key that signed
+	Signature *Signature
+}
+
+// Writes sig, created with the private key of pk, as a detached signature
+// file:
+//
+//   -----BEGIN XMSSMT SIGNATURE-----
+//   Alg: XMSSMT-SHA2_20/2_256
+//   Key-Id: 8a9c4f0b3e21d756
+//   Seq-No: 42
+//
+//   <base64 of the signature as described in the RFC>
+//   -----END XMSSMT SIGNATURE-----
+//
+// This is a PEM block, so the file is text and diffs well.  The headers
+// describe the signature, such that the right public key can be looked up
+// and the use of signature sequence numbers can be audited without parsing
+// the signature.  Read it with ReadSignatureFile().
+func WriteSignatureFile(w io.Writer, pk *PublicKey, sig *Signature) Error {
+	if pk.ctx.p != sig.ctx.p {
+		return errorf("Parameters of the public key and signature differ")
+	}
+	block := pem.Block{
+		Type: signatureFileType,
+		Headers: map[string]string{
+			sigFileAlgHeader:   sig.ctx.algName(),
+			sigFileKeyIDHeader: pk.KeyID().String(),
+			sigFileSeqNoHeader: strconv.FormatUint(uint64(sig.seqNo), 10),
+		},
+		Bytes: sig.MarshalRaw(),
+	}
+	if err := pem.Encode(w, &block); err != nil {
+		return wrapErrorf(err, "Failed to write signature file")
+	}
+	return nil
+}
+
+// Reads a detached signature file as written by WriteSignatureFile().
+// Checks that the headers match the signature.
+func ReadSignatureFile(r io.Reader) (*SignatureFile, Error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to read signature file")
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil || block.Type != signatureFileType {
+		return nil, kindErrorf(ErrMalformed, "Not a signature file")
+	}
+
+	var ret SignatureFile
+	ret.Alg = block.Headers[sigFileAlgHeader]
+	ctx, err2 := NewContextFromName2(ret.Alg)
+	if err2 != nil {
+		return nil, wrapErrorf(err2, "Signature file has unknown algorithm")
+	}
+	ret.KeyID, err2 = ParseKeyID(block.Headers[sigFileKeyIDHeader])
+	if err2 != nil {
+		return nil, wrapErrorf(err2, "Signature file has invalid key ID")
+	}
+	ret.Signature, err2 = ctx.UnmarshalRawSignature(block.Bytes)
+	if err2 != nil {
+		return nil, err2
+	}
+	seqNo, err := strconv.ParseUint(block.Headers[sigFileSeqNoHeader], 10, 64)
+	if err != nil || SignatureSeqNo(seqNo) != ret.Signature.seqNo {
+		return nil, kindErrorf(ErrMalformed,
+			"Seq-No of signature file does not match signature")
+	}
+	return &ret, nil
+}
+
+// Checks the detached signature in the file on msg, as written by
+// WriteSignatureFile(), with this public key.
+func (pk *PublicKey) VerifySignatureFile(file *SignatureFile, msg []byte) (
+	bool, Error) {
+	if file.KeyID != pk.KeyID() {
+		return false, kindErrorf(ErrInvalidSignature,
+			"Signature is by key %s instead of %s", file.KeyID, pk.KeyID())
+	}
+	return pk.Verify(file.Signature, msg)
+}
@@ -0,0 +1,74 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSignatureFile(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := NewContextFromName("XMSSMT-SHAKE_20/4_256")
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+	msg := []byte("test message")
+	sk.Sign(msg)
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteSignatureFile(&buf, pk, sig); err != nil {
+		t.Fatalf("WriteSignatureFile(): %v", err)
+	}
+	text := buf.String()
+	if !strings.Contains(text, "Alg: XMSSMT-SHAKE_20/4_256\n") ||
+		!strings.Contains(text, "Key-Id: "+pk.KeyID().String()+"\n") ||
+		!strings.Contains(text, "Seq-No: 1\n") {
+		t.Fatalf("Signature file has wrong headers:\n%s", text)
+	}
+
+	file, err := ReadSignatureFile(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ReadSignatureFile(): %v", err)
+	}
+	if file.Alg != "XMSSMT-SHAKE_20/4_256" || file.KeyID != pk.KeyID() {
+		t.Fatalf("ReadSignatureFile() returned %+v", file)
+	}
+	if ok, err := pk.VerifySignatureFile(file, msg); !ok {
+		t.Fatalf("VerifySignatureFile(): %v", err)
+	}
+
+	// Another public key
+	sk2, pk2, err := ctx.GenerateKeyPair(dir + "/key2")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk2.Close()
+	if ok, _ := pk2.VerifySignatureFile(file, msg); ok {
+		t.Fatalf("VerifySignatureFile() accepted wrong key")
+	}
+
+	// Tampered header
+	text2 := strings.Replace(text, "Seq-No: 1", "Seq-No: 2", 1)
+	if _, err = ReadSignatureFile(strings.NewReader(text2)); err == nil {
+		t.Fatalf("ReadSignatureFile() accepted wrong Seq-No")
+	}
+	if _, err = ReadSignatureFile(strings.NewReader("garbage")); err == nil {
+		t.Fatalf("ReadSignatureFile() accepted garbage")
+	}
+}
@@ -0,0 +1,67 @@
+package xmssmt
+
+// Contains PrivateKey.CloseOnSignal().
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Closes the private key when the process receives one of the given
+// signals, or SIGINT or SIGTERM if none are given.
+//
+// Signature sequence numbers that are borrowed, see BorrowExactly() and
+// SetAutoBorrow(), are lost when the process is killed.  Closing the
+// private key returns the unused ones to the container, so a service
+// that is stopped with SIGTERM does not lose signatures.
+//
+// After the private key is closed, the signal is raised again.  If the
+// program has not registered a handler for it with signal.Notify(), this
+// terminates the program, just like the signal would have done without
+// CloseOnSignal().  Otherwise that handler receives the signal a second
+// time.  On platforms that can't raise signals, such as Windows, the
+// program exits with status 1 instead.
+//
+// Call the returned function to stop listening for the signals, for
+// instance before closing the private key yourself.
+func (sk *PrivateKey) CloseOnSignal(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			log.Logf("Received %v: closing private key ...", sig)
+			if err := sk.Close(); err != nil {
+				log.Logf("Failed to close private key: %v", err)
+			}
+			raiseSignal(sig)
+		case <-done:
+			signal.Stop(ch)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// Raises sig again after it was handled by CloseOnSignal().
+func raiseSignal(sig os.Signal) {
+	p, err := os.FindProcess(os.Getpid())
+	if err == nil {
+		err = p.Signal(sig)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
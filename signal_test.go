@@ -0,0 +1,76 @@
+// +build !windows,!js,!wasip1
+
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCloseOnSignal(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	if err = sk.BorrowExactly(100); err != nil {
+		t.Fatalf("BorrowExactly(): %v", err)
+	}
+	if _, err = sk.Sign([]byte("test message")); err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+
+	// Register our own handler, such that the raised signal does not
+	// terminate the test.
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, syscall.SIGUSR1)
+	defer signal.Stop(ch)
+
+	sk.CloseOnSignal(syscall.SIGUSR1)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ch:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Signal was not raised again")
+		}
+	}
+
+	sk, _, lostSigs, err := LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	if lostSigs != 0 || sk.SeqNo() != 1 {
+		t.Fatalf("Borrowed signatures were not returned: seqNo %d, lost %d",
+			sk.SeqNo(), lostSigs)
+	}
+
+	// After stop() the private key isn't closed anymore.
+	stop := sk.CloseOnSignal(syscall.SIGUSR1)
+	stop()
+	time.Sleep(10 * time.Millisecond)
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	<-ch
+	if _, err = sk.Sign([]byte("test message")); err != nil {
+		t.Fatalf("Sign() after stop(): %v", err)
+	}
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+}
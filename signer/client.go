@@ -0,0 +1,100 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Client of a signer served by NewHandler().
+type Client struct {
+	url   string
+	token string
+
+	// The http.Client used to make requests.  http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Returns a client for the signer at the given URL, eg.
+// https://signer.internal:8443, which presents the given token.
+func NewClient(url, token string) *Client {
+	return &Client{url: strings.TrimRight(url, "/"), token: token}
+}
+
+// Performs a request and returns the response body.
+func (c *Client) do(method, path string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, c.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("signer: %s: %s", resp.Status,
+			strings.TrimSpace(string(buf)))
+	}
+	return buf, nil
+}
+
+// Signs the message with the private key of the signer.
+func (c *Client) Sign(msg []byte) (*xmssmt.Signature, error) {
+	return c.SignFrom(bytes.NewReader(msg))
+}
+
+// Signs the message read from the io.Reader with the private key of the
+// signer.  The message is streamed to the signer.
+func (c *Client) SignFrom(msg io.Reader) (*xmssmt.Signature, error) {
+	buf, err := c.do(http.MethodPost, "/sign", msg)
+	if err != nil {
+		return nil, err
+	}
+	var sig xmssmt.Signature
+	if err := sig.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return &sig, nil
+}
+
+// Returns the public key of the signer.
+func (c *Client) PublicKey() (*xmssmt.PublicKey, error) {
+	buf, err := c.do(http.MethodGet, "/publickey", nil)
+	if err != nil {
+		return nil, err
+	}
+	var pk xmssmt.PublicKey
+	if err := pk.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return &pk, nil
+}
+
+// Returns the status of the private key of the signer.
+func (c *Client) Status() (*Status, error) {
+	buf, err := c.do(http.MethodGet, "/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	var status Status
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,103 @@
+// Package signer implements a simple HTTP protocol to sign with a private
+// key held by another process, such that application servers never hold
+// the stateful private key themselves.
+//
+// The server exposes the following endpoints, which all require the header
+// "Authorization: Bearer <token>".
+//
+//   POST /sign        signs the request body; returns the signature as
+//                     encoded by Signature.MarshalBinary()
+//   GET  /publickey   returns the public key as encoded by
+//                     PublicKey.MarshalBinary()
+//   GET  /status      returns a JSON encoded Status
+//
+// The protocol does not encrypt: use TLS between client and server unless
+// they are on the same host.
+package signer
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Status of the private key of a signer.
+type Status struct {
+	Alg                 string  `json:"alg"`
+	SeqNo               uint64  `json:"seqNo"` // next sequence number
+	RemainingSignatures uint64  `json:"remainingSignatures"`
+	UsedFraction        float64 `json:"usedFraction"`
+	CachedSubTrees      int     `json:"cachedSubTrees"`
+}
+
+type handler struct {
+	sk    *xmssmt.PrivateKey
+	token []byte
+}
+
+// Returns a http.Handler which signs with sk for clients that present the
+// given token.  The token should be long and random.
+func NewHandler(sk *xmssmt.PrivateKey, token string) http.Handler {
+	h := &handler{sk: sk, token: []byte(token)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sign", h.auth(h.sign))
+	mux.HandleFunc("/publickey", h.auth(h.publicKey))
+	mux.HandleFunc("/status", h.auth(h.status))
+	return mux
+}
+
+// Wraps f to check the token presented by the client.
+func (h *handler) auth(f http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expected := append([]byte("Bearer "), h.token...)
+		got := []byte(r.Header.Get("Authorization"))
+		if len(h.token) == 0 || subtle.ConstantTimeCompare(got, expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		f(w, r)
+	}
+}
+
+func (h *handler) sign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	sig, err := h.sk.SignFrom(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	buf, err2 := sig.MarshalBinary()
+	if err2 != nil {
+		http.Error(w, err2.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(buf)
+}
+
+func (h *handler) publicKey(w http.ResponseWriter, r *http.Request) {
+	buf, err := h.sk.PublicKey().MarshalBinary()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(buf)
+}
+
+func (h *handler) status(w http.ResponseWriter, r *http.Request) {
+	status := Status{
+		Alg:                 h.sk.Context().Params().String(),
+		SeqNo:               uint64(h.sk.SeqNo()),
+		RemainingSignatures: h.sk.RemainingSignatures(),
+		UsedFraction:        h.sk.UsedFraction(),
+		CachedSubTrees:      h.sk.CachedSubTrees(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&status)
+}
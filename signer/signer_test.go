@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+func TestSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := xmssmt.NewContext(xmssmt.Params{
+		Func:       xmssmt.SHAKE,
+		N:          16,
+		FullHeight: 8,
+		D:          2,
+		WotsW:      16,
+		Prf:        xmssmt.RFC,
+	})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	server := httptest.NewServer(NewHandler(sk, "secret"))
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "wrong").PublicKey(); err == nil {
+		t.Fatalf("Server accepted wrong token")
+	}
+
+	c := NewClient(server.URL, "secret")
+	pk2, err := c.PublicKey()
+	if err != nil {
+		t.Fatalf("PublicKey(): %v", err)
+	}
+	buf1, _ := pk.MarshalBinary()
+	buf2, _ := pk2.MarshalBinary()
+	if string(buf1) != string(buf2) {
+		t.Fatalf("PublicKey() returned wrong public key")
+	}
+
+	msg := []byte("test message")
+	for i := 0; i < 3; i++ {
+		sig, err := c.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+
+	status, err := c.Status()
+	if err != nil {
+		t.Fatalf("Status(): %v", err)
+	}
+	if status.SeqNo != 3 || status.RemainingSignatures != 252 {
+		t.Fatalf("Status() returned %+v", status)
+	}
+}
@@ -0,0 +1,198 @@
+package xmssmt
+
+// Contains helpers to inspect the parts of a signature.
+
+import (
+	"bytes"
+)
+
+// One layer of a Signature: a WOTS+ signature by a leaf of a subtree
+// together with the authentication path of that leaf.
+type SignatureLayer struct {
+	SubTree       SubTreeAddress // the subtree that signed
+	Leaf          uint32         // index of the signing leaf in the subtree
+	WotsSignature []byte
+
+	// The sibling nodes on the path from the leaf up to the root
+	// of the subtree.  AuthPath[0] is the sibling of the leaf.
+	AuthPath [][]byte
+}
+
+// Values recomputed from a layer of a signature.
+// See PublicKey.InspectSignature().
+type SignatureLayerInfo struct {
+	SignatureLayer
+
+	// The value signed by the WOTS+ signature: the message hash on the
+	// lowest layer and the root of the subtree one layer below otherwise.
+	Message []byte
+
+	WotsPublicKey []byte // WOTS+ public key recovered from the signature
+	LeafNode      []byte // leaf of the subtree: the L-tree of WotsPublicKey
+	Root          []byte // root of the subtree computed with the AuthPath
+}
+
+// Returns the layers of the signature, starting with the lowest layer,
+// which signs the message.  The returned slices are copies.
+func (sig *Signature) Layers() []SignatureLayer {
+	N := sig.ctx.p.N
+	staPath, leafs := sig.ctx.subTreePathForSeqNo(sig.seqNo)
+	ret := make([]SignatureLayer, len(sig.sigs))
+	for i, ss := range sig.sigs {
+		authPath := make([][]byte, len(ss.authPath)/int(N))
+		for j := 0; j < len(authPath); j++ {
+			authPath[j] = append([]byte{},
+				ss.authPath[uint32(j)*N:uint32(j+1)*N]...)
+		}
+		ret[i] = SignatureLayer{
+			SubTree:       staPath[i],
+			Leaf:          leafs[i],
+			WotsSignature: append([]byte{}, ss.wotsSig...),
+			AuthPath:      authPath,
+		}
+	}
+	return ret
+}
+
+// Assembles a signature from its parts, for instance from WOTS+ signatures
+// created by an HSM.  drv is the digest randomized value (R) and layers
+// are as returned by Signature.Layers(): starting with the lowest layer.
+// The SubTree and Leaf of each layer have to match seqNo.  The parts are
+// copied.
+//
+// The signature is not checked: use PublicKey.Verify() for that.
+func NewSignature(ctx *Context, seqNo SignatureSeqNo, drv []byte,
+	layers []SignatureLayer) (*Signature, Error) {
+	N := ctx.p.N
+	if uint64(seqNo) > ctx.p.MaxSignatureSeqNo() {
+		return nil, kindErrorf(ErrMalformed,
+			"Signature sequence number %d is out of range", seqNo)
+	}
+	if uint32(len(drv)) != N {
+		return nil, kindErrorf(ErrMalformed, "drv should have length %d", N)
+	}
+	if uint32(len(layers)) != ctx.p.D {
+		return nil, kindErrorf(ErrMalformed,
+			"Signature should have %d layers instead of %d",
+			ctx.p.D, len(layers))
+	}
+
+	staPath, leafs := ctx.subTreePathForSeqNo(seqNo)
+	authPathBytes := ctx.treeHeight * N
+	buf := make([]byte, N+ctx.p.D*(ctx.wotsSigBytes+authPathBytes))
+	sig := Signature{
+		ctx:   ctx,
+		seqNo: seqNo,
+		sigs:  make([]subTreeSig, ctx.p.D),
+	}
+	sig.drv, buf = buf[:N:N], buf[N:]
+	copy(sig.drv, drv)
+	for i, layer := range layers {
+		if layer.SubTree != staPath[i] || layer.Leaf != leafs[i] {
+			return nil, kindErrorf(ErrMalformed,
+				"Layer %d is by leaf %d of subtree %v instead of leaf %d "+
+					"of subtree %v", i, layer.Leaf, layer.SubTree,
+				leafs[i], staPath[i])
+		}
+		if uint32(len(layer.WotsSignature)) != ctx.wotsSigBytes {
+			return nil, kindErrorf(ErrMalformed,
+				"WOTS+ signature of layer %d should have length %d",
+				i, ctx.wotsSigBytes)
+		}
+		if uint32(len(layer.AuthPath)) != ctx.treeHeight {
+			return nil, kindErrorf(ErrMalformed,
+				"Authentication path of layer %d should have %d nodes",
+				i, ctx.treeHeight)
+		}
+		ss := &sig.sigs[i]
+		ss.wotsSig, buf = buf[:ctx.wotsSigBytes:ctx.wotsSigBytes],
+			buf[ctx.wotsSigBytes:]
+		copy(ss.wotsSig, layer.WotsSignature)
+		ss.authPath, buf = buf[:authPathBytes:authPathBytes],
+			buf[authPathBytes:]
+		for j, node := range layer.AuthPath {
+			if uint32(len(node)) != N {
+				return nil, kindErrorf(ErrMalformed,
+					"Node %d of the authentication path of layer %d "+
+						"should have length %d", j, i, N)
+			}
+			copy(ss.authPath[uint32(j)*N:], node)
+		}
+	}
+	return &sig, nil
+}
+
+// Returns the signature encoded as described in RFC 8391.  Same as
+// MarshalStandard().  Decode it with SignatureFromBytes().
+func (sig *Signature) Bytes() []byte {
+	return sig.MarshalStandard()
+}
+
+// Decodes a signature encoded by Signature.Bytes() for the given instance.
+// Same as Context.UnmarshalRawSignature().
+func SignatureFromBytes(ctx *Context, buf []byte) (*Signature, Error) {
+	return ctx.UnmarshalRawSignature(buf)
+}
+
+// Returns the digest randomized value (R) of the signature.
+func (sig *Signature) Randomizer() []byte {
+	return append([]byte{}, sig.drv...)
+}
+
+// Recomputes the intermediate values of the verification of the signature
+// on msg layer by layer, which is useful to debug or audit signatures.
+//
+// InspectSignature does not check whether the signature is valid: it is if
+// and only if the Root of the last layer equals the root of the public key.
+// Use Verify() for that.
+func (pk *PublicKey) InspectSignature(sig *Signature, msg []byte) (
+	[]SignatureLayerInfo, Error) {
+	if err := sig.Validate(pk.ctx); err != nil {
+		return nil, err
+	}
+
+	pad := pk.ctx.newScratchPad()
+	rxMsg, err := pk.ctx.hashMessage(pad, nil, bytes.NewReader(msg), sig.drv,
+		pk.root, uint64(sig.seqNo))
+	if err != nil {
+		return nil, wrapErrorf(err, "Failed to hash message")
+	}
+
+	layers := sig.Layers()
+	ret := make([]SignatureLayerInfo, len(layers))
+	curHash := make([]byte, pk.ctx.p.N)
+	for i, layer := range layers {
+		ret[i].SignatureLayer = layer
+		pk.layerRootInto(pad, sig.sigs[i], layer.SubTree, layer.Leaf,
+			rxMsg, curHash, &ret[i])
+		rxMsg = curHash
+	}
+	return ret, nil
+}
+
+// Returns the root of the XMSS[MT] tree implied by the signature on msg.
+//
+// XMSS[MT] signatures do not determine the root by themselves: the public
+// seed is used in every hash and the message is hashed together with the
+// root.  Thus the public seed and the root the signature claims to be made
+// under have to be given.  The signature is valid if and only if the
+// returned root equals the claimed root.  This allows, for instance, to
+// compare the implied root against a root embedded in a certificate.
+func (ctx *Context) RootFromSignature(sig *Signature, msg, pubSeed,
+	claimedRoot []byte) ([]byte, Error) {
+	if len(pubSeed) != int(ctx.p.N) || len(claimedRoot) != int(ctx.p.N) {
+		return nil, errorf("pubSeed and claimedRoot should have length %d",
+			ctx.p.N)
+	}
+	pk := PublicKey{
+		ctx:     ctx,
+		pubSeed: pubSeed,
+		root:    claimedRoot,
+		ph:      ctx.precomputeHashes(pubSeed, nil),
+	}
+	layers, err := pk.InspectSignature(sig, msg)
+	if err != nil {
+		return nil, err
+	}
+	return layers[len(layers)-1].Root, nil
+}
@@ -0,0 +1,154 @@
+package xmssmt
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestInspectSignature(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	for i := 0; i < 6; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		layers := sig.Layers()
+		if len(layers) != int(ctx.p.D) {
+			t.Fatalf("Layers() returned %d layers", len(layers))
+		}
+		infos, err := pk.InspectSignature(sig, msg)
+		if err != nil {
+			t.Fatalf("InspectSignature(): %v", err)
+		}
+		for j, info := range infos {
+			if len(info.AuthPath) != int(ctx.treeHeight) {
+				t.Fatalf("Layer %d has AuthPath of length %d",
+					j, len(info.AuthPath))
+			}
+			if j > 0 && !bytes.Equal(info.Message, infos[j-1].Root) {
+				t.Fatalf("Layer %d does not sign the root below", j)
+			}
+			leaf, err := ctx.LTree(info.WotsPublicKey, pk.pubSeed,
+				info.SubTree, info.Leaf)
+			if err != nil {
+				t.Fatalf("LTree(): %v", err)
+			}
+			if !bytes.Equal(leaf, info.LeafNode) {
+				t.Fatalf("Layer %d has wrong LeafNode", j)
+			}
+		}
+		if !bytes.Equal(infos[len(infos)-1].Root, pk.root) {
+			t.Fatalf("Root does not match public key")
+		}
+
+		root, err := ctx.RootFromSignature(sig, msg, pk.pubSeed, pk.root)
+		if err != nil {
+			t.Fatalf("RootFromSignature(): %v", err)
+		}
+		if !bytes.Equal(root, pk.root) {
+			t.Fatalf("RootFromSignature() does not return root")
+		}
+		root, err = ctx.RootFromSignature(sig, msg, pk.pubSeed,
+			make([]byte, ctx.p.N))
+		if err != nil {
+			t.Fatalf("RootFromSignature(): %v", err)
+		}
+		if bytes.Equal(root, pk.root) {
+			t.Fatalf("RootFromSignature() ignores claimed root")
+		}
+
+		infos, err = pk.InspectSignature(sig, []byte("other message"))
+		if err != nil {
+			t.Fatalf("InspectSignature(): %v", err)
+		}
+		if bytes.Equal(infos[len(infos)-1].Root, pk.root) {
+			t.Fatalf("Root matches public key for wrong message")
+		}
+	}
+}
+
+func TestNewSignature(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	for i := 0; i < 6; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		layers := sig.Layers()
+		sig2, err := NewSignature(ctx, sig.SeqNo(), sig.Randomizer(), layers)
+		if err != nil {
+			t.Fatalf("NewSignature(): %v", err)
+		}
+		if !bytes.Equal(sig.Bytes(), sig2.Bytes()) {
+			t.Fatalf("NewSignature() does not reassemble the signature")
+		}
+		ok, err := pk.Verify(sig2, msg)
+		if !ok {
+			t.Fatalf("Verify() rejected reassembled signature: %v", err)
+		}
+
+		sig3, err := SignatureFromBytes(ctx, sig.Bytes())
+		if err != nil {
+			t.Fatalf("SignatureFromBytes(): %v", err)
+		}
+		if sig3.SeqNo() != sig.SeqNo() ||
+			!bytes.Equal(sig3.Bytes(), sig.Bytes()) {
+			t.Fatalf("SignatureFromBytes() does not invert Bytes()")
+		}
+
+		if _, err = NewSignature(ctx, sig.SeqNo()+1, sig.Randomizer(),
+			layers); err == nil || !errors.Is(err, ErrMalformed) {
+			t.Fatalf("NewSignature() accepted layers for other seqNo")
+		}
+		if _, err = NewSignature(ctx, sig.SeqNo(), sig.Randomizer(),
+			layers[1:]); err == nil {
+			t.Fatalf("NewSignature() accepted missing layer")
+		}
+		layers[0].AuthPath = layers[0].AuthPath[1:]
+		if _, err = NewSignature(ctx, sig.SeqNo(), sig.Randomizer(),
+			layers); err == nil {
+			t.Fatalf("NewSignature() accepted short authentication path")
+		}
+	}
+}
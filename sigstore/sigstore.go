@@ -0,0 +1,96 @@
+// Package sigstore adapts go-xmssmt keys to the signer and verifier
+// interfaces of supply-chain tooling, such as sigstore/sigstore, and to the
+// key format of TUF, for experiments with hash-based signatures.
+//
+// The Signer and Verifier have the methods of the Signer and Verifier
+// interfaces in github.com/sigstore/sigstore/pkg/signature, except for the
+// variadic options, whose types are defined by that package.  As this
+// module doesn't depend on sigstore, plug them in with a thin wrapper:
+//
+//   type sigstoreSigner struct{ *sigstore.Signer }
+//
+//   func (s sigstoreSigner) PublicKey(...signature.PublicKeyOption) (
+//       crypto.PublicKey, error) {
+//       return s.Signer.PublicKey()
+//   }
+//
+//   func (s sigstoreSigner) SignMessage(msg io.Reader,
+//       _ ...signature.SignOption) ([]byte, error) {
+//       return s.Signer.SignMessage(msg)
+//   }
+//
+// Signatures are encoded as described in the RFC.  The options, such as
+// a context or a digest algorithm, are ignored: XMSS[MT] hashes the
+// message itself.
+package sigstore
+
+import (
+	"crypto"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Signs messages with a private key.
+type Signer struct {
+	sk *xmssmt.PrivateKey
+}
+
+// Returns a Signer for the private key.
+//
+// NOTE Every signature uses up a signature sequence number of sk.
+func NewSigner(sk *xmssmt.PrivateKey) *Signer {
+	return &Signer{sk: sk}
+}
+
+// Returns the public key, a *xmssmt.PublicKey.
+func (s *Signer) PublicKey() (crypto.PublicKey, error) {
+	return s.sk.PublicKey(), nil
+}
+
+// Signs the message read from the io.Reader.
+func (s *Signer) SignMessage(msg io.Reader) ([]byte, error) {
+	sig, err := s.sk.SignFrom(msg)
+	if err != nil {
+		return nil, err
+	}
+	return sig.MarshalRaw(), nil
+}
+
+// Checks signatures with a public key.
+type Verifier struct {
+	pk *xmssmt.PublicKey
+}
+
+// Returns a Verifier for the public key.
+func NewVerifier(pk *xmssmt.PublicKey) *Verifier {
+	return &Verifier{pk: pk}
+}
+
+// Returns the public key, a *xmssmt.PublicKey.
+func (v *Verifier) PublicKey() (crypto.PublicKey, error) {
+	return v.pk, nil
+}
+
+// Checks the signature on the message, both read from the io.Readers.
+// Returns nil if the signature is valid.
+func (v *Verifier) VerifySignature(signature, msg io.Reader) error {
+	buf, err := ioutil.ReadAll(signature)
+	if err != nil {
+		return err
+	}
+	sig, err2 := v.pk.Context().UnmarshalRawSignature(buf)
+	if err2 != nil {
+		return err2
+	}
+	ok, err2 := v.pk.VerifyFrom(sig, msg)
+	if err2 != nil {
+		return err2
+	}
+	if !ok {
+		return errors.New("sigstore: invalid signature")
+	}
+	return nil
+}
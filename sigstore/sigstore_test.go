@@ -0,0 +1,87 @@
+package sigstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+func TestSignerVerifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := xmssmt.NewContextFromName("XMSSMT-SHAKE_20/4_256")
+	sk, pk, err2 := ctx.GenerateKeyPair(dir + "/key")
+	if err2 != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err2)
+	}
+	defer sk.Close()
+
+	s := NewSigner(sk)
+	v := NewVerifier(pk)
+	if spk, _ := s.PublicKey(); !pk.Equal(spk) {
+		t.Fatalf("Signer.PublicKey() is wrong")
+	}
+	msg := "test message"
+	sig, err := s.SignMessage(strings.NewReader(msg))
+	if err != nil {
+		t.Fatalf("SignMessage(): %v", err)
+	}
+	err = v.VerifySignature(bytes.NewReader(sig), strings.NewReader(msg))
+	if err != nil {
+		t.Fatalf("VerifySignature(): %v", err)
+	}
+	err = v.VerifySignature(bytes.NewReader(sig), strings.NewReader("other"))
+	if err == nil {
+		t.Fatalf("VerifySignature() accepted wrong message")
+	}
+	err = v.VerifySignature(bytes.NewReader(sig[1:]), strings.NewReader(msg))
+	if err == nil {
+		t.Fatalf("VerifySignature() accepted truncated signature")
+	}
+}
+
+func TestTUFKey(t *testing.T) {
+	ctx := xmssmt.NewContextFromName("XMSSMT-SHA2_20/2_256")
+	pk, err := ctx.UnmarshalRawPublicKey(make([]byte, 64))
+	if err != nil {
+		t.Fatalf("UnmarshalRawPublicKey(): %v", err)
+	}
+	key := NewTUFKey(pk)
+	buf, err2 := key.canonicalJSON()
+	if err2 != nil {
+		t.Fatalf("canonicalJSON(): %v", err2)
+	}
+	expected := `{"keytype":"xmssmt","keyval":{"public":"` +
+		strings.Repeat("00", 64) + `"},"scheme":"XMSSMT-SHA2_20/2_256"}`
+	if string(buf) != expected {
+		t.Fatalf("canonicalJSON() = %s", buf)
+	}
+	if _, err2 := key.ID(); err2 != nil {
+		t.Fatalf("ID(): %v", err2)
+	}
+
+	var key2 TUFKey
+	if err2 = json.Unmarshal(buf, &key2); err2 != nil {
+		t.Fatalf("json.Unmarshal(): %v", err2)
+	}
+	pk2, err2 := key2.PublicKey()
+	if err2 != nil {
+		t.Fatalf("PublicKey(): %v", err2)
+	}
+	if !pk.Equal(pk2) {
+		t.Fatalf("PublicKey() returned a different public key")
+	}
+	key2.KeyType = KeyTypeXMSS
+	if _, err2 = key2.PublicKey(); err2 == nil {
+		t.Fatalf("PublicKey() accepted wrong key type")
+	}
+}
@@ -0,0 +1,98 @@
+package sigstore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// TUF key types of XMSS and XMSSMT public keys.
+const (
+	KeyTypeXMSS   = "xmss"
+	KeyTypeXMSSMT = "xmssmt"
+)
+
+// A public key as it appears in TUF metadata:
+//
+//   {"keytype": "xmssmt", "scheme": "XMSSMT-SHA2_20/2_256",
+//    "keyval": {"public": "<hex of root ‖ pubSeed>"}}.
+//
+// The scheme is the name of the instance.
+type TUFKey struct {
+	// The fields are in the order of the canonical JSON encoding.
+	KeyType string    `json:"keytype"`
+	KeyVal  TUFKeyVal `json:"keyval"`
+	Scheme  string    `json:"scheme"`
+}
+
+// Value of a TUFKey.
+type TUFKeyVal struct {
+	Public string `json:"public"`
+}
+
+// Returns the public key as a TUFKey.
+func NewTUFKey(pk *xmssmt.PublicKey) *TUFKey {
+	ctx := pk.Context()
+	keyType := KeyTypeXMSS
+	if ctx.MT() {
+		keyType = KeyTypeXMSSMT
+	}
+	scheme := ctx.Name()
+	if scheme == "" {
+		scheme = ctx.Params().String()
+	}
+	return &TUFKey{
+		KeyType: keyType,
+		Scheme:  scheme,
+		KeyVal:  TUFKeyVal{Public: hex.EncodeToString(pk.MarshalRaw())},
+	}
+}
+
+// Returns the public key described by the TUFKey.
+func (k *TUFKey) PublicKey() (*xmssmt.PublicKey, error) {
+	if k.KeyType != KeyTypeXMSS && k.KeyType != KeyTypeXMSSMT {
+		return nil, fmt.Errorf("sigstore: unsupported key type %q", k.KeyType)
+	}
+	ctx, err := xmssmt.NewContextFromName2(k.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.MT() != (k.KeyType == KeyTypeXMSSMT) {
+		return nil, fmt.Errorf("sigstore: scheme %s doesn't match key type %s",
+			k.Scheme, k.KeyType)
+	}
+	buf, err2 := hex.DecodeString(k.KeyVal.Public)
+	if err2 != nil {
+		return nil, err2
+	}
+	return ctx.UnmarshalRawPublicKey(buf)
+}
+
+// Returns the TUF key ID: the hex encoded SHA-256 of the canonical JSON
+// encoding of the key.
+func (k *TUFKey) ID() (string, error) {
+	buf, err := k.canonicalJSON()
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(buf)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// Returns the canonical JSON encoding of the key as used by TUF: the
+// fields are sorted and there is no whitespace.  As the values are
+// plain ASCII, encoding/json does this for us as long as it doesn't
+// escape HTML.
+func (k *TUFKey) canonicalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(k); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,252 @@
+package xmssmt
+
+// Contains signatures that are split in a part for the lowest layer and
+// a part for the other layers, such that they can be created by different
+// signers.
+
+import (
+	"bytes"
+)
+
+// The part of a signature created on the lowest layer: the signature
+// sequence number, the randomizer and the WOTS+ signature on the message
+// with its authentication path.  Combine it with the SubTreeCertificate
+// of its subtree to get a Signature.  See PrivateKey.SignPartial().
+type PartialSignature struct {
+	ctx   *Context
+	seqNo SignatureSeqNo
+	drv   []byte
+	sig   subTreeSig
+}
+
+// The part of a signature created on the layers above the lowest: the
+// signatures on the root of a subtree on the lowest layer, its parent,
+// and so on.  It is the same for all signatures created with that
+// subtree.  See PrivateKey.CertifySubTree().
+type SubTreeCertificate struct {
+	ctx  *Context
+	tree uint64 // index of the subtree on the lowest layer
+	sigs []subTreeSig
+}
+
+// Returns the part of the signatures for the layers above the lowest, for
+// the signatures with the given subtree on the lowest layer.  This does not
+// use signature sequence numbers: the same subtree is always signed with
+// the same leaf of its parent.
+//
+// This allows an offline signer that holds the private key to certify a
+// subtree for an online signer, which creates the parts of the signatures
+// on the lowest layer, see SignPartial(), just like an offline root CA
+// certifies an online intermediate CA.  The signature sequence numbers of
+// the certified subtree must then only be used by the online signer: use
+// SetSeqNoRange() to keep this private key out of them.
+func (sk *PrivateKey) CertifySubTree(tree uint64) (*SubTreeCertificate, Error) {
+	if tree >= 1<<(sk.ctx.p.FullHeight-sk.ctx.treeHeight) {
+		return nil, errorf("Subtree %d is out of range", tree)
+	}
+	pad := sk.ctx.getScratchPad()
+	defer sk.ctx.putScratchPad(pad)
+	seqNo := SignatureSeqNo(tree << sk.ctx.treeHeight)
+	staPath, leafs := sk.ctx.subTreePathForSeqNo(seqNo)
+	_, tail, err := sk.signatureTail(*pad, staPath, leafs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tail is shared with signatureTail(), so copy it.
+	cert := SubTreeCertificate{
+		ctx:  sk.ctx,
+		tree: tree,
+		sigs: make([]subTreeSig, len(tail)),
+	}
+	for i, ss := range tail {
+		cert.sigs[i] = subTreeSig{
+			wotsSig:  append([]byte{}, ss.wotsSig...),
+			authPath: append([]byte{}, ss.authPath...),
+		}
+	}
+	return &cert, nil
+}
+
+// Signs the given message, but only creates the part of the signature
+// on the lowest layer.  Combine it with the certificate of its subtree,
+// see CertifySubTree(), to get the full signature.
+func (sk *PrivateKey) SignPartial(msg []byte) (*PartialSignature, Error) {
+	if err := sk.ctx.checkMessageContext(nil); err != nil {
+		return nil, err
+	}
+	pad := sk.ctx.getScratchPad()
+	defer sk.ctx.putScratchPad(pad)
+	seqNo, err := sk.getSeqNo()
+	if err != nil {
+		return nil, err
+	}
+	defer sk.retireSeqNo(seqNo)
+
+	staPath, leafs := sk.ctx.subTreePathForSeqNo(seqNo)
+	mt, _, err := sk.getSubTree(*pad, staPath[0])
+	if err != nil {
+		return nil, err
+	}
+	sig := sk.allocSignature(seqNo)
+	mt.AuthPathInto(leafs[0], sig.sigs[0].authPath)
+	err = sk.signMessageInto(*pad, sig, nil, bytes.NewReader(msg),
+		staPath[0], leafs[0])
+	if err != nil {
+		return nil, err
+	}
+	return &PartialSignature{
+		ctx:   sk.ctx,
+		seqNo: seqNo,
+		drv:   sig.drv,
+		sig:   sig.sigs[0],
+	}, nil
+}
+
+// Returns the signature sequence number of the partial signature.
+func (ps *PartialSignature) SeqNo() SignatureSeqNo {
+	return ps.seqNo
+}
+
+// Returns the index of the subtree on the lowest layer that created the
+// partial signature.  Combine it with the SubTreeCertificate for this
+// subtree.
+func (ps *PartialSignature) SubTree() uint64 {
+	return uint64(ps.seqNo) >> ps.ctx.treeHeight
+}
+
+// Returns the index of the subtree on the lowest layer that is certified.
+func (cert *SubTreeCertificate) SubTree() uint64 {
+	return cert.tree
+}
+
+// Returns the parts of the certificate, starting with the signature of
+// the subtree on the lowest layer by its parent.  See Signature.Layers().
+func (cert *SubTreeCertificate) Layers() []SignatureLayer {
+	sig := cert.signature(&PartialSignature{
+		ctx:   cert.ctx,
+		seqNo: SignatureSeqNo(cert.tree << cert.ctx.treeHeight),
+	})
+	return sig.Layers()[1:]
+}
+
+// Combines the partial signature with the certificate of its subtree into
+// a full signature.  Checks that the parts belong together, but not
+// whether the signature is valid: use PublicKey.Verify() for that.
+func (cert *SubTreeCertificate) Combine(ps *PartialSignature) (
+	*Signature, Error) {
+	if err := checkCompatibleParams("Partial signature", ps.ctx.p,
+		cert.ctx.p); err != nil {
+		return nil, err
+	}
+	if ps.SubTree() != cert.tree {
+		return nil, kindErrorf(ErrMalformed,
+			"Partial signature is by subtree %d instead of %d",
+			ps.SubTree(), cert.tree)
+	}
+	return cert.signature(ps), nil
+}
+
+// Combines the partial signature with the certificate without checks.
+func (cert *SubTreeCertificate) signature(ps *PartialSignature) *Signature {
+	sig := Signature{
+		ctx:   cert.ctx,
+		seqNo: ps.seqNo,
+		drv:   ps.drv,
+		sigs:  make([]subTreeSig, cert.ctx.p.D),
+	}
+	sig.sigs[0] = ps.sig
+	copy(sig.sigs[1:], cert.sigs)
+	return &sig
+}
+
+// Size of the encoding of a part of a signature for a single layer.
+func (ctx *Context) subTreeSigBytes() uint32 {
+	return ctx.wotsSigBytes + ctx.p.N*ctx.treeHeight
+}
+
+// Returns the partial signature encoded as the start of the encoding of
+// the full signature as described in RFC 8391.  Appending the encoding of
+// the certificate, see SubTreeCertificate.Bytes(), gives the encoding of
+// the full signature.  Decode it with Context.UnmarshalPartialSignature().
+func (ps *PartialSignature) Bytes() []byte {
+	ctx := ps.ctx
+	ret := make([]byte, ctx.indexBytes+ctx.p.N+ctx.subTreeSigBytes())
+	encodeUint64Into(uint64(ps.seqNo), ret[:ctx.indexBytes])
+	copy(ret[ctx.indexBytes:], ps.drv)
+	copy(ret[ctx.indexBytes+ctx.p.N:], ps.sig.wotsSig)
+	copy(ret[ctx.indexBytes+ctx.p.N+ctx.wotsSigBytes:], ps.sig.authPath)
+	return ret
+}
+
+// Decodes a partial signature for this instance encoded by
+// PartialSignature.Bytes().
+func (ctx *Context) UnmarshalPartialSignature(buf []byte) (
+	*PartialSignature, Error) {
+	if uint32(len(buf)) != ctx.indexBytes+ctx.p.N+ctx.subTreeSigBytes() {
+		return nil, kindErrorf(ErrMalformed,
+			"Partial signature has wrong length: %d", len(buf))
+	}
+	seqNo := decodeUint64(buf[:ctx.indexBytes])
+	if seqNo > ctx.p.MaxSignatureSeqNo() {
+		return nil, kindErrorf(ErrMalformed,
+			"Signature sequence number %d is out of range", seqNo)
+	}
+	buf = buf[ctx.indexBytes:]
+	return &PartialSignature{
+		ctx:   ctx,
+		seqNo: SignatureSeqNo(seqNo),
+		drv:   append([]byte{}, buf[:ctx.p.N]...),
+		sig:   ctx.decodeSubTreeSig(buf[ctx.p.N:]),
+	}, nil
+}
+
+// Returns the certificate encoded as the index of the certified subtree,
+// in as many bytes as the signature sequence number of a signature,
+// followed by the end of the encoding of a full signature.  Decode it with
+// Context.UnmarshalSubTreeCertificate().
+func (cert *SubTreeCertificate) Bytes() []byte {
+	ctx := cert.ctx
+	stLen := ctx.subTreeSigBytes()
+	ret := make([]byte, ctx.indexBytes+uint32(len(cert.sigs))*stLen)
+	encodeUint64Into(cert.tree, ret[:ctx.indexBytes])
+	for i, ss := range cert.sigs {
+		off := ctx.indexBytes + uint32(i)*stLen
+		copy(ret[off:], ss.wotsSig)
+		copy(ret[off+ctx.wotsSigBytes:], ss.authPath)
+	}
+	return ret
+}
+
+// Decodes a certificate for this instance encoded by
+// SubTreeCertificate.Bytes().
+func (ctx *Context) UnmarshalSubTreeCertificate(buf []byte) (
+	*SubTreeCertificate, Error) {
+	stLen := ctx.subTreeSigBytes()
+	if uint32(len(buf)) != ctx.indexBytes+(ctx.p.D-1)*stLen {
+		return nil, kindErrorf(ErrMalformed,
+			"Subtree certificate has wrong length: %d", len(buf))
+	}
+	tree := decodeUint64(buf[:ctx.indexBytes])
+	if tree >= 1<<(ctx.p.FullHeight-ctx.treeHeight) {
+		return nil, kindErrorf(ErrMalformed, "Subtree %d is out of range", tree)
+	}
+	cert := SubTreeCertificate{
+		ctx:  ctx,
+		tree: tree,
+		sigs: make([]subTreeSig, ctx.p.D-1),
+	}
+	for i := range cert.sigs {
+		off := ctx.indexBytes + uint32(i)*stLen
+		cert.sigs[i] = ctx.decodeSubTreeSig(buf[off : off+stLen])
+	}
+	return &cert, nil
+}
+
+// Decodes the part of a signature for a single layer.
+func (ctx *Context) decodeSubTreeSig(buf []byte) subTreeSig {
+	return subTreeSig{
+		wotsSig:  append([]byte{}, buf[:ctx.wotsSigBytes]...),
+		authPath: append([]byte{}, buf[ctx.wotsSigBytes:ctx.subTreeSigBytes()]...),
+	}
+}
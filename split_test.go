@@ -0,0 +1,80 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSplitSignature(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 6, 3, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	certs := make(map[uint64]*SubTreeCertificate)
+	msg := []byte("test message")
+	for i := 0; i < 10; i++ {
+		ps, err := sk.SignPartial(msg)
+		if err != nil {
+			t.Fatalf("SignPartial(): %v", err)
+		}
+		cert, ok := certs[ps.SubTree()]
+		if !ok {
+			cert, err = sk.CertifySubTree(ps.SubTree())
+			if err != nil {
+				t.Fatalf("CertifySubTree(): %v", err)
+			}
+			cert, err = ctx.UnmarshalSubTreeCertificate(cert.Bytes())
+			if err != nil {
+				t.Fatalf("UnmarshalSubTreeCertificate(): %v", err)
+			}
+			certs[ps.SubTree()] = cert
+		}
+		if len(cert.Layers()) != int(ctx.p.D)-1 {
+			t.Fatalf("Layers() returned %d layers", len(cert.Layers()))
+		}
+		ps, err = ctx.UnmarshalPartialSignature(ps.Bytes())
+		if err != nil {
+			t.Fatalf("UnmarshalPartialSignature(): %v", err)
+		}
+		sig, err := cert.Combine(ps)
+		if err != nil {
+			t.Fatalf("Combine(): %v", err)
+		}
+		if !bytes.Equal(sig.Bytes(), append(ps.Bytes(),
+			cert.Bytes()[ctx.indexBytes:]...)) {
+			t.Fatalf("Encodings of the parts do not add up")
+		}
+		ok, err = pk.Verify(sig, msg)
+		if !ok {
+			t.Fatalf("Verify() rejected combined signature: %v", err)
+		}
+	}
+
+	if len(certs) != 3 {
+		t.Fatalf("Signatures used %d subtrees instead of 3", len(certs))
+	}
+	ps, _ := sk.SignPartial(msg)
+	if _, err = certs[0].Combine(ps); err == nil {
+		t.Fatalf("Combine() accepted partial signature of other subtree")
+	}
+	if _, err = sk.CertifySubTree(1 << 4); err == nil {
+		t.Fatalf("CertifySubTree() accepted subtree out of range")
+	}
+}
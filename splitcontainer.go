@@ -0,0 +1,378 @@
+package xmssmt
+
+import (
+	"github.com/hashicorp/go-multierror"
+)
+
+// A SeqNoStore stores the XMSS[MT] secret key and the sequence number of
+// the first unused signature.  This is the part of a PrivateKeyContainer
+// that must be durable: losing an update of the sequence number might
+// lead to the reuse of a signature, which breaks security.  It might be
+// backed by a database or HSM.
+//
+// The methods have the same meaning as those of PrivateKeyContainer,
+// except that Reset() does not have to reset a cache.
+//
+// NOTE A SeqNoStore does not have to be thread safe.
+type SeqNoStore interface {
+	Reset(privateKey []byte, params Params) Error
+	BorrowSeqNos(amount uint32) (SignatureSeqNo, Error)
+	SetSeqNo(seqNo SignatureSeqNo) Error
+	GetSeqNo() (seqNo SignatureSeqNo, lostSigs uint32, err Error)
+	GetPrivateKey() ([]byte, Error)
+	Initialized() *Params
+	Close() Error
+}
+
+// A SubTreeCache caches the precomputed subtrees of a single private key.
+// This is the disposable part of a PrivateKeyContainer: if the cache is lost
+// the subtrees are simply recomputed.  It can be kept on fast ephemeral
+// storage.
+//
+// The methods have the same meaning as those of PrivateKeyContainer,
+// except that ResetCache() and CacheInitialized() are passed the parameters
+// of the private key.
+//
+// NOTE A SubTreeCache does not have to be thread safe.
+type SubTreeCache interface {
+	// Reset (or initialize) the cache to store subtrees of size
+	// params.CachedSubTreeSize().
+	ResetCache(params Params) Error
+
+	// Returns whether the cache is initialized for the given parameters.
+	CacheInitialized(params Params) bool
+
+	GetSubTree(address SubTreeAddress) (buf []byte, exists bool, err Error)
+	HasSubTree(address SubTreeAddress) bool
+	DropSubTree(address SubTreeAddress) Error
+	ListSubTrees() ([]SubTreeAddress, Error)
+	Close() Error
+}
+
+// PrivateKeyContainer combining a SeqNoStore and a SubTreeCache.
+type splitContainer struct {
+	store SeqNoStore
+	cache SubTreeCache
+}
+
+// Returns a PrivateKeyContainer that stores the private key and signature
+// sequence number in store and caches the subtrees in cache.
+//
+// NOTE The cache must only be used for the private key in store: it will
+// be reset when a new private key is stored, but it is not checked whether
+// the cached subtrees belong to the key.
+func NewSplitPrivateKeyContainer(store SeqNoStore,
+	cache SubTreeCache) PrivateKeyContainer {
+	return &splitContainer{store: store, cache: cache}
+}
+
+func (ctr *splitContainer) ResetCache() Error {
+	params := ctr.store.Initialized()
+	if params == nil {
+		return kindErrorf(ErrNotInitialized,
+			"Container is not initialized")
+	}
+	return ctr.cache.ResetCache(*params)
+}
+
+func (ctr *splitContainer) GetSubTree(address SubTreeAddress) (
+	buf []byte, exists bool, err Error) {
+	return ctr.cache.GetSubTree(address)
+}
+
+func (ctr *splitContainer) HasSubTree(address SubTreeAddress) bool {
+	return ctr.cache.HasSubTree(address)
+}
+
+func (ctr *splitContainer) DropSubTree(address SubTreeAddress) Error {
+	return ctr.cache.DropSubTree(address)
+}
+
+func (ctr *splitContainer) CommitSubTree(address SubTreeAddress) Error {
+	if committer, ok := ctr.cache.(SubTreeCommitter); ok {
+		return committer.CommitSubTree(address)
+	}
+	return nil
+}
+
+func (ctr *splitContainer) CheckpointSubTree(address SubTreeAddress,
+	leafs uint32) Error {
+	if checkpointer, ok := ctr.cache.(SubTreeCheckpointer); ok {
+		return checkpointer.CheckpointSubTree(address, leafs)
+	}
+	return nil
+}
+
+func (ctr *splitContainer) SubTreeProgress(address SubTreeAddress) (
+	uint32, Error) {
+	if checkpointer, ok := ctr.cache.(SubTreeCheckpointer); ok {
+		return checkpointer.SubTreeProgress(address)
+	}
+	return 0, nil
+}
+
+func (ctr *splitContainer) KeyedCacheChecksum() (bool, Error) {
+	if store, ok := ctr.cache.(KeyedChecksumStore); ok {
+		return store.KeyedCacheChecksum()
+	}
+	return false, nil
+}
+
+func (ctr *splitContainer) SetKeyedCacheChecksum(enabled bool) Error {
+	if store, ok := ctr.cache.(KeyedChecksumStore); ok {
+		return store.SetKeyedCacheChecksum(enabled)
+	}
+	return nil
+}
+
+func (ctr *splitContainer) Compact() Error {
+	if compacter, ok := ctr.cache.(CacheCompacter); ok {
+		return compacter.Compact()
+	}
+	return nil
+}
+
+func (ctr *splitContainer) ListSubTrees() ([]SubTreeAddress, Error) {
+	return ctr.cache.ListSubTrees()
+}
+
+func (ctr *splitContainer) Reset(privateKey []byte, params Params) Error {
+	if err := ctr.store.Reset(privateKey, params); err != nil {
+		return err
+	}
+	return ctr.cache.ResetCache(params)
+}
+
+func (ctr *splitContainer) BorrowSeqNos(amount uint32) (
+	SignatureSeqNo, Error) {
+	return ctr.store.BorrowSeqNos(amount)
+}
+
+func (ctr *splitContainer) SetSeqNo(seqNo SignatureSeqNo) Error {
+	return ctr.store.SetSeqNo(seqNo)
+}
+
+func (ctr *splitContainer) GetSeqNo() (
+	seqNo SignatureSeqNo, lostSigs uint32, err Error) {
+	return ctr.store.GetSeqNo()
+}
+
+func (ctr *splitContainer) HighWaterMark() (SignatureSeqNo, Error) {
+	if marker, ok := ctr.store.(HighWaterMarker); ok {
+		return marker.HighWaterMark()
+	}
+	return 0, errorf("SeqNoStore does not keep a high-water mark")
+}
+
+func (ctr *splitContainer) KeyStructure() (KeyStructure, Error) {
+	if store, ok := ctr.store.(KeyStructureStore); ok {
+		return store.KeyStructure()
+	}
+	return FlatKeyStructure, nil
+}
+
+func (ctr *splitContainer) SetKeyStructure(ks KeyStructure) Error {
+	if store, ok := ctr.store.(KeyStructureStore); ok {
+		return store.SetKeyStructure(ks)
+	}
+	return errorf("SeqNoStore does not store the key structure")
+}
+
+func (ctr *splitContainer) Root() ([]byte, Error) {
+	if store, ok := ctr.store.(RootStore); ok {
+		return store.Root()
+	}
+	return nil, nil
+}
+
+func (ctr *splitContainer) SetRoot(root []byte) Error {
+	if store, ok := ctr.store.(RootStore); ok {
+		return store.SetRoot(root)
+	}
+	return errorf("SeqNoStore does not store the root")
+}
+
+func (ctr *splitContainer) GetPrivateKey() ([]byte, Error) {
+	return ctr.store.GetPrivateKey()
+}
+
+func (ctr *splitContainer) Initialized() *Params {
+	return ctr.store.Initialized()
+}
+
+func (ctr *splitContainer) CacheInitialized() bool {
+	params := ctr.store.Initialized()
+	return params != nil && ctr.cache.CacheInitialized(*params)
+}
+
+func (ctr *splitContainer) Close() Error {
+	var err error
+	if err2 := ctr.cache.Close(); err2 != nil {
+		err = multierror.Append(err, wrapErrorf(err2,
+			"Could not close cache"))
+	}
+	if err2 := ctr.store.Close(); err2 != nil {
+		err = multierror.Append(err, wrapErrorf(err2,
+			"Could not close store"))
+	}
+	if err != nil {
+		return wrapErrorf(err, "")
+	}
+	return nil
+}
+
+// SubTreeCache that keeps the subtrees in memory.
+type memorySubTreeCache struct {
+	params   *Params
+	subTrees map[SubTreeAddress][]byte
+}
+
+// Returns a SubTreeCache that keeps the subtrees in memory.  The subtrees
+// are lost when the process exits.
+func NewMemorySubTreeCache() SubTreeCache {
+	return &memorySubTreeCache{}
+}
+
+func (cache *memorySubTreeCache) ResetCache(params Params) Error {
+	cache.params = &params
+	cache.subTrees = make(map[SubTreeAddress][]byte)
+	return nil
+}
+
+func (cache *memorySubTreeCache) CacheInitialized(params Params) bool {
+	return cache.params != nil && *cache.params == params
+}
+
+func (cache *memorySubTreeCache) GetSubTree(address SubTreeAddress) (
+	buf []byte, exists bool, err Error) {
+	if cache.params == nil {
+		return nil, false, kindErrorf(ErrNotInitialized,
+			"Cache is not initialized")
+	}
+	if buf, ok := cache.subTrees[address]; ok {
+		return buf, true, nil
+	}
+	buf = make([]byte, cache.params.CachedSubTreeSize())
+	cache.subTrees[address] = buf
+	return buf, false, nil
+}
+
+func (cache *memorySubTreeCache) HasSubTree(address SubTreeAddress) bool {
+	_, ok := cache.subTrees[address]
+	return ok
+}
+
+func (cache *memorySubTreeCache) DropSubTree(address SubTreeAddress) Error {
+	if cache.params == nil {
+		return kindErrorf(ErrNotInitialized, "Cache is not initialized")
+	}
+	delete(cache.subTrees, address)
+	return nil
+}
+
+func (cache *memorySubTreeCache) ListSubTrees() ([]SubTreeAddress, Error) {
+	if cache.params == nil {
+		return nil, kindErrorf(ErrNotInitialized,
+			"Cache is not initialized")
+	}
+	ret := make([]SubTreeAddress, 0, len(cache.subTrees))
+	for addr := range cache.subTrees {
+		ret = append(ret, addr)
+	}
+	return ret, nil
+}
+
+func (cache *memorySubTreeCache) Close() Error {
+	cache.params = nil
+	cache.subTrees = nil
+	return nil
+}
+
+// SeqNoStore that keeps the private key and sequence number in memory.
+type memorySeqNoStore struct {
+	params        *Params
+	privateKey    []byte
+	seqNo         SignatureSeqNo
+	highWaterMark SignatureSeqNo
+	keyStructure  KeyStructure
+	root          []byte
+}
+
+func (store *memorySeqNoStore) Reset(privateKey []byte, params Params) Error {
+	store.params = &params
+	store.privateKey = append([]byte{}, privateKey...)
+	store.seqNo = 0
+	store.highWaterMark = 0
+	store.keyStructure = FlatKeyStructure
+	store.root = nil
+	return nil
+}
+
+func (store *memorySeqNoStore) BorrowSeqNos(amount uint32) (
+	SignatureSeqNo, Error) {
+	store.seqNo += SignatureSeqNo(amount)
+	if store.seqNo > store.highWaterMark {
+		store.highWaterMark = store.seqNo
+	}
+	return store.seqNo, nil
+}
+
+func (store *memorySeqNoStore) SetSeqNo(seqNo SignatureSeqNo) Error {
+	store.seqNo = seqNo
+	if seqNo > store.highWaterMark {
+		store.highWaterMark = seqNo
+	}
+	return nil
+}
+
+func (store *memorySeqNoStore) HighWaterMark() (SignatureSeqNo, Error) {
+	return store.highWaterMark, nil
+}
+
+func (store *memorySeqNoStore) GetSeqNo() (SignatureSeqNo, uint32, Error) {
+	return store.seqNo, 0, nil
+}
+
+func (store *memorySeqNoStore) KeyStructure() (KeyStructure, Error) {
+	return store.keyStructure, nil
+}
+
+func (store *memorySeqNoStore) SetKeyStructure(ks KeyStructure) Error {
+	store.keyStructure = ks
+	return nil
+}
+
+func (store *memorySeqNoStore) Root() ([]byte, Error) {
+	return store.root, nil
+}
+
+func (store *memorySeqNoStore) SetRoot(root []byte) Error {
+	store.root = append([]byte{}, root...)
+	return nil
+}
+
+func (store *memorySeqNoStore) GetPrivateKey() ([]byte, Error) {
+	return store.privateKey, nil
+}
+
+func (store *memorySeqNoStore) Initialized() *Params {
+	return store.params
+}
+
+func (store *memorySeqNoStore) Close() Error {
+	zeroize(store.privateKey)
+	return nil
+}
+
+// Returns a PrivateKeyContainer that keeps the private key, signature
+// sequence number and subtrees in memory.  It works on every platform,
+// including js/wasm, which has no filesystem in the browser.
+//
+// NOTE The private key is lost when the container is closed or the process
+// exits.  To keep using a key afterwards, store it and its signature
+// sequence number durably, for instance in IndexedDB, by passing a
+// SeqNoStore to NewSplitPrivateKeyContainer() with NewMemorySubTreeCache().
+func NewMemoryPrivateKeyContainer() PrivateKeyContainer {
+	return NewSplitPrivateKeyContainer(&memorySeqNoStore{},
+		NewMemorySubTreeCache())
+}
@@ -0,0 +1,190 @@
+// Package sshxmss converts XMSS public keys and signatures to and from the
+// ssh-xmss@openssh.com wire format of OpenSSH and implements the Signer and
+// PublicKey interfaces of golang.org/x/crypto/ssh.
+//
+// OpenSSH only supports the XMSS instances XMSS-SHA2_10_256,
+// XMSS-SHA2_16_256 and XMSS-SHA2_20_256.  The public key blob is
+//
+//   string "ssh-xmss@openssh.com"
+//   string instance, eg. "XMSS_SHA2-256_W16_H10"
+//   string root ‖ pubSeed
+//
+// and the signature blob is the signature as described in RFC 8391.
+package sshxmss
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Key type of XMSS in OpenSSH.
+const KeyAlgoXMSS = "ssh-xmss@openssh.com"
+
+// Names used by OpenSSH for the supported instances.
+var sshNames = map[string]string{
+	"XMSS-SHA2_10_256": "XMSS_SHA2-256_W16_H10",
+	"XMSS-SHA2_16_256": "XMSS_SHA2-256_W16_H16",
+	"XMSS-SHA2_20_256": "XMSS_SHA2-256_W16_H20",
+}
+
+// Returns the name used by OpenSSH for the instance of ctx.
+func sshName(ctx *xmssmt.Context) (string, error) {
+	name, ok := sshNames[ctx.Name()]
+	if !ok || ctx.MT() {
+		return "", fmt.Errorf("sshxmss: %s is not supported by OpenSSH",
+			ctx.Params())
+	}
+	return name, nil
+}
+
+// Returns the Context for the name used by OpenSSH.
+func contextFromSSHName(name string) (*xmssmt.Context, error) {
+	for ours, theirs := range sshNames {
+		if theirs == name {
+			return xmssmt.NewContextFromName(ours), nil
+		}
+	}
+	return nil, fmt.Errorf("sshxmss: unsupported instance %q", name)
+}
+
+// XMSS public key that implements ssh.PublicKey.
+type PublicKey struct {
+	pk      *xmssmt.PublicKey
+	sshName string
+}
+
+// Wraps an XMSS public key for use with golang.org/x/crypto/ssh, for
+// instance with ssh.MarshalAuthorizedKey().
+func NewPublicKey(pk *xmssmt.PublicKey) (*PublicKey, error) {
+	name, err := sshName(pk.Context())
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{pk: pk, sshName: name}, nil
+}
+
+// Returns the wrapped XMSS public key.
+func (k *PublicKey) XMSS() *xmssmt.PublicKey {
+	return k.pk
+}
+
+func (k *PublicKey) Type() string {
+	return KeyAlgoXMSS
+}
+
+// Returns the public key blob in OpenSSH wire format.
+func (k *PublicKey) Marshal() []byte {
+	// pk.MarshalStandard() is OID ‖ root ‖ pubSeed and never fails for
+	// the supported instances.
+	std, _ := k.pk.MarshalStandard()
+	return ssh.Marshal(struct {
+		Type string
+		Name string
+		Pk   []byte
+	}{KeyAlgoXMSS, k.sshName, std[4:]})
+}
+
+// Checks the signature on data.
+func (k *PublicKey) Verify(data []byte, sig *ssh.Signature) error {
+	if sig.Format != KeyAlgoXMSS {
+		return fmt.Errorf("sshxmss: unexpected signature format %q",
+			sig.Format)
+	}
+	xsig, err := k.pk.Context().UnmarshalSignature(sig.Blob)
+	if err != nil {
+		return err
+	}
+	if ok, err := k.pk.Verify(xsig, data); !ok {
+		return err
+	}
+	return nil
+}
+
+// Parses a public key blob in OpenSSH wire format, as returned by
+// PublicKey.Marshal() or found base64 encoded in authorized_keys.
+func ParsePublicKey(blob []byte) (*PublicKey, error) {
+	var w struct {
+		Type string
+		Name string
+		Pk   []byte
+	}
+	if err := ssh.Unmarshal(blob, &w); err != nil {
+		return nil, err
+	}
+	if w.Type != KeyAlgoXMSS {
+		return nil, fmt.Errorf("sshxmss: unexpected key type %q", w.Type)
+	}
+	ctx, err := contextFromSSHName(w.Name)
+	if err != nil {
+		return nil, err
+	}
+	std := make([]byte, 4, 4+len(w.Pk))
+	binary.BigEndian.PutUint32(std, ctx.Oid())
+	pk, err2 := ctx.UnmarshalPublicKey(append(std, w.Pk...))
+	if err2 != nil {
+		return nil, err2
+	}
+	return &PublicKey{pk: pk, sshName: w.Name}, nil
+}
+
+// Parses a line "ssh-xmss@openssh.com <base64 blob> [comment]" as found in
+// authorized_keys files.  Lines with options are not supported.  Returns
+// the public key and its comment.
+func ParseAuthorizedKey(line []byte) (*PublicKey, string, error) {
+	fields := strings.Fields(string(line))
+	if len(fields) < 2 || fields[0] != KeyAlgoXMSS {
+		return nil, "", errors.New("sshxmss: not an XMSS public key")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, "", err
+	}
+	pk, err := ParsePublicKey(blob)
+	if err != nil {
+		return nil, "", err
+	}
+	return pk, strings.Join(fields[2:], " "), nil
+}
+
+// XMSS private key that implements ssh.Signer.
+type Signer struct {
+	sk *xmssmt.PrivateKey
+	pk *PublicKey
+}
+
+// Wraps an XMSS private key for use with golang.org/x/crypto/ssh, for
+// instance as ssh.PublicKeys() client authentication, to sign certificates
+// or in an SSH agent.
+//
+// NOTE Each signature uses up a signature sequence number.
+func NewSigner(sk *xmssmt.PrivateKey) (*Signer, error) {
+	pk, err := NewPublicKey(sk.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{sk: sk, pk: pk}, nil
+}
+
+func (s *Signer) PublicKey() ssh.PublicKey {
+	return s.pk
+}
+
+// Signs data.  rand is not used, as XMSS signatures are deterministic.
+func (s *Signer) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	sig, err := s.sk.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+	return &ssh.Signature{
+		Format: KeyAlgoXMSS,
+		Blob:   sig.MarshalStandard(),
+	}, nil
+}
@@ -0,0 +1,88 @@
+package sshxmss
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sk, _, err := xmssmt.GenerateKeyPair("XMSS-SHA2_10_256", dir+"/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	signer, err := NewSigner(sk)
+	if err != nil {
+		t.Fatalf("NewSigner(): %v", err)
+	}
+	data := []byte("session identifier")
+	sig, err := signer.Sign(nil, data)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = signer.PublicKey().Verify(data, sig); err != nil {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if err = signer.PublicKey().Verify([]byte("other"), sig); err == nil {
+		t.Fatalf("Verify() accepted signature on other data")
+	}
+
+	// Round trip through authorized_keys.
+	line := ssh.MarshalAuthorizedKey(signer.PublicKey())
+	if !bytes.HasPrefix(line, []byte(KeyAlgoXMSS+" ")) {
+		t.Fatalf("MarshalAuthorizedKey() returned %s", line)
+	}
+	pk, comment, err := ParseAuthorizedKey(
+		append(bytes.TrimSpace(line), []byte(" user@host")...))
+	if err != nil {
+		t.Fatalf("ParseAuthorizedKey(): %v", err)
+	}
+	if comment != "user@host" {
+		t.Fatalf("ParseAuthorizedKey() returned comment %q", comment)
+	}
+	if !bytes.Equal(pk.Marshal(), signer.PublicKey().Marshal()) {
+		t.Fatalf("ParseAuthorizedKey() returned different key")
+	}
+	if err = pk.Verify(data, sig); err != nil {
+		t.Fatalf("Verify() with parsed key: %v", err)
+	}
+
+	// The signature is wrapped in the usual way by ssh.Marshal().
+	var sig2 ssh.Signature
+	if err = ssh.Unmarshal(ssh.Marshal(sig), &sig2); err != nil {
+		t.Fatalf("ssh.Unmarshal(): %v", err)
+	}
+	if sig2.Format != KeyAlgoXMSS || !bytes.Equal(sig2.Blob, sig.Blob) {
+		t.Fatalf("Signature did not round trip")
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	sk, _, err := xmssmt.GenerateKeyPair("XMSSMT-SHA2_20/4_256", dir+"/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+	if _, err = NewSigner(sk); err == nil {
+		t.Fatalf("NewSigner() accepted XMSSMT key")
+	}
+}
@@ -0,0 +1,141 @@
+package xmssmt
+
+// Contains the streaming computation of a subtree, which only keeps the
+// nodes at and above a given height in memory.
+
+import (
+	"sync"
+)
+
+// Computes the nodes of the subtree sta at height mt.lowest and above
+// into mt, which only stores those.
+//
+// The leafs are computed in batches on the workers, each of which hashes
+// its leafs up to the root of the batch in its own small tree.  The roots
+// of the batches are then hashed up in order with a stack, as in the
+// classic tree hash algorithm.  Thus besides mt, only the small trees of
+// the batches in flight and a node per height are kept in memory.
+//
+// Does not use the LeafGenerator, which is meant to compute many leafs at
+// once.
+func (ctx *Context) genSubTreeStreamInto(pad scratchPad, skSeed []byte,
+	ph precomputedHashes, sta SubTreeAddress, mt merkleTree) {
+
+	log.Logf("Generating subtree %v keeping heights %d and up ...",
+		sta, mt.lowest)
+
+	var nodeAddr address
+	nodeAddr.setSubTreeFrom(sta.address())
+	nodeAddr.setType(ADDR_TYPE_HASHTREE)
+
+	reportProgress := ctx.newProgressReporter()
+	var total uint32 = 1 << ctx.treeHeight
+	n := ctx.p.N
+
+	// Batches don't go above the lowest stored height, such that their
+	// roots pass through the stack.
+	batchHeight := ctx.leafBatchHeightFor(total)
+	if batchHeight > mt.lowest {
+		batchHeight = mt.lowest
+	}
+	var perBatch uint32 = 1 << batchHeight
+
+	// The number of batches in flight is a power of two, such that it
+	// divides the number of batches of the subtree.
+	var batches uint32 = 1
+	for batches < uint32(4*ctx.threads()) && batches*perBatch < total {
+		batches *= 2
+	}
+	trees := make([]merkleTree, batches)
+	for i := range trees {
+		trees[i] = newMerkleTree(batchHeight+1, n)
+	}
+
+	// The nodes that wait for their right sibling.  Their heights decrease
+	// from the bottom to the top of the stack.
+	stack := make([][]byte, 0, ctx.treeHeight+1)
+	stackBuf := make([]byte, (ctx.treeHeight+1)*n)
+	parents := [2][]byte{make([]byte, n), make([]byte, n)}
+
+	// Hashes up the given node with index idx at the given height with the
+	// nodes on the stack to the left of it.
+	push := func(node []byte, height, idx uint32) {
+		for i := 0; ; i++ {
+			if height >= mt.lowest {
+				copy(mt.Node(height, idx), node)
+			}
+			if idx%2 == 0 {
+				break
+			}
+			parent := parents[i%2]
+			nodeAddr.setTreeHeight(height)
+			nodeAddr.setTreeIndex(idx >> 1)
+			ctx.hInto(pad, stack[len(stack)-1], node, ph, nodeAddr, parent)
+			stack = stack[:len(stack)-1]
+			node, height, idx = parent, height+1, idx>>1
+		}
+		top := stackBuf[uint32(len(stack))*n : uint32(len(stack)+1)*n]
+		copy(top, node)
+		stack = append(stack, top)
+	}
+
+	genBatch := func(pad scratchPad, batch uint32, bt merkleTree) {
+		ctx.genSubTreeBlockInto(pad, ph, sta, batch, bt)
+		reportProgress(perBatch)
+	}
+
+	for first := uint32(0); first < total/perBatch; first += batches {
+		if ctx.Threads == 1 {
+			for i := uint32(0); i < batches; i++ {
+				genBatch(pad, first+i, trees[i])
+			}
+		} else {
+			wg := &sync.WaitGroup{}
+			pool := ctx.workerPool()
+			for i := uint32(0); i < batches; i++ {
+				wg.Add(1)
+				batch, bt := first+i, trees[i]
+				pool.jobs <- func(pad scratchPad) {
+					genBatch(pad, batch, bt)
+					wg.Done()
+				}
+			}
+			wg.Wait()
+		}
+
+		for i := uint32(0); i < batches; i++ {
+			push(trees[i].Root(), batchHeight, first+i)
+		}
+	}
+}
+
+// Computes the leafs of the subtree sta with index in [block·2^h,
+// (block+1)·2^h) and the nodes above them into bt, which should have
+// height h+1.  The nodes of bt are those of the subtree, but with an offset
+// in their index.
+func (ctx *Context) genSubTreeBlockInto(pad scratchPad, ph precomputedHashes,
+	sta SubTreeAddress, block uint32, bt merkleTree) {
+	var otsAddr, lTreeAddr, nodeAddr address
+	addr := sta.address()
+	otsAddr.setSubTreeFrom(addr)
+	otsAddr.setType(ADDR_TYPE_OTS)
+	lTreeAddr.setSubTreeFrom(addr)
+	lTreeAddr.setType(ADDR_TYPE_LTREE)
+	nodeAddr.setSubTreeFrom(addr)
+	nodeAddr.setType(ADDR_TYPE_HASHTREE)
+
+	h := bt.height - 1
+	for i := uint32(0); i < 1<<h; i++ {
+		lTreeAddr.setLTree(block<<h + i)
+		otsAddr.setOTS(block<<h + i)
+		ctx.genLeafInto(pad, ph, lTreeAddr, otsAddr, bt.Node(0, i))
+	}
+	for height := uint32(1); height <= h; height++ {
+		nodeAddr.setTreeHeight(height - 1)
+		for i := uint32(0); i < 1<<(h-height); i++ {
+			nodeAddr.setTreeIndex(block<<(h-height) + i)
+			ctx.hInto(pad, bt.Node(height-1, 2*i), bt.Node(height-1, 2*i+1),
+				ph, nodeAddr, bt.Node(height, i))
+		}
+	}
+}
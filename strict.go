@@ -0,0 +1,139 @@
+package xmssmt
+
+// Contains the strict mode, which only allows the instances and encodings
+// of RFC 8391 and NIST SP 800-208, and the encodings of the RFC.
+
+import (
+	"encoding/binary"
+)
+
+var errStrictCompressed = errorf(
+	"Compressed parameters are not allowed in strict mode")
+
+// Puts the Context in strict mode, which only allows what is defined in
+// RFC 8391 and NIST SP 800-208.  Returns an error, and leaves the Context
+// as is, if its parameters are not those of a named instance from either.
+// In particular parameters with WotsW≠16, SHA512_256 and algorithms added
+// with RegisterAlgorithm() are rejected.
+//
+// In strict mode the compressed parameter prefix is not allowed:
+// MarshalBinary() and WriteInto() of public keys and signatures fail.
+// Use MarshalStandard() and Context.UnmarshalPublicKey() and
+// Context.UnmarshalSignature() instead.
+func (ctx *Context) RequireStandard() Error {
+	if !ctx.FromRFC() && !ctx.FromNIST() {
+		return errorf("%s is not defined in RFC 8391 or NIST SP 800-208",
+			ctx.p)
+	}
+	if ctx.mt != (ctx.p.D > 1) {
+		return errorf("%s is not defined in RFC 8391 or NIST SP 800-208",
+			ctx.p)
+	}
+	ctx.strict = true
+	return nil
+}
+
+// Returns whether the Context is in strict mode.  See RequireStandard().
+func (ctx *Context) Strict() bool {
+	return ctx.strict
+}
+
+// Returns the signature encoded as described in RFC 8391, which, unlike
+// MarshalBinary(), does not include the parameters.
+func (sig *Signature) MarshalStandard() []byte {
+	ret := make([]byte, sig.ctx.sigBytes)
+	sig.encodeInto(ret)
+	return ret
+}
+
+// Returns the public key encoded as described in RFC 8391: the OID followed
+// by the root and the public seed.  Returns an error if the instance has
+// no OID.
+func (pk *PublicKey) MarshalStandard() ([]byte, Error) {
+	oid := pk.ctx.Oid()
+	if oid == 0 {
+		return nil, errorf("%s has no OID", pk.ctx.p)
+	}
+	ret := make([]byte, pk.ctx.pkBytes+4)
+	binary.BigEndian.PutUint32(ret, oid)
+	copy(ret[4:], pk.root)
+	copy(ret[4+pk.ctx.p.N:], pk.pubSeed)
+	return ret, nil
+}
+
+// Decodes a signature for this instance encoded as described in RFC 8391,
+// see Signature.MarshalStandard().  If the Context is not in strict mode,
+// signatures encoded by Signature.MarshalBinary() are accepted as well,
+// if they have the same parameters.
+func (ctx *Context) UnmarshalSignature(buf []byte) (*Signature, Error) {
+	var sig Signature
+	var err Error
+	switch uint32(len(buf)) {
+	case ctx.sigBytes:
+		err = sig.decodeFrom(ctx, buf)
+	case ctx.sigBytes + 4:
+		if ctx.strict {
+			return nil, errStrictCompressed
+		}
+		if err = ctx.checkCompressedParams(buf[:4]); err != nil {
+			return nil, err
+		}
+		err = sig.decodeFrom(ctx, buf[4:])
+	default:
+		// If it's a signature with compressed parameters for another
+		// instance, say so.
+		if !ctx.strict && len(buf) >= 4 && buf[0] == 0xea {
+			if err = ctx.checkCompressedParams(buf[:4]); err != nil {
+				return nil, err
+			}
+		}
+		return nil, kindErrorf(ErrMalformed,
+			"Signature has wrong length: %d", len(buf))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sig, nil
+}
+
+// Decodes a public key for this instance encoded as described in RFC 8391,
+// see PublicKey.MarshalStandard().  If the Context is not in strict mode,
+// public keys encoded by PublicKey.MarshalBinary() are accepted as well,
+// if they have the same parameters.
+func (ctx *Context) UnmarshalPublicKey(buf []byte) (*PublicKey, Error) {
+	if uint32(len(buf)) != ctx.pkBytes+4 {
+		return nil, kindErrorf(ErrMalformed,
+			"Public key has wrong length: %d", len(buf))
+	}
+	oid := binary.BigEndian.Uint32(buf)
+	if oid == 0 || oid != ctx.Oid() {
+		if ctx.strict {
+			if buf[0] == 0xea {
+				return nil, errStrictCompressed
+			}
+			return nil, errorf("Public key has wrong OID: %d", oid)
+		}
+		if err := ctx.checkCompressedParams(buf[:4]); err != nil {
+			return nil, err
+		}
+	}
+	N := ctx.p.N
+	pk := PublicKey{
+		ctx:     ctx,
+		root:    append([]byte{}, buf[4:4+N]...),
+		pubSeed: append([]byte{}, buf[4+N:4+2*N]...),
+	}
+	pk.ph = ctx.precomputeHashes(pk.pubSeed, nil)
+	return &pk, nil
+}
+
+// Checks whether buf contains the compressed parameters of this instance.
+func (ctx *Context) checkCompressedParams(buf []byte) Error {
+	var params Params
+	if err := params.UnmarshalBinary(buf); err != nil {
+		err2 := wrapErrorf(err, "Failed to parse parameters")
+		err2.kind = ErrMalformed
+		return err2
+	}
+	return checkCompatibleParams("Encoding", params, ctx.p)
+}
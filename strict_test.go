@@ -0,0 +1,230 @@
+package xmssmt
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestRequireStandard(t *testing.T) {
+	for _, params := range []Params{
+		{SHAKE, 16, 20, 4, 4, RFC},
+		{SHA512_256, 32, 20, 4, 16, RFC},
+		{SHA2, 32, 20, 4, 16, NIST},
+	} {
+		ctx, err := NewContext(params)
+		if err != nil {
+			t.Fatalf("NewContext(): %v", err)
+		}
+		if ctx.RequireStandard() == nil {
+			t.Fatalf("RequireStandard() accepted %s", params)
+		}
+		if ctx.Strict() {
+			t.Fatalf("Context is strict after RequireStandard() failed")
+		}
+	}
+	ctx := NewContextFromName("XMSSMT-SHAKE256_20/4_192")
+	if err := ctx.RequireStandard(); err != nil {
+		t.Fatalf("RequireStandard(): %v", err)
+	}
+}
+
+func TestStrictEncodings(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := NewContextFromName("XMSSMT-SHAKE_20/4_256")
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	sigBuf, _ := sig.MarshalBinary()
+	pkBuf, _ := pk.MarshalBinary()
+	stdSigBuf := sig.MarshalStandard()
+	stdPkBuf, err := pk.MarshalStandard()
+	if err != nil {
+		t.Fatalf("MarshalStandard(): %v", err)
+	}
+	if uint32(len(stdSigBuf)) != ctx.SignatureSize() ||
+		!bytes.Equal(stdSigBuf, sigBuf[4:]) {
+		t.Fatalf("MarshalStandard() is not MarshalBinary() without prefix")
+	}
+
+	// Without strict mode both encodings are accepted.
+	ctx2 := NewContextFromName("XMSSMT-SHAKE_20/4_256")
+	for _, buf := range [][]byte{pkBuf, stdPkBuf} {
+		if _, err := ctx2.UnmarshalPublicKey(buf); err != nil {
+			t.Fatalf("UnmarshalPublicKey(): %v", err)
+		}
+	}
+	for _, buf := range [][]byte{sigBuf, stdSigBuf} {
+		if _, err := ctx2.UnmarshalSignature(buf); err != nil {
+			t.Fatalf("UnmarshalSignature(): %v", err)
+		}
+	}
+
+	// In strict mode, only the standard encodings are.
+	if err := ctx2.RequireStandard(); err != nil {
+		t.Fatalf("RequireStandard(): %v", err)
+	}
+	if _, err := ctx2.UnmarshalPublicKey(pkBuf); err == nil {
+		t.Fatalf("UnmarshalPublicKey() accepted compressed parameters")
+	}
+	if _, err := ctx2.UnmarshalSignature(sigBuf); err == nil {
+		t.Fatalf("UnmarshalSignature() accepted compressed parameters")
+	}
+	pk2, err := ctx2.UnmarshalPublicKey(stdPkBuf)
+	if err != nil {
+		t.Fatalf("UnmarshalPublicKey(): %v", err)
+	}
+	sig2, err := ctx2.UnmarshalSignature(stdSigBuf)
+	if err != nil {
+		t.Fatalf("UnmarshalSignature(): %v", err)
+	}
+	if _, err := pk2.MarshalBinary(); err == nil {
+		t.Fatalf("MarshalBinary() allowed in strict mode")
+	}
+	if _, err := sig2.MarshalBinary(); err == nil {
+		t.Fatalf("MarshalBinary() allowed in strict mode")
+	}
+	if ok, err := pk2.Verify(sig2, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if ok, _ := pk2.Verify(sig, msg); !ok {
+		t.Fatalf("Verify() rejected signature with the same parameters")
+	}
+
+	ctx3 := NewContextFromName("XMSSMT-SHAKE_20/2_256")
+	if _, err := ctx3.UnmarshalPublicKey(stdPkBuf); err == nil {
+		t.Fatalf("UnmarshalPublicKey() accepted wrong OID")
+	}
+}
+
+func TestMalformedSignatures(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := NewContextFromName("XMSSMT-SHAKE_20/4_256")
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	msg := []byte("test message")
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = sig.Validate(ctx); err != nil {
+		t.Fatalf("Validate(): %v", err)
+	}
+	sigBuf, _ := sig.MarshalBinary()
+	stdSigBuf := sig.MarshalStandard()
+
+	// The index has 24 bits, but FullHeight is 20.
+	buf := append([]byte{}, stdSigBuf...)
+	buf[0] |= 0x80
+	if _, err = ctx.UnmarshalSignature(buf); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("UnmarshalSignature() accepted seqNo out of range: %v", err)
+	}
+	buf = append(append([]byte{}, sigBuf...), 0)
+	var sig2 Signature
+	if err = sig2.UnmarshalBinary(buf); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("UnmarshalBinary() accepted trailing bytes: %v", err)
+	}
+
+	ctx2 := NewContextFromName("XMSSMT-SHAKE_20/2_256")
+	if err = sig.Validate(ctx2); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("Validate() accepted other parameters: %v", err)
+	}
+	sig3 := Signature{ctx: ctx2}
+	if err = sig3.UnmarshalBinary(sigBuf); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("UnmarshalBinary() changed the parameters: %v", err)
+	}
+
+	sig.sigs[1].authPath = sig.sigs[1].authPath[1:]
+	if ok, err := pk.Verify(sig, msg); ok || !errors.Is(err, ErrMalformed) {
+		t.Fatalf("Verify() of truncated signature: %v", err)
+	}
+
+	if err = sig2.UnmarshalBinary(sigBuf); err != nil {
+		t.Fatalf("UnmarshalBinary(): %v", err)
+	}
+	sig2.drv[0] ^= 1
+	ok, err := pk.Verify(&sig2, msg)
+	if ok || !errors.Is(err, ErrInvalidSignature) ||
+		errors.Is(err, ErrMalformed) {
+		t.Fatalf("Verify() of tampered signature: %v", err)
+	}
+}
+
+func TestVerifyRaw(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+	msg := []byte("test message")
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+
+	pkBuf := pk.MarshalRaw()
+	sigBuf := sig.MarshalRaw()
+	if uint32(len(pkBuf)) != 2*ctx.p.N ||
+		uint32(len(sigBuf)) != ctx.SignatureSize() {
+		t.Fatalf("Raw encodings have wrong length")
+	}
+
+	// The verifier only knows the parameters.
+	ctx2, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	if ok, err := ctx2.VerifyRaw(pkBuf, sigBuf, msg); !ok {
+		t.Fatalf("VerifyRaw(): %v", err)
+	}
+	if ok, _ := ctx2.VerifyRaw(pkBuf, sigBuf, []byte("other")); ok {
+		t.Fatalf("VerifyRaw() accepted other message")
+	}
+	prefixed, _ := sig.MarshalBinary()
+	if _, err := ctx2.UnmarshalRawSignature(prefixed); err == nil {
+		t.Fatalf("UnmarshalRawSignature() accepted prefix")
+	}
+}
@@ -0,0 +1,210 @@
+package xmssmt
+
+import (
+	"sort"
+	"time"
+)
+
+// Requirements passed to SuggestParams().
+type SuggestOptions struct {
+	// Number of signatures the key should be able to create.
+	Signatures uint64
+
+	// Upper bound on the time a Sign() may take, including the generation
+	// of a new subtree on the lowest layer.  Zero means no bound.
+	//
+	// NOTE With EnableSubTreePrecomputation() the subtrees are generated
+	//      in advance and Sign() rarely has to wait for them.
+	MaxSignLatency time.Duration
+
+	// Upper bound on the time to verify a signature.  Zero means no bound.
+	MaxVerifyTime time.Duration
+
+	// Hash functions to consider.  Defaults to SHA2 and SHAKE.
+	Funcs []HashFunc
+
+	// Security parameter: the length of the hashes in bytes.  Defaults
+	// to 32.
+	N uint32
+
+	// If set, the cost of the hash functions is measured on this machine
+	// instead of taken from a table of costs of a single amd64 core.
+	Measure bool
+}
+
+// Candidate parameters returned by SuggestParams() with their
+// estimated costs.
+type Suggestion struct {
+	Params        Params
+	SignatureSize uint32
+
+	// Estimated time of a Sign() that has to generate a new subtree
+	// on the lowest layer.  A Sign() with a cached subtree is much faster.
+	SignLatency time.Duration
+
+	// Estimated time to verify a signature.
+	VerifyTime time.Duration
+
+	// Estimated time to generate a key pair, which requires a subtree on
+	// every layer.
+	KeygenTime time.Duration
+}
+
+// Time of a single call to F or H on a single amd64 core, indexed by
+// hash function and N.  Measured with SuggestOptions.Measure.
+var tabulatedHashCosts = map[HashFunc]map[uint32]time.Duration{
+	SHA2:       {16: 410, 24: 400, 32: 340, 64: 1070},
+	SHAKE:      {16: 620, 24: 1320, 32: 660, 64: 2360},
+	SHAKE256:   {16: 1220, 24: 1180, 32: 1130, 64: 2330},
+	SHA512_256: {16: 840, 24: 810, 32: 870},
+}
+
+// Returns candidate parameters that allow for at least opts.Signatures
+// signatures within the given bounds, sorted by signature size.
+//
+// The costs are rough single-core estimates computed from the number of
+// hash function calls; use the bench command of cmd/xmssmt to measure
+// a specific instance.
+func SuggestParams(opts SuggestOptions) ([]Suggestion, Error) {
+	if opts.Signatures == 0 {
+		return nil, errorf("Signatures can't be zero")
+	}
+	if opts.N == 0 {
+		opts.N = 32
+	}
+	if len(opts.Funcs) == 0 {
+		opts.Funcs = []HashFunc{SHA2, SHAKE}
+	}
+
+	// The smallest full height that allows for the requested signatures.
+	var minHeight uint32
+	for minHeight = 1; minHeight < 63 &&
+		(uint64(1)<<minHeight) < opts.Signatures; minHeight++ {
+	}
+	if (uint64(1) << minHeight) < opts.Signatures {
+		return nil, errorf("Too many signatures requested")
+	}
+
+	// A larger full height only yields larger signatures, so besides the
+	// minimal height we only consider those of the named instances.
+	heights := []uint32{minHeight}
+	for _, h := range []uint32{10, 16, 20, 40, 60} {
+		if h > minHeight {
+			heights = append(heights, h)
+		}
+	}
+
+	var ret []Suggestion
+	for _, hf := range opts.Funcs {
+		p := Params{Func: hf, N: opts.N, WotsW: 16, Prf: RFC}
+		if p.N == 24 {
+			p.Prf = NIST
+		}
+		var hashCost time.Duration
+		if opts.Measure {
+			var err Error
+			if hashCost, err = measureHashCost(p); err != nil {
+				return nil, err
+			}
+		} else {
+			hashCost = tabulatedHashCosts[hf][p.N]
+			if hashCost == 0 {
+				return nil, errorf("%s does not support N=%d", hf, p.N)
+			}
+		}
+
+		for _, fh := range heights {
+			for d := uint32(1); d <= fh; d++ {
+				// Subtrees higher than 20 take too long to generate.
+				if fh%d != 0 || fh/d > 20 {
+					continue
+				}
+				for _, w := range []uint16{4, 16, 256} {
+					p.FullHeight = fh
+					p.D = d
+					p.WotsW = w
+					s := p.suggestion(hashCost)
+					if opts.MaxSignLatency != 0 &&
+						s.SignLatency > opts.MaxSignLatency {
+						continue
+					}
+					if opts.MaxVerifyTime != 0 &&
+						s.VerifyTime > opts.MaxVerifyTime {
+						continue
+					}
+					ret = append(ret, s)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].SignatureSize != ret[j].SignatureSize {
+			return ret[i].SignatureSize < ret[j].SignatureSize
+		}
+		return ret[i].SignLatency < ret[j].SignLatency
+	})
+	return ret, nil
+}
+
+// Number of hash function calls to generate a leaf: the WOTS+ secret key,
+// the chains and the L-tree.
+func (params *Params) leafHashes() uint64 {
+	l := uint64(params.WotsLen())
+	return l + l*uint64(params.WotsW-1) + l - 1
+}
+
+// Number of hash function calls to generate a subtree.
+func (params *Params) subTreeHashes() uint64 {
+	leafs := uint64(1) << (params.FullHeight / params.D)
+	return leafs*params.leafHashes() + leafs - 1
+}
+
+// Estimates the costs of these parameters given the cost of a single hash.
+func (params *Params) suggestion(hashCost time.Duration) Suggestion {
+	l := uint64(params.WotsLen())
+	treeHeight := uint64(params.FullHeight / params.D)
+
+	// On average, half of each WOTS+ chain is computed when signing or
+	// verifying.
+	wotsHalf := l * uint64(params.WotsW-1) / 2
+	sign := l + wotsHalf + params.subTreeHashes()
+	verify := uint64(params.D) * (wotsHalf + l - 1 + treeHeight)
+	keygen := uint64(params.D) * params.subTreeHashes()
+
+	return Suggestion{
+		Params:        *params,
+		SignatureSize: params.SignatureSize(),
+		SignLatency:   time.Duration(sign) * hashCost,
+		VerifyTime:    time.Duration(verify) * hashCost,
+		KeygenTime:    time.Duration(keygen) * hashCost,
+	}
+}
+
+// Measures the time of a single hash function call by generating
+// a few leafs.
+func measureHashCost(params Params) (time.Duration, Error) {
+	params.FullHeight = 1
+	params.D = 1
+	ctx, err := NewContext(params)
+	if err != nil {
+		return 0, err
+	}
+	seed := make([]byte, params.N)
+	ph := ctx.precomputeHashes(seed, seed)
+	pad := ctx.newScratchPad()
+	out := make([]byte, params.N)
+	var lTreeAddr, otsAddr address
+	otsAddr.setType(ADDR_TYPE_OTS)
+	lTreeAddr.setType(ADDR_TYPE_LTREE)
+
+	const leafs = 16
+	start := time.Now()
+	for i := uint32(0); i < leafs; i++ {
+		otsAddr.setOTS(i)
+		lTreeAddr.setLTree(i)
+		ctx.genLeafInto(pad, ph, lTreeAddr, otsAddr, out)
+	}
+	elapsed := time.Since(start)
+	return elapsed / time.Duration(leafs*params.leafHashes()), nil
+}
@@ -0,0 +1,104 @@
+package xmssmt
+
+// Contains the exported L-tree and tree hash primitives, which allow to
+// recompute or audit cached subtrees without a PrivateKey.
+
+// Compresses the WOTS+ public key of the key pair with the given index in
+// the subtree sta into a leaf of the subtree using an L-tree.
+func (ctx *Context) LTree(wotsPk, pubSeed []byte, sta SubTreeAddress,
+	idx uint32) ([]byte, Error) {
+	if len(wotsPk) != int(ctx.wotsSigBytes) {
+		return nil, errorf("WOTS+ public key should have length %d",
+			ctx.wotsSigBytes)
+	}
+	if len(pubSeed) != int(ctx.p.N) {
+		return nil, errorf("pubSeed should have length %d", ctx.p.N)
+	}
+	if idx >= 1<<ctx.treeHeight {
+		return nil, errorf("Index %d is out of range", idx)
+	}
+
+	var addr address
+	addr.setSubTreeFrom(sta.address())
+	addr.setType(ADDR_TYPE_LTREE)
+	addr.setLTree(idx)
+
+	// lTreeInto overwrites its input.
+	buf := make([]byte, len(wotsPk))
+	copy(buf, wotsPk)
+	ret := make([]byte, ctx.p.N)
+	ctx.lTreeInto(ctx.newScratchPad(), buf,
+		ctx.precomputeHashes(pubSeed, nil), addr, ret)
+	return ret, nil
+}
+
+// Computes the Merkle tree of the subtree sta on the given leafs, which
+// should be the 2^(FullHeight/D) leafs of N bytes concatenated.
+//
+// Returns the nodes of the tree in the same layout as they are stored in
+// the first Params.BareSubTreeSize() bytes of a cached subtree: first the
+// leafs, then their parents, and so on, up to the root.
+func (ctx *Context) TreeHash(leafs, pubSeed []byte, sta SubTreeAddress) (
+	[]byte, Error) {
+	nLeafs := uint32(1) << ctx.treeHeight
+	if len(leafs) != int(nLeafs*ctx.p.N) {
+		return nil, errorf("Leafs should have length %d", nLeafs*ctx.p.N)
+	}
+	if len(pubSeed) != int(ctx.p.N) {
+		return nil, errorf("pubSeed should have length %d", ctx.p.N)
+	}
+
+	mt := newMerkleTree(ctx.treeHeight+1, ctx.p.N)
+	copy(mt.buf, leafs)
+
+	var nodeAddr address
+	nodeAddr.setSubTreeFrom(sta.address())
+	nodeAddr.setType(ADDR_TYPE_HASHTREE)
+	ctx.genInternalNodesInto(ctx.newScratchPad(),
+		ctx.precomputeHashes(pubSeed, nil), nodeAddr, mt,
+		1, ctx.treeHeight, 0, nLeafs)
+	return mt.buf, nil
+}
+
+// Computes the subtree sta from the seeds.  Returns the nodes in the
+// layout of TreeHash(), which can be compared to the first
+// Params.BareSubTreeSize() bytes of a cached subtree.
+func (ctx *Context) ComputeSubTree(pubSeed, skSeed []byte,
+	sta SubTreeAddress) ([]byte, Error) {
+	if len(pubSeed) != int(ctx.p.N) || len(skSeed) != int(ctx.p.N) {
+		return nil, errorf("Seeds should have length %d", ctx.p.N)
+	}
+	if sta.Layer >= ctx.p.D {
+		return nil, errorf("Layer %d is out of range", sta.Layer)
+	}
+	pad := ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	mt := ctx.genSubTree(pad, skSeed, pubSeed, sta)
+	return mt.buf, nil
+}
+
+// Like ComputeSubTree(), but only computes the nodes at height lowest and
+// above, which are returned in the same layout: first the nodes at height
+// lowest, then their parents, and so on, up to the root.
+//
+// Only these nodes and a few per worker are kept in memory, instead of the
+// whole subtree, which for subtrees of height 20 with N=64 is 128MB.  Pass
+// lowest=FullHeight/D to compute just the root.
+func (ctx *Context) ComputeSubTreeLevels(pubSeed, skSeed []byte,
+	sta SubTreeAddress, lowest uint32) ([]byte, Error) {
+	if len(pubSeed) != int(ctx.p.N) || len(skSeed) != int(ctx.p.N) {
+		return nil, errorf("Seeds should have length %d", ctx.p.N)
+	}
+	if sta.Layer >= ctx.p.D {
+		return nil, errorf("Layer %d is out of range", sta.Layer)
+	}
+	if lowest > ctx.treeHeight {
+		return nil, errorf("Height %d is out of range", lowest)
+	}
+	pad := ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	mt := newMerkleTreeTop(ctx.treeHeight+1, lowest, ctx.p.N)
+	ctx.genSubTreeInto(pad, skSeed, ctx.precomputeHashes(pubSeed, skSeed),
+		sta, mt)
+	return mt.buf, nil
+}
@@ -0,0 +1,103 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestTreeHash(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, _, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	for _, sta := range []SubTreeAddress{{0, 0}, {1, 0}} {
+		// Recompute the subtree from the leafs.
+		n := int(ctx.p.N)
+		leafs := make([]byte, n<<ctx.treeHeight)
+		for i := uint32(0); i < 1<<ctx.treeHeight; i++ {
+			wotsPk, err := ctx.WotsPublicKey(sk.pubSeed, sk.skSeed,
+				WotsAddress(sta, i))
+			if err != nil {
+				t.Fatalf("WotsPublicKey(): %v", err)
+			}
+			leaf, err := ctx.LTree(wotsPk, sk.pubSeed, sta, i)
+			if err != nil {
+				t.Fatalf("LTree(): %v", err)
+			}
+			copy(leafs[int(i)*n:], leaf)
+		}
+		nodes, err := ctx.TreeHash(leafs, sk.pubSeed, sta)
+		if err != nil {
+			t.Fatalf("TreeHash(): %v", err)
+		}
+
+		nodes2, err := ctx.ComputeSubTree(sk.pubSeed, sk.skSeed, sta)
+		if err != nil {
+			t.Fatalf("ComputeSubTree(): %v", err)
+		}
+		if !bytes.Equal(nodes, nodes2) {
+			t.Fatalf("TreeHash() and ComputeSubTree() differ for %v", sta)
+		}
+
+		// Compare with the cached subtree
+		buf, exists, err := sk.ctr.GetSubTree(sta)
+		if err != nil || !exists {
+			t.Fatalf("GetSubTree(%v): %v", sta, err)
+		}
+		if !bytes.Equal(nodes, buf[:ctx.p.BareSubTreeSize()]) {
+			t.Fatalf("Cached subtree %v differs", sta)
+		}
+	}
+}
+
+func TestComputeSubTreeLevels(t *testing.T) {
+	for _, threads := range []int{1, 0} {
+		ctx, err := NewContext(Params{SHA2, 16, 12, 2, 16, RFC})
+		if err != nil {
+			t.Fatalf("NewContext(): %v", err)
+		}
+		ctx.Threads = threads
+		pubSeed := make([]byte, 16)
+		skSeed := bytes.Repeat([]byte{1}, 16)
+		sta := SubTreeAddress{Layer: 1}
+		nodes, err := ctx.ComputeSubTree(pubSeed, skSeed, sta)
+		if err != nil {
+			t.Fatalf("ComputeSubTree(): %v", err)
+		}
+
+		height := ctx.treeHeight + 1
+		for lowest := uint32(0); lowest <= ctx.treeHeight; lowest++ {
+			top, err := ctx.ComputeSubTreeLevels(pubSeed, skSeed, sta, lowest)
+			if err != nil {
+				t.Fatalf("ComputeSubTreeLevels(): %v", err)
+			}
+			offset := ((1 << height) - (1 << (height - lowest))) * 16
+			if !bytes.Equal(top, nodes[offset:]) {
+				t.Fatalf("ComputeSubTreeLevels() differs for height %d",
+					lowest)
+			}
+		}
+		_, err = ctx.ComputeSubTreeLevels(pubSeed, skSeed, sta,
+			ctx.treeHeight+1)
+		if err == nil {
+			t.Fatalf("ComputeSubTreeLevels() accepted a height out of range")
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package xmssmt
+
+// Contains the StatefulVerifier, which detects reuse of signature
+// sequence numbers.
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"sync"
+)
+
+// Records the signatures seen by a StatefulVerifier.
+//
+// NOTE A SeenSeqNoStore does not have to be thread safe.
+type SeenSeqNoStore interface {
+	// Records that a signature with the given sequence number and digest
+	// was seen.  Returns the digest recorded earlier for seqNo, or nil
+	// if no signature with seqNo was seen before.
+	Record(seqNo SignatureSeqNo, digest []byte) ([]byte, Error)
+}
+
+// SeenSeqNoStore that keeps the seen signatures in memory.
+type memorySeenSeqNoStore struct {
+	seen map[SignatureSeqNo][]byte
+}
+
+// Returns a SeenSeqNoStore that keeps the seen signatures in memory.  They
+// are lost when the process exits.
+func NewMemorySeenSeqNoStore() SeenSeqNoStore {
+	return &memorySeenSeqNoStore{seen: make(map[SignatureSeqNo][]byte)}
+}
+
+func (store *memorySeenSeqNoStore) Record(seqNo SignatureSeqNo,
+	digest []byte) ([]byte, Error) {
+	if prev, ok := store.seen[seqNo]; ok {
+		return prev, nil
+	}
+	store.seen[seqNo] = append([]byte{}, digest...)
+	return nil, nil
+}
+
+// Verifies signatures like PublicKey.Verify(), but also records the
+// sequence numbers of valid signatures and detects when the same sequence
+// number is used for different signatures.  That means the signer lost its
+// state, which breaks the security of XMSS[MT].  Receiving the same
+// signature twice is not considered reuse.
+type StatefulVerifier struct {
+	pk    *PublicKey
+	store SeenSeqNoStore
+	mux   sync.Mutex // protects store
+
+	onReuse     func(seqNo SignatureSeqNo)
+	acceptReuse bool
+}
+
+// Creates a StatefulVerifier for the public key, which records the seen
+// signatures in store.
+func NewStatefulVerifier(pk *PublicKey,
+	store SeenSeqNoStore) *StatefulVerifier {
+	return &StatefulVerifier{pk: pk, store: store}
+}
+
+// Sets a function that is called when reuse of a sequence number is
+// detected.  If accept is false, which is the default, the signature is
+// rejected as well; otherwise reuse is only reported to f.
+func (v *StatefulVerifier) SetReuseHandler(f func(seqNo SignatureSeqNo),
+	accept bool) {
+	v.onReuse = f
+	v.acceptReuse = accept
+}
+
+// Checks whether the signature is valid for the message and whether its
+// sequence number has not been used for a different signature before.
+func (v *StatefulVerifier) Verify(sig *Signature, msg []byte) (bool, Error) {
+	ok, err := v.pk.Verify(sig, msg)
+	return v.record(sig, ok, err)
+}
+
+// Like Verify(), but for a signature with a context string.
+// See PrivateKey.SignWithContext().
+func (v *StatefulVerifier) VerifyWithContext(sig *Signature, msg,
+	ctxStr []byte) (bool, Error) {
+	ok, err := v.pk.VerifyWithContext(sig, msg, ctxStr)
+	return v.record(sig, ok, err)
+}
+
+// Records the sequence number of the signature, if it's valid, and checks
+// for reuse.  ok and err are the result of the verification.
+func (v *StatefulVerifier) record(sig *Signature, ok bool, err Error) (
+	bool, Error) {
+	if !ok {
+		// Invalid signatures are not recorded, as otherwise anyone
+		// could make valid signatures look like reuse.
+		return false, err
+	}
+
+	digest := sha256.Sum256(sig.MarshalStandard())
+
+	v.mux.Lock()
+	prev, err := v.store.Record(sig.seqNo, digest[:])
+	v.mux.Unlock()
+
+	if err != nil {
+		return false, wrapErrorf(err, "Failed to record sequence number")
+	}
+	if prev == nil || subtle.ConstantTimeCompare(prev, digest[:]) == 1 {
+		return true, nil
+	}
+
+	log.Logf("Signature sequence number %d is reused", sig.seqNo)
+	if v.onReuse != nil {
+		v.onReuse(sig.seqNo)
+	}
+	if v.acceptReuse {
+		return true, nil
+	}
+	return false, errorf("Signature sequence number %d was used before "+
+		"for a different signature", sig.seqNo)
+}
+
+// Returns the public key of the verifier.
+func (v *StatefulVerifier) PublicKey() *PublicKey {
+	return v.pk
+}
@@ -0,0 +1,66 @@
+package xmssmt
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestStatefulVerifier(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, err := NewContext(Params{SHAKE, 16, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+	defer sk.Close()
+
+	v := NewStatefulVerifier(pk, NewMemorySeenSeqNoStore())
+	sig1, _ := sk.Sign([]byte("message 1"))
+
+	// An invalid signature should not be recorded.
+	if ok, _ := v.Verify(sig1, []byte("message 2")); ok {
+		t.Fatalf("Verify() accepted invalid signature")
+	}
+	if ok, err := v.Verify(sig1, []byte("message 1")); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+	if ok, err := v.Verify(sig1, []byte("message 1")); !ok {
+		t.Fatalf("Verify() rejected the same signature twice: %v", err)
+	}
+
+	// Reuse the sequence number of sig1.
+	sk.DangerousAllowSeqNoRegression()
+	if err = sk.DangerousSetSeqNo(sig1.SeqNo()); err != nil {
+		t.Fatalf("DangerousSetSeqNo(): %v", err)
+	}
+	sig2, _ := sk.Sign([]byte("message 2"))
+	if ok, _ := pk.Verify(sig2, []byte("message 2")); !ok {
+		t.Fatalf("Verify() rejected signature")
+	}
+	if ok, _ := v.Verify(sig2, []byte("message 2")); ok {
+		t.Fatalf("Verify() accepted reused sequence number")
+	}
+
+	var reused []SignatureSeqNo
+	v.SetReuseHandler(func(seqNo SignatureSeqNo) {
+		reused = append(reused, seqNo)
+	}, true)
+	if ok, err := v.Verify(sig2, []byte("message 2")); !ok {
+		t.Fatalf("Verify() rejected reuse while accepting reuse: %v", err)
+	}
+	if len(reused) != 1 || reused[0] != sig1.SeqNo() {
+		t.Fatalf("Reuse handler was not called properly: %v", reused)
+	}
+}
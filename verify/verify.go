@@ -0,0 +1,546 @@
+// Package verify checks XMSS[MT] signatures (RFC 8391 and NIST SP 800-208)
+// as created by the xmssmt package.
+//
+// Unlike the xmssmt package, it does not depend on the private key
+// containers, mmap, file locks or the vectorized hash implementations,
+// which makes it suitable for constrained verifiers such as bootloaders
+// and WebAssembly.  It only depends on the standard library and
+// golang.org/x/crypto/sha3.  Public keys and signatures are expected in
+// the format of xmssmt.PublicKey.MarshalBinary() and
+// xmssmt.Signature.MarshalBinary(), which start with the compressed
+// parameters.
+package verify
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Hash function to use.  Same as xmssmt.HashFunc.
+type HashFunc uint8
+
+const (
+	// SHA-256 for n≤32 and SHA-512 otherwise.
+	SHA2 HashFunc = iota
+
+	// SHAKE-128 for n≤32 and SHAKE-256 otherwise.
+	SHAKE
+
+	// SHAKE-256.
+	SHAKE256
+
+	// SHA-512/256.  Only supports n≤32.
+	SHA512_256
+)
+
+// Way to construct the various PRFs from the hash function.  Same as
+// xmssmt.PrfConstruction.
+type PrfConstruction uint8
+
+const (
+	// As described by RFC8391.
+	RFC PrfConstruction = iota
+
+	// As described by NIST SP 800-208.
+	NIST
+)
+
+// Parameters of an XMSS[MT] instance.  Same as xmssmt.Params.
+type Params struct {
+	Func       HashFunc // which has function to use
+	N          uint32   // security parameter: influences length of hashes
+	FullHeight uint32   // full height of tree
+	D          uint32   // number of subtrees; 1 for XMSS, >1 for XMSSMT
+
+	// WOTS+ Winternitz parameter.  Only 4, 16 and 256 are supported.
+	WotsW uint16
+
+	// Method to use for construction of the PRFs.
+	Prf PrfConstruction
+}
+
+const (
+	paddingF    = 0
+	paddingH    = 1
+	paddingHash = 2
+	paddingPrf  = 3
+
+	addrTypeOts      = 0
+	addrTypeLTree    = 1
+	addrTypeHashTree = 2
+)
+
+// Prefixes of the messages signed by xmssmt.PrivateKey.SignWithContext()
+// and xmssmt.PrivateKey.SignHash().
+var (
+	contextPrefix = []byte("XMSSMT-CTX")
+	prehashPrefix = []byte("XMSSMT-PREHASH")
+)
+
+// Parses parameters as encoded by xmssmt.Params.MarshalBinary() and checks
+// that they are supported.
+func ParseParams(buf []byte) (*Params, error) {
+	if len(buf) != 4 {
+		return nil, fmt.Errorf("verify: parameters must be 4 bytes long "+
+			"(instead of %d)", len(buf))
+	}
+	val := binary.BigEndian.Uint32(buf)
+	if val>>24 != 0xea {
+		return nil, errors.New("verify: these are not compressed parameters")
+	}
+	if (val>>21)&7 != 0 {
+		return nil, errors.New("verify: unsupported compressed parameters version")
+	}
+	var params Params
+	switch (val >> 12) & 3 {
+	case 0:
+		params.WotsW = 4
+	case 1:
+		params.WotsW = 16
+	case 2:
+		params.WotsW = 256
+	default:
+		return nil, errors.New("verify: unsupported Winternitz parameter")
+	}
+	params.Prf = PrfConstruction((val >> 20) & 1)
+	params.N = (((val >> 16) & 15) + 1) * 8
+	params.Func = HashFunc((val >> 14) & 3)
+	params.FullHeight = (val >> 6) & 63
+	params.D = val & 63
+	if _, err := newInstance(params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
+
+// Sizes derived from the Params.
+type instance struct {
+	p          Params
+	treeHeight uint32
+	indexBytes uint32
+	prefixLen  uint32
+	logW       uint8
+	wotsLen1   uint32
+	wotsLen2   uint32
+	wotsLen    uint32
+	sigBytes   uint32
+}
+
+func newInstance(p Params) (*instance, error) {
+	in := instance{p: p}
+	if p.N != 16 && p.N != 24 && p.N != 32 && p.N != 64 {
+		return nil, errors.New("verify: only N=16,24,32,64 are supported")
+	}
+	if p.Func > SHA512_256 {
+		return nil, errors.New("verify: unknown hash function")
+	}
+	if p.Func == SHA512_256 && p.N > 32 {
+		return nil, errors.New("verify: SHA512_256 only supports N=16,24,32")
+	}
+	if p.D == 0 {
+		return nil, errors.New("verify: D can't be zero")
+	}
+	if p.FullHeight%p.D != 0 {
+		return nil, errors.New("verify: D does not divide FullHeight")
+	}
+	in.treeHeight = p.FullHeight / p.D
+
+	switch p.WotsW {
+	case 4:
+		in.logW = 2
+		in.wotsLen2 = 5
+		if p.N == 16 {
+			in.wotsLen2 = 4
+		}
+	case 16:
+		in.logW = 4
+		in.wotsLen2 = 3
+	case 256:
+		in.logW = 8
+		in.wotsLen2 = 2
+	default:
+		return nil, errors.New("verify: only WotsW=4,16,256 are supported")
+	}
+	in.wotsLen1 = 8 * p.N / uint32(in.logW)
+	in.wotsLen = in.wotsLen1 + in.wotsLen2
+
+	in.indexBytes = 4
+	if p.D > 1 {
+		in.indexBytes = (p.FullHeight + 7) / 8
+	}
+	in.prefixLen = p.N
+	if p.Prf == NIST {
+		in.prefixLen = 4
+	}
+	in.sigBytes = in.indexBytes + p.N + p.D*in.wotsLen*p.N + p.FullHeight*p.N
+	return &in, nil
+}
+
+// XMSS[MT] public key.
+type PublicKey struct {
+	in      *instance
+	root    []byte
+	pubSeed []byte
+}
+
+// Parses a public key as written by xmssmt.PublicKey.MarshalBinary().
+func ParsePublicKey(buf []byte) (*PublicKey, error) {
+	if len(buf) < 4 {
+		return nil, errors.New("verify: public key is too short")
+	}
+	params, err := ParseParams(buf[:4])
+	if err != nil {
+		return nil, err
+	}
+	in, _ := newInstance(*params)
+	if uint32(len(buf)) != 4+2*params.N {
+		return nil, fmt.Errorf("verify: public key has wrong length: %d",
+			len(buf))
+	}
+	return &PublicKey{
+		in:      in,
+		root:    append([]byte{}, buf[4:4+params.N]...),
+		pubSeed: append([]byte{}, buf[4+params.N:]...),
+	}, nil
+}
+
+// Returns the parameters of the public key.
+func (pk *PublicKey) Params() Params {
+	return pk.in.p
+}
+
+// Checks whether sig is a valid signature of the public key pk on msg.
+// pk and sig are as written by xmssmt.PublicKey.MarshalBinary() and
+// xmssmt.Signature.MarshalBinary().
+func Verify(pk, sig, msg []byte) (bool, error) {
+	thePk, err := ParsePublicKey(pk)
+	if err != nil {
+		return false, err
+	}
+	return thePk.Verify(sig, msg)
+}
+
+// Checks whether sig is a valid signature of this public key on msg.
+// See xmssmt.PublicKey.Verify().
+func (pk *PublicKey) Verify(sig, msg []byte) (bool, error) {
+	return pk.verify(sig, nil, bytes.NewReader(msg))
+}
+
+// Reads a message from the io.Reader and checks whether sig is a valid
+// signature of this public key on it.  See xmssmt.PublicKey.VerifyFrom().
+func (pk *PublicKey) VerifyFrom(sig []byte, msg io.Reader) (bool, error) {
+	return pk.verify(sig, nil, msg)
+}
+
+// Checks whether sig is a valid signature of this public key on msg with
+// the context string ctxStr.  See xmssmt.PublicKey.VerifyWithContext().
+func (pk *PublicKey) VerifyWithContext(sig, msg, ctxStr []byte) (
+	bool, error) {
+	if len(ctxStr) > 255 {
+		return false, errors.New("verify: context string can be at most 255 bytes")
+	}
+	prefix := append(append([]byte{}, contextPrefix...), byte(len(ctxStr)))
+	prefix = append(prefix, ctxStr...)
+	return pk.verify(sig, prefix, bytes.NewReader(msg))
+}
+
+// Checks whether sig is a valid signature of this public key on the
+// digest and domain.  See xmssmt.PublicKey.VerifyHash().
+func (pk *PublicKey) VerifyHash(sig, digest, domain []byte) (bool, error) {
+	if len(digest) != int(pk.in.p.N) {
+		return false, fmt.Errorf("verify: digest should have length %d",
+			pk.in.p.N)
+	}
+	if len(domain) > 255 {
+		return false, errors.New("verify: domain can be at most 255 bytes")
+	}
+	msg := append(append([]byte{}, prehashPrefix...), byte(len(domain)))
+	msg = append(msg, domain...)
+	msg = append(msg, digest...)
+	return pk.verify(sig, nil, bytes.NewReader(msg))
+}
+
+// Verifies the signature on the message prepended by prefix.
+func (pk *PublicKey) verify(sig, prefix []byte, msg io.Reader) (bool, error) {
+	in := pk.in
+	n := in.p.N
+	if len(sig) < 4 {
+		return false, errors.New("verify: signature is too short")
+	}
+	params, err := ParseParams(sig[:4])
+	if err != nil {
+		return false, err
+	}
+	if *params != in.p {
+		return false, errors.New(
+			"verify: signature and public key have different parameters")
+	}
+	sig = sig[4:]
+	if uint32(len(sig)) != in.sigBytes {
+		return false, fmt.Errorf("verify: signature has wrong length: %d",
+			len(sig)+4)
+	}
+
+	var seqNo uint64
+	for _, b := range sig[:in.indexBytes] {
+		seqNo = seqNo<<8 | uint64(b)
+	}
+	if in.p.FullHeight < 64 && seqNo>>in.p.FullHeight != 0 {
+		return false, errors.New("verify: signature sequence number is too large")
+	}
+	R := sig[in.indexBytes : in.indexBytes+n]
+
+	node, err := in.hashMessage(prefix, msg, R, pk.root, seqNo)
+	if err != nil {
+		return false, err
+	}
+
+	off := in.indexBytes + n
+	wotsBytes := in.wotsLen * n
+	for layer := uint32(0); layer < in.p.D; layer++ {
+		wotsSig := sig[off : off+wotsBytes]
+		authPath := sig[off+wotsBytes : off+wotsBytes+in.treeHeight*n]
+		off += wotsBytes + in.treeHeight*n
+
+		var addr [8]uint32
+		addr[0] = layer
+		tree := seqNo >> ((layer + 1) * in.treeHeight)
+		addr[1] = uint32(tree >> 32)
+		addr[2] = uint32(tree)
+		leaf := uint32((seqNo >> (layer * in.treeHeight)) &
+			((1 << in.treeHeight) - 1))
+		node = pk.layerRoot(addr, leaf, wotsSig, authPath, node)
+	}
+
+	if subtle.ConstantTimeCompare(node, pk.root) != 1 {
+		return false, errors.New("verify: invalid signature")
+	}
+	return true, nil
+}
+
+// Computes the root of the subtree at addr from the WOTS+ signature of
+// the given leaf on msg and the authentication path.
+func (pk *PublicKey) layerRoot(addr [8]uint32, leaf uint32,
+	wotsSig, authPath, msg []byte) []byte {
+	in := pk.in
+	n := in.p.N
+
+	// Compute the WOTS+ public key from the signature.
+	otsAddr := addr
+	otsAddr[3] = addrTypeOts
+	otsAddr[4] = leaf
+	lengths := in.chainLengths(msg)
+	wotsPk := make([]byte, in.wotsLen*n)
+	for i := uint32(0); i < in.wotsLen; i++ {
+		otsAddr[5] = i
+		out := wotsPk[i*n : (i+1)*n]
+		copy(out, wotsSig[i*n:(i+1)*n])
+		for j := uint32(lengths[i]); j < uint32(in.p.WotsW)-1; j++ {
+			otsAddr[6] = j
+			pk.f(out, otsAddr)
+		}
+	}
+
+	// Compress it into the leaf with the L-tree.
+	lTreeAddr := addr
+	lTreeAddr[3] = addrTypeLTree
+	lTreeAddr[4] = leaf
+	l := in.wotsLen
+	for height := uint32(0); l > 1; height++ {
+		lTreeAddr[5] = height
+		for i := uint32(0); i < l>>1; i++ {
+			lTreeAddr[6] = i
+			pk.h(wotsPk[2*i*n:(2*i+1)*n], wotsPk[(2*i+1)*n:(2*i+2)*n],
+				lTreeAddr, wotsPk[i*n:(i+1)*n])
+		}
+		if l&1 == 1 {
+			copy(wotsPk[(l>>1)*n:], wotsPk[(l-1)*n:l*n])
+			l = (l >> 1) + 1
+		} else {
+			l >>= 1
+		}
+	}
+	node := wotsPk[:n]
+
+	// Hash up the subtree with the authentication path.
+	nodeAddr := addr
+	nodeAddr[3] = addrTypeHashTree
+	offset := leaf
+	for height := uint32(0); height < in.treeHeight; height++ {
+		nodeAddr[5] = height
+		nodeAddr[6] = offset >> 1
+		sibling := authPath[height*n : (height+1)*n]
+		if offset&1 == 0 {
+			pk.h(node, sibling, nodeAddr, node)
+		} else {
+			pk.h(sibling, node, nodeAddr, node)
+		}
+		offset >>= 1
+	}
+	return node
+}
+
+// Converts a message into positions on the WOTS+ chains.
+func (in *instance) chainLengths(msg []byte) []uint8 {
+	ret := make([]uint8, in.wotsLen)
+	in.toBaseW(msg, ret[:in.wotsLen1])
+
+	var csum uint32
+	for _, l := range ret[:in.wotsLen1] {
+		csum += uint32(in.p.WotsW) - 1 - uint32(l)
+	}
+	csum <<= 8 - ((in.wotsLen2 * uint32(in.logW)) % 8)
+	csumBytes := make([]byte, (in.wotsLen2*uint32(in.logW)+7)/8)
+	encodeInto(uint64(csum), csumBytes)
+	in.toBaseW(csumBytes, ret[in.wotsLen1:])
+	return ret
+}
+
+// Converts input into base w.
+func (in *instance) toBaseW(input []byte, output []uint8) {
+	var total uint8
+	var bits uint8
+	for i := range output {
+		if bits == 0 {
+			total = input[0]
+			input = input[1:]
+			bits = 8
+		}
+		bits -= in.logW
+		output[i] = uint8(uint16(total>>bits) & (in.p.WotsW - 1))
+	}
+}
+
+// Sets out to the n-byte hash of in.
+func (in *instance) hashInto(buf, out []byte) {
+	switch in.p.Func {
+	case SHA2:
+		if in.p.N == 64 {
+			ret := sha512.Sum512(buf)
+			copy(out, ret[:])
+		} else {
+			ret := sha256.Sum256(buf)
+			copy(out, ret[:in.p.N])
+		}
+	case SHA512_256:
+		ret := sha512.Sum512_256(buf)
+		copy(out, ret[:in.p.N])
+	case SHAKE:
+		if in.p.N == 64 {
+			sha3.ShakeSum256(out[:in.p.N], buf)
+		} else {
+			sha3.ShakeSum128(out[:in.p.N], buf)
+		}
+	case SHAKE256:
+		sha3.ShakeSum256(out[:in.p.N], buf)
+	}
+}
+
+// Computes PRF(pubSeed, addr) into out.
+func (pk *PublicKey) prf(addr [8]uint32, out []byte) {
+	pl := pk.in.prefixLen
+	n := pk.in.p.N
+	buf := make([]byte, pl+n+32)
+	encodeInto(paddingPrf, buf[:pl])
+	copy(buf[pl:], pk.pubSeed)
+	for i, word := range addr {
+		binary.BigEndian.PutUint32(buf[pl+n+uint32(4*i):], word)
+	}
+	pk.in.hashInto(buf, out)
+}
+
+// Sets node to the chaining function F of WOTS+ applied to node.
+func (pk *PublicKey) f(node []byte, addr [8]uint32) {
+	pl := pk.in.prefixLen
+	n := pk.in.p.N
+	buf := make([]byte, pl+2*n)
+	encodeInto(paddingF, buf[:pl])
+	addr[7] = 0
+	pk.prf(addr, buf[pl:pl+n])
+	addr[7] = 1
+	pk.prf(addr, buf[pl+n:pl+2*n])
+	for i := uint32(0); i < n; i++ {
+		buf[pl+n+i] ^= node[i]
+	}
+	pk.in.hashInto(buf, node)
+}
+
+// Computes RAND_HASH of left and right into out, which may alias either.
+func (pk *PublicKey) h(left, right []byte, addr [8]uint32, out []byte) {
+	pl := pk.in.prefixLen
+	n := pk.in.p.N
+	buf := make([]byte, pl+3*n)
+	encodeInto(paddingH, buf[:pl])
+	for i := uint32(0); i < 3; i++ {
+		addr[7] = i
+		pk.prf(addr, buf[pl+i*n:pl+(i+1)*n])
+	}
+	for i := uint32(0); i < n; i++ {
+		buf[pl+n+i] ^= left[i]
+		buf[pl+2*n+i] ^= right[i]
+	}
+	pk.in.hashInto(buf, out)
+}
+
+// Computes the hash of the message, prepended by prefix, for the
+// signature with index idx and randomness R.
+func (in *instance) hashMessage(prefix []byte, msg io.Reader,
+	R, root []byte, idx uint64) ([]byte, error) {
+	n := in.p.N
+	var h io.Writer
+	var sha2 hash.Hash
+	var shake sha3.ShakeHash
+	switch {
+	case in.p.Func == SHA512_256:
+		sha2 = sha512.New512_256()
+		h = sha2
+	case in.p.Func == SHA2 && n == 64:
+		sha2 = sha512.New()
+		h = sha2
+	case in.p.Func == SHA2:
+		sha2 = sha256.New()
+		h = sha2
+	case in.p.Func == SHAKE && n != 64:
+		shake = sha3.NewShake128()
+		h = shake
+	default:
+		shake = sha3.NewShake256()
+		h = shake
+	}
+
+	header := make([]byte, in.prefixLen+2*n+n)
+	encodeInto(paddingHash, header[:in.prefixLen])
+	copy(header[in.prefixLen:], R)
+	copy(header[in.prefixLen+n:], root)
+	encodeInto(idx, header[in.prefixLen+2*n:])
+	h.Write(header)
+	h.Write(prefix)
+	if _, err := io.Copy(h, msg); err != nil {
+		return nil, fmt.Errorf("verify: failed to read message: %v", err)
+	}
+
+	ret := make([]byte, n)
+	if sha2 != nil {
+		copy(ret, sha2.Sum(nil))
+	} else {
+		shake.Read(ret)
+	}
+	return ret, nil
+}
+
+// Encodes x big endian into out.
+func encodeInto(x uint64, out []byte) {
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = byte(x)
+		x >>= 8
+	}
+}
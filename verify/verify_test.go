@@ -0,0 +1,114 @@
+package verify
+
+import (
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, params := range []xmssmt.Params{
+		{Func: xmssmt.SHAKE, N: 16, FullHeight: 8, D: 2, WotsW: 16,
+			Prf: xmssmt.RFC},
+		{Func: xmssmt.SHA2, N: 32, FullHeight: 4, D: 1, WotsW: 16,
+			Prf: xmssmt.RFC},
+		{Func: xmssmt.SHA2, N: 24, FullHeight: 4, D: 1, WotsW: 256,
+			Prf: xmssmt.NIST},
+		{Func: xmssmt.SHA2, N: 64, FullHeight: 4, D: 2, WotsW: 16,
+			Prf: xmssmt.RFC},
+		{Func: xmssmt.SHAKE, N: 64, FullHeight: 4, D: 1, WotsW: 4,
+			Prf: xmssmt.RFC},
+		{Func: xmssmt.SHAKE256, N: 32, FullHeight: 4, D: 2, WotsW: 4,
+			Prf: xmssmt.RFC},
+		{Func: xmssmt.SHA512_256, N: 16, FullHeight: 4, D: 2, WotsW: 16,
+			Prf: xmssmt.RFC},
+	} {
+		ctx, err := xmssmt.NewContext(params)
+		if err != nil {
+			t.Fatalf("NewContext(%v): %v", params, err)
+		}
+		sk, xpk, err := ctx.GenerateKeyPair(fmt.Sprintf("%s/key%d", dir, i))
+		if err != nil {
+			t.Fatalf("GenerateKeyPair(%v): %v", params, err)
+		}
+		pkBuf, _ := xpk.MarshalBinary()
+		pk, err2 := ParsePublicKey(pkBuf)
+		if err2 != nil {
+			t.Fatalf("ParsePublicKey(%v): %v", params, err2)
+		}
+		if pk.Params() != (Params{HashFunc(params.Func), params.N,
+			params.FullHeight, params.D, params.WotsW,
+			PrfConstruction(params.Prf)}) {
+			t.Fatalf("Params() = %v", pk.Params())
+		}
+
+		msg := []byte("test message")
+		for j := 0; j < 3; j++ {
+			sig, err := sk.Sign(msg)
+			if err != nil {
+				t.Fatalf("Sign(%v): %v", params, err)
+			}
+			sigBuf, _ := sig.MarshalBinary()
+			if ok, err := Verify(pkBuf, sigBuf, msg); !ok {
+				t.Fatalf("Verify(%v): %v", params, err)
+			}
+			if ok, _ := pk.Verify(sigBuf, []byte("other message")); ok {
+				t.Fatalf("Verify(%v) accepted wrong message", params)
+			}
+			sigBuf[len(sigBuf)-1] ^= 1
+			if ok, _ := pk.Verify(sigBuf, msg); ok {
+				t.Fatalf("Verify(%v) accepted corrupted signature", params)
+			}
+		}
+
+		sig, err := sk.SignWithContext(msg, []byte("ctx"))
+		if err != nil {
+			t.Fatalf("SignWithContext(%v): %v", params, err)
+		}
+		sigBuf, _ := sig.MarshalBinary()
+		if ok, err := pk.VerifyWithContext(sigBuf, msg, []byte("ctx")); !ok {
+			t.Fatalf("VerifyWithContext(%v): %v", params, err)
+		}
+		if ok, _ := pk.VerifyWithContext(sigBuf, msg, []byte("xtc")); ok {
+			t.Fatalf("VerifyWithContext(%v) accepted wrong context", params)
+		}
+		if ok, _ := pk.Verify(sigBuf, msg); ok {
+			t.Fatalf("Verify(%v) accepted signature with context", params)
+		}
+
+		digest := make([]byte, params.N)
+		sig, err = sk.SignHash(digest, []byte("domain"))
+		if err != nil {
+			t.Fatalf("SignHash(%v): %v", params, err)
+		}
+		sigBuf, _ = sig.MarshalBinary()
+		if ok, err := pk.VerifyHash(sigBuf, digest, []byte("domain")); !ok {
+			t.Fatalf("VerifyHash(%v): %v", params, err)
+		}
+		sk.Close()
+	}
+}
+
+// The point of this package is to not depend on the xmssmt package.
+func TestImports(t *testing.T) {
+	pkg, err := build.ImportDir(".", 0)
+	if err != nil {
+		t.Fatalf("ImportDir: %v", err)
+	}
+	for _, imp := range pkg.Imports {
+		if strings.Contains(imp, ".") && imp != "golang.org/x/crypto/sha3" {
+			t.Fatalf("Unexpected import %s", imp)
+		}
+	}
+}
@@ -1,9 +1,5 @@
 package xmssmt
 
-import (
-	"sort"
-)
-
 // The Winternitz One-Time Signature scheme as used by XMSS[MT].
 
 // Generate WOTS+ secret key
@@ -23,7 +19,12 @@ func (ctx *Context) genWotsSk(pad scratchPad, ph precomputedHashes,
 // are called "chain lengths".
 func (ctx *Context) wotsChainLengths(msg []byte) []uint8 {
 	ret := make([]uint8, ctx.wotsLen)
+	ctx.wotsChainLengthsInto(msg, ret)
+	return ret
+}
 
+// Converts a message into chain lengths and stores them in ret.
+func (ctx *Context) wotsChainLengthsInto(msg []byte, ret []uint8) {
 	// compute the chain lengths for the message itself
 	ctx.toBaseW(msg, ret[:ctx.wotsLen1])
 
@@ -35,12 +36,10 @@ func (ctx *Context) wotsChainLengths(msg []byte) []uint8 {
 	csum = csum << (8 - ((ctx.wotsLen2 * uint32(ctx.wotsLogW)) % 8))
 
 	// put checksum in buffer
-	ctx.toBaseW(
-		encodeUint64(
-			uint64(csum),
-			int((ctx.wotsLen2*uint32(ctx.wotsLogW)+7)/8)),
-		ret[ctx.wotsLen1:])
-	return ret
+	var csumBuf [8]byte
+	csumBytes := csumBuf[:(ctx.wotsLen2*uint32(ctx.wotsLogW)+7)/8]
+	encodeUint64Into(uint64(csum), csumBytes)
+	ctx.toBaseW(csumBytes, ret[ctx.wotsLen1:])
 }
 
 // Converts the given array of bytes into base w for the WOTS+ one-time
@@ -94,7 +93,7 @@ func (ctx *Context) wotsPkGenInto(pad scratchPad, ph precomputedHashes,
 	ctx.genWotsSk(pad, ph, addr, out)
 	n := ctx.p.N
 
-	if !ctx.x4Available {
+	if ctx.lanes == 0 {
 		// Unvectorized
 		for i := uint32(0); i < ctx.wotsLen; i++ {
 			addr.setChain(uint32(i))
@@ -105,19 +104,22 @@ func (ctx *Context) wotsPkGenInto(pad scratchPad, ph precomputedHashes,
 		return
 	}
 
-	// Fourway vectorized
-	addrs := [4]address{addr, addr, addr, addr}
-	for i := uint32(0); i < ctx.wotsLen; i += 4 {
-		var bufs [4][]byte
-		for j := uint32(0); j < 4 && i+j < ctx.wotsLen; j++ {
+	// Four- or eightway vectorized
+	var addrs [8]address
+	for j := 0; j < 8; j++ {
+		addrs[j] = addr
+	}
+	for i := uint32(0); i < ctx.wotsLen; i += ctx.lanes {
+		var bufs [8][]byte
+		for j := uint32(0); j < ctx.lanes && i+j < ctx.wotsLen; j++ {
 			addrs[j].setChain(uint32(i + j))
 			bufs[j] = out[n*(i+j) : n*(i+j+1)]
 		}
 		for k := uint16(0); k < ctx.p.WotsW-1; k++ {
-			for j := 0; j < 4; j++ {
+			for j := uint32(0); j < ctx.lanes; j++ {
 				addrs[j].setHash(uint32(k))
 			}
-			ctx.fX4Into(pad, bufs, ph.pubSeed, addrs, bufs)
+			ctx.fXNInto(pad, bufs, ph, addrs, bufs)
 		}
 	}
 }
@@ -133,11 +135,17 @@ func (ctx *Context) wotsSign(pad scratchPad, msg, pubSeed, skSeed []byte,
 // Create a WOTS+ signature of a n-byte message
 func (ctx *Context) wotsSignInto(pad scratchPad, msg []byte,
 	ph precomputedHashes, addr address, wotsSig []byte) {
-	lengths := ctx.wotsChainLengths(msg)
+	lengths := pad.wotsLengths
+	ctx.wotsChainLengthsInto(msg, lengths)
 	ctx.genWotsSk(pad, ph, addr, wotsSig)
 	n := ctx.p.N
 
-	if !ctx.x4Available {
+	if ctx.constantTime {
+		ctx.wotsSignConstantTimeInto(pad, lengths, ph, addr, wotsSig)
+		return
+	}
+
+	if ctx.lanes == 0 {
 		// Unvectorized
 		for i := uint32(0); i < ctx.wotsLen; i++ {
 			addr.setChain(uint32(i))
@@ -148,29 +156,34 @@ func (ctx *Context) wotsSignInto(pad scratchPad, msg []byte,
 		return
 	}
 
-	// Fourway vectorized
-	steps := make([]uint16, ctx.wotsLen)
+	// Four- or eightway vectorized
+	start, steps := pad.wotsStart, pad.wotsSteps
 	for i := uint32(0); i < ctx.wotsLen; i++ {
+		start[i] = 0
 		steps[i] = uint16(lengths[i])
 	}
-	ctx.wotsGenChainsX4Into(pad, wotsSig, make([]uint16, ctx.wotsLen),
-		steps, ph, addr, wotsSig)
+	ctx.wotsGenChainsXNInto(pad, wotsSig, start, steps, ph, addr, wotsSig)
+}
+
+// A WOTS+ chain to compute by wotsGenChainsXNInto().
+type wotsChain struct {
+	start uint16
+	steps uint16
+	idx   uint32
 }
 
 // Compute the (start + steps)th value in the WOTS+ chain, given
-// the start'th value in the chain.
-func (ctx *Context) wotsGenChainsX4Into(pad scratchPad, in []byte,
+// the start'th value in the chain, for all chains using the four- or
+// eightway hashes.
+func (ctx *Context) wotsGenChainsXNInto(pad scratchPad, in []byte,
 	start []uint16, steps []uint16, ph precomputedHashes,
 	addr address, out []byte) {
 	n := ctx.p.N
+	lanes := ctx.lanes
 	copy(out[:ctx.wotsLen*n], in)
 
 	// We group chains by their length
-	chains := make([]struct {
-		start uint16
-		steps uint16
-		idx   uint32
-	}, ctx.wotsLen)
+	chains := pad.wotsChains
 	for i := uint32(0); i < ctx.wotsLen; i++ {
 		chains[i].start = start[i]
 		chains[i].steps = steps[i]
@@ -178,16 +191,22 @@ func (ctx *Context) wotsGenChainsX4Into(pad scratchPad, in []byte,
 	}
 
 	// Note that we sort by reverse order so that the last chains that are
-	// left over when wotsLen is not divisable by four are short.
-	sort.Slice(chains, func(i, j int) bool {
-		return chains[i].steps > chains[j].steps
-	})
+	// left over when wotsLen is not divisable by the number of lanes
+	// are short.  We use an insertion sort, as sort.Slice allocates.
+	for i := 1; i < len(chains); i++ {
+		for j := i; j > 0 && chains[j].steps > chains[j-1].steps; j-- {
+			chains[j], chains[j-1] = chains[j-1], chains[j]
+		}
+	}
 
 	// Now we know what to do, do it.
-	addrs := [4]address{addr, addr, addr, addr}
-	for i := uint32(0); i < ctx.wotsLen; i += 4 {
-		var bufs [4][]byte
-		for j := uint32(0); j < 4 && i+j < ctx.wotsLen; j++ {
+	var addrs [8]address
+	for j := 0; j < 8; j++ {
+		addrs[j] = addr
+	}
+	for i := uint32(0); i < ctx.wotsLen; i += lanes {
+		var bufs [8][]byte
+		for j := uint32(0); j < lanes && i+j < ctx.wotsLen; j++ {
 			idx := chains[i+j].idx
 			addrs[j].setChain(idx)
 			bufs[j] = out[n*idx : n*(idx+1)]
@@ -195,7 +214,7 @@ func (ctx *Context) wotsGenChainsX4Into(pad scratchPad, in []byte,
 
 		// As we reverse sorted the chains, we know the first is longest and
 		// the last is shortest.
-		watching := uint32(3) // we're watching the shortest initially
+		watching := lanes - 1 // we're watching the shortest initially
 		for i+watching >= ctx.wotsLen {
 			watching--
 		}
@@ -215,7 +234,7 @@ func (ctx *Context) wotsGenChainsX4Into(pad scratchPad, in []byte,
 			for j := uint32(0); j < watching+1; j++ {
 				addrs[j].setHash(uint32(k + chains[i+j].start))
 			}
-			ctx.fX4Into(pad, bufs, ph.pubSeed, addrs, bufs)
+			ctx.fXNInto(pad, bufs, ph, addrs, bufs)
 		}
 	}
 }
@@ -224,10 +243,11 @@ func (ctx *Context) wotsGenChainsX4Into(pad scratchPad, in []byte,
 // stores it in the provided buffer.
 func (ctx *Context) wotsPkFromSigInto(pad scratchPad, sig, msg []byte,
 	ph precomputedHashes, addr address, pk []byte) {
-	lengths := ctx.wotsChainLengths(msg)
+	lengths := pad.wotsLengths
+	ctx.wotsChainLengthsInto(msg, lengths)
 	n := ctx.p.N
 
-	if !ctx.x4Available {
+	if ctx.lanes == 0 {
 		// Unvectorized
 		for i := uint32(0); i < ctx.wotsLen; i++ {
 			addr.setChain(uint32(i))
@@ -238,14 +258,13 @@ func (ctx *Context) wotsPkFromSigInto(pad scratchPad, sig, msg []byte,
 		return
 	}
 
-	// Fourway vectorized
-	steps := make([]uint16, ctx.wotsLen)
-	start := make([]uint16, ctx.wotsLen)
+	// Four- or eightway vectorized
+	start, steps := pad.wotsStart, pad.wotsSteps
 	for i := uint32(0); i < ctx.wotsLen; i++ {
 		steps[i] = ctx.p.WotsW - 1 - uint16(lengths[i])
 		start[i] = uint16(lengths[i])
 	}
-	ctx.wotsGenChainsX4Into(pad, sig, start, steps, ph, addr, pk)
+	ctx.wotsGenChainsXNInto(pad, sig, start, steps, ph, addr, pk)
 }
 
 // Returns the public key from a message and its WOTS+ signature.
@@ -0,0 +1,82 @@
+// Package wots exposes the WOTS+ one-time signature scheme used by XMSS[MT]
+// (RFC 8391) with the same parameters and hash function.
+//
+// A WOTS+ key pair is determined by the secret skSeed, the public pubSeed
+// and an Address.  Each key pair may sign only a single message: signing
+// two different messages with the same key pair allows forgeries.
+package wots
+
+import (
+	"crypto/subtle"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+// Address of a WOTS+ key pair.  See NewAddress().
+type Address = xmssmt.HashAddress
+
+// Returns the address of the key pair with index ots in the subtree
+// at the given layer and tree.
+func NewAddress(layer uint32, tree uint64, ots uint32) Address {
+	return xmssmt.WotsAddress(xmssmt.SubTreeAddress{
+		Layer: layer,
+		Tree:  tree,
+	}, ots)
+}
+
+// WOTS+ with the hash function, N and Winternitz parameter of the XMSS[MT]
+// parameters it was created with.
+type Scheme struct {
+	ctx *xmssmt.Context
+}
+
+// Returns the WOTS+ scheme with the hash function, N and Winternitz
+// parameter of the given XMSS[MT] parameters.
+func New(params xmssmt.Params) (*Scheme, error) {
+	ctx, err := xmssmt.NewContext(params)
+	if err != nil {
+		return nil, err
+	}
+	return &Scheme{ctx: ctx}, nil
+}
+
+// Returns the length of the seeds and of the messages that can be signed.
+func (s *Scheme) N() int {
+	return int(s.ctx.Params().N)
+}
+
+// Returns the size of a signature, which is also the size of a public key.
+func (s *Scheme) SignatureSize() int {
+	return s.ctx.WotsSignatureSize()
+}
+
+// Derives the public key of the key pair at addr.
+func (s *Scheme) KeyGen(pubSeed, skSeed []byte, addr Address) ([]byte, error) {
+	pk, err := s.ctx.WotsPublicKey(pubSeed, skSeed, addr)
+	if err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// Signs the N-byte message with the key pair at addr.
+//
+// NOTE Never sign two different messages with the same key pair.
+func (s *Scheme) Sign(msg, pubSeed, skSeed []byte, addr Address) (
+	[]byte, error) {
+	sig, err := s.ctx.WotsSign(msg, pubSeed, skSeed, addr)
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// Returns whether sig is a valid signature of the N-byte message for the
+// public key pk of the key pair at addr.
+func (s *Scheme) Verify(sig, msg, pubSeed, pk []byte, addr Address) bool {
+	pk2, err := s.ctx.WotsPublicKeyFromSignature(sig, msg, pubSeed, addr)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(pk, pk2) == 1
+}
@@ -0,0 +1,62 @@
+package wots
+
+import (
+	"testing"
+
+	xmssmt "github.com/bwesterb/go-xmssmt"
+)
+
+func TestSignVerify(t *testing.T) {
+	for _, params := range []xmssmt.Params{
+		{Func: xmssmt.SHA2, N: 32, FullHeight: 10, D: 1, WotsW: 16,
+			Prf: xmssmt.RFC},
+		{Func: xmssmt.SHAKE, N: 16, FullHeight: 10, D: 1, WotsW: 4,
+			Prf: xmssmt.RFC},
+		{Func: xmssmt.SHAKE256, N: 32, FullHeight: 10, D: 1, WotsW: 256,
+			Prf: xmssmt.RFC},
+	} {
+		s, err := New(params)
+		if err != nil {
+			t.Fatalf("New(%v): %v", params, err)
+		}
+		pubSeed := make([]byte, s.N())
+		skSeed := make([]byte, s.N())
+		msg := make([]byte, s.N())
+		for i := 0; i < s.N(); i++ {
+			pubSeed[i] = byte(i)
+			skSeed[i] = byte(2 * i)
+			msg[i] = byte(3 * i)
+		}
+		addr := NewAddress(1, 2, 3)
+
+		pk, err := s.KeyGen(pubSeed, skSeed, addr)
+		if err != nil {
+			t.Fatalf("KeyGen(): %v", err)
+		}
+		sig, err := s.Sign(msg, pubSeed, skSeed, addr)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if len(sig) != s.SignatureSize() || len(pk) != s.SignatureSize() {
+			t.Fatalf("%v: wrong signature or public key size", params)
+		}
+		if !s.Verify(sig, msg, pubSeed, pk, addr) {
+			t.Fatalf("%v: signature does not verify", params)
+		}
+
+		msg[0] ^= 1
+		if s.Verify(sig, msg, pubSeed, pk, addr) {
+			t.Fatalf("%v: signature verifies for other message", params)
+		}
+		msg[0] ^= 1
+		if s.Verify(sig, msg, pubSeed, pk, NewAddress(1, 2, 4)) {
+			t.Fatalf("%v: signature verifies for other address", params)
+		}
+		if s.Verify(sig[1:], msg, pubSeed, pk, addr) {
+			t.Fatalf("%v: truncated signature verifies", params)
+		}
+		if _, err = s.Sign(msg[1:], pubSeed, skSeed, addr); err == nil {
+			t.Fatalf("%v: Sign() accepted a short message", params)
+		}
+	}
+}
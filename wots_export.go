@@ -0,0 +1,91 @@
+package xmssmt
+
+// Contains the exported WOTS+ functions, see the wots package.
+
+// Address of a WOTS+ key pair as used to diversify the hashes.  Words 0–2
+// contain the layer and tree of the subtree and word 4 the index of the
+// key pair within it.  The other words are set by the WOTS+ functions.
+type HashAddress [8]uint32
+
+// Returns the address of the WOTS+ key pair with the given index in the
+// given subtree.
+func WotsAddress(sta SubTreeAddress, ots uint32) HashAddress {
+	addr := sta.address()
+	addr.setType(ADDR_TYPE_OTS)
+	addr.setOTS(ots)
+	return HashAddress(addr)
+}
+
+// Returns the address with only the fields relevant to WOTS+ kept.
+func (addr HashAddress) wots() address {
+	ret := address(addr)
+	ret.setType(ADDR_TYPE_OTS)
+	ret.setChain(0)
+	ret.setHash(0)
+	ret.setKeyAndMask(0)
+	return ret
+}
+
+// Returns the size of a WOTS+ signature, which is also the size of
+// a WOTS+ public key.
+func (ctx *Context) WotsSignatureSize() int {
+	return int(ctx.wotsSigBytes)
+}
+
+// Checks the lengths of the seeds passed to the Wots functions.
+func (ctx *Context) checkWotsSeeds(seeds ...[]byte) Error {
+	for _, seed := range seeds {
+		if len(seed) != int(ctx.p.N) {
+			return errorf("Seeds should have length %d", ctx.p.N)
+		}
+	}
+	return nil
+}
+
+// Derives the WOTS+ public key for the given address from the seeds.
+func (ctx *Context) WotsPublicKey(pubSeed, skSeed []byte, addr HashAddress) (
+	[]byte, Error) {
+	if err := ctx.checkWotsSeeds(pubSeed, skSeed); err != nil {
+		return nil, err
+	}
+	pad := ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	return ctx.wotsPkGen(pad, ctx.precomputeHashes(pubSeed, skSeed),
+		addr.wots()), nil
+}
+
+// Creates a WOTS+ signature of the N-byte msg with the key pair at the
+// given address.
+//
+// NOTE A WOTS+ key pair must be used to sign only a single message.
+func (ctx *Context) WotsSign(msg, pubSeed, skSeed []byte, addr HashAddress) (
+	[]byte, Error) {
+	if err := ctx.checkWotsSeeds(pubSeed, skSeed); err != nil {
+		return nil, err
+	}
+	if len(msg) != int(ctx.p.N) {
+		return nil, errorf("Message should have length %d", ctx.p.N)
+	}
+	pad := ctx.newScratchPad()
+	defer pad.zeroizeIfEnabled()
+	return ctx.wotsSign(pad, msg, pubSeed, skSeed, addr.wots()), nil
+}
+
+// Computes the WOTS+ public key from a signature on the N-byte msg.
+// The signature is valid if and only if it matches the public key.
+func (ctx *Context) WotsPublicKeyFromSignature(sig, msg, pubSeed []byte,
+	addr HashAddress) ([]byte, Error) {
+	if err := ctx.checkWotsSeeds(pubSeed); err != nil {
+		return nil, err
+	}
+	if len(msg) != int(ctx.p.N) {
+		return nil, errorf("Message should have length %d", ctx.p.N)
+	}
+	if len(sig) != int(ctx.wotsSigBytes) {
+		return nil, errorf("Signature should have length %d",
+			ctx.wotsSigBytes)
+	}
+	pad := ctx.newScratchPad()
+	return ctx.wotsPkFromSig(pad, sig, msg,
+		ctx.precomputeHashes(pubSeed, nil), addr.wots()), nil
+}
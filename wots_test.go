@@ -128,6 +128,40 @@ func TestWotsSignThenVerify(t *testing.T) {
 	testWotSignThenVerify(ctx, t)
 }
 
+func TestWotsSignConstantTime(t *testing.T) {
+	for _, params := range []Params{
+		{SHA2, 16, 10, 1, 16, RFC},
+		{SHA2, 32, 10, 1, 256, RFC},
+		{SHAKE, 16, 10, 1, 4, RFC},
+		{SHAKE, 32, 10, 1, 16, RFC},
+		{SHAKE256, 64, 10, 1, 16, RFC},
+	} {
+		for _, lanes := range []uint32{0, 1} {
+			ctx, err := NewContext(params)
+			if err != nil {
+				t.Fatalf("NewContext(): %v", err)
+			}
+			if lanes == 0 {
+				ctx.lanes = 0 // force the unvectorized implementation
+			}
+			pubSeed := make([]byte, ctx.p.N)
+			skSeed := make([]byte, ctx.p.N)
+			msg := make([]byte, ctx.p.N)
+			rand.Read(pubSeed)
+			rand.Read(skSeed)
+			rand.Read(msg)
+			sig1 := ctx.wotsSign(ctx.newScratchPad(), msg, pubSeed, skSeed,
+				address{})
+			ctx.SetConstantTime(true)
+			sig2 := ctx.wotsSign(ctx.newScratchPad(), msg, pubSeed, skSeed,
+				address{})
+			if !bytes.Equal(sig1, sig2) {
+				t.Fatalf("%s: constant-time signature differs", params)
+			}
+		}
+	}
+}
+
 func BenchmarkWotsSign_SHA256_16_w16(b *testing.B) {
 	benchmarkWotsSign(b, true, 16, 16)
 }
@@ -0,0 +1,109 @@
+package xmssmt
+
+// Contains the functions that wipe secret material from memory.
+//
+// NOTE The Go runtime might have copied secrets elsewhere, for instance
+// when growing a stack, so zeroization is a best effort.
+
+import (
+	"sync/atomic"
+
+	"github.com/bwesterb/go-xmssmt/internal/sha256x8"
+)
+
+// Non-zero if scratchpads should be zeroized after use.
+// See SetZeroizeScratchPads().
+var zeroizeScratchPads int32
+
+// If enabled, scratchpads are wiped after each signature and subtree,
+// such that no intermediate WOTS+ secret keys remain in memory.  This
+// slows down signing and subtree generation a bit.  Disabled by default.
+func SetZeroizeScratchPads(enabled bool) {
+	var val int32
+	if enabled {
+		val = 1
+	}
+	atomic.StoreInt32(&zeroizeScratchPads, val)
+}
+
+// Overwrites buf with zeroes.
+func zeroize(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+// Wipes the buffers and hash states of the scratchpad.
+func (pad scratchPad) zeroize() {
+	zeroize(pad.buf)
+	h := pad.hash
+	if h.h != nil {
+		h.h.Reset()
+	}
+	if h.shake != nil {
+		h.shake.Reset()
+	}
+	if h.shakeXN != nil {
+		h.shakeXN.Zero()
+	}
+	if h.sha2X8 != nil {
+		*h.sha2X8 = sha256x8.State{}
+		zeroize(h.sha2X8B)
+	}
+}
+
+// Wipes the scratchpad if enabled with SetZeroizeScratchPads().
+func (pad scratchPad) zeroizeIfEnabled() {
+	if atomic.LoadInt32(&zeroizeScratchPads) != 0 {
+		pad.zeroize()
+	}
+}
+
+// Wipes the secret seeds of the private key from memory.  The public
+// seed and root are kept, so the public key can still be used.
+//
+// NOTE Assumes a lock on sk.mux.
+func (sk *PrivateKey) zeroize() {
+	zeroize(sk.skSeed)
+	zeroize(sk.skPrf)
+	zeroize(sk.checksumKey)
+	if sk.ph.zeroizeSkState != nil {
+		sk.ph.zeroizeSkState()
+	}
+	sk.ph.prfAddrSkSeedInto = nil
+	sk.ph.prfKeyGenInto = nil
+	sk.zeroized = true
+}
+
+// Destroys the private key: overwrites the private key stored in the
+// container with zeroes, closes the container and wipes the secret seeds
+// from memory.  Use this to destroy a key in an emergency, for instance
+// when it is feared to be compromised.
+//
+// The private key is lost irrecoverably and cannot be used to sign
+// afterwards.  The public key remains usable to verify signatures.
+func (sk *PrivateKey) Zeroize() Error {
+	// Refuse to sign from now on and wait for the background goroutines,
+	// which might write into the cache and use the seeds, before we
+	// reset and close the container.  They need sk.mux, so we can't hold it.
+	sk.mux.Lock()
+	sk.zeroized = true
+	sk.cond.Broadcast()
+	sk.mux.Unlock()
+	sk.wg.Wait()
+
+	sk.mux.Lock()
+	defer sk.mux.Unlock()
+
+	// Overwrite the stored key.  Reset also drops the cached subtrees.
+	var err Error
+	if params := sk.ctr.Initialized(); params != nil {
+		err = sk.ctr.Reset(make([]byte, params.PrivateKeySize()), *params)
+	}
+	if err2 := sk.ctr.Close(); err2 != nil && err == nil {
+		err = err2
+	}
+
+	sk.zeroize()
+	return err
+}
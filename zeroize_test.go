@@ -0,0 +1,86 @@
+package xmssmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestZeroize(t *testing.T) {
+	SetLogger(t)
+	defer SetLogger(nil)
+
+	dir, err := ioutil.TempDir("", "go-xmssmt-tests")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	SetZeroizeScratchPads(true)
+	defer SetZeroizeScratchPads(false)
+
+	ctx, err := NewContext(Params{SHA2, 32, 8, 2, 16, RFC})
+	if err != nil {
+		t.Fatalf("NewContext(): %v", err)
+	}
+	defer ctx.Close()
+	sk, pk, err := ctx.GenerateKeyPair(dir + "/key")
+	if err != nil {
+		t.Fatalf("GenerateKeyPair(): %v", err)
+	}
+
+	msg := []byte("test message")
+	for i := 0; i < 5; i++ {
+		sig, err := sk.Sign(msg)
+		if err != nil {
+			t.Fatalf("Sign(): %v", err)
+		}
+		if ok, err := pk.Verify(sig, msg); !ok {
+			t.Fatalf("Verify(): %v", err)
+		}
+	}
+
+	// Close() should wipe the seeds.
+	skSeed := sk.skSeed
+	skPrf := sk.skPrf
+	if err = sk.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	zero := make([]byte, 32)
+	if !bytes.Equal(skSeed, zero) || !bytes.Equal(skPrf, zero) {
+		t.Fatalf("Close() did not wipe the seeds")
+	}
+
+	// Zeroize() should destroy the stored key.
+	sk, _, _, err = LoadPrivateKey(dir + "/key")
+	if err != nil {
+		t.Fatalf("LoadPrivateKey(): %v", err)
+	}
+	sig, err := sk.Sign(msg)
+	if err != nil {
+		t.Fatalf("Sign(): %v", err)
+	}
+	if err = sk.Zeroize(); err != nil {
+		t.Fatalf("Zeroize(): %v", err)
+	}
+	if _, err = sk.Sign(msg); err == nil {
+		t.Fatalf("Sign() should fail after Zeroize()")
+	}
+	if ok, err := pk.Verify(sig, msg); !ok {
+		t.Fatalf("Verify(): %v", err)
+	}
+
+	ctr, err := OpenFSPrivateKeyContainer(dir + "/key")
+	if err != nil {
+		t.Fatalf("OpenFSPrivateKeyContainer(): %v", err)
+	}
+	defer ctr.Close()
+	stored, err := ctr.GetPrivateKey()
+	if err != nil {
+		t.Fatalf("GetPrivateKey(): %v", err)
+	}
+	if !bytes.Equal(stored, make([]byte, len(stored))) {
+		t.Fatalf("Zeroize() did not overwrite the stored key")
+	}
+}